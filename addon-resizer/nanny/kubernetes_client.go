@@ -29,19 +29,24 @@ import (
 	watch "k8s.io/kubernetes/pkg/watch"
 )
 
+// nodeCounter counts the nodes of the cluster, which it watches.
+type nodeCounter struct {
+	nodeStore cache.Store
+	reflector *cache.Reflector
+}
+
 type kubernetesClient struct {
+	*nodeCounter
 	namespace  string
 	deployment string
 	pod        string
 	container  string
 	clientset  *client.Clientset
-	nodeStore  cache.Store
-	reflector  *cache.Reflector
 }
 
-func (k *kubernetesClient) CountNodes() (uint64, error) {
+func (n *nodeCounter) CountNodes() (uint64, error) {
 	err := wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
-		if k.reflector.LastSyncResourceVersion() == "" {
+		if n.reflector.LastSyncResourceVersion() == "" {
 			return false, nil
 		}
 		return true, nil
@@ -49,10 +54,13 @@ func (k *kubernetesClient) CountNodes() (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return uint64(len(k.nodeStore.List())), nil
+	return uint64(len(n.nodeStore.List())), nil
 }
 
 func (k *kubernetesClient) ContainerResources() (*apiv1.ResourceRequirements, error) {
+	if k.pod == "" {
+		return k.deploymentContainerResources()
+	}
 	pod, err := k.clientset.CoreClient.Pods(k.namespace).Get(k.pod)
 
 	if err != nil {
@@ -66,6 +74,21 @@ func (k *kubernetesClient) ContainerResources() (*apiv1.ResourceRequirements, er
 	return nil, fmt.Errorf("container %s was not found in deployment %s in namespace %s.", k.container, k.deployment, k.namespace)
 }
 
+// deploymentContainerResources returns the resources of the container in the
+// pod template of the deployment, for deployments the nanny doesn't run in.
+func (k *kubernetesClient) deploymentContainerResources() (*apiv1.ResourceRequirements, error) {
+	dep, err := k.clientset.Extensions().Deployments(k.namespace).Get(k.deployment)
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range dep.Spec.Template.Spec.Containers {
+		if container.Name == k.container {
+			return &container.Resources, nil
+		}
+	}
+	return nil, fmt.Errorf("container %s was not found in deployment %s in namespace %s.", k.container, k.deployment, k.namespace)
+}
+
 func (k *kubernetesClient) UpdateDeployment(resources *apiv1.ResourceRequirements) error {
 	// First, get the Deployment.
 	dep, err := k.clientset.Extensions().Deployments(k.namespace).Get(k.deployment)
@@ -88,13 +111,37 @@ func (k *kubernetesClient) UpdateDeployment(resources *apiv1.ResourceRequirement
 
 // NewKubernetesClient gives a KubernetesClient with the given dependencies.
 func NewKubernetesClient(namespace, deployment, pod, container string, clientset *client.Clientset) KubernetesClient {
-	result := &kubernetesClient{
-		namespace:  namespace,
-		deployment: deployment,
-		pod:        pod,
-		container:  container,
-		clientset:  clientset,
-		nodeStore:  cache.NewStore(cache.MetaNamespaceKeyFunc),
+	return &kubernetesClient{
+		nodeCounter: newNodeCounter(clientset),
+		namespace:   namespace,
+		deployment:  deployment,
+		pod:         pod,
+		container:   container,
+		clientset:   clientset,
+	}
+}
+
+// NewKubernetesClients gives a KubernetesClient for each of the targets, all
+// of them sharing a single watch of the nodes.
+func NewKubernetesClients(targets []Target, clientset *client.Clientset) []KubernetesClient {
+	nodes := newNodeCounter(clientset)
+	var result []KubernetesClient
+	for _, target := range targets {
+		result = append(result, &kubernetesClient{
+			nodeCounter: nodes,
+			namespace:   target.Namespace,
+			deployment:  target.Deployment,
+			pod:         target.Pod,
+			container:   target.Container,
+			clientset:   clientset,
+		})
+	}
+	return result
+}
+
+func newNodeCounter(clientset *client.Clientset) *nodeCounter {
+	result := &nodeCounter{
+		nodeStore: cache.NewStore(cache.MetaNamespaceKeyFunc),
 	}
 	// Start propagating contents of the nodeStore.
 	nodeListWatch := &cache.ListWatch{
@@ -48,6 +48,7 @@ var (
 	deployment    = flag.String("deployment", "", "The name of the deployment being monitored. This is required.")
 	podName       = flag.String("pod", os.Getenv("MY_POD_NAME"), "The name of the pod to watch. This defaults to the nanny's own pod.")
 	containerName = flag.String("container", "pod-nanny", "The name of the container to watch. This defaults to the nanny itself.")
+	targetsConfig = flag.String("targets-config", "", "The path of a file, e.g. mounted from a ConfigMap, listing other deployments to resize, each with its own scaling parameters.")
 	// Flags to control runtime behavior.
 	pollPeriodMillis = flag.Int("poll-period", 10000, "The time, in milliseconds, to poll the dependent container.")
 )
@@ -64,8 +65,8 @@ func main() {
 	flag.Parse()
 
 	// Perform further validation of flags.
-	if *deployment == "" {
-		log.Fatal("Must specify a deployment.")
+	if *deployment == "" && *targetsConfig == "" {
+		log.Fatal("Must specify a deployment or a targets config.")
 	}
 
 	checkPercentageFlagBounds("recommendation-offset", *recommendationOffset)
@@ -73,8 +74,6 @@ func main() {
 
 	pollPeriod := time.Duration(int64(*pollPeriodMillis) * int64(time.Millisecond))
 	log.Infof("Poll period: %+v", pollPeriod)
-	log.Infof("Watching namespace: %s, pod: %s, container: %s.", *podNamespace, *podName, *containerName)
-	log.Infof("cpu: %s, extra_cpu: %s, memory: %s, extra_memory: %s, storage: %s, extra_storage: %s", *baseCPU, *cpuPerNode, *baseMemory, *memoryPerNode, *baseStorage, *storagePerNode)
 	log.Infof("Accepted range +/-%d%%", *acceptanceOffset)
 	log.Infof("Recommended range +/-%d%%", *recommendationOffset)
 
@@ -90,6 +89,36 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	var wards []nanny.Ward
+	if *deployment != "" {
+		wards = append(wards, deploymentWard(clientset))
+	}
+	if *targetsConfig != "" {
+		targets, err := nanny.LoadTargets(*targetsConfig, *podNamespace)
+		if err != nil {
+			log.Fatal(err)
+		}
+		clients := nanny.NewKubernetesClients(targets, clientset)
+		for i, target := range targets {
+			estimator := target.Estimator(int64(*acceptanceOffset), int64(*recommendationOffset))
+			log.Infof("Watching namespace: %s, deployment: %s, container: %s, resources: %+v", target.Namespace, target.Deployment, target.Container, estimator.Resources)
+			wards = append(wards, nanny.Ward{
+				Name:      target.Namespace + "/" + target.Deployment,
+				Client:    clients[i],
+				Estimator: estimator,
+			})
+		}
+	}
+
+	// Begin nannying.
+	nanny.PollWards(wards, pollPeriod)
+}
+
+// deploymentWard returns the ward set by the flags, usually the nanny itself.
+func deploymentWard(clientset *client.Clientset) nanny.Ward {
+	log.Infof("Watching namespace: %s, pod: %s, container: %s.", *podNamespace, *podName, *containerName)
+	log.Infof("cpu: %s, extra_cpu: %s, memory: %s, extra_memory: %s, storage: %s, extra_storage: %s", *baseCPU, *cpuPerNode, *baseMemory, *memoryPerNode, *baseStorage, *storagePerNode)
 	k8s := nanny.NewKubernetesClient(*podNamespace, *deployment, *podName, *containerName, clientset)
 
 	var resources []nanny.Resource
@@ -121,13 +150,13 @@ func main() {
 
 	log.Infof("Resources: %+v", resources)
 
-	// Begin nannying.
-	nanny.PollAPIServer(
-		k8s,
-		nanny.Estimator{
+	return nanny.Ward{
+		Name:   *podNamespace + "/" + *deployment,
+		Client: k8s,
+		Estimator: nanny.Estimator{
 			AcceptanceOffset:     int64(*acceptanceOffset),
 			RecommendationOffset: int64(*recommendationOffset),
 			Resources:            resources,
 		},
-		pollPeriod)
+	}
 }
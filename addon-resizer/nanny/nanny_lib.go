@@ -80,45 +80,72 @@ type ResourceEstimator interface {
 	scaleWithNodes(numNodes uint64) *EstimatorResult
 }
 
+// Ward is a container of a deployment resized by the nanny.
+type Ward struct {
+	// Name identifies the ward in the logs.
+	Name      string
+	Client    KubernetesClient
+	Estimator ResourceEstimator
+}
+
 // PollAPIServer periodically counts the number of nodes, estimates the expected
 // ResourceRequirements, compares them to the actual ResourceRequirements, and
 // updates the deployment with the expected ResourceRequirements if necessary.
 func PollAPIServer(k8s KubernetesClient, est ResourceEstimator, pollPeriod time.Duration) {
+	PollWards([]Ward{{Client: k8s, Estimator: est}}, pollPeriod)
+}
+
+// PollWards is PollAPIServer for several wards, each with its own estimator,
+// which are checked in turn every poll period.
+func PollWards(wards []Ward, pollPeriod time.Duration) {
 	for i := 0; true; i++ {
 		if i != 0 {
 			// Sleep for the poll period.
 			time.Sleep(pollPeriod)
 		}
 
-		// Query the apiserver for the number of nodes.
-		num, err := k8s.CountNodes()
-		if err != nil {
-			log.Error(err)
-			continue
+		for _, ward := range wards {
+			checkWard(ward)
 		}
-		log.V(4).Infof("The number of nodes is %d", num)
+	}
+}
 
-		// Query the apiserver for this pod's information.
-		resources, err := k8s.ContainerResources()
-		if err != nil {
-			log.Errorf("Error while querying apiserver for resources: %v", err)
-			continue
-		}
+// checkWard updates the deployment of the ward if the resources of its
+// container aren't within the expected limits.
+func checkWard(ward Ward) {
+	k8s := ward.Client
+	prefix := ""
+	if ward.Name != "" {
+		prefix = ward.Name + ": "
+	}
 
-		// Get the expected resource limits.
-		estimation := est.scaleWithNodes(num)
+	// Query the apiserver for the number of nodes.
+	num, err := k8s.CountNodes()
+	if err != nil {
+		log.Errorf("%s%v", prefix, err)
+		return
+	}
+	log.V(4).Infof("%sThe number of nodes is %d", prefix, num)
 
-		// If there's a difference, go ahead and set the new values.
-		overwrite := shouldOverwriteResources(estimation, resources.Limits, resources.Requests)
-		if overwrite == nil {
-			log.V(4).Infof("Resources are within the expected limits. Actual: %+v, accepted range: %+v", *resources, estimation.AcceptableRange)
-			continue
-		}
+	// Query the apiserver for this pod's information.
+	resources, err := k8s.ContainerResources()
+	if err != nil {
+		log.Errorf("%sError while querying apiserver for resources: %v", prefix, err)
+		return
+	}
 
-		log.Infof("Resources are not within the expected limits, updating the deployment. Actual: %+v New: %+v", *resources, *overwrite)
-		if err := k8s.UpdateDeployment(overwrite); err != nil {
-			log.Error(err)
-			continue
-		}
+	// Get the expected resource limits.
+	estimation := ward.Estimator.scaleWithNodes(num)
+
+	// If there's a difference, go ahead and set the new values.
+	overwrite := shouldOverwriteResources(estimation, resources.Limits, resources.Requests)
+	if overwrite == nil {
+		log.V(4).Infof("%sResources are within the expected limits. Actual: %+v, accepted range: %+v", prefix, *resources, estimation.AcceptableRange)
+		return
+	}
+
+	log.Infof("%sResources are not within the expected limits, updating the deployment. Actual: %+v New: %+v", prefix, *resources, *overwrite)
+	if err := k8s.UpdateDeployment(overwrite); err != nil {
+		log.Errorf("%s%v", prefix, err)
 	}
 }
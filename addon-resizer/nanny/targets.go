@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nanny
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/kubernetes/pkg/api/resource"
+	api "k8s.io/kubernetes/pkg/api/v1"
+)
+
+// Target is a container of a deployment resized by the nanny, with its own
+// scaling parameters. Only the resources with a base requirement are resized.
+type Target struct {
+	// Namespace of the deployment. Defaults to the namespace of the nanny.
+	Namespace string `json:"namespace,omitempty"`
+	// Deployment is the name of the deployment.
+	Deployment string `json:"deployment"`
+	// Container is the name of the resized container of the deployment.
+	Container string `json:"container"`
+	// Pod is the pod whose container resources are compared to the
+	// estimation. The resources of the deployment are used if empty.
+	Pod string `json:"-"`
+
+	CPU          *resource.Quantity `json:"cpu,omitempty"`
+	ExtraCPU     *resource.Quantity `json:"extraCPU,omitempty"`
+	Memory       *resource.Quantity `json:"memory,omitempty"`
+	ExtraMemory  *resource.Quantity `json:"extraMemory,omitempty"`
+	Storage      *resource.Quantity `json:"storage,omitempty"`
+	ExtraStorage *resource.Quantity `json:"extraStorage,omitempty"`
+
+	// AcceptanceOffset and RecommendationOffset default to the ones of
+	// the nanny.
+	AcceptanceOffset     *int64 `json:"acceptanceOffset,omitempty"`
+	RecommendationOffset *int64 `json:"recommendationOffset,omitempty"`
+}
+
+// TargetsConfig is the configuration of the deployments resized by the nanny,
+// usually mounted from a ConfigMap.
+type TargetsConfig struct {
+	Targets []Target `json:"targets"`
+}
+
+// LoadTargets reads the targets of the YAML or JSON TargetsConfig in the file
+// at path. The targets without a namespace are given defaultNamespace.
+func LoadTargets(path, defaultNamespace string) ([]Target, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config TargetsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("cannot parse targets config %s: %v", path, err)
+	}
+	for i := range config.Targets {
+		target := &config.Targets[i]
+		if target.Namespace == "" {
+			target.Namespace = defaultNamespace
+		}
+		if err := target.validate(); err != nil {
+			return nil, fmt.Errorf("invalid target %d in %s: %v", i, path, err)
+		}
+	}
+	return config.Targets, nil
+}
+
+func (t *Target) validate() error {
+	if t.Deployment == "" {
+		return fmt.Errorf("deployment is required")
+	}
+	if t.Container == "" {
+		return fmt.Errorf("container of deployment %s is required", t.Deployment)
+	}
+	if len(t.resources()) == 0 {
+		return fmt.Errorf("deployment %s needs a base cpu, memory or storage", t.Deployment)
+	}
+	for _, q := range t.quantities() {
+		if q.base == nil && q.extra != nil {
+			return fmt.Errorf("extra %s of deployment %s needs a base %s", q.name, t.Deployment, q.name)
+		}
+	}
+	for name, offset := range map[string]*int64{
+		"acceptanceOffset":     t.AcceptanceOffset,
+		"recommendationOffset": t.RecommendationOffset,
+	} {
+		if offset != nil && (*offset < 0 || *offset > 100) {
+			return fmt.Errorf("%s of deployment %s must be between 0 and 100 inclusively, was %d", name, t.Deployment, *offset)
+		}
+	}
+	return nil
+}
+
+// targetQuantities are the base and extra per node quantities of a resource
+// of a target.
+type targetQuantities struct {
+	name        api.ResourceName
+	base, extra *resource.Quantity
+}
+
+func (t *Target) quantities() []targetQuantities {
+	return []targetQuantities{
+		{"cpu", t.CPU, t.ExtraCPU},
+		{"memory", t.Memory, t.ExtraMemory},
+		{"storage", t.Storage, t.ExtraStorage},
+	}
+}
+
+func (t *Target) resources() []Resource {
+	var resources []Resource
+	for _, q := range t.quantities() {
+		if q.base == nil {
+			continue
+		}
+		r := Resource{Base: *q.base, ExtraPerNode: resource.MustParse("0"), Name: q.name}
+		if q.extra != nil {
+			r.ExtraPerNode = *q.extra
+		}
+		resources = append(resources, r)
+	}
+	return resources
+}
+
+// Estimator returns the Estimator of the resources of the target, with the
+// offsets the target doesn't set.
+func (t *Target) Estimator(acceptanceOffset, recommendationOffset int64) Estimator {
+	if t.AcceptanceOffset != nil {
+		acceptanceOffset = *t.AcceptanceOffset
+	}
+	if t.RecommendationOffset != nil {
+		recommendationOffset = *t.RecommendationOffset
+	}
+	return Estimator{
+		AcceptanceOffset:     acceptanceOffset,
+		RecommendationOffset: recommendationOffset,
+		Resources:            t.resources(),
+	}
+}
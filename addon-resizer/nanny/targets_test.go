@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nanny
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func writeTargetsConfig(t *testing.T, config string) string {
+	file, err := ioutil.TempFile("", "targets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(config); err != nil {
+		t.Fatal(err)
+	}
+	return file.Name()
+}
+
+func TestLoadTargets(t *testing.T) {
+	path := writeTargetsConfig(t, `
+targets:
+- deployment: heapster
+  container: heapster
+  cpu: 80m
+  extraCPU: 0.5m
+  memory: 140Mi
+  extraMemory: 4Mi
+- namespace: monitoring
+  deployment: kube-state-metrics
+  container: kube-state-metrics
+  memory: 100Mi
+  acceptanceOffset: 30
+`)
+	defer os.Remove(path)
+
+	targets, err := LoadTargets(path, "kube-system")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].Namespace != "kube-system" || targets[1].Namespace != "monitoring" {
+		t.Errorf("unexpected namespaces %s and %s", targets[0].Namespace, targets[1].Namespace)
+	}
+
+	heapster := targets[0].Estimator(20, 10)
+	if len(heapster.Resources) != 2 || heapster.Resources[0].Name != "cpu" || heapster.Resources[1].Name != "memory" {
+		t.Errorf("unexpected resources %+v", heapster.Resources)
+	}
+	if heapster.Resources[1].ExtraPerNode.String() != "4Mi" {
+		t.Errorf("expected extra memory 4Mi, got %s", heapster.Resources[1].ExtraPerNode.String())
+	}
+	if heapster.AcceptanceOffset != 20 || heapster.RecommendationOffset != 10 {
+		t.Errorf("expected the default offsets, got %d and %d", heapster.AcceptanceOffset, heapster.RecommendationOffset)
+	}
+
+	ksm := targets[1].Estimator(20, 10)
+	if len(ksm.Resources) != 1 || ksm.Resources[0].Name != "memory" {
+		t.Errorf("unexpected resources %+v", ksm.Resources)
+	}
+	if ksm.AcceptanceOffset != 30 || ksm.RecommendationOffset != 10 {
+		t.Errorf("expected offsets 30 and 10, got %d and %d", ksm.AcceptanceOffset, ksm.RecommendationOffset)
+	}
+}
+
+func TestLoadInvalidTargets(t *testing.T) {
+	for _, config := range []string{
+		"targets:\n- container: heapster\n  cpu: 80m\n",
+		"targets:\n- deployment: heapster\n  cpu: 80m\n",
+		"targets:\n- deployment: heapster\n  container: heapster\n",
+		"targets:\n- deployment: heapster\n  container: heapster\n  cpu: 80m\n  extraMemory: 4Mi\n",
+		"targets:\n- deployment: heapster\n  container: heapster\n  cpu: 80m\n  recommendationOffset: 101\n",
+	} {
+		path := writeTargetsConfig(t, config)
+		if _, err := LoadTargets(path, "kube-system"); err == nil {
+			t.Errorf("expected an error loading %q", config)
+		}
+		os.Remove(path)
+	}
+}
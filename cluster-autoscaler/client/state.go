@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	core_api "k8s.io/autoscaler/cluster-autoscaler/core/api"
+)
+
+// StatePath is the path of the runtime state of the autoscaler on its metrics address.
+const StatePath = "/state"
+
+// StateClient exports and imports the runtime state of a running autoscaler.
+type StateClient struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// NewStateClient creates a StateClient for the autoscaler whose metrics address is served at url,
+// e.g. http://localhost:8085, sending token as bearer token unless it is empty, using httpClient,
+// or http.DefaultClient if it is nil.
+func NewStateClient(url, token string, httpClient *http.Client) *StateClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &StateClient{
+		url:        strings.TrimSuffix(url, "/") + StatePath,
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// ExportState returns the runtime state of the autoscaler. It fails while the autoscaler isn't
+// running, e.g. while it isn't the leader.
+func (c *StateClient) ExportState() (*core_api.AutoscalerState, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	state := &core_api.AutoscalerState{}
+	if err := json.NewDecoder(resp.Body).Decode(state); err != nil {
+		return nil, fmt.Errorf("invalid state: %v", err)
+	}
+	return state, nil
+}
+
+// ImportState sends the state to the autoscaler, which imports it once it is running. It fails
+// unless the autoscaler enables state import and the token of the client is the one of the autoscaler.
+func (c *StateClient) ImportState(state *core_api.AutoscalerState) error {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (c *StateClient) do(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.httpClient.Do(req)
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	core_api "k8s.io/autoscaler/cluster-autoscaler/core/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateClient(t *testing.T) {
+	lastScaleUpTime := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	var imported *core_api.AutoscalerState
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, StatePath, r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(&core_api.AutoscalerState{LastScaleUpTime: lastScaleUpTime})
+		case http.MethodPost:
+			imported = &core_api.AutoscalerState{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(imported))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := NewStateClient(server.URL+"/", "token", nil)
+	state, err := client.ExportState()
+	assert.NoError(t, err)
+	assert.True(t, lastScaleUpTime.Equal(state.LastScaleUpTime))
+
+	state.UnneededNodes = map[string]time.Time{"n1": lastScaleUpTime}
+	assert.NoError(t, client.ImportState(state))
+	if assert.NotNil(t, imported) {
+		assert.True(t, lastScaleUpTime.Equal(imported.UnneededNodes["n1"]))
+	}
+}
+
+func TestStateClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "autoscaler is not running", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewStateClient(server.URL, "", nil)
+	_, err := client.ExportState()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "autoscaler is not running")
+	}
+	assert.Error(t, client.ImportState(&core_api.AutoscalerState{}))
+}
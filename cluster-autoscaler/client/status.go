@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client gives typed access to the status and the runtime state a running cluster
+// autoscaler publishes, for tools and tests to read them without parsing them themselves.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	kube_client "k8s.io/client-go/kubernetes"
+)
+
+// Status is the status the autoscaler writes to its status ConfigMap.
+type Status struct {
+	// LastUpdated is when the autoscaler last wrote the status.
+	LastUpdated time.Time
+	// Message is the human-readable status.
+	Message string
+	// Status is the status of the cluster and of its node groups. It is nil while the autoscaler
+	// doesn't know it, e.g. while it is initializing or when the cluster is empty.
+	Status *api.ClusterAutoscalerStatus
+}
+
+// GetStatus reads the status of the autoscaler from its status ConfigMap in namespace.
+func GetStatus(kubeClient kube_client.Interface, namespace string) (*Status, error) {
+	configMap, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(utils.StatusConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatusConfigMap(configMap)
+}
+
+// ParseStatusConfigMap returns the status held by the status ConfigMap.
+func ParseStatusConfigMap(configMap *apiv1.ConfigMap) (*Status, error) {
+	message, found := configMap.Data[utils.StatusConfigMapStatusKey]
+	if !found {
+		return nil, fmt.Errorf("ConfigMap %s/%s has no %s key", configMap.Namespace, configMap.Name, utils.StatusConfigMapStatusKey)
+	}
+	status := &Status{Message: message}
+
+	if lastUpdated, found := configMap.Annotations[utils.ConfigMapLastUpdatedKey]; found {
+		t, err := time.Parse(utils.ConfigMapLastUpdateFormat, lastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %v", utils.ConfigMapLastUpdatedKey, err)
+		}
+		status.LastUpdated = t
+	}
+
+	if structured, found := configMap.Data[utils.StatusConfigMapStructuredStatusKey]; found {
+		status.Status = &api.ClusterAutoscalerStatus{}
+		if err := json.Unmarshal([]byte(structured), status.Status); err != nil {
+			return nil, fmt.Errorf("invalid %s key: %v", utils.StatusConfigMapStructuredStatusKey, err)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStatus(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	_, err := GetStatus(kubeClient, "kube-system")
+	assert.Error(t, err)
+
+	status := &api.ClusterAutoscalerStatus{
+		ClusterwideConditions: []api.ClusterAutoscalerCondition{{
+			Type:   api.ClusterAutoscalerHealth,
+			Status: api.ClusterAutoscalerHealthy,
+		}},
+		NodeGroupStatuses: []api.NodeGroupStatus{{
+			ProviderID: "ng1",
+			Conditions: []api.ClusterAutoscalerCondition{{
+				Type:   api.ClusterAutoscalerScaleUp,
+				Status: api.ClusterAutoscalerBackoff,
+			}},
+		}},
+	}
+	_, err = utils.WriteStructuredStatusConfigMap(kubeClient, "kube-system", status, nil)
+	assert.NoError(t, err)
+
+	result, err := GetStatus(kubeClient, "kube-system")
+	assert.NoError(t, err)
+	assert.Contains(t, result.Message, "Name:        ng1")
+	assert.False(t, result.LastUpdated.IsZero())
+	assert.Equal(t, status, result.Status)
+
+	// The status is unknown while initializing.
+	_, err = utils.WriteStatusConfigMap(kubeClient, "kube-system", "Initializing", nil)
+	assert.NoError(t, err)
+	result, err = GetStatus(kubeClient, "kube-system")
+	assert.NoError(t, err)
+	assert.Contains(t, result.Message, "Initializing")
+	assert.Nil(t, result.Status)
+}
+
+func TestParseStatusConfigMapErrors(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		data        map[string]string
+	}{{
+		description: "missing status",
+		data:        map[string]string{},
+	}, {
+		description: "invalid last update",
+		annotations: map[string]string{utils.ConfigMapLastUpdatedKey: "yesterday"},
+		data:        map[string]string{utils.StatusConfigMapStatusKey: "ok"},
+	}, {
+		description: "invalid structured status",
+		data: map[string]string{
+			utils.StatusConfigMapStatusKey:           "ok",
+			utils.StatusConfigMapStructuredStatusKey: "not json",
+		},
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := ParseStatusConfigMap(&apiv1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.StatusConfigMapName,
+					Namespace:   "kube-system",
+					Annotations: tc.annotations,
+				},
+				Data: tc.data,
+			})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -19,6 +19,7 @@ package aws
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 
@@ -42,6 +43,10 @@ type asgCache struct {
 
 	asgAutoDiscoverySpecs []cloudprovider.ASGAutoDiscoveryConfig
 	explicitlyConfigured  map[AwsRef]bool
+
+	// availableIPs holds the free IP addresses of the subnets of the ASGs, by subnet ID.
+	// It is reset when the cache is regenerated and filled by the first lookup after that.
+	availableIPs map[string]int
 }
 
 type asg struct {
@@ -50,8 +55,12 @@ type asg struct {
 	minSize int
 	maxSize int
 	curSize int
+	// awsMaxSize is the MaxSize of the ASG as reported by AWS. It can be
+	// lower than maxSize for explicitly configured groups.
+	awsMaxSize int
 
 	AvailabilityZones       []string
+	Subnets                 []string
 	LaunchTemplateName      string
 	LaunchTemplateVersion   string
 	LaunchConfigurationName string
@@ -109,10 +118,12 @@ func (m *asgCache) register(asg *asg) *asg {
 			}
 
 			existing.curSize = asg.curSize
+			existing.awsMaxSize = asg.awsMaxSize
 
 			// Those information are mainly required to create templates when scaling
 			// from zero
 			existing.AvailabilityZones = asg.AvailabilityZones
+			existing.Subnets = asg.Subnets
 			existing.LaunchConfigurationName = asg.LaunchConfigurationName
 			existing.LaunchTemplateName = asg.LaunchTemplateName
 			existing.LaunchTemplateVersion = asg.LaunchTemplateVersion
@@ -352,9 +363,50 @@ func (m *asgCache) regenerate() error {
 
 	m.asgToInstances = newAsgToInstancesCache
 	m.instanceToAsg = newInstanceToAsgCache
+	m.availableIPs = nil
 	return nil
 }
 
+// AvailableIPs returns the number of free IP addresses in the subnets of the ASG. The subnets of
+// all the registered ASGs are described at once by describeSubnets on the first lookup after the
+// cache was regenerated.
+func (m *asgCache) AvailableIPs(asg *asg, describeSubnets func(subnetIDs []string) (map[string]int, error)) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.availableIPs == nil {
+		subnets := make(map[string]bool)
+		for _, subnet := range asg.Subnets {
+			subnets[subnet] = true
+		}
+		for _, registered := range m.registeredAsgs {
+			for _, subnet := range registered.Subnets {
+				subnets[subnet] = true
+			}
+		}
+		subnetIDs := make([]string, 0, len(subnets))
+		for subnet := range subnets {
+			subnetIDs = append(subnetIDs, subnet)
+		}
+		sort.Strings(subnetIDs)
+		availableIPs, err := describeSubnets(subnetIDs)
+		if err != nil {
+			return 0, err
+		}
+		m.availableIPs = availableIPs
+	}
+
+	available := 0
+	for _, subnet := range asg.Subnets {
+		n, found := m.availableIPs[subnet]
+		if !found {
+			return 0, fmt.Errorf("subnet %s of ASG %s not found", subnet, asg.Name)
+		}
+		available += n
+	}
+	return available, nil
+}
+
 func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 	spec := dynamic.NodeGroupSpec{
 		Name:               aws.StringValue(g.AutoScalingGroupName),
@@ -375,7 +427,9 @@ func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 		maxSize: spec.MaxSize,
 
 		curSize:                 int(aws.Int64Value(g.DesiredCapacity)),
+		awsMaxSize:              spec.MaxSize,
 		AvailabilityZones:       aws.StringValueSlice(g.AvailabilityZones),
+		Subnets:                 buildSubnets(g),
 		LaunchConfigurationName: aws.StringValue(g.LaunchConfigurationName),
 		LaunchTemplateName:      launchTemplateName,
 		LaunchTemplateVersion:   launchTemplateVersion,
@@ -385,6 +439,16 @@ func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 	return asg, nil
 }
 
+func buildSubnets(g *autoscaling.Group) []string {
+	subnets := []string{}
+	for _, subnet := range strings.Split(aws.StringValue(g.VPCZoneIdentifier), ",") {
+		if subnet = strings.TrimSpace(subnet); subnet != "" {
+			subnets = append(subnets, subnet)
+		}
+	}
+	return subnets
+}
+
 func (m *asgCache) buildLaunchTemplateParams(g *autoscaling.Group) (string, string) {
 	if g.LaunchTemplate != nil {
 		return aws.StringValue(g.LaunchTemplate.LaunchTemplateName), aws.StringValue(g.LaunchTemplate.Version)
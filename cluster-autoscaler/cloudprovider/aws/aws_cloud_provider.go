@@ -99,6 +99,15 @@ func (aws *awsCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.N
 	}, nil
 }
 
+// HasInstance returns false if the EC2 instance of the node doesn't exist anymore.
+func (aws *awsCloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	ref, err := AwsRefFromProviderId(node.Spec.ProviderID)
+	if err != nil {
+		return true, cloudprovider.ErrNotImplemented
+	}
+	return aws.awsManager.InstanceExists(*ref)
+}
+
 // Pricing returns pricing model for this cloud provider or error if not available.
 func (aws *awsCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
 	return nil, cloudprovider.ErrNotImplemented
@@ -159,11 +168,29 @@ type AwsNodeGroup struct {
 	asg        *asg
 }
 
+var _ cloudprovider.ProviderLimitedNodeGroup = (*AwsNodeGroup)(nil)
+var _ cloudprovider.IPCapacityNodeGroup = (*AwsNodeGroup)(nil)
+
 // MaxSize returns maximum size of the node group.
 func (ng *AwsNodeGroup) MaxSize() int {
 	return ng.asg.maxSize
 }
 
+// ProviderMaxSize returns the MaxSize of the ASG as configured in AWS. AWS
+// rejects desired capacities above it regardless of the configured max size.
+func (ng *AwsNodeGroup) ProviderMaxSize() (int, string, bool) {
+	if ng.asg.awsMaxSize <= 0 {
+		return 0, "", false
+	}
+	return ng.asg.awsMaxSize, fmt.Sprintf("ASG %s max size is %d", ng.asg.Name, ng.asg.awsMaxSize), true
+}
+
+// AvailableIPs returns the number of free IP addresses in the VPC subnets of
+// the ASG.
+func (ng *AwsNodeGroup) AvailableIPs() (int, error) {
+	return ng.awsManager.getAvailableIPs(ng.asg)
+}
+
 // MinSize returns minimum size of the node group.
 func (ng *AwsNodeGroup) MinSize() int {
 	return ng.asg.minSize
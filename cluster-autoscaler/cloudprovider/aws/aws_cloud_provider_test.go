@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/stretchr/testify/assert"
@@ -66,6 +67,16 @@ func (e *EC2Mock) DescribeLaunchTemplateVersions(i *ec2.DescribeLaunchTemplateVe
 	return args.Get(0).(*ec2.DescribeLaunchTemplateVersionsOutput), nil
 }
 
+func (e *EC2Mock) DescribeSubnets(i *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	args := e.Called(i)
+	return args.Get(0).(*ec2.DescribeSubnetsOutput), nil
+}
+
+func (e *EC2Mock) DescribeInstances(i *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	args := e.Called(i)
+	return args.Get(0).(*ec2.DescribeInstancesOutput), args.Error(1)
+}
+
 var testService = autoScalingWrapper{&AutoScalingMock{}, map[string]string{}}
 
 var testAwsManager = &AwsManager{
@@ -256,6 +267,74 @@ func TestNodeGroupForNode(t *testing.T) {
 	service.AssertNumberOfCalls(t, "DescribeAutoScalingGroupsPages", 1)
 }
 
+func TestHasInstance(t *testing.T) {
+	service := &AutoScalingMock{}
+	ec2Service := &EC2Mock{}
+	m := newTestAwsManagerWithAsgs(t, service, []string{"1:5:test-asg"})
+	m.ec2Service = ec2Wrapper{ec2Service}
+	provider := testProvider(t, m)
+
+	service.On("DescribeAutoScalingGroupsPages",
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice([]string{"test-asg"}),
+			MaxRecords:            aws.Int64(maxRecordsReturnedByAPI),
+		},
+		mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+	).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+		fn(testNamedDescribeAutoScalingGroupsOutput("test-asg", 1, "test-instance-id"), false)
+	}).Return(nil)
+	describeInstances := func(instanceID, state string, err error) {
+		output := &ec2.DescribeInstancesOutput{}
+		if state != "" {
+			output.Reservations = []*ec2.Reservation{{Instances: []*ec2.Instance{{
+				InstanceId: aws.String(instanceID),
+				State:      &ec2.InstanceState{Name: aws.String(state)},
+			}}}}
+		}
+		ec2Service.On("DescribeInstances", &ec2.DescribeInstancesInput{
+			InstanceIds: aws.StringSlice([]string{instanceID}),
+		}).Return(output, err)
+	}
+	describeInstances("static-instance-id", ec2.InstanceStateNameRunning, nil)
+	describeInstances("terminated-instance-id", ec2.InstanceStateNameTerminated, nil)
+	describeInstances("gone-instance-id", "", awserr.New("InvalidInstanceID.NotFound", "not found", nil))
+	assert.NoError(t, provider.Refresh())
+
+	hasInstance := func(providerID string) (bool, error) {
+		return provider.HasInstance(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: providerID}})
+	}
+
+	// Instances of the ASGs exist.
+	exists, err := hasInstance("aws:///us-east-1a/test-instance-id")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	ec2Service.AssertNotCalled(t, "DescribeInstances", mock.Anything)
+
+	// Other instances are looked up once per refresh, whether they exist or not.
+	for i := 0; i < 2; i++ {
+		exists, err = hasInstance("aws:///us-east-1a/static-instance-id")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = hasInstance("aws:///us-east-1a/terminated-instance-id")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = hasInstance("aws:///us-east-1a/gone-instance-id")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+	ec2Service.AssertNumberOfCalls(t, "DescribeInstances", 3)
+
+	assert.NoError(t, m.forceRefresh())
+	exists, err = hasInstance("aws:///us-east-1a/terminated-instance-id")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	ec2Service.AssertNumberOfCalls(t, "DescribeInstances", 4)
+
+	_, err = hasInstance("")
+	assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+}
+
 func TestNodeGroupForNodeWithNoProviderId(t *testing.T) {
 	node := &apiv1.Node{
 		Spec: apiv1.NodeSpec{
@@ -306,6 +385,35 @@ func TestTargetSize(t *testing.T) {
 	service.AssertNumberOfCalls(t, "DescribeAutoScalingGroupsPages", 1)
 }
 
+func TestProviderMaxSize(t *testing.T) {
+	service := &AutoScalingMock{}
+	provider := testProvider(t, newTestAwsManagerWithAsgs(t, service, []string{"1:10:test-asg"}))
+	asgs := provider.NodeGroups()
+
+	service.On("DescribeAutoScalingGroupsPages",
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice([]string{"test-asg"}),
+			MaxRecords:            aws.Int64(maxRecordsReturnedByAPI),
+		},
+		mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+	).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+		fn(testNamedDescribeAutoScalingGroupsOutput("test-asg", 2, "test-instance-id", "second-test-instance-id"), false)
+	}).Return(nil)
+
+	_, _, ok := asgs[0].(*AwsNodeGroup).ProviderMaxSize()
+	assert.False(t, ok)
+
+	provider.Refresh()
+
+	// Explicitly configured max size is kept, but the ASG max size is
+	// reported as the provider limit.
+	assert.Equal(t, 10, asgs[0].MaxSize())
+	maxSize, reason := cloudprovider.EffectiveMaxSize(asgs[0])
+	assert.Equal(t, 5, maxSize)
+	assert.Equal(t, "ASG test-asg max size is 5", reason)
+}
+
 func TestIncreaseSize(t *testing.T) {
 	service := &AutoScalingMock{}
 	provider := testProvider(t, newTestAwsManagerWithAsgs(t, service, []string{"1:5:test-asg"}))
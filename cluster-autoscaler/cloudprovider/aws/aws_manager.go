@@ -25,6 +25,7 @@ import (
 	"math/rand"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -59,6 +60,9 @@ type AwsManager struct {
 	ec2Service         ec2Wrapper
 	asgCache           *asgCache
 	lastRefresh        time.Time
+	// instancesExist holds whether the instances outside of the ASGs looked up since the last
+	// refresh exist, by instance ID.
+	instancesExist map[string]bool
 }
 
 type asgTemplate struct {
@@ -251,6 +255,7 @@ func (m *AwsManager) forceRefresh() error {
 		klog.Errorf("Failed to regenerate ASG cache: %v", err)
 		return err
 	}
+	m.instancesExist = make(map[string]bool)
 	m.lastRefresh = time.Now()
 	klog.V(2).Infof("Refreshed ASG list, next refresh after %v", m.lastRefresh.Add(refreshInterval))
 	return nil
@@ -261,6 +266,26 @@ func (m *AwsManager) GetAsgForInstance(instance AwsInstanceRef) *asg {
 	return m.asgCache.FindForInstance(instance)
 }
 
+// InstanceExists returns false if the instance doesn't exist anymore. Instances of the ASGs are known
+// to exist, other instances are looked up once per refresh.
+func (m *AwsManager) InstanceExists(instance AwsInstanceRef) (bool, error) {
+	if m.asgCache.FindForInstance(instance) != nil {
+		return true, nil
+	}
+	if exists, found := m.instancesExist[instance.Name]; found {
+		return exists, nil
+	}
+	exists, err := m.ec2Service.instanceExists(instance.Name)
+	if err != nil {
+		return false, err
+	}
+	if m.instancesExist == nil {
+		m.instancesExist = make(map[string]bool)
+	}
+	m.instancesExist[instance.Name] = exists
+	return exists, nil
+}
+
 // Cleanup the ASG cache.
 func (m *AwsManager) Cleanup() {
 	m.asgCache.Cleanup()
@@ -323,6 +348,15 @@ func (m *AwsManager) buildInstanceType(asg *asg) (string, error) {
 	return "", errors.New("Unable to get instance type from launch config or launch template")
 }
 
+// getAvailableIPs returns the number of free IP addresses in the VPC subnets
+// the ASG launches instances in. The subnets are described once per refresh.
+func (m *AwsManager) getAvailableIPs(asg *asg) (int, error) {
+	if len(asg.Subnets) == 0 {
+		return 0, cloudprovider.ErrNotImplemented
+	}
+	return m.asgCache.AvailableIPs(asg, m.ec2Service.getAvailableIPsBySubnet)
+}
+
 func (m *AwsManager) buildNodeFromTemplate(asg *asg, template *asgTemplate) (*apiv1.Node, error) {
 	node := apiv1.Node{}
 	nodeName := fmt.Sprintf("%s-asg-%d", asg.Name, rand.Int63())
@@ -350,6 +384,7 @@ func (m *AwsManager) buildNodeFromTemplate(asg *asg, template *asgTemplate) (*ap
 
 	// TODO: use proper allocatable!!
 	node.Status.Allocatable = node.Status.Capacity
+	cloudprovider.SetCSIAttachLimits(&node, extractCSIAttachLimitsFromAsg(template.Tags))
 
 	// NodeLabels
 	node.Labels = cloudprovider.JoinStringMaps(node.Labels, extractLabelsFromAsg(template.Tags))
@@ -416,6 +451,29 @@ func extractAllocatableResourcesFromAsg(tags []*autoscaling.TagDescription) map[
 	return result
 }
 
+func extractCSIAttachLimitsFromAsg(tags []*autoscaling.TagDescription) map[string]int64 {
+	result := make(map[string]int64)
+
+	for _, tag := range tags {
+		k := *tag.Key
+		v := *tag.Value
+		splits := strings.Split(k, "k8s.io/cluster-autoscaler/node-template/csi-attach-limit/")
+		if len(splits) > 1 {
+			driver := splits[1]
+			if driver != "" {
+				limit, err := strconv.ParseInt(v, 10, 64)
+				if err != nil || limit < 0 {
+					klog.Warningf("Ignoring invalid CSI attach limit %q for driver %s", v, driver)
+					continue
+				}
+				result[driver] = limit
+			}
+		}
+	}
+
+	return result
+}
+
 func extractTaintsFromAsg(tags []*autoscaling.TagDescription) []apiv1.Taint {
 	taints := make([]apiv1.Taint, 0)
 
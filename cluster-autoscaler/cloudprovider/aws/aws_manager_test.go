@@ -109,6 +109,23 @@ func TestExtractAllocatableResourcesFromAsg(t *testing.T) {
 	assert.Equal(t, (&expectedEphemeralStorage).String(), labels["ephemeral-storage"].String())
 }
 
+func TestExtractCSIAttachLimitsFromAsg(t *testing.T) {
+	tags := []*autoscaling.TagDescription{
+		{
+			Key:   aws.String("k8s.io/cluster-autoscaler/node-template/csi-attach-limit/ebs.csi.aws.com"),
+			Value: aws.String("25"),
+		},
+		{
+			Key:   aws.String("k8s.io/cluster-autoscaler/node-template/csi-attach-limit/invalid"),
+			Value: aws.String("many"),
+		},
+	}
+
+	limits := extractCSIAttachLimitsFromAsg(tags)
+
+	assert.Equal(t, map[string]int64{"ebs.csi.aws.com": 25}, limits)
+}
+
 func TestExtractLabelsFromAsg(t *testing.T) {
 	tags := []*autoscaling.TagDescription{
 		{
@@ -268,6 +285,42 @@ func TestBuildInstanceType(t *testing.T) {
 	assert.Equal(t, instanceType, builtInstanceType)
 }
 
+func TestGetAvailableIPs(t *testing.T) {
+	s := &EC2Mock{}
+	s.On("DescribeSubnets", &ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice([]string{"subnet-a", "subnet-b"}),
+	}).Return(&ec2.DescribeSubnetsOutput{
+		Subnets: []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-a"), AvailableIpAddressCount: aws.Int64(3)},
+			{SubnetId: aws.String("subnet-b"), AvailableIpAddressCount: aws.Int64(4)},
+		},
+	})
+
+	defer resetAWSRegion(os.LookupEnv("AWS_REGION"))
+	os.Setenv("AWS_REGION", "fanghorn")
+	m, err := createAWSManagerInternal(nil, cloudprovider.NodeGroupDiscoveryOptions{}, nil, &ec2Wrapper{s})
+	assert.NoError(t, err)
+
+	available, err := m.getAvailableIPs(&asg{Subnets: []string{"subnet-b", "subnet-a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, available)
+
+	// The subnets are only described again after a refresh.
+	available, err = m.getAvailableIPs(&asg{Subnets: []string{"subnet-b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, available)
+	s.AssertNumberOfCalls(t, "DescribeSubnets", 1)
+
+	assert.NoError(t, m.forceRefresh())
+	available, err = m.getAvailableIPs(&asg{Subnets: []string{"subnet-a", "subnet-b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, available)
+	s.AssertNumberOfCalls(t, "DescribeSubnets", 2)
+
+	_, err = m.getAvailableIPs(&asg{})
+	assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+}
+
 func TestGetASGTemplate(t *testing.T) {
 	const (
 		knownInstanceType = "t3.micro"
@@ -20,11 +20,14 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 type ec2I interface {
 	DescribeLaunchTemplateVersions(input *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+	DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
+	DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
 }
 
 type ec2Wrapper struct {
@@ -55,3 +58,47 @@ func (m ec2Wrapper) getInstanceTypeByLT(name string, version string) (string, er
 
 	return aws.StringValue(instanceType), nil
 }
+
+// getAvailableIPsBySubnet returns the number of free IP addresses of the subnets, by subnet ID.
+func (m ec2Wrapper) getAvailableIPsBySubnet(subnetIDs []string) (map[string]int, error) {
+	params := &ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice(subnetIDs),
+	}
+
+	describeData, err := m.DescribeSubnets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]int, len(describeData.Subnets))
+	for _, subnet := range describeData.Subnets {
+		available[aws.StringValue(subnet.SubnetId)] = int(aws.Int64Value(subnet.AvailableIpAddressCount))
+	}
+	return available, nil
+}
+
+// instanceExists returns false if the instance is unknown to EC2, or terminated or being terminated.
+func (m ec2Wrapper) instanceExists(instanceID string) (bool, error) {
+	params := &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{instanceID}),
+	}
+
+	describeData, err := m.DescribeInstances(params)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidInstanceID.NotFound" {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, reservation := range describeData.Reservations {
+		for _, instance := range reservation.Instances {
+			if aws.StringValue(instance.InstanceId) != instanceID || instance.State == nil {
+				continue
+			}
+			state := aws.StringValue(instance.State.Name)
+			return state != ec2.InstanceStateNameTerminated && state != ec2.InstanceStateNameShuttingDown, nil
+		}
+	}
+	return false, nil
+}
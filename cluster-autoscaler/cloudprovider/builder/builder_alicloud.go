@@ -1,4 +1,4 @@
-// +build alicloud
+// +build alicloud !alicloud,!aws,!azure,!baiducloud,!exoscale,!fake,!gce,!kubemark,!kubevirt,!nutanix,!openshiftmachineapi,!ovhcloud
 
 /*
 Copyright 2018 The Kubernetes Authors.
@@ -19,24 +19,9 @@ limitations under the License.
 package builder
 
 import (
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud"
-	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
-// AvailableCloudProviders supported by the cloud provider builder.
-var AvailableCloudProviders = []string{
-	alicloud.ProviderName,
-}
-
-// DefaultCloudProvider for alicloud-only build is alicloud.
-const DefaultCloudProvider = alicloud.ProviderName
-
-func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	switch opts.CloudProviderName {
-	case alicloud.ProviderName:
-		return alicloud.BuildAlicloud(opts, do, rl)
-	}
-
-	return nil
+func init() {
+	registerCloudProvider(alicloud.ProviderName, alicloud.BuildAlicloud)
 }
@@ -1,4 +1,4 @@
-// +build aws
+// +build aws !alicloud,!aws,!azure,!baiducloud,!exoscale,!fake,!gce,!kubemark,!kubevirt,!nutanix,!openshiftmachineapi,!ovhcloud
 
 /*
 Copyright 2018 The Kubernetes Authors.
@@ -19,24 +19,9 @@ limitations under the License.
 package builder
 
 import (
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws"
-	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
-// AvailableCloudProviders supported by the cloud provider builder.
-var AvailableCloudProviders = []string{
-	aws.ProviderName,
-}
-
-// DefaultCloudProvider for AWS-only build is AWS.
-const DefaultCloudProvider = aws.ProviderName
-
-func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	switch opts.CloudProviderName {
-	case aws.ProviderName:
-		return aws.BuildAWS(opts, do, rl)
-	}
-
-	return nil
+func init() {
+	registerCloudProvider(aws.ProviderName, aws.BuildAWS)
 }
@@ -1,4 +1,4 @@
-// +build azure
+// +build azure !alicloud,!aws,!azure,!baiducloud,!exoscale,!fake,!gce,!kubemark,!kubevirt,!nutanix,!openshiftmachineapi,!ovhcloud
 
 /*
 Copyright 2018 The Kubernetes Authors.
@@ -19,24 +19,9 @@ limitations under the License.
 package builder
 
 import (
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/azure"
-	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
-// AvailableCloudProviders supported by the cloud provider builder.
-var AvailableCloudProviders = []string{
-	azure.ProviderName,
-}
-
-// DefaultCloudProvider on Azure-only build is Azure.
-const DefaultCloudProvider = azure.ProviderName
-
-func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	switch opts.CloudProviderName {
-	case azure.ProviderName:
-		return azure.BuildAzure(opts, do, rl)
-	}
-
-	return nil
+func init() {
+	registerCloudProvider(azure.ProviderName, azure.BuildAzure)
 }
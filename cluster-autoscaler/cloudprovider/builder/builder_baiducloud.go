@@ -1,4 +1,4 @@
-// +build baiducloud
+// +build baiducloud !alicloud,!aws,!azure,!baiducloud,!exoscale,!fake,!gce,!kubemark,!kubevirt,!nutanix,!openshiftmachineapi,!ovhcloud
 
 /*
 Copyright 2018 The Kubernetes Authors.
@@ -19,25 +19,9 @@ limitations under the License.
 package builder
 
 import (
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/baiducloud"
-	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
-// AvailableCloudProviders supported by the cloud provider builder.
-var AvailableCloudProviders = []string{
-	baiducloud.ProviderName,
-}
-
-// DefaultCloudProvider for baiducloud-only build is baiducloud.
-const DefaultCloudProvider = baiducloud.ProviderName
-
-func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	switch opts.CloudProviderName {
-	case baiducloud.ProviderName:
-		return baiducloud.BuildBaiducloud(opts, do, rl)
-	}
-
-	return nil
+func init() {
+	registerCloudProvider(baiducloud.ProviderName, baiducloud.BuildBaiducloud)
 }
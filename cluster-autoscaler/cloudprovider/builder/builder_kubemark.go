@@ -19,24 +19,9 @@ limitations under the License.
 package builder
 
 import (
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/kubemark"
-	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
-// AvailableCloudProviders supported by the cloud provider builder.
-var AvailableCloudProviders = []string{
-	kubemark.ProviderName,
-}
-
-// DefaultCloudProvider for Kubemark-only build is Kubemark.
-const DefaultCloudProvider = kubemark.ProviderName
-
-func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	switch opts.CloudProviderName {
-	case kubemark.ProviderName:
-		return kubemark.BuildKubemark(opts, do, rl)
-	}
-
-	return nil
+func init() {
+	registerCloudProvider(kubemark.ProviderName, kubemark.BuildKubemark)
 }
@@ -1,4 +1,4 @@
-// +build openshiftmachineapi
+// +build openshiftmachineapi !alicloud,!aws,!azure,!baiducloud,!exoscale,!fake,!gce,!kubemark,!kubevirt,!nutanix,!openshiftmachineapi,!ovhcloud
 
 /*
 Copyright 2019 The Kubernetes Authors.
@@ -19,24 +19,9 @@ limitations under the License.
 package builder
 
 import (
-	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/openshiftmachineapi"
-	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
-// AvailableCloudProviders supported by the cloud provider builder.
-var AvailableCloudProviders = []string{
-	openshiftmachineapi.ProviderName,
-}
-
-// DefaultCloudProvider for machineapi-only build.
-const DefaultCloudProvider = openshiftmachineapi.ProviderName
-
-func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	switch opts.CloudProviderName {
-	case openshiftmachineapi.ProviderName:
-		return openshiftmachineapi.BuildOpenShiftMachineAPI(opts, do, rl)
-	}
-
-	return nil
+func init() {
+	registerCloudProvider(openshiftmachineapi.ProviderName, openshiftmachineapi.BuildOpenShiftMachineAPI)
 }
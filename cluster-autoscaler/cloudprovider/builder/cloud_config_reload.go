@@ -0,0 +1,212 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+
+	"k8s.io/klog"
+)
+
+// reloadingCloudProvider is a cloud provider rebuilt whenever the content of its configuration file
+// changes, e.g. when the credentials in the mounted Secret holding it are rotated. The file is checked
+// on every Refresh, i.e. before every main loop, so that all the node groups used by a loop come from
+// the same provider. Kubelet swaps the files of updated Secret volumes atomically, while a file that
+// can't be read, or a provider that can't be built or refreshed from it, keeps the current provider.
+// The optional cloud provider interfaces are delegated to the current provider.
+type reloadingCloudProvider struct {
+	sync.RWMutex
+	path     string
+	build    func() cloudprovider.CloudProvider
+	provider cloudprovider.CloudProvider
+	checksum [sha256.Size]byte
+}
+
+func newReloadingCloudProvider(path string, build func() cloudprovider.CloudProvider) cloudprovider.CloudProvider {
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		klog.Warningf("Failed to read cloud provider configuration %s: %v", path, err)
+	}
+	provider := build()
+	if provider == nil {
+		return nil
+	}
+	return &reloadingCloudProvider{
+		path:     path,
+		build:    build,
+		provider: provider,
+		checksum: checksum,
+	}
+}
+
+func fileChecksum(path string) ([sha256.Size]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(content), nil
+}
+
+func (r *reloadingCloudProvider) current() cloudprovider.CloudProvider {
+	r.RLock()
+	defer r.RUnlock()
+	return r.provider
+}
+
+// Name returns name of the cloud provider.
+func (r *reloadingCloudProvider) Name() string {
+	return r.current().Name()
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (r *reloadingCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	return r.current().NodeGroups()
+}
+
+// NodeGroupForNode returns the node group for the given node.
+func (r *reloadingCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	return r.current().NodeGroupForNode(node)
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+func (r *reloadingCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return r.current().Pricing()
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
+func (r *reloadingCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return r.current().GetAvailableMachineTypes()
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition provided.
+func (r *reloadingCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return r.current().NewNodeGroup(machineType, labels, systemLabels, taints, extraResources)
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (r *reloadingCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return r.current().GetResourceLimiter()
+}
+
+// GPULabel returns the GPU label of the current cloud provider.
+func (r *reloadingCloudProvider) GPULabel() string {
+	return gpu.GetGpuLabel(r.current())
+}
+
+// GetAvailableGPUTypes returns the GPU types of the current cloud provider.
+func (r *reloadingCloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	return gpu.GetAvailableGpuTypes(r.current())
+}
+
+// IsNodeUnremovable delegates to the current cloud provider if it implements
+// cloudprovider.UnremovableNodeCloudProvider.
+func (r *reloadingCloudProvider) IsNodeUnremovable(node *apiv1.Node) (bool, string, error) {
+	if provider, ok := r.current().(cloudprovider.UnremovableNodeCloudProvider); ok {
+		return provider.IsNodeUnremovable(node)
+	}
+	return false, "", cloudprovider.ErrNotImplemented
+}
+
+// HasInstance delegates to the current cloud provider if it implements
+// cloudprovider.InstanceLookupCloudProvider.
+func (r *reloadingCloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	if provider, ok := r.current().(cloudprovider.InstanceLookupCloudProvider); ok {
+		return provider.HasInstance(node)
+	}
+	return true, cloudprovider.ErrNotImplemented
+}
+
+// SpotLabel returns the spot label of the current cloud provider, or an empty string if it
+// doesn't implement cloudprovider.SpotCloudProvider.
+func (r *reloadingCloudProvider) SpotLabel() string {
+	if provider, ok := r.current().(cloudprovider.SpotCloudProvider); ok {
+		return provider.SpotLabel()
+	}
+	return ""
+}
+
+// BalancingIgnoredLabels returns the balancing ignored labels of the current cloud provider,
+// if it implements cloudprovider.BalancingIgnoredLabelsCloudProvider.
+func (r *reloadingCloudProvider) BalancingIgnoredLabels() []string {
+	if provider, ok := r.current().(cloudprovider.BalancingIgnoredLabelsCloudProvider); ok {
+		return provider.BalancingIgnoredLabels()
+	}
+	return nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (r *reloadingCloudProvider) Cleanup() error {
+	return r.current().Cleanup()
+}
+
+// Refresh rebuilds the cloud provider if its configuration changed, and refreshes it. The new
+// cloud provider only replaces the current one once it was built and refreshed successfully.
+func (r *reloadingCloudProvider) Refresh() error {
+	checksum, err := fileChecksum(r.path)
+	if err != nil {
+		klog.Warningf("Failed to read cloud provider configuration %s, keeping the current one: %v", r.path, err)
+	} else if checksum != r.checksum {
+		klog.V(0).Infof("Cloud provider configuration %s changed, rebuilding the cloud provider", r.path)
+		// The configuration isn't rebuilt again until it changes.
+		r.checksum = checksum
+		provider, err := r.rebuild()
+		if err != nil {
+			klog.Errorf("Failed to rebuild the cloud provider from %s, keeping the current one: %v", r.path, err)
+		} else {
+			r.Lock()
+			previous := r.provider
+			r.provider = provider
+			r.Unlock()
+			if err := previous.Cleanup(); err != nil {
+				klog.Warningf("Failed to clean up the previous cloud provider: %v", err)
+			}
+			return nil
+		}
+	}
+	return r.current().Refresh()
+}
+
+// rebuild builds and refreshes a cloud provider from the current configuration, turning the
+// panics of the builder into errors.
+func (r *reloadingCloudProvider) rebuild() (provider cloudprovider.CloudProvider, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			provider, err = nil, fmt.Errorf("cloud provider builder panicked: %v", recovered)
+		}
+	}()
+	provider = r.build()
+	if provider == nil {
+		return nil, fmt.Errorf("cloud provider builder returned no cloud provider")
+	}
+	if err := provider.Refresh(); err != nil {
+		if cleanupErr := provider.Cleanup(); cleanupErr != nil {
+			klog.Warningf("Failed to clean up the rejected cloud provider: %v", cleanupErr)
+		}
+		return nil, fmt.Errorf("failed to refresh the rebuilt cloud provider: %v", err)
+	}
+	return provider, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadingCloudProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloud-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cloud-config")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("ng1"), 0600))
+
+	builds := 0
+	provider := newReloadingCloudProvider(path, func() cloudprovider.CloudProvider {
+		builds++
+		content, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		switch string(content) {
+		case "panic":
+			panic("invalid configuration")
+		case "nil":
+			return nil
+		}
+		provider := testprovider.NewTestCloudProvider(nil, nil)
+		provider.AddNodeGroup(string(content), 0, 10, 1)
+		if string(content) == "refresh-error" {
+			return &failingRefreshCloudProvider{provider}
+		}
+		return provider
+	})
+	nodeGroupIds := func() []string {
+		var ids []string
+		for _, nodeGroup := range provider.NodeGroups() {
+			ids = append(ids, nodeGroup.Id())
+		}
+		return ids
+	}
+	assert.Equal(t, 1, builds)
+	assert.Equal(t, []string{"ng1"}, nodeGroupIds())
+
+	// Unchanged configuration.
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, 1, builds)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("ng2"), 0600))
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, 2, builds)
+	assert.Equal(t, []string{"ng2"}, nodeGroupIds())
+
+	// A configuration that can't be read keeps the current provider.
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, 2, builds)
+	assert.Equal(t, []string{"ng2"}, nodeGroupIds())
+
+	// Providers that can't be built or refreshed don't replace the current one, and aren't
+	// rebuilt until the configuration changes again.
+	for i, content := range []string{"panic", "nil", "refresh-error"} {
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		assert.NoError(t, provider.Refresh())
+		assert.NoError(t, provider.Refresh())
+		assert.Equal(t, 3+i, builds)
+		assert.Equal(t, []string{"ng2"}, nodeGroupIds())
+	}
+}
+
+func TestReloadingCloudProviderOptionalInterfaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloud-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cloud-config")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("ng1"), 0600))
+
+	testProvider := testprovider.NewTestCloudProvider(nil, nil)
+	testProvider.AddNodeGroup("ng1", 0, 10, 1)
+	node := BuildTestNode("n1", 1000, 1000)
+	testProvider.AddNode("ng1", node)
+	testProvider.SetNodeUnremovable("n1", "protected")
+	provider := newReloadingCloudProvider(path, func() cloudprovider.CloudProvider {
+		return testProvider
+	})
+
+	unremovable, reason, err := provider.(cloudprovider.UnremovableNodeCloudProvider).IsNodeUnremovable(node)
+	assert.NoError(t, err)
+	assert.True(t, unremovable)
+	assert.Equal(t, "protected", reason)
+	exists, err := provider.(cloudprovider.InstanceLookupCloudProvider).HasInstance(node)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// The test provider doesn't label spot nodes.
+	assert.Equal(t, "", provider.(cloudprovider.SpotCloudProvider).SpotLabel())
+	assert.Empty(t, provider.(cloudprovider.BalancingIgnoredLabelsCloudProvider).BalancingIgnoredLabels())
+}
+
+// failingRefreshCloudProvider is a cloud provider that can't be refreshed.
+type failingRefreshCloudProvider struct {
+	*testprovider.TestCloudProvider
+}
+
+func (f *failingRefreshCloudProvider) Refresh() error {
+	return fmt.Errorf("invalid credentials")
+}
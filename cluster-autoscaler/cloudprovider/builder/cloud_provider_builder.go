@@ -42,7 +42,14 @@ func NewCloudProvider(opts config.AutoscalingOptions) cloudprovider.CloudProvide
 		return nil
 	}
 
-	provider := buildCloudProvider(opts, do, rl)
+	var provider cloudprovider.CloudProvider
+	if opts.CloudConfigReload && opts.CloudConfig != "" {
+		provider = newReloadingCloudProvider(opts.CloudConfig, func() cloudprovider.CloudProvider {
+			return buildCloudProvider(opts, do, rl)
+		})
+	} else {
+		provider = buildCloudProvider(opts, do, rl)
+	}
 	if provider != nil {
 		return provider
 	}
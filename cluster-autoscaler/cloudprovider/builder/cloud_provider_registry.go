@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+// Every cloud provider is registered by a builder_<provider>.go file, built when the <provider>
+// build tag is set or when no provider build tag is set at all. Building with one or more provider
+// tags, e.g. `go build -tags aws`, only links the SDKs of these providers into the binary. The
+// kubemark provider is only built with its tag.
+
+// preferredDefaultCloudProvider is the default cloud provider when it's built into the binary. It
+// isn't taken from the gce package, which would link it into every build.
+const preferredDefaultCloudProvider = "gce"
+
+// cloudProviderBuilder builds a cloud provider from the autoscaling options.
+type cloudProviderBuilder func(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider
+
+// cloudProviderBuilders are the registered cloud providers, by name.
+var cloudProviderBuilders = make(map[string]cloudProviderBuilder)
+
+// registerCloudProvider makes a cloud provider available. It's called from init functions, so
+// registering the same name twice panics.
+func registerCloudProvider(name string, build cloudProviderBuilder) {
+	if _, found := cloudProviderBuilders[name]; found {
+		panic(fmt.Sprintf("cloud provider %s registered twice", name))
+	}
+	cloudProviderBuilders[name] = build
+}
+
+// AvailableCloudProviders returns the sorted names of the cloud providers built into the binary.
+func AvailableCloudProviders() []string {
+	names := make([]string, 0, len(cloudProviderBuilders))
+	for name := range cloudProviderBuilders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultCloudProvider returns GCE if it's built into the binary, or the first available cloud
+// provider otherwise.
+func DefaultCloudProvider() string {
+	if _, found := cloudProviderBuilders[preferredDefaultCloudProvider]; found {
+		return preferredDefaultCloudProvider
+	}
+	if names := AvailableCloudProviders(); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	build, found := cloudProviderBuilders[opts.CloudProviderName]
+	if !found {
+		return nil
+	}
+	return build(opts, do, rl)
+}
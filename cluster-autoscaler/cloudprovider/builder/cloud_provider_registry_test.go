@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	available := AvailableCloudProviders()
+	assert.NotEmpty(t, available)
+	assert.True(t, sort.StringsAreSorted(available))
+	assert.Contains(t, available, DefaultCloudProvider())
+	assert.Nil(t, buildCloudProvider(config.AutoscalingOptions{CloudProviderName: "unknown"}, cloudprovider.NodeGroupDiscoveryOptions{}, nil))
+	assert.Panics(t, func() { registerCloudProvider(available[0], nil) })
+}
+
+// TestBuildConstraints checks that every provider file is built either with its own tag or when
+// no provider tag is set, so that adding a provider doesn't break the builds of the others.
+func TestBuildConstraints(t *testing.T) {
+	files, err := filepath.Glob("builder_*.go")
+	assert.NoError(t, err)
+	tags := make([]string, 0, len(files))
+	for _, file := range files {
+		tags = append(tags, strings.TrimSuffix(strings.TrimPrefix(file, "builder_"), ".go"))
+	}
+	sort.Strings(tags)
+	none := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		none = append(none, "!"+tag)
+	}
+
+	for i, file := range files {
+		expected := "// +build " + tags[i] + " " + strings.Join(none, ",")
+		if tags[i] == "kubemark" {
+			expected = "// +build kubemark"
+		}
+		f, err := os.Open(file)
+		assert.NoError(t, err)
+		line, err := bufio.NewReader(f).ReadString('\n')
+		f.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, strings.TrimSpace(line), file)
+	}
+}
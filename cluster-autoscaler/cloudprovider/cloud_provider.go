@@ -142,6 +142,134 @@ type NodeGroup interface {
 	Autoprovisioned() bool
 }
 
+// ProviderLimitedNodeGroup is an optional interface implemented by node groups
+// whose underlying infrastructure enforces a hard limit on the group size that
+// can be lower than MaxSize (e.g. ASG max size, quota or subnet capacity).
+type ProviderLimitedNodeGroup interface {
+	// ProviderMaxSize returns the maximum size the cloud provider is able to
+	// deliver for the node group together with a human readable reason. ok is
+	// false if the provider doesn't impose any limit of its own.
+	ProviderMaxSize() (maxSize int, reason string, ok bool)
+}
+
+// IPCapacityNodeGroup is an optional interface implemented by node groups
+// that can report how many IP addresses are still free in the subnets new
+// nodes are launched in.
+type IPCapacityNodeGroup interface {
+	// AvailableIPs returns the number of free IP addresses left for new nodes
+	// of the node group. ErrNotImplemented is returned if the information is
+	// not available for the node group.
+	AvailableIPs() (int, error)
+}
+
+// HealthReportingNodeGroup is an optional interface implemented by node groups
+// whose underlying infrastructure can report problems keeping it from
+// providing the nodes it is asked for, e.g. terminal errors of the controller
+// reconciling its instances.
+type HealthReportingNodeGroup interface {
+	// Problem returns a one-word, CamelCase reason and a human readable message
+	// describing the problem of the node group. ok is false if the node group
+	// has no problem.
+	Problem() (reason, message string, ok bool)
+}
+
+// SizeLimitsNodeGroup is an optional interface implemented by the node groups
+// built by NewNodeGroup whose min and max sizes can be chosen before they are
+// created.
+type SizeLimitsNodeGroup interface {
+	// SetSizeLimits sets the min and max sizes the node group is created
+	// with. An error is returned if the node group already exists.
+	SetSizeLimits(minSize, maxSize int) error
+}
+
+// PersistentNodeGroup is an optional interface implemented by the node groups
+// built by NewNodeGroup that can be created as regular node groups, which are
+// kept when unused instead of being deleted like autoprovisioned ones.
+type PersistentNodeGroup interface {
+	// SetPersistent makes the node group be created as a regular node group.
+	// An error is returned if the node group already exists.
+	SetPersistent() error
+}
+
+// NodeGroupAutoscalingOptions are the autoscaling options that node groups
+// can override for their nodes.
+type NodeGroupAutoscalingOptions struct {
+	// MaxNodeProvisionTime is the maximum time CA waits for the nodes of the
+	// node group to be provisioned.
+	MaxNodeProvisionTime time.Duration
+	// ReadinessConditions are the node condition types that have to be True
+	// on a ready node of the node group before it's counted as available
+	// capacity.
+	ReadinessConditions []string
+	// ReadinessLabels are the labels, either keys or key=value pairs, a
+	// ready node of the node group has to carry before it's counted as
+	// available capacity.
+	ReadinessLabels []string
+	// StartupTaints are the keys of the taints, on top of the uninitialized
+	// and startup-taint prefixed ones, that the new nodes of the node group
+	// carry until they are initialized.
+	StartupTaints []string
+}
+
+// OptionsNodeGroup is an optional interface implemented by node groups that
+// override some of the autoscaling options, e.g. because their nodes take
+// longer to provision than the ones of other node groups.
+type OptionsNodeGroup interface {
+	// GetOptions returns the autoscaling options of the node group, defaults
+	// holding the global ones. ErrNotImplemented is returned if the node
+	// group doesn't override any.
+	GetOptions(defaults NodeGroupAutoscalingOptions) (*NodeGroupAutoscalingOptions, error)
+}
+
+// InstanceLookupCloudProvider is an optional interface implemented by cloud
+// providers that can tell whether the instance backing a node still exists.
+type InstanceLookupCloudProvider interface {
+	// HasInstance returns false if the instance backing the node doesn't
+	// exist on the cloud provider side anymore. ErrNotImplemented is returned
+	// if it can't be determined for the node.
+	HasInstance(*apiv1.Node) (bool, error)
+}
+
+// UnremovableNodeCloudProvider is an optional interface implemented by cloud
+// providers whose infrastructure can be in the middle of replacing or
+// repairing a node, during which the autoscaler must not remove it.
+type UnremovableNodeCloudProvider interface {
+	// IsNodeUnremovable returns true, with a human readable reason, if the
+	// node must not be considered for scale down. ErrNotImplemented is
+	// returned if it can't be determined for the node.
+	IsNodeUnremovable(*apiv1.Node) (bool, string, error)
+}
+
+// GpuCloudProvider is an optional interface implemented by cloud providers
+// whose GPU nodes are labeled with their GPU type by a label other than the
+// GKE one.
+type GpuCloudProvider interface {
+	// GPULabel returns the label added to the nodes with GPUs, whose value
+	// is their GPU type.
+	GPULabel() string
+	// GetAvailableGPUTypes returns all the GPU types the nodes of the cloud
+	// provider can have.
+	GetAvailableGPUTypes() map[string]struct{}
+}
+
+// SpotCloudProvider is an optional interface implemented by cloud providers
+// whose node groups can be backed by spot or preemptible instances, which are
+// cheaper but can be reclaimed by the infrastructure at any time.
+type SpotCloudProvider interface {
+	// SpotLabel returns the label of the nodes, including template nodes,
+	// backed by spot or preemptible instances.
+	SpotLabel() string
+}
+
+// BalancingIgnoredLabelsCloudProvider is an optional interface implemented by
+// cloud providers that set labels on the nodes of each node group, e.g. the
+// name of the group, that differ between otherwise similar node groups.
+type BalancingIgnoredLabelsCloudProvider interface {
+	// BalancingIgnoredLabels returns the labels to ignore when comparing
+	// node groups to balance scale-ups between similar ones.
+	BalancingIgnoredLabels() []string
+}
+
 // Instance represents a cloud-provider node. The node does not necessarily map to k8s node
 // i.e it does not have to be registered in k8s cluster despite being returned by NodeGroup.Nodes()
 // method. Also it is sane to have Instance object for nodes which are being created or deleted.
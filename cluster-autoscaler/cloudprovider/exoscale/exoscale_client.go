@@ -0,0 +1,233 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	// signatureLifetime is how long signed requests are valid for.
+	signatureLifetime = 10 * time.Minute
+)
+
+type resourceRef struct {
+	ID string `json:"id"`
+}
+
+// sksCluster is an Exoscale SKS cluster.
+type sksCluster struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Nodepools []sksNodepool `json:"nodepools"`
+}
+
+// sksNodepool is a node pool of an SKS cluster, backed by an instance pool.
+type sksNodepool struct {
+	ID                 string                      `json:"id"`
+	Name               string                      `json:"name"`
+	Size               int                         `json:"size"`
+	InstancePool       resourceRef                 `json:"instance-pool"`
+	InstanceType       resourceRef                 `json:"instance-type"`
+	AntiAffinityGroups []resourceRef               `json:"anti-affinity-groups"`
+	Labels             map[string]string           `json:"labels"`
+	Taints             map[string]sksNodepoolTaint `json:"taints"`
+}
+
+type sksNodepoolTaint struct {
+	Value  string `json:"value"`
+	Effect string `json:"effect"`
+}
+
+// instancePool is an Exoscale instance pool.
+type instancePool struct {
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	Size               int               `json:"size"`
+	Instances          []resourceRef     `json:"instances"`
+	InstanceType       resourceRef       `json:"instance-type"`
+	AntiAffinityGroups []resourceRef     `json:"anti-affinity-groups"`
+	Labels             map[string]string `json:"labels"`
+}
+
+// instanceType describes the resources of instances.
+type instanceType struct {
+	ID     string `json:"id"`
+	Family string `json:"family"`
+	Size   string `json:"size"`
+	Cpus   int64  `json:"cpus"`
+	Gpus   int64  `json:"gpus"`
+	Memory int64  `json:"memory"`
+}
+
+// antiAffinityGroup places its instances on different hypervisors.
+type antiAffinityGroup struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Instances []resourceRef `json:"instances"`
+}
+
+type scaleRequest struct {
+	Size int `json:"size"`
+}
+
+type evictRequest struct {
+	Instances []resourceRef `json:"instances"`
+}
+
+// exoscaleClient is the subset of the Exoscale API v2 used by the cloud provider.
+type exoscaleClient interface {
+	getSKSCluster(id string) (*sksCluster, error)
+	scaleSKSNodepool(clusterID, nodepoolID string, size int) error
+	evictSKSNodepoolMembers(clusterID, nodepoolID string, instanceIDs []string) error
+	getInstancePool(id string) (*instancePool, error)
+	scaleInstancePool(id string, size int) error
+	evictInstancePoolMembers(id string, instanceIDs []string) error
+	getInstanceType(id string) (*instanceType, error)
+	getAntiAffinityGroup(id string) (*antiAffinityGroup, error)
+}
+
+type exoscaleHTTPClient struct {
+	endpoint   string
+	apiKey     string
+	apiSecret  string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func newExoscaleHTTPClient(endpoint, apiKey, apiSecret string) *exoscaleHTTPClient {
+	return &exoscaleHTTPClient{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		now:        time.Now,
+	}
+}
+
+func (c *exoscaleHTTPClient) getSKSCluster(id string) (*sksCluster, error) {
+	cluster := &sksCluster{}
+	return cluster, c.do(http.MethodGet, "/sks-cluster/"+id, nil, cluster)
+}
+
+func (c *exoscaleHTTPClient) scaleSKSNodepool(clusterID, nodepoolID string, size int) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/sks-cluster/%s/nodepool/%s:scale", clusterID, nodepoolID), &scaleRequest{Size: size}, nil)
+}
+
+func (c *exoscaleHTTPClient) evictSKSNodepoolMembers(clusterID, nodepoolID string, instanceIDs []string) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/sks-cluster/%s/nodepool/%s:evict", clusterID, nodepoolID), newEvictRequest(instanceIDs), nil)
+}
+
+func (c *exoscaleHTTPClient) getInstancePool(id string) (*instancePool, error) {
+	pool := &instancePool{}
+	return pool, c.do(http.MethodGet, "/instance-pool/"+id, nil, pool)
+}
+
+func (c *exoscaleHTTPClient) scaleInstancePool(id string, size int) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/instance-pool/%s:scale", id), &scaleRequest{Size: size}, nil)
+}
+
+func (c *exoscaleHTTPClient) evictInstancePoolMembers(id string, instanceIDs []string) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/instance-pool/%s:evict", id), newEvictRequest(instanceIDs), nil)
+}
+
+func (c *exoscaleHTTPClient) getInstanceType(id string) (*instanceType, error) {
+	t := &instanceType{}
+	return t, c.do(http.MethodGet, "/instance-type/"+id, nil, t)
+}
+
+func (c *exoscaleHTTPClient) getAntiAffinityGroup(id string) (*antiAffinityGroup, error) {
+	group := &antiAffinityGroup{}
+	return group, c.do(http.MethodGet, "/anti-affinity-group/"+id, nil, group)
+}
+
+func newEvictRequest(instanceIDs []string) *evictRequest {
+	request := &evictRequest{Instances: make([]resourceRef, 0, len(instanceIDs))}
+	for _, id := range instanceIDs {
+		request.Instances = append(request.Instances, resourceRef{ID: id})
+	}
+	return request
+}
+
+// signature returns the value of the Authorization header for the request, following the
+// EXO2-HMAC-SHA256 scheme of the Exoscale API v2.
+func (c *exoscaleHTTPClient) signature(method, path string, body []byte, expires int64) string {
+	message := strings.Join([]string{
+		method + " " + path,
+		string(body),
+		"", // No signed query arguments.
+		"", // No signed headers.
+		fmt.Sprint(expires),
+	}, "\n")
+	mac := hmac.New(sha256.New, []byte(c.apiSecret))
+	mac.Write([]byte(message))
+	return fmt.Sprintf("EXO2-HMAC-SHA256 credential=%s,expires=%d,signature=%s",
+		c.apiKey, expires, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func (c *exoscaleHTTPClient) do(method, path string, body interface{}, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	request, err := http.NewRequest(method, c.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	expires := c.now().Add(signatureLifetime).Unix()
+	request.Header.Set("Authorization", c.signature(method, request.URL.EscapedPath(), payload, expires))
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	klog.V(5).Infof("Exoscale request: %s %s", method, path)
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s failed to read response: %v", method, path, err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, response.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("%s %s returned invalid response: %v", method, path, err)
+	}
+	return nil
+}
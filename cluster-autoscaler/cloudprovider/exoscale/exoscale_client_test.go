@@ -0,0 +1,132 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExoscaleHTTPClient(t *testing.T) {
+	now := time.Unix(1570000000, 0)
+	expires := now.Add(signatureLifetime).Unix()
+
+	var requests []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		fmt.Fprintf(mac, "%s %s\n%s\n\n\n%d", r.Method, r.URL.EscapedPath(), data, expires)
+		expected := fmt.Sprintf("EXO2-HMAC-SHA256 credential=EXOkey,expires=%d,signature=%s",
+			expires, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		if r.Header.Get("Authorization") != expected {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		body := make(map[string]interface{})
+		json.Unmarshal(data, &body)
+		bodies = append(bodies, body)
+
+		switch r.URL.EscapedPath() {
+		case "/v2/sks-cluster/cluster":
+			fmt.Fprint(w, `{"id": "cluster", "name": "prod", "nodepools": [{"id": "np", "name": "workers", "size": 2,
+				"instance-pool": {"id": "pool"}, "labels": {"team": "a"}, "taints": {"dedicated": {"value": "a", "effect": "NoSchedule"}}}]}`)
+		case "/v2/instance-pool/pool":
+			fmt.Fprint(w, `{"id": "pool", "size": 2, "instances": [{"id": "i-0"}, {"id": "i-1"}],
+				"instance-type": {"id": "type"}, "anti-affinity-groups": [{"id": "aag"}]}`)
+		case "/v2/instance-type/type":
+			fmt.Fprint(w, `{"id": "type", "family": "standard", "size": "medium", "cpus": 2, "memory": 4294967296}`)
+		case "/v2/anti-affinity-group/aag":
+			fmt.Fprint(w, `{"id": "aag", "instances": [{"id": "i-0"}]}`)
+		case "/v2/sks-cluster/cluster/nodepool/np:scale", "/v2/sks-cluster/cluster/nodepool/np:evict",
+			"/v2/instance-pool/pool:scale", "/v2/instance-pool/pool:evict":
+			fmt.Fprint(w, `{"id": "operation", "state": "pending"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := newExoscaleHTTPClient(server.URL+"/v2/", "EXOkey", "secret")
+	client.now = func() time.Time { return now }
+
+	cluster, err := client.getSKSCluster("cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, &sksCluster{ID: "cluster", Name: "prod", Nodepools: []sksNodepool{{
+		ID:           "np",
+		Name:         "workers",
+		Size:         2,
+		InstancePool: resourceRef{ID: "pool"},
+		Labels:       map[string]string{"team": "a"},
+		Taints:       map[string]sksNodepoolTaint{"dedicated": {Value: "a", Effect: "NoSchedule"}},
+	}}}, cluster)
+
+	pool, err := client.getInstancePool("pool")
+	assert.NoError(t, err)
+	assert.Equal(t, &instancePool{
+		ID:                 "pool",
+		Size:               2,
+		Instances:          []resourceRef{{ID: "i-0"}, {ID: "i-1"}},
+		InstanceType:       resourceRef{ID: "type"},
+		AntiAffinityGroups: []resourceRef{{ID: "aag"}},
+	}, pool)
+
+	instanceTypeInfo, err := client.getInstanceType("type")
+	assert.NoError(t, err)
+	assert.Equal(t, &instanceType{ID: "type", Family: "standard", Size: "medium", Cpus: 2, Memory: 4294967296}, instanceTypeInfo)
+
+	group, err := client.getAntiAffinityGroup("aag")
+	assert.NoError(t, err)
+	assert.Equal(t, []resourceRef{{ID: "i-0"}}, group.Instances)
+
+	assert.NoError(t, client.scaleSKSNodepool("cluster", "np", 3))
+	assert.NoError(t, client.evictSKSNodepoolMembers("cluster", "np", []string{"i-1"}))
+	assert.NoError(t, client.scaleInstancePool("pool", 4))
+	assert.NoError(t, client.evictInstancePoolMembers("pool", []string{"i-0"}))
+	assert.Equal(t, []string{
+		"GET /v2/sks-cluster/cluster",
+		"GET /v2/instance-pool/pool",
+		"GET /v2/instance-type/type",
+		"GET /v2/anti-affinity-group/aag",
+		"PUT /v2/sks-cluster/cluster/nodepool/np:scale",
+		"PUT /v2/sks-cluster/cluster/nodepool/np:evict",
+		"PUT /v2/instance-pool/pool:scale",
+		"PUT /v2/instance-pool/pool:evict",
+	}, requests)
+	assert.Equal(t, float64(3), bodies[4]["size"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "i-1"}}, bodies[5]["instances"])
+	assert.Equal(t, float64(4), bodies[6]["size"])
+
+	_, err = client.getInstancePool("missing")
+	assert.EqualError(t, err, `GET /instance-pool/missing failed with status 404: {"message": "not found"}`)
+
+	unauthorized := newExoscaleHTTPClient(server.URL+"/v2", "EXOkey", "wrong")
+	_, err = unauthorized.getInstancePool("pool")
+	assert.Error(t, err)
+}
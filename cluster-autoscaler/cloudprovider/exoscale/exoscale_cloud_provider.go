@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"io"
+	"os"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for Exoscale.
+	ProviderName = "exoscale"
+)
+
+// exoscaleCloudProvider implements CloudProvider interface for Exoscale instance pools and SKS node pools.
+type exoscaleCloudProvider struct {
+	manager         *exoscaleManager
+	resourceLimiter *cloudprovider.ResourceLimiter
+}
+
+// BuildExoscale builds the Exoscale cloud provider.
+func BuildExoscale(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	var configReader io.Reader
+	if opts.CloudConfig != "" {
+		configFile, err := os.Open(opts.CloudConfig)
+		if err != nil {
+			klog.Fatalf("Couldn't open cloud provider configuration %s: %#v", opts.CloudConfig, err)
+		}
+		defer configFile.Close()
+		configReader = configFile
+	}
+	cfg, err := readConfig(configReader)
+	if err != nil {
+		klog.Fatalf("Failed to read Exoscale cloud provider configuration: %v", err)
+	}
+	if do.AutoDiscoverySpecified() {
+		klog.Fatalf("Failed to create Exoscale cloud provider: node group auto-discovery isn't supported")
+	}
+	if !do.StaticDiscoverySpecified() {
+		klog.Fatalf("Failed to create Exoscale cloud provider: node groups must be passed with --nodes")
+	}
+
+	client := newExoscaleHTTPClient(cfg.APIEndpoint, cfg.APIKey, cfg.APISecret)
+	manager, err := newExoscaleManager(client, cfg, do.NodeGroupSpecs)
+	if err != nil {
+		klog.Fatalf("Failed to create Exoscale manager: %v", err)
+	}
+	if err := manager.refresh(); err != nil {
+		klog.Fatalf("Failed to load Exoscale instance pools: %v", err)
+	}
+	return &exoscaleCloudProvider{
+		manager:         manager,
+		resourceLimiter: rl,
+	}
+}
+
+// Name returns name of the cloud provider.
+func (exoscale *exoscaleCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (exoscale *exoscaleCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	result := make([]cloudprovider.NodeGroup, 0, len(exoscale.manager.nodeGroups))
+	for _, ng := range exoscale.manager.nodeGroups {
+		result = append(result, ng)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node.
+func (exoscale *exoscaleCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	ng := exoscale.manager.nodeGroupForProviderID(node.Spec.ProviderID)
+	if ng == nil {
+		return nil, nil
+	}
+	return ng, nil
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+func (exoscale *exoscaleCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
+func (exoscale *exoscaleCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition provided.
+func (exoscale *exoscaleCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (exoscale *exoscaleCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return exoscale.resourceLimiter, nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (exoscale *exoscaleCloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh reloads the instance pools and their anti-affinity groups.
+func (exoscale *exoscaleCloudProvider) Refresh() error {
+	return exoscale.manager.refresh()
+}
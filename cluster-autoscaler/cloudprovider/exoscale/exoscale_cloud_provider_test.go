@@ -0,0 +1,331 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExoscaleClient struct {
+	cluster            *sksCluster
+	pools              map[string]*instancePool
+	instanceTypes      map[string]*instanceType
+	antiAffinityGroups map[string]*antiAffinityGroup
+	scaled             map[string]int
+	evicted            map[string][]string
+	instanceTypeCalls  int
+}
+
+func (c *fakeExoscaleClient) getSKSCluster(id string) (*sksCluster, error) {
+	if c.cluster == nil || c.cluster.ID != id {
+		return nil, fmt.Errorf("SKS cluster %s not found", id)
+	}
+	cluster := *c.cluster
+	return &cluster, nil
+}
+
+func (c *fakeExoscaleClient) scaleSKSNodepool(clusterID, nodepoolID string, size int) error {
+	c.scaled[clusterID+"/"+nodepoolID] = size
+	return nil
+}
+
+func (c *fakeExoscaleClient) evictSKSNodepoolMembers(clusterID, nodepoolID string, instanceIDs []string) error {
+	c.evicted[clusterID+"/"+nodepoolID] = append(c.evicted[clusterID+"/"+nodepoolID], instanceIDs...)
+	return nil
+}
+
+func (c *fakeExoscaleClient) getInstancePool(id string) (*instancePool, error) {
+	pool, found := c.pools[id]
+	if !found {
+		return nil, fmt.Errorf("instance pool %s not found", id)
+	}
+	result := *pool
+	return &result, nil
+}
+
+func (c *fakeExoscaleClient) scaleInstancePool(id string, size int) error {
+	c.scaled[id] = size
+	return nil
+}
+
+func (c *fakeExoscaleClient) evictInstancePoolMembers(id string, instanceIDs []string) error {
+	c.evicted[id] = append(c.evicted[id], instanceIDs...)
+	return nil
+}
+
+func (c *fakeExoscaleClient) getInstanceType(id string) (*instanceType, error) {
+	c.instanceTypeCalls++
+	t, found := c.instanceTypes[id]
+	if !found {
+		return nil, fmt.Errorf("instance type %s not found", id)
+	}
+	return t, nil
+}
+
+func (c *fakeExoscaleClient) getAntiAffinityGroup(id string) (*antiAffinityGroup, error) {
+	group, found := c.antiAffinityGroups[id]
+	if !found {
+		return nil, fmt.Errorf("anti-affinity group %s not found", id)
+	}
+	return group, nil
+}
+
+func instanceRefs(ids ...string) []resourceRef {
+	refs := make([]resourceRef, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, resourceRef{ID: id})
+	}
+	return refs
+}
+
+func newFakeExoscaleClient() *fakeExoscaleClient {
+	return &fakeExoscaleClient{
+		cluster: &sksCluster{
+			ID: "cluster",
+			Nodepools: []sksNodepool{
+				{
+					ID:           "np-workers",
+					Name:         "workers",
+					InstancePool: resourceRef{ID: "pool-workers"},
+					Labels:       map[string]string{"team": "a"},
+					Taints:       map[string]sksNodepoolTaint{"dedicated": {Value: "a", Effect: "NoSchedule"}},
+				},
+				{
+					ID:           "np-gpu",
+					Name:         "gpu",
+					InstancePool: resourceRef{ID: "pool-gpu"},
+				},
+			},
+		},
+		pools: map[string]*instancePool{
+			"pool-workers": {
+				ID:                 "pool-workers",
+				Size:               2,
+				Instances:          instanceRefs("w-0", "w-1"),
+				InstanceType:       resourceRef{ID: "standard-medium"},
+				AntiAffinityGroups: instanceRefs("aag-a", "aag-b"),
+			},
+			"pool-gpu": {
+				ID:           "pool-gpu",
+				Size:         1,
+				Instances:    instanceRefs("g-0"),
+				InstanceType: resourceRef{ID: "gpu-small"},
+			},
+		},
+		instanceTypes: map[string]*instanceType{
+			"standard-medium": {ID: "standard-medium", Family: "standard", Size: "medium", Cpus: 2, Memory: 4 * 1024 * 1024 * 1024},
+			"gpu-small":       {ID: "gpu-small", Family: "gpu", Size: "small", Cpus: 12, Gpus: 1, Memory: 56 * 1024 * 1024 * 1024},
+		},
+		antiAffinityGroups: map[string]*antiAffinityGroup{
+			"aag-a": {ID: "aag-a", Instances: instanceRefs("w-0", "w-1")},
+			// Shared with 3 other instances, leaving room for 3 more.
+			"aag-b": {ID: "aag-b", Instances: instanceRefs("w-0", "w-1", "o-0", "o-1", "o-2")},
+		},
+		scaled:  make(map[string]int),
+		evicted: make(map[string][]string),
+	}
+}
+
+func buildTestProvider(t *testing.T, client *fakeExoscaleClient, cluster string, specs ...string) *exoscaleCloudProvider {
+	manager, err := newExoscaleManager(client, &CloudConfig{Zone: "ch-gva-2", SKSCluster: cluster}, specs)
+	assert.NoError(t, err)
+	provider := &exoscaleCloudProvider{manager: manager}
+	assert.NoError(t, provider.Refresh())
+	return provider
+}
+
+func nodeWithProviderID(name, providerID string) *apiv1.Node {
+	return &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: apiv1.NodeSpec{ProviderID: providerID}}
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := readConfig(strings.NewReader(`{"apiKey": "EXOkey", "apiSecret": "secret", "zone": "ch-gva-2"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api-ch-gva-2.exoscale.com/v2", cfg.APIEndpoint)
+
+	os.Setenv(apiKeyEnvVar, "EXOenv")
+	os.Setenv(apiSecretEnvVar, "env-secret")
+	os.Setenv(zoneEnvVar, "de-fra-1")
+	defer os.Unsetenv(apiKeyEnvVar)
+	defer os.Unsetenv(apiSecretEnvVar)
+	defer os.Unsetenv(zoneEnvVar)
+	cfg, err = readConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "EXOenv", cfg.APIKey)
+	assert.Equal(t, "env-secret", cfg.APISecret)
+	assert.Equal(t, "de-fra-1", cfg.Zone)
+	cfg, err = readConfig(strings.NewReader(`{"zone": "ch-gva-2", "apiEndpoint": "https://api.example.com/v2"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "ch-gva-2", cfg.Zone)
+	assert.Equal(t, "https://api.example.com/v2", cfg.APIEndpoint)
+
+	os.Unsetenv(apiSecretEnvVar)
+	_, err = readConfig(nil)
+	assert.Error(t, err)
+	os.Setenv(apiSecretEnvVar, "env-secret")
+	os.Unsetenv(zoneEnvVar)
+	_, err = readConfig(nil)
+	assert.Error(t, err)
+}
+
+func TestRefresh(t *testing.T) {
+	provider := buildTestProvider(t, newFakeExoscaleClient(), "cluster", "1:10:workers", "0:2:np-gpu")
+	nodeGroups := provider.NodeGroups()
+	assert.Equal(t, 2, len(nodeGroups))
+	assert.Equal(t, "workers", nodeGroups[0].Id())
+	assert.Equal(t, "np-gpu", nodeGroups[1].Id())
+	assert.True(t, nodeGroups[1].Exist())
+
+	manager, err := newExoscaleManager(newFakeExoscaleClient(), &CloudConfig{SKSCluster: "cluster"}, []string{"1:3:missing"})
+	assert.NoError(t, err)
+	assert.Error(t, manager.refresh())
+	_, err = newExoscaleManager(newFakeExoscaleClient(), &CloudConfig{}, []string{"workers"})
+	assert.Error(t, err)
+}
+
+func TestMaxSizeAntiAffinity(t *testing.T) {
+	client := newFakeExoscaleClient()
+	provider := buildTestProvider(t, client, "cluster", "1:10:workers", "0:2:gpu")
+	workers := provider.NodeGroups()[0]
+	gpuPool := provider.NodeGroups()[1]
+
+	// aag-b has room for 3 more instances.
+	assert.Equal(t, 5, workers.MaxSize())
+	assert.Equal(t, 2, gpuPool.MaxSize())
+
+	assert.EqualError(t, workers.IncreaseSize(4), "size increase too large - desired:6 max:5")
+	assert.NoError(t, workers.IncreaseSize(2))
+	assert.Equal(t, map[string]int{"cluster/np-workers": 4}, client.scaled)
+	assert.Equal(t, 5, workers.MaxSize())
+	assert.Error(t, workers.IncreaseSize(2))
+}
+
+func TestIncreaseSize(t *testing.T) {
+	client := newFakeExoscaleClient()
+	provider := buildTestProvider(t, client, "", "0:3:pool-gpu")
+	ng := provider.NodeGroups()[0]
+
+	assert.Error(t, ng.IncreaseSize(0))
+	assert.Error(t, ng.IncreaseSize(3))
+	assert.NoError(t, ng.IncreaseSize(2))
+	assert.Equal(t, map[string]int{"pool-gpu": 3}, client.scaled)
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider := buildTestProvider(t, newFakeExoscaleClient(), "cluster", "1:10:workers")
+
+	group, err := provider.NodeGroupForNode(nodeWithProviderID("w-1", "exoscale://w-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "workers", group.Id())
+
+	for _, providerID := range []string{"exoscale://g-0", "exoscale://unknown", "aws:///us-east-1a/w-1", ""} {
+		group, err = provider.NodeGroupForNode(nodeWithProviderID("node", providerID))
+		assert.NoError(t, err)
+		assert.Nil(t, group, providerID)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	provider := buildTestProvider(t, newFakeExoscaleClient(), "cluster", "1:10:workers")
+
+	instances, err := provider.NodeGroups()[0].Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{{Id: "exoscale://w-0"}, {Id: "exoscale://w-1"}}, instances)
+}
+
+func TestDeleteNodes(t *testing.T) {
+	client := newFakeExoscaleClient()
+	provider := buildTestProvider(t, client, "cluster", "1:10:workers", "0:2:gpu")
+	workers := provider.NodeGroups()[0]
+	gpuPool := provider.NodeGroups()[1]
+
+	assert.Error(t, workers.DeleteNodes([]*apiv1.Node{nodeWithProviderID("g-0", "exoscale://g-0")}))
+	assert.Error(t, workers.DeleteNodes([]*apiv1.Node{nodeWithProviderID("w-0", "exoscale://w-0"), nodeWithProviderID("w-1", "exoscale://w-1")}))
+	assert.Empty(t, client.evicted)
+
+	assert.NoError(t, workers.DeleteNodes([]*apiv1.Node{nodeWithProviderID("w-0", "exoscale://w-0")}))
+	assert.NoError(t, gpuPool.DeleteNodes([]*apiv1.Node{nodeWithProviderID("g-0", "exoscale://g-0")}))
+	assert.Equal(t, map[string][]string{"cluster/np-workers": {"w-0"}, "cluster/np-gpu": {"g-0"}}, client.evicted)
+	size, err := workers.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+	assert.Equal(t, 5, workers.MaxSize())
+	group, err := provider.NodeGroupForNode(nodeWithProviderID("w-0", "exoscale://w-0"))
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+
+	provider = buildTestProvider(t, client, "", "0:3:pool-gpu")
+	assert.NoError(t, provider.NodeGroups()[0].DeleteNodes([]*apiv1.Node{nodeWithProviderID("g-0", "exoscale://g-0")}))
+	assert.Equal(t, []string{"g-0"}, client.evicted["pool-gpu"])
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	client := newFakeExoscaleClient()
+	client.pools["pool-gpu"].Size = 3
+	provider := buildTestProvider(t, client, "", "0:3:pool-gpu")
+	ng := provider.NodeGroups()[0]
+
+	assert.Error(t, ng.DecreaseTargetSize(0))
+	assert.Error(t, ng.DecreaseTargetSize(-3))
+	assert.NoError(t, ng.DecreaseTargetSize(-2))
+	assert.Equal(t, map[string]int{"pool-gpu": 1}, client.scaled)
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+}
+
+func TestTemplateNodeInfo(t *testing.T) {
+	client := newFakeExoscaleClient()
+	provider := buildTestProvider(t, client, "cluster", "1:10:workers", "0:2:gpu")
+
+	nodeInfo, err := provider.NodeGroups()[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node := nodeInfo.Node()
+	cpu := node.Status.Allocatable[apiv1.ResourceCPU]
+	assert.Equal(t, int64(2), cpu.Value())
+	memory := node.Status.Allocatable[apiv1.ResourceMemory]
+	assert.Equal(t, int64(4*1024*1024*1024), memory.Value())
+	assert.Equal(t, "a", node.Labels["team"])
+	assert.Equal(t, "standard.medium", node.Labels[apiv1.LabelInstanceType])
+	assert.Equal(t, "ch-gva-2", node.Labels[apiv1.LabelZoneFailureDomain])
+	assert.Equal(t, cloudprovider.DefaultOS, node.Labels[apiv1.LabelOSStable])
+	assert.Equal(t, []apiv1.Taint{{Key: "dedicated", Value: "a", Effect: apiv1.TaintEffectNoSchedule}}, node.Spec.Taints)
+	assert.Equal(t, 1, len(nodeInfo.Pods()))
+
+	nodeInfo, err = provider.NodeGroups()[1].TemplateNodeInfo()
+	assert.NoError(t, err)
+	gpus := nodeInfo.Node().Status.Capacity[gpu.ResourceNvidiaGPU]
+	assert.Equal(t, int64(1), gpus.Value())
+
+	// Instance types are only fetched once.
+	_, err = provider.NodeGroups()[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.instanceTypeCalls)
+}
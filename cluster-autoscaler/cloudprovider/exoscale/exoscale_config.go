@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	apiKeyEnvVar    = "EXOSCALE_API_KEY"
+	apiSecretEnvVar = "EXOSCALE_API_SECRET"
+	zoneEnvVar      = "EXOSCALE_ZONE"
+)
+
+// CloudConfig is the Exoscale cloud provider configuration, read as JSON from --cloud-config.
+// API credentials are usually passed with the EXOSCALE_API_KEY and EXOSCALE_API_SECRET environment
+// variables, populated from a secret.
+type CloudConfig struct {
+	APIKey    string `json:"apiKey"`
+	APISecret string `json:"apiSecret"`
+	// Zone is the Exoscale zone of the node pools, e.g. ch-gva-2.
+	Zone string `json:"zone"`
+	// APIEndpoint overrides the API endpoint of the zone.
+	APIEndpoint string `json:"apiEndpoint"`
+	// SKSCluster is the ID of the SKS cluster whose node pools are scaled. Node groups are
+	// instance pool IDs if empty.
+	SKSCluster string `json:"sksCluster"`
+}
+
+func readConfig(configReader io.Reader) (*CloudConfig, error) {
+	cfg := &CloudConfig{}
+	if configReader != nil {
+		if err := json.NewDecoder(configReader).Decode(cfg); err != nil {
+			return nil, fmt.Errorf("exoscale: failed to decode cloud config: %v", err)
+		}
+	}
+	if key := os.Getenv(apiKeyEnvVar); key != "" {
+		cfg.APIKey = key
+	}
+	if secret := os.Getenv(apiSecretEnvVar); secret != "" {
+		cfg.APISecret = secret
+	}
+	if cfg.Zone == "" {
+		cfg.Zone = os.Getenv(zoneEnvVar)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.APIEndpoint == "" {
+		cfg.APIEndpoint = fmt.Sprintf("https://api-%s.exoscale.com/v2", cfg.Zone)
+	}
+	return cfg, nil
+}
+
+func (cc *CloudConfig) validate() error {
+	if cc.APIKey == "" || cc.APISecret == "" {
+		return fmt.Errorf("exoscale: API credentials must be set with %s and %s", apiKeyEnvVar, apiSecretEnvVar)
+	}
+	if cc.Zone == "" {
+		return fmt.Errorf("exoscale: zone must be set in the cloud config or with %s", zoneEnvVar)
+	}
+	return nil
+}
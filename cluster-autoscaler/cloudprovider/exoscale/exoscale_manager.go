@@ -0,0 +1,234 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+)
+
+const (
+	// antiAffinityGroupMaxInstances is the maximum number of instances of an anti-affinity group.
+	antiAffinityGroupMaxInstances = 8
+
+	providerIDPrefix = "exoscale://"
+)
+
+// exoscaleManager resolves node groups to instance pools and caches their state between refreshes.
+type exoscaleManager struct {
+	client     exoscaleClient
+	zone       string
+	sksCluster string
+	nodeGroups []*exoscaleNodeGroup
+
+	cacheMutex    sync.Mutex
+	instanceTypes map[string]*instanceType
+	// instanceNodeGroups is the node group of every cached instance, by instance ID.
+	instanceNodeGroups map[string]*exoscaleNodeGroup
+}
+
+func newExoscaleManager(client exoscaleClient, cfg *CloudConfig, specs []string) (*exoscaleManager, error) {
+	m := &exoscaleManager{
+		client:             client,
+		zone:               cfg.Zone,
+		sksCluster:         cfg.SKSCluster,
+		instanceTypes:      make(map[string]*instanceType),
+		instanceNodeGroups: make(map[string]*exoscaleNodeGroup),
+	}
+	for _, value := range specs {
+		spec, err := dynamic.SpecFromString(value, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node group spec: %v", err)
+		}
+		m.nodeGroups = append(m.nodeGroups, &exoscaleNodeGroup{
+			manager: m,
+			id:      spec.Name,
+			minSize: spec.MinSize,
+			maxSize: spec.MaxSize,
+		})
+	}
+	return m, nil
+}
+
+// refresh resolves the node groups to SKS node pools and instance pools and reloads them.
+func (m *exoscaleManager) refresh() error {
+	var nodepools []sksNodepool
+	if m.sksCluster != "" {
+		cluster, err := m.client.getSKSCluster(m.sksCluster)
+		if err != nil {
+			return fmt.Errorf("failed to get SKS cluster %s: %v", m.sksCluster, err)
+		}
+		nodepools = cluster.Nodepools
+	}
+
+	instanceNodeGroups := make(map[string]*exoscaleNodeGroup)
+	for _, ng := range m.nodeGroups {
+		var nodepool *sksNodepool
+		for i := range nodepools {
+			if nodepools[i].ID == ng.id || nodepools[i].Name == ng.id {
+				nodepool = &nodepools[i]
+				break
+			}
+		}
+		if m.sksCluster != "" && nodepool == nil {
+			return fmt.Errorf("node pool %s not found in SKS cluster %s", ng.id, m.sksCluster)
+		}
+		instancePoolID := ng.id
+		if nodepool != nil {
+			instancePoolID = nodepool.InstancePool.ID
+		}
+		pool, err := m.client.getInstancePool(instancePoolID)
+		if err != nil {
+			return fmt.Errorf("failed to get instance pool %s: %v", instancePoolID, err)
+		}
+		freeSlots, err := m.antiAffinityFreeSlots(pool.AntiAffinityGroups)
+		if err != nil {
+			return err
+		}
+		for _, instance := range pool.Instances {
+			instanceNodeGroups[instance.ID] = ng
+		}
+
+		m.cacheMutex.Lock()
+		ng.nodepool = nodepool
+		ng.instancePool = pool
+		ng.antiAffinityFreeSlots = freeSlots
+		m.cacheMutex.Unlock()
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	m.instanceNodeGroups = instanceNodeGroups
+	return nil
+}
+
+// antiAffinityFreeSlots returns how many instances can be added to all of the anti-affinity
+// groups, or -1 if there are no groups.
+func (m *exoscaleManager) antiAffinityFreeSlots(groups []resourceRef) (int, error) {
+	free := -1
+	for _, ref := range groups {
+		group, err := m.client.getAntiAffinityGroup(ref.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get anti-affinity group %s: %v", ref.ID, err)
+		}
+		groupFree := antiAffinityGroupMaxInstances - len(group.Instances)
+		if groupFree < 0 {
+			groupFree = 0
+		}
+		if free < 0 || groupFree < free {
+			free = groupFree
+		}
+	}
+	return free, nil
+}
+
+// instanceType returns the instance type, fetching it the first time it's used.
+func (m *exoscaleManager) instanceType(id string) (*instanceType, error) {
+	m.cacheMutex.Lock()
+	cached, found := m.instanceTypes[id]
+	m.cacheMutex.Unlock()
+	if found {
+		return cached, nil
+	}
+	t, err := m.client.getInstanceType(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance type %s: %v", id, err)
+	}
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	m.instanceTypes[id] = t
+	return t, nil
+}
+
+// nodeGroupForProviderID returns the node group of the instance with the given provider id.
+func (m *exoscaleManager) nodeGroupForProviderID(providerID string) *exoscaleNodeGroup {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	return m.instanceNodeGroups[instanceIDFromProviderID(providerID)]
+}
+
+// scale sets the size of the node group through its SKS node pool or its instance pool.
+func (m *exoscaleManager) scale(ng *exoscaleNodeGroup, size int) error {
+	m.cacheMutex.Lock()
+	nodepool, pool := ng.nodepool, ng.instancePool
+	m.cacheMutex.Unlock()
+
+	var err error
+	if nodepool != nil {
+		err = m.client.scaleSKSNodepool(m.sksCluster, nodepool.ID, size)
+	} else {
+		err = m.client.scaleInstancePool(pool.ID, size)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scale node group %s to %d: %v", ng.id, size, err)
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	if ng.antiAffinityFreeSlots >= 0 && size > pool.Size {
+		ng.antiAffinityFreeSlots -= size - pool.Size
+	}
+	pool.Size = size
+	return nil
+}
+
+// evict removes the instances from the node group, shrinking it.
+func (m *exoscaleManager) evict(ng *exoscaleNodeGroup, instanceIDs []string) error {
+	m.cacheMutex.Lock()
+	nodepool, pool := ng.nodepool, ng.instancePool
+	m.cacheMutex.Unlock()
+
+	var err error
+	if nodepool != nil {
+		err = m.client.evictSKSNodepoolMembers(m.sksCluster, nodepool.ID, instanceIDs)
+	} else {
+		err = m.client.evictInstancePoolMembers(pool.ID, instanceIDs)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to evict instances %v from node group %s: %v", instanceIDs, ng.id, err)
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	evicted := make(map[string]bool, len(instanceIDs))
+	for _, id := range instanceIDs {
+		evicted[id] = true
+		delete(m.instanceNodeGroups, id)
+	}
+	instances := make([]resourceRef, 0, len(pool.Instances))
+	for _, instance := range pool.Instances {
+		if !evicted[instance.ID] {
+			instances = append(instances, instance)
+		}
+	}
+	pool.Instances = instances
+	pool.Size -= len(instanceIDs)
+	if ng.antiAffinityFreeSlots >= 0 {
+		ng.antiAffinityFreeSlots += len(instanceIDs)
+	}
+	return nil
+}
+
+func instanceIDFromProviderID(providerID string) string {
+	if !strings.HasPrefix(providerID, providerIDPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(providerID, providerIDPrefix)
+}
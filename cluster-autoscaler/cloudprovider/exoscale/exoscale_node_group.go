@@ -0,0 +1,244 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exoscale
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const defaultPodsPerNode = 110
+
+// exoscaleNodeGroup implements NodeGroup for an instance pool, directly or through the SKS node
+// pool backed by it.
+type exoscaleNodeGroup struct {
+	manager *exoscaleManager
+	// id is the SKS node pool ID or name, or the instance pool ID.
+	id      string
+	minSize int
+	maxSize int
+
+	// Guarded by the manager cache mutex.
+	nodepool     *sksNodepool
+	instancePool *instancePool
+	// antiAffinityFreeSlots is how many instances the anti-affinity groups of the pool can still
+	// take, or -1 if the pool isn't in any anti-affinity group.
+	antiAffinityFreeSlots int
+}
+
+var _ cloudprovider.NodeGroup = (*exoscaleNodeGroup)(nil)
+
+// MaxSize returns maximum size of the node group. Instances of a pool in anti-affinity groups run on
+// different hypervisors, so the pool can't grow beyond the room left in its groups.
+func (ng *exoscaleNodeGroup) MaxSize() int {
+	ng.manager.cacheMutex.Lock()
+	defer ng.manager.cacheMutex.Unlock()
+	if ng.instancePool == nil || ng.antiAffinityFreeSlots < 0 {
+		return ng.maxSize
+	}
+	if limit := ng.instancePool.Size + ng.antiAffinityFreeSlots; limit < ng.maxSize {
+		return limit
+	}
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *exoscaleNodeGroup) MinSize() int {
+	return ng.minSize
+}
+
+// TargetSize returns the current target size of the node group.
+func (ng *exoscaleNodeGroup) TargetSize() (int, error) {
+	pool := ng.pool()
+	if pool == nil {
+		return 0, fmt.Errorf("instance pool of node group %s not found", ng.id)
+	}
+	return pool.Size, nil
+}
+
+// IncreaseSize scales up the instance pool. The new instances join the anti-affinity groups of the
+// pool.
+func (ng *exoscaleNodeGroup) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	size, err := ng.TargetSize()
+	if err != nil {
+		return err
+	}
+	if size+delta > ng.MaxSize() {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.MaxSize())
+	}
+	return ng.manager.scale(ng, size+delta)
+}
+
+// DeleteNodes evicts the given nodes from the instance pool.
+func (ng *exoscaleNodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	size, err := ng.TargetSize()
+	if err != nil {
+		return err
+	}
+	if size-len(nodes) < ng.MinSize() {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", size-len(nodes), ng.MinSize())
+	}
+	instanceIDs := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if ng.manager.nodeGroupForProviderID(node.Spec.ProviderID) != ng {
+			return fmt.Errorf("node %s doesn't belong to node group %s", node.Name, ng.id)
+		}
+		instanceIDs = append(instanceIDs, instanceIDFromProviderID(node.Spec.ProviderID))
+	}
+	return ng.manager.evict(ng, instanceIDs)
+}
+
+// DecreaseTargetSize decreases the size of the instance pool without evicting existing instances.
+func (ng *exoscaleNodeGroup) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	pool := ng.pool()
+	if pool == nil {
+		return fmt.Errorf("instance pool of node group %s not found", ng.id)
+	}
+	if pool.Size+delta < len(pool.Instances) {
+		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
+			pool.Size, delta, len(pool.Instances))
+	}
+	return ng.manager.scale(ng, pool.Size+delta)
+}
+
+// Id returns the node group ID.
+func (ng *exoscaleNodeGroup) Id() string {
+	return ng.id
+}
+
+// Debug returns a debug string for the node group.
+func (ng *exoscaleNodeGroup) Debug() string {
+	return fmt.Sprintf("%s (%d:%d)", ng.Id(), ng.MinSize(), ng.MaxSize())
+}
+
+// Nodes returns the instances of the pool.
+func (ng *exoscaleNodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	pool := ng.pool()
+	if pool == nil {
+		return []cloudprovider.Instance{}, nil
+	}
+	instances := make([]cloudprovider.Instance, 0, len(pool.Instances))
+	for _, instance := range pool.Instances {
+		instances = append(instances, cloudprovider.Instance{Id: providerIDPrefix + instance.ID})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template built from the instance type of the pool and the labels
+// and taints of its SKS node pool.
+func (ng *exoscaleNodeGroup) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	ng.manager.cacheMutex.Lock()
+	nodepool := ng.nodepool
+	ng.manager.cacheMutex.Unlock()
+	pool := ng.pool()
+	if pool == nil {
+		return nil, fmt.Errorf("instance pool of node group %s not found", ng.id)
+	}
+	instanceType, err := ng.manager.instanceType(pool.InstanceType.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-template", ng.id)
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: make(map[string]string),
+		},
+		Spec: apiv1.NodeSpec{
+			ProviderID: providerIDPrefix + name,
+		},
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    *resource.NewQuantity(instanceType.Cpus, resource.DecimalSI),
+				apiv1.ResourceMemory: *resource.NewQuantity(instanceType.Memory, resource.BinarySI),
+				apiv1.ResourcePods:   *resource.NewQuantity(defaultPodsPerNode, resource.DecimalSI),
+			},
+			Conditions: cloudprovider.BuildReadyConditions(),
+		},
+	}
+	if instanceType.Gpus > 0 {
+		node.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(instanceType.Gpus, resource.DecimalSI)
+	}
+	node.Status.Allocatable = node.Status.Capacity
+
+	if nodepool != nil {
+		for key, value := range nodepool.Labels {
+			node.Labels[key] = value
+		}
+		for key, taint := range nodepool.Taints {
+			node.Spec.Taints = append(node.Spec.Taints, apiv1.Taint{
+				Key:    key,
+				Value:  taint.Value,
+				Effect: apiv1.TaintEffect(taint.Effect),
+			})
+		}
+	}
+	node.Labels[apiv1.LabelHostname] = name
+	node.Labels[apiv1.LabelOSStable] = cloudprovider.DefaultOS
+	node.Labels[apiv1.LabelArchStable] = cloudprovider.DefaultArch
+	node.Labels[apiv1.LabelInstanceType] = fmt.Sprintf("%s.%s", instanceType.Family, instanceType.Size)
+	node.Labels[apiv1.LabelZoneRegion] = ng.manager.zone
+	node.Labels[apiv1.LabelZoneFailureDomain] = ng.manager.zone
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.id))
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (ng *exoscaleNodeGroup) Exist() bool {
+	return ng.pool() != nil
+}
+
+// Create creates the node group on the cloud provider side.
+func (ng *exoscaleNodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side.
+func (ng *exoscaleNodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *exoscaleNodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// pool returns a copy of the cached instance pool, or nil if it hasn't been loaded.
+func (ng *exoscaleNodeGroup) pool() *instancePool {
+	ng.manager.cacheMutex.Lock()
+	defer ng.manager.cacheMutex.Unlock()
+	if ng.instancePool == nil {
+		return nil
+	}
+	pool := *ng.instancePool
+	return &pool
+}
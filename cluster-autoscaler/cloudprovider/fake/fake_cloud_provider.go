@@ -0,0 +1,280 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for the fake cloud provider.
+	ProviderName = "fake"
+
+	providerIDPrefix = "fake://"
+)
+
+// CloudProvider is a fake cloud provider meant for testing the autoscaler without a real cloud.
+// Node groups, latencies and failures are configurable and instances only exist in memory.
+// Instance state only changes in Refresh, which makes the provider deterministic.
+type CloudProvider struct {
+	sync.Mutex
+	nodeGroups      []*NodeGroup
+	resourceLimiter *cloudprovider.ResourceLimiter
+	// kubeClient is used to register nodes for running instances, nil if disabled.
+	kubeClient kube_client.Interface
+	now        func() time.Time
+}
+
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+var _ cloudprovider.InstanceLookupCloudProvider = (*CloudProvider)(nil)
+
+// NewCloudProvider builds a fake cloud provider from the given configuration. If kubeClient is not nil,
+// a Node object is registered for every instance once it is provisioned.
+func NewCloudProvider(cfg *Config, kubeClient kube_client.Interface, rl *cloudprovider.ResourceLimiter) (*CloudProvider, error) {
+	provider := &CloudProvider{
+		nodeGroups:      make([]*NodeGroup, 0, len(cfg.NodeGroups)),
+		resourceLimiter: rl,
+		kubeClient:      kubeClient,
+		now:             time.Now,
+	}
+	for _, ngConfig := range cfg.NodeGroups {
+		ng, err := buildNodeGroup(provider, ngConfig)
+		if err != nil {
+			return nil, fmt.Errorf("node group %s: %v", ngConfig.Name, err)
+		}
+		provider.nodeGroups = append(provider.nodeGroups, ng)
+	}
+	return provider, nil
+}
+
+// BuildFake builds the fake cloud provider from the JSON configuration in --cloud-config.
+func BuildFake(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	if opts.CloudConfig == "" {
+		klog.Fatalf("The fake cloud provider requires a configuration file passed with --cloud-config")
+	}
+	configFile, err := os.Open(opts.CloudConfig)
+	if err != nil {
+		klog.Fatalf("Couldn't open cloud provider configuration %s: %#v", opts.CloudConfig, err)
+	}
+	defer configFile.Close()
+	cfg, err := ReadConfig(configFile)
+	if err != nil {
+		klog.Fatalf("Failed to read fake cloud provider configuration: %v", err)
+	}
+
+	var kubeClient kube_client.Interface
+	if cfg.RegisterNodes {
+		kubeConfig, err := clientcmd.BuildConfigFromFlags("", opts.KubeConfigPath)
+		if err != nil {
+			klog.Fatalf("Failed to build kube client config: %v", err)
+		}
+		kubeClient = kube_client.NewForConfigOrDie(kubeConfig)
+	}
+
+	provider, err := NewCloudProvider(cfg, kubeClient, rl)
+	if err != nil {
+		klog.Fatalf("Failed to create fake cloud provider: %v", err)
+	}
+	return provider
+}
+
+// Name returns name of the cloud provider.
+func (provider *CloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (provider *CloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	result := make([]cloudprovider.NodeGroup, 0, len(provider.nodeGroups))
+	for _, ng := range provider.nodeGroups {
+		result = append(result, ng)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node.
+func (provider *CloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if !strings.HasPrefix(node.Spec.ProviderID, providerIDPrefix) {
+		return nil, nil
+	}
+	groupID, _, err := parseProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if ng := provider.nodeGroup(groupID); ng != nil {
+		return ng, nil
+	}
+	return nil, nil
+}
+
+// HasInstance returns false if the instance of the given node doesn't exist in its node group.
+// ErrNotImplemented is returned for nodes that weren't created by the fake cloud provider.
+func (provider *CloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	if !strings.HasPrefix(node.Spec.ProviderID, providerIDPrefix) {
+		return false, cloudprovider.ErrNotImplemented
+	}
+	groupID, instanceName, err := parseProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return false, err
+	}
+	ng := provider.nodeGroup(groupID)
+	if ng == nil {
+		return false, nil
+	}
+
+	provider.Lock()
+	defer provider.Unlock()
+	_, found := ng.instances[instanceName]
+	return found, nil
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+func (provider *CloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
+func (provider *CloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition provided.
+func (provider *CloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (provider *CloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return provider.resourceLimiter, nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (provider *CloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh moves instances that were provisioned for long enough to the running state, registering
+// Node objects for them if enabled.
+func (provider *CloudProvider) Refresh() error {
+	provider.Lock()
+	defer provider.Unlock()
+
+	now := provider.now()
+	for _, ng := range provider.nodeGroups {
+		for _, inst := range ng.instances {
+			if inst.state != cloudprovider.InstanceCreating || inst.errorInfo != nil {
+				continue
+			}
+			if inst.createTime.Add(ng.provisionTime).After(now) {
+				continue
+			}
+			if err := provider.registerNode(ng, inst); err != nil {
+				return err
+			}
+			inst.state = cloudprovider.InstanceRunning
+		}
+	}
+	return nil
+}
+
+// InjectFailure injects a failure into the given node group's operations at runtime.
+func (provider *CloudProvider) InjectFailure(nodeGroup string, failure FailureConfig) error {
+	if err := validateFailure(failure); err != nil {
+		return err
+	}
+	provider.Lock()
+	defer provider.Unlock()
+	ng := provider.nodeGroup(nodeGroup)
+	if ng == nil {
+		return fmt.Errorf("node group %s not found", nodeGroup)
+	}
+	ng.injectFailure(failure)
+	return nil
+}
+
+// SetClock overrides the source of the current time, used to decide when instances are provisioned.
+func (provider *CloudProvider) SetClock(now func() time.Time) {
+	provider.Lock()
+	defer provider.Unlock()
+	provider.now = now
+}
+
+func (provider *CloudProvider) nodeGroup(id string) *NodeGroup {
+	for _, ng := range provider.nodeGroups {
+		if ng.id == id {
+			return ng
+		}
+	}
+	return nil
+}
+
+func (provider *CloudProvider) registerNode(ng *NodeGroup, inst *instance) error {
+	if provider.kubeClient == nil {
+		return nil
+	}
+	node, err := buildTemplateNode(inst.name, ng.template)
+	if err != nil {
+		return err
+	}
+	node.Spec.ProviderID = providerID(ng.id, inst.name)
+	now := metav1.NewTime(provider.now())
+	for i := range node.Status.Conditions {
+		node.Status.Conditions[i].LastHeartbeatTime = now
+		node.Status.Conditions[i].LastTransitionTime = now
+	}
+	if _, err := provider.kubeClient.CoreV1().Nodes().Create(node); err != nil && !kube_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to register node %s: %v", node.Name, err)
+	}
+	return nil
+}
+
+func (provider *CloudProvider) unregisterNode(inst *instance) {
+	if provider.kubeClient == nil || inst.state != cloudprovider.InstanceRunning {
+		return
+	}
+	if err := provider.kubeClient.CoreV1().Nodes().Delete(inst.name, &metav1.DeleteOptions{}); err != nil && !kube_errors.IsNotFound(err) {
+		klog.Warningf("Failed to unregister node %s: %v", inst.name, err)
+	}
+}
+
+func providerID(nodeGroup, instance string) string {
+	return fmt.Sprintf("%s%s/%s", providerIDPrefix, nodeGroup, instance)
+}
+
+func parseProviderID(id string) (string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(id, providerIDPrefix), "/")
+	if !strings.HasPrefix(id, providerIDPrefix) || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid fake provider id %q", id)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	fake_client "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `{
+  "nodeGroups": [
+    {
+      "name": "ng1",
+      "minSize": 1,
+      "maxSize": 5,
+      "targetSize": 1,
+      "provisionTime": "1m",
+      "template": {"cpu": "2", "memory": "4Gi", "labels": {"pool": "ng1"}},
+      "failures": [{"operation": "IncreaseSize", "count": 1, "message": "quota exceeded"}]
+    },
+    {
+      "name": "ng2",
+      "maxSize": 3
+    }
+  ]
+}`
+
+func buildTestProvider(t *testing.T, config string, registerNodes bool) (*CloudProvider, *fake_client.Clientset, *time.Time) {
+	cfg, err := ReadConfig(strings.NewReader(config))
+	assert.NoError(t, err)
+	client := fake_client.NewSimpleClientset()
+	var provider *CloudProvider
+	if registerNodes {
+		provider, err = NewCloudProvider(cfg, client, nil)
+	} else {
+		provider, err = NewCloudProvider(cfg, nil, nil)
+	}
+	assert.NoError(t, err)
+	now := time.Now()
+	provider.SetClock(func() time.Time { return now })
+	return provider, client, &now
+}
+
+func instanceStates(t *testing.T, ng cloudprovider.NodeGroup) map[cloudprovider.InstanceState]int {
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	result := make(map[cloudprovider.InstanceState]int)
+	for _, instance := range instances {
+		result[instance.Status.State]++
+	}
+	return result
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := ReadConfig(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(cfg.NodeGroups))
+	assert.Equal(t, "1m", cfg.NodeGroups[0].ProvisionTime)
+	assert.Equal(t, IncreaseSizeOperation, cfg.NodeGroups[0].Failures[0].Operation)
+
+	for _, invalid := range []string{
+		`{"nodeGroups": [{"name": "", "maxSize": 1}]}`,
+		`{"nodeGroups": [{"name": "a", "maxSize": 1}, {"name": "a", "maxSize": 1}]}`,
+		`{"nodeGroups": [{"name": "a", "minSize": 2, "maxSize": 1}]}`,
+		`{"nodeGroups": [{"name": "a", "maxSize": 1, "targetSize": 2}]}`,
+		`{"nodeGroups": [{"name": "a", "maxSize": 1, "provisionTime": "soon"}]}`,
+		`{"nodeGroups": [{"name": "a", "maxSize": 1, "template": {"cpu": "lots"}}]}`,
+		`{"nodeGroups": [{"name": "a", "maxSize": 1, "failures": [{"operation": "Reboot"}]}]}`,
+		`{"nodeGroups": [{"name": "a", "maxSize": 1, "failures": [{"operation": "IncreaseSize", "count": -1}]}]}`,
+		`{"nodeGroups": `,
+	} {
+		_, err := ReadConfig(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestIncreaseSizeWithFailures(t *testing.T) {
+	provider, _, _ := buildTestProvider(t, testConfig, false)
+	ng := provider.NodeGroups()[0]
+
+	err := ng.IncreaseSize(1)
+	assert.EqualError(t, err, "quota exceeded")
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	assert.NoError(t, ng.IncreaseSize(2))
+	size, err = ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+	assert.Error(t, ng.IncreaseSize(3))
+
+	assert.NoError(t, provider.InjectFailure("ng1", FailureConfig{Operation: IncreaseSizeOperation}))
+	assert.Error(t, ng.IncreaseSize(1))
+	assert.Error(t, ng.IncreaseSize(1))
+	assert.Error(t, provider.InjectFailure("missing", FailureConfig{Operation: IncreaseSizeOperation}))
+	assert.Error(t, provider.InjectFailure("ng1", FailureConfig{Operation: "Reboot"}))
+}
+
+func TestInstanceCreationFailure(t *testing.T) {
+	provider, _, now := buildTestProvider(t, testConfig, false)
+	ng := provider.NodeGroups()[1]
+	assert.NoError(t, provider.InjectFailure("ng2", FailureConfig{
+		Operation:      InstanceCreationOperation,
+		Count:          1,
+		OutOfResources: true,
+		ErrorCode:      "STOCKOUT",
+	}))
+
+	assert.NoError(t, ng.IncreaseSize(2))
+	*now = now.Add(time.Minute)
+	assert.NoError(t, provider.Refresh())
+
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(instances))
+	failed := instances[0]
+	assert.Equal(t, cloudprovider.InstanceCreating, failed.Status.State)
+	assert.Equal(t, cloudprovider.OutOfResourcesErrorClass, failed.Status.ErrorInfo.ErrorClass)
+	assert.Equal(t, "STOCKOUT", failed.Status.ErrorInfo.ErrorCode)
+	assert.Equal(t, cloudprovider.InstanceRunning, instances[1].Status.State)
+	assert.Nil(t, instances[1].Status.ErrorInfo)
+
+	// Failed instances are cleaned up the same way the core does it.
+	assert.NoError(t, ng.DeleteNodes([]*apiv1.Node{{Spec: apiv1.NodeSpec{ProviderID: failed.Id}}}))
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+}
+
+func TestRefreshRegistersNodes(t *testing.T) {
+	provider, client, now := buildTestProvider(t, testConfig, true)
+	ng := provider.NodeGroups()[0]
+
+	// The initial instance is already provisioned.
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, map[cloudprovider.InstanceState]int{cloudprovider.InstanceRunning: 1}, instanceStates(t, ng))
+
+	assert.Error(t, ng.IncreaseSize(1))
+	assert.NoError(t, ng.IncreaseSize(1))
+	*now = now.Add(30 * time.Second)
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, map[cloudprovider.InstanceState]int{
+		cloudprovider.InstanceRunning:  1,
+		cloudprovider.InstanceCreating: 1,
+	}, instanceStates(t, ng))
+
+	*now = now.Add(30 * time.Second)
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, map[cloudprovider.InstanceState]int{cloudprovider.InstanceRunning: 2}, instanceStates(t, ng))
+
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(nodes.Items))
+	node, err := client.CoreV1().Nodes().Get("ng1-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1", node.Labels["pool"])
+	assert.Equal(t, "fake://ng1/ng1-1", node.Spec.ProviderID)
+	cpu := node.Status.Capacity[apiv1.ResourceCPU]
+	assert.Equal(t, int64(2), cpu.Value())
+
+	group, err := provider.NodeGroupForNode(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1", group.Id())
+
+	assert.NoError(t, ng.DeleteNodes([]*apiv1.Node{node}))
+	nodes, err = client.CoreV1().Nodes().List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nodes.Items))
+	assert.Equal(t, "ng1-0", nodes.Items[0].Name)
+
+	// The node group can't go below its minimum size.
+	assert.Error(t, ng.DeleteNodes([]*apiv1.Node{&nodes.Items[0]}))
+}
+
+func TestDeleteNodesWithFailures(t *testing.T) {
+	provider, _, _ := buildTestProvider(t, testConfig, false)
+	ng := provider.NodeGroups()[1]
+	assert.NoError(t, ng.IncreaseSize(2))
+	assert.NoError(t, provider.InjectFailure("ng2", FailureConfig{Operation: DeleteNodesOperation, Count: 1}))
+
+	node := &apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: providerID("ng2", "ng2-0")}}
+	assert.EqualError(t, ng.DeleteNodes([]*apiv1.Node{node}), "injected DeleteNodes failure")
+	assert.NoError(t, ng.DeleteNodes([]*apiv1.Node{node}))
+	assert.Error(t, ng.DeleteNodes([]*apiv1.Node{node}))
+
+	other := &apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: providerID("ng1", "ng1-0")}}
+	assert.Error(t, ng.DeleteNodes([]*apiv1.Node{other}))
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	provider, _, now := buildTestProvider(t, testConfig, false)
+	ng := provider.NodeGroups()[1]
+	assert.NoError(t, ng.IncreaseSize(1))
+	*now = now.Add(time.Second)
+	assert.NoError(t, ng.IncreaseSize(1))
+
+	assert.Error(t, ng.DecreaseTargetSize(0))
+	assert.Error(t, ng.DecreaseTargetSize(-3))
+	assert.NoError(t, provider.InjectFailure("ng2", FailureConfig{Operation: DecreaseTargetSizeOperation, Count: 1}))
+	assert.Error(t, ng.DecreaseTargetSize(-1))
+
+	assert.NoError(t, ng.DecreaseTargetSize(-1))
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(instances))
+	assert.Equal(t, "fake://ng2/ng2-0", instances[0].Id)
+
+	assert.NoError(t, provider.Refresh())
+	assert.Error(t, ng.DecreaseTargetSize(-1))
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider, _, _ := buildTestProvider(t, testConfig, false)
+
+	group, err := provider.NodeGroupForNode(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "fake://ng2/ng2-7"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ng2", group.Id())
+
+	group, err = provider.NodeGroupForNode(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "fake://missing/node"}})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+
+	group, err = provider.NodeGroupForNode(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "gce://project/zone/node"}})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+
+	_, err = provider.NodeGroupForNode(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "fake://ng2"}})
+	assert.Error(t, err)
+}
+
+func TestTemplateNodeInfo(t *testing.T) {
+	provider, _, _ := buildTestProvider(t, testConfig, false)
+
+	nodeInfo, err := provider.NodeGroups()[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node := nodeInfo.Node()
+	assert.Equal(t, "ng1", node.Labels["pool"])
+	memory := node.Status.Allocatable[apiv1.ResourceMemory]
+	assert.Equal(t, int64(4*1024*1024*1024), memory.Value())
+	assert.Equal(t, 1, len(nodeInfo.Pods()))
+
+	nodeInfo, err = provider.NodeGroups()[1].TemplateNodeInfo()
+	assert.NoError(t, err)
+	cpu := nodeInfo.Node().Status.Capacity[apiv1.ResourceCPU]
+	assert.Equal(t, int64(1), cpu.Value())
+}
+
+func TestHasInstance(t *testing.T) {
+	provider, _, _ := buildTestProvider(t, testConfig, false)
+
+	exists, err := provider.HasInstance(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "fake://ng1/ng1-0"}})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = provider.HasInstance(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "fake://ng2/ng2-0"}})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = provider.HasInstance(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "fake://missing/node"}})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = provider.HasInstance(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "gce://project/zone/node"}})
+	assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+}
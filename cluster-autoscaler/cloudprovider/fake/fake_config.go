@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Operation is a node group operation a failure can be injected into.
+type Operation string
+
+const (
+	// IncreaseSizeOperation fails NodeGroup.IncreaseSize calls.
+	IncreaseSizeOperation Operation = "IncreaseSize"
+	// DeleteNodesOperation fails NodeGroup.DeleteNodes calls.
+	DeleteNodesOperation Operation = "DeleteNodes"
+	// DecreaseTargetSizeOperation fails NodeGroup.DecreaseTargetSize calls.
+	DecreaseTargetSizeOperation Operation = "DecreaseTargetSize"
+	// InstanceCreationOperation makes new instances fail to start. They are reported by
+	// NodeGroup.Nodes with an error until they are deleted.
+	InstanceCreationOperation Operation = "InstanceCreation"
+)
+
+// Config is the configuration of the fake cloud provider, read as JSON from --cloud-config.
+type Config struct {
+	// NodeGroups lists the node groups of the fake cloud.
+	NodeGroups []NodeGroupConfig `json:"nodeGroups"`
+	// RegisterNodes makes the provider create Node objects in the cluster once instances are
+	// provisioned and delete them together with the instances.
+	RegisterNodes bool `json:"registerNodes"`
+}
+
+// NodeGroupConfig describes a single fake node group.
+type NodeGroupConfig struct {
+	Name       string `json:"name"`
+	MinSize    int    `json:"minSize"`
+	MaxSize    int    `json:"maxSize"`
+	TargetSize int    `json:"targetSize"`
+	// Template describes the nodes of the node group.
+	Template TemplateConfig `json:"template"`
+	// ProvisionTime is how long new instances take to start, e.g. "1m".
+	ProvisionTime string `json:"provisionTime,omitempty"`
+	// IncreaseSizeLatency and DeleteNodesLatency delay the corresponding calls, e.g. "5s".
+	IncreaseSizeLatency string `json:"increaseSizeLatency,omitempty"`
+	DeleteNodesLatency  string `json:"deleteNodesLatency,omitempty"`
+	// Failures are injected into node group operations in order.
+	Failures []FailureConfig `json:"failures,omitempty"`
+}
+
+// TemplateConfig describes the capacity, labels and taints of template nodes.
+type TemplateConfig struct {
+	CPU    string            `json:"cpu"`
+	Memory string            `json:"memory"`
+	GPU    int64             `json:"gpu,omitempty"`
+	Pods   int64             `json:"pods,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Taints []apiv1.Taint     `json:"taints,omitempty"`
+}
+
+// FailureConfig describes a failure injected into a node group operation.
+type FailureConfig struct {
+	Operation Operation `json:"operation"`
+	// Count is how many consecutive calls fail, 0 means all of them.
+	Count int `json:"count,omitempty"`
+	// Message is the error message returned by the failing call.
+	Message string `json:"message,omitempty"`
+	// OutOfResources marks instance creation failures as stockouts or quota errors rather than
+	// generic errors. Only used with InstanceCreationOperation.
+	OutOfResources bool `json:"outOfResources,omitempty"`
+	// ErrorCode is the error code reported for failed instances. Only used with InstanceCreationOperation.
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// ReadConfig reads and validates the fake cloud provider configuration.
+func ReadConfig(config io.Reader) (*Config, error) {
+	cfg := &Config{}
+	if err := json.NewDecoder(config).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode fake cloud provider config: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, ng := range cfg.NodeGroups {
+		if ng.Name == "" {
+			return nil, fmt.Errorf("node group name must not be empty")
+		}
+		if seen[ng.Name] {
+			return nil, fmt.Errorf("node group %s is defined more than once", ng.Name)
+		}
+		seen[ng.Name] = true
+		if ng.MinSize < 0 || ng.MaxSize < ng.MinSize || ng.TargetSize < ng.MinSize || ng.TargetSize > ng.MaxSize {
+			return nil, fmt.Errorf("node group %s: sizes must satisfy 0 <= min <= target <= max", ng.Name)
+		}
+		if _, err := buildTemplateNode(ng.Name, ng.Template); err != nil {
+			return nil, fmt.Errorf("node group %s: %v", ng.Name, err)
+		}
+		for _, value := range []string{ng.ProvisionTime, ng.IncreaseSizeLatency, ng.DeleteNodesLatency} {
+			if _, err := parseDuration(value); err != nil {
+				return nil, fmt.Errorf("node group %s: %v", ng.Name, err)
+			}
+		}
+		for _, failure := range ng.Failures {
+			if err := validateFailure(failure); err != nil {
+				return nil, fmt.Errorf("node group %s: %v", ng.Name, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+func validateFailure(failure FailureConfig) error {
+	switch failure.Operation {
+	case IncreaseSizeOperation, DeleteNodesOperation, DecreaseTargetSizeOperation, InstanceCreationOperation:
+	default:
+		return fmt.Errorf("unknown failure operation %q", failure.Operation)
+	}
+	if failure.Count < 0 {
+		return fmt.Errorf("failure count must not be negative")
+	}
+	return nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %v", value, err)
+	}
+	return duration, nil
+}
+
+func parseQuantity(value, defaultValue string) (resource.Quantity, error) {
+	if value == "" {
+		value = defaultValue
+	}
+	return resource.ParseQuantity(value)
+}
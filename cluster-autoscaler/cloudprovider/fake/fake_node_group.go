@@ -0,0 +1,343 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const defaultPodsPerNode = 110
+
+type instance struct {
+	name       string
+	createTime time.Time
+	state      cloudprovider.InstanceState
+	errorInfo  *cloudprovider.InstanceErrorInfo
+}
+
+type injectedFailure struct {
+	FailureConfig
+	remaining int
+}
+
+// NodeGroup is a fake node group. Instances are only kept in memory, optionally backed by
+// Node objects registered in the cluster.
+type NodeGroup struct {
+	provider            *CloudProvider
+	id                  string
+	minSize             int
+	maxSize             int
+	targetSize          int
+	template            TemplateConfig
+	provisionTime       time.Duration
+	increaseSizeLatency time.Duration
+	deleteNodesLatency  time.Duration
+	instances           map[string]*instance
+	failures            []*injectedFailure
+	nextInstance        int
+}
+
+var _ cloudprovider.NodeGroup = (*NodeGroup)(nil)
+
+func buildNodeGroup(provider *CloudProvider, cfg NodeGroupConfig) (*NodeGroup, error) {
+	ng := &NodeGroup{
+		provider:  provider,
+		id:        cfg.Name,
+		minSize:   cfg.MinSize,
+		maxSize:   cfg.MaxSize,
+		template:  cfg.Template,
+		instances: make(map[string]*instance),
+		failures:  make([]*injectedFailure, 0),
+	}
+	var err error
+	if ng.provisionTime, err = parseDuration(cfg.ProvisionTime); err != nil {
+		return nil, err
+	}
+	if ng.increaseSizeLatency, err = parseDuration(cfg.IncreaseSizeLatency); err != nil {
+		return nil, err
+	}
+	if ng.deleteNodesLatency, err = parseDuration(cfg.DeleteNodesLatency); err != nil {
+		return nil, err
+	}
+	// Initial instances are provisioned on the first refresh.
+	for i := 0; i < cfg.TargetSize; i++ {
+		ng.addInstance(provider.now().Add(-ng.provisionTime))
+	}
+	for _, failure := range cfg.Failures {
+		ng.injectFailure(failure)
+	}
+	return ng, nil
+}
+
+func (ng *NodeGroup) injectFailure(failure FailureConfig) {
+	ng.failures = append(ng.failures, &injectedFailure{FailureConfig: failure, remaining: failure.Count})
+}
+
+// takeFailure returns the failure to inject into the next call of the operation, if any.
+func (ng *NodeGroup) takeFailure(operation Operation) *FailureConfig {
+	for i, failure := range ng.failures {
+		if failure.Operation != operation {
+			continue
+		}
+		if failure.Count > 0 {
+			failure.remaining--
+			if failure.remaining == 0 {
+				ng.failures = append(ng.failures[:i], ng.failures[i+1:]...)
+			}
+		}
+		return &failure.FailureConfig
+	}
+	return nil
+}
+
+func failureError(operation Operation, failure *FailureConfig) error {
+	if failure.Message != "" {
+		return fmt.Errorf("%s", failure.Message)
+	}
+	return fmt.Errorf("injected %s failure", operation)
+}
+
+func (ng *NodeGroup) addInstance(createTime time.Time) *instance {
+	inst := &instance{
+		name:       fmt.Sprintf("%s-%d", ng.id, ng.nextInstance),
+		createTime: createTime,
+		state:      cloudprovider.InstanceCreating,
+	}
+	ng.nextInstance++
+	ng.targetSize++
+	if failure := ng.takeFailure(InstanceCreationOperation); failure != nil {
+		errorClass := cloudprovider.OtherErrorClass
+		if failure.OutOfResources {
+			errorClass = cloudprovider.OutOfResourcesErrorClass
+		}
+		inst.errorInfo = &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   errorClass,
+			ErrorCode:    failure.ErrorCode,
+			ErrorMessage: failureError(InstanceCreationOperation, failure).Error(),
+		}
+	}
+	ng.instances[inst.name] = inst
+	return inst
+}
+
+// MaxSize returns maximum size of the node group.
+func (ng *NodeGroup) MaxSize() int {
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *NodeGroup) MinSize() int {
+	return ng.minSize
+}
+
+// TargetSize returns the current target size of the node group.
+func (ng *NodeGroup) TargetSize() (int, error) {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	return ng.targetSize, nil
+}
+
+// IncreaseSize increases the size of the node group.
+func (ng *NodeGroup) IncreaseSize(delta int) error {
+	time.Sleep(ng.increaseSizeLatency)
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	if ng.targetSize+delta > ng.maxSize {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", ng.targetSize+delta, ng.maxSize)
+	}
+	if failure := ng.takeFailure(IncreaseSizeOperation); failure != nil {
+		return failureError(IncreaseSizeOperation, failure)
+	}
+	for i := 0; i < delta; i++ {
+		ng.addInstance(ng.provider.now())
+	}
+	return nil
+}
+
+// DeleteNodes deletes nodes from this node group.
+func (ng *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	time.Sleep(ng.deleteNodesLatency)
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+
+	if ng.targetSize-len(nodes) < ng.minSize {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", ng.targetSize-len(nodes), ng.minSize)
+	}
+	for _, node := range nodes {
+		groupID, name, err := parseProviderID(node.Spec.ProviderID)
+		if err != nil {
+			return err
+		}
+		if _, found := ng.instances[name]; groupID != ng.id || !found {
+			return fmt.Errorf("node %s doesn't belong to node group %s", node.Name, ng.id)
+		}
+	}
+	if failure := ng.takeFailure(DeleteNodesOperation); failure != nil {
+		return failureError(DeleteNodesOperation, failure)
+	}
+	for _, node := range nodes {
+		_, name, _ := parseProviderID(node.Spec.ProviderID)
+		ng.provider.unregisterNode(ng.instances[name])
+		delete(ng.instances, name)
+		ng.targetSize--
+	}
+	return nil
+}
+
+// DecreaseTargetSize decreases the target size of the node group by dropping instances that
+// haven't started yet.
+func (ng *NodeGroup) DecreaseTargetSize(delta int) error {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	creating := make([]*instance, 0)
+	for _, inst := range ng.instances {
+		if inst.state == cloudprovider.InstanceCreating {
+			creating = append(creating, inst)
+		}
+	}
+	if len(creating) < -delta {
+		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
+			ng.targetSize, delta, len(ng.instances)-len(creating))
+	}
+	if failure := ng.takeFailure(DecreaseTargetSizeOperation); failure != nil {
+		return failureError(DecreaseTargetSizeOperation, failure)
+	}
+	// Drop the most recently requested instances first.
+	sort.Slice(creating, func(i, j int) bool { return creating[i].createTime.After(creating[j].createTime) })
+	for _, inst := range creating[:-delta] {
+		delete(ng.instances, inst.name)
+		ng.targetSize--
+	}
+	return nil
+}
+
+// Id returns node group id.
+func (ng *NodeGroup) Id() string {
+	return ng.id
+}
+
+// Debug returns a debug string for the node group.
+func (ng *NodeGroup) Debug() string {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	return fmt.Sprintf("%s (min: %d, max: %d, target: %d, instances: %d)", ng.id, ng.minSize, ng.maxSize, ng.targetSize, len(ng.instances))
+}
+
+// Nodes returns a list of all nodes that belong to this node group.
+func (ng *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+
+	result := make([]cloudprovider.Instance, 0, len(ng.instances))
+	for _, inst := range ng.instances {
+		result = append(result, cloudprovider.Instance{
+			Id: providerID(ng.id, inst.name),
+			Status: &cloudprovider.InstanceStatus{
+				State:     inst.state,
+				ErrorInfo: inst.errorInfo,
+			},
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result, nil
+}
+
+// TemplateNodeInfo returns a node template for this node group.
+func (ng *NodeGroup) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	node, err := buildTemplateNode(fmt.Sprintf("%s-template", ng.id), ng.template)
+	if err != nil {
+		return nil, err
+	}
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.id))
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (ng *NodeGroup) Exist() bool {
+	return true
+}
+
+// Create creates the node group on the cloud provider side.
+func (ng *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side.
+func (ng *NodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *NodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+func buildTemplateNode(name string, template TemplateConfig) (*apiv1.Node, error) {
+	cpu, err := parseQuantity(template.CPU, "1")
+	if err != nil {
+		return nil, fmt.Errorf("invalid cpu %q: %v", template.CPU, err)
+	}
+	memory, err := parseQuantity(template.Memory, "1Gi")
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory %q: %v", template.Memory, err)
+	}
+	pods := template.Pods
+	if pods == 0 {
+		pods = defaultPodsPerNode
+	}
+
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: cloudprovider.JoinStringMaps(template.Labels, map[string]string{apiv1.LabelHostname: name}),
+		},
+		Spec: apiv1.NodeSpec{
+			Taints: append([]apiv1.Taint{}, template.Taints...),
+		},
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    cpu,
+				apiv1.ResourceMemory: memory,
+				apiv1.ResourcePods:   *resource.NewQuantity(pods, resource.DecimalSI),
+			},
+			Conditions: cloudprovider.BuildReadyConditions(),
+		},
+	}
+	if template.GPU > 0 {
+		node.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(template.GPU, resource.DecimalSI)
+	}
+	node.Status.Allocatable = node.Status.Capacity
+	return node, nil
+}
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	gce "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 	"k8s.io/klog"
 )
 
@@ -53,6 +54,7 @@ type AutoscalingGceClient interface {
 	FetchMigTemplate(GceRef) (*gce.InstanceTemplate, error)
 	FetchMigsWithName(zone string, filter *regexp.Regexp) ([]string, error)
 	FetchZones(region string) ([]string, error)
+	FetchInstanceExists(GceRef) (bool, error)
 
 	// modifying resources
 	ResizeMig(GceRef, int64) error
@@ -260,6 +262,19 @@ func (client *autoscalingGceClientV1) FetchZones(region string) ([]string, error
 	return zones, nil
 }
 
+// FetchInstanceExists returns false if the instance is unknown to GCE.
+func (client *autoscalingGceClientV1) FetchInstanceExists(instanceRef GceRef) (bool, error) {
+	registerRequest("instances", "get")
+	_, err := client.gceService.Instances.Get(instanceRef.Project, instanceRef.Zone, instanceRef.Name).Do()
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (client *autoscalingGceClientV1) FetchMigTemplate(migRef GceRef) (*gce.InstanceTemplate, error) {
 	registerRequest("instance_group_managers", "get")
 	igm, err := client.gceService.InstanceGroupManagers.Get(migRef.Project, migRef.Zone, migRef.Name).Do()
@@ -18,6 +18,7 @@ package gce
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -97,3 +98,24 @@ func TestWaitForOpTimeout(t *testing.T) {
 	err := g.waitForOp(operation, projectId, zoneB)
 	assert.Error(t, err)
 }
+
+func TestFetchInstanceExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/project1/zones/us-central1-b/instances/existing-instance" {
+			http.Error(w, `{"error": {"code": 404, "message": "not found"}}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name": "existing-instance", "status": "TERMINATED"}`))
+	}))
+	defer server.Close()
+	g := newTestAutoscalingGceClient(t, "project1", server.URL)
+
+	// Stopped instances still exist.
+	exists, err := g.FetchInstanceExists(GceRef{Project: "project1", Zone: zoneB, Name: "existing-instance"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = g.FetchInstanceExists(GceRef{Project: "project1", Zone: zoneB, Name: "deleted-instance"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -79,6 +79,18 @@ func (gce *GceCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.N
 	return mig, err
 }
 
+// HasInstance returns false if the GCE instance of the given node doesn't exist anymore.
+func (gce *GceCloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	if !strings.HasPrefix(node.Spec.ProviderID, "gce://") {
+		return true, cloudprovider.ErrNotImplemented
+	}
+	ref, err := GceRefFromProviderId(node.Spec.ProviderID)
+	if err != nil {
+		return true, cloudprovider.ErrNotImplemented
+	}
+	return gce.gceManager.InstanceExists(ref)
+}
+
 // Pricing returns pricing model for this cloud provider or error if not available.
 func (gce *GceCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
 	return &GcePriceModel{}, nil
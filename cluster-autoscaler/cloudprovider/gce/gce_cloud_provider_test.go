@@ -57,6 +57,11 @@ func (m *gceManagerMock) GetMigForInstance(instance *GceRef) (Mig, error) {
 	return args.Get(0).(*gceMig), args.Error(1)
 }
 
+func (m *gceManagerMock) InstanceExists(instance *GceRef) (bool, error) {
+	args := m.Called(instance)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *gceManagerMock) GetMigNodes(mig Mig) ([]cloudprovider.Instance, error) {
 	args := m.Called(mig)
 	return args.Get(0).([]cloudprovider.Instance), args.Error(1)
@@ -137,6 +142,25 @@ func TestNodeGroupForNode(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, gceManagerMock)
 }
 
+func TestHasInstance(t *testing.T) {
+	gceManagerMock := &gceManagerMock{}
+	gce := &GceCloudProvider{
+		gceManager: gceManagerMock,
+	}
+	n := BuildTestNode("n1", 1000, 1000)
+	n.Spec.ProviderID = "gce://project1/us-central1-b/n1"
+	gceManagerMock.On("InstanceExists", &GceRef{Project: "project1", Zone: "us-central1-b", Name: "n1"}).Return(false, nil).Once()
+
+	exists, err := gce.HasInstance(n)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	n.Spec.ProviderID = ""
+	_, err = gce.HasInstance(n)
+	assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+	mock.AssertExpectationsForObjects(t, gceManagerMock)
+}
+
 func TestGetResourceLimiter(t *testing.T) {
 	gceManagerMock := &gceManagerMock{}
 	resourceLimiter := cloudprovider.NewResourceLimiter(
@@ -69,6 +69,8 @@ type GceManager interface {
 	GetMigNodes(mig Mig) ([]cloudprovider.Instance, error)
 	// GetMigForInstance returns MIG to which the given instance belongs.
 	GetMigForInstance(instance *GceRef) (Mig, error)
+	// InstanceExists returns false if the given instance doesn't exist anymore.
+	InstanceExists(instance *GceRef) (bool, error)
 	// GetMigTemplateNode returns a template node for MIG.
 	GetMigTemplateNode(mig Mig) (*apiv1.Node, error)
 	// GetResourceLimiter returns resource limiter.
@@ -96,6 +98,9 @@ type gceManagerImpl struct {
 	regional              bool
 	explicitlyConfigured  map[GceRef]bool
 	migAutoDiscoverySpecs []cloudprovider.MIGAutoDiscoveryConfig
+	// instancesExist holds whether the instances outside of the MIGs looked up since the
+	// last refresh exist.
+	instancesExist map[GceRef]bool
 }
 
 // CreateGceManager constructs GceManager object.
@@ -256,6 +261,26 @@ func (m *gceManagerImpl) GetMigForInstance(instance *GceRef) (Mig, error) {
 	return m.cache.GetMigForInstance(instance)
 }
 
+// InstanceExists returns false if the given instance doesn't exist anymore. Instances of the MIGs
+// are known to exist, other instances are looked up once per refresh.
+func (m *gceManagerImpl) InstanceExists(instance *GceRef) (bool, error) {
+	if mig, err := m.GetMigForInstance(instance); err == nil && mig != nil {
+		return true, nil
+	}
+	if exists, found := m.instancesExist[*instance]; found {
+		return exists, nil
+	}
+	exists, err := m.GceService.FetchInstanceExists(*instance)
+	if err != nil {
+		return false, err
+	}
+	if m.instancesExist == nil {
+		m.instancesExist = make(map[GceRef]bool)
+	}
+	m.instancesExist[*instance] = exists
+	return exists, nil
+}
+
 // GetMigNodes returns mig nodes.
 func (m *gceManagerImpl) GetMigNodes(mig Mig) ([]cloudprovider.Instance, error) {
 	return m.GceService.FetchMigInstances(mig.GceRef())
@@ -272,6 +297,7 @@ func (m *gceManagerImpl) Refresh() error {
 
 func (m *gceManagerImpl) forceRefresh() error {
 	m.clearMachinesCache()
+	m.instancesExist = make(map[GceRef]bool)
 	if err := m.fetchAutoMigs(); err != nil {
 		klog.Errorf("Failed to fetch MIGs: %v", err)
 		return err
@@ -558,6 +558,31 @@ func TestGetMigForInstance(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, server)
 }
 
+func TestInstanceExists(t *testing.T) {
+	server := NewHttpServerMock()
+	defer server.Close()
+	g := newTestGceManager(t, server.URL, false)
+
+	setupTestDefaultPool(g)
+
+	server.On("handle", "/project1/zones/us-central1-b/instanceGroupManagers/gke-cluster-1-default-pool").Return(buildDefaultInstanceGroupManagerResponse(zoneB)).Once()
+	server.On("handle", "/project1/zones/us-central1-b/instanceGroupManagers/gke-cluster-1-default-pool/listManagedInstances").Return(buildFourRunningInstancesOnDefaultMigManagedInstancesResponse(zoneB)).Once()
+	server.On("handle", "/project1/zones/us-central1-b/instances/static-instance").Return(`{"name": "static-instance", "status": "RUNNING"}`).Once()
+
+	// Instances of the MIGs exist.
+	exists, err := g.InstanceExists(&GceRef{Project: projectId, Zone: zoneB, Name: "gke-cluster-1-default-pool-f7607aac-f1hm"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Other instances are looked up once per refresh.
+	for i := 0; i < 2; i++ {
+		exists, err = g.InstanceExists(&GceRef{Project: projectId, Zone: zoneB, Name: "static-instance"})
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+	mock.AssertExpectationsForObjects(t, server)
+}
+
 func TestGetMigNodesBasic(t *testing.T) {
 	server := NewHttpServerMock()
 	defer server.Close()
@@ -189,9 +189,9 @@ func (m *autoscalingGkeClientV1beta1) CreateNodePool(mig *GkeMig) error {
 
 	autoscaling := gke_api_beta.NodePoolAutoscaling{
 		Enabled:         true,
-		MinNodeCount:    napMinNodes,
-		MaxNodeCount:    napMaxNodes,
-		Autoprovisioned: true,
+		MinNodeCount:    int64(mig.MinSize()),
+		MaxNodeCount:    int64(mig.MaxSize()),
+		Autoprovisioned: !mig.persistent,
 	}
 
 	createRequest := gke_api_beta.CreateNodePoolRequest{
@@ -229,9 +229,12 @@ type GkeMig struct {
 	minSize         int
 	maxSize         int
 	autoprovisioned bool
-	exist           bool
-	nodePoolName    string
-	spec            *MigSpec
+	// persistent node groups are created as regular node pools, which node
+	// autoprovisioning doesn't delete.
+	persistent   bool
+	exist        bool
+	nodePoolName string
+	spec         *MigSpec
 }
 
 // GceRef returns Mig's GceRef
@@ -390,6 +393,27 @@ func (mig *GkeMig) Create() (cloudprovider.NodeGroup, error) {
 	return nil, fmt.Errorf("cannot create non-autoprovisioned node group")
 }
 
+// SetSizeLimits sets the min and max sizes of the autoscaling of the node pool that will be
+// created for the node group.
+func (mig *GkeMig) SetSizeLimits(minSize, maxSize int) error {
+	if mig.exist || !mig.autoprovisioned {
+		return fmt.Errorf("cannot set size limits of existing node group %s", mig.Id())
+	}
+	mig.minSize = minSize
+	mig.maxSize = maxSize
+	return nil
+}
+
+// SetPersistent makes the node pool that will be created for the node group a regular node
+// pool, which isn't deleted by node autoprovisioning once unused.
+func (mig *GkeMig) SetPersistent() error {
+	if mig.exist || !mig.autoprovisioned {
+		return fmt.Errorf("cannot make existing node group %s persistent", mig.Id())
+	}
+	mig.persistent = true
+	return nil
+}
+
 // Delete deletes the node group on the cloud provider side.
 // This will be executed only for autoprovisioned node groups, once their size drops to 0.
 func (mig *GkeMig) Delete() error {
@@ -431,6 +431,19 @@ func TestMig(t *testing.T) {
 	assert.NoError(t, err)
 	mock.AssertExpectationsForObjects(t, gkeManagerMock)
 
+	// Test SetSizeLimits.
+	assert.NoError(t, mig1.SetSizeLimits(2, 20))
+	assert.Equal(t, 2, mig1.MinSize())
+	assert.Equal(t, 20, mig1.MaxSize())
+	mig1.exist = true
+	assert.Error(t, mig1.SetSizeLimits(0, 10))
+
+	// Test SetPersistent.
+	assert.Error(t, mig1.SetPersistent())
+	mig1.exist = false
+	assert.NoError(t, mig1.SetPersistent())
+	assert.True(t, mig1.persistent)
+
 	gkeManagerMock.On("DeleteNodePool", mock.AnythingOfType("*gke.GkeMig")).Return(nil).Once()
 	mig1.exist = true
 	err = mig1.Delete()
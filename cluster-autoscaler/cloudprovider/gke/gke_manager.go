@@ -61,8 +61,6 @@ const (
 	machinesRefreshInterval    = 1 * time.Hour
 	httpTimeout                = 30 * time.Second
 	nodeAutoprovisioningPrefix = "nap"
-	napMaxNodes                = 1000
-	napMinNodes                = 0
 	scaleToZeroSupported       = true
 )
 
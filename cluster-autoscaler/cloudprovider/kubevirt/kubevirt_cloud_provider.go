@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"os"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for KubeVirt.
+	ProviderName = "kubevirt"
+)
+
+// kubevirtCloudProvider implements CloudProvider interface for nodes running in KubeVirt
+// VirtualMachines of an infra cluster.
+type kubevirtCloudProvider struct {
+	manager         *kubevirtManager
+	pools           []*vmPool
+	resourceLimiter *cloudprovider.ResourceLimiter
+}
+
+// BuildKubevirt builds the KubeVirt cloud provider from the configuration in --cloud-config.
+func BuildKubevirt(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	if opts.CloudConfig == "" {
+		klog.Fatalf("The kubevirt cloud provider requires a configuration file passed with --cloud-config")
+	}
+	configFile, err := os.Open(opts.CloudConfig)
+	if err != nil {
+		klog.Fatalf("Couldn't open cloud provider configuration %s: %#v", opts.CloudConfig, err)
+	}
+	defer configFile.Close()
+	cfg, err := readConfig(configFile)
+	if err != nil {
+		klog.Fatalf("Failed to read kubevirt cloud provider configuration: %v", err)
+	}
+	if do.StaticDiscoverySpecified() || do.AutoDiscoverySpecified() {
+		klog.Warningf("The kubevirt cloud provider reads pools from --cloud-config, node group discovery flags are ignored")
+	}
+
+	kubeconfig := cfg.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = opts.KubeConfigPath
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		klog.Fatalf("Failed to build infra cluster client config: %v", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		klog.Fatalf("Failed to create infra cluster client: %v", err)
+	}
+
+	manager := newKubevirtManager(client.Resource(virtualMachineResource).Namespace(cfg.Namespace))
+	return buildKubevirtCloudProvider(manager, cfg, rl)
+}
+
+func buildKubevirtCloudProvider(manager *kubevirtManager, cfg *CloudConfig, rl *cloudprovider.ResourceLimiter) *kubevirtCloudProvider {
+	provider := &kubevirtCloudProvider{
+		manager:         manager,
+		pools:           make([]*vmPool, 0, len(cfg.Pools)),
+		resourceLimiter: rl,
+	}
+	for i := range cfg.Pools {
+		provider.pools = append(provider.pools, &vmPool{manager: manager, config: &cfg.Pools[i]})
+	}
+	return provider
+}
+
+// Name returns name of the cloud provider.
+func (kubevirt *kubevirtCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (kubevirt *kubevirtCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	result := make([]cloudprovider.NodeGroup, 0, len(kubevirt.pools))
+	for _, pool := range kubevirt.pools {
+		result = append(result, pool)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node, based on the VM named in its provider id.
+func (kubevirt *kubevirtCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	name, ok := vmNameFromProviderID(node.Spec.ProviderID)
+	if !ok {
+		return nil, nil
+	}
+	poolName, vm := kubevirt.manager.findVM(name)
+	if vm == nil {
+		return nil, nil
+	}
+	for _, pool := range kubevirt.pools {
+		if pool.Id() == poolName {
+			return pool, nil
+		}
+	}
+	return nil, nil
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+func (kubevirt *kubevirtCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
+func (kubevirt *kubevirtCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition provided.
+func (kubevirt *kubevirtCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (kubevirt *kubevirtCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return kubevirt.resourceLimiter, nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (kubevirt *kubevirtCloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh lists the VMs of all pools in the infra cluster.
+func (kubevirt *kubevirtCloudProvider) Refresh() error {
+	return kubevirt.manager.refresh()
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `{
+  "namespace": "nodes",
+  "pools": [
+    {
+      "name": "workers",
+      "minSize": 1,
+      "maxSize": 3,
+      "nodeLabels": {"pool": "workers"},
+      "nodeTaints": [{"key": "dedicated", "value": "workers", "effect": "NoSchedule"}],
+      "virtualMachineTemplate": {
+        "apiVersion": "kubevirt.io/v1alpha3",
+        "kind": "VirtualMachine",
+        "metadata": {"name": "ignored", "labels": {"app": "node"}},
+        "spec": {
+          "running": false,
+          "template": {
+            "spec": {
+              "domain": {
+                "cpu": {"cores": 2, "sockets": 2},
+                "resources": {"requests": {"memory": "8Gi"}}
+              }
+            }
+          }
+        }
+      }
+    },
+    {
+      "name": "small",
+      "maxSize": 2,
+      "virtualMachineTemplate": {
+        "spec": {"template": {"spec": {"domain": {"resources": {"requests": {"cpu": "500m", "memory": "1Gi"}}}}}}
+      }
+    }
+  ]
+}`
+
+type fakeVirtualMachinesClient struct {
+	vms     map[string]*unstructured.Unstructured
+	created int
+	deleted []string
+}
+
+func newFakeVirtualMachinesClient() *fakeVirtualMachinesClient {
+	return &fakeVirtualMachinesClient{vms: make(map[string]*unstructured.Unstructured)}
+}
+
+func (c *fakeVirtualMachinesClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	for _, vm := range c.vms {
+		if _, found := vm.GetLabels()[opts.LabelSelector]; found {
+			list.Items = append(list.Items, *vm.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (c *fakeVirtualMachinesClient) Create(obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	vm := obj.DeepCopy()
+	vm.SetName(fmt.Sprintf("%s%d", vm.GetGenerateName(), c.created))
+	vm.SetCreationTimestamp(metav1.NewTime(time.Unix(int64(c.created), 0)))
+	c.created++
+	c.vms[vm.GetName()] = vm
+	return vm.DeepCopy(), nil
+}
+
+func (c *fakeVirtualMachinesClient) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	if _, found := c.vms[name]; !found {
+		return fmt.Errorf("virtual machine %s not found", name)
+	}
+	delete(c.vms, name)
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func (c *fakeVirtualMachinesClient) setStatus(name string, ready bool, printableStatus string) {
+	vm := c.vms[name]
+	unstructured.SetNestedField(vm.Object, ready, "status", "ready")
+	unstructured.SetNestedField(vm.Object, printableStatus, "status", "printableStatus")
+}
+
+func buildTestProvider(t *testing.T) (*kubevirtCloudProvider, *fakeVirtualMachinesClient) {
+	cfg, err := readConfig(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+	client := newFakeVirtualMachinesClient()
+	provider := buildKubevirtCloudProvider(newKubevirtManager(client), cfg, nil)
+	assert.NoError(t, provider.Refresh())
+	return provider, client
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := readConfig(strings.NewReader(testConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, "nodes", cfg.Namespace)
+	assert.Equal(t, 2, len(cfg.Pools))
+	cores, _, err := unstructured.NestedInt64(cfg.Pools[0].template.Object, "spec", "template", "spec", "domain", "cpu", "cores")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), cores)
+
+	for _, invalid := range []string{
+		`{"pools": []}`,
+		`{"namespace": "nodes", "pools": [{"name": "", "maxSize": 1, "virtualMachineTemplate": {}}]}`,
+		`{"namespace": "nodes", "pools": [{"name": "a", "minSize": 2, "maxSize": 1, "virtualMachineTemplate": {}}]}`,
+		`{"namespace": "nodes", "pools": [{"name": "a", "maxSize": 1}]}`,
+		`{"namespace": "nodes", "pools": [{"name": "a", "maxSize": 1, "virtualMachineTemplate": {"spec": {}}}]}`,
+		`{"namespace": "nodes", "pools": [{"name": "a", "maxSize": 1, "virtualMachineTemplate": {"spec": {"template": {"spec": {"domain": {"memory": {"guest": "lots"}}}}}}}]}`,
+		`{"namespace": `,
+	} {
+		_, err := readConfig(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestIncreaseSize(t *testing.T) {
+	provider, client := buildTestProvider(t)
+	pool := provider.NodeGroups()[0]
+
+	assert.Error(t, pool.IncreaseSize(0))
+	assert.Error(t, pool.IncreaseSize(4))
+	assert.NoError(t, pool.IncreaseSize(2))
+
+	size, err := pool.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+	assert.Equal(t, 2, len(client.vms))
+
+	vm := client.vms["workers-0"]
+	assert.Equal(t, "workers", vm.GetLabels()[poolLabel])
+	assert.Equal(t, "node", vm.GetLabels()["app"])
+	assert.Equal(t, "", vm.GetNamespace())
+	assert.Equal(t, "VirtualMachine", vm.GetKind())
+	running, _, _ := unstructured.NestedBool(vm.Object, "spec", "running")
+	assert.True(t, running)
+	vmiLabels, _, _ := unstructured.NestedStringMap(vm.Object, "spec", "template", "metadata", "labels")
+	assert.Equal(t, map[string]string{poolLabel: "workers"}, vmiLabels)
+
+	// The created VMs are visible without waiting for a refresh.
+	assert.NoError(t, provider.Refresh())
+	size, err = pool.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+	assert.Error(t, pool.IncreaseSize(2))
+}
+
+func TestNodesAndStatus(t *testing.T) {
+	provider, client := buildTestProvider(t)
+	pool := provider.NodeGroups()[0]
+	assert.NoError(t, pool.IncreaseSize(3))
+	client.setStatus("workers-0", true, "Running")
+	client.setStatus("workers-1", false, "ErrorUnschedulable")
+	client.setStatus("workers-2", false, "Starting")
+	assert.NoError(t, provider.Refresh())
+
+	instances, err := pool.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{
+		{Id: "kubevirt://workers-0", Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning}},
+		{Id: "kubevirt://workers-1", Status: &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+				ErrorCode:    "ErrorUnschedulable",
+				ErrorMessage: "virtual machine workers-1 failed to start: ErrorUnschedulable",
+			},
+		}},
+		{Id: "kubevirt://workers-2", Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}},
+	}, instances)
+
+	now := metav1.Now()
+	client.vms["workers-2"].SetDeletionTimestamp(&now)
+	assert.NoError(t, provider.Refresh())
+	instances, err = pool.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, cloudprovider.InstanceDeleting, instances[2].Status.State)
+	size, err := pool.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider, _ := buildTestProvider(t)
+	assert.NoError(t, provider.NodeGroups()[1].IncreaseSize(1))
+
+	group, err := provider.NodeGroupForNode(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: "kubevirt://small-0"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "small", group.Id())
+
+	for _, providerID := range []string{"kubevirt://unknown", "kubevirt://", "gce://project/zone/small-0", ""} {
+		group, err = provider.NodeGroupForNode(&apiv1.Node{Spec: apiv1.NodeSpec{ProviderID: providerID}})
+		assert.NoError(t, err)
+		assert.Nil(t, group, providerID)
+	}
+}
+
+func TestDeleteNodes(t *testing.T) {
+	provider, client := buildTestProvider(t)
+	workers := provider.NodeGroups()[0]
+	small := provider.NodeGroups()[1]
+	assert.NoError(t, workers.IncreaseSize(2))
+	assert.NoError(t, small.IncreaseSize(1))
+
+	node := func(name string) *apiv1.Node {
+		return &apiv1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       apiv1.NodeSpec{ProviderID: providerIDPrefix + name},
+		}
+	}
+	assert.Error(t, workers.DeleteNodes([]*apiv1.Node{node("small-2")}))
+	assert.Error(t, workers.DeleteNodes([]*apiv1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "no-provider-id"}}}))
+	assert.Error(t, workers.DeleteNodes([]*apiv1.Node{node("workers-0"), node("workers-1")}))
+	assert.Empty(t, client.deleted)
+
+	assert.NoError(t, workers.DeleteNodes([]*apiv1.Node{node("workers-0")}))
+	assert.Equal(t, []string{"workers-0"}, client.deleted)
+	size, err := workers.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	provider, client := buildTestProvider(t)
+	pool := provider.NodeGroups()[0]
+	assert.NoError(t, pool.IncreaseSize(3))
+	client.setStatus("workers-0", true, "Running")
+	assert.NoError(t, provider.Refresh())
+
+	assert.Error(t, pool.DecreaseTargetSize(0))
+	assert.Error(t, pool.DecreaseTargetSize(-3))
+	assert.NoError(t, pool.DecreaseTargetSize(-1))
+	assert.Equal(t, []string{"workers-2"}, client.deleted)
+	assert.NoError(t, pool.DecreaseTargetSize(-1))
+	assert.Equal(t, []string{"workers-2", "workers-1"}, client.deleted)
+	assert.Error(t, pool.DecreaseTargetSize(-1))
+}
+
+func TestTemplateNodeInfo(t *testing.T) {
+	provider, _ := buildTestProvider(t)
+
+	nodeInfo, err := provider.NodeGroups()[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node := nodeInfo.Node()
+	assert.Equal(t, "workers", node.Labels["pool"])
+	assert.Equal(t, cloudprovider.DefaultOS, node.Labels[apiv1.LabelOSStable])
+	assert.Equal(t, 1, len(node.Spec.Taints))
+	cpu := node.Status.Capacity[apiv1.ResourceCPU]
+	assert.Equal(t, int64(4), cpu.Value())
+	memory := node.Status.Allocatable[apiv1.ResourceMemory]
+	assert.Equal(t, int64(8*1024*1024*1024), memory.Value())
+	assert.Equal(t, 1, len(nodeInfo.Pods()))
+
+	nodeInfo, err = provider.NodeGroups()[1].TemplateNodeInfo()
+	assert.NoError(t, err)
+	cpu = nodeInfo.Node().Status.Capacity[apiv1.ResourceCPU]
+	assert.Equal(t, int64(500), cpu.MilliValue())
+}
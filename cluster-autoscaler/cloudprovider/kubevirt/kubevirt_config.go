@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// CloudConfig is the KubeVirt cloud provider configuration, read as JSON from --cloud-config.
+type CloudConfig struct {
+	// Kubeconfig is the path to the kubeconfig of the infra cluster running the VMs. The kubeconfig
+	// passed with --kubeconfig is used if empty.
+	Kubeconfig string `json:"kubeconfig"`
+	// Namespace is the infra cluster namespace the VMs are created in.
+	Namespace string `json:"namespace"`
+	// Pools lists the VM pools used as node groups.
+	Pools []PoolConfig `json:"pools"`
+}
+
+// PoolConfig describes a pool of identical VirtualMachines.
+type PoolConfig struct {
+	Name    string `json:"name"`
+	MinSize int    `json:"minSize"`
+	MaxSize int    `json:"maxSize"`
+	// VirtualMachineTemplate is the VirtualMachine object new VMs of the pool are created from.
+	// Name and namespace are ignored.
+	VirtualMachineTemplate json.RawMessage `json:"virtualMachineTemplate"`
+	// NodeLabels and NodeTaints are the labels and taints nodes of the pool register with, used
+	// to build template nodes when scaling up from zero.
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	NodeTaints []apiv1.Taint     `json:"nodeTaints,omitempty"`
+
+	template *unstructured.Unstructured
+}
+
+func readConfig(configReader io.Reader) (*CloudConfig, error) {
+	cfg := &CloudConfig{}
+	if configReader == nil {
+		return nil, fmt.Errorf("kubevirt: cloud config is required")
+	}
+	if err := json.NewDecoder(configReader).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("kubevirt: failed to decode cloud config: %v", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cc *CloudConfig) validate() error {
+	if cc.Namespace == "" {
+		return fmt.Errorf("kubevirt: cloud config must have a namespace")
+	}
+	seen := make(map[string]bool)
+	for i := range cc.Pools {
+		pool := &cc.Pools[i]
+		if pool.Name == "" {
+			return fmt.Errorf("kubevirt: pool name must not be empty")
+		}
+		if seen[pool.Name] {
+			return fmt.Errorf("kubevirt: pool %s is defined more than once", pool.Name)
+		}
+		seen[pool.Name] = true
+		if pool.MinSize < 0 || pool.MaxSize < pool.MinSize {
+			return fmt.Errorf("kubevirt: pool %s sizes must satisfy 0 <= min <= max", pool.Name)
+		}
+		if len(pool.VirtualMachineTemplate) == 0 {
+			return fmt.Errorf("kubevirt: pool %s must have a virtual machine template", pool.Name)
+		}
+		// The generic JSON decoder turns all numbers into floats, unstructured objects expect integers.
+		object := make(map[string]interface{})
+		if err := utiljson.Unmarshal(pool.VirtualMachineTemplate, &object); err != nil {
+			return fmt.Errorf("kubevirt: invalid virtual machine template for pool %s: %v", pool.Name, err)
+		}
+		pool.template = &unstructured.Unstructured{Object: object}
+		if _, _, err := templateCapacity(pool.template); err != nil {
+			return fmt.Errorf("kubevirt: invalid virtual machine template for pool %s: %v", pool.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/klog"
+)
+
+const (
+	// poolLabel is set on VMs created by the autoscaler, with the pool name as value.
+	poolLabel = "kubevirt.cluster-autoscaler.kubernetes.io/pool"
+
+	providerIDPrefix = "kubevirt://"
+)
+
+var virtualMachineResource = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1alpha3", Resource: "virtualmachines"}
+
+// virtualMachineErrorStatuses maps VM printable statuses reporting a failed start to error classes.
+var virtualMachineErrorStatuses = map[string]cloudprovider.InstanceErrorClass{
+	"ErrorUnschedulable": cloudprovider.OutOfResourcesErrorClass,
+	"ErrorPvcNotFound":   cloudprovider.OtherErrorClass,
+	"DataVolumeError":    cloudprovider.OtherErrorClass,
+	"ErrImagePull":       cloudprovider.OtherErrorClass,
+	"ImagePullBackOff":   cloudprovider.OtherErrorClass,
+	"CrashLoopBackOff":   cloudprovider.OtherErrorClass,
+}
+
+// virtualMachinesClient is the subset of the dynamic client used to manage VMs in the infra namespace.
+type virtualMachinesClient interface {
+	List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+	Create(obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error)
+	Delete(name string, options *metav1.DeleteOptions, subresources ...string) error
+}
+
+// kubevirtManager creates and deletes VMs and caches the VMs of all pools between refreshes.
+type kubevirtManager struct {
+	client virtualMachinesClient
+
+	cacheMutex sync.Mutex
+	// vms maps pool names to the VMs of the pool.
+	vms map[string][]*unstructured.Unstructured
+}
+
+func newKubevirtManager(client virtualMachinesClient) *kubevirtManager {
+	return &kubevirtManager{
+		client: client,
+		vms:    make(map[string][]*unstructured.Unstructured),
+	}
+}
+
+// refresh lists the VMs of all pools.
+func (m *kubevirtManager) refresh() error {
+	list, err := m.client.List(metav1.ListOptions{LabelSelector: poolLabel})
+	if err != nil {
+		return fmt.Errorf("failed to list virtual machines: %v", err)
+	}
+	vms := make(map[string][]*unstructured.Unstructured)
+	for i := range list.Items {
+		vm := &list.Items[i]
+		pool := vm.GetLabels()[poolLabel]
+		vms[pool] = append(vms[pool], vm)
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	m.vms = vms
+	return nil
+}
+
+// poolVMs returns the cached VMs of the pool, oldest first.
+func (m *kubevirtManager) poolVMs(pool string) []*unstructured.Unstructured {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	result := append([]*unstructured.Unstructured{}, m.vms[pool]...)
+	sort.Slice(result, func(i, j int) bool {
+		ti, tj := result[i].GetCreationTimestamp(), result[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result
+}
+
+// findVM returns the pool and the VM with the given name, or nil if the VM doesn't belong to any pool.
+func (m *kubevirtManager) findVM(name string) (string, *unstructured.Unstructured) {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	for pool, vms := range m.vms {
+		for _, vm := range vms {
+			if vm.GetName() == name {
+				return pool, vm
+			}
+		}
+	}
+	return "", nil
+}
+
+// createVM creates a new running VM of the pool from its template.
+func (m *kubevirtManager) createVM(pool *PoolConfig) error {
+	vm := pool.template.DeepCopy()
+	vm.SetGroupVersionKind(virtualMachineResource.GroupVersion().WithKind("VirtualMachine"))
+	vm.SetName("")
+	vm.SetNamespace("")
+	vm.SetResourceVersion("")
+	vm.SetGenerateName(pool.Name + "-")
+	vm.SetLabels(cloudprovider.JoinStringMaps(vm.GetLabels(), map[string]string{poolLabel: pool.Name}))
+	if err := unstructured.SetNestedField(vm.Object, true, "spec", "running"); err != nil {
+		return err
+	}
+	vmiLabels, _, err := unstructured.NestedStringMap(vm.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return err
+	}
+	vmiLabels = cloudprovider.JoinStringMaps(vmiLabels, map[string]string{poolLabel: pool.Name})
+	if err := unstructured.SetNestedStringMap(vm.Object, vmiLabels, "spec", "template", "metadata", "labels"); err != nil {
+		return err
+	}
+	unstructured.RemoveNestedField(vm.Object, "status")
+
+	created, err := m.client.Create(vm, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create virtual machine for pool %s: %v", pool.Name, err)
+	}
+	klog.V(2).Infof("Created virtual machine %s for pool %s", created.GetName(), pool.Name)
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	m.vms[pool.Name] = append(m.vms[pool.Name], created)
+	return nil
+}
+
+// deleteVM deletes the VM of the pool, together with its VMI.
+func (m *kubevirtManager) deleteVM(pool string, name string) error {
+	propagation := metav1.DeletePropagationForeground
+	if err := m.client.Delete(name, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+		return fmt.Errorf("failed to delete virtual machine %s: %v", name, err)
+	}
+	klog.V(2).Infof("Deleted virtual machine %s of pool %s", name, pool)
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	vms := m.vms[pool]
+	for i, vm := range vms {
+		if vm.GetName() == name {
+			m.vms[pool] = append(vms[:i:i], vms[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func vmProviderID(vm *unstructured.Unstructured) string {
+	return providerIDPrefix + vm.GetName()
+}
+
+func vmNameFromProviderID(providerID string) (string, bool) {
+	if !strings.HasPrefix(providerID, providerIDPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(providerID, providerIDPrefix)
+	return name, name != ""
+}
+
+// vmReady returns true if the VMI of the VM is running and ready.
+func vmReady(vm *unstructured.Unstructured) bool {
+	ready, _, _ := unstructured.NestedBool(vm.Object, "status", "ready")
+	return ready
+}
+
+func vmStatus(vm *unstructured.Unstructured) *cloudprovider.InstanceStatus {
+	if vm.GetDeletionTimestamp() != nil {
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting}
+	}
+	if vmReady(vm) {
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning}
+	}
+	status := &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}
+	printableStatus, _, _ := unstructured.NestedString(vm.Object, "status", "printableStatus")
+	if errorClass, found := virtualMachineErrorStatuses[printableStatus]; found {
+		status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   errorClass,
+			ErrorCode:    printableStatus,
+			ErrorMessage: fmt.Sprintf("virtual machine %s failed to start: %s", vm.GetName(), printableStatus),
+		}
+	}
+	return status
+}
+
+// templateCapacity returns the CPU and memory of VMs created from the template. Guest CPU topology and
+// memory take precedence over resource requests, the same way KubeVirt sizes the guest.
+func templateCapacity(template *unstructured.Unstructured) (resource.Quantity, resource.Quantity, error) {
+	domain := []string{"spec", "template", "spec", "domain"}
+	var cpu, memory resource.Quantity
+
+	cores, err := topologyValue(template, append(domain, "cpu", "cores")...)
+	if err != nil {
+		return cpu, memory, err
+	}
+	sockets, err := topologyValue(template, append(domain, "cpu", "sockets")...)
+	if err != nil {
+		return cpu, memory, err
+	}
+	threads, err := topologyValue(template, append(domain, "cpu", "threads")...)
+	if err != nil {
+		return cpu, memory, err
+	}
+	if cores*sockets*threads > 1 {
+		cpu = *resource.NewQuantity(cores*sockets*threads, resource.DecimalSI)
+	} else if cpu, err = quantityValue(template, append(domain, "resources", "requests", "cpu")...); err != nil {
+		return cpu, memory, err
+	}
+	if cpu.IsZero() {
+		cpu = *resource.NewQuantity(1, resource.DecimalSI)
+	}
+
+	if memory, err = quantityValue(template, append(domain, "memory", "guest")...); err != nil {
+		return cpu, memory, err
+	}
+	if memory.IsZero() {
+		if memory, err = quantityValue(template, append(domain, "resources", "requests", "memory")...); err != nil {
+			return cpu, memory, err
+		}
+	}
+	if memory.IsZero() {
+		return cpu, memory, fmt.Errorf("guest memory or memory request must be set")
+	}
+	return cpu, memory, nil
+}
+
+func topologyValue(template *unstructured.Unstructured, fields ...string) (int64, error) {
+	value, found, err := unstructured.NestedInt64(template.Object, fields...)
+	if err != nil {
+		return 0, err
+	}
+	if !found || value < 1 {
+		return 1, nil
+	}
+	return value, nil
+}
+
+func quantityValue(template *unstructured.Unstructured, fields ...string) (resource.Quantity, error) {
+	value, found, err := unstructured.NestedFieldNoCopy(template.Object, fields...)
+	if err != nil || !found {
+		return resource.Quantity{}, err
+	}
+	switch v := value.(type) {
+	case string:
+		return resource.ParseQuantity(v)
+	case int64:
+		return *resource.NewQuantity(v, resource.DecimalSI), nil
+	}
+	return resource.Quantity{}, fmt.Errorf("%s has unexpected type %T", strings.Join(fields, "."), value)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const defaultPodsPerNode = 110
+
+// vmPool implements NodeGroup for a pool of KubeVirt VirtualMachines.
+type vmPool struct {
+	manager *kubevirtManager
+	config  *PoolConfig
+}
+
+var _ cloudprovider.NodeGroup = (*vmPool)(nil)
+
+// MaxSize returns maximum size of the node group.
+func (pool *vmPool) MaxSize() int {
+	return pool.config.MaxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (pool *vmPool) MinSize() int {
+	return pool.config.MinSize
+}
+
+// TargetSize returns the current target size of the node group. It is the number of VMs
+// of the pool that aren't being deleted.
+func (pool *vmPool) TargetSize() (int, error) {
+	size := 0
+	for _, vm := range pool.manager.poolVMs(pool.config.Name) {
+		if vm.GetDeletionTimestamp() == nil {
+			size++
+		}
+	}
+	return size, nil
+}
+
+// IncreaseSize increases the pool size by creating new VMs.
+func (pool *vmPool) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	size, err := pool.TargetSize()
+	if err != nil {
+		return err
+	}
+	if size+delta > pool.MaxSize() {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, pool.MaxSize())
+	}
+	for i := 0; i < delta; i++ {
+		if err := pool.manager.createVM(pool.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteNodes deletes the VMs backing the given nodes.
+func (pool *vmPool) DeleteNodes(nodes []*apiv1.Node) error {
+	size, err := pool.TargetSize()
+	if err != nil {
+		return err
+	}
+	if size-len(nodes) < pool.MinSize() {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", size-len(nodes), pool.MinSize())
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		name, ok := vmNameFromProviderID(node.Spec.ProviderID)
+		if !ok {
+			return fmt.Errorf("node %s has no kubevirt provider id", node.Name)
+		}
+		if vmPoolName, vm := pool.manager.findVM(name); vm == nil || vmPoolName != pool.config.Name {
+			return fmt.Errorf("node %s doesn't belong to pool %s", node.Name, pool.config.Name)
+		}
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if err := pool.manager.deleteVM(pool.config.Name, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecreaseTargetSize decreases the target size of the node group by deleting VMs that
+// haven't become ready yet, newest first.
+func (pool *vmPool) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	vms := pool.manager.poolVMs(pool.config.Name)
+	names := make([]string, 0, -delta)
+	for i := len(vms) - 1; i >= 0 && len(names) < -delta; i-- {
+		if vms[i].GetDeletionTimestamp() == nil && !vmReady(vms[i]) {
+			names = append(names, vms[i].GetName())
+		}
+	}
+	if len(names) < -delta {
+		return fmt.Errorf("attempt to delete existing nodes, delta:%d not ready virtual machines:%d", delta, len(names))
+	}
+	for _, name := range names {
+		if err := pool.manager.deleteVM(pool.config.Name, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Id returns the pool name.
+func (pool *vmPool) Id() string {
+	return pool.config.Name
+}
+
+// Debug returns a debug string for the pool.
+func (pool *vmPool) Debug() string {
+	return fmt.Sprintf("%s (%d:%d)", pool.Id(), pool.MinSize(), pool.MaxSize())
+}
+
+// Nodes returns the VMs of the pool.
+func (pool *vmPool) Nodes() ([]cloudprovider.Instance, error) {
+	vms := pool.manager.poolVMs(pool.config.Name)
+	instances := make([]cloudprovider.Instance, 0, len(vms))
+	for _, vm := range vms {
+		instances = append(instances, cloudprovider.Instance{
+			Id:     vmProviderID(vm),
+			Status: vmStatus(vm),
+		})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template built from the pool VM template and node labels.
+func (pool *vmPool) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	cpu, memory, err := templateCapacity(pool.config.template)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s-template", pool.config.Name)
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: cloudprovider.JoinStringMaps(map[string]string{
+				apiv1.LabelHostname:   name,
+				apiv1.LabelOSStable:   cloudprovider.DefaultOS,
+				apiv1.LabelArchStable: cloudprovider.DefaultArch,
+			}, pool.config.NodeLabels),
+		},
+		Spec: apiv1.NodeSpec{
+			ProviderID: providerIDPrefix + name,
+			Taints:     append([]apiv1.Taint{}, pool.config.NodeTaints...),
+		},
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    cpu,
+				apiv1.ResourceMemory: memory,
+				apiv1.ResourcePods:   *resource.NewQuantity(defaultPodsPerNode, resource.DecimalSI),
+			},
+			Conditions: cloudprovider.BuildReadyConditions(),
+		},
+	}
+	node.Status.Allocatable = node.Status.Capacity
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(pool.config.Name))
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (pool *vmPool) Exist() bool {
+	return true
+}
+
+// Create creates the node group on the cloud provider side.
+func (pool *vmPool) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side.
+func (pool *vmPool) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (pool *vmPool) Autoprovisioned() bool {
+	return false
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"reflect"
+
+	"k8s.io/klog"
+)
+
+// GetNodeGroupOptions returns the autoscaling options of the node group, or
+// defaults if it doesn't implement OptionsNodeGroup, doesn't override any or
+// fails to return its options.
+func GetNodeGroupOptions(nodeGroup NodeGroup, defaults NodeGroupAutoscalingOptions) NodeGroupAutoscalingOptions {
+	if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+		return defaults
+	}
+	optionsNodeGroup, ok := nodeGroup.(OptionsNodeGroup)
+	if !ok {
+		return defaults
+	}
+	options, err := optionsNodeGroup.GetOptions(defaults)
+	if err == ErrNotImplemented {
+		return defaults
+	}
+	if err != nil {
+		klog.Warningf("Failed to get autoscaling options of node group %s, using the defaults: %v", nodeGroup.Id(), err)
+		return defaults
+	}
+	if options == nil {
+		return defaults
+	}
+	return *options
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	karbonNodePoolsPath  = "/karbon/v1-beta.1/k8s/clusters/%s/node-pools"
+	karbonAddNodesPath   = "/karbon/v1-alpha.1/k8s/clusters/%s/node-pools/%s/add-nodes"
+	karbonRemoveNodePath = "/karbon/v1-alpha.1/k8s/clusters/%s/node-pools/%s/remove-nodes"
+	prismVMsListPath     = "/api/nutanix/v3/vms/list"
+
+	vmsListPageSize = 250
+	requestTimeout  = 30 * time.Second
+)
+
+// nodePool is a Karbon node pool.
+type nodePool struct {
+	Name      string         `json:"name"`
+	AHVConfig nodePoolAHV    `json:"ahv_config"`
+	Nodes     []nodePoolNode `json:"nodes"`
+}
+
+// nodePoolAHV describes the VMs of a node pool.
+type nodePoolAHV struct {
+	CPU       int64 `json:"cpu"`
+	MemoryMib int64 `json:"memory_mib"`
+	DiskMib   int64 `json:"disk_mib"`
+}
+
+type nodePoolNode struct {
+	Hostname    string `json:"hostname"`
+	IPv4Address string `json:"ipv4_address"`
+}
+
+// vm is the part of a Prism VM the cloud provider uses.
+type vm struct {
+	UUID       string
+	Name       string
+	Categories map[string]string
+}
+
+type vmsListRequest struct {
+	Kind   string `json:"kind"`
+	Length int    `json:"length"`
+	Offset int    `json:"offset"`
+}
+
+type vmsListResponse struct {
+	Entities []struct {
+		Spec struct {
+			Name string `json:"name"`
+		} `json:"spec"`
+		Metadata struct {
+			UUID       string            `json:"uuid"`
+			Categories map[string]string `json:"categories"`
+		} `json:"metadata"`
+	} `json:"entities"`
+	Metadata struct {
+		TotalMatches int `json:"total_matches"`
+	} `json:"metadata"`
+}
+
+type addNodesRequest struct {
+	Count int `json:"count"`
+}
+
+type removeNodesRequest struct {
+	NodeList []string `json:"node_list"`
+}
+
+// prismClient talks to the Karbon and Prism Central v3 APIs.
+type prismClient interface {
+	listNodePools(cluster string) ([]nodePool, error)
+	addNodes(cluster, pool string, count int) error
+	removeNodes(cluster, pool string, hostnames []string) error
+	listVMs() ([]vm, error)
+}
+
+type prismHTTPClient struct {
+	endpoint   string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func newPrismHTTPClient(endpoint, username, password string, insecure bool) *prismHTTPClient {
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+	}
+	return &prismHTTPClient{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Transport: transport, Timeout: requestTimeout},
+	}
+}
+
+func (c *prismHTTPClient) listNodePools(cluster string) ([]nodePool, error) {
+	pools := make([]nodePool, 0)
+	if err := c.do(http.MethodGet, fmt.Sprintf(karbonNodePoolsPath, url.PathEscape(cluster)), nil, &pools); err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
+func (c *prismHTTPClient) addNodes(cluster, pool string, count int) error {
+	path := fmt.Sprintf(karbonAddNodesPath, url.PathEscape(cluster), url.PathEscape(pool))
+	return c.do(http.MethodPost, path, &addNodesRequest{Count: count}, nil)
+}
+
+func (c *prismHTTPClient) removeNodes(cluster, pool string, hostnames []string) error {
+	path := fmt.Sprintf(karbonRemoveNodePath, url.PathEscape(cluster), url.PathEscape(pool))
+	return c.do(http.MethodPost, path, &removeNodesRequest{NodeList: hostnames}, nil)
+}
+
+func (c *prismHTTPClient) listVMs() ([]vm, error) {
+	result := make([]vm, 0)
+	for offset := 0; ; {
+		response := &vmsListResponse{}
+		request := &vmsListRequest{Kind: "vm", Length: vmsListPageSize, Offset: offset}
+		if err := c.do(http.MethodPost, prismVMsListPath, request, response); err != nil {
+			return nil, err
+		}
+		for _, entity := range response.Entities {
+			result = append(result, vm{
+				UUID:       entity.Metadata.UUID,
+				Name:       entity.Spec.Name,
+				Categories: entity.Metadata.Categories,
+			})
+		}
+		offset += len(response.Entities)
+		if len(response.Entities) == 0 || offset >= response.Metadata.TotalMatches {
+			return result, nil
+		}
+	}
+}
+
+func (c *prismHTTPClient) do(method, path string, body interface{}, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	request, err := http.NewRequest(method, c.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(c.username, c.password)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	klog.V(5).Infof("Prism request: %s %s", method, path)
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s failed to read response: %v", method, path, err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, response.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("%s %s returned invalid response: %v", method, path, err)
+	}
+	return nil
+}
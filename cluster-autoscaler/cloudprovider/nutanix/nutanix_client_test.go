@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrismHTTPClient(t *testing.T) {
+	var requests []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		body := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+
+		switch r.URL.EscapedPath() {
+		case "/karbon/v1-beta.1/k8s/clusters/prod/node-pools":
+			fmt.Fprint(w, `[{"name": "workers", "ahv_config": {"cpu": 4, "memory_mib": 8192, "disk_mib": 122880},
+				"nodes": [{"hostname": "karbon-prod-worker-0", "ipv4_address": "10.0.0.10"}]}]`)
+		case "/api/nutanix/v3/vms/list":
+			if body["offset"].(float64) == 0 {
+				fmt.Fprint(w, `{"entities": [{"spec": {"name": "vm-0"}, "metadata": {"uuid": "uuid-0", "categories": {"Team": "a"}}}],
+					"metadata": {"total_matches": 2}}`)
+			} else {
+				fmt.Fprint(w, `{"entities": [{"spec": {"name": "vm-1"}, "metadata": {"uuid": "uuid-1"}}], "metadata": {"total_matches": 2}}`)
+			}
+		case "/karbon/v1-alpha.1/k8s/clusters/prod/node-pools/workers/add-nodes",
+			"/karbon/v1-alpha.1/k8s/clusters/prod/node-pools/workers/remove-nodes":
+			fmt.Fprint(w, `{"task_uuid": "task"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := newPrismHTTPClient(server.URL+"/", "admin", "secret", false)
+
+	pools, err := client.listNodePools("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []nodePool{{
+		Name:      "workers",
+		AHVConfig: nodePoolAHV{CPU: 4, MemoryMib: 8192, DiskMib: 122880},
+		Nodes:     []nodePoolNode{{Hostname: "karbon-prod-worker-0", IPv4Address: "10.0.0.10"}},
+	}}, pools)
+
+	vms, err := client.listVMs()
+	assert.NoError(t, err)
+	assert.Equal(t, []vm{
+		{UUID: "uuid-0", Name: "vm-0", Categories: map[string]string{"Team": "a"}},
+		{UUID: "uuid-1", Name: "vm-1"},
+	}, vms)
+
+	assert.NoError(t, client.addNodes("prod", "workers", 2))
+	assert.NoError(t, client.removeNodes("prod", "workers", []string{"karbon-prod-worker-0"}))
+	assert.Equal(t, []string{
+		"GET /karbon/v1-beta.1/k8s/clusters/prod/node-pools",
+		"POST /api/nutanix/v3/vms/list",
+		"POST /api/nutanix/v3/vms/list",
+		"POST /karbon/v1-alpha.1/k8s/clusters/prod/node-pools/workers/add-nodes",
+		"POST /karbon/v1-alpha.1/k8s/clusters/prod/node-pools/workers/remove-nodes",
+	}, requests)
+	assert.Equal(t, float64(2), bodies[3]["count"])
+	assert.Equal(t, []interface{}{"karbon-prod-worker-0"}, bodies[4]["node_list"])
+
+	_, err = client.listNodePools("missing")
+	assert.EqualError(t, err, `GET /karbon/v1-beta.1/k8s/clusters/missing/node-pools failed with status 404: {"message": "not found"}`)
+
+	unauthorized := newPrismHTTPClient(server.URL, "admin", "wrong", false)
+	_, err = unauthorized.listVMs()
+	assert.Error(t, err)
+}
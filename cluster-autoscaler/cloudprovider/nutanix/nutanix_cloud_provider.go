@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"io"
+	"os"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for Nutanix.
+	ProviderName = "nutanix"
+)
+
+// nutanixCloudProvider implements CloudProvider interface for Nutanix Karbon clusters.
+type nutanixCloudProvider struct {
+	manager         *nutanixManager
+	resourceLimiter *cloudprovider.ResourceLimiter
+}
+
+// BuildNutanix builds the Nutanix cloud provider, scaling Karbon node pools through Prism Central.
+func BuildNutanix(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	var configReader io.Reader
+	if opts.CloudConfig != "" {
+		configFile, err := os.Open(opts.CloudConfig)
+		if err != nil {
+			klog.Fatalf("Couldn't open cloud provider configuration %s: %#v", opts.CloudConfig, err)
+		}
+		defer configFile.Close()
+		configReader = configFile
+	}
+	cfg, err := readConfig(configReader)
+	if err != nil {
+		klog.Fatalf("Failed to read Nutanix cloud provider configuration: %v", err)
+	}
+	if !do.DiscoverySpecified() {
+		klog.Fatalf("Failed to create Nutanix cloud provider: node pools must be passed with --nodes or --node-group-auto-discovery")
+	}
+
+	client := newPrismHTTPClient(cfg.Endpoint, cfg.Username, cfg.Password, cfg.Insecure)
+	manager, err := newNutanixManager(client, cfg.Cluster, do)
+	if err != nil {
+		klog.Fatalf("Failed to create Nutanix manager: %v", err)
+	}
+	if err := manager.refresh(); err != nil {
+		klog.Fatalf("Failed to list Karbon node pools: %v", err)
+	}
+	return &nutanixCloudProvider{
+		manager:         manager,
+		resourceLimiter: rl,
+	}
+}
+
+// Name returns name of the cloud provider.
+func (nutanix *nutanixCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (nutanix *nutanixCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	pools := nutanix.manager.managedNodePools()
+	result := make([]cloudprovider.NodeGroup, 0, len(pools))
+	for _, pool := range pools {
+		result = append(result, pool)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node.
+func (nutanix *nutanixCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	pool, _ := nutanix.manager.nodePoolForProviderID(node.Spec.ProviderID)
+	if pool == nil {
+		return nil, nil
+	}
+	return pool, nil
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+func (nutanix *nutanixCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
+func (nutanix *nutanixCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition provided.
+func (nutanix *nutanixCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (nutanix *nutanixCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return nutanix.resourceLimiter, nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (nutanix *nutanixCloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh reloads Karbon node pools and Prism VMs and discovers new node pools.
+func (nutanix *nutanixCloudProvider) Refresh() error {
+	return nutanix.manager.refresh()
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePrismClient struct {
+	pools   []nodePool
+	vms     []vm
+	added   map[string]int
+	removed map[string][]string
+}
+
+func (c *fakePrismClient) listNodePools(cluster string) ([]nodePool, error) {
+	if cluster != "prod" {
+		return nil, fmt.Errorf("cluster %s not found", cluster)
+	}
+	result := make([]nodePool, 0, len(c.pools))
+	for _, pool := range c.pools {
+		pool.Nodes = append([]nodePoolNode{}, pool.Nodes...)
+		result = append(result, pool)
+	}
+	return result, nil
+}
+
+func (c *fakePrismClient) addNodes(cluster, pool string, count int) error {
+	c.added[pool] += count
+	return nil
+}
+
+func (c *fakePrismClient) removeNodes(cluster, pool string, hostnames []string) error {
+	c.removed[pool] = append(c.removed[pool], hostnames...)
+	return nil
+}
+
+func (c *fakePrismClient) listVMs() ([]vm, error) {
+	return c.vms, nil
+}
+
+func newFakePrismClient() *fakePrismClient {
+	discovered := map[string]string{"ClusterAutoscaler": "enabled", maxSizeCategory: "5", minSizeCategory: "1"}
+	return &fakePrismClient{
+		pools: []nodePool{
+			{
+				Name:      "static",
+				AHVConfig: nodePoolAHV{CPU: 8, MemoryMib: 32768, DiskMib: 102400},
+				Nodes:     []nodePoolNode{{Hostname: "static-0"}, {Hostname: "static-1"}},
+			},
+			{
+				Name:      "discovered",
+				AHVConfig: nodePoolAHV{CPU: 4, MemoryMib: 8192},
+				Nodes:     []nodePoolNode{{Hostname: "discovered-0"}},
+			},
+			{
+				Name:      "ignored",
+				AHVConfig: nodePoolAHV{CPU: 4, MemoryMib: 8192},
+				Nodes:     []nodePoolNode{{Hostname: "ignored-0"}},
+			},
+		},
+		vms: []vm{
+			{UUID: "uuid-static-0", Name: "static-0"},
+			{UUID: "uuid-static-1", Name: "static-1"},
+			{UUID: "uuid-discovered-0", Name: "discovered-0", Categories: discovered},
+			{UUID: "uuid-ignored-0", Name: "ignored-0", Categories: map[string]string{maxSizeCategory: "5"}},
+		},
+		added:   make(map[string]int),
+		removed: make(map[string][]string),
+	}
+}
+
+func buildTestProvider(t *testing.T, client *fakePrismClient) *nutanixCloudProvider {
+	manager, err := newNutanixManager(client, "prod", cloudprovider.NodeGroupDiscoveryOptions{
+		NodeGroupSpecs:              []string{"0:3:static", "1:2:missing"},
+		NodeGroupAutoDiscoverySpecs: []string{"label:ClusterAutoscaler=enabled"},
+	})
+	assert.NoError(t, err)
+	provider := &nutanixCloudProvider{manager: manager}
+	assert.NoError(t, provider.Refresh())
+	return provider
+}
+
+func nodeWithProviderID(name, providerID string) *apiv1.Node {
+	return &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: apiv1.NodeSpec{ProviderID: providerID}}
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := readConfig(strings.NewReader(`{"endpoint": "https://prism:9440", "cluster": "prod", "username": "admin", "password": "secret"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", cfg.Cluster)
+
+	os.Setenv(usernameEnvVar, "env-admin")
+	os.Setenv(passwordEnvVar, "env-secret")
+	defer os.Unsetenv(usernameEnvVar)
+	defer os.Unsetenv(passwordEnvVar)
+	cfg, err = readConfig(strings.NewReader(`{"endpoint": "https://prism:9440", "cluster": "prod"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "env-admin", cfg.Username)
+	assert.Equal(t, "env-secret", cfg.Password)
+
+	_, err = readConfig(strings.NewReader(`{"cluster": "prod"}`))
+	assert.Error(t, err)
+	_, err = readConfig(strings.NewReader(`{"endpoint": "https://prism:9440"}`))
+	assert.Error(t, err)
+	os.Unsetenv(passwordEnvVar)
+	_, err = readConfig(strings.NewReader(`{"endpoint": "https://prism:9440", "cluster": "prod"}`))
+	assert.Error(t, err)
+}
+
+func TestNodeGroups(t *testing.T) {
+	client := newFakePrismClient()
+	provider := buildTestProvider(t, client)
+
+	nodeGroups := provider.NodeGroups()
+	assert.Equal(t, 2, len(nodeGroups))
+	assert.Equal(t, "discovered", nodeGroups[0].Id())
+	assert.Equal(t, 1, nodeGroups[0].MinSize())
+	assert.Equal(t, 5, nodeGroups[0].MaxSize())
+	assert.Equal(t, "static", nodeGroups[1].Id())
+	assert.Equal(t, 0, nodeGroups[1].MinSize())
+	assert.Equal(t, 3, nodeGroups[1].MaxSize())
+
+	// Discovered pools stay managed after being scaled to zero.
+	client.pools[1].Nodes = nil
+	client.vms = client.vms[:2]
+	assert.NoError(t, provider.Refresh())
+	nodeGroups = provider.NodeGroups()
+	assert.Equal(t, 2, len(nodeGroups))
+	size, err := nodeGroups[0].TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider := buildTestProvider(t, newFakePrismClient())
+
+	group, err := provider.NodeGroupForNode(nodeWithProviderID("static-1", "nutanix://uuid-static-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "static", group.Id())
+
+	for _, providerID := range []string{"nutanix://uuid-ignored-0", "nutanix://unknown", "aws:///us-east-1a/i-1", ""} {
+		group, err = provider.NodeGroupForNode(nodeWithProviderID("node", providerID))
+		assert.NoError(t, err)
+		assert.Nil(t, group, providerID)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	client := newFakePrismClient()
+	client.pools[0].Nodes = append(client.pools[0].Nodes, nodePoolNode{Hostname: "static-2"})
+	provider := buildTestProvider(t, client)
+
+	instances, err := provider.NodeGroups()[1].Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{{Id: "nutanix://uuid-static-0"}, {Id: "nutanix://uuid-static-1"}}, instances)
+}
+
+func TestIncreaseSize(t *testing.T) {
+	client := newFakePrismClient()
+	provider := buildTestProvider(t, client)
+	ng := provider.NodeGroups()[1]
+
+	assert.Error(t, ng.IncreaseSize(0))
+	assert.Error(t, ng.IncreaseSize(2))
+	assert.NoError(t, ng.IncreaseSize(1))
+	assert.Equal(t, map[string]int{"static": 1}, client.added)
+
+	// The requested node counts towards the target size until it shows up in the pool.
+	assert.NoError(t, provider.Refresh())
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+	assert.Error(t, ng.IncreaseSize(1))
+
+	client.pools[0].Nodes = append(client.pools[0].Nodes, nodePoolNode{Hostname: "static-2"})
+	assert.NoError(t, provider.Refresh())
+	size, err = ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+	client.pools[0].Nodes = client.pools[0].Nodes[:2]
+	assert.NoError(t, provider.Refresh())
+	size, err = ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	client := newFakePrismClient()
+	provider := buildTestProvider(t, client)
+	ng := provider.NodeGroups()[1]
+
+	assert.Error(t, ng.DecreaseTargetSize(-1))
+	assert.NoError(t, ng.IncreaseSize(1))
+	assert.Error(t, ng.DecreaseTargetSize(0))
+	assert.Error(t, ng.DecreaseTargetSize(-2))
+	assert.NoError(t, ng.DecreaseTargetSize(-1))
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+}
+
+func TestDeleteNodes(t *testing.T) {
+	client := newFakePrismClient()
+	provider := buildTestProvider(t, client)
+	discovered := provider.NodeGroups()[0]
+	static := provider.NodeGroups()[1]
+
+	assert.Error(t, discovered.DeleteNodes([]*apiv1.Node{nodeWithProviderID("discovered-0", "nutanix://uuid-discovered-0")}))
+	assert.Error(t, static.DeleteNodes([]*apiv1.Node{nodeWithProviderID("discovered-0", "nutanix://uuid-discovered-0")}))
+	assert.Error(t, static.DeleteNodes([]*apiv1.Node{nodeWithProviderID("static-0", "")}))
+	assert.Empty(t, client.removed)
+
+	assert.NoError(t, static.DeleteNodes([]*apiv1.Node{nodeWithProviderID("static-0", "nutanix://uuid-static-0")}))
+	assert.Equal(t, map[string][]string{"static": {"static-0"}}, client.removed)
+	size, err := static.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+}
+
+func TestTemplateNodeInfo(t *testing.T) {
+	provider := buildTestProvider(t, newFakePrismClient())
+
+	nodeInfo, err := provider.NodeGroups()[1].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node := nodeInfo.Node()
+	cpu := node.Status.Allocatable[apiv1.ResourceCPU]
+	assert.Equal(t, int64(8), cpu.Value())
+	memory := node.Status.Allocatable[apiv1.ResourceMemory]
+	assert.Equal(t, int64(32*1024*1024*1024), memory.Value())
+	storage := node.Status.Capacity[apiv1.ResourceEphemeralStorage]
+	assert.Equal(t, int64(100*1024*1024*1024), storage.Value())
+	assert.Equal(t, cloudprovider.DefaultOS, node.Labels[apiv1.LabelOSStable])
+	assert.Equal(t, 1, len(nodeInfo.Pods()))
+
+	nodeInfo, err = provider.NodeGroups()[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	_, found := nodeInfo.Node().Status.Capacity[apiv1.ResourceEphemeralStorage]
+	assert.False(t, found)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	usernameEnvVar = "NUTANIX_USERNAME"
+	passwordEnvVar = "NUTANIX_PASSWORD"
+)
+
+// CloudConfig is the Nutanix cloud provider configuration, read as JSON from --cloud-config.
+type CloudConfig struct {
+	// Endpoint is the Prism Central URL, e.g. https://prism-central:9440.
+	Endpoint string `json:"endpoint"`
+	// Username and Password are the Prism Central credentials. They default to the
+	// NUTANIX_USERNAME and NUTANIX_PASSWORD environment variables.
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Insecure disables verification of the Prism Central certificate.
+	Insecure bool `json:"insecure"`
+	// Cluster is the name of the Karbon cluster whose node pools are scaled.
+	Cluster string `json:"cluster"`
+}
+
+func readConfig(configReader io.Reader) (*CloudConfig, error) {
+	cfg := &CloudConfig{}
+	if configReader != nil {
+		if err := json.NewDecoder(configReader).Decode(cfg); err != nil {
+			return nil, fmt.Errorf("nutanix: failed to decode cloud config: %v", err)
+		}
+	}
+	if cfg.Username == "" {
+		cfg.Username = os.Getenv(usernameEnvVar)
+	}
+	if cfg.Password == "" {
+		cfg.Password = os.Getenv(passwordEnvVar)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cc *CloudConfig) validate() error {
+	if cc.Endpoint == "" {
+		return fmt.Errorf("nutanix: cloud config must have an endpoint")
+	}
+	if cc.Cluster == "" {
+		return fmt.Errorf("nutanix: cloud config must have a cluster")
+	}
+	if cc.Username == "" || cc.Password == "" {
+		return fmt.Errorf("nutanix: credentials must be set in the cloud config or with %s and %s", usernameEnvVar, passwordEnvVar)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+	"k8s.io/klog"
+)
+
+const (
+	// minSizeCategory and maxSizeCategory are the Prism categories setting the size limits of
+	// autodiscovered node pools. They have to be set on the VMs of the pool.
+	minSizeCategory = "ClusterAutoscalerMinSize"
+	maxSizeCategory = "ClusterAutoscalerMaxSize"
+
+	providerIDPrefix = "nutanix://"
+)
+
+// nutanixManager caches Karbon node pools and Prism VMs and keeps track of the node pools
+// managed by the autoscaler.
+type nutanixManager struct {
+	client  prismClient
+	cluster string
+	// staticPools are the node pools passed with --nodes, by name.
+	staticPools map[string]*dynamic.NodeGroupSpec
+	// discoverySpecs select node pools by the categories of their VMs.
+	discoverySpecs []cloudprovider.LabelAutoDiscoveryConfig
+
+	cacheMutex sync.Mutex
+	nodePools  map[string]*nodePool
+	vmsByName  map[string]vm
+	vmsByUUID  map[string]vm
+	// managed are the node pools scaled by the autoscaler. Discovered pools stay managed while
+	// they exist, so pools scaled to zero don't disappear together with their VMs.
+	managed map[string]*karbonNodePool
+}
+
+func newNutanixManager(client prismClient, cluster string, discoveryOpts cloudprovider.NodeGroupDiscoveryOptions) (*nutanixManager, error) {
+	m := &nutanixManager{
+		client:      client,
+		cluster:     cluster,
+		staticPools: make(map[string]*dynamic.NodeGroupSpec),
+		nodePools:   make(map[string]*nodePool),
+		vmsByName:   make(map[string]vm),
+		vmsByUUID:   make(map[string]vm),
+		managed:     make(map[string]*karbonNodePool),
+	}
+	for _, value := range discoveryOpts.NodeGroupSpecs {
+		spec, err := dynamic.SpecFromString(value, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node group spec: %v", err)
+		}
+		m.staticPools[spec.Name] = spec
+	}
+	specs, err := discoveryOpts.ParseLabelAutoDiscoverySpecs()
+	if err != nil {
+		return nil, err
+	}
+	m.discoverySpecs = specs
+	return m, nil
+}
+
+// refresh reloads node pools and VMs and updates the set of managed node pools.
+func (m *nutanixManager) refresh() error {
+	pools, err := m.client.listNodePools(m.cluster)
+	if err != nil {
+		return fmt.Errorf("failed to list node pools of cluster %s: %v", m.cluster, err)
+	}
+	vms, err := m.client.listVMs()
+	if err != nil {
+		return fmt.Errorf("failed to list VMs: %v", err)
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+
+	m.nodePools = make(map[string]*nodePool, len(pools))
+	for i := range pools {
+		m.nodePools[pools[i].Name] = &pools[i]
+	}
+	m.vmsByName = make(map[string]vm, len(vms))
+	m.vmsByUUID = make(map[string]vm, len(vms))
+	for _, v := range vms {
+		m.vmsByName[v.Name] = v
+		m.vmsByUUID[v.UUID] = v
+	}
+
+	managed := make(map[string]*karbonNodePool)
+	for name, pool := range m.nodePools {
+		if ng, found := m.managed[name]; found {
+			managed[name] = ng
+		} else if spec, found := m.staticPools[name]; found {
+			managed[name] = &karbonNodePool{manager: m, name: name, minSize: spec.MinSize, maxSize: spec.MaxSize}
+		} else if ng := m.discoverNodePool(pool); ng != nil {
+			klog.V(1).Infof("Discovered node pool %s (%d:%d)", name, ng.minSize, ng.maxSize)
+			managed[name] = ng
+		} else {
+			continue
+		}
+		// Nodes requested from Karbon show up in the pool once their VMs are created.
+		if len(pool.Nodes) >= managed[name].requestedSize {
+			managed[name].requestedSize = 0
+		}
+	}
+	for name := range m.staticPools {
+		if _, found := m.nodePools[name]; !found {
+			klog.Warningf("Node pool %s doesn't exist in cluster %s", name, m.cluster)
+		}
+	}
+	m.managed = managed
+	return nil
+}
+
+// discoverNodePool returns the node group for the pool if its VMs match the autodiscovery specs.
+func (m *nutanixManager) discoverNodePool(pool *nodePool) *karbonNodePool {
+	if len(m.discoverySpecs) == 0 {
+		return nil
+	}
+	for _, node := range pool.Nodes {
+		v, found := m.vmsByName[node.Hostname]
+		if !found || !matchDiscoveryConfig(v.Categories, m.discoverySpecs) {
+			continue
+		}
+		minSize, err := sizeCategory(v.Categories, minSizeCategory, 0)
+		if err != nil {
+			klog.Warningf("Ignoring node pool %s: %v", pool.Name, err)
+			return nil
+		}
+		maxSize, err := sizeCategory(v.Categories, maxSizeCategory, -1)
+		if err != nil || maxSize < 0 {
+			klog.Warningf("Ignoring node pool %s: %s category must be set to a number", pool.Name, maxSizeCategory)
+			return nil
+		}
+		if minSize > maxSize {
+			klog.Warningf("Ignoring node pool %s: minimum size %d is greater than maximum size %d", pool.Name, minSize, maxSize)
+			return nil
+		}
+		return &karbonNodePool{manager: m, name: pool.Name, minSize: minSize, maxSize: maxSize}
+	}
+	return nil
+}
+
+func matchDiscoveryConfig(categories map[string]string, configs []cloudprovider.LabelAutoDiscoveryConfig) bool {
+	for _, c := range configs {
+		if len(c.Selector) == 0 {
+			return false
+		}
+		for k, v := range c.Selector {
+			value, found := categories[k]
+			if !found || (v != "" && value != v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sizeCategory(categories map[string]string, key string, defaultValue int) (int, error) {
+	value, found := categories[key]
+	if !found {
+		return defaultValue, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid %s category %q", key, value)
+	}
+	return size, nil
+}
+
+// managedNodePools returns the node pools scaled by the autoscaler, sorted by name.
+func (m *nutanixManager) managedNodePools() []*karbonNodePool {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	result := make([]*karbonNodePool, 0, len(m.managed))
+	for _, ng := range m.managed {
+		result = append(result, ng)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// nodePool returns a copy of the cached node pool, or nil if it doesn't exist.
+func (m *nutanixManager) nodePool(name string) *nodePool {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	pool, found := m.nodePools[name]
+	if !found {
+		return nil
+	}
+	result := *pool
+	result.Nodes = append([]nodePoolNode{}, pool.Nodes...)
+	return &result
+}
+
+// nodePoolForProviderID returns the managed node pool and the hostname of the VM with the given provider id.
+func (m *nutanixManager) nodePoolForProviderID(providerID string) (*karbonNodePool, string) {
+	if !strings.HasPrefix(providerID, providerIDPrefix) {
+		return nil, ""
+	}
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	v, found := m.vmsByUUID[strings.TrimPrefix(providerID, providerIDPrefix)]
+	if !found {
+		return nil, ""
+	}
+	for name, pool := range m.nodePools {
+		for _, node := range pool.Nodes {
+			if node.Hostname == v.Name {
+				return m.managed[name], v.Name
+			}
+		}
+	}
+	return nil, ""
+}
+
+// providerIDForHostname returns the provider id of the pool node, or false if its VM isn't known yet.
+func (m *nutanixManager) providerIDForHostname(hostname string) (string, bool) {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	v, found := m.vmsByName[hostname]
+	if !found {
+		return "", false
+	}
+	return providerIDPrefix + v.UUID, true
+}
+
+// targetSize returns the number of nodes of the pool, including requested nodes that aren't part of it yet.
+func (m *nutanixManager) targetSize(ng *karbonNodePool) int {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	return m.targetSizeLocked(ng)
+}
+
+func (m *nutanixManager) targetSizeLocked(ng *karbonNodePool) int {
+	size := 0
+	if pool, found := m.nodePools[ng.name]; found {
+		size = len(pool.Nodes)
+	}
+	if ng.requestedSize > size {
+		return ng.requestedSize
+	}
+	return size
+}
+
+func (m *nutanixManager) addNodes(ng *karbonNodePool, count int) error {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	size := m.targetSizeLocked(ng)
+	if err := m.client.addNodes(m.cluster, ng.name, count); err != nil {
+		return fmt.Errorf("failed to add %d nodes to node pool %s: %v", count, ng.name, err)
+	}
+	ng.requestedSize = size + count
+	return nil
+}
+
+// decreaseRequestedSize gives up on nodes requested from Karbon that aren't part of the pool yet.
+func (m *nutanixManager) decreaseRequestedSize(ng *karbonNodePool, delta int) error {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	size := 0
+	if pool, found := m.nodePools[ng.name]; found {
+		size = len(pool.Nodes)
+	}
+	if ng.requestedSize+delta < size {
+		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
+			m.targetSizeLocked(ng), delta, size)
+	}
+	ng.requestedSize += delta
+	return nil
+}
+
+// removeNodes removes the nodes from the pool and drops them from the cache.
+func (m *nutanixManager) removeNodes(ng *karbonNodePool, hostnames []string) error {
+	if err := m.client.removeNodes(m.cluster, ng.name, hostnames); err != nil {
+		return fmt.Errorf("failed to remove nodes %v from node pool %s: %v", hostnames, ng.name, err)
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	if ng.requestedSize > 0 {
+		ng.requestedSize -= len(hostnames)
+	}
+	cached, found := m.nodePools[ng.name]
+	if !found {
+		return nil
+	}
+	removed := make(map[string]bool, len(hostnames))
+	for _, hostname := range hostnames {
+		removed[hostname] = true
+	}
+	nodes := make([]nodePoolNode, 0, len(cached.Nodes))
+	for _, node := range cached.Nodes {
+		if !removed[node.Hostname] {
+			nodes = append(nodes, node)
+		}
+	}
+	cached.Nodes = nodes
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nutanix
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	defaultPodsPerNode = 110
+	mib                = 1024 * 1024
+)
+
+// karbonNodePool implements NodeGroup for a Karbon node pool.
+type karbonNodePool struct {
+	manager *nutanixManager
+	name    string
+	minSize int
+	maxSize int
+	// requestedSize is the pool size requested from Karbon while added nodes aren't part of the
+	// pool yet, 0 otherwise. Guarded by the manager cache mutex.
+	requestedSize int
+}
+
+var _ cloudprovider.NodeGroup = (*karbonNodePool)(nil)
+
+// MaxSize returns maximum size of the node group.
+func (ng *karbonNodePool) MaxSize() int {
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *karbonNodePool) MinSize() int {
+	return ng.minSize
+}
+
+// TargetSize returns the current target size of the node group.
+func (ng *karbonNodePool) TargetSize() (int, error) {
+	return ng.manager.targetSize(ng), nil
+}
+
+// IncreaseSize adds nodes to the Karbon node pool.
+func (ng *karbonNodePool) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	size := ng.manager.targetSize(ng)
+	if size+delta > ng.MaxSize() {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.MaxSize())
+	}
+	return ng.manager.addNodes(ng, delta)
+}
+
+// DeleteNodes removes the given nodes from the Karbon node pool.
+func (ng *karbonNodePool) DeleteNodes(nodes []*apiv1.Node) error {
+	size := ng.manager.targetSize(ng)
+	if size-len(nodes) < ng.MinSize() {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", size-len(nodes), ng.MinSize())
+	}
+	hostnames := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		pool, hostname := ng.manager.nodePoolForProviderID(node.Spec.ProviderID)
+		if pool != ng {
+			return fmt.Errorf("node %s doesn't belong to node pool %s", node.Name, ng.name)
+		}
+		hostnames = append(hostnames, hostname)
+	}
+	return ng.manager.removeNodes(ng, hostnames)
+}
+
+// DecreaseTargetSize decreases the target size of the node group. Karbon can't cancel adding
+// nodes, so only the requested size tracked by the autoscaler is lowered.
+func (ng *karbonNodePool) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	return ng.manager.decreaseRequestedSize(ng, delta)
+}
+
+// Id returns the node pool name.
+func (ng *karbonNodePool) Id() string {
+	return ng.name
+}
+
+// Debug returns a debug string for the node pool.
+func (ng *karbonNodePool) Debug() string {
+	return fmt.Sprintf("%s (%d:%d)", ng.Id(), ng.MinSize(), ng.MaxSize())
+}
+
+// Nodes returns the nodes of the pool whose VMs are known to Prism.
+func (ng *karbonNodePool) Nodes() ([]cloudprovider.Instance, error) {
+	pool := ng.manager.nodePool(ng.name)
+	if pool == nil {
+		return []cloudprovider.Instance{}, nil
+	}
+	instances := make([]cloudprovider.Instance, 0, len(pool.Nodes))
+	for _, node := range pool.Nodes {
+		if providerID, found := ng.manager.providerIDForHostname(node.Hostname); found {
+			instances = append(instances, cloudprovider.Instance{Id: providerID})
+		}
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template built from the VM spec of the pool, used when
+// scaling up from zero.
+func (ng *karbonNodePool) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	pool := ng.manager.nodePool(ng.name)
+	if pool == nil {
+		return nil, fmt.Errorf("node pool %s not found", ng.name)
+	}
+	if pool.AHVConfig.CPU <= 0 || pool.AHVConfig.MemoryMib <= 0 {
+		return nil, fmt.Errorf("node pool %s has no VM spec", ng.name)
+	}
+
+	name := fmt.Sprintf("%s-template", ng.name)
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				apiv1.LabelHostname:   name,
+				apiv1.LabelOSStable:   cloudprovider.DefaultOS,
+				apiv1.LabelArchStable: cloudprovider.DefaultArch,
+			},
+		},
+		Spec: apiv1.NodeSpec{
+			ProviderID: providerIDPrefix + name,
+		},
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    *resource.NewQuantity(pool.AHVConfig.CPU, resource.DecimalSI),
+				apiv1.ResourceMemory: *resource.NewQuantity(pool.AHVConfig.MemoryMib*mib, resource.BinarySI),
+				apiv1.ResourcePods:   *resource.NewQuantity(defaultPodsPerNode, resource.DecimalSI),
+			},
+			Conditions: cloudprovider.BuildReadyConditions(),
+		},
+	}
+	if pool.AHVConfig.DiskMib > 0 {
+		node.Status.Capacity[apiv1.ResourceEphemeralStorage] = *resource.NewQuantity(pool.AHVConfig.DiskMib*mib, resource.BinarySI)
+	}
+	node.Status.Allocatable = node.Status.Capacity
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.name))
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (ng *karbonNodePool) Exist() bool {
+	return true
+}
+
+// Create creates the node group on the cloud provider side.
+func (ng *karbonNodePool) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side.
+func (ng *karbonNodePool) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *karbonNodePool) Autoprovisioned() bool {
+	return false
+}
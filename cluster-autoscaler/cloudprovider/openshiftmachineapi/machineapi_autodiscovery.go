@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+const (
+	autoDiscovererTypeMachineAPI = "machineapi"
+
+	autoDiscovererKeyNamespace = "namespace"
+	autoDiscovererKeyLabel     = "label"
+	autoDiscovererKeyMinNodes  = "min"
+	autoDiscovererKeyMaxNodes  = "max"
+)
+
+var (
+	// errInvalidAutoDiscoverySpec is the error returned when a
+	// --node-group-auto-discovery spec is malformed.
+	errInvalidAutoDiscoverySpec = errors.New("invalid node group auto-discovery spec")
+)
+
+// autoDiscoveryConfig opts the scalable resources in namespace
+// matching selector in as node groups with the given bounds, without
+// them being annotated. It is parsed from a spec such as
+// machineapi:namespace=ns,label=key=value,min=0,max=10; label can be
+// given several times and takes any label selector requirement.
+type autoDiscoveryConfig struct {
+	// namespace is empty for all namespaces.
+	namespace string
+	selector  labels.Selector
+	minSize   int
+	maxSize   int
+}
+
+func parseAutoDiscoverySpecs(do cloudprovider.NodeGroupDiscoveryOptions) ([]autoDiscoveryConfig, error) {
+	cfgs := make([]autoDiscoveryConfig, len(do.NodeGroupAutoDiscoverySpecs))
+	var err error
+	for i, spec := range do.NodeGroupAutoDiscoverySpecs {
+		cfgs[i], err = parseAutoDiscoverySpec(spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfgs, nil
+}
+
+func parseAutoDiscoverySpec(spec string) (autoDiscoveryConfig, error) {
+	cfg := autoDiscoveryConfig{}
+
+	tokens := strings.SplitN(spec, ":", 2)
+	if len(tokens) != 2 {
+		return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "spec %q should be %s:key=value,key=value", spec, autoDiscovererTypeMachineAPI)
+	}
+	if discoverer := tokens[0]; discoverer != autoDiscovererTypeMachineAPI {
+		return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "unsupported discoverer %q", discoverer)
+	}
+
+	var requirements []string
+	maxFound := false
+	for _, arg := range strings.Split(tokens[1], ",") {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "invalid key=value pair %q", arg)
+		}
+		k, v := kv[0], kv[1]
+
+		var err error
+		switch k {
+		case autoDiscovererKeyNamespace:
+			cfg.namespace = v
+		case autoDiscovererKeyLabel:
+			requirements = append(requirements, v)
+		case autoDiscovererKeyMinNodes:
+			if cfg.minSize, err = strconv.Atoi(v); err != nil || cfg.minSize < 0 {
+				return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "invalid minimum nodes %q", v)
+			}
+		case autoDiscovererKeyMaxNodes:
+			if cfg.maxSize, err = strconv.Atoi(v); err != nil {
+				return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "invalid maximum nodes %q", v)
+			}
+			maxFound = true
+		default:
+			return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "unsupported key %q", k)
+		}
+	}
+
+	selector, err := labels.Parse(strings.Join(requirements, ","))
+	if err != nil {
+		return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "invalid label selector: %v", err)
+	}
+	cfg.selector = selector
+
+	if cfg.namespace == "" && len(requirements) == 0 {
+		return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "spec %q needs a namespace or a label", spec)
+	}
+	if !maxFound || cfg.maxSize < 1 {
+		return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "spec %q needs a maximum size of at least 1", spec)
+	}
+	if cfg.minSize > cfg.maxSize {
+		return cfg, errors.Wrapf(errInvalidAutoDiscoverySpec, "minimum size %d is greater than maximum size %d", cfg.minSize, cfg.maxSize)
+	}
+	return cfg, nil
+}
+
+func (cfg autoDiscoveryConfig) matches(meta metav1.ObjectMeta) bool {
+	if cfg.namespace != "" && cfg.namespace != meta.Namespace {
+		return false
+	}
+	return cfg.selector.Matches(labels.Set(meta.Labels))
+}
+
+// findAutoDiscoveryConfig returns the first of the auto-discovery
+// configs matching the scalable resource, or nil if there is none.
+func (c *machineController) findAutoDiscoveryConfig(meta metav1.ObjectMeta) *autoDiscoveryConfig {
+	for i := range c.autoDiscoveryConfigs {
+		if c.autoDiscoveryConfigs[i].matches(meta) {
+			return &c.autoDiscoveryConfigs[i]
+		}
+	}
+	return nil
+}
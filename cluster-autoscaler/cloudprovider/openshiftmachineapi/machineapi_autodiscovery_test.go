@@ -0,0 +1,230 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+func TestParseAutoDiscoverySpec(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		spec        string
+		expectedErr bool
+		matches     []metav1.ObjectMeta
+		noMatches   []metav1.ObjectMeta
+		min, max    int
+	}{{
+		description: "namespace",
+		spec:        "machineapi:namespace=ns,min=1,max=5",
+		min:         1,
+		max:         5,
+		matches:     []metav1.ObjectMeta{{Namespace: "ns"}},
+		noMatches:   []metav1.ObjectMeta{{Namespace: "other"}},
+	}, {
+		description: "labels",
+		spec:        "machineapi:label=role=worker,label=!spot,max=3",
+		max:         3,
+		matches:     []metav1.ObjectMeta{{Namespace: "ns", Labels: map[string]string{"role": "worker"}}},
+		noMatches: []metav1.ObjectMeta{
+			{Namespace: "ns"},
+			{Namespace: "ns", Labels: map[string]string{"role": "worker", "spot": ""}},
+		},
+	}, {
+		description: "namespace and label",
+		spec:        "machineapi:namespace=ns,label=role,max=3",
+		max:         3,
+		matches:     []metav1.ObjectMeta{{Namespace: "ns", Labels: map[string]string{"role": "infra"}}},
+		noMatches:   []metav1.ObjectMeta{{Namespace: "other", Labels: map[string]string{"role": "infra"}}},
+	}, {
+		description: "other discoverer",
+		spec:        "mig:namePrefix=pfx,min=0,max=10",
+		expectedErr: true,
+	}, {
+		description: "no key=value",
+		spec:        "machineapi",
+		expectedErr: true,
+	}, {
+		description: "unsupported key",
+		spec:        "machineapi:namespace=ns,tag=foo,max=3",
+		expectedErr: true,
+	}, {
+		description: "no namespace or label",
+		spec:        "machineapi:min=0,max=3",
+		expectedErr: true,
+	}, {
+		description: "no max",
+		spec:        "machineapi:namespace=ns",
+		expectedErr: true,
+	}, {
+		description: "min greater than max",
+		spec:        "machineapi:namespace=ns,min=4,max=3",
+		expectedErr: true,
+	}, {
+		description: "negative min",
+		spec:        "machineapi:namespace=ns,min=-1,max=3",
+		expectedErr: true,
+	}, {
+		description: "invalid label selector",
+		spec:        "machineapi:label=ro le,max=3",
+		expectedErr: true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := parseAutoDiscoverySpec(tc.spec)
+			if tc.expectedErr {
+				if errors.Cause(err) != errInvalidAutoDiscoverySpec {
+					t.Fatalf("expected error %v, got %v", errInvalidAutoDiscoverySpec, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.minSize != tc.min || cfg.maxSize != tc.max {
+				t.Errorf("expected bounds %d-%d, got %d-%d", tc.min, tc.max, cfg.minSize, cfg.maxSize)
+			}
+			for _, meta := range tc.matches {
+				if !cfg.matches(meta) {
+					t.Errorf("expected %+v to match", meta)
+				}
+			}
+			for _, meta := range tc.noMatches {
+				if cfg.matches(meta) {
+					t.Errorf("expected %+v not to match", meta)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAutoDiscoverySpecs(t *testing.T) {
+	cfgs, err := parseAutoDiscoverySpecs(cloudprovider.NodeGroupDiscoveryOptions{})
+	if err != nil || len(cfgs) != 0 {
+		t.Errorf("expected no configs and no error, got %v and %v", cfgs, err)
+	}
+
+	cfgs, err = parseAutoDiscoverySpecs(cloudprovider.NodeGroupDiscoveryOptions{
+		NodeGroupAutoDiscoverySpecs: []string{"machineapi:namespace=a,max=1", "machineapi:namespace=b,max=2"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfgs) != 2 || cfgs[0].namespace != "a" || cfgs[1].namespace != "b" {
+		t.Errorf("expected configs for namespaces a and b, got %+v", cfgs)
+	}
+
+	if _, err := parseAutoDiscoverySpecs(cloudprovider.NodeGroupDiscoveryOptions{
+		NodeGroupAutoDiscoverySpecs: []string{"machineapi:namespace=a,max=1", "machineapi:namespace=b"},
+	}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestControllerScalingBoundsFromAutoDiscovery(t *testing.T) {
+	controller, stop := mustCreateTestController(t)
+	defer stop()
+
+	for _, spec := range []string{"machineapi:namespace=other,min=3,max=9", "machineapi:label=autoscale=true,min=1,max=6"} {
+		cfg, err := parseAutoDiscoverySpec(spec)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		controller.autoDiscoveryConfigs = append(controller.autoDiscoveryConfigs, cfg)
+	}
+
+	for _, tc := range []struct {
+		description string
+		meta        metav1.ObjectMeta
+		min, max    int
+	}{{
+		description: "not discovered",
+		meta:        metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace},
+		min:         0,
+		max:         0,
+	}, {
+		description: "discovered by label",
+		meta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace, Labels: map[string]string{
+			"autoscale": "true",
+		}},
+		min: 1,
+		max: 6,
+	}, {
+		description: "discovered by namespace first",
+		meta: metav1.ObjectMeta{Name: "machineset-0", Namespace: "other", Labels: map[string]string{
+			"autoscale": "true",
+		}},
+		min: 3,
+		max: 9,
+	}, {
+		description: "annotations take precedence",
+		meta: metav1.ObjectMeta{Name: "machineset-0", Namespace: "other", Annotations: map[string]string{
+			nodeGroupMaxSizeAnnotationKey: "2",
+		}},
+		min: 0,
+		max: 2,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			min, max, err := controller.scalingBounds("MachineSet", tc.meta)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if min != tc.min || max != tc.max {
+				t.Errorf("expected bounds %d-%d, got %d-%d", tc.min, tc.max, min, max)
+			}
+		})
+	}
+}
+
+func TestControllerNodeGroupsFromAutoDiscovery(t *testing.T) {
+	testConfigs := createMachineSetTestConfigs(testNamespace, 2, 1, nil)
+	testConfigs[0].machineSet.Labels = map[string]string{"autoscale": "true"}
+
+	controller, stop := mustCreateTestController(t, testConfigs...)
+	defer stop()
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodegroups) != 0 {
+		t.Fatalf("expected no nodegroups without auto-discovery, got %d", len(nodegroups))
+	}
+
+	cfg, err := parseAutoDiscoverySpec("machineapi:label=autoscale=true,min=1,max=4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	controller.autoDiscoveryConfigs = []autoDiscoveryConfig{cfg}
+
+	nodegroups, err = controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodegroups) != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", len(nodegroups))
+	}
+	if actual, expected := nodegroups[0].Name(), testConfigs[0].machineSet.Name; actual != expected {
+		t.Errorf("expected nodegroup %q, got %q", expected, actual)
+	}
+	if nodegroups[0].MinSize() != 1 || nodegroups[0].MaxSize() != 4 {
+		t.Errorf("expected bounds 1-4, got %d-%d", nodegroups[0].MinSize(), nodegroups[0].MaxSize())
+	}
+}
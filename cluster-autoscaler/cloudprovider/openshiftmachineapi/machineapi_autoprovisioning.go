@@ -0,0 +1,347 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// machineTemplatesConfigMapName is the name of the ConfigMaps
+	// whose data maps machine types to JSON encoded
+	// autoprovisioningTemplates, from which MachineDeployments are
+	// created in the namespace of the ConfigMap by node
+	// autoprovisioning.
+	machineTemplatesConfigMapName = "cluster-autoscaler-machine-templates"
+
+	// autoprovisionedAnnotationKey annotates the MachineDeployments
+	// created by node autoprovisioning with their machine type.
+	autoprovisionedAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-autoprovisioned"
+
+	// machineDeploymentLabelKey labels the autoprovisioned
+	// MachineDeployments and their machines with the name of the
+	// MachineDeployment, which selects its machines.
+	machineDeploymentLabelKey = machineAPIGroup + "/cluster-api-machinedeployment"
+
+	// autoprovisionedHashLength is the length of the hash of the
+	// labels and taints suffixed to the machine type in the name
+	// of autoprovisioned MachineDeployments.
+	autoprovisionedHashLength = 10
+
+	// maxMachineTypeLength is the maximum length of a machine
+	// type, so that the names of its MachineDeployments are valid
+	// label values.
+	maxMachineTypeLength = validation.DNS1123LabelMaxLength - autoprovisionedHashLength - 1
+)
+
+var (
+	// errMissingMachineTemplate is the error returned when a
+	// machine type is not defined by a machine templates ConfigMap.
+	errMissingMachineTemplate = errors.New("missing machine template")
+
+	// errInvalidMachineTemplate is the error returned when a
+	// machine template cannot be parsed.
+	errInvalidMachineTemplate = errors.New("invalid machine template")
+)
+
+// autoprovisioningTemplate describes the MachineDeployments of a
+// machine type, as defined in the machine templates ConfigMap, for
+// example {"instanceClass": "large", "maxSize": 10, "labels":
+// {"machine.openshift.io/cluster-api-cluster": "ocp"}, "spec":
+// {"providerSpec": {"value": {...}}}}. The labels are set on the
+// MachineDeployments and their machines, the spec is the spec of
+// their machines. The capacity of the machines comes from the
+// instance class, if any, or from the providerSpec.
+type autoprovisioningTemplate struct {
+	InstanceClass string              `json:"instanceClass,omitempty"`
+	MinSize       int                 `json:"minSize,omitempty"`
+	MaxSize       int                 `json:"maxSize"`
+	Labels        map[string]string   `json:"labels,omitempty"`
+	Spec          v1beta1.MachineSpec `json:"spec"`
+}
+
+// parseAutoprovisioningTemplate returns the template of machineType
+// encoded in value.
+func parseAutoprovisioningTemplate(machineType, value string) (*autoprovisioningTemplate, error) {
+	if msgs := validation.IsDNS1123Label(machineType); len(msgs) > 0 {
+		return nil, errors.Errorf("%s: machine type %q: %v", errInvalidMachineTemplate, machineType, msgs)
+	}
+	if len(machineType) > maxMachineTypeLength {
+		return nil, errors.Errorf("%s: machine type %q is longer than %d characters", errInvalidMachineTemplate, machineType, maxMachineTypeLength)
+	}
+	template := &autoprovisioningTemplate{}
+	if err := json.Unmarshal([]byte(value), template); err != nil {
+		return nil, errors.Wrapf(err, "%s", errInvalidMachineTemplate)
+	}
+	if template.MinSize < 0 || template.MaxSize < template.MinSize || template.MaxSize == 0 {
+		return nil, errors.Errorf("%s: sizes must satisfy 0 <= minSize (%d) <= maxSize (%d), 0 < maxSize", errInvalidMachineTemplate, template.MinSize, template.MaxSize)
+	}
+	return template, nil
+}
+
+// machineTemplateNamespaces returns the namespace of the machine
+// templates ConfigMap defining each machine type. A machine type
+// defined in several namespaces is taken from the first of them in
+// lexical order.
+func (c *machineController) machineTemplateNamespaces() (map[string]string, error) {
+	var configMaps []*corev1.ConfigMap
+	for _, item := range c.machineTemplatesInformer.GetStore().List() {
+		configMap, ok := item.(*corev1.ConfigMap)
+		if !ok {
+			return nil, fmt.Errorf("internal error; unexpected type %T", item)
+		}
+		configMaps = append(configMaps, configMap)
+	}
+	sort.Slice(configMaps, func(i, j int) bool {
+		return configMaps[i].Namespace < configMaps[j].Namespace
+	})
+
+	namespaces := map[string]string{}
+	for _, configMap := range configMaps {
+		for machineType := range configMap.Data {
+			if namespace, found := namespaces[machineType]; found {
+				klog.Warningf("machine type %q of ConfigMap %q is already defined in namespace %q", machineType, path.Join(configMap.Namespace, configMap.Name), namespace)
+				continue
+			}
+			namespaces[machineType] = configMap.Namespace
+		}
+	}
+	return namespaces, nil
+}
+
+// machineTypes returns the machine types defined by the machine
+// templates ConfigMaps, sorted.
+func (c *machineController) machineTypes() ([]string, error) {
+	namespaces, err := c.machineTemplateNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	machineTypes := make([]string, 0, len(namespaces))
+	for machineType := range namespaces {
+		machineTypes = append(machineTypes, machineType)
+	}
+	sort.Strings(machineTypes)
+	return machineTypes, nil
+}
+
+// findAutoprovisioningTemplate returns the template of machineType
+// and the namespace of the machine templates ConfigMap defining it.
+// Returns errMissingMachineTemplate if no ConfigMap defines it.
+func (c *machineController) findAutoprovisioningTemplate(machineType string) (string, *autoprovisioningTemplate, error) {
+	namespaces, err := c.machineTemplateNamespaces()
+	if err != nil {
+		return "", nil, err
+	}
+	namespace, found := namespaces[machineType]
+	if !found {
+		return "", nil, errors.Wrapf(errMissingMachineTemplate, "machine type %q not defined in any ConfigMap %q", machineType, machineTemplatesConfigMapName)
+	}
+
+	key := path.Join(namespace, machineTemplatesConfigMapName)
+	item, exists, err := c.machineTemplatesInformer.GetStore().GetByKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	if !exists {
+		return "", nil, errors.Wrapf(errMissingMachineTemplate, "ConfigMap %q not found", key)
+	}
+	configMap, ok := item.(*corev1.ConfigMap)
+	if !ok {
+		return "", nil, fmt.Errorf("internal error; unexpected type %T", item)
+	}
+	template, err := parseAutoprovisioningTemplate(machineType, configMap.Data[machineType])
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "machine type %q in ConfigMap %q", machineType, key)
+	}
+	return namespace, template, nil
+}
+
+// autoprovisionedName returns the name of the MachineDeployment of
+// machineType for the given node labels and taints.
+func autoprovisionedName(machineType string, labels map[string]string, taints []corev1.Taint) (string, error) {
+	sorted := append([]corev1.Taint{}, taints...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Key != sorted[j].Key {
+			return sorted[i].Key < sorted[j].Key
+		}
+		return sorted[i].Effect < sorted[j].Effect
+	})
+	// Maps are marshalled with sorted keys, the hash doesn't depend
+	// on the order of the labels.
+	raw, err := json.Marshal(struct {
+		Labels map[string]string `json:"labels"`
+		Taints []corev1.Taint    `json:"taints"`
+	}{labels, sorted})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("%s-%s", machineType, hex.EncodeToString(sum[:])[:autoprovisionedHashLength]), nil
+}
+
+// newAutoprovisionedNodeGroup returns the node group of the
+// MachineDeployment of machineType whose nodes have the given labels
+// and taints, which doesn't exist until it is created unless such a
+// MachineDeployment already exists. The system labels must be labels
+// the machine template gives its nodes, such as its zone. Returns
+// cloudprovider.ErrNotImplemented if MachineDeployments are not
+// enabled.
+func (c *machineController) newAutoprovisionedNodeGroup(
+	machineType string,
+	labels map[string]string,
+	systemLabels map[string]string,
+	taints []corev1.Taint,
+	extraResources map[string]resource.Quantity,
+) (*nodegroup, error) {
+	if !c.enableMachineDeployments {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	if len(extraResources) > 0 {
+		return nil, fmt.Errorf("unable to autoprovision machine type %q: extra resources are not supported", machineType)
+	}
+	namespace, template, err := c.findAutoprovisioningTemplate(machineType)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := template.Spec.DeepCopy()
+	templateLabels := map[string]string{}
+	if machineTemplate, err := parseProviderSpec(*spec); err == nil {
+		templateLabels = machineTemplate.labels()
+	}
+	templateLabels = cloudprovider.JoinStringMaps(templateLabels, spec.Labels)
+	for key, value := range systemLabels {
+		if templateLabels[key] != value {
+			return nil, fmt.Errorf("unable to autoprovision machine type %q: its machines don't have label %s=%s", machineType, key, value)
+		}
+	}
+	spec.Labels = cloudprovider.JoinStringMaps(spec.Labels, labels)
+	spec.Taints = mergeTaints(spec.Taints, taints)
+
+	name, err := autoprovisionedName(machineType, labels, taints)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := c.findMachineDeployment(path.Join(namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return newNodegroupFromMachineDeployment(c, existing)
+	}
+
+	machineLabels := cloudprovider.JoinStringMaps(template.Labels, map[string]string{
+		machineDeploymentLabelKey: name,
+	})
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: strconv.Itoa(template.MinSize),
+		nodeGroupMaxSizeAnnotationKey: strconv.Itoa(template.MaxSize),
+		autoprovisionedAnnotationKey:  machineType,
+	}
+	if template.InstanceClass != "" {
+		annotations[instanceClassAnnotationKey] = template.InstanceClass
+	}
+
+	machineDeployment := &v1beta1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: c.resources.machineDeployments.GroupVersion().String(),
+			Kind:       "MachineDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      machineLabels,
+			Annotations: annotations,
+		},
+		Spec: v1beta1.MachineDeploymentSpec{
+			Replicas: pointer.Int32Ptr(0),
+			Selector: metav1.LabelSelector{
+				MatchLabels: machineLabels,
+			},
+			Template: v1beta1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: machineLabels,
+				},
+				Spec: *spec,
+			},
+		},
+	}
+
+	ng, err := newNodegroupFromMachineDeployment(c, machineDeployment)
+	if err != nil {
+		return nil, err
+	}
+	ng.proposed = true
+	return ng, nil
+}
+
+// createMachineDeployment creates the MachineDeployment and returns
+// its node group. Returns cloudprovider.ErrAlreadyExist if it
+// already exists.
+func (c *machineController) createMachineDeployment(machineDeployment *v1beta1.MachineDeployment) (*nodegroup, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(machineDeployment)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	toAPIKeys(c.resources.machineDeployments.Group, u)
+	u, err = c.dynamicclient.Resource(c.resources.machineDeployments).Namespace(machineDeployment.Namespace).Create(u, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil, cloudprovider.ErrAlreadyExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create machineDeployment %q: %v", path.Join(machineDeployment.Namespace, machineDeployment.Name), err)
+	}
+
+	fromAPIKeys(c.resources.machineDeployments.Group, u)
+	created := &v1beta1.MachineDeployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), created); err != nil {
+		return nil, errors.Wrapf(err, "cannot convert machinedeployments %s/%s", u.GetNamespace(), u.GetName())
+	}
+	klog.V(1).Infof("created machineDeployment %q of machine type %q", path.Join(created.Namespace, created.Name), created.Annotations[autoprovisionedAnnotationKey])
+	return newNodegroupFromMachineDeployment(c, created)
+}
+
+// deleteMachineDeployment deletes the MachineDeployment, its
+// MachineSets being garbage collected with it.
+func (c *machineController) deleteMachineDeployment(namespace, name string) error {
+	err := c.dynamicclient.Resource(c.resources.machineDeployments).Namespace(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to delete machineDeployment %q: %v", path.Join(namespace, name), err)
+	}
+	klog.V(1).Infof("deleted machineDeployment %q", path.Join(namespace, name))
+	return nil
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+const testMachineTemplate = `{
+	"instanceClass": "large",
+	"maxSize": 10,
+	"labels": {"machine.openshift.io/cluster-api-cluster": "test"},
+	"spec": {
+		"metadata": {"labels": {"node-role.kubernetes.io/worker": ""}},
+		"providerSpec": {"value": {"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "placement": {"region": "us-east-1", "availabilityZone": "us-east-1a"}}}
+	}
+}`
+
+func TestParseAutoprovisioningTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		machineType string
+		value       string
+		error       error
+	}{{
+		description: "valid template",
+		machineType: "large",
+		value:       testMachineTemplate,
+	}, {
+		description: "invalid machine type",
+		machineType: "Large_1",
+		value:       testMachineTemplate,
+		error:       errInvalidMachineTemplate,
+	}, {
+		description: "machine type too long",
+		machineType: strings.Repeat("a", maxMachineTypeLength+1),
+		value:       testMachineTemplate,
+		error:       errInvalidMachineTemplate,
+	}, {
+		description: "missing max size",
+		machineType: "large",
+		value:       `{"spec": {}}`,
+		error:       errInvalidMachineTemplate,
+	}, {
+		description: "min size above max size",
+		machineType: "large",
+		value:       `{"minSize": 3, "maxSize": 2, "spec": {}}`,
+		error:       errInvalidMachineTemplate,
+	}, {
+		description: "invalid JSON",
+		machineType: "large",
+		value:       `maxSize: 2`,
+		error:       errInvalidMachineTemplate,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			template, err := parseAutoprovisioningTemplate(tc.machineType, tc.value)
+			if tc.error != nil {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.error.Error()) {
+					t.Errorf("expected %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if template.InstanceClass != "large" || template.MaxSize != 10 || template.Spec.ProviderSpec.Value == nil {
+				t.Errorf("unexpected template %+v", template)
+			}
+		})
+	}
+}
+
+func TestAutoprovisionedName(t *testing.T) {
+	labels := map[string]string{"a": "1", "b": "2"}
+	taints := []corev1.Taint{
+		{Key: "a", Value: "1", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "b", Value: "2", Effect: corev1.TaintEffectNoExecute},
+	}
+	name, err := autoprovisionedName("large", labels, taints)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(name, "large-") || len(name) != len("large-")+autoprovisionedHashLength {
+		t.Errorf("unexpected name %q", name)
+	}
+
+	// The name doesn't depend on the order of the taints.
+	if reversed, _ := autoprovisionedName("large", labels, []corev1.Taint{taints[1], taints[0]}); reversed != name {
+		t.Errorf("expected %q, got %q", name, reversed)
+	}
+	if other, _ := autoprovisionedName("large", map[string]string{"a": "1"}, taints); other == name {
+		t.Errorf("expected different labels to give a different name than %q", name)
+	}
+}
+
+func TestProviderAutoprovisioning(t *testing.T) {
+	controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 1, nil))
+	defer stop()
+
+	provider, err := newProvider(ProviderName, nil, controller)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, configMap := range []*corev1.ConfigMap{{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      machineTemplatesConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"large": testMachineTemplate,
+		},
+	}, {
+		ObjectMeta: v1.ObjectMeta{
+			Name:      machineTemplatesConfigMapName,
+			Namespace: "z-namespace",
+		},
+		Data: map[string]string{
+			"large": `{"maxSize": 1, "spec": {}}`,
+			"small": `{"maxSize": 1, "spec": {}}`,
+		},
+	}} {
+		if err := controller.machineTemplatesInformer.GetStore().Add(configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := controller.instanceClassInformer.GetStore().Add(&corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      instanceClassesConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"large": `{"cpu": "4", "memory": "16Gi"}`,
+		},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	machineTypes, err := provider.GetAvailableMachineTypes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"large", "small"}; !reflect.DeepEqual(machineTypes, expected) {
+		t.Errorf("expected %v, got %v", expected, machineTypes)
+	}
+
+	if _, err := provider.NewNodeGroup("medium", nil, nil, nil, nil); errors.Cause(err) != errMissingMachineTemplate {
+		t.Errorf("expected %q, got %v", errMissingMachineTemplate, err)
+	}
+	if _, err := provider.NewNodeGroup("large", nil, map[string]string{corev1.LabelZoneFailureDomain: "us-east-1b"}, nil, nil); err == nil {
+		t.Errorf("expected an error for a zone the template isn't in")
+	}
+
+	labels := map[string]string{"workload": "batch"}
+	taints := []corev1.Taint{{Key: "workload", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+	ng, err := provider.NewNodeGroup("large", labels, map[string]string{corev1.LabelZoneFailureDomain: "us-east-1a"}, taints, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ng.Exist() {
+		t.Errorf("expected the nodegroup not to exist")
+	}
+	if !ng.Autoprovisioned() {
+		t.Errorf("expected the nodegroup to be autoprovisioned")
+	}
+	if !strings.HasPrefix(ng.Id(), testNamespace+"/large-") {
+		t.Errorf("unexpected id %q", ng.Id())
+	}
+	if ng.MinSize() != 0 || ng.MaxSize() != 10 {
+		t.Errorf("expected sizes 0-10, got %d-%d", ng.MinSize(), ng.MaxSize())
+	}
+	if err := ng.Delete(); err == nil {
+		t.Errorf("expected an error deleting a nodegroup that doesn't exist")
+	}
+
+	nodeInfo, err := ng.TemplateNodeInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	node := nodeInfo.Node()
+	if cpu := node.Status.Capacity[corev1.ResourceCPU]; cpu.Value() != 4 {
+		t.Errorf("expected 4 cpus from the instance class, got %v", cpu.String())
+	}
+	for key, value := range map[string]string{
+		"workload":                       "batch",
+		"node-role.kubernetes.io/worker": "",
+		corev1.LabelZoneFailureDomain:    "us-east-1a",
+	} {
+		if actual, found := node.Labels[key]; !found || actual != value {
+			t.Errorf("expected label %s=%s, got %v", key, value, node.Labels)
+		}
+	}
+	if len(node.Spec.Taints) != 1 || node.Spec.Taints[0] != taints[0] {
+		t.Errorf("expected taints %v, got %v", taints, node.Spec.Taints)
+	}
+
+	limits, ok := ng.(cloudprovider.SizeLimitsNodeGroup)
+	if !ok {
+		t.Fatalf("expected the nodegroup to implement SizeLimitsNodeGroup")
+	}
+	if err := limits.SetSizeLimits(2, 1); err == nil {
+		t.Errorf("expected an error for invalid size limits")
+	}
+	if err := limits.SetSizeLimits(1, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ng.MinSize() != 1 || ng.MaxSize() != 5 {
+		t.Errorf("expected sizes 1-5, got %d-%d", ng.MinSize(), ng.MaxSize())
+	}
+
+	created, err := ng.Create()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !created.Exist() || !created.Autoprovisioned() || created.Id() != ng.Id() || created.MaxSize() != 5 {
+		t.Errorf("unexpected created nodegroup %s", created.Debug())
+	}
+	if _, err := ng.Create(); err != cloudprovider.ErrAlreadyExist {
+		t.Errorf("expected %q, got %v", cloudprovider.ErrAlreadyExist, err)
+	}
+	if err := created.(cloudprovider.SizeLimitsNodeGroup).SetSizeLimits(0, 1); err == nil {
+		t.Errorf("expected an error setting the size limits of an existing nodegroup")
+	}
+
+	machineDeployment, err := getMachineDeployment(controller, testNamespace, created.(*nodegroup).Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	selector := machineDeployment.Spec.Selector.MatchLabels
+	if selector[machineDeploymentLabelKey] != machineDeployment.Name || selector["machine.openshift.io/cluster-api-cluster"] != "test" {
+		t.Errorf("unexpected selector %v", selector)
+	}
+	if !reflect.DeepEqual(machineDeployment.Spec.Template.Labels, selector) {
+		t.Errorf("expected machine labels %v, got %v", selector, machineDeployment.Spec.Template.Labels)
+	}
+
+	// The created MachineDeployment is a node group, and building
+	// its node group again returns it.
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		for _, nodegroup := range provider.NodeGroups() {
+			if nodegroup.Id() == created.Id() {
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("created nodegroup not found: %v", err)
+	}
+	existing, err := provider.NewNodeGroup("large", labels, nil, taints, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !existing.Exist() || existing.Id() != created.Id() {
+		t.Errorf("expected existing nodegroup %q, got %s", created.Id(), existing.Debug())
+	}
+
+	if err := created.Delete(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := getMachineDeployment(controller, testNamespace, machineDeployment.Name); err == nil {
+		t.Errorf("expected the machinedeployment to be deleted")
+	}
+
+	persistent, err := provider.NewNodeGroup("small", nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := persistent.(cloudprovider.PersistentNodeGroup).SetPersistent(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if persistent.Autoprovisioned() {
+		t.Errorf("expected the persistent nodegroup not to be autoprovisioned")
+	}
+	created, err = persistent.Create()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Autoprovisioned() {
+		t.Errorf("expected the created nodegroup not to be autoprovisioned")
+	}
+	if err := created.(cloudprovider.PersistentNodeGroup).SetPersistent(); err == nil {
+		t.Errorf("expected an error making an existing nodegroup persistent")
+	}
+	if err := created.Delete(); err != cloudprovider.ErrNotImplemented {
+		t.Errorf("expected %q, got %v", cloudprovider.ErrNotImplemented, err)
+	}
+
+	// Only autoprovisioned node groups can be deleted.
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, nodegroup := range nodegroups {
+		if nodegroup.Autoprovisioned() {
+			continue
+		}
+		if err := nodegroup.Delete(); err != cloudprovider.ErrNotImplemented {
+			t.Errorf("expected %q deleting nodegroup %q, got %v", cloudprovider.ErrNotImplemented, nodegroup.Id(), err)
+		}
+	}
+
+	controller.enableMachineDeployments = false
+	if _, err := provider.NewNodeGroup("large", nil, nil, nil, nil); err != cloudprovider.ErrNotImplemented {
+		t.Errorf("expected %q, got %v", cloudprovider.ErrNotImplemented, err)
+	}
+	if machineTypes, _ := provider.GetAvailableMachineTypes(); len(machineTypes) != 0 {
+		t.Errorf("expected no machine types, got %v", machineTypes)
+	}
+}
+
+func TestNodeGroupDeleteWithReplicas(t *testing.T) {
+	testConfig := createMachineDeploymentTestConfig(testNamespace, 1, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "0",
+		nodeGroupMaxSizeAnnotationKey: "10",
+		autoprovisionedAnnotationKey:  "large",
+	})
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := len(nodegroups); l != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", l)
+	}
+	if !nodegroups[0].Autoprovisioned() {
+		t.Fatalf("expected the nodegroup to be autoprovisioned")
+	}
+	if err := nodegroups[0].Delete(); err == nil || !strings.Contains(err.Error(), "it has 1 replicas") {
+		t.Errorf("expected an error deleting a nodegroup with replicas, got %v", err)
+	}
+}
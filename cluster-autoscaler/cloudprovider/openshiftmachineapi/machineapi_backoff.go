@@ -0,0 +1,154 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	initialProvisioningBackoff      = 5 * time.Minute
+	maxProvisioningBackoff          = 30 * time.Minute
+	provisioningBackoffResetTimeout = 3 * time.Hour
+)
+
+// provisioningBackoff keeps track of the node groups whose last
+// scale-up produced machines that failed to provision. Such node
+// groups are not scaled up again until their backoff expires. The
+// backoff doubles, up to maxProvisioningBackoff, each time another
+// scale-up of the node group fails, and is reset once a scale-up
+// succeeds or no scale-up failed for
+// provisioningBackoffResetTimeout.
+type provisioningBackoff struct {
+	mutex      sync.Mutex
+	nodeGroups map[string]*provisioningBackoffInfo
+}
+
+type provisioningBackoffInfo struct {
+	// lastScaleUp is the time of the last scale-up of the node
+	// group. It is zero if the node group was not scaled up since
+	// the autoscaler started, in which case all its machines are
+	// considered to result from its last scale-up.
+	lastScaleUp time.Time
+	// scaleUpFailed is true once the node group was backed off
+	// for its last scale-up.
+	scaleUpFailed  bool
+	duration       time.Duration
+	backoffUntil   time.Time
+	lastFailure    time.Time
+	failedMachines int
+}
+
+func newProvisioningBackoff() *provisioningBackoff {
+	return &provisioningBackoff{
+		nodeGroups: make(map[string]*provisioningBackoffInfo),
+	}
+}
+
+func (b *provisioningBackoff) info(id string) *provisioningBackoffInfo {
+	info, found := b.nodeGroups[id]
+	if !found {
+		info = &provisioningBackoffInfo{}
+		b.nodeGroups[id] = info
+	}
+	return info
+}
+
+// lastScaleUp returns the time of the last scale-up of the node
+// group, or the zero time if it was not scaled up yet.
+func (b *provisioningBackoff) lastScaleUp(id string) time.Time {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.info(id).lastScaleUp
+}
+
+// scaledUp records a scale-up of the node group.
+func (b *provisioningBackoff) scaledUp(id string, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// Creation timestamps of machines are truncated to seconds.
+	info := b.info(id)
+	info.lastScaleUp = now.Truncate(time.Second)
+	info.scaleUpFailed = false
+}
+
+// update updates the backoff of the node group given the number of
+// machines produced by its last scale-up which failed to provision
+// and which got a node. The backoff is only reset by scale-ups none
+// of whose machines failed.
+func (b *provisioningBackoff) update(id string, failed, succeeded int, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	info := b.info(id)
+
+	if info.duration > 0 && info.lastFailure.Add(provisioningBackoffResetTimeout).Before(now) {
+		info.duration = 0
+	}
+
+	switch {
+	case failed > 0 && !info.scaleUpFailed:
+		// A scale-up only backs the node group off once, however
+		// many of its machines fail.
+		duration := initialProvisioningBackoff
+		if info.duration > 0 {
+			duration = 2 * info.duration
+			if duration > maxProvisioningBackoff {
+				duration = maxProvisioningBackoff
+			}
+		}
+		info.scaleUpFailed = true
+		info.duration = duration
+		info.backoffUntil = now.Add(duration)
+		info.lastFailure = now
+		info.failedMachines = failed
+		provisioningBackoffCounter.WithLabelValues(id).Inc()
+		klog.Warningf("nodegroup %q: %d machines failed to provision, backing off until %s", id, failed, info.backoffUntil)
+	case failed > 0:
+		info.failedMachines = failed
+	case succeeded > 0 && !info.scaleUpFailed && info.duration > 0:
+		klog.V(2).Infof("nodegroup %q: machines provisioned, removing backoff", id)
+		info.duration = 0
+		info.backoffUntil = time.Time{}
+		info.failedMachines = 0
+	}
+
+	if info.backoffUntil.After(now) {
+		backedOffNodeGroupsGauge.WithLabelValues(id).Set(1)
+	} else {
+		backedOffNodeGroupsGauge.WithLabelValues(id).Set(0)
+	}
+}
+
+// backedOffUntil returns the time until which the node group is
+// backed off and the number of machines that failed to provision,
+// and false if it is not backed off.
+func (b *provisioningBackoff) backedOffUntil(id string, now time.Time) (time.Time, int, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	info, found := b.nodeGroups[id]
+	if !found || !info.backoffUntil.After(now) {
+		return time.Time{}, 0, false
+	}
+	return info.backoffUntil, info.failedMachines, true
+}
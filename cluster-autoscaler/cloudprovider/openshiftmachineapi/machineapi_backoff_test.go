@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/common"
+	"k8s.io/utils/pointer"
+)
+
+func TestProvisioningBackoff(t *testing.T) {
+	const id = "ns/ng"
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := newProvisioningBackoff()
+
+	expectBackedOff := func(at time.Time, expected time.Time) {
+		t.Helper()
+		until, _, backedOff := b.backedOffUntil(id, at)
+		if expected.IsZero() {
+			if backedOff {
+				t.Errorf("expected no backoff at %s, got backoff until %s", at, until)
+			}
+			return
+		}
+		if !backedOff || !until.Equal(expected) {
+			t.Errorf("expected backoff until %s at %s, got %s (%v)", expected, at, until, backedOff)
+		}
+	}
+
+	b.update(id, 0, 3, now)
+	expectBackedOff(now, time.Time{})
+
+	// The first failed scale-up backs off for the initial duration,
+	// however often its failures are seen.
+	b.scaledUp(id, now)
+	b.update(id, 1, 0, now)
+	b.update(id, 2, 0, now.Add(time.Minute))
+	expectBackedOff(now.Add(time.Minute), now.Add(initialProvisioningBackoff))
+	if _, failed, _ := b.backedOffUntil(id, now.Add(time.Minute)); failed != 2 {
+		t.Errorf("expected 2 failed machines, got %d", failed)
+	}
+
+	// Machines of a failed scale-up which got a node don't reset
+	// the backoff.
+	b.update(id, 0, 1, now.Add(2*time.Minute))
+	expectBackedOff(now.Add(2*time.Minute), now.Add(initialProvisioningBackoff))
+	expectBackedOff(now.Add(initialProvisioningBackoff), time.Time{})
+
+	// Another failed scale-up doubles the backoff.
+	now = now.Add(10 * time.Minute)
+	b.scaledUp(id, now)
+	b.update(id, 1, 0, now)
+	expectBackedOff(now, now.Add(2*initialProvisioningBackoff))
+
+	// The backoff never exceeds its maximum.
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Hour)
+		b.scaledUp(id, now)
+		b.update(id, 1, 0, now)
+	}
+	expectBackedOff(now, now.Add(maxProvisioningBackoff))
+
+	// A successful scale-up resets the backoff.
+	now = now.Add(time.Hour)
+	b.scaledUp(id, now)
+	b.update(id, 0, 1, now)
+	b.scaledUp(id, now)
+	b.update(id, 1, 0, now)
+	expectBackedOff(now, now.Add(initialProvisioningBackoff))
+
+	// So does the lack of failures for the reset timeout.
+	now = now.Add(provisioningBackoffResetTimeout + time.Minute)
+	b.scaledUp(id, now)
+	b.update(id, 1, 0, now)
+	expectBackedOff(now, now.Add(initialProvisioningBackoff))
+}
+
+func TestNodeGroupProvisioningBackoff(t *testing.T) {
+	testConfig := createMachineSetTestConfig(testNamespace, 2, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := len(nodegroups); l != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", l)
+	}
+	ng := nodegroups[0]
+
+	if _, _, ok := ng.ProviderMaxSize(); ok {
+		t.Errorf("expected no provider max size for healthy nodegroup")
+	}
+
+	// The second machine failed to provision.
+	failed := testConfig.machines[1].DeepCopy()
+	failed.Spec.ProviderID = nil
+	failed.Status.NodeRef = nil
+	reason := common.CreateMachineError
+	failed.Status.ErrorReason = &reason
+	failed.Status.ErrorMessage = pointer.StringPtr("instance type not supported in zone")
+	failed.Status.Phase = pointer.StringPtr(machinePhaseFailed)
+	if err := controller.machineInformer.GetStore().Update(failed); err != nil {
+		t.Fatalf("unexpected error updating machine, got %v", err)
+	}
+
+	maxSize, limitReason, ok := ng.ProviderMaxSize()
+	if !ok {
+		t.Fatalf("expected provider max size for backed off nodegroup")
+	}
+	if maxSize != 2 {
+		t.Errorf("expected provider max size 2, got %d", maxSize)
+	}
+	if limitReason == "" {
+		t.Errorf("expected a reason for the provider max size")
+	}
+
+	if err := ng.IncreaseSize(1); err == nil {
+		t.Errorf("expected an error increasing the size of a backed off nodegroup")
+	}
+	if replicas := ng.scalableResource.Replicas(); replicas != 2 {
+		t.Errorf("expected 2 replicas, got %d", replicas)
+	}
+}
@@ -20,37 +20,78 @@ import (
 	"fmt"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
-	clusterclient "github.com/openshift/cluster-api/pkg/client/clientset_generated/clientset"
-	clusterinformers "github.com/openshift/cluster-api/pkg/client/informers_generated/externalversions"
-	machinev1beta1 "github.com/openshift/cluster-api/pkg/client/informers_generated/externalversions/machine/v1beta1"
+	machinelisters "github.com/openshift/cluster-api/pkg/client/listers_generated/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	kubeclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	kube_record "k8s.io/client-go/tools/record"
 	"k8s.io/klog"
-	"k8s.io/utils/pointer"
 )
 
 const (
 	machineProviderIDIndex = "openshiftmachineapi-machineProviderIDIndex"
+	machineNodeRefIndex    = "openshiftmachineapi-machineNodeRefIndex"
 	nodeProviderIDIndex    = "openshiftmachineapi-nodeProviderIDIndex"
 )
 
 // machineController watches for Nodes, Machines, MachineSets and
 // MachineDeployments as they are added, updated and deleted on the
-// cluster. Additionally, it adds indices to the node informers to
-// satisfy lookup by node.Spec.ProviderID.
+// cluster. Additionally, it adds indices to the node and machine
+// informers to satisfy lookup by node.Spec.ProviderID, and by
+// machine.Spec.ProviderID and machine.Status.NodeRef.Name.
 type machineController struct {
-	clusterClientset          clusterclient.Interface
-	clusterInformerFactory    clusterinformers.SharedInformerFactory
+	dynamicclient             dynamic.Interface
+	resources                 machineAPIResources
 	kubeInformerFactory       kubeinformers.SharedInformerFactory
-	machineDeploymentInformer machinev1beta1.MachineDeploymentInformer
-	machineInformer           machinev1beta1.MachineInformer
-	machineSetInformer        machinev1beta1.MachineSetInformer
+	machineDeploymentInformer cache.SharedIndexInformer
+	machineInformer           cache.SharedIndexInformer
+	machineSetInformer        cache.SharedIndexInformer
 	nodeInformer              cache.SharedIndexInformer
 	enableMachineDeployments  bool
+	// instanceClassInformer only watches the ConfigMaps named
+	// instanceClassesConfigMapName.
+	instanceClassInformer cache.SharedIndexInformer
+	// nodeGroupLimitsInformer only watches the ConfigMaps named
+	// nodeGroupLimitsConfigMapName.
+	nodeGroupLimitsInformer cache.SharedIndexInformer
+	// machineTemplatesInformer only watches the ConfigMaps named
+	// machineTemplatesConfigMapName.
+	machineTemplatesInformer cache.SharedIndexInformer
+	// machineAutoscalerInformer is nil if MachineAutoscalers are
+	// not enabled.
+	machineAutoscalerInformer cache.SharedIndexInformer
+	// machinePoolInformer is nil if MachinePools are not served.
+	machinePoolInformer cache.SharedIndexInformer
+	// autoDiscoveryConfigs give the bounds of the scalable
+	// resources without min/max annotations.
+	autoDiscoveryConfigs []autoDiscoveryConfig
+	// nodeGroupSelector selects the scalable resources that can be
+	// node groups, so that several autoscalers can each manage
+	// their own part of the machines of a cluster.
+	nodeGroupSelector labels.Selector
+	// namespaces are the namespaces of the watched machine API
+	// objects, all of them if empty.
+	namespaces []string
+	// provisioningBackoff tracks the node groups whose machines
+	// failed to provision.
+	provisioningBackoff *provisioningBackoff
+	// updateRejections tracks the node groups whose resizes were
+	// rejected by admission webhooks.
+	updateRejections *updateRejections
+	// machineDeletions tracks the machines whose deletion was
+	// requested until they disappear.
+	machineDeletions *machineDeletions
+	// recorder emits the events of the resizes of the scalable
+	// resources.
+	recorder kube_record.EventRecorder
 }
 
 type machineSetFilterFunc func(machineSet *v1beta1.MachineSet) error
@@ -58,7 +99,17 @@ type machineSetFilterFunc func(machineSet *v1beta1.MachineSet) error
 func indexMachineByProviderID(obj interface{}) ([]string, error) {
 	if machine, ok := obj.(*v1beta1.Machine); ok {
 		if machine.Spec.ProviderID != nil && *machine.Spec.ProviderID != "" {
-			return []string{*machine.Spec.ProviderID}, nil
+			return []string{normalizedProviderID(*machine.Spec.ProviderID)}, nil
+		}
+		return []string{}, nil
+	}
+	return []string{}, nil
+}
+
+func indexMachineByNodeRefName(obj interface{}) ([]string, error) {
+	if machine, ok := obj.(*v1beta1.Machine); ok {
+		if machine.Status.NodeRef != nil && machine.Status.NodeRef.Kind == "Node" && machine.Status.NodeRef.Name != "" {
+			return []string{machine.Status.NodeRef.Name}, nil
 		}
 		return []string{}, nil
 	}
@@ -68,7 +119,7 @@ func indexMachineByProviderID(obj interface{}) ([]string, error) {
 func indexNodeByProviderID(obj interface{}) ([]string, error) {
 	if node, ok := obj.(*corev1.Node); ok {
 		if node.Spec.ProviderID != "" {
-			return []string{node.Spec.ProviderID}, nil
+			return []string{normalizedProviderID(node.Spec.ProviderID)}, nil
 		}
 		return []string{}, nil
 	}
@@ -76,7 +127,7 @@ func indexNodeByProviderID(obj interface{}) ([]string, error) {
 }
 
 func (c *machineController) findMachine(id string) (*v1beta1.Machine, error) {
-	item, exists, err := c.machineInformer.Informer().GetStore().GetByKey(id)
+	item, exists, err := c.machineInformer.GetStore().GetByKey(id)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +145,7 @@ func (c *machineController) findMachine(id string) (*v1beta1.Machine, error) {
 }
 
 func (c *machineController) findMachineDeployment(id string) (*v1beta1.MachineDeployment, error) {
-	item, exists, err := c.machineDeploymentInformer.Informer().GetStore().GetByKey(id)
+	item, exists, err := c.machineDeploymentInformer.GetStore().GetByKey(id)
 	if err != nil {
 		return nil, err
 	}
@@ -120,7 +171,7 @@ func (c *machineController) findMachineOwner(machine *v1beta1.Machine) (*v1beta1
 		return nil, nil
 	}
 
-	store := c.machineSetInformer.Informer().GetStore()
+	store := c.machineSetInformer.GetStore()
 	item, exists, err := store.GetByKey(fmt.Sprintf("%s/%s", machine.Namespace, machineOwnerRef.Name))
 	if err != nil {
 		return nil, err
@@ -145,16 +196,34 @@ func (c *machineController) findMachineOwner(machine *v1beta1.Machine) (*v1beta1
 // synchronize.
 func (c *machineController) run(stopCh <-chan struct{}) error {
 	c.kubeInformerFactory.Start(stopCh)
-	c.clusterInformerFactory.Start(stopCh)
+	go c.instanceClassInformer.Run(stopCh)
+	go c.nodeGroupLimitsInformer.Run(stopCh)
+	go c.machineTemplatesInformer.Run(stopCh)
+	go c.machineInformer.Run(stopCh)
+	go c.machineSetInformer.Run(stopCh)
 
 	syncFuncs := []cache.InformerSynced{
 		c.nodeInformer.HasSynced,
-		c.machineInformer.Informer().HasSynced,
-		c.machineSetInformer.Informer().HasSynced,
+		c.machineInformer.HasSynced,
+		c.machineSetInformer.HasSynced,
+		c.instanceClassInformer.HasSynced,
+		c.nodeGroupLimitsInformer.HasSynced,
+		c.machineTemplatesInformer.HasSynced,
 	}
 
 	if c.enableMachineDeployments {
-		syncFuncs = append(syncFuncs, c.machineDeploymentInformer.Informer().HasSynced)
+		go c.machineDeploymentInformer.Run(stopCh)
+		syncFuncs = append(syncFuncs, c.machineDeploymentInformer.HasSynced)
+	}
+
+	if c.machineAutoscalerInformer != nil {
+		go c.machineAutoscalerInformer.Run(stopCh)
+		syncFuncs = append(syncFuncs, c.machineAutoscalerInformer.HasSynced)
+	}
+
+	if c.machinePoolInformer != nil {
+		go c.machinePoolInformer.Run(stopCh)
+		syncFuncs = append(syncFuncs, c.machinePoolInformer.HasSynced)
 	}
 
 	klog.V(4).Infof("waiting for caches to sync")
@@ -165,10 +234,25 @@ func (c *machineController) run(stopCh <-chan struct{}) error {
 	return nil
 }
 
-// findMachineByProviderID finds machine matching providerID. A
-// DeepCopy() of the object is returned on success.
+// newConfigMapInformer returns an informer for the ConfigMaps named
+// name in namespace.
+func newConfigMapInformer(kubeclient kubeclient.Interface, namespace, name string) cache.SharedIndexInformer {
+	return coreinformers.NewFilteredConfigMapInformer(kubeclient, namespace, 0, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	}, func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	})
+}
+
+// findMachineByProviderID finds machine matching providerID, as
+// normalized by normalizedProviderID. A DeepCopy() of the object is
+// returned on success.
 func (c *machineController) findMachineByProviderID(providerID string) (*v1beta1.Machine, error) {
-	objs, err := c.machineInformer.Informer().GetIndexer().ByIndex(machineProviderIDIndex, providerID)
+	if isPendingMachineProviderID(providerID) {
+		return c.findMachine(machineKeyFromPendingMachineProviderID(providerID))
+	}
+
+	objs, err := c.machineInformer.GetIndexer().ByIndex(machineProviderIDIndex, normalizedProviderID(providerID))
 	if err != nil {
 		return nil, err
 	}
@@ -190,7 +274,8 @@ func (c *machineController) findMachineByProviderID(providerID string) (*v1beta1
 	// does not set this value (e.g., OpenStack)--then first
 	// lookup the node using ProviderID. If that is successful
 	// then the machine can be found using the annotation (should
-	// it exist).
+	// it exist), or else using Status.NodeRef.Name if the
+	// machine has no other providerID.
 	node, err := c.findNodeByProviderID(providerID)
 	if err != nil {
 		return nil, err
@@ -198,7 +283,59 @@ func (c *machineController) findMachineByProviderID(providerID string) (*v1beta1
 	if node == nil {
 		return nil, nil
 	}
-	return c.findMachine(node.Annotations[machineAnnotationKey])
+	if id, ok := node.Annotations[c.apiKey(machineAnnotationKey)]; ok {
+		return c.findMachine(id)
+	}
+	machine, err := c.findMachineByNodeName(node.Name)
+	if err != nil || machine == nil {
+		return nil, err
+	}
+	if machine.Spec.ProviderID != nil && *machine.Spec.ProviderID != "" && normalizedProviderID(*machine.Spec.ProviderID) != normalizedProviderID(providerID) {
+		return nil, nil
+	}
+	return machine, nil
+}
+
+// watchesMachine returns true if the machine keyed by id would be
+// watched, whether it exists or not.
+func (c *machineController) watchesMachine(id string) bool {
+	namespace, _, err := cache.SplitMetaNamespaceKey(id)
+	if err != nil {
+		return false
+	}
+	if len(c.namespaces) == 0 {
+		return true
+	}
+	for _, ns := range c.namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// findMachineByNodeName finds the machine whose Status.NodeRef
+// references the node name. Returns nil if it cannot be found. A
+// DeepCopy() of the object is returned on success.
+func (c *machineController) findMachineByNodeName(name string) (*v1beta1.Machine, error) {
+	objs, err := c.machineInformer.GetIndexer().ByIndex(machineNodeRefIndex, name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := len(objs); {
+	case n == 0:
+		return nil, nil
+	case n > 1:
+		return nil, fmt.Errorf("internal error; expected len==1, got %v", n)
+	}
+
+	machine, ok := objs[0].(*v1beta1.Machine)
+	if !ok {
+		return nil, fmt.Errorf("internal error; unexpected type %T", machine)
+	}
+
+	return machine.DeepCopy(), nil
 }
 
 // findNodeByNodeName finds the Node object keyed by name.. Returns
@@ -227,7 +364,7 @@ func (c *machineController) findNodeByNodeName(name string) (*corev1.Node, error
 // is returned.
 func (c *machineController) machinesInMachineSet(machineSet *v1beta1.MachineSet) ([]*v1beta1.Machine, error) {
 	listOptions := labels.SelectorFromSet(labels.Set(machineSet.Labels))
-	machines, err := c.machineInformer.Lister().Machines(machineSet.Namespace).List(listOptions)
+	machines, err := machinelisters.NewMachineLister(c.machineInformer.GetIndexer()).Machines(machineSet.Namespace).List(listOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -244,33 +381,58 @@ func (c *machineController) machinesInMachineSet(machineSet *v1beta1.MachineSet)
 }
 
 // newMachineController constructs a controller that watches Nodes,
-// Machines and MachineSet as they are added, updated and deleted on
-// the cluster.
+// and the Machines and MachineSet of resources as they are added,
+// updated and deleted on the cluster. MachineAutoscalers are also
+// watched, using machineAutoscalerListWatch, unless it is nil, and so
+// are the MachinePools of resources if they are served. The scalable
+// resources matching autoDiscoveryConfigs are node groups even
+// without min/max annotations. Only the scalable resources matching
+// nodeGroupSelector are node groups, all of them are if it is nil.
 func newMachineController(
 	kubeclient kubeclient.Interface,
-	clusterclient clusterclient.Interface,
+	dynamicclient dynamic.Interface,
+	resources machineAPIResources,
+	newMachineAutoscalerListWatch func(namespace string) cache.ListerWatcher,
 	enableMachineDeployments bool,
+	autoDiscoveryConfigs []autoDiscoveryConfig,
+	namespaces []string,
+	nodeGroupSelector labels.Selector,
 ) (*machineController, error) {
+	if nodeGroupSelector == nil {
+		nodeGroupSelector = labels.Everything()
+	}
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclient, 0)
-	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterclient, 0)
 
-	var machineDeploymentInformer machinev1beta1.MachineDeploymentInformer
+	var machineDeploymentInformer cache.SharedIndexInformer
 	if enableMachineDeployments {
-		machineDeploymentInformer = clusterInformerFactory.Machine().V1beta1().MachineDeployments()
-		machineDeploymentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{})
+		machineDeploymentInformer = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+			return newMachineDeploymentInformer(dynamicclient, resources, namespace)
+		})
 	}
 
-	machineInformer := clusterInformerFactory.Machine().V1beta1().Machines()
-	machineSetInformer := clusterInformerFactory.Machine().V1beta1().MachineSets()
-
-	machineInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{})
-	machineSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{})
+	machineInformer := newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newMachineInformer(dynamicclient, resources, namespace)
+	})
+	machineSetInformer := newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newMachineSetInformer(dynamicclient, resources, namespace)
+	})
 
 	nodeInformer := kubeInformerFactory.Core().V1().Nodes().Informer()
 	nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{})
 
-	if err := machineInformer.Informer().GetIndexer().AddIndexers(cache.Indexers{
+	instanceClassInformer := newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newConfigMapInformer(kubeclient, namespace, instanceClassesConfigMapName)
+	})
+	nodeGroupLimitsInformer := newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newConfigMapInformer(kubeclient, namespace, nodeGroupLimitsConfigMapName)
+	})
+	machineTemplatesInformer := newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newConfigMapInformer(kubeclient, namespace, machineTemplatesConfigMapName)
+	})
+
+	if err := machineInformer.GetIndexer().AddIndexers(cache.Indexers{
 		machineProviderIDIndex: indexMachineByProviderID,
+		machineNodeRefIndex:    indexMachineByNodeRefName,
 	}); err != nil {
 		return nil, fmt.Errorf("cannot add machine indexer: %v", err)
 	}
@@ -281,16 +443,64 @@ func newMachineController(
 		return nil, fmt.Errorf("cannot add node indexer: %v", err)
 	}
 
-	return &machineController{
-		clusterClientset:          clusterclient,
-		clusterInformerFactory:    clusterInformerFactory,
+	if err := machineSetInformer.GetIndexer().AddIndexers(cache.Indexers{
+		machineSetUIDIndex: indexMachineSetByUID,
+	}); err != nil {
+		return nil, fmt.Errorf("cannot add machineset indexer: %v", err)
+	}
+
+	var machineAutoscalerInformer cache.SharedIndexInformer
+	if newMachineAutoscalerListWatch != nil {
+		machineAutoscalerInformer = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+			return cache.NewSharedIndexInformer(newMachineAutoscalerListWatch(namespace), &unstructured.Unstructured{}, 0, cache.Indexers{
+				machineAutoscalerTargetIndex: indexMachineAutoscalerByTarget,
+			})
+		})
+	}
+
+	var machinePoolInformer cache.SharedIndexInformer
+	if !resources.machinePools.Empty() {
+		machinePoolInformer = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+			return newMachinePoolInformer(dynamicclient, resources, namespace)
+		})
+	}
+
+	c := &machineController{
+		dynamicclient:             dynamicclient,
+		resources:                 resources,
 		kubeInformerFactory:       kubeInformerFactory,
 		machineDeploymentInformer: machineDeploymentInformer,
 		machineInformer:           machineInformer,
 		machineSetInformer:        machineSetInformer,
 		nodeInformer:              nodeInformer,
 		enableMachineDeployments:  enableMachineDeployments,
-	}, nil
+
+		instanceClassInformer:     instanceClassInformer,
+		nodeGroupLimitsInformer:   nodeGroupLimitsInformer,
+		machineTemplatesInformer:  machineTemplatesInformer,
+		machineAutoscalerInformer: machineAutoscalerInformer,
+		machinePoolInformer:       machinePoolInformer,
+		autoDiscoveryConfigs:      autoDiscoveryConfigs,
+		nodeGroupSelector:         nodeGroupSelector,
+		namespaces:                namespaces,
+		provisioningBackoff:       newProvisioningBackoff(),
+		updateRejections:          newUpdateRejections(),
+		machineDeletions:          newMachineDeletions(),
+		recorder:                  kube_util.CreateEventRecorder(kubeclient),
+	}
+
+	machineInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: c.machineDeletions.machineDeleted,
+	})
+
+	machineSetInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.labelMachineSetTemplate,
+		UpdateFunc: func(_, obj interface{}) {
+			c.labelMachineSetTemplate(obj)
+		},
+	})
+
+	return c, nil
 }
 
 func (c *machineController) machineSetNodeNames(machineSet *v1beta1.MachineSet) ([]string, error) {
@@ -316,6 +526,12 @@ func (c *machineController) machineSetNodeNames(machineSet *v1beta1.MachineSet)
 
 		if machine.Status.NodeRef == nil {
 			klog.V(4).Infof("Status.NodeRef of machine %q is currently nil", machine.Name)
+			// The machine is still being created: it is
+			// accounted for with a placeholder so that it
+			// is not mistaken for missing capacity.
+			if machine.DeletionTimestamp == nil {
+				nodes = append(nodes, pendingMachineProviderID(machine))
+			}
 			continue
 		}
 		if machine.Status.NodeRef.Kind != "Node" {
@@ -343,7 +559,7 @@ func (c *machineController) filterAllMachineSets(f machineSetFilterFunc) error {
 }
 
 func (c *machineController) filterMachineSets(namespace string, f machineSetFilterFunc) error {
-	machineSets, err := c.machineSetInformer.Lister().MachineSets(namespace).List(labels.Everything())
+	machineSets, err := machinelisters.NewMachineSetLister(c.machineSetInformer.GetIndexer()).MachineSets(namespace).List(labels.Everything())
 	if err != nil {
 		return nil
 	}
@@ -366,7 +582,7 @@ func (c *machineController) machineSetNodeGroups() ([]*nodegroup, error) {
 		if err != nil {
 			return err
 		}
-		if ng.MaxSize()-ng.MinSize() > 0 && pointer.Int32PtrDerefOr(machineSet.Spec.Replicas, 0) > 0 {
+		if ng.isAutoscaled() {
 			nodegroups = append(nodegroups, ng)
 		}
 		return nil
@@ -382,7 +598,7 @@ func (c *machineController) machineDeploymentNodeGroups() ([]*nodegroup, error)
 		return nil, nil
 	}
 
-	machineDeployments, err := c.machineDeploymentInformer.Lister().MachineDeployments(corev1.NamespaceAll).List(labels.Everything())
+	machineDeployments, err := machinelisters.NewMachineDeploymentLister(c.machineDeploymentInformer.GetIndexer()).MachineDeployments(corev1.NamespaceAll).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
@@ -395,7 +611,7 @@ func (c *machineController) machineDeploymentNodeGroups() ([]*nodegroup, error)
 			return nil, err
 		}
 		// add nodegroup iff it has the capacity to scale
-		if ng.MaxSize()-ng.MinSize() > 0 && pointer.Int32PtrDerefOr(md.Spec.Replicas, 0) > 0 {
+		if ng.isAutoscaled() {
 			nodegroups = append(nodegroups, ng)
 		}
 	}
@@ -413,25 +629,22 @@ func (c *machineController) nodeGroups() ([]*nodegroup, error) {
 	if err != nil {
 		return nil, err
 	}
-	return append(machineSets, machineDeployments...), nil
-}
 
-func (c *machineController) nodeGroupForNode(node *corev1.Node) (*nodegroup, error) {
-	machine, err := c.findMachineByProviderID(node.Spec.ProviderID)
+	machinePools, err := c.machinePoolNodeGroups()
 	if err != nil {
 		return nil, err
 	}
-	if machine == nil {
-		return nil, nil
-	}
+	return append(append(machineSets, machineDeployments...), machinePools...), nil
+}
 
-	machineSet, err := c.findMachineOwner(machine)
+func (c *machineController) nodeGroupForNode(node *corev1.Node) (*nodegroup, error) {
+	machineSet, err := c.findNodeMachineSet(node)
 	if err != nil {
 		return nil, err
 	}
 
 	if machineSet == nil {
-		return nil, nil
+		return c.nodeGroupForMachinePoolNode(node)
 	}
 
 	if c.enableMachineDeployments {
@@ -448,10 +661,7 @@ func (c *machineController) nodeGroupForNode(node *corev1.Node) (*nodegroup, err
 			if err != nil {
 				return nil, fmt.Errorf("failed to build nodegroup for node %q: %v", node.Name, err)
 			}
-			// We don't scale from 0 so nodes must belong
-			// to a nodegroup that has a scale size of at
-			// least 1.
-			if nodegroup.MaxSize()-nodegroup.MinSize() < 1 {
+			if !nodegroup.isAutoscaled() {
 				return nil, nil
 			}
 			return nodegroup, nil
@@ -463,9 +673,7 @@ func (c *machineController) nodeGroupForNode(node *corev1.Node) (*nodegroup, err
 		return nil, fmt.Errorf("failed to build nodegroup for node %q: %v", node.Name, err)
 	}
 
-	// We don't scale from 0 so nodes must belong to a nodegroup
-	// that has a scale size of at least 1.
-	if nodegroup.MaxSize()-nodegroup.MinSize() < 1 {
+	if !nodegroup.isAutoscaled() {
 		return nil, nil
 	}
 
@@ -473,11 +681,12 @@ func (c *machineController) nodeGroupForNode(node *corev1.Node) (*nodegroup, err
 	return nodegroup, nil
 }
 
-// findNodeByProviderID find the Node object keyed by provideID.
-// Returns nil if it cannot be found. A DeepCopy() of the object is
+// findNodeByProviderID find the Node object keyed by provideID, as
+// normalized by normalizedProviderID. Returns nil if it cannot be
+// found. A DeepCopy() of the object is
 // returned on success.
 func (c *machineController) findNodeByProviderID(providerID string) (*corev1.Node, error) {
-	objs, err := c.nodeInformer.GetIndexer().ByIndex(nodeProviderIDIndex, providerID)
+	objs, err := c.nodeInformer.GetIndexer().ByIndex(nodeProviderIDIndex, normalizedProviderID(providerID))
 	if err != nil {
 		return nil, err
 	}
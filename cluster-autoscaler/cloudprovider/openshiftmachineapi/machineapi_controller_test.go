@@ -23,14 +23,16 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
-	fakeclusterapi "github.com/openshift/cluster-api/pkg/client/clientset_generated/clientset/fake"
+	machinelisters "github.com/openshift/cluster-api/pkg/client/listers_generated/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/utils/pointer"
 )
@@ -56,6 +58,13 @@ type testSpec struct {
 
 func mustCreateTestController(t *testing.T, testConfigs ...*testConfig) (*machineController, testControllerShutdownFunc) {
 	t.Helper()
+	return mustCreateNamespacedTestController(t, nil, testConfigs...)
+}
+
+// mustCreateNamespacedTestController creates a test controller only
+// watching the machine API objects of namespaces.
+func mustCreateNamespacedTestController(t *testing.T, namespaces []string, testConfigs ...*testConfig) (*machineController, testControllerShutdownFunc) {
+	t.Helper()
 
 	nodeObjects := make([]runtime.Object, 0)
 	machineObjects := make([]runtime.Object, 0)
@@ -76,8 +85,12 @@ func mustCreateTestController(t *testing.T, testConfigs ...*testConfig) (*machin
 	}
 
 	kubeclientSet := fakekube.NewSimpleClientset(nodeObjects...)
-	clusterclientSet := fakeclusterapi.NewSimpleClientset(machineObjects...)
-	controller, err := newMachineController(kubeclientSet, clusterclientSet, true)
+	resources := newMachineAPIResources(v1beta1.SchemeGroupVersion)
+	dynamicclient, err := newFakeDynamicClient(resources, machineObjects...)
+	if err != nil {
+		t.Fatalf("failed to create dynamic client: %v", err)
+	}
+	controller, err := newMachineController(kubeclientSet, dynamicclient, resources, newTestMachineAutoscalerListWatch, true, nil, namespaces, nil)
 	if err != nil {
 		t.Fatal("failed to create test controller")
 	}
@@ -215,6 +228,7 @@ func makeLinkedNodeAndMachine(i int, namespace string, owner v1.OwnerReference)
 		ObjectMeta: v1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%s-machine-%d", namespace, owner.Name, i),
 			Namespace: namespace,
+			UID:       types.UID(fmt.Sprintf("%s-%s-machine-%d", namespace, owner.Name, i)),
 			OwnerReferences: []v1.OwnerReference{{
 				Name: owner.Name,
 				Kind: owner.Kind,
@@ -244,15 +258,15 @@ func addTestConfigs(t *testing.T, controller *machineController, testConfigs ...
 
 	for _, config := range testConfigs {
 		if config.machineDeployment != nil {
-			if err := controller.machineDeploymentInformer.Informer().GetStore().Add(config.machineDeployment); err != nil {
+			if err := controller.machineDeploymentInformer.GetStore().Add(config.machineDeployment); err != nil {
 				return err
 			}
 		}
-		if err := controller.machineSetInformer.Informer().GetStore().Add(config.machineSet); err != nil {
+		if err := controller.machineSetInformer.GetStore().Add(config.machineSet); err != nil {
 			return err
 		}
 		for i := range config.machines {
-			if err := controller.machineInformer.Informer().GetStore().Add(config.machines[i]); err != nil {
+			if err := controller.machineInformer.GetStore().Add(config.machines[i]); err != nil {
 				return err
 			}
 		}
@@ -275,15 +289,15 @@ func deleteTestConfigs(t *testing.T, controller *machineController, testConfigs
 			}
 		}
 		for i := range config.machines {
-			if err := controller.machineInformer.Informer().GetStore().Delete(config.machines[i]); err != nil {
+			if err := controller.machineInformer.GetStore().Delete(config.machines[i]); err != nil {
 				return err
 			}
 		}
-		if err := controller.machineSetInformer.Informer().GetStore().Delete(config.machineSet); err != nil {
+		if err := controller.machineSetInformer.GetStore().Delete(config.machineSet); err != nil {
 			return err
 		}
 		if config.machineDeployment != nil {
-			if err := controller.machineDeploymentInformer.Informer().GetStore().Delete(config.machineDeployment); err != nil {
+			if err := controller.machineDeploymentInformer.GetStore().Delete(config.machineDeployment); err != nil {
 				return err
 			}
 		}
@@ -386,7 +400,7 @@ func TestControllerFindMachineOwner(t *testing.T) {
 	}
 
 	// Test #3: Delete the MachineSet and lookup should fail
-	if err := controller.machineSetInformer.Informer().GetStore().Delete(testResult1); err != nil {
+	if err := controller.machineSetInformer.GetStore().Delete(testResult1); err != nil {
 		t.Fatalf("unexpected error, got %v", err)
 	}
 	testResult3, err := controller.findMachineOwner(testConfig.machines[0].DeepCopy())
@@ -429,11 +443,26 @@ func TestControllerFindMachineByProviderID(t *testing.T) {
 		t.Fatalf("expected machines to be equal - expected %+v, got %+v", testConfig.machines[0], machine)
 	}
 
-	// Test #2: Verify machine is not found if it has a
+	// Test #2: Verify machine is found if its provider ID is
+	// formatted differently than the node's.
+	machine = testConfig.machines[0].DeepCopy()
+	machine.Spec.ProviderID = pointer.StringPtr("aws:///i-0123456789abcdef0")
+	if err := controller.machineInformer.GetStore().Update(machine); err != nil {
+		t.Fatalf("unexpected error updating machine, got %v", err)
+	}
+	machine, err = controller.findMachineByProviderID("aws:///us-east-1a/i-0123456789abcdef0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if machine == nil || machine.Name != testConfig.machines[0].Name {
+		t.Fatalf("expected to find machine %q, got %v", testConfig.machines[0].Name, machine)
+	}
+
+	// Test #3: Verify machine is not found if it has a
 	// non-existent or different provider ID.
 	machine = testConfig.machines[0].DeepCopy()
 	machine.Spec.ProviderID = pointer.StringPtr("does-not-match")
-	if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+	if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 		t.Fatalf("unexpected error updating machine, got %v", err)
 	}
 	machine, err = controller.findMachineByProviderID(testConfig.nodes[0].Spec.ProviderID)
@@ -495,12 +524,12 @@ func TestControllerMachinesInMachineSet(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	machinesInTestObjs1, err := controller.machineInformer.Lister().Machines(testConfig1.spec.namespace).List(labels.Everything())
+	machinesInTestObjs1, err := machinelisters.NewMachineLister(controller.machineInformer.GetIndexer()).Machines(testConfig1.spec.namespace).List(labels.Everything())
 	if err != nil {
 		t.Fatalf("error listing machines: %v", err)
 	}
 
-	machinesInTestObjs2, err := controller.machineInformer.Lister().Machines(testConfig2.spec.namespace).List(labels.Everything())
+	machinesInTestObjs2, err := machinelisters.NewMachineLister(controller.machineInformer.GetIndexer()).Machines(testConfig2.spec.namespace).List(labels.Everything())
 	if err != nil {
 		t.Fatalf("error listing machines: %v", err)
 	}
@@ -578,7 +607,7 @@ func TestControllerNodeGroupForNodeWithMissingMachineOwner(t *testing.T) {
 
 		machine := testConfig.machines[0].DeepCopy()
 		machine.OwnerReferences = []v1.OwnerReference{}
-		if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 			t.Fatalf("unexpected error updating machine, got %v", err)
 		}
 
@@ -618,8 +647,8 @@ func TestControllerNodeGroupForNodeWithPositiveScalingBounds(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		// We don't scale from 0 so nodes must belong to a
-		// nodegroup that has a scale size of at least 1.
+		// Nodes must belong to a nodegroup whose size can
+		// change.
 		if ng != nil {
 			t.Fatalf("unexpected nodegroup: %v", ng)
 		}
@@ -642,6 +671,213 @@ func TestControllerNodeGroupForNodeWithPositiveScalingBounds(t *testing.T) {
 	})
 }
 
+func TestControllerNodeGroupForNodeWithZeroMinSize(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig, replicas int32, expected bool) {
+		if testConfig.machineDeployment != nil {
+			testConfig.machineDeployment.Spec.Replicas = &replicas
+		}
+		testConfig.machineSet.Spec.Replicas = &replicas
+
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		ng, err := controller.nodeGroupForNode(testConfig.nodes[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !expected {
+			if ng != nil {
+				t.Fatalf("unexpected nodegroup: %v", ng)
+			}
+			return
+		}
+		if ng == nil {
+			t.Fatal("expected a nodegroup")
+		}
+		if actual := ng.MinSize(); actual != 0 {
+			t.Errorf("expected min size 0, got %d", actual)
+		}
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 || nodegroups[0].Id() != ng.Id() {
+			t.Errorf("expected nodegroup %q to be listed, got %v", ng.Id(), nodegroups)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "0",
+		nodeGroupMaxSizeAnnotationKey: "1",
+	}
+	scaleFromZeroAnnotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "0",
+		nodeGroupMaxSizeAnnotationKey: "1",
+		cpuCapacityAnnotationKey:      "2",
+		memoryCapacityAnnotationKey:   "8Gi",
+	}
+
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		replicas    int32
+		expected    bool
+	}{{
+		description: "with replicas",
+		annotations: annotations,
+		replicas:    1,
+		expected:    true,
+	}, {
+		// The node of a node group scaled down to zero outlives
+		// its replica.
+		description: "scaled down to zero without capacity",
+		annotations: annotations,
+		replicas:    0,
+		expected:    false,
+	}, {
+		description: "scaled down to zero with capacity",
+		annotations: scaleFromZeroAnnotations,
+		replicas:    0,
+		expected:    true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Run("MachineSet", func(t *testing.T) {
+				test(t, createMachineSetTestConfig(testNamespace, 1, tc.annotations), tc.replicas, tc.expected)
+			})
+
+			t.Run("MachineDeployment", func(t *testing.T) {
+				test(t, createMachineDeploymentTestConfig(testNamespace, 1, tc.annotations), tc.replicas, tc.expected)
+			})
+		})
+	}
+}
+
+func TestControllerNodeGroupsWithLabelSelector(t *testing.T) {
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+	selector := labels.SelectorFromSet(labels.Set{"autoscaler": "a"})
+
+	test := func(t *testing.T, newTestConfig func(namespace string) *testConfig) {
+		selected := newTestConfig("namespace-a")
+		unselected := newTestConfig("namespace-b")
+		setLabels := func(testConfig *testConfig, value string) {
+			// The labels of the MachineDeployment select it, not
+			// those of its MachineSet.
+			if testConfig.machineDeployment != nil {
+				testConfig.machineDeployment.Labels = map[string]string{"autoscaler": value}
+				return
+			}
+			testConfig.machineSet.Labels = map[string]string{"autoscaler": value}
+		}
+		setLabels(selected, "a")
+		setLabels(unselected, "b")
+
+		controller, stop := mustCreateTestController(t, selected, unselected)
+		defer stop()
+		controller.nodeGroupSelector = selector
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+		if ns := nodegroups[0].Namespace(); ns != "namespace-a" {
+			t.Errorf("expected nodegroup in namespace %q, got %q", "namespace-a", ns)
+		}
+
+		ng, err := controller.nodeGroupForNode(selected.nodes[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ng == nil {
+			t.Error("expected a nodegroup for the node of the selected scalable resource")
+		}
+
+		ng, err = controller.nodeGroupForNode(unselected.nodes[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ng != nil {
+			t.Errorf("unexpected nodegroup %q for the node of the unselected scalable resource", ng.Id())
+		}
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, func(namespace string) *testConfig {
+			return createMachineSetTestConfig(namespace, 1, annotations)
+		})
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, func(namespace string) *testConfig {
+			return createMachineDeploymentTestConfig(namespace, 1, annotations)
+		})
+	})
+}
+
+func TestControllerNodeGroupsPaused(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig, expected bool) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected && len(nodegroups) != 1 {
+			t.Errorf("expected 1 nodegroup, got %d", len(nodegroups))
+		}
+		if !expected && len(nodegroups) != 0 {
+			t.Errorf("expected no nodegroups, got %d", len(nodegroups))
+		}
+
+		ng, err := controller.nodeGroupForNode(testConfig.nodes[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected && ng == nil {
+			t.Error("expected a nodegroup for the node")
+		}
+		if !expected && ng != nil {
+			t.Errorf("unexpected nodegroup %q for the node", ng.Id())
+		}
+	}
+
+	for _, tc := range []struct {
+		description string
+		paused      string
+		expected    bool
+	}{{
+		description: "paused",
+		paused:      "true",
+		expected:    false,
+	}, {
+		description: "not paused",
+		paused:      "false",
+		expected:    true,
+	}} {
+		annotations := map[string]string{
+			nodeGroupMinSizeAnnotationKey: "1",
+			nodeGroupMaxSizeAnnotationKey: "10",
+			nodeGroupPausedAnnotationKey:  tc.paused,
+		}
+		t.Run(tc.description, func(t *testing.T) {
+			t.Run("MachineSet", func(t *testing.T) {
+				test(t, createMachineSetTestConfig(testNamespace, 1, annotations), tc.expected)
+			})
+
+			t.Run("MachineDeployment", func(t *testing.T) {
+				test(t, createMachineDeploymentTestConfig(testNamespace, 1, annotations), tc.expected)
+			})
+		})
+	}
+}
+
 func TestControllerNodeGroups(t *testing.T) {
 	assertNodegroupLen := func(t *testing.T, controller *machineController, expected int) {
 		t.Helper()
@@ -800,6 +1036,56 @@ func TestControllerNodeGroupsNodeCount(t *testing.T) {
 	})
 }
 
+func TestControllerNodeGroupsScaleFromZero(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig, expectedNodeGroups int) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := len(nodegroups); got != expectedNodeGroups {
+			t.Fatalf("expected %d, got %d", expectedNodeGroups, got)
+		}
+		if expectedNodeGroups == 0 {
+			return
+		}
+
+		if size, _ := nodegroups[0].TargetSize(); size != 0 {
+			t.Errorf("expected target size 0, got %d", size)
+		}
+		nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cpu := nodeInfo.Node().Status.Capacity[corev1.ResourceCPU]; cpu.Value() != 2 {
+			t.Errorf("expected 2 cpus, got %v", cpu.String())
+		}
+	}
+
+	withoutCapacity := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "0",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+	withCapacity := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "0",
+		nodeGroupMaxSizeAnnotationKey: "10",
+		cpuCapacityAnnotationKey:      "2",
+		memoryCapacityAnnotationKey:   "8Gi",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 0, withoutCapacity), 0)
+		test(t, createMachineSetTestConfig(testNamespace, 0, withCapacity), 1)
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 0, withoutCapacity), 0)
+		test(t, createMachineDeploymentTestConfig(testNamespace, 0, withCapacity), 1)
+	})
+}
+
 func TestControllerFindMachineFromNodeAnnotation(t *testing.T) {
 	testConfig := createMachineSetTestConfig(testNamespace, 1, map[string]string{
 		nodeGroupMinSizeAnnotationKey: "1",
@@ -814,7 +1100,7 @@ func TestControllerFindMachineFromNodeAnnotation(t *testing.T) {
 	// searching using the annotation on the node object.
 	for _, machine := range testConfig.machines {
 		machine.Spec.ProviderID = nil
-		if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 			t.Fatalf("unexpected error updating machine, got %v", err)
 		}
 	}
@@ -831,8 +1117,8 @@ func TestControllerFindMachineFromNodeAnnotation(t *testing.T) {
 		t.Fatalf("expected machines to be equal - expected %+v, got %+v", testConfig.machines[0], machine)
 	}
 
-	// Test #2: Verify machine is not found if it has no
-	// corresponding machine annotation.
+	// Test #2: Verify machine is found from Status.NodeRef.Name
+	// if the node has no corresponding machine annotation.
 	node := testConfig.nodes[0].DeepCopy()
 	delete(node.Annotations, machineAnnotationKey)
 	if err := controller.nodeInformer.GetStore().Update(node); err != nil {
@@ -842,6 +1128,24 @@ func TestControllerFindMachineFromNodeAnnotation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if machine == nil {
+		t.Fatal("expected to find machine")
+	}
+	if !reflect.DeepEqual(machine, testConfig.machines[0]) {
+		t.Fatalf("expected machines to be equal - expected %+v, got %+v", testConfig.machines[0], machine)
+	}
+
+	// Test #3: Verify machine is not found if it has no
+	// Status.NodeRef either.
+	machine = testConfig.machines[0].DeepCopy()
+	machine.Status.NodeRef = nil
+	if err := controller.machineInformer.GetStore().Update(machine); err != nil {
+		t.Fatalf("unexpected error updating machine, got %v", err)
+	}
+	machine, err = controller.findMachineByProviderID(testConfig.nodes[0].Spec.ProviderID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	if machine != nil {
 		t.Fatal("expected find to fail")
 	}
@@ -859,13 +1163,13 @@ func TestControllerMachineSetNodeNamesWithoutLinkage(t *testing.T) {
 	// Remove all linkage between node and machine.
 	for _, machine := range testConfig.machines {
 		machine.Spec.ProviderID = nil
-		if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 			t.Fatalf("unexpected error updating machine, got %v", err)
 		}
 	}
 	for _, machine := range testConfig.machines {
 		machine.Status.NodeRef = nil
-		if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 			t.Fatalf("unexpected error updating machine, got %v", err)
 		}
 	}
@@ -885,7 +1189,52 @@ func TestControllerMachineSetNodeNamesWithoutLinkage(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// We removed all linkage - so we should get 0 nodes back.
+	// We removed all linkage - so we should get placeholders of
+	// machines being created back.
+	if len(nodeNames) != len(testConfig.machines) {
+		t.Fatalf("expected len=%v, got len=%v", len(testConfig.machines), len(nodeNames))
+	}
+
+	for _, instance := range nodeNames {
+		if !isPendingMachineProviderID(instance.Id) {
+			t.Errorf("expected a pending machine provider ID, got %q", instance.Id)
+		}
+		if instance.Status == nil || instance.Status.State != cloudprovider.InstanceCreating {
+			t.Errorf("expected instance %q to be creating, got %+v", instance.Id, instance.Status)
+		}
+
+		// The placeholder leads back to the machine and its
+		// nodegroup, as for an unregistered node.
+		machine, err := controller.findMachineByProviderID(instance.Id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if machine == nil || pendingMachineProviderID(machine) != instance.Id {
+			t.Errorf("expected the machine of %q, got %v", instance.Id, machine)
+		}
+		nodegroup, err := controller.nodeGroupForNode(&corev1.Node{
+			Spec: corev1.NodeSpec{ProviderID: instance.Id},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if nodegroup == nil || nodegroup.Id() != ng.Id() {
+			t.Errorf("expected nodegroup %q for %q, got %v", ng.Id(), instance.Id, nodegroup)
+		}
+	}
+
+	// Machines being deleted are not expected to produce a node.
+	for _, machine := range testConfig.machines {
+		machine.DeletionTimestamp = &v1.Time{Time: time.Now()}
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
+			t.Fatalf("unexpected error updating machine, got %v", err)
+		}
+	}
+
+	nodeNames, err = ng.Nodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	if len(nodeNames) != 0 {
 		t.Fatalf("expected len=0, got len=%v", len(nodeNames))
 	}
@@ -905,7 +1254,7 @@ func TestControllerMachineSetNodeNamesUsingProviderID(t *testing.T) {
 	// ID for lookups.
 	for _, machine := range testConfig.machines {
 		machine.Status.NodeRef = nil
-		if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 			t.Fatalf("unexpected error updating machine, got %v", err)
 		}
 	}
@@ -954,7 +1303,7 @@ func TestControllerMachineSetNodeNamesUsingStatusNodeRefName(t *testing.T) {
 	// searching using Status.NodeRef.Name.
 	for _, machine := range testConfig.machines {
 		machine.Spec.ProviderID = nil
-		if err := controller.machineInformer.Informer().GetStore().Update(machine); err != nil {
+		if err := controller.machineInformer.GetStore().Update(machine); err != nil {
 			t.Fatalf("unexpected error updating machine, got %v", err)
 		}
 	}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+const (
+	// machineDeletionTimeout is how long a machine whose deletion
+	// was requested by DeleteNodes is considered being deleted if
+	// it doesn't disappear.
+	machineDeletionTimeout = 15 * time.Minute
+)
+
+// machineDeletions keeps track of the machines DeleteNodes annotated
+// for deletion and decreased the replicas of the scalable resource
+// for, until the machines disappear. The machine controllers and the
+// informers of the autoscaler take a while to catch up with such a
+// deletion, during which the machine isn't being deleted yet and the
+// replicas of the scalable resource may still be the old ones:
+// deleting its node again, or another node of the node group, would
+// then delete more machines than requested, or fewer.
+type machineDeletions struct {
+	mutex    sync.Mutex
+	machines map[types.UID]*machineDeletion
+}
+
+type machineDeletion struct {
+	// nodeGroup is the id of the node group of the machine.
+	nodeGroup string
+	// replicas is the replica count of the scalable resource set
+	// to delete the machine, and observed the one it had before,
+	// which the informers report until they catch up. observed is
+	// negative once the replicas were changed again.
+	replicas int32
+	observed int32
+	expires  time.Time
+}
+
+func newMachineDeletions() *machineDeletions {
+	return &machineDeletions{
+		machines: make(map[types.UID]*machineDeletion),
+	}
+}
+
+// expire forgets the deletions older than machineDeletionTimeout. The
+// mutex must be held.
+func (d *machineDeletions) expire(now time.Time) {
+	for uid, deletion := range d.machines {
+		if !deletion.expires.After(now) {
+			klog.Warningf("nodegroup %q: machine %s still exists %v after its deletion", deletion.nodeGroup, uid, machineDeletionTimeout)
+			delete(d.machines, uid)
+		}
+	}
+}
+
+// add records the deletion of the machine of the node group by
+// setting the replicas of its scalable resource from observed to
+// replicas.
+func (d *machineDeletions) add(uid types.UID, nodeGroup string, observed, replicas int32, now time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.machines[uid] = &machineDeletion{
+		nodeGroup: nodeGroup,
+		replicas:  replicas,
+		observed:  observed,
+		expires:   now.Add(machineDeletionTimeout),
+	}
+}
+
+// resized records that the replicas of the scalable resource of the
+// node group were changed for another reason than a deletion, so that
+// the replicas set by the deletions in flight don't apply anymore.
+func (d *machineDeletions) resized(nodeGroup string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, deletion := range d.machines {
+		if deletion.nodeGroup == nodeGroup {
+			deletion.observed = -1
+		}
+	}
+}
+
+// forget forgets the deletion of the machine, once it disappeared.
+func (d *machineDeletions) forget(uid types.UID) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.machines, uid)
+}
+
+// inFlight returns true if the deletion of the machine was requested
+// and it didn't disappear yet.
+func (d *machineDeletions) inFlight(uid types.UID, now time.Time) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.expire(now)
+	_, found := d.machines[uid]
+	return found
+}
+
+// replicas returns the replicas of the scalable resource of the node
+// group given the ones the informers report: the lowest replica count
+// set for the deletions in flight of its machines while the informers
+// still report the replicas from before these deletions.
+func (d *machineDeletions) replicas(nodeGroup string, observed int32, now time.Time) int32 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.expire(now)
+	replicas := observed
+	for _, deletion := range d.machines {
+		if deletion.nodeGroup == nodeGroup && deletion.observed == observed && deletion.replicas < replicas {
+			replicas = deletion.replicas
+		}
+	}
+	return replicas
+}
+
+// machineDeleted forgets the deletion of the machine removed from the
+// machine informer.
+func (d *machineDeletions) machineDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if machine, ok := obj.(*v1beta1.Machine); ok {
+		d.forget(machine.UID)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+)
+
+func TestMachineDeletions(t *testing.T) {
+	const id = "ns/ng"
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	d := newMachineDeletions()
+
+	d.add("m1", id, 5, 4, now)
+	d.add("m2", id, 5, 3, now)
+	d.add("other", "ns/other", 5, 1, now)
+	if !d.inFlight("m1", now) || d.inFlight("m3", now) {
+		t.Errorf("expected only m1 in flight")
+	}
+
+	// The replicas set by the deletions apply while the informers
+	// report the replicas from before them.
+	if actual := d.replicas(id, 5, now); actual != 3 {
+		t.Errorf("expected 3 replicas, got %d", actual)
+	}
+	if actual := d.replicas(id, 4, now); actual != 4 {
+		t.Errorf("expected 4 replicas, got %d", actual)
+	}
+
+	d.machineDeleted(&v1beta1.Machine{})
+	d.machineDeleted(cache.DeletedFinalStateUnknown{Obj: machineWithUID("m2")})
+	if d.inFlight("m2", now) {
+		t.Errorf("expected m2 not in flight once deleted")
+	}
+	if actual := d.replicas(id, 5, now); actual != 4 {
+		t.Errorf("expected 4 replicas, got %d", actual)
+	}
+
+	// Other resizes override the replicas of the deletions.
+	d.resized(id)
+	if actual := d.replicas(id, 5, now); actual != 5 {
+		t.Errorf("expected 5 replicas, got %d", actual)
+	}
+	if !d.inFlight("m1", now) {
+		t.Errorf("expected m1 in flight")
+	}
+
+	if later := now.Add(machineDeletionTimeout); d.inFlight("m1", later) || d.inFlight("other", later) {
+		t.Errorf("expected deletions to expire")
+	}
+}
+
+func machineWithUID(uid types.UID) *v1beta1.Machine {
+	machine := &v1beta1.Machine{}
+	machine.UID = uid
+	return machine
+}
+
+func TestNodeGroupDeleteNodesInFlight(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+		ng := nodegroups[0]
+
+		if err := ng.DeleteNodes(testConfig.nodes[:1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Deleting the node again before its machine disappears,
+		// from a node group the informers didn't update yet, leaves
+		// the replicas alone.
+		if err := ng.DeleteNodes(testConfig.nodes[:1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual := replicasOf(t, controller, testConfig); actual != 2 {
+			t.Errorf("expected 2 replicas, got %d", actual)
+		}
+		if size, err := ng.TargetSize(); err != nil || size != 2 {
+			t.Errorf("expected target size 2, got %d: %v", size, err)
+		}
+
+		instances, err := ng.Nodes()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, instance := range instances {
+			deleting := instance.Status != nil && instance.Status.State == cloudprovider.InstanceDeleting
+			if expected := instance.Id == testConfig.nodes[0].Spec.ProviderID; deleting != expected {
+				t.Errorf("expected instance %q deleting %v, got %v", instance.Id, expected, deleting)
+			}
+		}
+
+		// Once the machine disappears and the informers catch up,
+		// deleting another node decreases the replicas again.
+		controller.machineDeletions.machineDeleted(testConfig.machines[0])
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			nodegroups, err := controller.nodeGroups()
+			if err != nil {
+				return false, err
+			}
+			ng = nodegroups[0]
+			return ng.scalableResource.Replicas() == 2, nil
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := ng.DeleteNodes(testConfig.nodes[1:2]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual := replicasOf(t, controller, testConfig); actual != 1 {
+			t.Errorf("expected 1 replica, got %d", actual)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 3, annotations))
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 3, annotations))
+	})
+}
+
+// replicasOf returns the replicas of the scalable resource of the
+// test config stored by the machine API.
+func replicasOf(t *testing.T, controller *machineController, testConfig *testConfig) int32 {
+	t.Helper()
+	if testConfig.machineDeployment != nil {
+		machineDeployment, err := getMachineDeployment(controller, testConfig.machineDeployment.Namespace, testConfig.machineDeployment.Name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return pointer.Int32PtrDerefOr(machineDeployment.Spec.Replicas, 0)
+	}
+	machineSet, err := getMachineSet(controller, testConfig.machineSet.Namespace, testConfig.machineSet.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return pointer.Int32PtrDerefOr(machineSet.Spec.Replicas, 0)
+}
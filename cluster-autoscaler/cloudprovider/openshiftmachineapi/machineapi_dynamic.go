@@ -0,0 +1,336 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+const (
+	// clusterAPIGroup is the API group of the machine resources
+	// of upstream cluster-api.
+	clusterAPIGroup = "cluster.x-k8s.io"
+)
+
+var (
+	// machineAPIGroups are the API groups of the machine
+	// resources this provider can drive, by order of preference.
+	machineAPIGroups = []string{machineAPIGroup, clusterAPIGroup}
+
+	// errMachineAPINotFound is the error returned when none of
+	// machineAPIGroups is served by the cluster.
+	errMachineAPINotFound = errors.New("machine API not found")
+
+	// clusterAPIKeys are the annotation and label keys of upstream
+	// cluster-api that are not the machine.openshift.io ones with
+	// the clusterAPIGroup prefix.
+	clusterAPIKeys = map[string]string{
+		machineDeleteAnnotationKey: clusterAPIGroup + "/delete-machine",
+		machineSetLabelKey:         clusterAPIGroup + "/set-name",
+		machineDeploymentLabelKey:  clusterAPIGroup + "/deployment-name",
+	}
+
+	// keyFields are the fields of the machine resources holding
+	// annotations or labels.
+	keyFields = [][]string{
+		{"metadata", "labels"},
+		{"metadata", "annotations"},
+		{"spec", "selector", "matchLabels"},
+		{"spec", "template", "metadata", "labels"},
+		{"spec", "template", "metadata", "annotations"},
+		{"spec", "template", "spec", "metadata", "labels"},
+		{"spec", "template", "spec", "metadata", "annotations"},
+	}
+)
+
+// apiKey returns the annotation or label key of the machine API group
+// for key, one of the machine.openshift.io keys used by this
+// provider. The keys of the clusterAPIGroup resources and of their
+// nodes have its prefix.
+func apiKey(group, key string) string {
+	if group != clusterAPIGroup || !strings.HasPrefix(key, machineAPIGroup+"/") {
+		return key
+	}
+	if clusterAPIKey, found := clusterAPIKeys[key]; found {
+		return clusterAPIKey
+	}
+	return clusterAPIGroup + strings.TrimPrefix(key, machineAPIGroup)
+}
+
+// internalKey is the reverse of apiKey.
+func internalKey(group, key string) string {
+	if group != clusterAPIGroup || !strings.HasPrefix(key, clusterAPIGroup+"/") {
+		return key
+	}
+	for internal, clusterAPIKey := range clusterAPIKeys {
+		if clusterAPIKey == key {
+			return internal
+		}
+	}
+	return machineAPIGroup + strings.TrimPrefix(key, clusterAPIGroup)
+}
+
+// translateKeys returns a copy of values with its keys translated,
+// or values itself if it is nil.
+func translateKeys(values map[string]string, translate func(key string) string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	translated := make(map[string]string, len(values))
+	for key, value := range values {
+		translated[translate(key)] = value
+	}
+	return translated
+}
+
+// translateUnstructuredKeys translates the keys of the keyFields of u
+// in place.
+func translateUnstructuredKeys(u *unstructured.Unstructured, translate func(key string) string) {
+	for _, fields := range keyFields {
+		values, found, err := unstructured.NestedMap(u.Object, fields...)
+		if err != nil || !found {
+			continue
+		}
+		translated := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			translated[translate(key)] = value
+		}
+		if err := unstructured.SetNestedMap(u.Object, translated, fields...); err != nil {
+			klog.Warningf("cannot set %s of %s/%s: %v", strings.Join(fields, "."), u.GetNamespace(), u.GetName(), err)
+		}
+	}
+}
+
+// fromAPIKeys translates the keys of u, of the given machine API
+// group, to the keys used by this provider.
+func fromAPIKeys(group string, u *unstructured.Unstructured) {
+	translateUnstructuredKeys(u, func(key string) string { return internalKey(group, key) })
+}
+
+// toAPIKeys is the reverse of fromAPIKeys.
+func toAPIKeys(group string, u *unstructured.Unstructured) {
+	translateUnstructuredKeys(u, func(key string) string { return apiKey(group, key) })
+}
+
+// apiKey returns the annotation or label key of the machine API
+// served by the cluster for key.
+func (c *machineController) apiKey(key string) string {
+	return apiKey(c.resources.machines.Group, key)
+}
+
+// machineAPIResources are the machine resources of the API group
+// version served by the cluster. They are read and updated as
+// unstructured objects, so that the same types can be used for all
+// the groups in machineAPIGroups, and converted to the
+// machine.openshift.io types, and annotation and label keys, for
+// reading.
+type machineAPIResources struct {
+	machines           schema.GroupVersionResource
+	machineSets        schema.GroupVersionResource
+	machineDeployments schema.GroupVersionResource
+	// machinePools is empty if MachinePools are not served.
+	machinePools schema.GroupVersionResource
+}
+
+func newMachineAPIResources(groupVersion schema.GroupVersion) machineAPIResources {
+	return machineAPIResources{
+		machines:           groupVersion.WithResource("machines"),
+		machineSets:        groupVersion.WithResource("machinesets"),
+		machineDeployments: groupVersion.WithResource("machinedeployments"),
+	}
+}
+
+// discoverMachineAPIResources returns the machine resources of the
+// preferred version of the first of machineAPIGroups served by the
+// cluster, together with its MachinePools if any. Returns
+// errMachineAPINotFound if there is none.
+func discoverMachineAPIResources(client discovery.DiscoveryInterface) (machineAPIResources, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return machineAPIResources{}, err
+	}
+	for _, name := range machineAPIGroups {
+		for _, group := range groups.Groups {
+			if group.Name != name {
+				continue
+			}
+			groupVersion, err := schema.ParseGroupVersion(group.PreferredVersion.GroupVersion)
+			if err != nil {
+				return machineAPIResources{}, err
+			}
+			klog.V(1).Infof("using machine API %s", groupVersion)
+			resources := newMachineAPIResources(groupVersion)
+			resources.machinePools = discoverMachinePoolResource(client, groups)
+			return resources, nil
+		}
+	}
+	return machineAPIResources{}, errors.Wrapf(errMachineAPINotFound, "none of %v is served", machineAPIGroups)
+}
+
+// newMachineAPIInformer returns an informer for resource in
+// namespace, or in all namespaces if it is metav1.NamespaceAll,
+// storing its objects converted to the type of newObj.
+func newMachineAPIInformer(dynamicclient dynamic.Interface, resource schema.GroupVersionResource, namespace string, newObj, newList func() runtime.Object) cache.SharedIndexInformer {
+	client := dynamicclient.Resource(resource).Namespace(namespace)
+	convert := func(u *unstructured.Unstructured) (runtime.Object, error) {
+		fromAPIKeys(resource.Group, u)
+		obj := newObj()
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), obj); err != nil {
+			return nil, errors.Wrapf(err, "cannot convert %s %s/%s", resource.Resource, u.GetNamespace(), u.GetName())
+		}
+		return obj, nil
+	}
+
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			ul, err := client.List(options)
+			if err != nil {
+				return nil, err
+			}
+			objs := make([]runtime.Object, 0, len(ul.Items))
+			for i := range ul.Items {
+				obj, err := convert(&ul.Items[i])
+				if err != nil {
+					return nil, err
+				}
+				objs = append(objs, obj)
+			}
+			list := newList()
+			if err := meta.SetList(list, objs); err != nil {
+				return nil, err
+			}
+			listMeta, err := meta.ListAccessor(list)
+			if err != nil {
+				return nil, err
+			}
+			listMeta.SetResourceVersion(ul.GetResourceVersion())
+			return list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := client.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				if event.Type == watch.Error {
+					return event, true
+				}
+				u, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					return event, true
+				}
+				obj, err := convert(u)
+				if err != nil {
+					klog.Errorf("dropping watch event: %v", err)
+					return event, false
+				}
+				event.Object = obj
+				return event, true
+			}), nil
+		},
+	}
+
+	return cache.NewSharedIndexInformer(listWatch, newObj(), 0, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+}
+
+func newMachineInformer(dynamicclient dynamic.Interface, resources machineAPIResources, namespace string) cache.SharedIndexInformer {
+	return newMachineAPIInformer(dynamicclient, resources.machines, namespace,
+		func() runtime.Object { return &v1beta1.Machine{} },
+		func() runtime.Object { return &v1beta1.MachineList{} })
+}
+
+func newMachineSetInformer(dynamicclient dynamic.Interface, resources machineAPIResources, namespace string) cache.SharedIndexInformer {
+	return newMachineAPIInformer(dynamicclient, resources.machineSets, namespace,
+		func() runtime.Object { return &v1beta1.MachineSet{} },
+		func() runtime.Object { return &v1beta1.MachineSetList{} })
+}
+
+func newMachineDeploymentInformer(dynamicclient dynamic.Interface, resources machineAPIResources, namespace string) cache.SharedIndexInformer {
+	return newMachineAPIInformer(dynamicclient, resources.machineDeployments, namespace,
+		func() runtime.Object { return &v1beta1.MachineDeployment{} },
+		func() runtime.Object { return &v1beta1.MachineDeploymentList{} })
+}
+
+// updateUnstructured gets the object of resource with the given
+// namespace and name, modifies it with f and updates it. Only the
+// fields set by f are changed, the fields the machine.openshift.io
+// types don't know about are kept. The object f gets has the keys of
+// the served machine API, see apiKey.
+func (c *machineController) updateUnstructured(resource schema.GroupVersionResource, namespace, name string, f func(u *unstructured.Unstructured) error) error {
+	client := c.dynamicclient.Resource(resource).Namespace(namespace)
+	u, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := f(u); err != nil {
+		return err
+	}
+	_, err = client.Update(u, metav1.UpdateOptions{})
+	return err
+}
+
+// setMachineAnnotation sets the annotation key of the machine to
+// value, or removes it if value is nil.
+func (c *machineController) setMachineAnnotation(namespace, name, key string, value *string) error {
+	return c.updateUnstructured(c.resources.machines, namespace, name, func(u *unstructured.Unstructured) error {
+		annotations := u.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if value == nil {
+			delete(annotations, c.apiKey(key))
+		} else {
+			annotations[c.apiKey(key)] = *value
+		}
+		u.SetAnnotations(annotations)
+		return nil
+	})
+}
+
+// setReplicas sets spec.replicas of the scalable resource by patching
+// its scale subresource, which only needs RBAC rules on the scale
+// subresource and leaves the rest of the spec to the other
+// controllers updating it.
+func (c *machineController) setReplicas(resource schema.GroupVersionResource, namespace, name string, nreplicas int32) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": nreplicas,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.dynamicclient.Resource(resource).Namespace(namespace).Patch(name, types.MergePatchType, patch, metav1.PatchOptions{}, "scale")
+	return err
+}
@@ -0,0 +1,450 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+// fakeDynamicClient is an in-memory dynamic.Interface for the machine
+// resources. Objects are only stored, there is no validation.
+type fakeDynamicClient struct {
+	mu       sync.Mutex
+	objects  map[schema.GroupVersionResource]map[string]*unstructured.Unstructured
+	watchers map[schema.GroupVersionResource]*watch.Broadcaster
+	// patchError, if set, is returned by patches instead of
+	// applying them.
+	patchError error
+	patches    int
+}
+
+var _ dynamic.Interface = (*fakeDynamicClient)(nil)
+
+// newFakeDynamicClient returns a fakeDynamicClient holding the
+// machine.openshift.io objects as objects of resources. Their
+// TypeMeta is kept as is. Unstructured objects are MachinePools.
+func newFakeDynamicClient(resources machineAPIResources, objects ...runtime.Object) (*fakeDynamicClient, error) {
+	c := &fakeDynamicClient{
+		objects:  map[schema.GroupVersionResource]map[string]*unstructured.Unstructured{},
+		watchers: map[schema.GroupVersionResource]*watch.Broadcaster{},
+	}
+	for _, obj := range objects {
+		var resource schema.GroupVersionResource
+		switch obj := obj.(type) {
+		case *v1beta1.Machine:
+			resource = resources.machines
+		case *v1beta1.MachineSet:
+			resource = resources.machineSets
+		case *v1beta1.MachineDeployment:
+			resource = resources.machineDeployments
+		case *unstructured.Unstructured:
+			u := obj.DeepCopy()
+			c.store(resources.machinePools)[path.Join(u.GetNamespace(), u.GetName())] = u
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected type %T", obj)
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		u := &unstructured.Unstructured{Object: content}
+		toAPIKeys(resource.Group, u)
+		c.store(resource)[path.Join(u.GetNamespace(), u.GetName())] = u
+	}
+	return c, nil
+}
+
+func (c *fakeDynamicClient) store(resource schema.GroupVersionResource) map[string]*unstructured.Unstructured {
+	if _, found := c.objects[resource]; !found {
+		c.objects[resource] = map[string]*unstructured.Unstructured{}
+		c.watchers[resource] = watch.NewBroadcaster(100, watch.DropIfChannelFull)
+	}
+	return c.objects[resource]
+}
+
+func (c *fakeDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeDynamicResource{client: c, resource: resource}
+}
+
+type fakeDynamicResource struct {
+	client    *fakeDynamicClient
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+var errNotImplemented = errors.New("not implemented")
+
+func (r *fakeDynamicResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &fakeDynamicResource{client: r.client, resource: r.resource, namespace: namespace}
+}
+
+func (r *fakeDynamicResource) Create(obj *unstructured.Unstructured, options v1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, errNotImplemented
+	}
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	key := path.Join(r.namespace, obj.GetName())
+	store := r.client.store(r.resource)
+	if _, found := store[key]; found {
+		return nil, apierrors.NewAlreadyExists(r.resource.GroupResource(), obj.GetName())
+	}
+	created := obj.DeepCopy()
+	created.SetNamespace(r.namespace)
+	created.SetUID(types.UID(key))
+	store[key] = created
+	r.client.watchers[r.resource].Action(watch.Added, created.DeepCopy())
+	return created.DeepCopy(), nil
+}
+
+func (r *fakeDynamicResource) Update(obj *unstructured.Unstructured, options v1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	key := path.Join(r.namespace, obj.GetName())
+	store := r.client.store(r.resource)
+	if _, found := store[key]; !found {
+		return nil, apierrors.NewNotFound(r.resource.GroupResource(), obj.GetName())
+	}
+	store[key] = obj.DeepCopy()
+	r.client.watchers[r.resource].Action(watch.Modified, obj.DeepCopy())
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeDynamicResource) UpdateStatus(obj *unstructured.Unstructured, options v1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return nil, errNotImplemented
+}
+
+func (r *fakeDynamicResource) Delete(name string, options *v1.DeleteOptions, subresources ...string) error {
+	if len(subresources) > 0 {
+		return errNotImplemented
+	}
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	key := path.Join(r.namespace, name)
+	store := r.client.store(r.resource)
+	deleted, found := store[key]
+	if !found {
+		return apierrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	delete(store, key)
+	r.client.watchers[r.resource].Action(watch.Deleted, deleted.DeepCopy())
+	return nil
+}
+
+func (r *fakeDynamicResource) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return errNotImplemented
+}
+
+func (r *fakeDynamicResource) Get(name string, options v1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	u, found := r.client.store(r.resource)[path.Join(r.namespace, name)]
+	if !found {
+		return nil, apierrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	return u.DeepCopy(), nil
+}
+
+func isScaleSubresource(subresources []string) bool {
+	return len(subresources) == 1 && subresources[0] == "scale"
+}
+
+func (r *fakeDynamicResource) List(opts v1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	list := &unstructured.UnstructuredList{}
+	for _, u := range r.client.store(r.resource) {
+		if r.namespace == "" || u.GetNamespace() == r.namespace {
+			list.Items = append(list.Items, *u.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeDynamicResource) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	r.client.store(r.resource)
+	w := r.client.watchers[r.resource].Watch()
+	if r.namespace == "" {
+		return w, nil
+	}
+	return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+		u, ok := event.Object.(*unstructured.Unstructured)
+		return event, !ok || u.GetNamespace() == r.namespace
+	}), nil
+}
+
+// Patch only supports merge patches of the replicas of the scale
+// subresource.
+func (r *fakeDynamicResource) Patch(name string, pt types.PatchType, data []byte, options v1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if pt != types.MergePatchType || !isScaleSubresource(subresources) {
+		return nil, errNotImplemented
+	}
+	var patch struct {
+		Spec struct {
+			Replicas *int64 `json:"replicas"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	if patch.Spec.Replicas == nil {
+		return nil, fmt.Errorf("unexpected patch %s", data)
+	}
+
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	r.client.patches++
+	if r.client.patchError != nil {
+		return nil, r.client.patchError
+	}
+	key := path.Join(r.namespace, name)
+	store := r.client.store(r.resource)
+	stored, found := store[key]
+	if !found {
+		return nil, apierrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	stored = stored.DeepCopy()
+	if err := unstructured.SetNestedField(stored.Object, *patch.Spec.Replicas, "spec", "replicas"); err != nil {
+		return nil, err
+	}
+	store[key] = stored
+	r.client.watchers[r.resource].Action(watch.Modified, stored.DeepCopy())
+	return stored.DeepCopy(), nil
+}
+
+func getMachineAPIObject(controller *machineController, resource schema.GroupVersionResource, namespace, name string, obj runtime.Object) error {
+	u, err := controller.dynamicclient.Resource(resource).Namespace(namespace).Get(name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), obj)
+}
+
+func getMachine(controller *machineController, namespace, name string) (*v1beta1.Machine, error) {
+	machine := &v1beta1.Machine{}
+	return machine, getMachineAPIObject(controller, controller.resources.machines, namespace, name, machine)
+}
+
+func getMachineSet(controller *machineController, namespace, name string) (*v1beta1.MachineSet, error) {
+	machineSet := &v1beta1.MachineSet{}
+	return machineSet, getMachineAPIObject(controller, controller.resources.machineSets, namespace, name, machineSet)
+}
+
+func getMachineDeployment(controller *machineController, namespace, name string) (*v1beta1.MachineDeployment, error) {
+	machineDeployment := &v1beta1.MachineDeployment{}
+	return machineDeployment, getMachineAPIObject(controller, controller.resources.machineDeployments, namespace, name, machineDeployment)
+}
+
+func TestDiscoverMachineAPIResources(t *testing.T) {
+	for _, tc := range []struct {
+		description          string
+		groupVersions        []string
+		machinePools         string
+		expected             schema.GroupVersion
+		expectedMachinePools schema.GroupVersionResource
+		expectedErr          error
+	}{{
+		description:   "machine.openshift.io",
+		groupVersions: []string{"v1", "machine.openshift.io/v1beta1"},
+		expected:      schema.GroupVersion{Group: machineAPIGroup, Version: "v1beta1"},
+	}, {
+		description:   "cluster.x-k8s.io",
+		groupVersions: []string{"v1", "cluster.x-k8s.io/v1alpha2"},
+		expected:      schema.GroupVersion{Group: clusterAPIGroup, Version: "v1alpha2"},
+	}, {
+		description:   "machine.openshift.io is preferred",
+		groupVersions: []string{"cluster.x-k8s.io/v1alpha2", "machine.openshift.io/v1beta1"},
+		expected:      schema.GroupVersion{Group: machineAPIGroup, Version: "v1beta1"},
+	}, {
+		description:          "cluster.x-k8s.io with machine pools",
+		machinePools:         "cluster.x-k8s.io/v1alpha3",
+		expected:             schema.GroupVersion{Group: clusterAPIGroup, Version: "v1alpha3"},
+		expectedMachinePools: schema.GroupVersionResource{Group: clusterAPIGroup, Version: "v1alpha3", Resource: "machinepools"},
+	}, {
+		description:          "machine.openshift.io with experimental machine pools",
+		groupVersions:        []string{"machine.openshift.io/v1beta1"},
+		machinePools:         "exp.cluster.x-k8s.io/v1alpha3",
+		expected:             schema.GroupVersion{Group: machineAPIGroup, Version: "v1beta1"},
+		expectedMachinePools: schema.GroupVersionResource{Group: "exp.cluster.x-k8s.io", Version: "v1alpha3", Resource: "machinepools"},
+	}, {
+		description:   "no machine API",
+		groupVersions: []string{"v1"},
+		expectedErr:   errMachineAPINotFound,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			discovery := fakekube.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			for _, groupVersion := range tc.groupVersions {
+				discovery.Resources = append(discovery.Resources, &v1.APIResourceList{GroupVersion: groupVersion})
+			}
+			if tc.machinePools != "" {
+				discovery.Resources = append(discovery.Resources, &v1.APIResourceList{
+					GroupVersion: tc.machinePools,
+					APIResources: []v1.APIResource{{Name: "machinepools", Namespaced: true, Kind: "MachinePool"}},
+				})
+			}
+
+			resources, err := discoverMachineAPIResources(discovery)
+			if errors.Cause(err) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			expected := newMachineAPIResources(tc.expected)
+			expected.machinePools = tc.expectedMachinePools
+			if resources != expected {
+				t.Errorf("expected %+v, got %+v", expected, resources)
+			}
+		})
+	}
+}
+
+func TestControllerWithClusterAPI(t *testing.T) {
+	testConfig := createMachineSetTestConfig(testNamespace, 2, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	resources := newMachineAPIResources(schema.GroupVersion{Group: clusterAPIGroup, Version: "v1alpha2"})
+	dynamicclient, err := newFakeDynamicClient(resources, testConfig.machineSet, testConfig.machines[0], testConfig.machines[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Fields unknown to the machine.openshift.io types must be
+	// kept on updates.
+	machineSets := dynamicclient.objects[resources.machineSets]
+	key := path.Join(testConfig.machineSet.Namespace, testConfig.machineSet.Name)
+	if err := unstructured.SetNestedField(machineSets[key].Object, "v1.15.0", "spec", "template", "spec", "version"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The nodes have the cluster.x-k8s.io keys too.
+	for _, node := range testConfig.nodes {
+		node.Annotations = translateKeys(node.Annotations, func(key string) string { return apiKey(clusterAPIGroup, key) })
+		node.Labels = translateKeys(node.Labels, func(key string) string { return apiKey(clusterAPIGroup, key) })
+	}
+
+	kubeclient := fakekube.NewSimpleClientset(testConfig.nodes[0], testConfig.nodes[1])
+	controller, err := newMachineController(kubeclient, dynamicclient, resources, nil, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := controller.run(stopCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ng, err := controller.nodeGroupForNode(testConfig.nodes[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ng == nil {
+		t.Fatal("expected a nodegroup")
+	}
+	if err := ng.DeleteNodes(testConfig.nodes[1:]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	machine, err := getMachine(controller, testConfig.machines[1].Namespace, testConfig.machines[1].Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := machine.Annotations["cluster.x-k8s.io/delete-machine"]; !found {
+		t.Errorf("expected annotation %q on machine %s, got %v", "cluster.x-k8s.io/delete-machine", machine.Name, machine.Annotations)
+	}
+	if _, found := machine.Annotations[machineDeleteAnnotationKey]; found {
+		t.Errorf("unexpected annotation %q on machine %s", machineDeleteAnnotationKey, machine.Name)
+	}
+
+	u, err := dynamicclient.Resource(resources.machineSets).Namespace(testConfig.machineSet.Namespace).Get(testConfig.machineSet.Name, v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version, _, _ := unstructured.NestedString(u.Object, "spec", "template", "spec", "version"); version != "v1.15.0" {
+		t.Errorf("expected spec.template.spec.version to be kept, got %q", version)
+	}
+	machineSet, err := getMachineSet(controller, testConfig.machineSet.Namespace, testConfig.machineSet.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := pointer.Int32PtrDerefOr(machineSet.Spec.Replicas, 0); actual != 1 {
+		t.Errorf("expected 1 replica, got %d", actual)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	for _, tc := range []struct {
+		group    string
+		key      string
+		expected string
+	}{{
+		group:    machineAPIGroup,
+		key:      nodeGroupMinSizeAnnotationKey,
+		expected: nodeGroupMinSizeAnnotationKey,
+	}, {
+		group:    clusterAPIGroup,
+		key:      nodeGroupMinSizeAnnotationKey,
+		expected: "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size",
+	}, {
+		group:    clusterAPIGroup,
+		key:      machineAnnotationKey,
+		expected: "cluster.x-k8s.io/machine",
+	}, {
+		group:    clusterAPIGroup,
+		key:      machineDeleteAnnotationKey,
+		expected: "cluster.x-k8s.io/delete-machine",
+	}, {
+		group:    clusterAPIGroup,
+		key:      machineSetLabelKey,
+		expected: "cluster.x-k8s.io/set-name",
+	}, {
+		group:    clusterAPIGroup,
+		key:      "example.com/label",
+		expected: "example.com/label",
+	}} {
+		t.Run(tc.group+" "+tc.key, func(t *testing.T) {
+			if actual := apiKey(tc.group, tc.key); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+			if actual := internalKey(tc.group, tc.expected); actual != tc.key {
+				t.Errorf("expected %q, got %q", tc.key, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"path"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// scaledUpGroupReason is the reason of the events emitted on
+	// the scalable resources whose replica count was increased.
+	scaledUpGroupReason = "ScaledUpGroup"
+	// scaledDownGroupReason is the reason of the events emitted on
+	// the scalable resources whose replica count was decreased.
+	scaledDownGroupReason = "ScaledDownGroup"
+	// updateRejectedReason is the reason of the warning events
+	// emitted on the scalable resources whose replica count change
+	// was rejected by an admission webhook.
+	updateRejectedReason = "UpdateRejected"
+)
+
+// recordResize emits an event on the scalable resource of the given
+// kind and metadata telling that its replica count was changed from
+// replicas to nreplicas, or err was returned changing it, so that the
+// resizes of the autoscaler can be audited on the scalable resources
+// themselves. Changes rejected by an admission webhook also back the
+// node group off, with a warning event holding the message of the
+// webhook; other errors are not recorded.
+func (c *machineController) recordResize(resource schema.GroupVersionResource, kind string, meta metav1.ObjectMeta, replicas, nreplicas int32, err error) {
+	if nreplicas == replicas {
+		return
+	}
+	ref := &corev1.ObjectReference{
+		APIVersion: resource.GroupVersion().String(),
+		Kind:       kind,
+		Namespace:  meta.Namespace,
+		Name:       meta.Name,
+		UID:        meta.UID,
+	}
+	id := path.Join(meta.Namespace, meta.Name)
+	scaleUp := nreplicas > replicas
+
+	if err != nil {
+		if message, rejected := admissionRejection(err); rejected {
+			c.updateRejections.rejected(id, scaleUp, message, time.Now())
+			c.recorder.Eventf(ref, corev1.EventTypeWarning, updateRejectedReason, "Setting replicas from %d to %d was rejected: %s", replicas, nreplicas, message)
+		}
+		return
+	}
+
+	c.updateRejections.updated(id, scaleUp)
+	reason := scaledUpGroupReason
+	if !scaleUp {
+		reason = scaledDownGroupReason
+	}
+	c.recorder.Eventf(ref, corev1.EventTypeNormal, reason, "Setting replicas from %d to %d", replicas, nreplicas)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestNodeGroupResizeEvents(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+		recorder := record.NewFakeRecorder(10)
+		controller.recorder = recorder
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		if err := nodegroups[0].IncreaseSize(2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(recorder.Events); l != 1 {
+			t.Fatalf("expected 1 event, got %d", l)
+		}
+		if event, expected := <-recorder.Events, "Normal ScaledUpGroup Setting replicas from 3 to 5"; event != expected {
+			t.Errorf("expected event %q, got %q", expected, event)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 3, annotations))
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 3, annotations))
+	})
+}
+
+func TestRecordResize(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	controller := &machineController{recorder: recorder, updateRejections: newUpdateRejections()}
+	resources := newMachineAPIResources(v1beta1.SchemeGroupVersion)
+	meta := metav1.ObjectMeta{Namespace: testNamespace, Name: "machineset-0"}
+
+	controller.recordResize(resources.machineSets, "MachineSet", meta, 4, 3, nil)
+	if event, expected := <-recorder.Events, "Normal ScaledDownGroup Setting replicas from 4 to 3"; event != expected {
+		t.Errorf("expected event %q, got %q", expected, event)
+	}
+
+	controller.recordResize(resources.machineSets, "MachineSet", meta, 3, 3, nil)
+	if l := len(recorder.Events); l != 0 {
+		t.Errorf("expected no event, got %d", l)
+	}
+
+	controller.recordResize(resources.machineSets, "MachineSet", meta, 3, 4, newWebhookDenial("maximum replicas is 3"))
+	if event, expected := <-recorder.Events, `Warning UpdateRejected Setting replicas from 3 to 4 was rejected: admission webhook "autoscaling.openshift.io" denied the request: maximum replicas is 3`; event != expected {
+		t.Errorf("expected event %q, got %q", expected, event)
+	}
+
+	controller.recordResize(resources.machineSets, "MachineSet", meta, 3, 4, errors.New("connection refused"))
+	if l := len(recorder.Events); l != 0 {
+		t.Errorf("expected no event, got %d", l)
+	}
+}
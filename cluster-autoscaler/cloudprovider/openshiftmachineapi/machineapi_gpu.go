@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+)
+
+const (
+	// gpuLabelKey is the label carrying the type of the GPUs of
+	// a node. Nodes of GPU node groups are expected to get it
+	// from the labels of their machine spec, so that the
+	// autoscaler can tell them apart from nodes whose GPUs are
+	// not ready yet.
+	gpuLabelKey = machineAPIGroup + "/accelerator"
+
+	// gpuTypeAnnotationKey sets the type of the GPUs of the
+	// machines of a MachineSet or MachineDeployment, when it
+	// cannot be derived from their providerSpec.
+	gpuTypeAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-gpu-type"
+
+	// defaultGPUType is the type of the GPUs of template nodes
+	// whose GPU type is not known.
+	defaultGPUType = "nvidia-tesla"
+)
+
+// availableGPUTypes are the GPU types the autoscaler reports
+// metrics and applies resource limits for.
+var availableGPUTypes = map[string]struct{}{
+	defaultGPUType:      {},
+	"nvidia-tesla-k80":  {},
+	"nvidia-tesla-m60":  {},
+	"nvidia-tesla-p4":   {},
+	"nvidia-tesla-p40":  {},
+	"nvidia-tesla-p100": {},
+	"nvidia-tesla-v100": {},
+	"nvidia-tesla-t4":   {},
+	"nvidia-tesla-a100": {},
+}
+
+// awsGPUTypes maps the EC2 instance families with GPUs to the type of
+// their GPUs.
+var awsGPUTypes = map[string]string{
+	"p2":   "nvidia-tesla-k80",
+	"p3":   "nvidia-tesla-v100",
+	"p3dn": "nvidia-tesla-v100",
+	"p4d":  "nvidia-tesla-a100",
+	"g3":   "nvidia-tesla-m60",
+	"g3s":  "nvidia-tesla-m60",
+	"g4dn": "nvidia-tesla-t4",
+}
+
+// awsGPUType returns the type of the GPUs of an EC2 instance type, or
+// "" if it has none or it is not known.
+func awsGPUType(instanceType string) string {
+	family := strings.SplitN(instanceType, ".", 2)[0]
+	return awsGPUTypes[family]
+}
+
+// azureGPUType returns the type of the GPUs of an Azure VM size, or ""
+// if it has none or it is not known.
+func azureGPUType(vmSize string) string {
+	size := strings.TrimPrefix(vmSize, "Standard_")
+	if size == vmSize {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(size, "NC") && strings.HasSuffix(size, "T4_v3"):
+		return "nvidia-tesla-t4"
+	case strings.HasPrefix(size, "NC") && strings.HasSuffix(size, "_v3"):
+		return "nvidia-tesla-v100"
+	case strings.HasPrefix(size, "NC") && strings.HasSuffix(size, "_v2"):
+		return "nvidia-tesla-p100"
+	case strings.HasPrefix(size, "NC"):
+		return "nvidia-tesla-k80"
+	case strings.HasPrefix(size, "ND") && strings.HasSuffix(size, "_v2"):
+		return "nvidia-tesla-v100"
+	case strings.HasPrefix(size, "ND"):
+		return "nvidia-tesla-p40"
+	case strings.HasPrefix(size, "NV") && strings.HasSuffix(size, "_v4"):
+		return ""
+	case strings.HasPrefix(size, "NV"):
+		return "nvidia-tesla-m60"
+	}
+	return ""
+}
+
+// templateGPUType returns the type of the GPUs of the template nodes
+// of a node group: that of the GPU type annotation, else that of the
+// instance type of template, else defaultGPUType.
+func templateGPUType(annotations map[string]string, template *machineTemplate) string {
+	if gpuType := annotations[gpuTypeAnnotationKey]; gpuType != "" {
+		return gpuType
+	}
+	if template != nil && template.gpuType != "" {
+		return template.gpuType
+	}
+	return defaultGPUType
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+)
+
+func TestGPUTypeOfInstanceType(t *testing.T) {
+	for _, tc := range []struct {
+		description  string
+		gpuType      func(string) string
+		instanceType string
+		expected     string
+	}{
+		{"AWS p2", awsGPUType, "p2.xlarge", "nvidia-tesla-k80"},
+		{"AWS p3dn", awsGPUType, "p3dn.24xlarge", "nvidia-tesla-v100"},
+		{"AWS g4dn", awsGPUType, "g4dn.xlarge", "nvidia-tesla-t4"},
+		{"AWS without GPUs", awsGPUType, "m5.large", ""},
+		{"Azure NC", azureGPUType, "Standard_NC6", "nvidia-tesla-k80"},
+		{"Azure NCv2", azureGPUType, "Standard_NC6s_v2", "nvidia-tesla-p100"},
+		{"Azure NCv3", azureGPUType, "Standard_NC6s_v3", "nvidia-tesla-v100"},
+		{"Azure NC T4", azureGPUType, "Standard_NC4as_T4_v3", "nvidia-tesla-t4"},
+		{"Azure ND", azureGPUType, "Standard_ND6s", "nvidia-tesla-p40"},
+		{"Azure NV", azureGPUType, "Standard_NV6", "nvidia-tesla-m60"},
+		{"Azure without GPUs", azureGPUType, "Standard_D4s_v3", ""},
+		{"Azure without prefix", azureGPUType, "NC6", ""},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			if actual := tc.gpuType(tc.instanceType); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+			if tc.expected == "" {
+				return
+			}
+			if _, found := availableGPUTypes[tc.expected]; !found {
+				t.Errorf("expected %q to be an available GPU type", tc.expected)
+			}
+		})
+	}
+}
+
+func TestNodeGroupTemplateNodeInfoGPULabel(t *testing.T) {
+	for _, tc := range []struct {
+		description  string
+		annotations  map[string]string
+		providerSpec string
+		labels       map[string]string
+		expected     string
+	}{{
+		description: "GPU type annotation",
+		annotations: map[string]string{
+			gpuCapacityAnnotationKey: "1",
+			gpuTypeAnnotationKey:     "nvidia-tesla-t4",
+		},
+		expected: "nvidia-tesla-t4",
+	}, {
+		description: "GPU type from the providerSpec",
+		annotations: map[string]string{
+			gpuCapacityAnnotationKey: "1",
+		},
+		providerSpec: `{"kind": "AWSMachineProviderConfig", "instanceType": "p3.2xlarge"}`,
+		expected:     "nvidia-tesla-v100",
+	}, {
+		description: "GPU type annotation takes precedence over the providerSpec",
+		annotations: map[string]string{
+			gpuCapacityAnnotationKey: "1",
+			gpuTypeAnnotationKey:     "nvidia-tesla-p100",
+		},
+		providerSpec: `{"kind": "AWSMachineProviderConfig", "instanceType": "p3.2xlarge"}`,
+		expected:     "nvidia-tesla-p100",
+	}, {
+		description: "unknown GPU type",
+		annotations: map[string]string{
+			gpuCapacityAnnotationKey: "2",
+		},
+		expected: defaultGPUType,
+	}, {
+		description: "machine spec label takes precedence",
+		annotations: map[string]string{
+			gpuCapacityAnnotationKey: "1",
+			gpuTypeAnnotationKey:     "nvidia-tesla-t4",
+		},
+		labels:   map[string]string{gpuLabelKey: "nvidia-tesla-a100"},
+		expected: "nvidia-tesla-a100",
+	}, {
+		description: "no GPUs",
+		annotations: map[string]string{
+			gpuTypeAnnotationKey: "nvidia-tesla-t4",
+		},
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			annotations := map[string]string{
+				nodeGroupMinSizeAnnotationKey: "0",
+				nodeGroupMaxSizeAnnotationKey: "10",
+				cpuCapacityAnnotationKey:      "8",
+				memoryCapacityAnnotationKey:   "32Gi",
+			}
+			for k, v := range tc.annotations {
+				annotations[k] = v
+			}
+			testConfig := createMachineSetTestConfig(testNamespace, 0, annotations)
+			spec := &testConfig.machineSet.Spec.Template.Spec
+			spec.Labels = tc.labels
+			if tc.providerSpec != "" {
+				spec.ProviderSpec = machineSpecWithProviderSpec(tc.providerSpec).ProviderSpec
+			}
+
+			controller, stop := mustCreateTestController(t, testConfig)
+			defer stop()
+
+			nodegroups, err := controller.nodeGroups()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if l := len(nodegroups); l != 1 {
+				t.Fatalf("expected 1 nodegroup, got %d", l)
+			}
+
+			nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node := nodeInfo.Node()
+			actual, found := node.Labels[gpuLabelKey]
+			if tc.expected == "" {
+				if found {
+					t.Errorf("expected no GPU label, got %q", actual)
+				}
+				return
+			}
+			if actual != tc.expected {
+				t.Errorf("expected GPU label %q, got %q", tc.expected, actual)
+			}
+			gpuType, gpuCount, err := gpu.GetNodeTargetGpus(gpuLabelKey, node, nodegroups[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gpuType != tc.expected || gpuCount == 0 {
+				t.Errorf("expected %q GPUs, got %d %q GPUs", tc.expected, gpuCount, gpuType)
+			}
+		})
+	}
+}
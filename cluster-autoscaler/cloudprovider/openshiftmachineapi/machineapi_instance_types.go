@@ -0,0 +1,429 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+// instanceType is the capacity of an instance type.
+type instanceType struct {
+	VCPU     int64
+	MemoryMb int64
+	GPU      int64
+}
+
+// awsInstanceTypes are the EC2 instance types, copied from
+// cloudprovider/aws/ec2_instance_types.go to not depend on the AWS
+// cloud provider and its SDK. Keep them in sync when regenerating it.
+var awsInstanceTypes = map[string]instanceType{
+	"a1":            {VCPU: 16, MemoryMb: 0, GPU: 0},
+	"a1.2xlarge":    {VCPU: 8, MemoryMb: 16384, GPU: 0},
+	"a1.4xlarge":    {VCPU: 16, MemoryMb: 32768, GPU: 0},
+	"a1.large":      {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"a1.medium":     {VCPU: 1, MemoryMb: 2048, GPU: 0},
+	"a1.xlarge":     {VCPU: 4, MemoryMb: 8192, GPU: 0},
+	"c1.medium":     {VCPU: 2, MemoryMb: 1740, GPU: 0},
+	"c1.xlarge":     {VCPU: 8, MemoryMb: 7168, GPU: 0},
+	"c3":            {VCPU: 32, MemoryMb: 0, GPU: 0},
+	"c3.2xlarge":    {VCPU: 8, MemoryMb: 15360, GPU: 0},
+	"c3.4xlarge":    {VCPU: 16, MemoryMb: 30720, GPU: 0},
+	"c3.8xlarge":    {VCPU: 32, MemoryMb: 61440, GPU: 0},
+	"c3.large":      {VCPU: 2, MemoryMb: 3840, GPU: 0},
+	"c3.xlarge":     {VCPU: 4, MemoryMb: 7680, GPU: 0},
+	"c4":            {VCPU: 36, MemoryMb: 0, GPU: 0},
+	"c4.2xlarge":    {VCPU: 8, MemoryMb: 15360, GPU: 0},
+	"c4.4xlarge":    {VCPU: 16, MemoryMb: 30720, GPU: 0},
+	"c4.8xlarge":    {VCPU: 36, MemoryMb: 61440, GPU: 0},
+	"c4.large":      {VCPU: 2, MemoryMb: 3840, GPU: 0},
+	"c4.xlarge":     {VCPU: 4, MemoryMb: 7680, GPU: 0},
+	"c5":            {VCPU: 72, MemoryMb: 0, GPU: 0},
+	"c5.18xlarge":   {VCPU: 72, MemoryMb: 147456, GPU: 0},
+	"c5.2xlarge":    {VCPU: 8, MemoryMb: 16384, GPU: 0},
+	"c5.4xlarge":    {VCPU: 16, MemoryMb: 32768, GPU: 0},
+	"c5.9xlarge":    {VCPU: 36, MemoryMb: 73728, GPU: 0},
+	"c5.large":      {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"c5.xlarge":     {VCPU: 4, MemoryMb: 8192, GPU: 0},
+	"c5d":           {VCPU: 72, MemoryMb: 0, GPU: 0},
+	"c5d.18xlarge":  {VCPU: 72, MemoryMb: 147456, GPU: 0},
+	"c5d.2xlarge":   {VCPU: 8, MemoryMb: 16384, GPU: 0},
+	"c5d.4xlarge":   {VCPU: 16, MemoryMb: 32768, GPU: 0},
+	"c5d.9xlarge":   {VCPU: 36, MemoryMb: 73728, GPU: 0},
+	"c5d.large":     {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"c5d.xlarge":    {VCPU: 4, MemoryMb: 8192, GPU: 0},
+	"c5n":           {VCPU: 72, MemoryMb: 0, GPU: 0},
+	"c5n.18xlarge":  {VCPU: 72, MemoryMb: 196608, GPU: 0},
+	"c5n.2xlarge":   {VCPU: 8, MemoryMb: 21504, GPU: 0},
+	"c5n.4xlarge":   {VCPU: 16, MemoryMb: 43008, GPU: 0},
+	"c5n.9xlarge":   {VCPU: 36, MemoryMb: 98304, GPU: 0},
+	"c5n.large":     {VCPU: 2, MemoryMb: 5376, GPU: 0},
+	"c5n.xlarge":    {VCPU: 4, MemoryMb: 10752, GPU: 0},
+	"cc2.8xlarge":   {VCPU: 32, MemoryMb: 61952, GPU: 0},
+	"cr1.8xlarge":   {VCPU: 32, MemoryMb: 249856, GPU: 0},
+	"d2":            {VCPU: 36, MemoryMb: 0, GPU: 0},
+	"d2.2xlarge":    {VCPU: 8, MemoryMb: 62464, GPU: 0},
+	"d2.4xlarge":    {VCPU: 16, MemoryMb: 124928, GPU: 0},
+	"d2.8xlarge":    {VCPU: 36, MemoryMb: 249856, GPU: 0},
+	"d2.xlarge":     {VCPU: 4, MemoryMb: 31232, GPU: 0},
+	"f1":            {VCPU: 64, MemoryMb: 0, GPU: 0},
+	"f1.16xlarge":   {VCPU: 64, MemoryMb: 999424, GPU: 0},
+	"f1.2xlarge":    {VCPU: 8, MemoryMb: 124928, GPU: 0},
+	"f1.4xlarge":    {VCPU: 16, MemoryMb: 249856, GPU: 0},
+	"g2":            {VCPU: 32, MemoryMb: 0, GPU: 4},
+	"g2.2xlarge":    {VCPU: 8, MemoryMb: 15360, GPU: 1},
+	"g2.8xlarge":    {VCPU: 32, MemoryMb: 61440, GPU: 4},
+	"g3":            {VCPU: 64, MemoryMb: 0, GPU: 4},
+	"g3.16xlarge":   {VCPU: 64, MemoryMb: 499712, GPU: 4},
+	"g3.4xlarge":    {VCPU: 16, MemoryMb: 124928, GPU: 1},
+	"g3.8xlarge":    {VCPU: 32, MemoryMb: 249856, GPU: 2},
+	"g3s.xlarge":    {VCPU: 4, MemoryMb: 31232, GPU: 1},
+	"h1":            {VCPU: 64, MemoryMb: 0, GPU: 0},
+	"h1.16xlarge":   {VCPU: 64, MemoryMb: 262144, GPU: 0},
+	"h1.2xlarge":    {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"h1.4xlarge":    {VCPU: 16, MemoryMb: 65536, GPU: 0},
+	"h1.8xlarge":    {VCPU: 32, MemoryMb: 131072, GPU: 0},
+	"hs1.8xlarge":   {VCPU: 17, MemoryMb: 119808, GPU: 0},
+	"i2":            {VCPU: 32, MemoryMb: 0, GPU: 0},
+	"i2.2xlarge":    {VCPU: 8, MemoryMb: 62464, GPU: 0},
+	"i2.4xlarge":    {VCPU: 16, MemoryMb: 124928, GPU: 0},
+	"i2.8xlarge":    {VCPU: 32, MemoryMb: 249856, GPU: 0},
+	"i2.xlarge":     {VCPU: 4, MemoryMb: 31232, GPU: 0},
+	"i3":            {VCPU: 64, MemoryMb: 0, GPU: 0},
+	"i3.16xlarge":   {VCPU: 64, MemoryMb: 499712, GPU: 0},
+	"i3.2xlarge":    {VCPU: 8, MemoryMb: 62464, GPU: 0},
+	"i3.4xlarge":    {VCPU: 16, MemoryMb: 124928, GPU: 0},
+	"i3.8xlarge":    {VCPU: 32, MemoryMb: 249856, GPU: 0},
+	"i3.large":      {VCPU: 2, MemoryMb: 15616, GPU: 0},
+	"i3.metal":      {VCPU: 72, MemoryMb: 524288, GPU: 0},
+	"i3.xlarge":     {VCPU: 4, MemoryMb: 31232, GPU: 0},
+	"m1.large":      {VCPU: 2, MemoryMb: 7680, GPU: 0},
+	"m1.medium":     {VCPU: 1, MemoryMb: 3840, GPU: 0},
+	"m1.small":      {VCPU: 1, MemoryMb: 1740, GPU: 0},
+	"m1.xlarge":     {VCPU: 4, MemoryMb: 15360, GPU: 0},
+	"m2.2xlarge":    {VCPU: 4, MemoryMb: 35020, GPU: 0},
+	"m2.4xlarge":    {VCPU: 8, MemoryMb: 70041, GPU: 0},
+	"m2.xlarge":     {VCPU: 2, MemoryMb: 17510, GPU: 0},
+	"m3":            {VCPU: 8, MemoryMb: 0, GPU: 0},
+	"m3.2xlarge":    {VCPU: 8, MemoryMb: 30720, GPU: 0},
+	"m3.large":      {VCPU: 2, MemoryMb: 7680, GPU: 0},
+	"m3.medium":     {VCPU: 1, MemoryMb: 3840, GPU: 0},
+	"m3.xlarge":     {VCPU: 4, MemoryMb: 15360, GPU: 0},
+	"m4":            {VCPU: 40, MemoryMb: 0, GPU: 0},
+	"m4.10xlarge":   {VCPU: 40, MemoryMb: 163840, GPU: 0},
+	"m4.16xlarge":   {VCPU: 64, MemoryMb: 262144, GPU: 0},
+	"m4.2xlarge":    {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"m4.4xlarge":    {VCPU: 16, MemoryMb: 65536, GPU: 0},
+	"m4.large":      {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"m4.xlarge":     {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"m5":            {VCPU: 96, MemoryMb: 0, GPU: 0},
+	"m5.12xlarge":   {VCPU: 48, MemoryMb: 196608, GPU: 0},
+	"m5.24xlarge":   {VCPU: 96, MemoryMb: 393216, GPU: 0},
+	"m5.2xlarge":    {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"m5.4xlarge":    {VCPU: 16, MemoryMb: 65536, GPU: 0},
+	"m5.large":      {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"m5.metal":      {VCPU: 96, MemoryMb: 393216, GPU: 0},
+	"m5.xlarge":     {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"m5a.12xlarge":  {VCPU: 48, MemoryMb: 196608, GPU: 0},
+	"m5a.24xlarge":  {VCPU: 96, MemoryMb: 393216, GPU: 0},
+	"m5a.2xlarge":   {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"m5a.4xlarge":   {VCPU: 16, MemoryMb: 65536, GPU: 0},
+	"m5a.large":     {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"m5a.xlarge":    {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"m5d":           {VCPU: 96, MemoryMb: 0, GPU: 0},
+	"m5d.12xlarge":  {VCPU: 48, MemoryMb: 196608, GPU: 0},
+	"m5d.24xlarge":  {VCPU: 96, MemoryMb: 393216, GPU: 0},
+	"m5d.2xlarge":   {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"m5d.4xlarge":   {VCPU: 16, MemoryMb: 65536, GPU: 0},
+	"m5d.large":     {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"m5d.metal":     {VCPU: 96, MemoryMb: 393216, GPU: 0},
+	"m5d.xlarge":    {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"p2":            {VCPU: 64, MemoryMb: 0, GPU: 16},
+	"p2.16xlarge":   {VCPU: 64, MemoryMb: 786432, GPU: 16},
+	"p2.8xlarge":    {VCPU: 32, MemoryMb: 499712, GPU: 8},
+	"p2.xlarge":     {VCPU: 4, MemoryMb: 62464, GPU: 1},
+	"p3":            {VCPU: 64, MemoryMb: 499712, GPU: 8},
+	"p3.16xlarge":   {VCPU: 64, MemoryMb: 499712, GPU: 8},
+	"p3.2xlarge":    {VCPU: 8, MemoryMb: 62464, GPU: 1},
+	"p3.8xlarge":    {VCPU: 32, MemoryMb: 249856, GPU: 4},
+	"p3dn":          {VCPU: 96, MemoryMb: 786432, GPU: 8},
+	"p3dn.24xlarge": {VCPU: 96, MemoryMb: 786432, GPU: 8},
+	"r3":            {VCPU: 32, MemoryMb: 0, GPU: 0},
+	"r3.2xlarge":    {VCPU: 8, MemoryMb: 62464, GPU: 0},
+	"r3.4xlarge":    {VCPU: 16, MemoryMb: 124928, GPU: 0},
+	"r3.8xlarge":    {VCPU: 32, MemoryMb: 249856, GPU: 0},
+	"r3.large":      {VCPU: 2, MemoryMb: 15616, GPU: 0},
+	"r3.xlarge":     {VCPU: 4, MemoryMb: 31232, GPU: 0},
+	"r4":            {VCPU: 64, MemoryMb: 0, GPU: 0},
+	"r4.16xlarge":   {VCPU: 64, MemoryMb: 499712, GPU: 0},
+	"r4.2xlarge":    {VCPU: 8, MemoryMb: 62464, GPU: 0},
+	"r4.4xlarge":    {VCPU: 16, MemoryMb: 124928, GPU: 0},
+	"r4.8xlarge":    {VCPU: 32, MemoryMb: 249856, GPU: 0},
+	"r4.large":      {VCPU: 2, MemoryMb: 15616, GPU: 0},
+	"r4.xlarge":     {VCPU: 4, MemoryMb: 31232, GPU: 0},
+	"r5":            {VCPU: 96, MemoryMb: 0, GPU: 0},
+	"r5.12xlarge":   {VCPU: 48, MemoryMb: 393216, GPU: 0},
+	"r5.24xlarge":   {VCPU: 96, MemoryMb: 786432, GPU: 0},
+	"r5.2xlarge":    {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"r5.4xlarge":    {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"r5.large":      {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"r5.metal":      {VCPU: 96, MemoryMb: 786432, GPU: 0},
+	"r5.xlarge":     {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"r5a.12xlarge":  {VCPU: 48, MemoryMb: 393216, GPU: 0},
+	"r5a.24xlarge":  {VCPU: 96, MemoryMb: 786432, GPU: 0},
+	"r5a.2xlarge":   {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"r5a.4xlarge":   {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"r5a.large":     {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"r5a.xlarge":    {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"r5d":           {VCPU: 96, MemoryMb: 0, GPU: 0},
+	"r5d.12xlarge":  {VCPU: 48, MemoryMb: 393216, GPU: 0},
+	"r5d.24xlarge":  {VCPU: 96, MemoryMb: 786432, GPU: 0},
+	"r5d.2xlarge":   {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"r5d.4xlarge":   {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"r5d.large":     {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"r5d.metal":     {VCPU: 96, MemoryMb: 786432, GPU: 0},
+	"r5d.xlarge":    {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"t1.micro":      {VCPU: 1, MemoryMb: 627, GPU: 0},
+	"t2.2xlarge":    {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"t2.large":      {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"t2.medium":     {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"t2.micro":      {VCPU: 1, MemoryMb: 1024, GPU: 0},
+	"t2.nano":       {VCPU: 1, MemoryMb: 512, GPU: 0},
+	"t2.small":      {VCPU: 1, MemoryMb: 2048, GPU: 0},
+	"t2.xlarge":     {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"t3.2xlarge":    {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"t3.large":      {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"t3.medium":     {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"t3.micro":      {VCPU: 2, MemoryMb: 1024, GPU: 0},
+	"t3.nano":       {VCPU: 2, MemoryMb: 512, GPU: 0},
+	"t3.small":      {VCPU: 2, MemoryMb: 2048, GPU: 0},
+	"t3.xlarge":     {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"u-12tb1":       {VCPU: 448, MemoryMb: 12582912, GPU: 0},
+	"u-6tb1":        {VCPU: 448, MemoryMb: 6291456, GPU: 0},
+	"u-9tb1":        {VCPU: 448, MemoryMb: 9437184, GPU: 0},
+	"x1":            {VCPU: 128, MemoryMb: 0, GPU: 0},
+	"x1.16xlarge":   {VCPU: 64, MemoryMb: 999424, GPU: 0},
+	"x1.32xlarge":   {VCPU: 128, MemoryMb: 1998848, GPU: 0},
+	"x1e":           {VCPU: 128, MemoryMb: 0, GPU: 0},
+	"x1e.16xlarge":  {VCPU: 64, MemoryMb: 1998848, GPU: 0},
+	"x1e.2xlarge":   {VCPU: 8, MemoryMb: 249856, GPU: 0},
+	"x1e.32xlarge":  {VCPU: 128, MemoryMb: 3997696, GPU: 0},
+	"x1e.4xlarge":   {VCPU: 16, MemoryMb: 499712, GPU: 0},
+	"x1e.8xlarge":   {VCPU: 32, MemoryMb: 999424, GPU: 0},
+	"x1e.xlarge":    {VCPU: 4, MemoryMb: 124928, GPU: 0},
+	"z1d":           {VCPU: 48, MemoryMb: 0, GPU: 0},
+	"z1d.12xlarge":  {VCPU: 48, MemoryMb: 393216, GPU: 0},
+	"z1d.2xlarge":   {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"z1d.3xlarge":   {VCPU: 12, MemoryMb: 98304, GPU: 0},
+	"z1d.6xlarge":   {VCPU: 24, MemoryMb: 196608, GPU: 0},
+	"z1d.large":     {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"z1d.metal":     {VCPU: 48, MemoryMb: 393216, GPU: 0},
+	"z1d.xlarge":    {VCPU: 4, MemoryMb: 32768, GPU: 0},
+}
+
+// azureInstanceTypes are the Azure VM sizes, copied from
+// cloudprovider/azure/azure_instance_types.go to not depend on the
+// Azure cloud provider and its SDK. Keep them in sync with it.
+var azureInstanceTypes = map[string]instanceType{
+	"Standard_D4s_v3":        {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"Standard_DS11_v2_Promo": {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_D8s_v3":        {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"Standard_D32_v3":        {VCPU: 32, MemoryMb: 131072, GPU: 0},
+	"Standard_G1":            {VCPU: 2, MemoryMb: 28672, GPU: 0},
+	"Standard_G2":            {VCPU: 4, MemoryMb: 57344, GPU: 0},
+	"Standard_G3":            {VCPU: 8, MemoryMb: 114688, GPU: 0},
+	"Standard_G4":            {VCPU: 16, MemoryMb: 229376, GPU: 0},
+	"Standard_G5":            {VCPU: 32, MemoryMb: 458752, GPU: 0},
+	"Standard_NV24":          {VCPU: 24, MemoryMb: 229376, GPU: 4},
+	"Standard_E4s_v3":        {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"Standard_A2_v2":         {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"Standard_E2_v3":         {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"Standard_D2s_v3":        {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"Standard_M64-16ms":      {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_D5_v2":         {VCPU: 16, MemoryMb: 57344, GPU: 0},
+	"Standard_A1_v2":         {VCPU: 1, MemoryMb: 2048, GPU: 0},
+	"Standard_A8m_v2":        {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"Standard_D11_v2_Promo":  {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_DS14-4_v2":     {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_M64ms":         {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_E8s_v3":        {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"Standard_E64i_v3":       {VCPU: 64, MemoryMb: 442368, GPU: 0},
+	"Standard_A4m_v2":        {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"Standard_ND6s":          {VCPU: 6, MemoryMb: 114688, GPU: 1},
+	"Standard_H16r":          {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_D4":            {VCPU: 8, MemoryMb: 28672, GPU: 0},
+	"Standard_DS14-8_v2":     {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_E8_v3":         {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"Standard_D1":            {VCPU: 1, MemoryMb: 3584, GPU: 0},
+	"Standard_M64s":          {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_D3":            {VCPU: 4, MemoryMb: 14336, GPU: 0},
+	"Standard_D2":            {VCPU: 2, MemoryMb: 7168, GPU: 0},
+	"Standard_B1ms":          {VCPU: 1, MemoryMb: 2048, GPU: 0},
+	"Standard_GS5-16":        {VCPU: 32, MemoryMb: 458752, GPU: 0},
+	"Standard_D2_v2_Promo":   {VCPU: 2, MemoryMb: 7168, GPU: 0},
+	"Standard_DS13_v2":       {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_H8m":           {VCPU: 8, MemoryMb: 114688, GPU: 0},
+	"Standard_E32-8s_v3":     {VCPU: 32, MemoryMb: 262144, GPU: 0},
+	"Standard_F72s_v2":       {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_F32s_v2":       {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_F2s":           {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"Standard_D14_v2":        {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_D12_v2_Promo":  {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_DS13-2_v2":     {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_D4_v2_Promo":   {VCPU: 8, MemoryMb: 28672, GPU: 0},
+	"Standard_B2ms":          {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"Standard_D3_v2_Promo":   {VCPU: 4, MemoryMb: 14336, GPU: 0},
+	"Standard_DS12_v2_Promo": {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_NC6s_v3":       {VCPU: 6, MemoryMb: 114688, GPU: 1},
+	"Standard_NC6s_v2":       {VCPU: 6, MemoryMb: 114688, GPU: 1},
+	"Standard_DS13-4_v2":     {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_NC24":          {VCPU: 24, MemoryMb: 229376, GPU: 4},
+	"Standard_D64_v3":        {VCPU: 64, MemoryMb: 262144, GPU: 0},
+	"Standard_E32s_v3":       {VCPU: 32, MemoryMb: 262144, GPU: 0},
+	"Standard_D32s_v3":       {VCPU: 32, MemoryMb: 131072, GPU: 0},
+	"Standard_D4_v3":         {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"Standard_D4_v2":         {VCPU: 8, MemoryMb: 28672, GPU: 0},
+	"Standard_D13_v2_Promo":  {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_L8s":           {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"Standard_M64-32ms":      {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_DS3_v2_Promo":  {VCPU: 4, MemoryMb: 14336, GPU: 0},
+	"Standard_ND12s":         {VCPU: 12, MemoryMb: 688128, GPU: 2},
+	"Standard_DS5_v2_Promo":  {VCPU: 16, MemoryMb: 57344, GPU: 0},
+	"Standard_D5_v2_Promo":   {VCPU: 16, MemoryMb: 57344, GPU: 0},
+	"Standard_D16_v3":        {VCPU: 16, MemoryMb: 65638, GPU: 0},
+	"Standard_E64-32s_v3":    {VCPU: 64, MemoryMb: 442368, GPU: 0},
+	"Standard_GS5-8":         {VCPU: 32, MemoryMb: 458752, GPU: 0},
+	"Standard_DS13_v2_Promo": {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_F16s_v2":       {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_DS11_v2":       {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_D11":           {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_D13":           {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_D12":           {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_NC24r":         {VCPU: 24, MemoryMb: 229376, GPU: 4},
+	"Standard_D14":           {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_GS4-4":         {VCPU: 16, MemoryMb: 229376, GPU: 0},
+	"Standard_F4s":           {VCPU: 4, MemoryMb: 8192, GPU: 0},
+	"Standard_DS14":          {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_DS13":          {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_DS12":          {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_DS11":          {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_DS14_v2_Promo": {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_NV6":           {VCPU: 6, MemoryMb: 57344, GPU: 1},
+	"Standard_D15_v2":        {VCPU: 20, MemoryMb: 143360, GPU: 0},
+	"Standard_D3_v2":         {VCPU: 4, MemoryMb: 14336, GPU: 0},
+	"Standard_L32s":          {VCPU: 32, MemoryMb: 262144, GPU: 0},
+	"Standard_NC12s_v3":      {VCPU: 12, MemoryMb: 229376, GPU: 2},
+	"Standard_NC12s_v2":      {VCPU: 12, MemoryMb: 229376, GPU: 2},
+	"Standard_A2m_v2":        {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"Standard_E32_v3":        {VCPU: 32, MemoryMb: 262144, GPU: 0},
+	"Standard_DS12_v2":       {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_D14_v2_Promo":  {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_F4":            {VCPU: 4, MemoryMb: 8192, GPU: 0},
+	"Standard_H16m":          {VCPU: 16, MemoryMb: 229376, GPU: 0},
+	"Standard_F8s":           {VCPU: 8, MemoryMb: 16384, GPU: 0},
+	"Standard_E64s_v3":       {VCPU: 64, MemoryMb: 442368, GPU: 0},
+	"Standard_DS12-1_v2":     {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_E8-4s_v3":      {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"Standard_NV12":          {VCPU: 12, MemoryMb: 114688, GPU: 2},
+	"Standard_E16-4s_v3":     {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"Standard_ND24rs":        {VCPU: 24, MemoryMb: 458752, GPU: 4},
+	"Standard_D8_v3":         {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"Standard_DS12-2_v2":     {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_B8ms":          {VCPU: 8, MemoryMb: 32768, GPU: 0},
+	"Standard_DS4_v2_Promo":  {VCPU: 8, MemoryMb: 28672, GPU: 0},
+	"Standard_DS14_v2":       {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_NC24s_v2":      {VCPU: 24, MemoryMb: 458752, GPU: 4},
+	"Standard_M128-64ms":     {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_NC24rs_v3":     {VCPU: 24, MemoryMb: 458752, GPU: 4},
+	"Standard_NC24rs_v2":     {VCPU: 24, MemoryMb: 458752, GPU: 4},
+	"Standard_E8-2s_v3":      {VCPU: 8, MemoryMb: 65536, GPU: 0},
+	"Standard_E16_v3":        {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"Standard_E4-2s_v3":      {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"Standard_NC6":           {VCPU: 6, MemoryMb: 57344, GPU: 1},
+	"Standard_D11_v2":        {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_F16s":          {VCPU: 16, MemoryMb: 32768, GPU: 0},
+	"Standard_DS11-1_v2":     {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_NC24s_v3":      {VCPU: 24, MemoryMb: 458752, GPU: 4},
+	"Standard_M128s":         {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_DS3_v2":        {VCPU: 4, MemoryMb: 14336, GPU: 0},
+	"Standard_GS5":           {VCPU: 32, MemoryMb: 458752, GPU: 0},
+	"Standard_A4_v2":         {VCPU: 4, MemoryMb: 8192, GPU: 0},
+	"Standard_GS1":           {VCPU: 2, MemoryMb: 28672, GPU: 0},
+	"Standard_GS2":           {VCPU: 4, MemoryMb: 57344, GPU: 0},
+	"Standard_GS3":           {VCPU: 8, MemoryMb: 114688, GPU: 0},
+	"Standard_D64s_v3":       {VCPU: 64, MemoryMb: 262144, GPU: 0},
+	"Standard_F64s_v2":       {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_GS4":           {VCPU: 16, MemoryMb: 229376, GPU: 0},
+	"Standard_D13_v2":        {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_NC12":          {VCPU: 12, MemoryMb: 114688, GPU: 2},
+	"Standard_D1_v2":         {VCPU: 1, MemoryMb: 3584, GPU: 0},
+	"Standard_E16-8s_v3":     {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"Standard_L16s":          {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"Standard_D2_v3":         {VCPU: 2, MemoryMb: 8192, GPU: 0},
+	"Standard_D2_v2":         {VCPU: 2, MemoryMb: 7168, GPU: 0},
+	"Standard_H16mr":         {VCPU: 16, MemoryMb: 229376, GPU: 0},
+	"Standard_A6":            {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_A7":            {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_A4":            {VCPU: 8, MemoryMb: 14336, GPU: 0},
+	"Standard_A5":            {VCPU: 2, MemoryMb: 14336, GPU: 0},
+	"Standard_A2":            {VCPU: 2, MemoryMb: 3584, GPU: 0},
+	"Standard_A3":            {VCPU: 4, MemoryMb: 7168, GPU: 0},
+	"Standard_A0":            {VCPU: 1, MemoryMb: 768, GPU: 0},
+	"Standard_A1":            {VCPU: 1, MemoryMb: 1792, GPU: 0},
+	"Standard_DS5_v2":        {VCPU: 16, MemoryMb: 57344, GPU: 0},
+	"Standard_A8":            {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_A9":            {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Basic_A4":               {VCPU: 8, MemoryMb: 14336, GPU: 0},
+	"Standard_E64-16s_v3":    {VCPU: 64, MemoryMb: 442368, GPU: 0},
+	"Basic_A2":               {VCPU: 2, MemoryMb: 3584, GPU: 0},
+	"Basic_A3":               {VCPU: 4, MemoryMb: 7168, GPU: 0},
+	"Basic_A0":               {VCPU: 1, MemoryMb: 768, GPU: 0},
+	"Basic_A1":               {VCPU: 1, MemoryMb: 1792, GPU: 0},
+	"Standard_A10":           {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_A11":           {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_E4_v3":         {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"Standard_F8s_v2":        {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_F4s_v2":        {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_ND24s":         {VCPU: 24, MemoryMb: 458752, GPU: 4},
+	"Standard_B2s":           {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"Standard_DS2_v2":        {VCPU: 2, MemoryMb: 7168, GPU: 0},
+	"Standard_F1s":           {VCPU: 1, MemoryMb: 2048, GPU: 0},
+	"Standard_E16s_v3":       {VCPU: 16, MemoryMb: 131072, GPU: 0},
+	"Standard_B4ms":          {VCPU: 4, MemoryMb: 16384, GPU: 0},
+	"Standard_DS15_v2":       {VCPU: 20, MemoryMb: 143360, GPU: 0},
+	"Standard_D12_v2":        {VCPU: 4, MemoryMb: 28672, GPU: 0},
+	"Standard_M128-32ms":     {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_M128ms":        {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_DS3":           {VCPU: 4, MemoryMb: 14336, GPU: 0},
+	"Standard_DS2":           {VCPU: 2, MemoryMb: 7168, GPU: 0},
+	"Standard_DS1":           {VCPU: 1, MemoryMb: 3584, GPU: 0},
+	"Standard_DS2_v2_Promo":  {VCPU: 2, MemoryMb: 7168, GPU: 0},
+	"Standard_DS1_v2":        {VCPU: 1, MemoryMb: 3584, GPU: 0},
+	"Standard_DS4":           {VCPU: 8, MemoryMb: 28672, GPU: 0},
+	"Standard_F2":            {VCPU: 2, MemoryMb: 4096, GPU: 0},
+	"Standard_F1":            {VCPU: 1, MemoryMb: 2048, GPU: 0},
+	"Standard_E2s_v3":        {VCPU: 2, MemoryMb: 16384, GPU: 0},
+	"Standard_F2s_v2":        {VCPU: 0, MemoryMb: 0, GPU: 0},
+	"Standard_E64is_v3":      {VCPU: 64, MemoryMb: 442368, GPU: 0},
+	"Standard_L4s":           {VCPU: 4, MemoryMb: 32768, GPU: 0},
+	"Standard_H16":           {VCPU: 16, MemoryMb: 114688, GPU: 0},
+	"Standard_F8":            {VCPU: 8, MemoryMb: 16384, GPU: 0},
+	"Standard_GS4-8":         {VCPU: 16, MemoryMb: 229376, GPU: 0},
+	"Standard_DS4_v2":        {VCPU: 8, MemoryMb: 28672, GPU: 0},
+	"Standard_D16s_v3":       {VCPU: 16, MemoryMb: 65536, GPU: 0},
+	"Standard_H8":            {VCPU: 8, MemoryMb: 57344, GPU: 0},
+	"Standard_A8_v2":         {VCPU: 8, MemoryMb: 16384, GPU: 0},
+	"Standard_B1s":           {VCPU: 1, MemoryMb: 1024, GPU: 0},
+	"Standard_F16":           {VCPU: 16, MemoryMb: 32768, GPU: 0},
+	"Standard_E64_v3":        {VCPU: 64, MemoryMb: 442368, GPU: 0},
+	"Standard_E32-16s_v3":    {VCPU: 32, MemoryMb: 262144, GPU: 0},
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+)
+
+const (
+	// instanceClassAnnotationKey names the capacity class of the
+	// machines of a MachineSet or MachineDeployment.
+	instanceClassAnnotationKey = machineAPIGroup + "/instance-class"
+
+	// instanceClassesConfigMapName is the name of the ConfigMap,
+	// in the namespace of the MachineSet or MachineDeployment,
+	// whose data maps class names to JSON encoded capacities.
+	instanceClassesConfigMapName = "cluster-autoscaler-instance-classes"
+
+	// The capacity annotations set the capacity of the machines
+	// of a MachineSet or MachineDeployment directly, without an
+	// instance class. Values are resource quantities. The pods
+	// annotation alone overrides the pod capacity of the instance
+	// class or of the providerSpec, e.g. for kubelets configured
+	// with a lower max-pods.
+	cpuCapacityAnnotationKey    = machineAPIGroup + "/cluster-api-autoscaler-node-group-capacity-cpu"
+	memoryCapacityAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-capacity-memory"
+	gpuCapacityAnnotationKey    = machineAPIGroup + "/cluster-api-autoscaler-node-group-capacity-gpu"
+	podsCapacityAnnotationKey   = machineAPIGroup + "/cluster-api-autoscaler-node-group-capacity-pods"
+
+	// capacityResourcesAnnotationKey adds other resources to the
+	// capacity of the machines of a MachineSet or MachineDeployment,
+	// whatever it comes from, e.g. hugepages or the extended
+	// resources of device plugins. Values are a comma separated list
+	// of name=quantity pairs, such as
+	// "hugepages-1Gi=4Gi,example.com/fpga=1".
+	capacityResourcesAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-capacity-resources"
+
+	defaultPodCapacity = 110
+)
+
+var (
+	// errMissingInstanceClass is the error returned when the
+	// instance class named by a scalable resource is not defined.
+	errMissingInstanceClass = errors.New("missing instance class")
+
+	// errInvalidInstanceClass is the error returned when an
+	// instance class cannot be parsed.
+	errInvalidInstanceClass = errors.New("invalid instance class")
+
+	// errMissingCapacityAnnotations is the error returned when a
+	// scalable resource has none of the capacity annotations.
+	errMissingCapacityAnnotations = errors.New("missing capacity annotations")
+
+	// errInvalidPodsCapacityAnnotation is the error returned when a
+	// scalable resource has a non-positive or non-integral pods
+	// capacity annotation value.
+	errInvalidPodsCapacityAnnotation = errors.New("invalid pods capacity annotation")
+
+	// errInvalidCapacityResourcesAnnotation is the error returned
+	// when the capacity resources annotation of a scalable resource
+	// cannot be parsed.
+	errInvalidCapacityResourcesAnnotation = errors.New("invalid capacity resources annotation")
+)
+
+// instanceClass is the capacity of a machine, as defined in the
+// instance classes ConfigMap. Values are resource quantities, for
+// example {"cpu": "4", "memory": "16Gi", "gpu": "1", "disk": "120Gi"}.
+// The optional price is the hourly price of a machine of the class,
+// see parsePrice.
+type instanceClass struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+	GPU    string `json:"gpu,omitempty"`
+	Disk   string `json:"disk,omitempty"`
+	Pods   string `json:"pods,omitempty"`
+	Price  string `json:"price,omitempty"`
+}
+
+// parseInstanceClass returns the capacity encoded in value.
+func parseInstanceClass(value string) (corev1.ResourceList, error) {
+	class, err := decodeInstanceClass(value)
+	if err != nil {
+		return nil, err
+	}
+	return class.capacity()
+}
+
+// decodeInstanceClass returns the instance class encoded in value.
+func decodeInstanceClass(value string) (instanceClass, error) {
+	class := instanceClass{}
+	if err := json.Unmarshal([]byte(value), &class); err != nil {
+		return class, errors.Wrapf(err, "%s", errInvalidInstanceClass)
+	}
+	return class, nil
+}
+
+// capacityFromAnnotations returns the capacity encoded in the
+// capacity annotations. CPU and memory are required if any of the
+// annotations but the pods one is set. Returns
+// errMissingCapacityAnnotations if none of them are.
+func capacityFromAnnotations(annotations map[string]string) (corev1.ResourceList, error) {
+	class := instanceClass{
+		CPU:    annotations[cpuCapacityAnnotationKey],
+		Memory: annotations[memoryCapacityAnnotationKey],
+		GPU:    annotations[gpuCapacityAnnotationKey],
+		Pods:   annotations[podsCapacityAnnotationKey],
+	}
+	if class.CPU == "" && class.Memory == "" && class.GPU == "" {
+		return nil, errMissingCapacityAnnotations
+	}
+	capacity, err := class.capacity()
+	if err != nil {
+		return nil, errors.Wrap(err, "capacity annotations")
+	}
+	return capacity, nil
+}
+
+// withPodsCapacityAnnotation returns capacity with the pod capacity
+// of the pods capacity annotation, if it is set. capacity is left
+// untouched.
+func withPodsCapacityAnnotation(capacity corev1.ResourceList, annotations map[string]string) (corev1.ResourceList, error) {
+	value, found := annotations[podsCapacityAnnotationKey]
+	if !found {
+		return capacity, nil
+	}
+	pods, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", errInvalidPodsCapacityAnnotation)
+	}
+	if pods.Sign() <= 0 || pods.MilliValue()%1000 != 0 {
+		return nil, errInvalidPodsCapacityAnnotation
+	}
+	result := capacity.DeepCopy()
+	result[corev1.ResourcePods] = pods
+	return result, nil
+}
+
+// withCapacityResourcesAnnotation returns capacity with the resources
+// of the capacity resources annotation, if it is set. capacity is
+// left untouched.
+func withCapacityResourcesAnnotation(capacity corev1.ResourceList, annotations map[string]string) (corev1.ResourceList, error) {
+	value, found := annotations[capacityResourcesAnnotationKey]
+	if !found {
+		return capacity, nil
+	}
+	result := capacity.DeepCopy()
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("%s: %q", errInvalidCapacityResourcesAnnotation, pair)
+		}
+		name := strings.TrimSpace(kv[0])
+		if msgs := validation.IsQualifiedName(name); len(msgs) > 0 {
+			return nil, errors.Errorf("%s: resource name %q: %s", errInvalidCapacityResourcesAnnotation, name, strings.Join(msgs, ", "))
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: %s", errInvalidCapacityResourcesAnnotation, name)
+		}
+		if q.Sign() < 0 {
+			return nil, errors.Errorf("%s: negative %s", errInvalidCapacityResourcesAnnotation, name)
+		}
+		result[corev1.ResourceName(name)] = q
+	}
+	return result, nil
+}
+
+// capacity returns the resources of the instance class. CPU and
+// memory are required; the pod capacity defaults to
+// defaultPodCapacity.
+func (class instanceClass) capacity() (corev1.ResourceList, error) {
+	if class.CPU == "" || class.Memory == "" {
+		return nil, errors.Errorf("%s: cpu and memory must be set", errInvalidInstanceClass)
+	}
+	if class.Pods == "" {
+		class.Pods = fmt.Sprint(defaultPodCapacity)
+	}
+
+	capacity := corev1.ResourceList{}
+	for name, quantity := range map[corev1.ResourceName]string{
+		corev1.ResourceCPU:              class.CPU,
+		corev1.ResourceMemory:           class.Memory,
+		gpu.ResourceNvidiaGPU:           class.GPU,
+		corev1.ResourceEphemeralStorage: class.Disk,
+		corev1.ResourcePods:             class.Pods,
+	} {
+		if quantity == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(quantity)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: %s", errInvalidInstanceClass, name)
+		}
+		if q.Sign() < 0 {
+			return nil, errors.Errorf("%s: negative %s", errInvalidInstanceClass, name)
+		}
+		if q.IsZero() && name == gpu.ResourceNvidiaGPU {
+			continue
+		}
+		capacity[name] = q
+	}
+	return capacity, nil
+}
+
+// findInstanceClass returns the capacity of the instance class
+// defined in the instance classes ConfigMap of namespace. Returns
+// errMissingInstanceClass if the ConfigMap or the class don't exist.
+func (c *machineController) findInstanceClass(namespace, class string) (corev1.ResourceList, error) {
+	value, err := c.instanceClassValue(namespace, class)
+	if err != nil {
+		return nil, err
+	}
+	capacity, err := parseInstanceClass(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "class %q in ConfigMap %q", class, path.Join(namespace, instanceClassesConfigMapName))
+	}
+	return capacity, nil
+}
+
+// findInstanceClassPrice returns the price of the instance class
+// defined in the instance classes ConfigMap of namespace. Returns
+// errMissingInstanceClass if the ConfigMap or the class don't exist,
+// and errMissingPrice if the class has no price.
+func (c *machineController) findInstanceClassPrice(namespace, class string) (float64, error) {
+	key := path.Join(namespace, instanceClassesConfigMapName)
+	value, err := c.instanceClassValue(namespace, class)
+	if err != nil {
+		return 0, err
+	}
+	decoded, err := decodeInstanceClass(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "class %q in ConfigMap %q", class, key)
+	}
+	if decoded.Price == "" {
+		return 0, errors.Wrapf(errMissingPrice, "class %q in ConfigMap %q", class, key)
+	}
+	price, err := parsePrice(decoded.Price)
+	if err != nil {
+		return 0, errors.Wrapf(err, "class %q in ConfigMap %q", class, key)
+	}
+	return price, nil
+}
+
+// instanceClassValue returns the JSON encoded instance class defined
+// in the instance classes ConfigMap of namespace. Returns
+// errMissingInstanceClass if the ConfigMap or the class don't exist.
+func (c *machineController) instanceClassValue(namespace, class string) (string, error) {
+	key := path.Join(namespace, instanceClassesConfigMapName)
+	item, exists, err := c.instanceClassInformer.GetStore().GetByKey(key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", errors.Wrapf(errMissingInstanceClass, "ConfigMap %q not found", key)
+	}
+
+	configMap, ok := item.(*corev1.ConfigMap)
+	if !ok {
+		return "", fmt.Errorf("internal error; unexpected type %T", item)
+	}
+
+	value, found := configMap.Data[class]
+	if !found {
+		return "", errors.Wrapf(errMissingInstanceClass, "class %q not defined in ConfigMap %q", class, key)
+	}
+	return value, nil
+}
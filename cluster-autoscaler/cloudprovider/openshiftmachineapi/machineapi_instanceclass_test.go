@@ -0,0 +1,293 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+)
+
+func TestParseInstanceClass(t *testing.T) {
+	for i, tc := range []struct {
+		description string
+		value       string
+		error       error
+		capacity    corev1.ResourceList
+	}{{
+		description: "cpu and memory with default pods",
+		value:       `{"cpu": "4", "memory": "16Gi"}`,
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		},
+	}, {
+		description: "all resources",
+		value:       `{"cpu": "8", "memory": "61Gi", "gpu": "1", "disk": "120Gi", "pods": "250"}`,
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("8"),
+			corev1.ResourceMemory:           resource.MustParse("61Gi"),
+			gpu.ResourceNvidiaGPU:           resource.MustParse("1"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("120Gi"),
+			corev1.ResourcePods:             resource.MustParse("250"),
+		},
+	}, {
+		description: "zero gpus are omitted",
+		value:       `{"cpu": "500m", "memory": "1Gi", "gpu": "0"}`,
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		},
+	}, {
+		description: "missing memory errors",
+		value:       `{"cpu": "4"}`,
+		error:       errInvalidInstanceClass,
+	}, {
+		description: "invalid quantity errors",
+		value:       `{"cpu": "four", "memory": "16Gi"}`,
+		error:       errInvalidInstanceClass,
+	}, {
+		description: "negative quantity errors",
+		value:       `{"cpu": "4", "memory": "16Gi", "disk": "-1Gi"}`,
+		error:       errInvalidInstanceClass,
+	}, {
+		description: "invalid JSON errors",
+		value:       `cpu: 4`,
+		error:       errInvalidInstanceClass,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			capacity, err := parseInstanceClass(tc.value)
+			if tc.error != nil {
+				if err == nil {
+					t.Fatalf("test #%d: expected an error", i)
+				}
+				if !strings.HasPrefix(err.Error(), tc.error.Error()) {
+					t.Errorf("expected message to have prefix %q, got %q", tc.error.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("test #%d: unexpected error: %v", i, err)
+			}
+			if len(capacity) != len(tc.capacity) {
+				t.Fatalf("test #%d: expected %v, got %v", i, tc.capacity, capacity)
+			}
+			for name, expected := range tc.capacity {
+				if actual := capacity[name]; actual.Cmp(expected) != 0 {
+					t.Errorf("test #%d: expected %s %v, got %v", i, name, expected.String(), actual.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCapacityFromAnnotations(t *testing.T) {
+	capacity, err := capacityFromAnnotations(map[string]string{
+		cpuCapacityAnnotationKey:    "4",
+		memoryCapacityAnnotationKey: "16Gi",
+		gpuCapacityAnnotationKey:    "2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, expected := range map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+		gpu.ResourceNvidiaGPU: resource.MustParse("2"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	} {
+		if actual := capacity[name]; actual.Cmp(expected) != 0 {
+			t.Errorf("expected %s %v, got %v", name, expected.String(), actual.String())
+		}
+	}
+
+	if _, err := capacityFromAnnotations(map[string]string{nodeGroupMinSizeAnnotationKey: "1"}); err != errMissingCapacityAnnotations {
+		t.Errorf("expected %q, got %v", errMissingCapacityAnnotations, err)
+	}
+	if _, err := capacityFromAnnotations(map[string]string{podsCapacityAnnotationKey: "250"}); err != errMissingCapacityAnnotations {
+		t.Errorf("expected %q, got %v", errMissingCapacityAnnotations, err)
+	}
+	if _, err := capacityFromAnnotations(map[string]string{cpuCapacityAnnotationKey: "4"}); err == nil || !strings.Contains(err.Error(), errInvalidInstanceClass.Error()) {
+		t.Errorf("expected %q, got %v", errInvalidInstanceClass, err)
+	}
+}
+
+func TestWithPodsCapacityAnnotation(t *testing.T) {
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:  resource.MustParse("4"),
+		corev1.ResourcePods: resource.MustParse("110"),
+	}
+
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		expected    string
+		err         error
+	}{
+		{"no annotation", nil, "110", nil},
+		{"annotation", map[string]string{podsCapacityAnnotationKey: "250"}, "250", nil},
+		{"zero", map[string]string{podsCapacityAnnotationKey: "0"}, "", errInvalidPodsCapacityAnnotation},
+		{"fractional", map[string]string{podsCapacityAnnotationKey: "1500m"}, "", errInvalidPodsCapacityAnnotation},
+		{"invalid", map[string]string{podsCapacityAnnotationKey: "many"}, "", errInvalidPodsCapacityAnnotation},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := withPodsCapacityAnnotation(capacity, tc.annotations)
+			if tc.err != nil {
+				if err == nil || !strings.Contains(err.Error(), tc.err.Error()) {
+					t.Errorf("expected %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pods := actual[corev1.ResourcePods]; pods.Cmp(resource.MustParse(tc.expected)) != 0 {
+				t.Errorf("expected %s pods, got %v", tc.expected, pods.String())
+			}
+			if cpu := actual[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("4")) != 0 {
+				t.Errorf("expected 4 cpus, got %v", cpu.String())
+			}
+		})
+	}
+
+	if pods := capacity[corev1.ResourcePods]; pods.Cmp(resource.MustParse("110")) != 0 {
+		t.Errorf("expected the capacity to be left untouched, got %v pods", pods.String())
+	}
+}
+
+func TestWithCapacityResourcesAnnotation(t *testing.T) {
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}
+
+	for _, tc := range []struct {
+		description string
+		value       string
+		expected    corev1.ResourceList
+		err         error
+	}{{
+		description: "hugepages, ephemeral storage and extended resources",
+		value:       "hugepages-1Gi=4Gi, ephemeral-storage=100Gi,example.com/fpga=1,",
+		expected: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("4"),
+			corev1.ResourceMemory:           resource.MustParse("16Gi"),
+			"hugepages-1Gi":                 resource.MustParse("4Gi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+			"example.com/fpga":              resource.MustParse("1"),
+		},
+	}, {
+		description: "override",
+		value:       "memory=32Gi",
+		expected: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("32Gi"),
+		},
+	}, {
+		description: "missing quantity",
+		value:       "example.com/fpga",
+		err:         errInvalidCapacityResourcesAnnotation,
+	}, {
+		description: "invalid name",
+		value:       "example.com/fpga/v2=1",
+		err:         errInvalidCapacityResourcesAnnotation,
+	}, {
+		description: "invalid quantity",
+		value:       "example.com/fpga=one",
+		err:         errInvalidCapacityResourcesAnnotation,
+	}, {
+		description: "negative quantity",
+		value:       "example.com/fpga=-1",
+		err:         errInvalidCapacityResourcesAnnotation,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := withCapacityResourcesAnnotation(capacity, map[string]string{capacityResourcesAnnotationKey: tc.value})
+			if tc.err != nil {
+				if err == nil || !strings.Contains(err.Error(), tc.err.Error()) {
+					t.Errorf("expected %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(actual) != len(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+			for name, expected := range tc.expected {
+				if q := actual[name]; q.Cmp(expected) != 0 {
+					t.Errorf("expected %s %v, got %v", name, expected.String(), q.String())
+				}
+			}
+		})
+	}
+
+	if len(capacity) != 2 {
+		t.Errorf("expected the capacity to be left untouched, got %v", capacity)
+	}
+	if actual, err := withCapacityResourcesAnnotation(capacity, nil); err != nil || len(actual) != 2 {
+		t.Errorf("expected the capacity without the annotation, got %v, %v", actual, err)
+	}
+}
+
+func TestFindInstanceClass(t *testing.T) {
+	controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 1, nil))
+	defer stop()
+
+	if _, err := controller.findInstanceClass(testNamespace, "small"); errors.Cause(err) != errMissingInstanceClass {
+		t.Fatalf("expected %q, got %v", errMissingInstanceClass, err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      instanceClassesConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"small":   `{"cpu": "2", "memory": "4Gi"}`,
+			"invalid": `{"cpu": "2"}`,
+		},
+	}
+	if err := controller.instanceClassInformer.GetStore().Add(configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	capacity, err := controller.findInstanceClass(testNamespace, "small")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu := capacity[corev1.ResourceCPU]; cpu.Value() != 2 {
+		t.Errorf("expected 2 cpus, got %v", cpu.String())
+	}
+
+	if _, err := controller.findInstanceClass(testNamespace, "large"); errors.Cause(err) != errMissingInstanceClass {
+		t.Errorf("expected %q, got %v", errMissingInstanceClass, err)
+	}
+	if _, err := controller.findInstanceClass(testNamespace, "invalid"); err == nil || !strings.Contains(err.Error(), errInvalidInstanceClass.Error()) {
+		t.Errorf("expected %q, got %v", errInvalidInstanceClass, err)
+	}
+	if _, err := controller.findInstanceClass("other", "small"); errors.Cause(err) != errMissingInstanceClass {
+		t.Errorf("expected %q, got %v", errMissingInstanceClass, err)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// nodeGroupLimitsConfigMapName is the name of the ConfigMap,
+	// in the namespace of the scalable resources, whose data
+	// overrides the scaling bounds of node groups at runtime, e.g.
+	// to cap a node group during an incident without editing a
+	// MachineSet managed by GitOps. Keys are the lowercase kind and
+	// the name of the scalable resource, separated by a dot, like
+	// machineset.worker-us-east-1a; values are JSON encoded
+	// nodeGroupLimits.
+	nodeGroupLimitsConfigMapName = "cluster-autoscaler-node-group-limits"
+)
+
+// errInvalidNodeGroupLimits is the error returned when the limits of
+// a node group cannot be parsed.
+var errInvalidNodeGroupLimits = errors.New("invalid node group limits")
+
+// nodeGroupLimits overrides the min and/or max size of a node group,
+// for example {"maxSize": 3}.
+type nodeGroupLimits struct {
+	MinSize *int `json:"minSize,omitempty"`
+	MaxSize *int `json:"maxSize,omitempty"`
+}
+
+// nodeGroupLimitsKey returns the key of the limits of the scalable
+// resource of the given kind and name in the node group limits
+// ConfigMap.
+func nodeGroupLimitsKey(kind, name string) string {
+	return strings.ToLower(kind) + "." + name
+}
+
+// parseNodeGroupLimits returns the limits encoded in value. Returns
+// errInvalidNodeGroupLimits if they are negative or if maxSize is
+// less than minSize.
+func parseNodeGroupLimits(value string) (*nodeGroupLimits, error) {
+	limits := &nodeGroupLimits{}
+	if err := json.Unmarshal([]byte(value), limits); err != nil {
+		return nil, errors.Wrapf(err, "%s", errInvalidNodeGroupLimits)
+	}
+	if limits.MinSize != nil && *limits.MinSize < 0 {
+		return nil, errors.Errorf("%s: negative minSize", errInvalidNodeGroupLimits)
+	}
+	if limits.MaxSize != nil && *limits.MaxSize < 0 {
+		return nil, errors.Errorf("%s: negative maxSize", errInvalidNodeGroupLimits)
+	}
+	if limits.MinSize != nil && limits.MaxSize != nil && *limits.MaxSize < *limits.MinSize {
+		return nil, errors.Errorf("%s: maxSize less than minSize", errInvalidNodeGroupLimits)
+	}
+	return limits, nil
+}
+
+// apply returns the given scaling bounds overridden by the limits.
+// A maxSize below the min size lowers the min size to it, and a
+// minSize above the max size raises the max size to it.
+func (l *nodeGroupLimits) apply(minSize, maxSize int) (int, int) {
+	if l.MinSize != nil {
+		minSize = *l.MinSize
+		if maxSize < minSize {
+			maxSize = minSize
+		}
+	}
+	if l.MaxSize != nil {
+		maxSize = *l.MaxSize
+		if minSize > maxSize {
+			minSize = maxSize
+		}
+	}
+	return minSize, maxSize
+}
+
+// findNodeGroupLimits returns the limits of the scalable resource of
+// the given kind, namespace and name in the node group limits
+// ConfigMap of its namespace, or nil if there are none. Invalid
+// limits are logged and ignored, so that a bad entry doesn't make
+// the node group, or any other, disappear.
+func (c *machineController) findNodeGroupLimits(kind, namespace, name string) (*nodeGroupLimits, error) {
+	key := path.Join(namespace, nodeGroupLimitsConfigMapName)
+	item, exists, err := c.nodeGroupLimitsInformer.GetStore().GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	configMap, ok := item.(*corev1.ConfigMap)
+	if !ok {
+		return nil, fmt.Errorf("internal error; unexpected type %T", item)
+	}
+
+	limitsKey := nodeGroupLimitsKey(kind, name)
+	value, found := configMap.Data[limitsKey]
+	if !found {
+		return nil, nil
+	}
+	limits, err := parseNodeGroupLimits(value)
+	if err != nil {
+		klog.Warningf("ignoring %q in ConfigMap %q: %v", limitsKey, key, err)
+		return nil, nil
+	}
+	klog.V(4).Infof("%s %s/%s has limits %s in ConfigMap %q", kind, namespace, name, value, key)
+	return limits, nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseNodeGroupLimits(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		value       string
+		error       bool
+		minSize     int
+		maxSize     int
+	}{{
+		description: "max size only lowers the min size",
+		value:       `{"maxSize": 1}`,
+		minSize:     1,
+		maxSize:     1,
+	}, {
+		description: "min size only raises the max size",
+		value:       `{"minSize": 12}`,
+		minSize:     12,
+		maxSize:     12,
+	}, {
+		description: "both sizes",
+		value:       `{"minSize": 0, "maxSize": 3}`,
+		minSize:     0,
+		maxSize:     3,
+	}, {
+		description: "no sizes",
+		value:       `{}`,
+		minSize:     2,
+		maxSize:     10,
+	}, {
+		description: "negative size errors",
+		value:       `{"maxSize": -1}`,
+		error:       true,
+	}, {
+		description: "max size less than min size errors",
+		value:       `{"minSize": 3, "maxSize": 2}`,
+		error:       true,
+	}, {
+		description: "invalid JSON errors",
+		value:       `maxSize: 3`,
+		error:       true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			limits, err := parseNodeGroupLimits(tc.value)
+			if tc.error {
+				if err == nil || !strings.HasPrefix(err.Error(), errInvalidNodeGroupLimits.Error()) {
+					t.Fatalf("expected error with prefix %q, got %v", errInvalidNodeGroupLimits, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if minSize, maxSize := limits.apply(2, 10); minSize != tc.minSize || maxSize != tc.maxSize {
+				t.Errorf("expected bounds %d-%d, got %d-%d", tc.minSize, tc.maxSize, minSize, maxSize)
+			}
+		})
+	}
+}
+
+func TestControllerScalingBoundsWithNodeGroupLimits(t *testing.T) {
+	controller, stop := mustCreateTestController(t)
+	defer stop()
+
+	meta := metav1.ObjectMeta{
+		Name:      "machineset-0",
+		Namespace: testNamespace,
+		Annotations: map[string]string{
+			nodeGroupMinSizeAnnotationKey: "2",
+			nodeGroupMaxSizeAnnotationKey: "10",
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeGroupLimitsConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"machineset.machineset-0":        `{"maxSize": 4}`,
+			"machinedeployment.machineset-0": `{"maxSize": 6}`,
+			"machineset.unannotated":         `{"maxSize": 4}`,
+			"machineset.invalid":             `{"maxSize": "4"}`,
+		},
+	}
+	if err := controller.nodeGroupLimitsInformer.GetStore().Add(configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	min, max, err := controller.scalingBounds("MachineSet", meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 2 || max != 4 {
+		t.Errorf("expected bounds 2-4 from the node group limits, got %d-%d", min, max)
+	}
+
+	// Limits also override the replicas of MachineAutoscalers.
+	if err := controller.machineAutoscalerInformer.GetStore().Add(newTestMachineAutoscaler("ma", testNamespace, "MachineSet", "machineset-0", 0, 8)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	min, max, err = controller.scalingBounds("MachineSet", meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 0 || max != 4 {
+		t.Errorf("expected bounds 0-4 from the node group limits, got %d-%d", min, max)
+	}
+
+	// Scalable resources without bounds don't get any.
+	min, max, err = controller.scalingBounds("MachineSet", metav1.ObjectMeta{Name: "unannotated", Namespace: testNamespace})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 0 || max != 0 {
+		t.Errorf("expected no bounds, got %d-%d", min, max)
+	}
+
+	// Invalid limits are ignored.
+	invalid := meta.DeepCopy()
+	invalid.Name = "invalid"
+	min, max, err = controller.scalingBounds("MachineSet", *invalid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 2 || max != 10 {
+		t.Errorf("expected bounds 2-10 from annotations, got %d-%d", min, max)
+	}
+
+	// Limits of other namespaces don't apply.
+	other := meta.DeepCopy()
+	other.Namespace = "other"
+	min, max, err = controller.scalingBounds("MachineSet", *other)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 2 || max != 10 {
+		t.Errorf("expected bounds 2-10 from annotations, got %d-%d", min, max)
+	}
+}
+
+func TestNodeGroupMaxSizeWithNodeGroupLimits(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig, key string) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nodeGroupLimitsConfigMapName,
+				Namespace: testNamespace,
+			},
+			Data: map[string]string{key: `{"maxSize": 3}`},
+		}
+		if err := controller.nodeGroupLimitsInformer.GetStore().Add(configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+		if actual := nodegroups[0].MaxSize(); actual != 3 {
+			t.Errorf("expected max size 3, got %d", actual)
+		}
+
+		// Removing the limits restores the bounds of the annotations.
+		if err := controller.nodeGroupLimitsInformer.GetStore().Delete(configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		nodegroups, err = controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual := nodegroups[0].MaxSize(); actual != 10 {
+			t.Errorf("expected max size 10, got %d", actual)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 1, annotations), "machineset.machineset-0")
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 1, annotations), "machinedeployment.machinedeployment-0")
+	})
+}
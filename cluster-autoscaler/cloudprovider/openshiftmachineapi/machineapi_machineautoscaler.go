@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	machineAutoscalerTargetIndex = "openshiftmachineapi-machineAutoscalerTargetIndex"
+)
+
+var (
+	// machineAutoscalerResource is the resource of the
+	// MachineAutoscalers of the OpenShift cluster autoscaler
+	// operator. Their types are not vendored, so they are read as
+	// unstructured objects.
+	machineAutoscalerResource = schema.GroupVersionResource{
+		Group:    "autoscaling.openshift.io",
+		Version:  "v1beta1",
+		Resource: "machineautoscalers",
+	}
+
+	// errInvalidMachineAutoscaler is the error returned when a
+	// MachineAutoscaler has invalid min/max replicas or no
+	// scaleTargetRef.
+	errInvalidMachineAutoscaler = errors.New("invalid MachineAutoscaler")
+
+	// errConflictingMachineAutoscalers is the error returned
+	// when several MachineAutoscalers target the same scalable
+	// resource.
+	errConflictingMachineAutoscalers = errors.New("conflicting MachineAutoscalers")
+)
+
+// machineAutoscaler holds the scaling bounds of the scalable resource
+// targeted by a MachineAutoscaler.
+type machineAutoscaler struct {
+	name        string
+	minReplicas int
+	maxReplicas int
+}
+
+// machineAutoscalerTargetKey returns the key of the scalable resource
+// of the given kind, namespace and name in machineAutoscalerTargetIndex.
+func machineAutoscalerTargetKey(kind, namespace, name string) string {
+	return path.Join(kind, namespace, name)
+}
+
+func indexMachineAutoscalerByTarget(obj interface{}) ([]string, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return []string{}, nil
+	}
+	kind, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "kind")
+	name, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "name")
+	if kind == "" || name == "" {
+		return []string{}, nil
+	}
+	return []string{machineAutoscalerTargetKey(kind, u.GetNamespace(), name)}, nil
+}
+
+// parseMachineAutoscaler returns the scaling bounds of u. Returns
+// errInvalidMachineAutoscaler if the replicas are missing, negative
+// or if maxReplicas is less than minReplicas.
+func parseMachineAutoscaler(u *unstructured.Unstructured) (*machineAutoscaler, error) {
+	minReplicas, found, err := unstructured.NestedInt64(u.Object, "spec", "minReplicas")
+	if err != nil || !found || minReplicas < 0 {
+		return nil, errors.Wrapf(errInvalidMachineAutoscaler, "%q: spec.minReplicas", u.GetName())
+	}
+	maxReplicas, found, err := unstructured.NestedInt64(u.Object, "spec", "maxReplicas")
+	if err != nil || !found || maxReplicas < minReplicas {
+		return nil, errors.Wrapf(errInvalidMachineAutoscaler, "%q: spec.maxReplicas", u.GetName())
+	}
+	return &machineAutoscaler{
+		name:        u.GetName(),
+		minReplicas: int(minReplicas),
+		maxReplicas: int(maxReplicas),
+	}, nil
+}
+
+// newMachineAutoscalerListWatch returns a ListerWatcher for the
+// MachineAutoscalers in namespace.
+func newMachineAutoscalerListWatch(dynamicclient dynamic.Interface, namespace string) cache.ListerWatcher {
+	resource := dynamicclient.Resource(machineAutoscalerResource).Namespace(namespace)
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return resource.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return resource.Watch(options)
+		},
+	}
+}
+
+// findMachineAutoscaler returns the MachineAutoscaler targeting the
+// scalable resource of the given kind, namespace and name, or nil if
+// there is none or MachineAutoscalers are not enabled.
+func (c *machineController) findMachineAutoscaler(kind, namespace, name string) (*machineAutoscaler, error) {
+	if c.machineAutoscalerInformer == nil {
+		return nil, nil
+	}
+
+	key := machineAutoscalerTargetKey(kind, namespace, name)
+	objs, err := c.machineAutoscalerInformer.GetIndexer().ByIndex(machineAutoscalerTargetIndex, key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := len(objs); {
+	case n == 0:
+		return nil, nil
+	case n > 1:
+		return nil, errors.Wrapf(errConflictingMachineAutoscalers, "%d MachineAutoscalers target %q", n, key)
+	}
+
+	u, ok := objs[0].(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("internal error; unexpected type %T", objs[0])
+	}
+	return parseMachineAutoscaler(u)
+}
+
+// scalingBounds returns the min and max size of the scalable resource
+// of the given kind. The replicas of the MachineAutoscaler targeting
+// it take precedence over its min/max annotations, which take
+// precedence over the bounds of the first auto-discovery config
+// matching it. The limits of the node group limits ConfigMap, if any,
+// override the resulting bounds, unless the scalable resource has
+// none, so that the ConfigMap cannot make node groups of other
+// scalable resources.
+func (c *machineController) scalingBounds(kind string, meta metav1.ObjectMeta) (int, int, error) {
+	minSize, maxSize, err := c.configuredScalingBounds(kind, meta)
+	if err != nil || maxSize == 0 {
+		return minSize, maxSize, err
+	}
+	limits, err := c.findNodeGroupLimits(kind, meta.Namespace, meta.Name)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limits != nil {
+		minSize, maxSize = limits.apply(minSize, maxSize)
+	}
+	return minSize, maxSize, nil
+}
+
+// configuredScalingBounds returns the min and max size of the
+// scalable resource of the given kind, as configured by the machine
+// API objects.
+func (c *machineController) configuredScalingBounds(kind string, meta metav1.ObjectMeta) (int, int, error) {
+	ma, err := c.findMachineAutoscaler(kind, meta.Namespace, meta.Name)
+	if err != nil {
+		return 0, 0, err
+	}
+	if ma != nil {
+		return ma.minReplicas, ma.maxReplicas, nil
+	}
+	_, hasMin := meta.Annotations[nodeGroupMinSizeAnnotationKey]
+	_, hasMax := meta.Annotations[nodeGroupMaxSizeAnnotationKey]
+	if !hasMin && !hasMax {
+		if cfg := c.findAutoDiscoveryConfig(meta); cfg != nil {
+			return cfg.minSize, cfg.maxSize, nil
+		}
+	}
+	return parseScalingBounds(meta.Annotations)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestMachineAutoscalerListWatch returns a ListerWatcher with no
+// MachineAutoscalers. Tests add them to the informer store directly.
+func newTestMachineAutoscalerListWatch(namespace string) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &unstructured.UnstructuredList{}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}
+}
+
+func newTestMachineAutoscaler(name, namespace, targetKind, targetName string, minReplicas, maxReplicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": machineAutoscalerResource.GroupVersion().String(),
+			"kind":       "MachineAutoscaler",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"minReplicas": minReplicas,
+				"maxReplicas": maxReplicas,
+				"scaleTargetRef": map[string]interface{}{
+					"apiVersion": "machine.openshift.io/v1beta1",
+					"kind":       targetKind,
+					"name":       targetName,
+				},
+			},
+		},
+	}
+}
+
+func TestParseMachineAutoscaler(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		minReplicas int64
+		maxReplicas int64
+		expectedErr error
+	}{{
+		description: "valid replicas",
+		minReplicas: 1,
+		maxReplicas: 3,
+	}, {
+		description: "scale from zero",
+		minReplicas: 0,
+		maxReplicas: 3,
+	}, {
+		description: "negative min replicas",
+		minReplicas: -1,
+		maxReplicas: 3,
+		expectedErr: errInvalidMachineAutoscaler,
+	}, {
+		description: "max replicas less than min replicas",
+		minReplicas: 3,
+		maxReplicas: 1,
+		expectedErr: errInvalidMachineAutoscaler,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			ma, err := parseMachineAutoscaler(newTestMachineAutoscaler("ma", testNamespace, "MachineSet", "machineset-0", tc.minReplicas, tc.maxReplicas))
+			if errors.Cause(err) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if ma.minReplicas != int(tc.minReplicas) || ma.maxReplicas != int(tc.maxReplicas) {
+				t.Errorf("expected %d-%d replicas, got %d-%d", tc.minReplicas, tc.maxReplicas, ma.minReplicas, ma.maxReplicas)
+			}
+		})
+	}
+
+	missing := newTestMachineAutoscaler("ma", testNamespace, "MachineSet", "machineset-0", 1, 3)
+	unstructured.RemoveNestedField(missing.Object, "spec", "maxReplicas")
+	if _, err := parseMachineAutoscaler(missing); errors.Cause(err) != errInvalidMachineAutoscaler {
+		t.Errorf("expected error %v, got %v", errInvalidMachineAutoscaler, err)
+	}
+}
+
+func TestControllerNodeGroupsFromMachineAutoscalers(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig, kind, name string) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		// Without min/max annotations nor MachineAutoscaler the
+		// scalable resource is not a node group.
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(nodegroups) != 0 {
+			t.Fatalf("expected 0 nodegroups, got %d", len(nodegroups))
+		}
+
+		store := controller.machineAutoscalerInformer.GetStore()
+		if err := store.Add(newTestMachineAutoscaler("ma-0", testNamespace, kind, name, 1, 5)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// MachineAutoscalers targeting other resources are ignored.
+		if err := store.Add(newTestMachineAutoscaler("ma-1", testNamespace, kind, "other", 1, 10)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		nodegroups, err = controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(nodegroups) != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", len(nodegroups))
+		}
+		if nodegroups[0].MinSize() != 1 || nodegroups[0].MaxSize() != 5 {
+			t.Errorf("expected min 1 and max 5, got %s", nodegroups[0].Debug())
+		}
+
+		// Several MachineAutoscalers targeting the same resource
+		// are rejected.
+		if err := store.Add(newTestMachineAutoscaler("ma-2", testNamespace, kind, name, 1, 10)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := controller.nodeGroups(); err == nil {
+			t.Errorf("expected an error")
+		}
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 1, nil), "MachineSet", "machineset-0")
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 1, nil), "MachineDeployment", "machinedeployment-0")
+	})
+}
+
+func TestControllerScalingBoundsPreferMachineAutoscaler(t *testing.T) {
+	controller, stop := mustCreateTestController(t)
+	defer stop()
+
+	meta := metav1.ObjectMeta{
+		Name:      "machineset-0",
+		Namespace: testNamespace,
+		Annotations: map[string]string{
+			nodeGroupMinSizeAnnotationKey: "2",
+			nodeGroupMaxSizeAnnotationKey: "4",
+		},
+	}
+
+	min, max, err := controller.scalingBounds("MachineSet", meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 2 || max != 4 {
+		t.Errorf("expected bounds 2-4 from annotations, got %d-%d", min, max)
+	}
+
+	if err := controller.machineAutoscalerInformer.GetStore().Add(newTestMachineAutoscaler("ma", testNamespace, "MachineSet", "machineset-0", 0, 8)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	min, max, err = controller.scalingBounds("MachineSet", meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if min != 0 || max != 8 {
+		t.Errorf("expected bounds 0-8 from the MachineAutoscaler, got %d-%d", min, max)
+	}
+}
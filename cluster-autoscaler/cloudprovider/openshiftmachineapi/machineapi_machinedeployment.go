@@ -21,13 +21,11 @@ import (
 	"path"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
-	machinev1beta1 "github.com/openshift/cluster-api/pkg/client/clientset_generated/clientset/typed/machine/v1beta1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
 	"k8s.io/utils/pointer"
 )
 
 type machineDeploymentScalableResource struct {
-	machineapiClient  machinev1beta1.MachineV1beta1Interface
 	controller        *machineController
 	machineDeployment *v1beta1.MachineDeployment
 	maxSize           int
@@ -72,6 +70,7 @@ func (r machineDeploymentScalableResource) Nodes() ([]string, error) {
 		return nil, err
 	}
 
+	updatePendingMachines(r.ID(), result)
 	return result, nil
 }
 
@@ -79,16 +78,66 @@ func (r machineDeploymentScalableResource) Replicas() int32 {
 	return pointer.Int32PtrDerefOr(r.machineDeployment.Spec.Replicas, 0)
 }
 
-func (r machineDeploymentScalableResource) SetSize(nreplicas int32) error {
-	machineDeployment, err := r.machineapiClient.MachineDeployments(r.Namespace()).Get(r.Name(), metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("unable to get MachineDeployment %q: %v", r.ID(), err)
+func (r machineDeploymentScalableResource) Annotations() map[string]string {
+	return r.machineDeployment.Annotations
+}
+
+func (r machineDeploymentScalableResource) Labels() map[string]string {
+	return r.machineDeployment.Labels
+}
+
+func (r machineDeploymentScalableResource) MachineSpec() v1beta1.MachineSpec {
+	return r.machineDeployment.Spec.Template.Spec
+}
+
+// rolloutInProgress returns a description of the rollout of the
+// machine deployment and true until it has settled: while its rollout
+// is paused or the MachineDeployment controller is replacing its
+// machines. Nothing is reported before the controller has observed
+// the machine deployment, whose status is empty then.
+func (r machineDeploymentScalableResource) rolloutInProgress() (string, bool) {
+	spec, status := r.machineDeployment.Spec, r.machineDeployment.Status
+	replicas := r.Replicas()
+
+	switch {
+	case spec.Paused:
+		return "rollout is paused", true
+	case status.ObservedGeneration == 0:
+		return "", false
+	case status.ObservedGeneration < r.machineDeployment.Generation:
+		return "rollout is not observed yet", true
+	case status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d of %d machines are updated", status.UpdatedReplicas, replicas), true
+	case status.Replicas > status.UpdatedReplicas:
+		return fmt.Sprintf("%d old machines are pending termination", status.Replicas-status.UpdatedReplicas), true
 	}
+	return "", false
+}
 
-	machineDeployment = machineDeployment.DeepCopy()
-	machineDeployment.Spec.Replicas = &nreplicas
+// Problem returns the error of the first of the machine sets of the
+// machine deployment which has one. The machine deployment itself
+// reports no errors.
+func (r machineDeploymentScalableResource) Problem() (string, string, bool) {
+	var reason, message string
+	var found bool
+	if err := r.controller.filterAllMachineSets(func(machineSet *v1beta1.MachineSet) error {
+		if !found && machineSetIsOwnedByMachineDeployment(machineSet, r.machineDeployment) {
+			reason, message, found = machineSetProblem(machineSet)
+			if found {
+				message = fmt.Sprintf("machineset %s: %s", machineSet.Name, message)
+			}
+		}
+		return nil
+	}); err != nil {
+		klog.Warningf("unable to list the machinesets of machinedeployment %q: %v", r.ID(), err)
+	}
+	return reason, message, found
+}
 
-	_, err = r.machineapiClient.MachineDeployments(r.Namespace()).Update(machineDeployment)
+func (r machineDeploymentScalableResource) SetSize(nreplicas int32) error {
+	err := r.controller.setReplicas(r.controller.resources.machineDeployments, r.Namespace(), r.Name(), nreplicas)
+	registerResize(r.ID(), r.Replicas(), nreplicas, err)
+	r.controller.recordResize(r.controller.resources.machineDeployments, "MachineDeployment", r.machineDeployment.ObjectMeta, r.Replicas(), nreplicas, err)
 	if err != nil {
 		return fmt.Errorf("unable to update number of replicas of machineDeployment %q: %v", r.ID(), err)
 	}
@@ -96,13 +145,12 @@ func (r machineDeploymentScalableResource) SetSize(nreplicas int32) error {
 }
 
 func newMachineDeploymentScalableResource(controller *machineController, machineDeployment *v1beta1.MachineDeployment) (*machineDeploymentScalableResource, error) {
-	minSize, maxSize, err := parseScalingBounds(machineDeployment.Annotations)
+	minSize, maxSize, err := controller.scalingBounds("MachineDeployment", machineDeployment.ObjectMeta)
 	if err != nil {
-		return nil, fmt.Errorf("error validating min/max annotations: %v", err)
+		return nil, fmt.Errorf("error validating scaling bounds: %v", err)
 	}
 
 	return &machineDeploymentScalableResource{
-		machineapiClient:  controller.clusterClientset.MachineV1beta1(),
 		controller:        controller,
 		machineDeployment: machineDeployment,
 		maxSize:           maxSize,
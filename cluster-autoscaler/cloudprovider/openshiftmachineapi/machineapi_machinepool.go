@@ -0,0 +1,327 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+const (
+	machinePoolProviderIDIndex = "openshiftmachineapi-machinePoolProviderIDIndex"
+)
+
+var (
+	// machinePoolAPIGroups are the API groups the MachinePools of
+	// upstream cluster-api are served in, by order of preference.
+	// Their types are not vendored, so they are read as
+	// unstructured objects.
+	machinePoolAPIGroups = []string{clusterAPIGroup, "exp." + clusterAPIGroup}
+)
+
+// discoverMachinePoolResource returns the MachinePool resource of the
+// preferred version of the first of machinePoolAPIGroups serving it,
+// or an empty resource if there is none.
+func discoverMachinePoolResource(client discovery.DiscoveryInterface, groups *metav1.APIGroupList) schema.GroupVersionResource {
+	for _, name := range machinePoolAPIGroups {
+		for _, group := range groups.Groups {
+			if group.Name != name {
+				continue
+			}
+			resources, err := client.ServerResourcesForGroupVersion(group.PreferredVersion.GroupVersion)
+			if err != nil {
+				klog.V(4).Infof("cannot get the resources of %s: %v", group.PreferredVersion.GroupVersion, err)
+				continue
+			}
+			for _, resource := range resources.APIResources {
+				if resource.Name == "machinepools" {
+					groupVersion, err := schema.ParseGroupVersion(group.PreferredVersion.GroupVersion)
+					if err != nil {
+						continue
+					}
+					klog.V(1).Infof("using MachinePools of %s", groupVersion)
+					return groupVersion.WithResource(resource.Name)
+				}
+			}
+		}
+	}
+	return schema.GroupVersionResource{}
+}
+
+// newMachinePoolInformer returns an informer for the MachinePools of
+// resources in namespace, indexed by the provider IDs of their
+// instances.
+func newMachinePoolInformer(dynamicclient dynamic.Interface, resources machineAPIResources, namespace string) cache.SharedIndexInformer {
+	client := dynamicclient.Resource(resources.machinePools).Namespace(namespace)
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Watch(options)
+		},
+	}
+	return cache.NewSharedIndexInformer(listWatch, &unstructured.Unstructured{}, 0, cache.Indexers{
+		cache.NamespaceIndex:       cache.MetaNamespaceIndexFunc,
+		machinePoolProviderIDIndex: indexMachinePoolByProviderID,
+	})
+}
+
+func indexMachinePoolByProviderID(obj interface{}) ([]string, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return []string{}, nil
+	}
+	providerIDs, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "providerIDList")
+	result := []string{}
+	for _, providerID := range providerIDs {
+		if providerID != "" {
+			result = append(result, normalizedProviderID(providerID))
+		}
+	}
+	return result, nil
+}
+
+// findMachinePoolByProviderID returns the MachinePool whose
+// spec.providerIDList contains providerID, as normalized by
+// normalizedProviderID, or nil if there is none or MachinePools are
+// not enabled. A DeepCopy() of the object is returned on success.
+func (c *machineController) findMachinePoolByProviderID(providerID string) (*unstructured.Unstructured, error) {
+	if c.machinePoolInformer == nil {
+		return nil, nil
+	}
+
+	objs, err := c.machinePoolInformer.GetIndexer().ByIndex(machinePoolProviderIDIndex, normalizedProviderID(providerID))
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := len(objs); {
+	case n == 0:
+		return nil, nil
+	case n > 1:
+		return nil, fmt.Errorf("internal error; expected len==1, got %v", n)
+	}
+
+	machinePool, ok := objs[0].(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("internal error; unexpected type %T", objs[0])
+	}
+
+	return machinePool.DeepCopy(), nil
+}
+
+func (c *machineController) machinePoolNodeGroups() ([]*nodegroup, error) {
+	if c.machinePoolInformer == nil {
+		return nil, nil
+	}
+
+	var nodegroups []*nodegroup
+
+	for _, obj := range c.machinePoolInformer.GetStore().List() {
+		machinePool, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("internal error; unexpected type %T", obj)
+		}
+		ng, err := newNodegroupFromMachinePool(c, machinePool.DeepCopy())
+		if err != nil {
+			return nil, err
+		}
+		if ng.isAutoscaled() {
+			nodegroups = append(nodegroups, ng)
+		}
+	}
+
+	return nodegroups, nil
+}
+
+// machinePoolScalableResource is a MachinePool of upstream
+// cluster-api. Its instances have no machines, its nodes are found
+// using its spec.providerIDList and it is scaled using its scale
+// subresource.
+type machinePoolScalableResource struct {
+	controller  *machineController
+	machinePool *unstructured.Unstructured
+	maxSize     int
+	minSize     int
+}
+
+var _ scalableResource = (*machinePoolScalableResource)(nil)
+
+func (r machinePoolScalableResource) ID() string {
+	return path.Join(r.Namespace(), r.Name())
+}
+
+func (r machinePoolScalableResource) MaxSize() int {
+	return r.maxSize
+}
+
+func (r machinePoolScalableResource) MinSize() int {
+	return r.minSize
+}
+
+func (r machinePoolScalableResource) Name() string {
+	return r.machinePool.GetName()
+}
+
+func (r machinePoolScalableResource) Namespace() string {
+	return r.machinePool.GetNamespace()
+}
+
+func (r machinePoolScalableResource) Nodes() ([]string, error) {
+	providerIDs, _, err := unstructured.NestedStringSlice(r.machinePool.Object, "spec", "providerIDList")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.providerIDList of machinepool %q: %v", r.ID(), err)
+	}
+
+	var nodes []string
+
+	for _, providerID := range providerIDs {
+		node, err := r.controller.findNodeByProviderID(providerID)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			nodes = append(nodes, node.Spec.ProviderID)
+		}
+	}
+
+	klog.V(4).Infof("nodegroup %s has nodes %v", r.Name(), nodes)
+
+	return nodes, nil
+}
+
+func (r machinePoolScalableResource) Replicas() int32 {
+	replicas, found, err := unstructured.NestedInt64(r.machinePool.Object, "spec", "replicas")
+	if err != nil || !found {
+		return 0
+	}
+	return int32(replicas)
+}
+
+func (r machinePoolScalableResource) Annotations() map[string]string {
+	return translateKeys(r.machinePool.GetAnnotations(), machinePoolInternalKey)
+}
+
+func (r machinePoolScalableResource) Labels() map[string]string {
+	return translateKeys(r.machinePool.GetLabels(), machinePoolInternalKey)
+}
+
+// MachineSpec returns the labels of the template of the machine pool.
+// The spec of the upstream cluster-api machines has none of the other
+// fields of the machine.openshift.io one.
+func (r machinePoolScalableResource) MachineSpec() v1beta1.MachineSpec {
+	labels, _, _ := unstructured.NestedStringMap(r.machinePool.Object, "spec", "template", "metadata", "labels")
+	return v1beta1.MachineSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: translateKeys(labels, machinePoolInternalKey)},
+	}
+}
+
+// machinePoolInternalKey returns the key used by this provider for
+// a key of a MachinePool, which has the keys of upstream cluster-api
+// whatever the machine API served by the cluster.
+func machinePoolInternalKey(key string) string {
+	return internalKey(clusterAPIGroup, key)
+}
+
+// Problem returns the failure reason and message of the status of
+// the machine pool.
+func (r machinePoolScalableResource) Problem() (string, string, bool) {
+	reason, found, _ := unstructured.NestedString(r.machinePool.Object, "status", "failureReason")
+	if !found || reason == "" {
+		return "", "", false
+	}
+	message, _, _ := unstructured.NestedString(r.machinePool.Object, "status", "failureMessage")
+	return reason, message, true
+}
+
+func (r machinePoolScalableResource) SetSize(nreplicas int32) error {
+	err := r.controller.setReplicas(r.controller.resources.machinePools, r.Namespace(), r.Name(), nreplicas)
+	registerResize(r.ID(), r.Replicas(), nreplicas, err)
+	r.controller.recordResize(r.controller.resources.machinePools, "MachinePool", metav1.ObjectMeta{
+		Name:      r.Name(),
+		Namespace: r.Namespace(),
+		UID:       r.machinePool.GetUID(),
+	}, r.Replicas(), nreplicas, err)
+	if err != nil {
+		return fmt.Errorf("unable to update number of replicas of machinepool %q: %v", r.ID(), err)
+	}
+	return nil
+}
+
+func newMachinePoolScalableResource(controller *machineController, machinePool *unstructured.Unstructured) (*machinePoolScalableResource, error) {
+	minSize, maxSize, err := controller.scalingBounds("MachinePool", metav1.ObjectMeta{
+		Name:        machinePool.GetName(),
+		Namespace:   machinePool.GetNamespace(),
+		Labels:      machinePool.GetLabels(),
+		Annotations: machinePool.GetAnnotations(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error validating scaling bounds: %v", err)
+	}
+
+	return &machinePoolScalableResource{
+		controller:  controller,
+		machinePool: machinePool,
+		maxSize:     maxSize,
+		minSize:     minSize,
+	}, nil
+}
+
+func newNodegroupFromMachinePool(controller *machineController, machinePool *unstructured.Unstructured) (*nodegroup, error) {
+	scalableResource, err := newMachinePoolScalableResource(controller, machinePool)
+	if err != nil {
+		return nil, err
+	}
+	return &nodegroup{
+		machineController: controller,
+		scalableResource:  scalableResource,
+	}, nil
+}
+
+// nodeGroupForMachinePoolNode returns the node group of the
+// MachinePool of the node, or nil if it is not in one.
+func (c *machineController) nodeGroupForMachinePoolNode(node *corev1.Node) (*nodegroup, error) {
+	machinePool, err := c.findMachinePoolByProviderID(node.Spec.ProviderID)
+	if err != nil || machinePool == nil {
+		return nil, err
+	}
+
+	nodegroup, err := newNodegroupFromMachinePool(c, machinePool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build nodegroup for node %q: %v", node.Name, err)
+	}
+
+	if !nodegroup.isAutoscaled() {
+		return nil, nil
+	}
+
+	klog.V(4).Infof("node %q is in nodegroup %q", node.Name, machinePool.GetName())
+	return nodegroup, nil
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func makeMachinePool(namespace, name string, replicas int64, providerIDs []string, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("cluster.x-k8s.io/v1alpha3")
+	u.SetKind("MachinePool")
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetAnnotations(annotations)
+	ids := make([]interface{}, 0, len(providerIDs))
+	for _, providerID := range providerIDs {
+		ids = append(ids, providerID)
+	}
+	u.Object["spec"] = map[string]interface{}{
+		"replicas":       replicas,
+		"providerIDList": ids,
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"pool": name},
+			},
+		},
+	}
+	return u
+}
+
+func mustCreateMachinePoolTestController(t *testing.T, machinePool *unstructured.Unstructured, nodes ...*corev1.Node) (*machineController, *fakeDynamicClient, testControllerShutdownFunc) {
+	t.Helper()
+
+	resources := newMachineAPIResources(schema.GroupVersion{Group: clusterAPIGroup, Version: "v1alpha3"})
+	resources.machinePools = resources.machines.GroupVersion().WithResource("machinepools")
+	dynamicclient, err := newFakeDynamicClient(resources, machinePool)
+	if err != nil {
+		t.Fatalf("failed to create dynamic client: %v", err)
+	}
+
+	nodeObjects := make([]runtime.Object, 0, len(nodes))
+	for _, node := range nodes {
+		nodeObjects = append(nodeObjects, node)
+	}
+	kubeclient := fakekube.NewSimpleClientset(nodeObjects...)
+
+	controller, err := newMachineController(kubeclient, dynamicclient, resources, nil, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test controller: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	if err := controller.run(stopCh); err != nil {
+		t.Fatalf("failed to run controller: %v", err)
+	}
+
+	return controller, dynamicclient, func() {
+		close(stopCh)
+	}
+}
+
+func makeMachinePoolNodes(namespace, name string, n int) ([]*corev1.Node, []string) {
+	var nodes []*corev1.Node
+	var providerIDs []string
+	for i := 0; i < n; i++ {
+		node := &corev1.Node{
+			ObjectMeta: v1.ObjectMeta{
+				Name: fmt.Sprintf("%s-%s-node-%d", namespace, name, i),
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: fmt.Sprintf("%s-%s-nodeid-%d", namespace, name, i),
+			},
+		}
+		nodes = append(nodes, node)
+		providerIDs = append(providerIDs, node.Spec.ProviderID)
+	}
+	return nodes, providerIDs
+}
+
+func TestControllerMachinePoolNodeGroups(t *testing.T) {
+	nodes, providerIDs := makeMachinePoolNodes(testNamespace, "pool", 2)
+	machinePool := makeMachinePool(testNamespace, "pool", 2, providerIDs, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	controller, _, stop := mustCreateMachinePoolTestController(t, machinePool, nodes...)
+	defer stop()
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodegroups) != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", len(nodegroups))
+	}
+
+	ng := nodegroups[0]
+	if actual, expected := ng.Id(), testNamespace+"/pool"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if ng.MinSize() != 1 || ng.MaxSize() != 10 {
+		t.Errorf("expected min 1 and max 10, got min %d and max %d", ng.MinSize(), ng.MaxSize())
+	}
+	if size, _ := ng.TargetSize(); size != 2 {
+		t.Errorf("expected target size 2, got %d", size)
+	}
+
+	instances, err := ng.Nodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instances) != len(providerIDs) {
+		t.Fatalf("expected %d nodes, got %d", len(providerIDs), len(instances))
+	}
+	for i := range instances {
+		if instances[i].Id != providerIDs[i] {
+			t.Errorf("expected %q, got %q", providerIDs[i], instances[i].Id)
+		}
+	}
+
+	if labels := ng.scalableResource.MachineSpec().Labels; labels["pool"] != "pool" {
+		t.Errorf("expected the template labels, got %v", labels)
+	}
+
+	for _, node := range nodes {
+		ng, err := controller.nodeGroupForNode(node)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ng == nil {
+			t.Fatalf("expected a nodegroup for node %q", node.Name)
+		}
+		if actual, expected := ng.Id(), testNamespace+"/pool"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+	}
+
+	unknown := &corev1.Node{Spec: corev1.NodeSpec{ProviderID: "unknown"}}
+	if ng, err := controller.nodeGroupForNode(unknown); err != nil || ng != nil {
+		t.Errorf("expected no nodegroup and no error, got %v and %v", ng, err)
+	}
+
+	if err := ng.DeleteNodes(nodes[:1]); err == nil {
+		t.Error("expected an error deleting nodes of a machinepool")
+	}
+
+	provider, err := newProvider(ProviderName, &cloudprovider.ResourceLimiter{}, controller)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unremovable, reason, err := provider.(cloudprovider.UnremovableNodeCloudProvider).IsNodeUnremovable(nodes[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !unremovable || reason == "" {
+		t.Errorf("expected the node of the machinepool to be unremovable, got %v %q", unremovable, reason)
+	}
+}
+
+func TestControllerMachinePoolSetSize(t *testing.T) {
+	nodes, providerIDs := makeMachinePoolNodes(testNamespace, "pool", 2)
+	machinePool := makeMachinePool(testNamespace, "pool", 2, providerIDs, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "3",
+	})
+
+	controller, dynamicclient, stop := mustCreateMachinePoolTestController(t, machinePool, nodes...)
+	defer stop()
+
+	replicas := func() int64 {
+		u, err := dynamicclient.Resource(controller.resources.machinePools).Namespace(testNamespace).Get("pool", v1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		replicas, _, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		return replicas
+	}
+
+	// The nodegroup is looked up again after each update, once
+	// the informer has seen it.
+	nodegroup := func(expectedReplicas int32) *nodegroup {
+		var ng *nodegroup
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			var err error
+			ng, err = controller.nodeGroupForNode(nodes[0])
+			if err != nil {
+				return false, err
+			}
+			return ng != nil && ng.scalableResource.Replicas() == expectedReplicas, nil
+		}); err != nil {
+			t.Fatalf("nodegroup with %d replicas not found: %v", expectedReplicas, err)
+		}
+		return ng
+	}
+
+	if err := nodegroup(2).IncreaseSize(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := replicas(); actual != 3 {
+		t.Errorf("expected 3 replicas, got %d", actual)
+	}
+
+	if err := nodegroup(3).IncreaseSize(1); err == nil {
+		t.Error("expected an error increasing the size above the maximum")
+	}
+
+	if err := nodegroup(3).DecreaseTargetSize(-1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := replicas(); actual != 2 {
+		t.Errorf("expected 2 replicas, got %d", actual)
+	}
+
+	if err := nodegroup(2).DecreaseTargetSize(-1); err == nil {
+		t.Error("expected an error decreasing the target size below the number of nodes")
+	}
+	if actual := replicas(); actual != 2 {
+		t.Errorf("expected 2 replicas, got %d", actual)
+	}
+}
+
+func TestControllerWithoutMachinePools(t *testing.T) {
+	controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 1, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}))
+	defer stop()
+
+	if controller.machinePoolInformer != nil {
+		t.Error("expected no machinepool informer")
+	}
+	nodegroups, err := controller.machinePoolNodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodegroups) != 0 {
+		t.Errorf("expected no nodegroups, got %d", len(nodegroups))
+	}
+}
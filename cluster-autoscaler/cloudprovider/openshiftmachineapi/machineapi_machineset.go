@@ -21,17 +21,14 @@ import (
 	"path"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
-	machinev1beta1 "github.com/openshift/cluster-api/pkg/client/clientset_generated/clientset/typed/machine/v1beta1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 )
 
 type machineSetScalableResource struct {
-	machineapiClient machinev1beta1.MachineV1beta1Interface
-	controller       *machineController
-	machineSet       *v1beta1.MachineSet
-	maxSize          int
-	minSize          int
+	controller *machineController
+	machineSet *v1beta1.MachineSet
+	maxSize    int
+	minSize    int
 }
 
 var _ scalableResource = (*machineSetScalableResource)(nil)
@@ -57,23 +54,50 @@ func (r machineSetScalableResource) Namespace() string {
 }
 
 func (r machineSetScalableResource) Nodes() ([]string, error) {
-	return r.controller.machineSetNodeNames(r.machineSet)
+	nodes, err := r.controller.machineSetNodeNames(r.machineSet)
+	if err != nil {
+		return nil, err
+	}
+	updatePendingMachines(r.ID(), nodes)
+	return nodes, nil
 }
 
 func (r machineSetScalableResource) Replicas() int32 {
 	return pointer.Int32PtrDerefOr(r.machineSet.Spec.Replicas, 0)
 }
 
-func (r machineSetScalableResource) SetSize(nreplicas int32) error {
-	machineSet, err := r.machineapiClient.MachineSets(r.Namespace()).Get(r.Name(), metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("unable to get MachineSet %q: %v", r.ID(), err)
-	}
+func (r machineSetScalableResource) Annotations() map[string]string {
+	return r.machineSet.Annotations
+}
+
+func (r machineSetScalableResource) Labels() map[string]string {
+	return r.machineSet.Labels
+}
 
-	machineSet = machineSet.DeepCopy()
-	machineSet.Spec.Replicas = &nreplicas
+func (r machineSetScalableResource) MachineSpec() v1beta1.MachineSpec {
+	return r.machineSet.Spec.Template.Spec
+}
+
+func (r machineSetScalableResource) Problem() (string, string, bool) {
+	return machineSetProblem(r.machineSet)
+}
+
+// machineSetProblem returns the error reason and message of the
+// status of the machine set, ok is false if it has no error.
+func machineSetProblem(machineSet *v1beta1.MachineSet) (reason, message string, ok bool) {
+	if machineSet.Status.ErrorReason == nil {
+		return "", "", false
+	}
+	if machineSet.Status.ErrorMessage != nil {
+		message = *machineSet.Status.ErrorMessage
+	}
+	return string(*machineSet.Status.ErrorReason), message, true
+}
 
-	_, err = r.machineapiClient.MachineSets(r.Namespace()).Update(machineSet)
+func (r machineSetScalableResource) SetSize(nreplicas int32) error {
+	err := r.controller.setReplicas(r.controller.resources.machineSets, r.Namespace(), r.Name(), nreplicas)
+	registerResize(r.ID(), r.Replicas(), nreplicas, err)
+	r.controller.recordResize(r.controller.resources.machineSets, "MachineSet", r.machineSet.ObjectMeta, r.Replicas(), nreplicas, err)
 	if err != nil {
 		return fmt.Errorf("unable to update number of replicas of machineset %q: %v", r.ID(), err)
 	}
@@ -81,16 +105,15 @@ func (r machineSetScalableResource) SetSize(nreplicas int32) error {
 }
 
 func newMachineSetScalableResource(controller *machineController, machineSet *v1beta1.MachineSet) (*machineSetScalableResource, error) {
-	minSize, maxSize, err := parseScalingBounds(machineSet.Annotations)
+	minSize, maxSize, err := controller.scalingBounds("MachineSet", machineSet.ObjectMeta)
 	if err != nil {
-		return nil, fmt.Errorf("error validating min/max annotations: %v", err)
+		return nil, fmt.Errorf("error validating scaling bounds: %v", err)
 	}
 
 	return &machineSetScalableResource{
-		machineapiClient: controller.clusterClientset.MachineV1beta1(),
-		controller:       controller,
-		machineSet:       machineSet,
-		maxSize:          maxSize,
-		minSize:          minSize,
+		controller: controller,
+		machineSet: machineSet,
+		maxSize:    maxSize,
+		minSize:    minSize,
 	}, nil
 }
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/common"
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+const (
+	machinePhaseFailed   = "Failed"
+	machinePhaseDeleting = "Deleting"
+)
+
+// outOfResourcesMessages are the substrings, in lower case, of the
+// error messages actuators report when a machine could not be
+// created for lack of quota or capacity in the cloud.
+var outOfResourcesMessages = []string{
+	"quota",
+	"capacity",
+	"insufficient",
+}
+
+// machineInstanceStatus returns the status of the instance of a
+// machine, or nil if the machine is running. hasNode tells whether
+// the machine produced a node: only the errors of machines that did
+// not are creation errors, which make the core autoscaler back off
+// from the node group and delete the machine.
+func machineInstanceStatus(machine *v1beta1.Machine, hasNode bool) *cloudprovider.InstanceStatus {
+	var phase string
+	if machine.Status.Phase != nil {
+		phase = *machine.Status.Phase
+	}
+
+	if machine.DeletionTimestamp != nil || phase == machinePhaseDeleting {
+		return &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceDeleting,
+		}
+	}
+
+	if hasNode {
+		return nil
+	}
+
+	status := &cloudprovider.InstanceStatus{
+		State: cloudprovider.InstanceCreating,
+	}
+
+	if machine.Status.ErrorReason == nil && machine.Status.ErrorMessage == nil && phase != machinePhaseFailed {
+		return status
+	}
+
+	errorInfo := &cloudprovider.InstanceErrorInfo{
+		ErrorClass: cloudprovider.OtherErrorClass,
+		ErrorCode:  machinePhaseFailed,
+	}
+	if machine.Status.ErrorMessage != nil {
+		errorInfo.ErrorMessage = *machine.Status.ErrorMessage
+	}
+	if machine.Status.ErrorReason != nil {
+		errorInfo.ErrorCode = string(*machine.Status.ErrorReason)
+	}
+	if isOutOfResourcesMachineError(machine.Status.ErrorReason, errorInfo.ErrorMessage) {
+		errorInfo.ErrorClass = cloudprovider.OutOfResourcesErrorClass
+	}
+	status.ErrorInfo = errorInfo
+
+	return status
+}
+
+func isOutOfResourcesMachineError(reason *common.MachineStatusError, message string) bool {
+	if reason != nil && *reason == common.InsufficientResourcesMachineError {
+		return true
+	}
+	if reason != nil && *reason != common.CreateMachineError {
+		return false
+	}
+	message = strings.ToLower(message)
+	for _, s := range outOfResourcesMessages {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/common"
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/utils/pointer"
+)
+
+func TestMachineInstanceStatus(t *testing.T) {
+	errorReason := func(reason common.MachineStatusError) *common.MachineStatusError {
+		return &reason
+	}
+
+	for _, tc := range []struct {
+		description string
+		status      v1beta1.MachineStatus
+		deleted     bool
+		hasNode     bool
+		expected    *cloudprovider.InstanceStatus
+	}{{
+		description: "running",
+		status:      v1beta1.MachineStatus{Phase: pointer.StringPtr("Running")},
+		hasNode:     true,
+	}, {
+		description: "provisioning",
+		status:      v1beta1.MachineStatus{Phase: pointer.StringPtr("Provisioning")},
+		expected:    &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating},
+	}, {
+		description: "deleting phase",
+		status:      v1beta1.MachineStatus{Phase: pointer.StringPtr(machinePhaseDeleting)},
+		hasNode:     true,
+		expected:    &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting},
+	}, {
+		description: "deleted",
+		deleted:     true,
+		expected:    &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting},
+	}, {
+		description: "insufficient resources",
+		status: v1beta1.MachineStatus{
+			ErrorReason:  errorReason(common.InsufficientResourcesMachineError),
+			ErrorMessage: pointer.StringPtr("no m5.large left"),
+		},
+		expected: &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+				ErrorCode:    string(common.InsufficientResourcesMachineError),
+				ErrorMessage: "no m5.large left",
+			},
+		},
+	}, {
+		description: "create error with quota message",
+		status: v1beta1.MachineStatus{
+			ErrorReason:  errorReason(common.CreateMachineError),
+			ErrorMessage: pointer.StringPtr("CPU Quota exceeded in region"),
+			Phase:        pointer.StringPtr(machinePhaseFailed),
+		},
+		expected: &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+				ErrorCode:    string(common.CreateMachineError),
+				ErrorMessage: "CPU Quota exceeded in region",
+			},
+		},
+	}, {
+		description: "invalid configuration",
+		status: v1beta1.MachineStatus{
+			ErrorReason:  errorReason(common.InvalidConfigurationMachineError),
+			ErrorMessage: pointer.StringPtr("unknown capacity reservation"),
+		},
+		expected: &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OtherErrorClass,
+				ErrorCode:    string(common.InvalidConfigurationMachineError),
+				ErrorMessage: "unknown capacity reservation",
+			},
+		},
+	}, {
+		description: "failed phase only",
+		status:      v1beta1.MachineStatus{Phase: pointer.StringPtr(machinePhaseFailed)},
+		expected: &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass: cloudprovider.OtherErrorClass,
+				ErrorCode:  machinePhaseFailed,
+			},
+		},
+	}, {
+		description: "errors of machines with a node",
+		status: v1beta1.MachineStatus{
+			ErrorReason:  errorReason(common.UpdateMachineError),
+			ErrorMessage: pointer.StringPtr("insufficient permissions"),
+		},
+		hasNode: true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			machine := &v1beta1.Machine{Status: tc.status}
+			if tc.deleted {
+				machine.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			}
+			if actual := machineInstanceStatus(machine, tc.hasNode); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNodeGroupNodesWithFailedMachine(t *testing.T) {
+	testConfig := createMachineSetTestConfig(testNamespace, 2, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	// The first machine never got a node because of a quota error.
+	failed := testConfig.machines[0].DeepCopy()
+	failed.Spec.ProviderID = nil
+	failed.Status.NodeRef = nil
+	reason := common.InsufficientResourcesMachineError
+	failed.Status.ErrorReason = &reason
+	failed.Status.ErrorMessage = pointer.StringPtr("quota exceeded")
+	if err := controller.machineInformer.GetStore().Update(failed); err != nil {
+		t.Fatalf("unexpected error updating machine, got %v", err)
+	}
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := len(nodegroups); l != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", l)
+	}
+
+	instances, err := nodegroups[0].Nodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := len(instances); l != 2 {
+		t.Fatalf("expected 2 instances, got %d", l)
+	}
+
+	for _, instance := range instances {
+		if instance.Id != pendingMachineProviderID(failed) {
+			if instance.Status != nil {
+				t.Errorf("expected no status for running instance %q, got %+v", instance.Id, instance.Status)
+			}
+			continue
+		}
+		if instance.Status == nil || instance.Status.State != cloudprovider.InstanceCreating || instance.Status.ErrorInfo == nil {
+			t.Fatalf("expected instance %q to be creating with an error, got %+v", instance.Id, instance.Status)
+		}
+		if instance.Status.ErrorInfo.ErrorClass != cloudprovider.OutOfResourcesErrorClass {
+			t.Errorf("expected an out of resources error, got %+v", instance.Status.ErrorInfo)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	caNamespace = "cluster_autoscaler"
+)
+
+var (
+	/**** Metrics related to machine provisioning ****/
+	provisioningBackoffCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_provisioning_backoffs_total",
+			Help:      "Number of times a node group was backed off because machines of its scale-up failed to provision.",
+		}, []string{"node_group"},
+	)
+
+	backedOffNodeGroupsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_node_group_backed_off",
+			Help:      "Whether a node group is backed off because machines of its last scale-up failed to provision.",
+		}, []string{"node_group"},
+	)
+
+	/**** Metrics related to node group resizing ****/
+	scaleUpCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_scale_up_total",
+			Help:      "Number of machines added to node groups by increasing their replica count.",
+		}, []string{"node_group"},
+	)
+
+	scaleDownCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_scale_down_total",
+			Help:      "Number of machines removed from node groups by decreasing their replica count.",
+		}, []string{"node_group"},
+	)
+
+	apiErrorsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_api_errors_total",
+			Help:      "Number of machine API calls that failed while resizing node groups.",
+		}, []string{"node_group"},
+	)
+
+	updateRejectionsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_update_rejections_total",
+			Help:      "Number of resizes of node groups rejected by admission webhooks.",
+		}, []string{"node_group"},
+	)
+
+	pendingMachinesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_pending_machines",
+			Help:      "Number of machines of node groups which have not produced a node yet.",
+		}, []string{"node_group"},
+	)
+
+	healthyNodeGroupsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "machineapi_node_group_healthy",
+			Help:      "Whether a node group has no terminal error reported by the machine controllers.",
+		}, []string{"node_group"},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+// RegisterMetrics registers all machine API metrics. It can safely be
+// called more than once, e.g. when the provider is rebuilt.
+func RegisterMetrics() {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(provisioningBackoffCounter)
+		prometheus.MustRegister(backedOffNodeGroupsGauge)
+		prometheus.MustRegister(scaleUpCounter)
+		prometheus.MustRegister(scaleDownCounter)
+		prometheus.MustRegister(apiErrorsCounter)
+		prometheus.MustRegister(updateRejectionsCounter)
+		prometheus.MustRegister(pendingMachinesGauge)
+		prometheus.MustRegister(healthyNodeGroupsGauge)
+	})
+}
+
+// registerResize records the resize of the node group id from
+// replicas to nreplicas, or the error of the machine API it failed
+// with. Rejections by admission webhooks are not counted as API
+// errors.
+func registerResize(id string, replicas, nreplicas int32, err error) {
+	if _, rejected := admissionRejection(err); rejected {
+		updateRejectionsCounter.WithLabelValues(id).Inc()
+		return
+	}
+	switch {
+	case err != nil:
+		registerAPIError(id)
+	case nreplicas > replicas:
+		scaleUpCounter.WithLabelValues(id).Add(float64(nreplicas - replicas))
+	case nreplicas < replicas:
+		scaleDownCounter.WithLabelValues(id).Add(float64(replicas - nreplicas))
+	}
+}
+
+// registerAPIError records a failed machine API call made to resize
+// the node group id.
+func registerAPIError(id string) {
+	apiErrorsCounter.WithLabelValues(id).Inc()
+}
+
+// updatePendingMachines records the number of placeholder provider
+// IDs among the nodes of the node group id, which stand for the
+// machines that have not produced a node yet.
+func updatePendingMachines(id string, nodes []string) {
+	pending := 0
+	for _, node := range nodes {
+		if isPendingMachineProviderID(node) {
+			pending++
+		}
+	}
+	pendingMachinesGauge.WithLabelValues(id).Set(float64(pending))
+}
+
+// updateNodeGroupHealth records whether the node group id is healthy.
+func updateNodeGroupHealth(id string, healthy bool) {
+	if healthy {
+		healthyNodeGroupsGauge.WithLabelValues(id).Set(1)
+	} else {
+		healthyNodeGroupsGauge.WithLabelValues(id).Set(0)
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newNamespacedInformer returns an informer for the objects of
+// namespaces, made of the informers newInformer returns for each of
+// them. The objects of all the namespaces are watched if namespaces
+// is empty.
+func newNamespacedInformer(namespaces []string, newInformer func(namespace string) cache.SharedIndexInformer) cache.SharedIndexInformer {
+	switch len(namespaces) {
+	case 0:
+		return newInformer(metav1.NamespaceAll)
+	case 1:
+		return newInformer(namespaces[0])
+	}
+
+	informer := &multiNamespaceInformer{
+		namespaces: namespaces,
+		informers:  make(map[string]cache.SharedIndexInformer, len(namespaces)),
+	}
+	for _, namespace := range namespaces {
+		informer.informers[namespace] = newInformer(namespace)
+	}
+	return informer
+}
+
+// multiNamespaceInformer is an informer made of one informer per
+// namespace, so that objects are only listed and watched in the
+// namespaces the autoscaler is given access to. Its store and
+// indexer read from those of all the informers and write to that of
+// the namespace of the object.
+type multiNamespaceInformer struct {
+	namespaces []string
+	informers  map[string]cache.SharedIndexInformer
+}
+
+var _ cache.SharedIndexInformer = (*multiNamespaceInformer)(nil)
+
+func (m *multiNamespaceInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	for _, namespace := range m.namespaces {
+		m.informers[namespace].AddEventHandler(handler)
+	}
+}
+
+func (m *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) {
+	for _, namespace := range m.namespaces {
+		m.informers[namespace].AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+}
+
+func (m *multiNamespaceInformer) GetStore() cache.Store {
+	return m.GetIndexer()
+}
+
+// GetController returns nil, the informers of the namespaces have
+// a controller each.
+func (m *multiNamespaceInformer) GetController() cache.Controller {
+	return nil
+}
+
+func (m *multiNamespaceInformer) Run(stopCh <-chan struct{}) {
+	for _, namespace := range m.namespaces {
+		go m.informers[namespace].Run(stopCh)
+	}
+	<-stopCh
+}
+
+func (m *multiNamespaceInformer) HasSynced() bool {
+	for _, namespace := range m.namespaces {
+		if !m.informers[namespace].HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSyncResourceVersion returns "", resource versions are only
+// meaningful for the informer of a namespace.
+func (m *multiNamespaceInformer) LastSyncResourceVersion() string {
+	return ""
+}
+
+func (m *multiNamespaceInformer) AddIndexers(indexers cache.Indexers) error {
+	for _, namespace := range m.namespaces {
+		if err := m.informers[namespace].AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceInformer) GetIndexer() cache.Indexer {
+	return multiNamespaceIndexer{m}
+}
+
+// multiNamespaceIndexer is the indexer of a multiNamespaceInformer.
+type multiNamespaceIndexer struct {
+	informer *multiNamespaceInformer
+}
+
+var _ cache.Indexer = multiNamespaceIndexer{}
+
+// indexerOf returns the indexer of the namespace of obj.
+func (m multiNamespaceIndexer) indexerOf(obj interface{}) (cache.Indexer, error) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, err
+	}
+	return m.indexerOfKey(key)
+}
+
+// indexerOfKey returns the indexer of the namespace of the object
+// with the given key.
+func (m multiNamespaceIndexer) indexerOfKey(key string) (cache.Indexer, error) {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	informer, found := m.informer.informers[namespace]
+	if !found {
+		return nil, fmt.Errorf("namespace %q is not watched", namespace)
+	}
+	return informer.GetIndexer(), nil
+}
+
+func (m multiNamespaceIndexer) each(f func(indexer cache.Indexer) error) error {
+	for _, namespace := range m.informer.namespaces {
+		if err := f(m.informer.informers[namespace].GetIndexer()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiNamespaceIndexer) Add(obj interface{}) error {
+	indexer, err := m.indexerOf(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Add(obj)
+}
+
+func (m multiNamespaceIndexer) Update(obj interface{}) error {
+	indexer, err := m.indexerOf(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Update(obj)
+}
+
+func (m multiNamespaceIndexer) Delete(obj interface{}) error {
+	indexer, err := m.indexerOf(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Delete(obj)
+}
+
+func (m multiNamespaceIndexer) List() []interface{} {
+	var result []interface{}
+	m.each(func(indexer cache.Indexer) error {
+		result = append(result, indexer.List()...)
+		return nil
+	})
+	return result
+}
+
+func (m multiNamespaceIndexer) ListKeys() []string {
+	var result []string
+	m.each(func(indexer cache.Indexer) error {
+		result = append(result, indexer.ListKeys()...)
+		return nil
+	})
+	return result
+}
+
+func (m multiNamespaceIndexer) Get(obj interface{}) (interface{}, bool, error) {
+	indexer, err := m.indexerOf(obj)
+	if err != nil {
+		return nil, false, nil
+	}
+	return indexer.Get(obj)
+}
+
+func (m multiNamespaceIndexer) GetByKey(key string) (interface{}, bool, error) {
+	indexer, err := m.indexerOfKey(key)
+	if err != nil {
+		return nil, false, nil
+	}
+	return indexer.GetByKey(key)
+}
+
+// Replace replaces the objects of all the namespaces with list.
+func (m multiNamespaceIndexer) Replace(list []interface{}, resourceVersion string) error {
+	objs := make(map[string][]interface{}, len(m.informer.namespaces))
+	for _, obj := range list {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return err
+		}
+		namespace, _, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return err
+		}
+		if _, found := m.informer.informers[namespace]; !found {
+			return fmt.Errorf("namespace %q is not watched", namespace)
+		}
+		objs[namespace] = append(objs[namespace], obj)
+	}
+	for _, namespace := range m.informer.namespaces {
+		if err := m.informer.informers[namespace].GetIndexer().Replace(objs[namespace], resourceVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiNamespaceIndexer) Resync() error {
+	return m.each(func(indexer cache.Indexer) error {
+		return indexer.Resync()
+	})
+}
+
+func (m multiNamespaceIndexer) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	var result []interface{}
+	err := m.each(func(indexer cache.Indexer) error {
+		objs, err := indexer.Index(indexName, obj)
+		result = append(result, objs...)
+		return err
+	})
+	return result, err
+}
+
+func (m multiNamespaceIndexer) IndexKeys(indexName, indexKey string) ([]string, error) {
+	var result []string
+	err := m.each(func(indexer cache.Indexer) error {
+		keys, err := indexer.IndexKeys(indexName, indexKey)
+		result = append(result, keys...)
+		return err
+	})
+	return result, err
+}
+
+func (m multiNamespaceIndexer) ListIndexFuncValues(indexName string) []string {
+	values := sets.NewString()
+	m.each(func(indexer cache.Indexer) error {
+		values.Insert(indexer.ListIndexFuncValues(indexName)...)
+		return nil
+	})
+	return values.List()
+}
+
+func (m multiNamespaceIndexer) ByIndex(indexName, indexKey string) ([]interface{}, error) {
+	var result []interface{}
+	err := m.each(func(indexer cache.Indexer) error {
+		objs, err := indexer.ByIndex(indexName, indexKey)
+		result = append(result, objs...)
+		return err
+	})
+	return result, err
+}
+
+func (m multiNamespaceIndexer) GetIndexers() cache.Indexers {
+	return m.informer.informers[m.informer.namespaces[0]].GetIndexer().GetIndexers()
+}
+
+func (m multiNamespaceIndexer) AddIndexers(indexers cache.Indexers) error {
+	return m.informer.AddIndexers(indexers)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+)
+
+func TestControllerNodeGroupsInNamespaces(t *testing.T) {
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	test := func(t *testing.T, namespaces []string, newTestConfig func(namespace string) *testConfig) {
+		var testConfigs []*testConfig
+		for _, namespace := range []string{"namespace-a", "namespace-b", "namespace-c"} {
+			testConfigs = append(testConfigs, newTestConfig(namespace))
+		}
+
+		controller, stop := mustCreateNamespacedTestController(t, namespaces, testConfigs...)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != len(namespaces) {
+			t.Fatalf("expected %d nodegroups, got %d", len(namespaces), l)
+		}
+		watched := map[string]bool{}
+		for _, namespace := range namespaces {
+			watched[namespace] = true
+		}
+		for _, ng := range nodegroups {
+			if !watched[ng.Namespace()] {
+				t.Errorf("unexpected nodegroup %q in namespace %q", ng.Id(), ng.Namespace())
+			}
+		}
+
+		for _, testConfig := range testConfigs {
+			namespace := testConfig.spec.namespace
+			ng, err := controller.nodeGroupForNode(testConfig.nodes[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if watched[namespace] && ng == nil {
+				t.Errorf("expected a nodegroup for the node in namespace %q", namespace)
+			}
+			if !watched[namespace] && ng != nil {
+				t.Errorf("unexpected nodegroup %q for the node in namespace %q", ng.Id(), namespace)
+			}
+
+			// The store of the informer of a single namespace
+			// takes objects of any namespace.
+			if len(namespaces) == 1 {
+				continue
+			}
+			err = controller.machineInformer.GetStore().Update(testConfig.machines[0].DeepCopy())
+			if watched[namespace] && err != nil {
+				t.Errorf("unexpected error updating machine in namespace %q: %v", namespace, err)
+			}
+			if !watched[namespace] && err == nil {
+				t.Errorf("expected an error updating machine in namespace %q", namespace)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		description string
+		namespaces  []string
+	}{{
+		description: "one namespace",
+		namespaces:  []string{"namespace-b"},
+	}, {
+		description: "two namespaces",
+		namespaces:  []string{"namespace-a", "namespace-c"},
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Run("MachineSet", func(t *testing.T) {
+				test(t, tc.namespaces, func(namespace string) *testConfig {
+					return createMachineSetTestConfig(namespace, 1, annotations)
+				})
+			})
+
+			t.Run("MachineDeployment", func(t *testing.T) {
+				test(t, tc.namespaces, func(namespace string) *testConfig {
+					return createMachineDeploymentTestConfig(namespace, 1, annotations)
+				})
+			})
+		})
+	}
+}
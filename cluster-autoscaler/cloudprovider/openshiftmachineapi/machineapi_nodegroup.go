@@ -21,26 +21,35 @@ import (
 	"time"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
-	machinev1beta1 "github.com/openshift/cluster-api/pkg/client/clientset_generated/clientset/typed/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	"k8s.io/klog"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
 
 const (
-	machineDeleteAnnotationKey = "machine.openshift.io/cluster-api-delete-machine"
-	machineAnnotationKey       = "machine.openshift.io/machine"
+	machineDeleteAnnotationKey = machineAPIGroup + "/cluster-api-delete-machine"
+	machineAnnotationKey       = machineAPIGroup + "/machine"
 	debugFormat                = "%s (min: %d, max: %d, replicas: %d)"
 )
 
 type nodegroup struct {
-	machineapiClient  machinev1beta1.MachineV1beta1Interface
 	machineController *machineController
 	scalableResource  scalableResource
+	// proposed is true for the autoprovisioned node groups built
+	// by NewNodeGroup, which don't exist until they are created.
+	proposed bool
 }
 
 var _ cloudprovider.NodeGroup = (*nodegroup)(nil)
+var _ cloudprovider.IPCapacityNodeGroup = (*nodegroup)(nil)
+var _ cloudprovider.ProviderLimitedNodeGroup = (*nodegroup)(nil)
+var _ cloudprovider.HealthReportingNodeGroup = (*nodegroup)(nil)
+var _ cloudprovider.OptionsNodeGroup = (*nodegroup)(nil)
+var _ cloudprovider.SizeLimitsNodeGroup = (*nodegroup)(nil)
 
 func (ng *nodegroup) Name() string {
 	return ng.scalableResource.Name()
@@ -58,40 +67,247 @@ func (ng *nodegroup) MaxSize() int {
 	return ng.scalableResource.MaxSize()
 }
 
+// AvailableIPs returns the number of free IP addresses advertised by
+// the infrastructure provider through the available IPs annotation.
+// ErrNotImplemented is returned if the annotation is not set.
+func (ng *nodegroup) AvailableIPs() (int, error) {
+	n, err := availableIPs(ng.scalableResource.Annotations())
+	if err == errMissingAvailableIPsAnnotation {
+		return 0, cloudprovider.ErrNotImplemented
+	}
+	return n, err
+}
+
+// GetOptions overrides the max node provision time, the readiness
+// gates and the startup taints of the defaults with the max node
+// provision time, readiness and startup taints annotations.
+// ErrNotImplemented is returned if none of them is set.
+func (ng *nodegroup) GetOptions(defaults cloudprovider.NodeGroupAutoscalingOptions) (*cloudprovider.NodeGroupAutoscalingOptions, error) {
+	annotations := ng.scalableResource.Annotations()
+	overridden := false
+
+	d, err := maxNodeProvisionTime(annotations)
+	switch {
+	case err == nil:
+		defaults.MaxNodeProvisionTime = d
+		overridden = true
+	case err != errMissingMaxNodeProvisionTimeAnnotation:
+		return nil, err
+	}
+	if conditions, found := annotationList(annotations, nodeGroupReadinessConditionsAnnotationKey); found {
+		defaults.ReadinessConditions = conditions
+		overridden = true
+	}
+	if labels, found := annotationList(annotations, nodeGroupReadinessLabelsAnnotationKey); found {
+		defaults.ReadinessLabels = labels
+		overridden = true
+	}
+	if taints, found := annotationList(annotations, nodeGroupStartupTaintsAnnotationKey); found {
+		defaults.StartupTaints = taints
+		overridden = true
+	}
+
+	if !overridden {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	return &defaults, nil
+}
+
+// ProviderMaxSize limits the node group to its current size while it
+// is backed off because machines of its last scale-up failed to
+// provision or its last scale-up was rejected by an admission
+// webhook, or while its MachineDeployment is rolling out.
+func (ng *nodegroup) ProviderMaxSize() (int, string, bool) {
+	if rollout, inProgress := ng.rolloutInProgress(); inProgress {
+		size, _ := ng.TargetSize()
+		return size, rollout, true
+	}
+	now := time.Now()
+	if until, message, rejected := ng.machineController.updateRejections.rejectedUntil(ng.Id(), true, now); rejected {
+		return int(ng.scalableResource.Replicas()), fmt.Sprintf("scale-up rejected, backed off until %s: %s", until.Format(time.RFC3339), message), true
+	}
+	until, failed, backedOff := ng.provisioningBackedOff(now)
+	if !backedOff {
+		return 0, "", false
+	}
+	return int(ng.scalableResource.Replicas()), fmt.Sprintf("%d machines failed to provision, backed off until %s", failed, until.Format(time.RFC3339)), true
+}
+
+// provisioningBackedOff updates the provisioning backoff of the node
+// group from its machines and returns the time until which it is
+// backed off and the number of machines that failed to provision,
+// and false if it is not backed off.
+func (ng *nodegroup) provisioningBackedOff(now time.Time) (time.Time, int, bool) {
+	backoff := ng.machineController.provisioningBackoff
+	failed, succeeded, err := ng.provisioningResults(backoff.lastScaleUp(ng.Id()))
+	if err != nil {
+		klog.Warningf("nodegroup %q: unable to check machine provisioning: %v", ng.Id(), err)
+	} else {
+		backoff.update(ng.Id(), failed, succeeded, now)
+	}
+	return backoff.backedOffUntil(ng.Id(), now)
+}
+
+// provisioningResults returns the number of machines of the node
+// group created since the given time which failed to provision, and
+// of those which got a node.
+func (ng *nodegroup) provisioningResults(since time.Time) (int, int, error) {
+	nodes, err := ng.scalableResource.Nodes()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	failed, succeeded := 0, 0
+	for _, node := range nodes {
+		machine, err := ng.machineController.findMachineByProviderID(node)
+		if err != nil {
+			return 0, 0, err
+		}
+		if machine == nil || machine.CreationTimestamp.Time.Before(since) {
+			continue
+		}
+		status := machineInstanceStatus(machine, !isPendingMachineProviderID(node))
+		switch {
+		case status == nil:
+			succeeded++
+		case status.State == cloudprovider.InstanceCreating && status.ErrorInfo != nil:
+			failed++
+		}
+	}
+	return failed, succeeded, nil
+}
+
+// rolloutInProgress returns a description of the rollout of the
+// MachineDeployment of the node group and true until it has settled.
+// The node group is not resized meanwhile, lest its replica count
+// fight the MachineDeployment controller replacing its machines.
+func (ng *nodegroup) rolloutInProgress() (string, bool) {
+	r, ok := ng.scalableResource.(*machineDeploymentScalableResource)
+	if !ok {
+		return "", false
+	}
+	return r.rolloutInProgress()
+}
+
 // TargetSize returns the current target size of the node group. It is
 // possible that the number of nodes in Kubernetes is different at the
 // moment but should be equal to Size() once everything stabilizes
 // (new nodes finish startup and registration or removed nodes are
 // deleted completely). Implementation required.
+//
+// The target size of a MachineDeployment rolling out is the number of
+// its machines reported in its status, which counts the old and the
+// new machines. The target size doesn't exceed the replicas set to
+// delete the machines whose deletion is in flight, which the
+// informers may not reflect yet.
 func (ng *nodegroup) TargetSize() (int, error) {
-	return int(ng.scalableResource.Replicas()), nil
+	if r, ok := ng.scalableResource.(*machineDeploymentScalableResource); ok {
+		if _, inProgress := r.rolloutInProgress(); inProgress && r.machineDeployment.Status.ObservedGeneration > 0 {
+			return int(r.machineDeployment.Status.Replicas), nil
+		}
+	}
+	return int(ng.replicas()), nil
+}
+
+// replicas returns the replicas of the scalable resource of the node
+// group, accounting for the deletions in flight the informers don't
+// reflect yet.
+func (ng *nodegroup) replicas() int32 {
+	return ng.machineController.machineDeletions.replicas(ng.Id(), ng.scalableResource.Replicas(), time.Now())
 }
 
 // IncreaseSize increases the size of the node group. To delete a node
 // you need to explicitly name it and use DeleteNode. This function
 // should wait until node group size is updated. Implementation
 // required.
+//
+// Node groups backed off because machines of their last scale-up
+// failed to provision or their last scale-up was rejected by an
+// admission webhook, or whose MachineDeployment is rolling out,
+// cannot be increased.
 func (ng *nodegroup) IncreaseSize(delta int) error {
 	if delta <= 0 {
 		return fmt.Errorf("size increase must be positive")
 	}
-	size := int(ng.scalableResource.Replicas())
+	if rollout, inProgress := ng.rolloutInProgress(); inProgress {
+		return fmt.Errorf("nodegroup %q is rolling out: %s", ng.Id(), rollout)
+	}
+	size := int(ng.replicas())
 	if size+delta > ng.MaxSize() {
 		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.MaxSize())
 	}
-	return ng.scalableResource.SetSize(int32(size + delta))
+	now := time.Now()
+	if err := ng.checkScaleUpRejected(now); err != nil {
+		return err
+	}
+	if until, failed, backedOff := ng.provisioningBackedOff(now); backedOff {
+		return fmt.Errorf("nodegroup %q is backed off until %s: %d machines failed to provision", ng.Id(), until.Format(time.RFC3339), failed)
+	}
+	if err := ng.scalableResource.SetSize(int32(size + delta)); err != nil {
+		return err
+	}
+	ng.machineController.machineDeletions.resized(ng.Id())
+	ng.machineController.provisioningBackoff.scaledUp(ng.Id(), now)
+	return nil
+}
+
+// checkScaleUpRejected returns an error if scale-ups of the node group
+// are backed off because the last one was rejected by an admission
+// webhook, so that they are not retried right away. Backed off
+// scale-downs are kept from draining nodes by nodeDeletionBlocked
+// instead.
+func (ng *nodegroup) checkScaleUpRejected(now time.Time) error {
+	until, message, rejected := ng.machineController.updateRejections.rejectedUntil(ng.Id(), true, now)
+	if !rejected {
+		return nil
+	}
+	return fmt.Errorf("nodegroup %q is backed off until %s: resize rejected: %s", ng.Id(), until.Format(time.RFC3339), message)
+}
+
+// nodeDeletionBlocked returns the reason why the nodes of the node
+// group cannot be deleted at the moment, and true if they cannot,
+// so that scale down skips them before draining them: the instances
+// of a MachinePool cannot be picked for deletion, a MachineDeployment
+// rolling out is not resized, and neither is a node group whose last
+// scale-down was rejected by an admission webhook until its backoff
+// expires.
+func (ng *nodegroup) nodeDeletionBlocked() (string, bool) {
+	if _, ok := ng.scalableResource.(*machinePoolScalableResource); ok {
+		return fmt.Sprintf("the instances of machinepool %s cannot be picked for deletion", ng.Id()), true
+	}
+	if rollout, inProgress := ng.rolloutInProgress(); inProgress {
+		return fmt.Sprintf("nodegroup %s is rolling out: %s", ng.Id(), rollout), true
+	}
+	if until, message, rejected := ng.machineController.updateRejections.rejectedUntil(ng.Id(), false, time.Now()); rejected {
+		return fmt.Sprintf("scale-down of nodegroup %s rejected, backed off until %s: %s", ng.Id(), until.Format(time.RFC3339), message), true
+	}
+	return "", false
 }
 
 // DeleteNodes deletes nodes from this node group. Error is returned
 // either on failure or if the given node doesn't belong to this node
 // group. This function should wait until node group size is updated.
 // Implementation required.
+//
+// The instances of a MachinePool cannot be picked for deletion, so
+// deleting the nodes of one always fails. The provider reports them
+// as unremovable, as well as the nodes of MachineDeployments rolling
+// out and of node groups backed off after a rejected scale-down, see
+// nodeDeletionBlocked.
 func (ng *nodegroup) DeleteNodes(nodes []*corev1.Node) error {
+	if _, ok := ng.scalableResource.(*machinePoolScalableResource); ok {
+		return fmt.Errorf("unable to delete nodes of machinepool %q: its instances cannot be picked", ng.Id())
+	}
+
 	// Step 1: Verify all nodes belong to this node group.
 	for _, node := range nodes {
 		actualNodeGroup, err := ng.machineController.nodeGroupForNode(node)
 		if err != nil {
-			return nil
+			return err
+		}
+
+		if actualNodeGroup == nil {
+			return fmt.Errorf("node %q doesn't belong to a known node group", node.Spec.ProviderID)
 		}
 
 		if actualNodeGroup.Id() != ng.Id() {
@@ -102,7 +318,7 @@ func (ng *nodegroup) DeleteNodes(nodes []*corev1.Node) error {
 	// Step 2: if deleting len(nodes) would make the replica count
 	// <= 0, then the request to delete that many nodes is bogus
 	// and we fail fast.
-	replicas := ng.scalableResource.Replicas()
+	replicas := ng.replicas()
 
 	if replicas-int32(len(nodes)) <= 0 {
 		return fmt.Errorf("unable to delete %d machines in %q, machine replicas are <= 0 ", len(nodes), ng.Id())
@@ -110,7 +326,11 @@ func (ng *nodegroup) DeleteNodes(nodes []*corev1.Node) error {
 
 	// Step 3: annotate the corresponding machine that it is a
 	// suitable candidate for deletion and drop the replica count
-	// by 1. Fail fast on any error.
+	// by 1, so that the MachineSet controller removes that machine
+	// rather than an arbitrary one. Machines already being deleted
+	// are not counted by the MachineSet controller anymore, so the
+	// replica count is left alone for them lest another machine be
+	// removed. Fail fast on any error.
 	for _, node := range nodes {
 		machine, err := ng.machineController.findMachineByProviderID(node.Spec.ProviderID)
 		if err != nil {
@@ -120,31 +340,30 @@ func (ng *nodegroup) DeleteNodes(nodes []*corev1.Node) error {
 			return fmt.Errorf("unknown machine for node %q", node.Spec.ProviderID)
 		}
 
-		machine = machine.DeepCopy()
-
-		if machine.Annotations == nil {
-			machine.Annotations = map[string]string{}
+		if machine.DeletionTimestamp != nil || ng.machineController.machineDeletions.inFlight(machine.UID, time.Now()) {
+			klog.V(4).Infof("machine %q of node %q is already being deleted", machine.Name, node.Spec.ProviderID)
+			continue
 		}
 
-		machine.Annotations[machineDeleteAnnotationKey] = time.Now().String()
-		machine, err = ng.machineapiClient.Machines(machine.Namespace).Update(machine)
-		if err != nil {
+		deleteTime := time.Now().String()
+		if err := ng.machineController.setMachineAnnotation(machine.Namespace, machine.Name, machineDeleteAnnotationKey, &deleteTime); err != nil {
+			registerAPIError(ng.Id())
 			return err
 		}
 
 		if err := ng.scalableResource.SetSize(int32(replicas - 1)); err != nil {
-			delete(machine.Annotations, machineDeleteAnnotationKey)
 			// Log errors as warnings from Update()
 			// because no action is taken even if the
 			// annotation persists until the replica count
 			// is modified during a deletion.
-			_, updateErr := ng.machineapiClient.Machines(machine.Namespace).Update(machine)
+			updateErr := ng.machineController.setMachineAnnotation(machine.Namespace, machine.Name, machineDeleteAnnotationKey, nil)
 			if updateErr != nil {
 				klog.Warningf("failed to delete annotation %q from machine %q: %v", machineDeleteAnnotationKey, machine.Name, updateErr)
 			}
 			return err
 		}
 
+		ng.machineController.machineDeletions.add(machine.UID, ng.Id(), ng.scalableResource.Replicas(), replicas-1, time.Now())
 		replicas--
 	}
 
@@ -161,6 +380,9 @@ func (ng *nodegroup) DecreaseTargetSize(delta int) error {
 	if delta >= 0 {
 		return fmt.Errorf("size decrease must be negative")
 	}
+	if rollout, inProgress := ng.rolloutInProgress(); inProgress {
+		return fmt.Errorf("nodegroup %q is rolling out: %s", ng.Id(), rollout)
+	}
 
 	size, err := ng.TargetSize()
 	if err != nil {
@@ -176,8 +398,20 @@ func (ng *nodegroup) DecreaseTargetSize(delta int) error {
 		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
 			size, delta, len(nodes))
 	}
+	if err := ng.scalableResource.SetSize(int32(size + delta)); err != nil {
+		return err
+	}
+	ng.machineController.machineDeletions.resized(ng.Id())
+	return nil
+}
 
-	return ng.scalableResource.SetSize(int32(size + delta))
+// Problem returns the terminal error the machine controllers report
+// reconciling the replicas of the scalable resource of the node
+// group, such as an invalid template of its machines.
+func (ng *nodegroup) Problem() (string, string, bool) {
+	reason, message, ok := ng.scalableResource.Problem()
+	updateNodeGroupHealth(ng.Id(), !ok)
+	return reason, message, ok
 }
 
 // Id returns an unique identifier of the node group.
@@ -191,6 +425,9 @@ func (ng *nodegroup) Debug() string {
 }
 
 // Nodes returns a list of all nodes that belong to this node group.
+// Machines that have not produced a node yet are returned as
+// placeholder instances which are being created, with the errors
+// their creation failed with if any.
 func (ng *nodegroup) Nodes() ([]cloudprovider.Instance, error) {
 	nodes, err := ng.scalableResource.Nodes()
 	if err != nil {
@@ -202,6 +439,23 @@ func (ng *nodegroup) Nodes() ([]cloudprovider.Instance, error) {
 		instances[i] = cloudprovider.Instance{
 			Id: nodes[i],
 		}
+
+		machine, err := ng.machineController.findMachineByProviderID(nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		if machine != nil {
+			instances[i].Status = machineInstanceStatus(machine, !isPendingMachineProviderID(nodes[i]))
+			if ng.machineController.machineDeletions.inFlight(machine.UID, time.Now()) {
+				instances[i].Status = &cloudprovider.InstanceStatus{
+					State: cloudprovider.InstanceDeleting,
+				}
+			}
+		} else if isPendingMachineProviderID(nodes[i]) {
+			instances[i].Status = &cloudprovider.InstanceStatus{
+				State: cloudprovider.InstanceCreating,
+			}
+		}
 	}
 
 	return instances, nil
@@ -215,35 +469,267 @@ func (ng *nodegroup) Nodes() ([]cloudprovider.Instance, error) {
 // allocatable information as well as all pods that are started on the
 // node by default, using manifest (most likely only kube-proxy).
 // Implementation optional.
+//
+// The capacity of the template node comes from the instance class
+// named by the instance class annotation of the scalable resource,
+// from its capacity annotations or from the instance type in the
+// providerSpec of its machines, in that order, with the resources of
+// the capacity resources annotation and the pod capacity of the pods
+// capacity annotation if they are set. ErrNotImplemented is returned
+// if none of them is known. The labels and taints of the
+// template node are those of the machine spec, together with the
+// instance type and zone labels from the providerSpec and those of
+// the node labels and taints annotations of the scalable resource,
+// which take precedence. Template nodes with GPUs get the GPU label,
+// set to the GPU type annotation of the scalable resource or to the
+// type of the GPUs of the instance type from the providerSpec, unless
+// the machine spec or the node labels annotation sets it. Template
+// nodes of scalable resources with the spot annotation get the spot
+// label. Template nodes are annotated with the ID of their node group, for pricing,
+// and with the hash of their providerSpec without its zone fields,
+// for balancing similar node groups.
 func (ng *nodegroup) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
-	return nil, cloudprovider.ErrNotImplemented
+	spec := ng.scalableResource.MachineSpec()
+	template, err := parseProviderSpec(spec)
+	if err != nil && err != errMissingProviderSpec {
+		klog.V(4).Infof("nodegroup %q: ignoring providerSpec: %v", ng.Id(), err)
+	}
+
+	capacity, err := ng.templateCapacity()
+	if err == cloudprovider.ErrNotImplemented && template != nil && template.capacity != nil {
+		capacity, err = template.capacity, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	capacity, err = withCapacityResourcesAnnotation(capacity, ng.scalableResource.Annotations())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get capacity of nodegroup %q: %v", ng.Id(), err)
+	}
+	capacity, err = withPodsCapacityAnnotation(capacity, ng.scalableResource.Annotations())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pod capacity of nodegroup %q: %v", ng.Id(), err)
+	}
+
+	annotations := ng.scalableResource.Annotations()
+	extraLabels, err := labelsFromAnnotations(annotations)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get labels of nodegroup %q: %v", ng.Id(), err)
+	}
+	extraTaints, err := taintsFromAnnotations(annotations)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get taints of nodegroup %q: %v", ng.Id(), err)
+	}
+
+	name := fmt.Sprintf("%s-template", ng.Name())
+	labels := map[string]string{
+		corev1.LabelHostname:   name,
+		corev1.LabelOSStable:   cloudprovider.DefaultOS,
+		corev1.LabelArchStable: cloudprovider.DefaultArch,
+	}
+	if template != nil {
+		labels = cloudprovider.JoinStringMaps(labels, template.labels())
+	}
+	if gpus, found := capacity[gpu.ResourceNvidiaGPU]; found && !gpus.IsZero() {
+		labels[gpuLabelKey] = templateGPUType(annotations, template)
+	}
+	if isSpot(annotations) {
+		labels[spotLabelKey] = ""
+	}
+	labels = cloudprovider.JoinStringMaps(labels, spec.Labels, extraLabels)
+	// The labels of the template node are those of the real nodes.
+	labels = translateKeys(labels, ng.machineController.apiKey)
+
+	nodeAnnotations := map[string]string{
+		templateNodeGroupAnnotationKey: ng.Id(),
+	}
+	if hash, err := hashProviderSpec(spec); err == nil {
+		nodeAnnotations[providerSpecHashAnnotationKey] = hash
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: nodeAnnotations,
+		},
+		Spec: corev1.NodeSpec{
+			Taints: mergeTaints(spec.Taints, extraTaints),
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    capacity,
+			Allocatable: capacity,
+			Conditions:  cloudprovider.BuildReadyConditions(),
+		},
+	}
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.Name()))
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// templateCapacity returns the capacity of the machines of the node
+// group, as configured on the scalable resource.
+func (ng *nodegroup) templateCapacity() (corev1.ResourceList, error) {
+	annotations := ng.scalableResource.Annotations()
+	if class, found := annotations[instanceClassAnnotationKey]; found {
+		capacity, err := ng.machineController.findInstanceClass(ng.Namespace(), class)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get instance class of nodegroup %q: %v", ng.Id(), err)
+		}
+		return capacity, nil
+	}
+
+	capacity, err := capacityFromAnnotations(annotations)
+	if err == errMissingCapacityAnnotations {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get capacity of nodegroup %q: %v", ng.Id(), err)
+	}
+	return capacity, nil
+}
+
+// canScaleFromZero returns true if the capacity of the machines of
+// the node group is known, so that it can be scaled up from zero
+// replicas.
+func (ng *nodegroup) canScaleFromZero() bool {
+	annotations := ng.scalableResource.Annotations()
+	if _, found := annotations[instanceClassAnnotationKey]; found {
+		return true
+	}
+	if _, err := capacityFromAnnotations(annotations); err == nil {
+		return true
+	}
+	template, err := parseProviderSpec(ng.scalableResource.MachineSpec())
+	return err == nil && template.capacity != nil
+}
+
+// isAutoscaled returns true if the node group is managed by the
+// autoscaler: it matches the node group selector, isn't paused, its
+// size can change and it either has replicas or can be scaled up from
+// zero. Its minimum size can be zero.
+func (ng *nodegroup) isAutoscaled() bool {
+	if !ng.machineController.nodeGroupSelector.Matches(labels.Set(ng.scalableResource.Labels())) {
+		return false
+	}
+	if isPaused(ng.scalableResource.Annotations()) {
+		klog.V(4).Infof("nodegroup %s is paused", ng.Id())
+		return false
+	}
+	if ng.MaxSize()-ng.MinSize() < 1 {
+		return false
+	}
+	return ng.scalableResource.Replicas() > 0 || ng.canScaleFromZero()
 }
 
 // Exist checks if the node group really exists on the cloud nodegroup
 // side. Allows to tell the theoretical node group from the real one.
 // Implementation required.
 func (ng *nodegroup) Exist() bool {
-	return true
+	return !ng.proposed
 }
 
 // Create creates the node group on the cloud nodegroup side.
 // Implementation optional.
+//
+// Only the autoprovisioned node groups built by NewNodeGroup can be
+// created, by creating their MachineDeployment with zero replicas.
 func (ng *nodegroup) Create() (cloudprovider.NodeGroup, error) {
-	return nil, cloudprovider.ErrAlreadyExist
+	if !ng.proposed {
+		return nil, cloudprovider.ErrAlreadyExist
+	}
+	r, ok := ng.scalableResource.(*machineDeploymentScalableResource)
+	if !ok {
+		return nil, fmt.Errorf("internal error; unexpected type %T", ng.scalableResource)
+	}
+	return ng.machineController.createMachineDeployment(r.machineDeployment)
+}
+
+// SetSizeLimits sets the min and max sizes the autoprovisioned node
+// group is created with, overriding those of its machine template.
+func (ng *nodegroup) SetSizeLimits(minSize, maxSize int) error {
+	if !ng.proposed {
+		return fmt.Errorf("nodegroup %q already exists", ng.Id())
+	}
+	if minSize < 0 || maxSize < minSize {
+		return fmt.Errorf("sizes of nodegroup %q must satisfy 0 <= min (%d) <= max (%d)", ng.Id(), minSize, maxSize)
+	}
+	r, ok := ng.scalableResource.(*machineDeploymentScalableResource)
+	if !ok {
+		return fmt.Errorf("internal error; unexpected type %T", ng.scalableResource)
+	}
+	machineDeployment := r.machineDeployment.DeepCopy()
+	machineDeployment.Annotations[nodeGroupMinSizeAnnotationKey] = fmt.Sprint(minSize)
+	machineDeployment.Annotations[nodeGroupMaxSizeAnnotationKey] = fmt.Sprint(maxSize)
+	scalableResource, err := newMachineDeploymentScalableResource(ng.machineController, machineDeployment)
+	if err != nil {
+		return err
+	}
+	ng.scalableResource = scalableResource
+	return nil
+}
+
+// SetPersistent makes the node group be created as a regular node
+// group, its MachineDeployment not being annotated with the machine
+// type it is created from.
+func (ng *nodegroup) SetPersistent() error {
+	if !ng.proposed {
+		return fmt.Errorf("nodegroup %q already exists", ng.Id())
+	}
+	r, ok := ng.scalableResource.(*machineDeploymentScalableResource)
+	if !ok {
+		return fmt.Errorf("internal error; unexpected type %T", ng.scalableResource)
+	}
+	machineDeployment := r.machineDeployment.DeepCopy()
+	delete(machineDeployment.Annotations, autoprovisionedAnnotationKey)
+	scalableResource, err := newMachineDeploymentScalableResource(ng.machineController, machineDeployment)
+	if err != nil {
+		return err
+	}
+	ng.scalableResource = scalableResource
+	return nil
 }
 
 // Delete deletes the node group on the cloud nodegroup side. This will
 // be executed only for autoprovisioned node groups, once their size
 // drops to 0. Implementation optional.
+//
+// The MachineDeployment of an autoprovisioned node group is deleted
+// once it has no replicas and no machines left. ErrNotImplemented is
+// returned for the other node groups.
 func (ng *nodegroup) Delete() error {
-	return cloudprovider.ErrNotImplemented
+	if !ng.Autoprovisioned() {
+		return cloudprovider.ErrNotImplemented
+	}
+	if !ng.Exist() {
+		return fmt.Errorf("nodegroup %q doesn't exist", ng.Id())
+	}
+	if replicas := ng.replicas(); replicas > 0 {
+		return fmt.Errorf("unable to delete nodegroup %q: it has %d replicas", ng.Id(), replicas)
+	}
+	nodes, err := ng.scalableResource.Nodes()
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		return fmt.Errorf("unable to delete nodegroup %q: it has %d machines", ng.Id(), len(nodes))
+	}
+	return ng.machineController.deleteMachineDeployment(ng.Namespace(), ng.Name())
 }
 
 // Autoprovisioned returns true if the node group is autoprovisioned.
 // An autoprovisioned group was created by CA and can be deleted when
 // scaled to 0.
+//
+// Autoprovisioned node groups are the MachineDeployments annotated
+// with the machine type they were created from.
 func (ng *nodegroup) Autoprovisioned() bool {
-	return false
+	if _, ok := ng.scalableResource.(*machineDeploymentScalableResource); !ok {
+		return false
+	}
+	_, found := ng.scalableResource.Annotations()[autoprovisionedAnnotationKey]
+	return found
 }
 
 func newNodegroupFromMachineSet(controller *machineController, machineSet *v1beta1.MachineSet) (*nodegroup, error) {
@@ -252,7 +738,6 @@ func newNodegroupFromMachineSet(controller *machineController, machineSet *v1bet
 		return nil, err
 	}
 	return &nodegroup{
-		machineapiClient:  controller.clusterClientset.MachineV1beta1(),
 		machineController: controller,
 		scalableResource:  scalableResource,
 	}, nil
@@ -264,7 +749,6 @@ func newNodegroupFromMachineDeployment(controller *machineController, machineDep
 		return nil, err
 	}
 	return &nodegroup{
-		machineapiClient:  controller.clusterClientset.MachineV1beta1(),
 		machineController: controller,
 		scalableResource:  scalableResource,
 	}, nil
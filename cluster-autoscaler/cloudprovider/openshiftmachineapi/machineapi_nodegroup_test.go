@@ -19,12 +19,19 @@ package openshiftmachineapi
 import (
 	"fmt"
 	"path"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/openshift/cluster-api/pkg/apis/machine/common"
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	"k8s.io/utils/pointer"
 )
 
@@ -268,7 +275,7 @@ func TestNodeGroupIncreaseSizeErrors(t *testing.T) {
 		switch v := (ng.scalableResource).(type) {
 		case *machineSetScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			ms, err := ng.machineapiClient.MachineSets(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			ms, err := getMachineSet(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -277,7 +284,7 @@ func TestNodeGroupIncreaseSizeErrors(t *testing.T) {
 			}
 		case *machineDeploymentScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			md, err := ng.machineapiClient.MachineDeployments(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			md, err := getMachineDeployment(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -352,7 +359,7 @@ func TestNodeGroupIncreaseSize(t *testing.T) {
 		switch v := (ng.scalableResource).(type) {
 		case *machineSetScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			ms, err := ng.machineapiClient.MachineSets(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			ms, err := getMachineSet(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -361,7 +368,7 @@ func TestNodeGroupIncreaseSize(t *testing.T) {
 			}
 		case *machineDeploymentScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			md, err := ng.machineapiClient.MachineDeployments(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			md, err := getMachineDeployment(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -399,6 +406,264 @@ func TestNodeGroupIncreaseSize(t *testing.T) {
 	})
 }
 
+func TestNodeGroupMachineDeploymentRollout(t *testing.T) {
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	for _, tc := range []struct {
+		description  string
+		paused       bool
+		status       v1beta1.MachineDeploymentStatus
+		expectedSize int
+		rollingOut   bool
+	}{{
+		description:  "status not reported",
+		expectedSize: 3,
+	}, {
+		description:  "settled",
+		status:       v1beta1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3},
+		expectedSize: 3,
+	}, {
+		description:  "machines being updated",
+		status:       v1beta1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 1},
+		expectedSize: 4,
+		rollingOut:   true,
+	}, {
+		description:  "old machines pending termination",
+		status:       v1beta1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 3},
+		expectedSize: 4,
+		rollingOut:   true,
+	}, {
+		description:  "paused",
+		paused:       true,
+		status:       v1beta1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 1},
+		expectedSize: 3,
+		rollingOut:   true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			testConfig := createMachineDeploymentTestConfig(testNamespace, 3, annotations)
+			testConfig.machineDeployment.Generation = 1
+			testConfig.machineDeployment.Spec.Paused = tc.paused
+			testConfig.machineDeployment.Status = tc.status
+
+			controller, stop := mustCreateTestController(t, testConfig)
+			defer stop()
+
+			nodegroups, err := controller.nodeGroups()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if l := len(nodegroups); l != 1 {
+				t.Fatalf("expected 1 nodegroup, got %d", l)
+			}
+			ng := nodegroups[0]
+
+			size, err := ng.TargetSize()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size != tc.expectedSize {
+				t.Errorf("expected target size %d, got %d", tc.expectedSize, size)
+			}
+
+			maxSize, reason, limited := ng.ProviderMaxSize()
+			if limited != tc.rollingOut {
+				t.Errorf("expected the provider max size to be limited: %v, got %v", tc.rollingOut, limited)
+			}
+			if limited && (maxSize != tc.expectedSize || reason == "") {
+				t.Errorf("expected the provider max size %d with a reason, got %d %q", tc.expectedSize, maxSize, reason)
+			}
+
+			if reason, blocked := ng.nodeDeletionBlocked(); blocked != tc.rollingOut || blocked && reason == "" {
+				t.Errorf("expected the deletion of nodes to be blocked: %v, got %v %q", tc.rollingOut, blocked, reason)
+			}
+
+			if !tc.rollingOut {
+				return
+			}
+			if err := ng.IncreaseSize(1); err == nil {
+				t.Error("expected an error increasing the size")
+			}
+			if err := ng.DecreaseTargetSize(-1); err == nil {
+				t.Error("expected an error decreasing the target size")
+			}
+
+			md, err := getMachineDeployment(ng.machineController, ng.Namespace(), ng.Name())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := pointer.Int32PtrDerefOr(md.Spec.Replicas, 0); actual != 3 {
+				t.Errorf("expected the replicas to be left at 3, got %d", actual)
+			}
+		})
+	}
+}
+
+func TestNodeGroupProblem(t *testing.T) {
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	test := func(t *testing.T, testConfig *testConfig, expectedMessage string) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		reason, message, ok := nodegroups[0].Problem()
+		if expectedMessage == "" {
+			if ok {
+				t.Errorf("expected no problem, got %s: %s", reason, message)
+			}
+			return
+		}
+		if !ok || reason != string(common.InvalidConfigurationMachineSetError) || message != expectedMessage {
+			t.Errorf("expected problem %s: %s, got %v %s: %s", common.InvalidConfigurationMachineSetError, expectedMessage, ok, reason, message)
+		}
+	}
+
+	setError := func(testConfig *testConfig) *testConfig {
+		reason := common.InvalidConfigurationMachineSetError
+		message := "unknown instance type"
+		testConfig.machineSet.Status.ErrorReason = &reason
+		testConfig.machineSet.Status.ErrorMessage = &message
+		return testConfig
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 3, annotations), "")
+		test(t, setError(createMachineSetTestConfig(testNamespace, 3, annotations)), "unknown instance type")
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		testConfig := setError(createMachineDeploymentTestConfig(testNamespace, 3, annotations))
+		test(t, testConfig, fmt.Sprintf("machineset %s: unknown instance type", testConfig.machineSet.Name))
+	})
+}
+
+func TestNodeGroupGetOptions(t *testing.T) {
+	defaults := cloudprovider.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: 15 * time.Minute}
+
+	test := func(t *testing.T, testConfig *testConfig, expected time.Duration, expectedErr error) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		ng := nodegroups[0]
+		options, err := ng.GetOptions(defaults)
+		if expectedErr != nil {
+			if err == nil || !strings.HasPrefix(err.Error(), expectedErr.Error()) {
+				t.Fatalf("expected error with prefix %q, got %v", expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if options.MaxNodeProvisionTime != expected {
+			t.Errorf("expected max node provision time %v, got %v", expected, options.MaxNodeProvisionTime)
+		}
+		if actual := cloudprovider.GetNodeGroupOptions(ng, defaults).MaxNodeProvisionTime; actual != expected {
+			t.Errorf("expected max node provision time %v, got %v", expected, actual)
+		}
+	}
+
+	annotations := func(maxNodeProvisionTime string) map[string]string {
+		return map[string]string{
+			nodeGroupMinSizeAnnotationKey:              "1",
+			nodeGroupMaxSizeAnnotationKey:              "10",
+			nodeGroupMaxNodeProvisionTimeAnnotationKey: maxNodeProvisionTime,
+		}
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 1, annotations("45m")), 45*time.Minute, nil)
+		test(t, createMachineSetTestConfig(testNamespace, 1, annotations("45")), 0, errInvalidMaxNodeProvisionTimeAnnotation)
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 1, annotations("45m")), 45*time.Minute, nil)
+	})
+
+	t.Run("without annotation", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 1, map[string]string{
+			nodeGroupMinSizeAnnotationKey: "1",
+			nodeGroupMaxSizeAnnotationKey: "10",
+		}), 0, cloudprovider.ErrNotImplemented)
+	})
+
+	t.Run("readiness annotations", func(t *testing.T) {
+		controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 1, map[string]string{
+			nodeGroupMinSizeAnnotationKey:             "1",
+			nodeGroupMaxSizeAnnotationKey:             "10",
+			nodeGroupReadinessConditionsAnnotationKey: "GPUDriverReady, CNIReady",
+			nodeGroupReadinessLabelsAnnotationKey:     "",
+		}))
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		options := cloudprovider.GetNodeGroupOptions(nodegroups[0], cloudprovider.NodeGroupAutoscalingOptions{
+			MaxNodeProvisionTime: 15 * time.Minute,
+			ReadinessConditions:  []string{"CNIReady"},
+			ReadinessLabels:      []string{"cni-ready"},
+		})
+		if options.MaxNodeProvisionTime != 15*time.Minute {
+			t.Errorf("expected max node provision time %v, got %v", 15*time.Minute, options.MaxNodeProvisionTime)
+		}
+		if expected := []string{"GPUDriverReady", "CNIReady"}; !reflect.DeepEqual(options.ReadinessConditions, expected) {
+			t.Errorf("expected readiness conditions %v, got %v", expected, options.ReadinessConditions)
+		}
+		if len(options.ReadinessLabels) != 0 {
+			t.Errorf("expected no readiness labels, got %v", options.ReadinessLabels)
+		}
+	})
+
+	t.Run("startup taints annotation", func(t *testing.T) {
+		controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 1, map[string]string{
+			nodeGroupMinSizeAnnotationKey:       "1",
+			nodeGroupMaxSizeAnnotationKey:       "10",
+			nodeGroupStartupTaintsAnnotationKey: "node.cilium.io/agent-not-ready,example.com/gpu-driver",
+		}))
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		options := cloudprovider.GetNodeGroupOptions(nodegroups[0], cloudprovider.NodeGroupAutoscalingOptions{})
+		if expected := []string{"node.cilium.io/agent-not-ready", "example.com/gpu-driver"}; !reflect.DeepEqual(options.StartupTaints, expected) {
+			t.Errorf("expected startup taints %v, got %v", expected, options.StartupTaints)
+		}
+	})
+}
+
 func TestNodeGroupDecreaseTargetSize(t *testing.T) {
 	type testCase struct {
 		description string
@@ -433,7 +698,7 @@ func TestNodeGroupDecreaseTargetSize(t *testing.T) {
 			t.Fatalf("failed to add new node: %v", err)
 		}
 
-		if err := controller.machineInformer.Informer().GetStore().Add(testConfig.machines[0]); err != nil {
+		if err := controller.machineInformer.GetStore().Add(testConfig.machines[0]); err != nil {
 			t.Fatalf("failed to add new machine: %v", err)
 		}
 
@@ -444,7 +709,7 @@ func TestNodeGroupDecreaseTargetSize(t *testing.T) {
 		switch v := (ng.scalableResource).(type) {
 		case *machineSetScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			ms, err := ng.machineapiClient.MachineSets(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			ms, err := getMachineSet(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -453,7 +718,7 @@ func TestNodeGroupDecreaseTargetSize(t *testing.T) {
 			}
 		case *machineDeploymentScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			md, err := ng.machineapiClient.MachineDeployments(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			md, err := getMachineDeployment(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -552,7 +817,7 @@ func TestNodeGroupDecreaseSizeErrors(t *testing.T) {
 		switch v := (ng.scalableResource).(type) {
 		case *machineSetScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			ms, err := ng.machineapiClient.MachineSets(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			ms, err := getMachineSet(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -561,7 +826,7 @@ func TestNodeGroupDecreaseSizeErrors(t *testing.T) {
 			}
 		case *machineDeploymentScalableResource:
 			// A nodegroup is immutable; get a fresh copy.
-			md, err := ng.machineapiClient.MachineDeployments(ng.Namespace()).Get(ng.Name(), v1.GetOptions{})
+			md, err := getMachineDeployment(ng.machineController, ng.Namespace(), ng.Name())
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -637,7 +902,7 @@ func TestNodeGroupDeleteNodes(t *testing.T) {
 		}
 
 		for i := 5; i < len(testConfig.machines); i++ {
-			machine, err := controller.clusterClientset.MachineV1beta1().Machines(testConfig.machines[i].Namespace).Get(testConfig.machines[i].Name, v1.GetOptions{})
+			machine, err := getMachine(controller, testConfig.machines[i].Namespace, testConfig.machines[i].Name)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -648,7 +913,7 @@ func TestNodeGroupDeleteNodes(t *testing.T) {
 
 		switch v := (ng.scalableResource).(type) {
 		case *machineSetScalableResource:
-			updatedMachineSet, err := controller.clusterClientset.MachineV1beta1().MachineSets(testConfig.machineSet.Namespace).Get(testConfig.machineSet.Name, v1.GetOptions{})
+			updatedMachineSet, err := getMachineSet(controller, testConfig.machineSet.Namespace, testConfig.machineSet.Name)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -656,7 +921,7 @@ func TestNodeGroupDeleteNodes(t *testing.T) {
 				t.Fatalf("expected 5 nodes, got %v", actual)
 			}
 		case *machineDeploymentScalableResource:
-			updatedMachineDeployment, err := controller.clusterClientset.MachineV1beta1().MachineDeployments(testConfig.machineDeployment.Namespace).Get(testConfig.machineDeployment.Name, v1.GetOptions{})
+			updatedMachineDeployment, err := getMachineDeployment(controller, testConfig.machineDeployment.Namespace, testConfig.machineDeployment.Name)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -688,6 +953,85 @@ func TestNodeGroupDeleteNodes(t *testing.T) {
 	})
 }
 
+func TestNodeGroupDeleteNodesOfMachinesBeingDeleted(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig) {
+		now := v1.Now()
+		testConfig.machines[4].DeletionTimestamp = &now
+
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		ng := nodegroups[0]
+		unknown := &corev1.Node{Spec: corev1.NodeSpec{ProviderID: "unknown"}}
+		if err := ng.DeleteNodes([]*corev1.Node{unknown}); err == nil {
+			t.Error("expected an error deleting a node without node group")
+		}
+
+		if err := ng.DeleteNodes(testConfig.nodes[3:]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		machine, err := getMachine(controller, testConfig.spec.namespace, testConfig.machines[3].Name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, found := machine.Annotations[machineDeleteAnnotationKey]; !found {
+			t.Errorf("expected annotation %q on machine %s", machineDeleteAnnotationKey, machine.Name)
+		}
+		machine, err = getMachine(controller, testConfig.spec.namespace, testConfig.machines[4].Name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, found := machine.Annotations[machineDeleteAnnotationKey]; found {
+			t.Errorf("unexpected annotation %q on machine %s", machineDeleteAnnotationKey, machine.Name)
+		}
+
+		// Only the machine that wasn't being deleted already
+		// is removed from the replica count.
+		var replicas int32
+		switch v := (ng.scalableResource).(type) {
+		case *machineSetScalableResource:
+			updated, err := getMachineSet(controller, testConfig.spec.namespace, testConfig.machineSet.Name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			replicas = pointer.Int32PtrDerefOr(updated.Spec.Replicas, 0)
+		case *machineDeploymentScalableResource:
+			updated, err := getMachineDeployment(controller, testConfig.spec.namespace, testConfig.machineDeployment.Name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			replicas = pointer.Int32PtrDerefOr(updated.Spec.Replicas, 0)
+		default:
+			t.Fatalf("unexpected type: %T", v)
+		}
+		if replicas != 4 {
+			t.Errorf("expected 4 replicas, got %d", replicas)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 5, annotations))
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 5, annotations))
+	})
+}
+
 func TestNodeGroupMachineSetDeleteNodesWithMismatchedNodes(t *testing.T) {
 	test := func(t *testing.T, expected int, testConfigs []*testConfig) {
 		t.Helper()
@@ -773,3 +1117,180 @@ func TestNodeGroupMachineSetDeleteNodesWithMismatchedNodes(t *testing.T) {
 		test(t, 2, append(testConfig0, testConfig1...))
 	})
 }
+
+func TestNodeGroupTemplateNodeInfo(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      instanceClassesConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"medium": `{"cpu": "4", "memory": "16Gi", "gpu": "1"}`,
+		},
+	}
+
+	test := func(t *testing.T, testConfig *testConfig, expectedErr error) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		if err := controller.instanceClassInformer.GetStore().Add(configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+		if expectedErr != nil {
+			if err == nil || !strings.Contains(err.Error(), expectedErr.Error()) {
+				t.Fatalf("expected error %q, got %v", expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		node := nodeInfo.Node()
+		if node.Labels[corev1.LabelHostname] != node.Name {
+			t.Errorf("expected hostname label %q, got %q", node.Name, node.Labels[corev1.LabelHostname])
+		}
+		if cpu := node.Status.Allocatable[corev1.ResourceCPU]; cpu.Value() != 4 {
+			t.Errorf("expected 4 cpus, got %v", cpu.String())
+		}
+		if memory := node.Status.Capacity[corev1.ResourceMemory]; memory.Cmp(resource.MustParse("16Gi")) != 0 {
+			t.Errorf("expected 16Gi memory, got %v", memory.String())
+		}
+		if gpus := node.Status.Capacity[gpu.ResourceNvidiaGPU]; gpus.Value() != 1 {
+			t.Errorf("expected 1 gpu, got %v", gpus.String())
+		}
+		if l := len(nodeInfo.Pods()); l != 1 {
+			t.Errorf("expected 1 pod, got %d", l)
+		}
+	}
+
+	annotationsWithClass := func(class string) map[string]string {
+		annotations := map[string]string{
+			nodeGroupMinSizeAnnotationKey: "1",
+			nodeGroupMaxSizeAnnotationKey: "10",
+		}
+		if class != "" {
+			annotations[instanceClassAnnotationKey] = class
+		}
+		return annotations
+	}
+
+	for _, tc := range []struct {
+		description string
+		class       string
+		expectedErr error
+	}{{
+		description: "without instance class",
+		expectedErr: cloudprovider.ErrNotImplemented,
+	}, {
+		description: "with instance class",
+		class:       "medium",
+	}, {
+		description: "with undefined instance class",
+		class:       "large",
+		expectedErr: errMissingInstanceClass,
+	}} {
+		t.Run("MachineSet", func(t *testing.T) {
+			t.Run(tc.description, func(t *testing.T) {
+				test(t, createMachineSetTestConfig(testNamespace, 1, annotationsWithClass(tc.class)), tc.expectedErr)
+			})
+		})
+
+		t.Run("MachineDeployment", func(t *testing.T) {
+			t.Run(tc.description, func(t *testing.T) {
+				test(t, createMachineDeploymentTestConfig(testNamespace, 1, annotationsWithClass(tc.class)), tc.expectedErr)
+			})
+		})
+	}
+}
+
+func TestNodeGroupTemplateNodeInfoFromProviderSpec(t *testing.T) {
+	spec := machineSpecWithProviderSpec(`{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "placement": {"region": "us-east-1", "availabilityZone": "us-east-1b"}}`)
+	spec.Labels = map[string]string{"node-role.kubernetes.io/worker": ""}
+	spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+
+	test := func(t *testing.T, testConfig *testConfig) {
+		if testConfig.machineDeployment != nil {
+			testConfig.machineDeployment.Spec.Template.Spec = spec
+		} else {
+			testConfig.machineSet.Spec.Template.Spec = spec
+		}
+
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		// The group has no replicas but can be scaled from zero.
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		node := nodeInfo.Node()
+		for k, v := range map[string]string{
+			corev1.LabelInstanceType:         "m5.xlarge",
+			corev1.LabelZoneRegion:           "us-east-1",
+			corev1.LabelZoneFailureDomain:    "us-east-1b",
+			"node-role.kubernetes.io/worker": "",
+		} {
+			if actual, found := node.Labels[k]; !found || actual != v {
+				t.Errorf("expected label %s=%s, got %q", k, v, actual)
+			}
+		}
+		if cpu := node.Status.Allocatable[corev1.ResourceCPU]; cpu.Value() != 4 {
+			t.Errorf("expected 4 cpus, got %v", cpu.String())
+		}
+		if memory := node.Status.Capacity[corev1.ResourceMemory]; memory.Cmp(resource.MustParse("16Gi")) != 0 {
+			t.Errorf("expected 16Gi memory, got %v", memory.String())
+		}
+		// The pods capacity annotation overrides the pod capacity of the providerSpec.
+		if pods := node.Status.Allocatable[corev1.ResourcePods]; pods.Value() != 250 {
+			t.Errorf("expected 250 pods, got %v", pods.String())
+		}
+		if hugepages := node.Status.Allocatable["hugepages-1Gi"]; hugepages.Cmp(resource.MustParse("4Gi")) != 0 {
+			t.Errorf("expected 4Gi of hugepages-1Gi, got %v", hugepages.String())
+		}
+		if len(node.Spec.Taints) != 1 || node.Spec.Taints[0].Key != "dedicated" {
+			t.Errorf("expected the dedicated taint, got %v", node.Spec.Taints)
+		}
+		hash, err := hashProviderSpec(spec)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual := node.Annotations[providerSpecHashAnnotationKey]; actual != hash {
+			t.Errorf("expected providerSpec hash annotation %q, got %q", hash, actual)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey:  "0",
+		nodeGroupMaxSizeAnnotationKey:  "10",
+		podsCapacityAnnotationKey:      "250",
+		capacityResourcesAnnotationKey: "hugepages-1Gi=4Gi",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 0, annotations))
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 0, annotations))
+	})
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+)
+
+const (
+	// machineSetUIDLabelKey is the label of the nodes of a
+	// MachineSet set to its UID. It is added to the template of
+	// the MachineSets, so that the node group of a node is found
+	// without looking up its machine.
+	machineSetUIDLabelKey = machineAPIGroup + "/cluster-api-autoscaler-machineset-uid"
+
+	machineSetUIDIndex = "openshiftmachineapi-machineSetUIDIndex"
+)
+
+func indexMachineSetByUID(obj interface{}) ([]string, error) {
+	if machineSet, ok := obj.(*v1beta1.MachineSet); ok && machineSet.UID != "" {
+		return []string{string(machineSet.UID)}, nil
+	}
+	return []string{}, nil
+}
+
+// labelMachineSetTemplate adds machineSetUIDLabelKey to the template
+// of the machines of a MachineSet, unless it's already there. Only the
+// MachineSets that are autoscaled node groups are labelled, the others
+// being left alone, as are the MachineSets owned by a MachineDeployment
+// whose template is managed by it. The nodes of MachineSets that become
+// autoscaled before they are next updated are found from their
+// machines until then.
+func (c *machineController) labelMachineSetTemplate(obj interface{}) {
+	machineSet, ok := obj.(*v1beta1.MachineSet)
+	if !ok || machineSet.UID == "" || machineSetHasMachineDeploymentOwnerRef(machineSet) {
+		return
+	}
+	if machineSet.Spec.Template.Spec.Labels[machineSetUIDLabelKey] == string(machineSet.UID) {
+		return
+	}
+	ng, err := newNodegroupFromMachineSet(c, machineSet.DeepCopy())
+	if err != nil || !ng.isAutoscaled() {
+		return
+	}
+
+	uid := string(machineSet.UID)
+	err = c.updateUnstructured(c.resources.machineSets, machineSet.Namespace, machineSet.Name, func(u *unstructured.Unstructured) error {
+		return unstructured.SetNestedField(u.Object, uid, "spec", "template", "spec", "metadata", "labels", c.apiKey(machineSetUIDLabelKey))
+	})
+	if err != nil {
+		klog.Warningf("failed to add label %q to the template of machineset %s/%s: %v", machineSetUIDLabelKey, machineSet.Namespace, machineSet.Name, err)
+		return
+	}
+	klog.V(4).Infof("added label %q to the template of machineset %s/%s", machineSetUIDLabelKey, machineSet.Namespace, machineSet.Name)
+}
+
+// findMachineSetByUID returns the MachineSet with the given UID, or
+// nil if there is none. A DeepCopy() of the object is returned on
+// success.
+func (c *machineController) findMachineSetByUID(uid string) (*v1beta1.MachineSet, error) {
+	objs, err := c.machineSetInformer.GetIndexer().ByIndex(machineSetUIDIndex, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := len(objs); {
+	case n == 0:
+		return nil, nil
+	case n > 1:
+		return nil, fmt.Errorf("internal error; expected len==1, got %v", n)
+	}
+
+	machineSet, ok := objs[0].(*v1beta1.MachineSet)
+	if !ok {
+		return nil, fmt.Errorf("internal error; unexpected type %T", machineSet)
+	}
+
+	return machineSet.DeepCopy(), nil
+}
+
+// findNodeMachineSet returns the MachineSet of the node, using
+// machineSetUIDLabelKey if the node has it and falling back to the
+// owner of its machine otherwise. Returns nil if it cannot be found.
+func (c *machineController) findNodeMachineSet(node *corev1.Node) (*v1beta1.MachineSet, error) {
+	if uid, found := node.Labels[c.apiKey(machineSetUIDLabelKey)]; found {
+		machineSet, err := c.findMachineSetByUID(uid)
+		if err != nil {
+			return nil, err
+		}
+		if machineSet != nil {
+			return machineSet, nil
+		}
+		klog.V(4).Infof("no machineset with UID %q of node %q, looking up its machine", uid, node.Name)
+	}
+
+	machine, err := c.findMachineByProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return nil, nil
+	}
+	return c.findMachineOwner(machine)
+}
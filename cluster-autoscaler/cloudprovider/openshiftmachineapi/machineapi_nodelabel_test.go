@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestControllerLabelsMachineSetTemplates(t *testing.T) {
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+	machineSetConfig := createMachineSetTestConfig(testNamespace, 1, annotations)
+	controller, stop := mustCreateTestController(t, machineSetConfig)
+	defer stop()
+
+	// The template is labelled asynchronously by the informer
+	// event handler.
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		machineSet, err := controller.findMachineSetByUID(string(machineSetConfig.machineSet.UID))
+		if err != nil || machineSet == nil {
+			return false, err
+		}
+		return machineSet.Spec.Template.Spec.Labels[machineSetUIDLabelKey] == string(machineSet.UID), nil
+	}); err != nil {
+		t.Fatalf("expected label %q on the template of machineset %s: %v", machineSetUIDLabelKey, machineSetConfig.machineSet.Name, err)
+	}
+
+	// The templates of the MachineSets of MachineDeployments are
+	// left alone.
+	machineDeploymentConfig := createMachineDeploymentTestConfig(testNamespace, 1, annotations)
+	controller, stop = mustCreateTestController(t, machineDeploymentConfig)
+	defer stop()
+
+	machineSet, err := getMachineSet(controller, machineDeploymentConfig.machineSet.Namespace, machineDeploymentConfig.machineSet.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := machineSet.Spec.Template.Spec.Labels[machineSetUIDLabelKey]; found {
+		t.Errorf("unexpected label %q on the template of machineset %s", machineSetUIDLabelKey, machineSet.Name)
+	}
+
+	// So are the MachineSets that aren't autoscaled.
+	unautoscaledConfig := createMachineSetTestConfig(testNamespace, 1, nil)
+	controller, stop = mustCreateTestController(t, unautoscaledConfig)
+	defer stop()
+
+	machineSet, err = getMachineSet(controller, unautoscaledConfig.machineSet.Namespace, unautoscaledConfig.machineSet.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := machineSet.Spec.Template.Spec.Labels[machineSetUIDLabelKey]; found {
+		t.Errorf("unexpected label %q on the template of machineset %s", machineSetUIDLabelKey, machineSet.Name)
+	}
+}
+
+func TestControllerNodeGroupForNodeFromLabel(t *testing.T) {
+	testConfig := createMachineSetTestConfig(testNamespace, 1, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	// The node has no machine, it can be only be resolved using
+	// its label.
+	node := &corev1.Node{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   "labelled-node",
+			Labels: map[string]string{machineSetUIDLabelKey: string(testConfig.machineSet.UID)},
+		},
+		Spec: corev1.NodeSpec{ProviderID: "labelled-nodeid"},
+	}
+	ng, err := controller.nodeGroupForNode(node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ng == nil {
+		t.Fatal("expected a nodegroup")
+	}
+	if ng.Name() != testConfig.machineSet.Name {
+		t.Errorf("expected nodegroup %q, got %q", testConfig.machineSet.Name, ng.Name())
+	}
+
+	// Unknown UIDs fall back to machine lookups.
+	node = testConfig.nodes[0].DeepCopy()
+	node.Labels = map[string]string{machineSetUIDLabelKey: "unknown"}
+	ng, err = controller.nodeGroupForNode(node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ng == nil {
+		t.Fatal("expected a nodegroup")
+	}
+	if ng.Name() != testConfig.machineSet.Name {
+		t.Errorf("expected nodegroup %q, got %q", testConfig.machineSet.Name, ng.Name())
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// The node labels and taints annotations add labels and
+	// taints to the template node of a MachineSet or
+	// MachineDeployment, for those the nodes of its machines get
+	// without them being in the machine template, e.g. set by the
+	// kubelet. Labels are a comma separated list of key=value
+	// pairs, taints a comma separated list of key[=value]:effect.
+	nodeLabelsAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-labels"
+	nodeTaintsAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-taints"
+)
+
+// labelsFromAnnotations returns the node labels of the node labels
+// annotation, or nil if it is not set.
+func labelsFromAnnotations(annotations map[string]string) (map[string]string, error) {
+	value, found := annotations[nodeLabelsAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+
+	labels := make(map[string]string)
+	for _, label := range strings.Split(value, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		kv := strings.SplitN(label, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label %q in annotation %q", label, nodeLabelsAnnotationKey)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+// taintsFromAnnotations returns the node taints of the node taints
+// annotation, or nil if it is not set.
+func taintsFromAnnotations(annotations map[string]string) ([]corev1.Taint, error) {
+	value, found := annotations[nodeTaintsAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+
+	var taints []corev1.Taint
+	for _, taint := range strings.Split(value, ",") {
+		taint = strings.TrimSpace(taint)
+		if taint == "" {
+			continue
+		}
+		i := strings.LastIndex(taint, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid taint %q in annotation %q", taint, nodeTaintsAnnotationKey)
+		}
+		effect := corev1.TaintEffect(taint[i+1:])
+		switch effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nil, fmt.Errorf("invalid effect of taint %q in annotation %q", taint, nodeTaintsAnnotationKey)
+		}
+		kv := strings.SplitN(taint[:i], "=", 2)
+		if kv[0] == "" {
+			return nil, fmt.Errorf("invalid taint %q in annotation %q", taint, nodeTaintsAnnotationKey)
+		}
+		t := corev1.Taint{Key: kv[0], Effect: effect}
+		if len(kv) == 2 {
+			t.Value = kv[1]
+		}
+		taints = append(taints, t)
+	}
+	return taints, nil
+}
+
+// mergeTaints returns the taints of the base list replaced or
+// completed by the overrides with the same key and effect. The
+// lists are left untouched.
+func mergeTaints(base, overrides []corev1.Taint) []corev1.Taint {
+	var result []corev1.Taint
+	for _, taint := range base {
+		overridden := false
+		for _, override := range overrides {
+			if override.MatchTaint(&taint) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			result = append(result, taint)
+		}
+	}
+	return append(result, overrides...)
+}
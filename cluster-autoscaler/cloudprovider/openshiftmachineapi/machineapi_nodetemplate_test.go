@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestLabelsFromAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		expected    map[string]string
+		expectErr   bool
+	}{{
+		description: "no annotation",
+	}, {
+		description: "labels",
+		annotations: map[string]string{nodeLabelsAnnotationKey: "disktype=ssd, node-role.kubernetes.io/infra="},
+		expected:    map[string]string{"disktype": "ssd", "node-role.kubernetes.io/infra": ""},
+	}, {
+		description: "missing value",
+		annotations: map[string]string{nodeLabelsAnnotationKey: "disktype"},
+		expectErr:   true,
+	}, {
+		description: "missing key",
+		annotations: map[string]string{nodeLabelsAnnotationKey: "=ssd"},
+		expectErr:   true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			labels, err := labelsFromAnnotations(tc.annotations)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(labels, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, labels)
+			}
+		})
+	}
+}
+
+func TestTaintsFromAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		expected    []corev1.Taint
+		expectErr   bool
+	}{{
+		description: "no annotation",
+	}, {
+		description: "taints",
+		annotations: map[string]string{nodeTaintsAnnotationKey: "dedicated=gpu:NoSchedule, spot:PreferNoSchedule"},
+		expected: []corev1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+		},
+	}, {
+		description: "missing effect",
+		annotations: map[string]string{nodeTaintsAnnotationKey: "dedicated=gpu"},
+		expectErr:   true,
+	}, {
+		description: "invalid effect",
+		annotations: map[string]string{nodeTaintsAnnotationKey: "dedicated=gpu:Never"},
+		expectErr:   true,
+	}, {
+		description: "missing key",
+		annotations: map[string]string{nodeTaintsAnnotationKey: "=gpu:NoSchedule"},
+		expectErr:   true,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			taints, err := taintsFromAnnotations(tc.annotations)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(taints, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, taints)
+			}
+		})
+	}
+}
+
+func TestNodeGroupTemplateNodeInfoFromAnnotations(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig) {
+		spec := &testConfig.machineSet.Spec.Template.Spec
+		if testConfig.machineDeployment != nil {
+			spec = &testConfig.machineDeployment.Spec.Template.Spec
+		}
+		spec.Labels = map[string]string{"disktype": "hdd", "node-role.kubernetes.io/worker": ""}
+		spec.Taints = []corev1.Taint{
+			{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: corev1.TaintEffectNoExecute},
+		}
+
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+
+		nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		node := nodeInfo.Node()
+		for k, v := range map[string]string{
+			"disktype":                       "ssd",
+			"node-role.kubernetes.io/worker": "",
+			"gpu":                            "true",
+		} {
+			if actual, found := node.Labels[k]; !found || actual != v {
+				t.Errorf("expected label %s=%s, got %q", k, v, actual)
+			}
+		}
+
+		expectedTaints := []corev1.Taint{
+			{Key: "spot", Effect: corev1.TaintEffectNoExecute},
+			{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		}
+		if !reflect.DeepEqual(node.Spec.Taints, expectedTaints) {
+			t.Errorf("expected taints %v, got %v", expectedTaints, node.Spec.Taints)
+		}
+		if len(spec.Taints) != 2 || spec.Taints[0].Value != "batch" {
+			t.Errorf("expected the taints of the template to be left alone, got %v", spec.Taints)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "0",
+		nodeGroupMaxSizeAnnotationKey: "10",
+		cpuCapacityAnnotationKey:      "4",
+		memoryCapacityAnnotationKey:   "16Gi",
+		nodeLabelsAnnotationKey:       "disktype=ssd,gpu=true",
+		nodeTaintsAnnotationKey:       "dedicated=gpu:NoSchedule",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 0, annotations))
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 0, annotations))
+	})
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+const (
+	// priceAnnotationKey sets the hourly price of the machines of
+	// a MachineSet or MachineDeployment whose instance class has
+	// no price. Values are decimal numbers, see parsePrice.
+	priceAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-price"
+
+	// templateNodeGroupAnnotationKey is set on the template nodes
+	// to the ID of their node group, so that they can be priced
+	// although they have no provider ID.
+	templateNodeGroupAnnotationKey = machineAPIGroup + "/template-node-group"
+)
+
+var (
+	// errMissingPrice is the error returned when the price of the
+	// machines of a node group is not known.
+	errMissingPrice = errors.New("missing price")
+)
+
+var _ cloudprovider.PricingModel = (*pricingModel)(nil)
+
+// pricingModel prices the nodes of the node groups with the hourly
+// prices operators give their machines, either in the instance
+// classes ConfigMap or with the price annotation. All the prices must
+// be in the same currency.
+type pricingModel struct {
+	controller *machineController
+}
+
+// parsePrice returns the hourly price encoded in value, a
+// non-negative decimal number such as "0.192".
+func parsePrice(value string) (float64, error) {
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid price %q", value)
+	}
+	if price < 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0, errors.Errorf("invalid price %q", value)
+	}
+	return price, nil
+}
+
+// pricePerHour returns the hourly price of a machine of the node
+// group: the price of its instance class if it names one with a
+// price, otherwise its price annotation. Returns errMissingPrice if
+// neither is set.
+func (ng *nodegroup) pricePerHour() (float64, error) {
+	annotations := ng.scalableResource.Annotations()
+	if class, found := annotations[instanceClassAnnotationKey]; found {
+		price, err := ng.machineController.findInstanceClassPrice(ng.Namespace(), class)
+		if err == nil {
+			return price, nil
+		}
+		if errors.Cause(err) != errMissingPrice {
+			return 0, fmt.Errorf("unable to get price of nodegroup %q: %v", ng.Id(), err)
+		}
+	}
+
+	value, found := annotations[priceAnnotationKey]
+	if !found {
+		return 0, errors.Wrapf(errMissingPrice, "nodegroup %q", ng.Id())
+	}
+	price, err := parsePrice(value)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get price of nodegroup %q: %v", ng.Id(), err)
+	}
+	return price, nil
+}
+
+// NodePrice returns the price of running the node, a node of a node
+// group or the template node of one, from startTime to endTime.
+func (m *pricingModel) NodePrice(node *corev1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	ng, err := m.nodeGroupForNode(node)
+	if err != nil {
+		return 0, err
+	}
+	price, err := ng.pricePerHour()
+	if err != nil {
+		return 0, err
+	}
+	return price * endTime.Sub(startTime).Hours(), nil
+}
+
+// PodPrice returns the price of running the pod from startTime to
+// endTime on the share of the machine its CPU and memory requests
+// take, on the node group where that share is the cheapest. Node
+// groups without a price or a known capacity are left out; an error
+// is returned if there are none left.
+func (m *pricingModel) PodPrice(pod *corev1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	nodegroups, err := m.controller.nodeGroups()
+	if err != nil {
+		return 0, err
+	}
+
+	var cpu, memory float64
+	for _, container := range pod.Spec.Containers {
+		cpu += float64(container.Resources.Requests.Cpu().MilliValue())
+		memory += float64(container.Resources.Requests.Memory().Value())
+	}
+
+	best := -1.0
+	for _, ng := range nodegroups {
+		price, err := ng.pricePerHour()
+		if err != nil {
+			continue
+		}
+		nodeInfo, err := ng.TemplateNodeInfo()
+		if err != nil {
+			continue
+		}
+		capacity := nodeInfo.Node().Status.Capacity
+		nodeCPU := float64(capacity.Cpu().MilliValue())
+		nodeMemory := float64(capacity.Memory().Value())
+		if nodeCPU == 0 || nodeMemory == 0 {
+			continue
+		}
+		share := math.Max(cpu/nodeCPU, memory/nodeMemory)
+		if podPrice := share * price; best < 0 || podPrice < best {
+			best = podPrice
+		}
+	}
+	if best < 0 {
+		return 0, errors.Wrap(errMissingPrice, "no node group with a price and a capacity")
+	}
+	return best * endTime.Sub(startTime).Hours(), nil
+}
+
+// nodeGroupForNode returns the node group of the node, which is the
+// one named by the template node group annotation for template nodes.
+func (m *pricingModel) nodeGroupForNode(node *corev1.Node) (*nodegroup, error) {
+	if id, found := node.Annotations[templateNodeGroupAnnotationKey]; found {
+		nodegroups, err := m.controller.nodeGroups()
+		if err != nil {
+			return nil, err
+		}
+		for _, ng := range nodegroups {
+			if ng.Id() == id {
+				return ng, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown nodegroup %q of template node %q", id, node.Name)
+	}
+
+	ng, err := m.controller.nodeGroupForNode(node)
+	if err != nil {
+		return nil, err
+	}
+	if ng == nil {
+		return nil, fmt.Errorf("node %q doesn't belong to a known node group", node.Name)
+	}
+	return ng, nil
+}
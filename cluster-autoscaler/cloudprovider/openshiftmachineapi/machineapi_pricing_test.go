@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePrice(t *testing.T) {
+	for value, expected := range map[string]float64{"0.192": 0.192, "2": 2, "0": 0} {
+		if price, err := parsePrice(value); err != nil || price != expected {
+			t.Errorf("expected %v for %q, got %v, %v", expected, value, price, err)
+		}
+	}
+	for _, value := range []string{"", "-1", "cheap", "NaN", "Inf"} {
+		if _, err := parsePrice(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestNodeGroupPricePerHour(t *testing.T) {
+	controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 1, nil))
+	defer stop()
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      instanceClassesConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"priced":   `{"cpu": "2", "memory": "4Gi", "price": "0.5"}`,
+			"unpriced": `{"cpu": "2", "memory": "4Gi"}`,
+		},
+	}
+	if err := controller.instanceClassInformer.GetStore().Add(configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		expected    float64
+		expectedErr error
+	}{{
+		description: "instance class price",
+		annotations: map[string]string{instanceClassAnnotationKey: "priced", priceAnnotationKey: "1"},
+		expected:    0.5,
+	}, {
+		description: "price annotation",
+		annotations: map[string]string{instanceClassAnnotationKey: "unpriced", priceAnnotationKey: "1"},
+		expected:    1,
+	}, {
+		description: "no price",
+		annotations: map[string]string{instanceClassAnnotationKey: "unpriced"},
+		expectedErr: errMissingPrice,
+	}, {
+		description: "missing instance class",
+		annotations: map[string]string{instanceClassAnnotationKey: "unknown", priceAnnotationKey: "1"},
+		expectedErr: errMissingInstanceClass,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			ng := &nodegroup{
+				machineController: controller,
+				scalableResource: &machineSetScalableResource{
+					controller: controller,
+					machineSet: createMachineSetTestConfig(testNamespace, 1, tc.annotations).machineSet,
+				},
+			}
+			price, err := ng.pricePerHour()
+			if tc.expectedErr != nil {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr.Error()) {
+					t.Errorf("expected %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if price != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, price)
+			}
+		})
+	}
+}
+
+func TestPricingModel(t *testing.T) {
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+		cpuCapacityAnnotationKey:      "4",
+		memoryCapacityAnnotationKey:   "8Gi",
+		priceAnnotationKey:            "1",
+	}
+	testConfig := createMachineSetTestConfig(testNamespace, 1, annotations)
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+	model := &pricingModel{controller: controller}
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := len(nodegroups); l != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", l)
+	}
+	nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	end := start.Add(2 * time.Hour)
+	for _, node := range []*corev1.Node{testConfig.nodes[0], nodeInfo.Node()} {
+		price, err := model.NodePrice(node, start, end)
+		if err != nil {
+			t.Fatalf("unexpected error pricing node %q: %v", node.Name, err)
+		}
+		if price != 2 {
+			t.Errorf("expected price 2 for node %q, got %v", node.Name, price)
+		}
+	}
+
+	unknown := &corev1.Node{ObjectMeta: v1.ObjectMeta{Name: "unknown"}}
+	if _, err := model.NodePrice(unknown, start, end); err == nil {
+		t.Error("expected an error pricing a node of no node group")
+	}
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			}},
+		},
+	}
+	// The pod takes half of the memory of a machine.
+	price, err := model.PodPrice(pod, start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(price-1) > 1e-9 {
+		t.Errorf("expected pod price 1, got %v", price)
+	}
+}
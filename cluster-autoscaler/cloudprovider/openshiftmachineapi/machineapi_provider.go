@@ -19,13 +19,15 @@ package openshiftmachineapi
 import (
 	"reflect"
 
-	clusterclientset "github.com/openshift/cluster-api/pkg/client/clientset_generated/clientset"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
 )
@@ -36,6 +38,18 @@ const (
 )
 
 var _ cloudprovider.CloudProvider = (*provider)(nil)
+var _ cloudprovider.GpuCloudProvider = (*provider)(nil)
+var _ cloudprovider.BalancingIgnoredLabelsCloudProvider = (*provider)(nil)
+var _ cloudprovider.SpotCloudProvider = (*provider)(nil)
+var _ cloudprovider.InstanceLookupCloudProvider = (*provider)(nil)
+
+// balancingIgnoredLabels are the labels naming the MachineSet or the
+// MachineDeployment of a machine, which differ between otherwise
+// similar node groups when they are propagated to the nodes.
+var balancingIgnoredLabels = []string{
+	machineSetLabelKey,
+	machineDeploymentLabelKey,
+}
 
 type provider struct {
 	controller      *machineController
@@ -76,22 +90,78 @@ func (p *provider) NodeGroupForNode(node *corev1.Node) (cloudprovider.NodeGroup,
 	return ng, nil
 }
 
-func (*provider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
-	return nil, cloudprovider.ErrNotImplemented
+// HasInstance returns false if the machine annotation of the node
+// references a machine that doesn't exist anymore. The nodes without
+// annotation, or whose machine isn't watched, may not be managed by
+// the machine API and are never reported as missing.
+func (p *provider) HasInstance(node *corev1.Node) (bool, error) {
+	id, ok := node.Annotations[p.controller.apiKey(machineAnnotationKey)]
+	if !ok || !p.controller.watchesMachine(id) {
+		return true, cloudprovider.ErrNotImplemented
+	}
+	machine, err := p.controller.findMachine(id)
+	if err != nil {
+		return false, err
+	}
+	return machine != nil, nil
 }
 
-func (*provider) GetAvailableMachineTypes() ([]string, error) {
-	return []string{}, nil
+// Pricing returns a pricing model using the prices of the instance
+// classes and the price annotations of the scalable resources.
+func (p *provider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return &pricingModel{controller: p.controller}, nil
 }
 
-func (*provider) NewNodeGroup(
+// GetAvailableMachineTypes returns the machine types defined by the
+// machine templates ConfigMaps, or none if MachineDeployments are not
+// enabled.
+func (p *provider) GetAvailableMachineTypes() ([]string, error) {
+	if !p.controller.enableMachineDeployments {
+		return []string{}, nil
+	}
+	return p.controller.machineTypes()
+}
+
+// NewNodeGroup builds the node group of an autoprovisioned
+// MachineDeployment created from the machine template of machineType,
+// whose nodes get the given labels and taints.
+func (p *provider) NewNodeGroup(
 	machineType string,
 	labels map[string]string,
 	systemLabels map[string]string,
 	taints []corev1.Taint,
 	extraResources map[string]resource.Quantity,
 ) (cloudprovider.NodeGroup, error) {
-	return nil, cloudprovider.ErrNotImplemented
+	ng, err := p.controller.newAutoprovisionedNodeGroup(machineType, labels, systemLabels, taints, extraResources)
+	if err != nil {
+		return nil, err
+	}
+	return ng, nil
+}
+
+// GPULabel returns the label added to the nodes with GPUs.
+func (p *provider) GPULabel() string {
+	return p.controller.apiKey(gpuLabelKey)
+}
+
+// GetAvailableGPUTypes returns the GPU types of availableGPUTypes.
+func (*provider) GetAvailableGPUTypes() map[string]struct{} {
+	return availableGPUTypes
+}
+
+// BalancingIgnoredLabels returns balancingIgnoredLabels.
+func (p *provider) BalancingIgnoredLabels() []string {
+	labels := make([]string, 0, len(balancingIgnoredLabels))
+	for _, label := range balancingIgnoredLabels {
+		labels = append(labels, p.controller.apiKey(label))
+	}
+	return labels
+}
+
+// SpotLabel returns the label of the nodes backed by spot or
+// preemptible instances.
+func (p *provider) SpotLabel() string {
+	return p.controller.apiKey(spotLabelKey)
 }
 
 func (*provider) Cleanup() error {
@@ -131,13 +201,46 @@ func BuildOpenShiftMachineAPI(opts config.AutoscalingOptions, do cloudprovider.N
 		klog.Fatalf("create kube clientset failed: %v", err)
 	}
 
-	clusterclient, err := clusterclientset.NewForConfig(externalConfig)
+	dynamicclient, err := dynamic.NewForConfig(externalConfig)
 	if err != nil {
-		klog.Fatalf("create cluster clientset failed: %v", err)
+		klog.Fatalf("create dynamic client failed: %v", err)
+	}
+
+	resources, err := discoverMachineAPIResources(kubeclient.Discovery())
+	if err != nil {
+		klog.Fatalf("cannot discover machine API: %v", err)
+	}
+
+	// MachineAutoscalers are only watched if their CRD is installed.
+	var machineAutoscalerListWatch func(namespace string) cache.ListerWatcher
+	if _, err := kubeclient.Discovery().ServerResourcesForGroupVersion(machineAutoscalerResource.GroupVersion().String()); err == nil {
+		machineAutoscalerListWatch = func(namespace string) cache.ListerWatcher {
+			return newMachineAutoscalerListWatch(dynamicclient, namespace)
+		}
+	} else {
+		klog.V(1).Infof("MachineAutoscalers not available, using min/max annotations only: %v", err)
 	}
 
-	enableMachineDeployments := false
-	controller, err := newMachineController(kubeclient, clusterclient, enableMachineDeployments)
+	autoDiscoveryConfigs, err := parseAutoDiscoverySpecs(do)
+	if err != nil {
+		klog.Fatalf("cannot parse node group auto-discovery specs: %v", err)
+	}
+
+	nodeGroupSelector, err := labels.Parse(opts.MachineAPILabelSelector)
+	if err != nil {
+		klog.Fatalf("cannot parse machine API label selector %q: %v", opts.MachineAPILabelSelector, err)
+	}
+
+	// Autoprovisioned node groups are MachineDeployments, which are
+	// only watched when node groups can be created.
+	enableMachineDeployments := opts.NodeAutoprovisioningEnabled || opts.NodeGroupSpecsEnabled
+	if len(opts.MachineAPINamespaces) > 0 {
+		klog.V(1).Infof("watching machine API objects in namespaces %v", opts.MachineAPINamespaces)
+	}
+	if !nodeGroupSelector.Empty() {
+		klog.V(1).Infof("managing machine API node groups matching %q", nodeGroupSelector)
+	}
+	controller, err := newMachineController(kubeclient, dynamicclient, resources, machineAutoscalerListWatch, enableMachineDeployments, autoDiscoveryConfigs, opts.MachineAPINamespaces, nodeGroupSelector)
 
 	if err != nil {
 		klog.Fatal(err)
@@ -155,6 +258,8 @@ func BuildOpenShiftMachineAPI(opts config.AutoscalingOptions, do cloudprovider.N
 	if err != nil {
 		klog.Fatal(err)
 	}
+	// Register machine API metrics.
+	RegisterMetrics()
 
 	return provider
 }
@@ -49,8 +49,8 @@ func TestProviderConstructorProperties(t *testing.T) {
 		t.Errorf("expected %+v, got %+v", resourceLimits, rl)
 	}
 
-	if _, err := provider.Pricing(); err != cloudprovider.ErrNotImplemented {
-		t.Errorf("expected an error")
+	if _, err := provider.Pricing(); err != nil {
+		t.Errorf("unexpected error: %v", err)
 	}
 
 	machineTypes, err := provider.GetAvailableMachineTypes()
@@ -61,6 +61,33 @@ func TestProviderConstructorProperties(t *testing.T) {
 		t.Errorf("expected 0, got %v", len(machineTypes))
 	}
 
+	gpuProvider, ok := provider.(cloudprovider.GpuCloudProvider)
+	if !ok {
+		t.Fatalf("expected the provider to implement GpuCloudProvider")
+	}
+	if actual := gpuProvider.GPULabel(); actual != gpuLabelKey {
+		t.Errorf("expected %q, got %q", gpuLabelKey, actual)
+	}
+	if _, found := gpuProvider.GetAvailableGPUTypes()["nvidia-tesla-v100"]; !found {
+		t.Errorf("expected nvidia-tesla-v100 to be available, got %v", gpuProvider.GetAvailableGPUTypes())
+	}
+
+	balancingProvider, ok := provider.(cloudprovider.BalancingIgnoredLabelsCloudProvider)
+	if !ok {
+		t.Fatalf("expected the provider to implement BalancingIgnoredLabelsCloudProvider")
+	}
+	if actual := balancingProvider.BalancingIgnoredLabels(); !reflect.DeepEqual(actual, balancingIgnoredLabels) {
+		t.Errorf("expected %v, got %v", balancingIgnoredLabels, actual)
+	}
+
+	spotProvider, ok := provider.(cloudprovider.SpotCloudProvider)
+	if !ok {
+		t.Fatalf("expected the provider to implement SpotCloudProvider")
+	}
+	if actual := spotProvider.SpotLabel(); actual != spotLabelKey {
+		t.Errorf("expected %q, got %q", spotLabelKey, actual)
+	}
+
 	if _, err := provider.NewNodeGroup("foo", nil, nil, nil, nil); err == nil {
 		t.Error("expected an error")
 	}
@@ -96,3 +123,67 @@ func TestProviderConstructorProperties(t *testing.T) {
 		t.Fatalf("unexpected nodegroup: %v", ng.Id())
 	}
 }
+
+func TestProviderHasInstance(t *testing.T) {
+	testConfig := createMachineSetTestConfig(testNamespace, 2, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	provider, err := newProvider(ProviderName, nil, controller)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lookupProvider := provider.(cloudprovider.InstanceLookupCloudProvider)
+
+	if err := controller.machineInformer.GetStore().Delete(testConfig.machines[1]); err != nil {
+		t.Fatalf("unexpected error deleting machine, got %v", err)
+	}
+	unmanagedNode := testConfig.nodes[1].DeepCopy()
+	delete(unmanagedNode.Annotations, machineAnnotationKey)
+	otherNamespaceNode := testConfig.nodes[1].DeepCopy()
+	otherNamespaceNode.Annotations[machineAnnotationKey] = "other-namespace/machine"
+
+	for _, tc := range []struct {
+		description string
+		node        *corev1.Node
+		expected    bool
+		expectedErr error
+	}{{
+		description: "machine exists",
+		node:        testConfig.nodes[0],
+		expected:    true,
+	}, {
+		description: "machine deleted",
+		node:        testConfig.nodes[1],
+		expected:    false,
+	}, {
+		description: "no machine annotation",
+		node:        unmanagedNode,
+		expected:    true,
+		expectedErr: cloudprovider.ErrNotImplemented,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			exists, err := lookupProvider.HasInstance(tc.node)
+			if err != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if exists != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, exists)
+			}
+		})
+	}
+
+	namespacedController, stop := mustCreateNamespacedTestController(t, []string{testNamespace}, testConfig)
+	defer stop()
+	namespacedProvider, err := newProvider(ProviderName, nil, namespacedController)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := namespacedProvider.(cloudprovider.InstanceLookupCloudProvider).HasInstance(otherNamespaceNode); err != cloudprovider.ErrNotImplemented {
+		t.Errorf("expected %v for the machine of an unwatched namespace, got %v", cloudprovider.ErrNotImplemented, err)
+	}
+}
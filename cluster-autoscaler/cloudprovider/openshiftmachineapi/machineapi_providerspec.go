@@ -0,0 +1,252 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+)
+
+const (
+	awsProviderSpecKind   = "AWSMachineProviderConfig"
+	azureProviderSpecKind = "AzureMachineProviderSpec"
+	gcpProviderSpecKind   = "GCPMachineProviderSpec"
+
+	// providerSpecHashAnnotationKey annotates template nodes with
+	// the hash of the providerSpec of their node group, zone fields
+	// excluded, so that MachineSets only differing in their zone can
+	// be balanced.
+	providerSpecHashAnnotationKey = machineAPIGroup + "/template-provider-spec-hash"
+)
+
+var (
+	// errMissingProviderSpec is the error returned when the
+	// machines of a scalable resource have no providerSpec.
+	errMissingProviderSpec = errors.New("missing providerSpec")
+
+	// errUnsupportedProviderSpec is the error returned when the
+	// kind of a providerSpec is not known.
+	errUnsupportedProviderSpec = errors.New("unsupported providerSpec")
+)
+
+// providerSpec holds the fields of the AWS, Azure and GCP machine
+// provider configs that the template node is built from. Only the
+// fields of the config of the given kind are set.
+type providerSpec struct {
+	Kind string `json:"kind"`
+
+	// AWSMachineProviderConfig
+	InstanceType string `json:"instanceType"`
+	Placement    struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+	} `json:"placement"`
+
+	// AzureMachineProviderSpec
+	VMSize   string `json:"vmSize"`
+	Location string `json:"location"`
+
+	// GCPMachineProviderSpec
+	MachineType string `json:"machineType"`
+	Region      string `json:"region"`
+
+	// AzureMachineProviderSpec and GCPMachineProviderSpec
+	Zone string `json:"zone"`
+}
+
+// machineTemplate describes the machines of a scalable resource, as
+// decoded from their providerSpec. The capacity is nil if it is not
+// known for the instance type.
+type machineTemplate struct {
+	instanceType string
+	region       string
+	zone         string
+	capacity     corev1.ResourceList
+	// gpuType is the type of the GPUs of the instance type, or ""
+	// if it has none or it is not known.
+	gpuType string
+}
+
+// parseProviderSpec decodes the providerSpec of spec. Returns
+// errMissingProviderSpec if there is none and
+// errUnsupportedProviderSpec if its kind is not known.
+func parseProviderSpec(spec v1beta1.MachineSpec) (*machineTemplate, error) {
+	if spec.ProviderSpec.Value == nil || len(spec.ProviderSpec.Value.Raw) == 0 {
+		return nil, errMissingProviderSpec
+	}
+	ps := providerSpec{}
+	if err := json.Unmarshal(spec.ProviderSpec.Value.Raw, &ps); err != nil {
+		return nil, errors.Wrapf(err, "%s", errUnsupportedProviderSpec)
+	}
+
+	switch ps.Kind {
+	case awsProviderSpecKind:
+		return &machineTemplate{
+			instanceType: ps.InstanceType,
+			region:       ps.Placement.Region,
+			zone:         ps.Placement.AvailabilityZone,
+			capacity:     awsCapacity(ps.InstanceType),
+			gpuType:      awsGPUType(ps.InstanceType),
+		}, nil
+	case azureProviderSpecKind:
+		template := &machineTemplate{
+			instanceType: ps.VMSize,
+			region:       ps.Location,
+			capacity:     azureCapacity(ps.VMSize),
+			gpuType:      azureGPUType(ps.VMSize),
+		}
+		if ps.Zone != "" {
+			template.zone = fmt.Sprintf("%s-%s", ps.Location, ps.Zone)
+		}
+		return template, nil
+	case gcpProviderSpecKind:
+		return &machineTemplate{
+			instanceType: ps.MachineType,
+			region:       ps.Region,
+			zone:         ps.Zone,
+			capacity:     gcpCapacity(ps.MachineType),
+		}, nil
+	default:
+		return nil, errors.Errorf("%s: kind %q", errUnsupportedProviderSpec, ps.Kind)
+	}
+}
+
+// zonalProviderSpecFields are the paths of the fields of the
+// providerSpecs of each kind that differ between the MachineSets of
+// a cluster spread across zones. AWS subnets belong to a single zone.
+var zonalProviderSpecFields = map[string][][]string{
+	awsProviderSpecKind:   {{"placement", "availabilityZone"}, {"subnet"}},
+	azureProviderSpecKind: {{"zone"}},
+	gcpProviderSpecKind:   {{"zone"}},
+}
+
+// hashProviderSpec returns the hash of the providerSpec of spec
+// without its zonal fields, which is the same for the machines of
+// MachineSets only differing in their zone. Returns the same errors
+// as parseProviderSpec.
+func hashProviderSpec(spec v1beta1.MachineSpec) (string, error) {
+	if spec.ProviderSpec.Value == nil || len(spec.ProviderSpec.Value.Raw) == 0 {
+		return "", errMissingProviderSpec
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(spec.ProviderSpec.Value.Raw, &fields); err != nil {
+		return "", errors.Wrapf(err, "%s", errUnsupportedProviderSpec)
+	}
+	kind, _ := fields["kind"].(string)
+	zonalFields, found := zonalProviderSpecFields[kind]
+	if !found {
+		return "", errors.Errorf("%s: kind %q", errUnsupportedProviderSpec, kind)
+	}
+	for _, path := range zonalFields {
+		removeField(fields, path)
+	}
+	// Maps are marshalled with sorted keys, the hash doesn't depend
+	// on the order of the fields of the providerSpec.
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// removeField deletes the field at path from fields, if present.
+func removeField(fields map[string]interface{}, path []string) {
+	for i, name := range path {
+		if i == len(path)-1 {
+			delete(fields, name)
+			return
+		}
+		nested, ok := fields[name].(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields = nested
+	}
+}
+
+// labels returns the well-known labels of the nodes of the template.
+func (t *machineTemplate) labels() map[string]string {
+	labels := map[string]string{
+		corev1.LabelInstanceType: t.instanceType,
+	}
+	if t.region != "" {
+		labels[corev1.LabelZoneRegion] = t.region
+	}
+	if t.zone != "" {
+		labels[corev1.LabelZoneFailureDomain] = t.zone
+	}
+	return labels
+}
+
+func awsCapacity(instanceType string) corev1.ResourceList {
+	if t, found := awsInstanceTypes[instanceType]; found {
+		return buildCapacity(t.VCPU, t.MemoryMb, t.GPU)
+	}
+	return nil
+}
+
+func azureCapacity(vmSize string) corev1.ResourceList {
+	if t, found := azureInstanceTypes[vmSize]; found {
+		return buildCapacity(t.VCPU, t.MemoryMb, t.GPU)
+	}
+	return nil
+}
+
+// gcpMemoryMbPerCPU is the memory per vCPU of the predefined N1
+// machine types.
+var gcpMemoryMbPerCPU = map[string]float64{
+	"n1-standard": 3840,
+	"n1-highmem":  6656,
+	"n1-highcpu":  921.6,
+}
+
+// gcpCapacity returns the capacity of the predefined N1 and of the
+// custom machine types. The capacity of other machine types is only
+// available through the GCE API.
+func gcpCapacity(machineType string) corev1.ResourceList {
+	var cpu, memoryMb int64
+	if n, _ := fmt.Sscanf(machineType, "custom-%d-%d", &cpu, &memoryMb); n == 2 {
+		return buildCapacity(cpu, memoryMb, 0)
+	}
+	for family, memoryMbPerCPU := range gcpMemoryMbPerCPU {
+		if n, _ := fmt.Sscanf(machineType, family+"-%d", &cpu); n == 1 && machineType == fmt.Sprintf("%s-%d", family, cpu) {
+			return buildCapacity(cpu, int64(float64(cpu)*memoryMbPerCPU), 0)
+		}
+	}
+	return nil
+}
+
+func buildCapacity(cpu, memoryMb, gpus int64) corev1.ResourceList {
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewQuantity(cpu, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(memoryMb*1024*1024, resource.DecimalSI),
+		corev1.ResourcePods:   *resource.NewQuantity(defaultPodCapacity, resource.DecimalSI),
+	}
+	if gpus > 0 {
+		capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(gpus, resource.DecimalSI)
+	}
+	return capacity
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/azure"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+)
+
+func machineSpecWithProviderSpec(value string) v1beta1.MachineSpec {
+	return v1beta1.MachineSpec{
+		ProviderSpec: v1beta1.ProviderSpec{
+			Value: &runtime.RawExtension{Raw: []byte(value)},
+		},
+	}
+}
+
+func TestParseProviderSpec(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		value       string
+		error       error
+		labels      map[string]string
+		capacity    corev1.ResourceList
+	}{{
+		description: "AWS",
+		value:       `{"kind": "AWSMachineProviderConfig", "instanceType": "p2.xlarge", "placement": {"region": "us-east-1", "availabilityZone": "us-east-1a"}}`,
+		labels: map[string]string{
+			corev1.LabelInstanceType:      "p2.xlarge",
+			corev1.LabelZoneRegion:        "us-east-1",
+			corev1.LabelZoneFailureDomain: "us-east-1a",
+		},
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("62464Mi"),
+			gpu.ResourceNvidiaGPU: resource.MustParse("1"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		},
+	}, {
+		description: "Azure",
+		value:       `{"kind": "AzureMachineProviderSpec", "vmSize": "Standard_D4s_v3", "location": "eastus", "zone": "2"}`,
+		labels: map[string]string{
+			corev1.LabelInstanceType:      "Standard_D4s_v3",
+			corev1.LabelZoneRegion:        "eastus",
+			corev1.LabelZoneFailureDomain: "eastus-2",
+		},
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		},
+	}, {
+		description: "GCP predefined machine type",
+		value:       `{"kind": "GCPMachineProviderSpec", "machineType": "n1-standard-4", "region": "us-central1", "zone": "us-central1-b"}`,
+		labels: map[string]string{
+			corev1.LabelInstanceType:      "n1-standard-4",
+			corev1.LabelZoneRegion:        "us-central1",
+			corev1.LabelZoneFailureDomain: "us-central1-b",
+		},
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("15Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		},
+	}, {
+		description: "GCP custom machine type",
+		value:       `{"kind": "GCPMachineProviderSpec", "machineType": "custom-2-4096"}`,
+		labels: map[string]string{
+			corev1.LabelInstanceType: "custom-2-4096",
+		},
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		},
+	}, {
+		description: "unknown instance type has no capacity",
+		value:       `{"kind": "GCPMachineProviderSpec", "machineType": "e2-medium", "zone": "us-central1-b"}`,
+		labels: map[string]string{
+			corev1.LabelInstanceType:      "e2-medium",
+			corev1.LabelZoneFailureDomain: "us-central1-b",
+		},
+	}, {
+		description: "unsupported kind errors",
+		value:       `{"kind": "OpenstackProviderSpec", "flavor": "m1.large"}`,
+		error:       errUnsupportedProviderSpec,
+	}, {
+		description: "invalid JSON errors",
+		value:       `kind: AWSMachineProviderConfig`,
+		error:       errUnsupportedProviderSpec,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			template, err := parseProviderSpec(machineSpecWithProviderSpec(tc.value))
+			if tc.error != nil {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.error.Error()) {
+					t.Fatalf("expected error with prefix %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			labels := template.labels()
+			if len(labels) != len(tc.labels) {
+				t.Errorf("expected labels %v, got %v", tc.labels, labels)
+			}
+			for k, v := range tc.labels {
+				if labels[k] != v {
+					t.Errorf("expected label %s=%s, got %q", k, v, labels[k])
+				}
+			}
+			if len(template.capacity) != len(tc.capacity) {
+				t.Fatalf("expected %v, got %v", tc.capacity, template.capacity)
+			}
+			for name, expected := range tc.capacity {
+				if actual := template.capacity[name]; actual.Cmp(expected) != 0 {
+					t.Errorf("expected %s %v, got %v", name, expected.String(), actual.String())
+				}
+			}
+		})
+	}
+
+	if _, err := parseProviderSpec(v1beta1.MachineSpec{}); err != errMissingProviderSpec {
+		t.Errorf("expected %q, got %v", errMissingProviderSpec, err)
+	}
+}
+
+func TestHashProviderSpec(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		value1      string
+		value2      string
+		similar     bool
+	}{{
+		description: "AWS zones and subnets are ignored",
+		value1:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "placement": {"region": "us-east-1", "availabilityZone": "us-east-1a"}, "subnet": {"filters": [{"name": "tag:Name", "values": ["private-us-east-1a"]}]}}`,
+		value2:      `{"kind": "AWSMachineProviderConfig", "placement": {"availabilityZone": "us-east-1b", "region": "us-east-1"}, "instanceType": "m5.xlarge", "subnet": {"filters": [{"name": "tag:Name", "values": ["private-us-east-1b"]}]}}`,
+		similar:     true,
+	}, {
+		description: "AWS instance types differ",
+		value1:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "placement": {"region": "us-east-1", "availabilityZone": "us-east-1a"}}`,
+		value2:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.2xlarge", "placement": {"region": "us-east-1", "availabilityZone": "us-east-1b"}}`,
+	}, {
+		description: "AWS regions differ",
+		value1:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "placement": {"region": "us-east-1"}}`,
+		value2:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "placement": {"region": "us-west-2"}}`,
+	}, {
+		description: "AWS AMIs differ",
+		value1:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "ami": {"id": "ami-1"}}`,
+		value2:      `{"kind": "AWSMachineProviderConfig", "instanceType": "m5.xlarge", "ami": {"id": "ami-2"}}`,
+	}, {
+		description: "Azure zones are ignored",
+		value1:      `{"kind": "AzureMachineProviderSpec", "vmSize": "Standard_D4s_v3", "location": "eastus", "zone": "1"}`,
+		value2:      `{"kind": "AzureMachineProviderSpec", "vmSize": "Standard_D4s_v3", "location": "eastus", "zone": "2"}`,
+		similar:     true,
+	}, {
+		description: "GCP zones are ignored",
+		value1:      `{"kind": "GCPMachineProviderSpec", "machineType": "n1-standard-4", "region": "us-central1", "zone": "us-central1-a"}`,
+		value2:      `{"kind": "GCPMachineProviderSpec", "machineType": "n1-standard-4", "region": "us-central1", "zone": "us-central1-b"}`,
+		similar:     true,
+	}, {
+		description: "kinds differ",
+		value1:      `{"kind": "AzureMachineProviderSpec", "zone": "1"}`,
+		value2:      `{"kind": "GCPMachineProviderSpec", "zone": "1"}`,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			hash1, err := hashProviderSpec(machineSpecWithProviderSpec(tc.value1))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hash2, err := hashProviderSpec(machineSpecWithProviderSpec(tc.value2))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if similar := hash1 == hash2; similar != tc.similar {
+				t.Errorf("expected similar %v, got hashes %q and %q", tc.similar, hash1, hash2)
+			}
+		})
+	}
+
+	if _, err := hashProviderSpec(machineSpecWithProviderSpec(`{"kind": "OpenstackProviderSpec"}`)); err == nil || !strings.HasPrefix(err.Error(), errUnsupportedProviderSpec.Error()) {
+		t.Errorf("expected error with prefix %q, got %v", errUnsupportedProviderSpec, err)
+	}
+	if _, err := hashProviderSpec(v1beta1.MachineSpec{}); err != errMissingProviderSpec {
+		t.Errorf("expected %q, got %v", errMissingProviderSpec, err)
+	}
+}
+
+func TestInstanceTypesInSync(t *testing.T) {
+	if len(awsInstanceTypes) != len(aws.InstanceTypes) {
+		t.Errorf("expected %d aws instance types, got %d", len(aws.InstanceTypes), len(awsInstanceTypes))
+	}
+	for name, expected := range aws.InstanceTypes {
+		if actual := awsInstanceTypes[name]; actual != (instanceType{VCPU: expected.VCPU, MemoryMb: expected.MemoryMb, GPU: expected.GPU}) {
+			t.Errorf("expected aws instance type %q to be %+v, got %+v", name, *expected, actual)
+		}
+	}
+	if len(azureInstanceTypes) != len(azure.InstanceTypes) {
+		t.Errorf("expected %d azure instance types, got %d", len(azure.InstanceTypes), len(azureInstanceTypes))
+	}
+	for name, expected := range azure.InstanceTypes {
+		if actual := azureInstanceTypes[name]; actual != (instanceType{VCPU: expected.VCPU, MemoryMb: expected.MemoryMb, GPU: expected.GPU}) {
+			t.Errorf("expected azure instance type %q to be %+v, got %+v", name, *expected, actual)
+		}
+	}
+}
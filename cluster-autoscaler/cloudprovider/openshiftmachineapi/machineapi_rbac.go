@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// PolicyRules are the cluster-wide RBAC rules needed by this provider,
+// on top of the ones of cluster-autoscaler itself. They must be
+// updated together with the resources the controller watches and
+// updates. The rules of the machine API resources can be granted
+// with a Role in each namespace instead when --machine-api-namespace
+// is set.
+var PolicyRules = []rbacv1.PolicyRule{
+	{
+		// Machines are annotated for deletion and the templates of
+		// MachineSets not owned by a MachineDeployment are
+		// labelled, in whichever of machineAPIGroups the cluster
+		// serves.
+		APIGroups: machineAPIGroups,
+		Resources: []string{"machines", "machinesets"},
+		Verbs:     []string{"get", "list", "watch", "update"},
+	},
+	{
+		// MachineDeployments are created and deleted by node
+		// autoprovisioning.
+		APIGroups: machineAPIGroups,
+		Resources: []string{"machinedeployments"},
+		Verbs:     []string{"get", "list", "watch", "create", "delete"},
+	},
+	{
+		// MachineSets and MachineDeployments are scaled through
+		// their scale subresource.
+		APIGroups: machineAPIGroups,
+		Resources: []string{"machinesets/scale", "machinedeployments/scale"},
+		Verbs:     []string{"patch"},
+	},
+	{
+		APIGroups: machinePoolAPIGroups,
+		Resources: []string{"machinepools"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		// MachinePools are scaled through their scale
+		// subresource too.
+		APIGroups: machinePoolAPIGroups,
+		Resources: []string{"machinepools/scale"},
+		Verbs:     []string{"patch"},
+	},
+	{
+		// Instance classes, node group limits and machine
+		// templates are read from ConfigMaps in the namespaces of
+		// the scalable resources.
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{machineAutoscalerResource.Group},
+		Resources: []string{machineAutoscalerResource.Resource},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+)
+
+const (
+	initialUpdateRejectionBackoff = 1 * time.Minute
+	maxUpdateRejectionBackoff     = 30 * time.Minute
+)
+
+// admissionRejection returns the message of err if it is the denial
+// of a request by a validating admission webhook, such as the one of
+// the cluster-autoscaler-operator enforcing the bounds of the
+// MachineAutoscalers, and false otherwise.
+func admissionRejection(err error) (string, bool) {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok {
+		return "", false
+	}
+	message := status.Status().Message
+	if !strings.Contains(message, "admission webhook") || !strings.Contains(message, "denied the request") {
+		return "", false
+	}
+	return message, true
+}
+
+// updateRejections keeps track of the node groups whose last resize
+// was rejected by an admission webhook. Retrying such a resize right
+// away would only be rejected again, so node groups are not resized
+// in the same direction until their backoff expires. The backoff
+// doubles, up to maxUpdateRejectionBackoff, each time another resize
+// is rejected, and is reset once a resize in the same direction
+// succeeds.
+type updateRejections struct {
+	mutex      sync.Mutex
+	nodeGroups map[updateRejectionKey]*updateRejection
+}
+
+type updateRejectionKey struct {
+	id      string
+	scaleUp bool
+}
+
+type updateRejection struct {
+	message      string
+	duration     time.Duration
+	backoffUntil time.Time
+}
+
+func newUpdateRejections() *updateRejections {
+	return &updateRejections{
+		nodeGroups: make(map[updateRejectionKey]*updateRejection),
+	}
+}
+
+// rejected records the rejection of a scale-up, or of a scale-down,
+// of the node group with the given message.
+func (r *updateRejections) rejected(id string, scaleUp bool, message string, now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := updateRejectionKey{id: id, scaleUp: scaleUp}
+	rejection, found := r.nodeGroups[key]
+	if !found {
+		rejection = &updateRejection{}
+		r.nodeGroups[key] = rejection
+	}
+	duration := initialUpdateRejectionBackoff
+	if rejection.duration > 0 {
+		duration = 2 * rejection.duration
+		if duration > maxUpdateRejectionBackoff {
+			duration = maxUpdateRejectionBackoff
+		}
+	}
+	rejection.message = message
+	rejection.duration = duration
+	rejection.backoffUntil = now.Add(duration)
+	klog.Warningf("nodegroup %q: resize rejected, backing off until %s: %s", id, rejection.backoffUntil, message)
+}
+
+// updated records a successful scale-up, or scale-down, of the node
+// group.
+func (r *updateRejections) updated(id string, scaleUp bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := updateRejectionKey{id: id, scaleUp: scaleUp}
+	if _, found := r.nodeGroups[key]; found {
+		klog.V(2).Infof("nodegroup %q: resize accepted, removing backoff", id)
+		delete(r.nodeGroups, key)
+	}
+}
+
+// rejectedUntil returns the time until which scale-ups, or
+// scale-downs, of the node group are backed off and the message of
+// their last rejection, and false if they are not backed off.
+func (r *updateRejections) rejectedUntil(id string, scaleUp bool, now time.Time) (time.Time, string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rejection, found := r.nodeGroups[updateRejectionKey{id: id, scaleUp: scaleUp}]
+	if !found || !rejection.backoffUntil.After(now) {
+		return time.Time{}, "", false
+	}
+	return rejection.backoffUntil, rejection.message, true
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+)
+
+// newWebhookDenial returns the error of the API server for a request
+// denied by a validating admission webhook with the given message.
+func newWebhookDenial(message string) error {
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusForbidden,
+		Reason:  metav1.StatusReasonForbidden,
+		Message: `admission webhook "autoscaling.openshift.io" denied the request: ` + message,
+	}}
+}
+
+func TestAdmissionRejection(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		err         error
+		rejected    bool
+	}{{
+		description: "webhook denial",
+		err:         newWebhookDenial("maximum replicas is 3"),
+		rejected:    true,
+	}, {
+		description: "no error",
+	}, {
+		description: "not an API error",
+		err:         errors.New("admission webhook denied the request"),
+	}, {
+		description: "other API error",
+		err:         apierrors.NewForbidden(schema.GroupResource{Resource: "machinesets"}, "machineset-0", errors.New("no RBAC")),
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			message, rejected := admissionRejection(tc.err)
+			if rejected != tc.rejected {
+				t.Fatalf("expected rejected %v, got %v", tc.rejected, rejected)
+			}
+			if rejected && message != tc.err.Error() {
+				t.Errorf("expected message %q, got %q", tc.err.Error(), message)
+			}
+		})
+	}
+}
+
+func TestUpdateRejections(t *testing.T) {
+	const id = "ns/ng"
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := newUpdateRejections()
+
+	expectRejected := func(scaleUp bool, at time.Time, expected time.Time) {
+		t.Helper()
+		until, _, rejected := r.rejectedUntil(id, scaleUp, at)
+		if expected.IsZero() {
+			if rejected {
+				t.Errorf("expected no backoff at %s, got backoff until %s", at, until)
+			}
+			return
+		}
+		if !rejected || !until.Equal(expected) {
+			t.Errorf("expected backoff until %s at %s, got %s", expected, at, until)
+		}
+	}
+
+	expectRejected(true, now, time.Time{})
+
+	r.rejected(id, true, "denied", now)
+	expectRejected(true, now, now.Add(initialUpdateRejectionBackoff))
+	expectRejected(false, now, time.Time{})
+	expectRejected(true, now.Add(initialUpdateRejectionBackoff), time.Time{})
+
+	// Rejections double the backoff up to its maximum.
+	later := now.Add(time.Hour)
+	r.rejected(id, true, "denied", later)
+	expectRejected(true, later, later.Add(2*initialUpdateRejectionBackoff))
+	for i := 0; i < 10; i++ {
+		r.rejected(id, true, "denied", later)
+	}
+	expectRejected(true, later, later.Add(maxUpdateRejectionBackoff))
+
+	// Scale-downs don't reset the backoff of scale-ups.
+	r.updated(id, false)
+	expectRejected(true, later, later.Add(maxUpdateRejectionBackoff))
+
+	r.updated(id, true)
+	expectRejected(true, later, time.Time{})
+	r.rejected(id, true, "denied", later)
+	expectRejected(true, later, later.Add(initialUpdateRejectionBackoff))
+}
+
+func TestNodeGroupUpdateRejected(t *testing.T) {
+	test := func(t *testing.T, testConfig *testConfig) {
+		controller, stop := mustCreateTestController(t, testConfig)
+		defer stop()
+		recorder := record.NewFakeRecorder(10)
+		controller.recorder = recorder
+		client := controller.dynamicclient.(*fakeDynamicClient)
+		client.mu.Lock()
+		client.patchError = newWebhookDenial("maximum replicas is 3")
+		client.mu.Unlock()
+
+		nodegroups, err := controller.nodeGroups()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if l := len(nodegroups); l != 1 {
+			t.Fatalf("expected 1 nodegroup, got %d", l)
+		}
+		ng := nodegroups[0]
+
+		if err := ng.IncreaseSize(1); err == nil || !strings.Contains(err.Error(), "denied the request: maximum replicas is 3") {
+			t.Fatalf("expected webhook denial, got %v", err)
+		}
+		if event := <-recorder.Events; !strings.HasPrefix(event, "Warning UpdateRejected Setting replicas from 3 to 4 was rejected: ") {
+			t.Errorf("unexpected event %q", event)
+		}
+
+		// Scale-ups are not retried while backed off.
+		patches := client.patches
+		if err := ng.IncreaseSize(1); err == nil || !strings.Contains(err.Error(), "is backed off until") {
+			t.Errorf("expected backoff error, got %v", err)
+		}
+		if client.patches != patches {
+			t.Errorf("expected no patch while backed off, got %d", client.patches-patches)
+		}
+		maxSize, reason, limited := ng.ProviderMaxSize()
+		if !limited || maxSize != 3 || !strings.Contains(reason, "maximum replicas is 3") {
+			t.Errorf("expected max size 3 from the rejection, got %d %q %v", maxSize, reason, limited)
+		}
+
+		// Accepted scale-ups remove the backoff.
+		client.mu.Lock()
+		client.patchError = nil
+		client.mu.Unlock()
+		controller.updateRejections.nodeGroups[updateRejectionKey{id: ng.Id(), scaleUp: true}].backoffUntil = time.Now()
+		if err := ng.IncreaseSize(1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, rejected := controller.updateRejections.rejectedUntil(ng.Id(), true, time.Now()); rejected {
+			t.Errorf("expected no backoff after an accepted scale-up")
+		}
+
+		// Nodes of node groups whose scale-downs are backed off are
+		// not offered for scale-down, rather than failing to be
+		// deleted once drained.
+		if reason, blocked := ng.nodeDeletionBlocked(); blocked {
+			t.Errorf("expected the deletion of nodes not to be blocked, got %q", reason)
+		}
+		controller.updateRejections.rejected(ng.Id(), false, "minimum replicas is 3", time.Now())
+		if reason, blocked := ng.nodeDeletionBlocked(); !blocked || !strings.Contains(reason, "minimum replicas is 3") {
+			t.Errorf("expected the deletion of nodes to be blocked by the rejection, got %v %q", blocked, reason)
+		}
+		if err := ng.IncreaseSize(1); err != nil {
+			t.Errorf("expected scale-ups not to be backed off by a rejected scale-down, got %v", err)
+		}
+	}
+
+	annotations := map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	}
+
+	t.Run("MachineSet", func(t *testing.T) {
+		test(t, createMachineSetTestConfig(testNamespace, 3, annotations))
+	})
+
+	t.Run("MachineDeployment", func(t *testing.T) {
+		test(t, createMachineDeploymentTestConfig(testNamespace, 3, annotations))
+	})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"fmt"
+
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+// machineExternalRemediationAnnotationKey is set on a machine by a
+// MachineHealthCheck handing its remediation over to an external
+// controller, which reboots or reprovisions the machine. The
+// machines of this API version have no conditions to report it.
+const machineExternalRemediationAnnotationKey = "host.metal3.io/external-remediation"
+
+// machineScaleDownDisabledAnnotationKey set to "true" on a machine
+// protects its node from scale down, like the annotation of the same
+// name on nodes, for machines managed declaratively whose nodes are
+// not.
+const machineScaleDownDisabledAnnotationKey = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
+
+var _ cloudprovider.UnremovableNodeCloudProvider = (*provider)(nil)
+
+// isUnderRemediation returns true if a MachineHealthCheck is
+// remediating the machine.
+func isUnderRemediation(machine *v1beta1.Machine) bool {
+	_, found := machine.Annotations[machineExternalRemediationAnnotationKey]
+	return found
+}
+
+// isScaleDownDisabled returns true if the annotations of the machine
+// protect its node from scale down.
+func isScaleDownDisabled(machine *v1beta1.Machine) bool {
+	return machine.Annotations[machineScaleDownDisabledAnnotationKey] == "true"
+}
+
+// IsNodeUnremovable returns true for the nodes whose machine is
+// being remediated, so that scale down doesn't race with the
+// MachineHealthCheck replacing or rebooting it, for the nodes whose
+// machine has scale down disabled, and for the nodes of node groups
+// which cannot delete them, so that scale down doesn't drain them
+// in vain.
+func (p *provider) IsNodeUnremovable(node *corev1.Node) (bool, string, error) {
+	machine, err := p.controller.findMachineByProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return false, "", err
+	}
+	if machine != nil {
+		if isScaleDownDisabled(machine) {
+			return true, fmt.Sprintf("machine %s/%s has scale down disabled", machine.Namespace, machine.Name), nil
+		}
+		if isUnderRemediation(machine) {
+			return true, fmt.Sprintf("machine %s/%s is being remediated", machine.Namespace, machine.Name), nil
+		}
+	}
+	ng, err := p.controller.nodeGroupForNode(node)
+	if err != nil {
+		return false, "", err
+	}
+	if ng == nil {
+		return false, "", nil
+	}
+	reason, blocked := ng.nodeDeletionBlocked()
+	return blocked, reason, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+func TestProviderIsNodeUnremovable(t *testing.T) {
+	testConfig := createMachineSetTestConfig(testNamespace, 3, map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	provider, err := newProvider(ProviderName, &cloudprovider.ResourceLimiter{}, controller)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := provider.(cloudprovider.UnremovableNodeCloudProvider)
+
+	machine := testConfig.machines[0].DeepCopy()
+	machine.Annotations = map[string]string{
+		machineExternalRemediationAnnotationKey: "",
+	}
+	if err := controller.machineInformer.GetStore().Update(machine); err != nil {
+		t.Fatalf("unexpected error updating machine, got %v", err)
+	}
+
+	protected := testConfig.machines[2].DeepCopy()
+	protected.Annotations = map[string]string{
+		machineScaleDownDisabledAnnotationKey: "true",
+	}
+	if err := controller.machineInformer.GetStore().Update(protected); err != nil {
+		t.Fatalf("unexpected error updating machine, got %v", err)
+	}
+
+	unremovable, reason, err := p.IsNodeUnremovable(testConfig.nodes[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !unremovable || reason == "" {
+		t.Errorf("expected the node of the remediated machine to be unremovable, got %v %q", unremovable, reason)
+	}
+
+	unremovable, _, err = p.IsNodeUnremovable(testConfig.nodes[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unremovable {
+		t.Errorf("expected the node of the healthy machine to be removable")
+	}
+
+	unremovable, reason, err = p.IsNodeUnremovable(testConfig.nodes[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !unremovable || !strings.Contains(reason, "scale down disabled") {
+		t.Errorf("expected the node of the protected machine to be unremovable, got %v %q", unremovable, reason)
+	}
+
+	unremovable, _, err = p.IsNodeUnremovable(&corev1.Node{
+		Spec: corev1.NodeSpec{ProviderID: "does-not-exist"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unremovable {
+		t.Errorf("expected a node without a machine to be removable")
+	}
+}
@@ -16,6 +16,10 @@ limitations under the License.
 
 package openshiftmachineapi
 
+import (
+	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
+)
+
 // scalableResource is a resource that can be scaled up and down by
 // adjusting its replica count field.
 type scalableResource interface {
@@ -43,4 +47,18 @@ type scalableResource interface {
 
 	// Replicas returns the current replica count of the resource
 	Replicas() int32
+
+	// Annotations returns the annotations of the resource
+	Annotations() map[string]string
+
+	// Labels returns the labels of the resource
+	Labels() map[string]string
+
+	// MachineSpec returns the spec of the machines of the resource
+	MachineSpec() v1beta1.MachineSpec
+
+	// Problem returns the reason and message of the terminal
+	// error the controller of the resource reports reconciling
+	// its replicas, ok is false if there is none
+	Problem() (reason, message string, ok bool)
 }
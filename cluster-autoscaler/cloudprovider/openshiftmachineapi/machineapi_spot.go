@@ -0,0 +1,36 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+const (
+	// spotLabelKey is the label of the nodes backed by spot or
+	// preemptible instances, which the machine controllers set on
+	// the nodes of interruptible machines.
+	spotLabelKey = machineAPIGroup + "/interruptible-instance"
+
+	// nodeGroupSpotAnnotationKey set to "true" marks the machines
+	// of a MachineSet or MachineDeployment as backed by spot or
+	// preemptible instances, so that its template node gets
+	// spotLabelKey.
+	nodeGroupSpotAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-spot"
+)
+
+// isSpot returns true if the annotations mark the machines of the
+// scalable resource as backed by spot or preemptible instances.
+func isSpot(annotations map[string]string) bool {
+	return annotations[nodeGroupSpotAnnotationKey] == "true"
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openshiftmachineapi
+
+import (
+	"testing"
+)
+
+func TestNodeGroupTemplateNodeInfoSpotLabel(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		spot        string
+		expected    bool
+	}{
+		{"spot annotation", "true", true},
+		{"spot annotation set to false", "false", false},
+		{"no spot annotation", "", false},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			annotations := map[string]string{
+				nodeGroupMinSizeAnnotationKey: "0",
+				nodeGroupMaxSizeAnnotationKey: "10",
+				cpuCapacityAnnotationKey:      "8",
+				memoryCapacityAnnotationKey:   "32Gi",
+			}
+			if tc.spot != "" {
+				annotations[nodeGroupSpotAnnotationKey] = tc.spot
+			}
+			controller, stop := mustCreateTestController(t, createMachineSetTestConfig(testNamespace, 0, annotations))
+			defer stop()
+
+			nodegroups, err := controller.nodeGroups()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if l := len(nodegroups); l != 1 {
+				t.Fatalf("expected 1 nodegroup, got %d", l)
+			}
+
+			nodeInfo, err := nodegroups[0].TemplateNodeInfo()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, found := nodeInfo.Node().Labels[spotLabelKey]; found != tc.expected {
+				t.Errorf("expected spot label %v, got %v", tc.expected, found)
+			}
+		})
+	}
+}
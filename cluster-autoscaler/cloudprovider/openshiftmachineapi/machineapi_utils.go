@@ -18,6 +18,8 @@ package openshiftmachineapi
 
 import (
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
 	"github.com/pkg/errors"
@@ -25,8 +27,52 @@ import (
 )
 
 const (
-	nodeGroupMinSizeAnnotationKey = "machine.openshift.io/cluster-api-autoscaler-node-group-min-size"
-	nodeGroupMaxSizeAnnotationKey = "machine.openshift.io/cluster-api-autoscaler-node-group-max-size"
+	// machineAPIGroup is the API group of the machine resources
+	// and the prefix of the annotation keys of this provider.
+	machineAPIGroup = "machine.openshift.io"
+
+	nodeGroupMinSizeAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-min-size"
+	nodeGroupMaxSizeAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-max-size"
+
+	// nodeGroupAvailableIPsAnnotationKey is set by the infrastructure
+	// provider to the number of free IP addresses in the subnets
+	// machines of the node group are created in.
+	nodeGroupAvailableIPsAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-available-ips"
+
+	// nodeGroupMaxNodeProvisionTimeAnnotationKey overrides
+	// --max-node-provision-time for the machines of the node group,
+	// e.g. for bare metal hosts taking much longer to provision than
+	// cloud instances. Values are durations such as "45m".
+	nodeGroupMaxNodeProvisionTimeAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-max-node-provision-time"
+
+	// nodeGroupReadinessConditionsAnnotationKey and
+	// nodeGroupReadinessLabelsAnnotationKey override
+	// --node-readiness-condition and --node-readiness-label for the
+	// nodes of the node group. Values are comma-separated lists, an
+	// empty value removing the readiness gates.
+	nodeGroupReadinessConditionsAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-readiness-conditions"
+	nodeGroupReadinessLabelsAnnotationKey     = machineAPIGroup + "/cluster-api-autoscaler-node-group-readiness-labels"
+
+	// nodeGroupStartupTaintsAnnotationKey lists the keys of the
+	// taints, on top of the uninitialized and startup-taint prefixed
+	// ones, that the new nodes of the node group carry until they are
+	// initialized, e.g. "node.cilium.io/agent-not-ready". Values are
+	// comma-separated lists.
+	nodeGroupStartupTaintsAnnotationKey = machineAPIGroup + "/cluster-api-autoscaler-node-group-startup-taints"
+
+	// nodeGroupPausedAnnotationKey set to "true" excludes a scalable
+	// resource from autoscaling without removing its min/max
+	// annotations, e.g. during maintenance.
+	nodeGroupPausedAnnotationKey = "cluster-autoscaler.kubernetes.io/paused"
+
+	// pendingMachinePrefix prefixes the namespace/name key of
+	// machines that have no node yet to give them a placeholder
+	// provider ID.
+	pendingMachinePrefix = "pending-machine." + machineAPIGroup + "://"
+
+	// machineSetLabelKey labels the machines of a MachineSet with
+	// the name of the MachineSet.
+	machineSetLabelKey = machineAPIGroup + "/cluster-api-machineset"
 )
 
 var (
@@ -47,6 +93,26 @@ var (
 	// errInvalidMaxAnnotationValue is the error returned when a
 	// machine set has a non-integral max annotation value.
 	errInvalidMaxAnnotation = errors.New("invalid max annotation")
+
+	// errMissingAvailableIPsAnnotation is the error returned when a
+	// machine set does not have an annotation keyed by
+	// nodeGroupAvailableIPsAnnotationKey.
+	errMissingAvailableIPsAnnotation = errors.New("missing available IPs annotation")
+
+	// errInvalidAvailableIPsAnnotation is the error returned when a
+	// machine set has a negative or non-integral available IPs
+	// annotation value.
+	errInvalidAvailableIPsAnnotation = errors.New("invalid available IPs annotation")
+
+	// errMissingMaxNodeProvisionTimeAnnotation is the error returned
+	// when a machine set does not have an annotation keyed by
+	// nodeGroupMaxNodeProvisionTimeAnnotationKey.
+	errMissingMaxNodeProvisionTimeAnnotation = errors.New("missing max node provision time annotation")
+
+	// errInvalidMaxNodeProvisionTimeAnnotation is the error returned
+	// when a machine set has a non-positive or unparsable max node
+	// provision time annotation value.
+	errInvalidMaxNodeProvisionTimeAnnotation = errors.New("invalid max node provision time annotation")
 )
 
 // minSize returns the minimum value encoded in the annotations keyed
@@ -81,6 +147,69 @@ func maxSize(annotations map[string]string) (int, error) {
 	return i, nil
 }
 
+// availableIPs returns the value encoded in the annotation keyed by
+// nodeGroupAvailableIPsAnnotationKey. Returns
+// errMissingAvailableIPsAnnotation if the annotation doesn't exist or
+// errInvalidAvailableIPsAnnotation if the value is not a non-negative
+// int.
+func availableIPs(annotations map[string]string) (int, error) {
+	val, found := annotations[nodeGroupAvailableIPsAnnotationKey]
+	if !found {
+		return 0, errMissingAvailableIPsAnnotation
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, errors.Wrapf(err, "%s", errInvalidAvailableIPsAnnotation)
+	}
+	if i < 0 {
+		return 0, errInvalidAvailableIPsAnnotation
+	}
+	return i, nil
+}
+
+// maxNodeProvisionTime returns the duration encoded in the annotation
+// keyed by nodeGroupMaxNodeProvisionTimeAnnotationKey. Returns
+// errMissingMaxNodeProvisionTimeAnnotation if the annotation doesn't
+// exist or errInvalidMaxNodeProvisionTimeAnnotation if the value is
+// not a positive duration.
+func maxNodeProvisionTime(annotations map[string]string) (time.Duration, error) {
+	val, found := annotations[nodeGroupMaxNodeProvisionTimeAnnotationKey]
+	if !found {
+		return 0, errMissingMaxNodeProvisionTimeAnnotation
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, errors.Wrapf(err, "%s", errInvalidMaxNodeProvisionTimeAnnotation)
+	}
+	if d <= 0 {
+		return 0, errInvalidMaxNodeProvisionTimeAnnotation
+	}
+	return d, nil
+}
+
+// annotationList returns the comma-separated values of the
+// annotation, e.g. readiness conditions or startup taints, and false
+// if the annotation is not set.
+func annotationList(annotations map[string]string, key string) ([]string, bool) {
+	val, found := annotations[key]
+	if !found {
+		return nil, false
+	}
+	values := []string{}
+	for _, value := range strings.Split(val, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values, true
+}
+
+// isPaused returns true if the annotations pause the autoscaling of
+// the scalable resource.
+func isPaused(annotations map[string]string) bool {
+	return annotations[nodeGroupPausedAnnotationKey] == "true"
+}
+
 func parseScalingBounds(annotations map[string]string) (int, int, error) {
 	minSize, err := minSize(annotations)
 	if err != nil && err != errMissingMinAnnotation {
@@ -144,3 +273,44 @@ func machineSetIsOwnedByMachineDeployment(machineSet *v1beta1.MachineSet, machin
 	}
 	return false
 }
+
+// pendingMachineProviderID returns the placeholder provider ID of a
+// machine that has not produced a node yet.
+func pendingMachineProviderID(machine *v1beta1.Machine) string {
+	return pendingMachinePrefix + machine.Namespace + "/" + machine.Name
+}
+
+func isPendingMachineProviderID(providerID string) bool {
+	return strings.HasPrefix(providerID, pendingMachinePrefix)
+}
+
+// machineKeyFromPendingMachineProviderID returns the namespace/name
+// key of the machine a placeholder provider ID was given to.
+func machineKeyFromPendingMachineProviderID(providerID string) string {
+	return strings.TrimPrefix(providerID, pendingMachinePrefix)
+}
+
+// normalizedProviderID returns the form of providerID that is used to
+// match machines, machine pools and nodes, so that the provider IDs
+// actuators and cloud controller managers set for the same instance
+// in different formats match. The scheme is lowercased and the slashes
+// around the path are dropped. AWS and OpenStack provider IDs are
+// reduced to the instance ID, which is unique, dropping the zone
+// (aws:///us-east-1a/i-0123 and aws:///i-0123 match). Azure resource
+// IDs are case insensitive and lowercased. Provider IDs without a
+// scheme are returned unchanged.
+func normalizedProviderID(providerID string) string {
+	i := strings.Index(providerID, "://")
+	if i < 0 {
+		return providerID
+	}
+	scheme := strings.ToLower(providerID[:i])
+	path := strings.Trim(providerID[i+len("://"):], "/")
+	switch scheme {
+	case "aws", "openstack":
+		path = path[strings.LastIndex(path, "/")+1:]
+	case "azure":
+		path = strings.ToLower(path)
+	}
+	return scheme + "://" + path
+}
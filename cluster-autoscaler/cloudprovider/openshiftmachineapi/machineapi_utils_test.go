@@ -19,6 +19,7 @@ package openshiftmachineapi
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -142,6 +143,112 @@ func TestParseScalingBounds(t *testing.T) {
 	}
 }
 
+func TestAvailableIPs(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		error       error
+		available   int
+	}{{
+		description: "missing annotation errors",
+		annotations: map[string]string{},
+		error:       errMissingAvailableIPsAnnotation,
+	}, {
+		description: "negative value errors",
+		annotations: map[string]string{
+			nodeGroupAvailableIPsAnnotationKey: "-1",
+		},
+		error: errInvalidAvailableIPsAnnotation,
+	}, {
+		description: "non-integral value errors",
+		annotations: map[string]string{
+			nodeGroupAvailableIPsAnnotationKey: "not-an-int",
+		},
+		error: errInvalidAvailableIPsAnnotation,
+	}, {
+		description: "result is 0",
+		annotations: map[string]string{
+			nodeGroupAvailableIPsAnnotationKey: "0",
+		},
+		available: 0,
+	}, {
+		description: "result is 42",
+		annotations: map[string]string{
+			nodeGroupAvailableIPsAnnotationKey: "42",
+		},
+		available: 42,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			available, err := availableIPs(tc.annotations)
+			if tc.error != nil {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if !strings.HasPrefix(err.Error(), tc.error.Error()) {
+					t.Errorf("expected message to have prefix %q, got %q", tc.error.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.available != available {
+				t.Errorf("expected %d, got %d", tc.available, available)
+			}
+		})
+	}
+}
+
+func TestMaxNodeProvisionTime(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		annotations map[string]string
+		error       error
+		duration    time.Duration
+	}{{
+		description: "missing annotation errors",
+		annotations: map[string]string{},
+		error:       errMissingMaxNodeProvisionTimeAnnotation,
+	}, {
+		description: "zero value errors",
+		annotations: map[string]string{
+			nodeGroupMaxNodeProvisionTimeAnnotationKey: "0s",
+		},
+		error: errInvalidMaxNodeProvisionTimeAnnotation,
+	}, {
+		description: "non-duration value errors",
+		annotations: map[string]string{
+			nodeGroupMaxNodeProvisionTimeAnnotationKey: "45",
+		},
+		error: errInvalidMaxNodeProvisionTimeAnnotation,
+	}, {
+		description: "result is 45m",
+		annotations: map[string]string{
+			nodeGroupMaxNodeProvisionTimeAnnotationKey: "45m",
+		},
+		duration: 45 * time.Minute,
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			duration, err := maxNodeProvisionTime(tc.annotations)
+			if tc.error != nil {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if !strings.HasPrefix(err.Error(), tc.error.Error()) {
+					t.Errorf("expected message to have prefix %q, got %q", tc.error.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.duration != duration {
+				t.Errorf("expected %v, got %v", tc.duration, duration)
+			}
+		})
+	}
+}
+
 func TestMachineSetIsOwnedByMachineDeployment(t *testing.T) {
 	for _, tc := range []struct {
 		description       string
@@ -370,3 +477,46 @@ func TestMachineSetMachineDeploymentOwnerRef(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizedProviderID(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		providerIDs []string
+		normalized  string
+	}{{
+		description: "AWS provider IDs with and without zone",
+		providerIDs: []string{"aws:///us-east-1a/i-0123456789abcdef0", "aws:///i-0123456789abcdef0", "aws://us-east-1a/i-0123456789abcdef0", "AWS:///i-0123456789abcdef0"},
+		normalized:  "aws://i-0123456789abcdef0",
+	}, {
+		description: "Azure resource IDs in different cases",
+		providerIDs: []string{
+			"azure:///subscriptions/sub/resourceGroups/RG/providers/Microsoft.Compute/virtualMachines/worker-1",
+			"azure://subscriptions/sub/resourcegroups/rg/providers/microsoft.compute/virtualmachines/worker-1",
+		},
+		normalized: "azure://subscriptions/sub/resourcegroups/rg/providers/microsoft.compute/virtualmachines/worker-1",
+	}, {
+		description: "OpenStack provider IDs with and without region",
+		providerIDs: []string{"openstack:///" + uuid1, "openstack://regionOne/" + uuid1},
+		normalized:  "openstack://" + uuid1,
+	}, {
+		description: "GCE provider IDs keep their zone",
+		providerIDs: []string{"gce://project/us-central1-a/worker-1", "gce://project/us-central1-a/worker-1/"},
+		normalized:  "gce://project/us-central1-a/worker-1",
+	}, {
+		description: "provider IDs without scheme are unchanged",
+		providerIDs: []string{"i-0123456789abcdef0"},
+		normalized:  "i-0123456789abcdef0",
+	}} {
+		t.Run(tc.description, func(t *testing.T) {
+			for _, providerID := range tc.providerIDs {
+				if actual := normalizedProviderID(providerID); actual != tc.normalized {
+					t.Errorf("expected %q to be normalized to %q, got %q", providerID, tc.normalized, actual)
+				}
+			}
+		})
+	}
+
+	if a, b := normalizedProviderID("gce://project/us-central1-a/worker-1"), normalizedProviderID("gce://project/us-central1-b/worker-1"); a == b {
+		t.Errorf("expected GCE provider IDs of different zones to differ, got %q", a)
+	}
+}
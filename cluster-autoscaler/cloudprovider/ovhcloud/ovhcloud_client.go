@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const requestTimeout = 30 * time.Second
+
+// nodePool is a node pool of an OVHcloud managed Kubernetes cluster.
+type nodePool struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	Flavor       string           `json:"flavor"`
+	Autoscale    bool             `json:"autoscale"`
+	MinNodes     int              `json:"minNodes"`
+	MaxNodes     int              `json:"maxNodes"`
+	DesiredNodes int              `json:"desiredNodes"`
+	CurrentNodes int              `json:"currentNodes"`
+	Status       string           `json:"status"`
+	Template     nodePoolTemplate `json:"template"`
+}
+
+// nodePoolTemplate is applied by OVHcloud to the nodes of the pool.
+type nodePoolTemplate struct {
+	Metadata struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Taints []apiv1.Taint `json:"taints"`
+	} `json:"spec"`
+}
+
+// node is a node of a node pool. InstanceID is empty until the instance is created.
+type node struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	InstanceID string `json:"instanceId"`
+	NodePoolID string `json:"nodePoolId"`
+	Status     string `json:"status"`
+}
+
+// flavor describes the resources of the instances of a node pool. RAM is in GB.
+type flavor struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	State    string `json:"state"`
+	VCPUs    int64  `json:"vCPUs"`
+	GPUs     int64  `json:"gpus"`
+	RAM      int64  `json:"ram"`
+}
+
+type updateNodePoolRequest struct {
+	DesiredNodes  int      `json:"desiredNodes"`
+	NodesToRemove []string `json:"nodesToRemove,omitempty"`
+}
+
+// ovhClient is the subset of the OVHcloud API used by the cloud provider.
+type ovhClient interface {
+	listNodePools() ([]nodePool, error)
+	listNodes(poolID string) ([]node, error)
+	listFlavors() ([]flavor, error)
+	updateNodePool(poolID string, desiredNodes int, nodesToRemove []string) error
+}
+
+type ovhHTTPClient struct {
+	endpoint          string
+	applicationKey    string
+	applicationSecret string
+	consumerKey       string
+	// basePath is the path of the cluster resources.
+	basePath   string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func newOVHHTTPClient(cfg *CloudConfig) *ovhHTTPClient {
+	return &ovhHTTPClient{
+		endpoint:          strings.TrimSuffix(cfg.Endpoint, "/"),
+		applicationKey:    cfg.ApplicationKey,
+		applicationSecret: cfg.ApplicationSecret,
+		consumerKey:       cfg.ConsumerKey,
+		basePath:          fmt.Sprintf("/cloud/project/%s/kube/%s", cfg.ProjectID, cfg.ClusterID),
+		httpClient:        &http.Client{Timeout: requestTimeout},
+		now:               time.Now,
+	}
+}
+
+func (c *ovhHTTPClient) listNodePools() ([]nodePool, error) {
+	var pools []nodePool
+	return pools, c.do(http.MethodGet, c.basePath+"/nodepool", nil, &pools)
+}
+
+func (c *ovhHTTPClient) listNodes(poolID string) ([]node, error) {
+	var nodes []node
+	return nodes, c.do(http.MethodGet, fmt.Sprintf("%s/nodepool/%s/nodes", c.basePath, poolID), nil, &nodes)
+}
+
+func (c *ovhHTTPClient) listFlavors() ([]flavor, error) {
+	var flavors []flavor
+	return flavors, c.do(http.MethodGet, c.basePath+"/flavors", nil, &flavors)
+}
+
+func (c *ovhHTTPClient) updateNodePool(poolID string, desiredNodes int, nodesToRemove []string) error {
+	request := &updateNodePoolRequest{DesiredNodes: desiredNodes, NodesToRemove: nodesToRemove}
+	return c.do(http.MethodPut, fmt.Sprintf("%s/nodepool/%s", c.basePath, poolID), request, nil)
+}
+
+// signature returns the X-Ovh-Signature header value of the request.
+func (c *ovhHTTPClient) signature(method, url string, body []byte, timestamp int64) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s+%s+%s+%s+%s+%d", c.applicationSecret, c.consumerKey, method, url, body, timestamp)
+	return fmt.Sprintf("$1$%x", h.Sum(nil))
+}
+
+func (c *ovhHTTPClient) do(method, path string, body interface{}, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	url := c.endpoint + path
+	request, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	timestamp := c.now().Unix()
+	request.Header.Set("X-Ovh-Application", c.applicationKey)
+	request.Header.Set("X-Ovh-Consumer", c.consumerKey)
+	request.Header.Set("X-Ovh-Timestamp", fmt.Sprint(timestamp))
+	request.Header.Set("X-Ovh-Signature", c.signature(method, url, payload, timestamp))
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	klog.V(5).Infof("OVHcloud request: %s %s", method, path)
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s failed to read response: %v", method, path, err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, response.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("%s %s returned invalid response: %v", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOVHHTTPClient(t *testing.T) {
+	now := time.Unix(1570000000, 0)
+
+	var requests []string
+	var bodies []map[string]interface{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		expected := fmt.Sprintf("$1$%x", sha1.Sum([]byte(fmt.Sprintf("secret+consumer+%s+%s%s+%s+1570000000",
+			r.Method, server.URL, r.URL.EscapedPath(), data))))
+		if r.Header.Get("X-Ovh-Application") != "app" || r.Header.Get("X-Ovh-Consumer") != "consumer" ||
+			r.Header.Get("X-Ovh-Timestamp") != "1570000000" || r.Header.Get("X-Ovh-Signature") != expected {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		body := make(map[string]interface{})
+		json.Unmarshal(data, &body)
+		bodies = append(bodies, body)
+
+		switch r.URL.EscapedPath() {
+		case "/1.0/cloud/project/project/kube/cluster/nodepool":
+			fmt.Fprint(w, `[{"id": "np-1", "name": "workers", "flavor": "b2-7", "desiredNodes": 2,
+				"template": {"metadata": {"labels": {"team": "a"}, "annotations": {"`+maxSizeAnnotation+`": "5"}},
+				"spec": {"taints": [{"key": "dedicated", "value": "a", "effect": "NoSchedule"}]}}}]`)
+		case "/1.0/cloud/project/project/kube/cluster/nodepool/np-1/nodes":
+			fmt.Fprint(w, `[{"id": "node-1", "name": "workers-node-1", "instanceId": "instance-1", "nodePoolId": "np-1", "status": "READY"}]`)
+		case "/1.0/cloud/project/project/kube/cluster/flavors":
+			fmt.Fprint(w, `[{"name": "b2-7", "category": "b", "state": "available", "vCPUs": 2, "gpus": 0, "ram": 7}]`)
+		case "/1.0/cloud/project/project/kube/cluster/nodepool/np-1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &CloudConfig{
+		Endpoint:          server.URL + "/1.0/",
+		ApplicationKey:    "app",
+		ApplicationSecret: "secret",
+		ConsumerKey:       "consumer",
+		ProjectID:         "project",
+		ClusterID:         "cluster",
+	}
+	client := newOVHHTTPClient(cfg)
+	client.now = func() time.Time { return now }
+
+	pools, err := client.listNodePools()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pools))
+	assert.Equal(t, "b2-7", pools[0].Flavor)
+	assert.Equal(t, 2, pools[0].DesiredNodes)
+	assert.Equal(t, map[string]string{"team": "a"}, pools[0].Template.Metadata.Labels)
+	assert.Equal(t, "5", pools[0].Template.Metadata.Annotations[maxSizeAnnotation])
+	assert.Equal(t, []apiv1.Taint{{Key: "dedicated", Value: "a", Effect: apiv1.TaintEffectNoSchedule}}, pools[0].Template.Spec.Taints)
+
+	nodes, err := client.listNodes("np-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []node{{ID: "node-1", Name: "workers-node-1", InstanceID: "instance-1", NodePoolID: "np-1", Status: "READY"}}, nodes)
+
+	flavors, err := client.listFlavors()
+	assert.NoError(t, err)
+	assert.Equal(t, []flavor{{Name: "b2-7", Category: "b", State: "available", VCPUs: 2, RAM: 7}}, flavors)
+
+	assert.NoError(t, client.updateNodePool("np-1", 3, nil))
+	assert.NoError(t, client.updateNodePool("np-1", 1, []string{"node-1"}))
+	assert.Equal(t, []string{
+		"GET /1.0/cloud/project/project/kube/cluster/nodepool",
+		"GET /1.0/cloud/project/project/kube/cluster/nodepool/np-1/nodes",
+		"GET /1.0/cloud/project/project/kube/cluster/flavors",
+		"PUT /1.0/cloud/project/project/kube/cluster/nodepool/np-1",
+		"PUT /1.0/cloud/project/project/kube/cluster/nodepool/np-1",
+	}, requests)
+	assert.Equal(t, map[string]interface{}{"desiredNodes": float64(3)}, bodies[3])
+	assert.Equal(t, map[string]interface{}{"desiredNodes": float64(1), "nodesToRemove": []interface{}{"node-1"}}, bodies[4])
+
+	_, err = client.listNodes("missing")
+	assert.EqualError(t, err, `GET /cloud/project/project/kube/cluster/nodepool/missing/nodes failed with status 404: {"message": "not found"}`)
+
+	cfg.ApplicationSecret = "wrong"
+	_, err = newOVHHTTPClient(cfg).listNodePools()
+	assert.Error(t, err)
+}
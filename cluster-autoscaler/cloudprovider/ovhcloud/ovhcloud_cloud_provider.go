@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"io"
+	"os"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for OVHcloud.
+	ProviderName = "ovhcloud"
+)
+
+// ovhCloudProvider implements CloudProvider interface for OVHcloud managed Kubernetes clusters.
+type ovhCloudProvider struct {
+	manager         *ovhManager
+	resourceLimiter *cloudprovider.ResourceLimiter
+}
+
+// BuildOVHcloud builds the OVHcloud cloud provider, scaling managed node pools through the OVHcloud API.
+func BuildOVHcloud(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	var configReader io.Reader
+	if opts.CloudConfig != "" {
+		configFile, err := os.Open(opts.CloudConfig)
+		if err != nil {
+			klog.Fatalf("Couldn't open cloud provider configuration %s: %#v", opts.CloudConfig, err)
+		}
+		defer configFile.Close()
+		configReader = configFile
+	}
+	cfg, err := readConfig(configReader)
+	if err != nil {
+		klog.Fatalf("Failed to read OVHcloud cloud provider configuration: %v", err)
+	}
+	if do.AutoDiscoverySpecified() {
+		klog.Fatalf("Failed to create OVHcloud cloud provider: node pools are discovered from their %s annotation, --node-group-auto-discovery isn't supported", maxSizeAnnotation)
+	}
+
+	manager, err := newOVHManager(newOVHHTTPClient(cfg), do.NodeGroupSpecs)
+	if err != nil {
+		klog.Fatalf("Failed to create OVHcloud manager: %v", err)
+	}
+	if err := manager.refresh(); err != nil {
+		klog.Fatalf("Failed to list OVHcloud node pools: %v", err)
+	}
+	return &ovhCloudProvider{
+		manager:         manager,
+		resourceLimiter: rl,
+	}
+}
+
+// Name returns name of the cloud provider.
+func (ovh *ovhCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (ovh *ovhCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	pools := ovh.manager.managedNodePools()
+	result := make([]cloudprovider.NodeGroup, 0, len(pools))
+	for _, pool := range pools {
+		result = append(result, pool)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node.
+func (ovh *ovhCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	ref, found := ovh.manager.nodeForProviderID(node.Spec.ProviderID)
+	if !found {
+		return nil, nil
+	}
+	return ref.pool, nil
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+func (ovh *ovhCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
+func (ovh *ovhCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition provided.
+func (ovh *ovhCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (ovh *ovhCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return ovh.resourceLimiter, nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (ovh *ovhCloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh reloads the node pools of the cluster and their nodes.
+func (ovh *ovhCloudProvider) Refresh() error {
+	return ovh.manager.refresh()
+}
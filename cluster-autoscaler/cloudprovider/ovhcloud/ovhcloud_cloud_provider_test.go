@@ -0,0 +1,291 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nodePoolUpdate struct {
+	desiredNodes  int
+	nodesToRemove []string
+}
+
+type fakeOVHClient struct {
+	pools       []nodePool
+	nodes       map[string][]node
+	flavors     []flavor
+	updates     map[string][]nodePoolUpdate
+	flavorCalls int
+}
+
+func (c *fakeOVHClient) listNodePools() ([]nodePool, error) {
+	return append([]nodePool{}, c.pools...), nil
+}
+
+func (c *fakeOVHClient) listNodes(poolID string) ([]node, error) {
+	return c.nodes[poolID], nil
+}
+
+func (c *fakeOVHClient) listFlavors() ([]flavor, error) {
+	c.flavorCalls++
+	return c.flavors, nil
+}
+
+func (c *fakeOVHClient) updateNodePool(poolID string, desiredNodes int, nodesToRemove []string) error {
+	c.updates[poolID] = append(c.updates[poolID], nodePoolUpdate{desiredNodes: desiredNodes, nodesToRemove: nodesToRemove})
+	return nil
+}
+
+func annotatedPool(id, name, flavor string, desiredNodes int, annotations map[string]string) nodePool {
+	pool := nodePool{ID: id, Name: name, Flavor: flavor, DesiredNodes: desiredNodes}
+	pool.Template.Metadata.Annotations = annotations
+	return pool
+}
+
+func newFakeOVHClient() *fakeOVHClient {
+	annotated := annotatedPool("np-annotated", "annotated", "t1-45", 1, map[string]string{minSizeAnnotation: "1", maxSizeAnnotation: "4"})
+	annotated.Template.Metadata.Labels = map[string]string{"team": "a"}
+	annotated.Template.Spec.Taints = []apiv1.Taint{{Key: "nvidia.com/gpu", Effect: apiv1.TaintEffectNoSchedule}}
+	return &fakeOVHClient{
+		pools: []nodePool{
+			annotatedPool("np-static", "static", "b2-7", 2, nil),
+			annotated,
+			annotatedPool("np-ignored", "ignored", "b2-7", 1, nil),
+			annotatedPool("np-invalid", "invalid", "b2-7", 1, map[string]string{maxSizeAnnotation: "many"}),
+		},
+		nodes: map[string][]node{
+			"np-static": {
+				{ID: "node-s0", Name: "static-0", InstanceID: "instance-s0", Status: "READY"},
+				{ID: "node-s1", Name: "static-1", InstanceID: "instance-s1", Status: "READY"},
+			},
+			"np-annotated": {{ID: "node-a0", Name: "annotated-0", Status: "INSTALLING"}},
+			"np-ignored":   {{ID: "node-i0", Name: "ignored-0", InstanceID: "instance-i0", Status: "READY"}},
+		},
+		flavors: []flavor{
+			{Name: "b2-7", Category: "b", VCPUs: 2, RAM: 7},
+			{Name: "t1-45", Category: "t", VCPUs: 8, GPUs: 1, RAM: 45},
+		},
+		updates: make(map[string][]nodePoolUpdate),
+	}
+}
+
+func buildTestProvider(t *testing.T, client *fakeOVHClient) *ovhCloudProvider {
+	manager, err := newOVHManager(client, []string{"0:3:static", "1:2:missing"})
+	assert.NoError(t, err)
+	provider := &ovhCloudProvider{manager: manager}
+	assert.NoError(t, provider.Refresh())
+	return provider
+}
+
+func nodeWithProviderID(name, providerID string) *apiv1.Node {
+	return &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: apiv1.NodeSpec{ProviderID: providerID}}
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := readConfig(strings.NewReader(`{"applicationKey": "app", "applicationSecret": "secret", "consumerKey": "consumer",
+		"projectId": "project", "clusterId": "cluster"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, defaultEndpoint, cfg.Endpoint)
+
+	os.Setenv(applicationKeyEnvVar, "env-app")
+	os.Setenv(applicationSecretEnvVar, "env-secret")
+	os.Setenv(consumerKeyEnvVar, "env-consumer")
+	defer os.Unsetenv(applicationKeyEnvVar)
+	defer os.Unsetenv(applicationSecretEnvVar)
+	defer os.Unsetenv(consumerKeyEnvVar)
+	cfg, err = readConfig(strings.NewReader(`{"endpoint": "https://ca.api.ovh.com/1.0", "projectId": "project", "clusterId": "cluster"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "env-app", cfg.ApplicationKey)
+	assert.Equal(t, "env-secret", cfg.ApplicationSecret)
+	assert.Equal(t, "env-consumer", cfg.ConsumerKey)
+	assert.Equal(t, "https://ca.api.ovh.com/1.0", cfg.Endpoint)
+
+	_, err = readConfig(strings.NewReader(`{"projectId": "project"}`))
+	assert.Error(t, err)
+	os.Unsetenv(consumerKeyEnvVar)
+	_, err = readConfig(strings.NewReader(`{"projectId": "project", "clusterId": "cluster"}`))
+	assert.Error(t, err)
+}
+
+func TestNodeGroups(t *testing.T) {
+	client := newFakeOVHClient()
+	provider := buildTestProvider(t, client)
+
+	nodeGroups := provider.NodeGroups()
+	assert.Equal(t, 2, len(nodeGroups))
+	assert.Equal(t, "annotated", nodeGroups[0].Id())
+	assert.Equal(t, 1, nodeGroups[0].MinSize())
+	assert.Equal(t, 4, nodeGroups[0].MaxSize())
+	assert.Equal(t, "static", nodeGroups[1].Id())
+	assert.Equal(t, 0, nodeGroups[1].MinSize())
+	assert.Equal(t, 3, nodeGroups[1].MaxSize())
+
+	// Annotation changes are picked up on refresh, keeping the same node groups.
+	client.pools[1].Template.Metadata.Annotations[maxSizeAnnotation] = "6"
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, 6, nodeGroups[0].MaxSize())
+	assert.Equal(t, nodeGroups, provider.NodeGroups())
+
+	delete(client.pools[1].Template.Metadata.Annotations, maxSizeAnnotation)
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, 1, len(provider.NodeGroups()))
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider := buildTestProvider(t, newFakeOVHClient())
+
+	group, err := provider.NodeGroupForNode(nodeWithProviderID("static-1", "openstack:///instance-s1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "static", group.Id())
+
+	for _, providerID := range []string{"openstack:///instance-i0", "openstack:///unknown", "aws:///us-east-1a/i-1", ""} {
+		group, err = provider.NodeGroupForNode(nodeWithProviderID("node", providerID))
+		assert.NoError(t, err)
+		assert.Nil(t, group, providerID)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	provider := buildTestProvider(t, newFakeOVHClient())
+
+	instances, err := provider.NodeGroups()[0].Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{{
+		Id:     "ovhcloud://node-a0",
+		Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating},
+	}}, instances)
+
+	instances, err = provider.NodeGroups()[1].Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(instances))
+	assert.Equal(t, "openstack:///instance-s0", instances[0].Id)
+	assert.Equal(t, cloudprovider.InstanceRunning, instances[0].Status.State)
+
+	status := nodeStatus(node{Name: "broken", Status: "ERROR"})
+	assert.Equal(t, cloudprovider.OtherErrorClass, status.ErrorInfo.ErrorClass)
+}
+
+func TestIncreaseSize(t *testing.T) {
+	client := newFakeOVHClient()
+	provider := buildTestProvider(t, client)
+	ng := provider.NodeGroups()[1]
+
+	assert.Error(t, ng.IncreaseSize(0))
+	assert.Error(t, ng.IncreaseSize(2))
+	assert.NoError(t, ng.IncreaseSize(1))
+	assert.Equal(t, []nodePoolUpdate{{desiredNodes: 3}}, client.updates["np-static"])
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	client := newFakeOVHClient()
+	client.pools[0].DesiredNodes = 3
+	provider := buildTestProvider(t, client)
+	ng := provider.NodeGroups()[1]
+
+	assert.Error(t, ng.DecreaseTargetSize(0))
+	assert.Error(t, ng.DecreaseTargetSize(-2))
+	assert.NoError(t, ng.DecreaseTargetSize(-1))
+	assert.Equal(t, []nodePoolUpdate{{desiredNodes: 2}}, client.updates["np-static"])
+}
+
+func TestDeleteNodes(t *testing.T) {
+	client := newFakeOVHClient()
+	provider := buildTestProvider(t, client)
+	annotated := provider.NodeGroups()[0]
+	static := provider.NodeGroups()[1]
+
+	assert.Error(t, annotated.DeleteNodes([]*apiv1.Node{nodeWithProviderID("annotated-0", "ovhcloud://node-a0")}))
+	assert.Error(t, static.DeleteNodes([]*apiv1.Node{nodeWithProviderID("ignored-0", "openstack:///instance-i0")}))
+	assert.Empty(t, client.updates)
+
+	assert.NoError(t, static.DeleteNodes([]*apiv1.Node{nodeWithProviderID("static-0", "openstack:///instance-s0")}))
+	assert.Equal(t, []nodePoolUpdate{{desiredNodes: 1, nodesToRemove: []string{"node-s0"}}}, client.updates["np-static"])
+	instances, err := static.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(instances))
+	group, err := provider.NodeGroupForNode(nodeWithProviderID("static-0", "openstack:///instance-s0"))
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestTemplateNodeInfo(t *testing.T) {
+	client := newFakeOVHClient()
+	provider := buildTestProvider(t, client)
+
+	nodeInfo, err := provider.NodeGroups()[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node := nodeInfo.Node()
+	cpu := node.Status.Allocatable[apiv1.ResourceCPU]
+	assert.Equal(t, int64(8), cpu.Value())
+	memory := node.Status.Allocatable[apiv1.ResourceMemory]
+	assert.Equal(t, int64(45*1024*1024*1024), memory.Value())
+	gpus := node.Status.Allocatable[gpu.ResourceNvidiaGPU]
+	assert.Equal(t, int64(1), gpus.Value())
+	assert.Equal(t, "a", node.Labels["team"])
+	assert.Equal(t, "annotated", node.Labels[nodePoolLabel])
+	assert.Equal(t, "t1-45", node.Labels[apiv1.LabelInstanceType])
+	assert.Equal(t, "4", node.Annotations[maxSizeAnnotation])
+	assert.Equal(t, []apiv1.Taint{{Key: "nvidia.com/gpu", Effect: apiv1.TaintEffectNoSchedule}}, node.Spec.Taints)
+	assert.Equal(t, 1, len(nodeInfo.Pods()))
+
+	nodeInfo, err = provider.NodeGroups()[1].TemplateNodeInfo()
+	assert.NoError(t, err)
+	_, found := nodeInfo.Node().Status.Capacity[gpu.ResourceNvidiaGPU]
+	assert.False(t, found)
+	assert.Equal(t, 1, client.flavorCalls)
+
+	client.pools[0].Flavor = "unknown"
+	assert.NoError(t, provider.Refresh())
+	_, err = provider.NodeGroups()[1].TemplateNodeInfo()
+	assert.Error(t, err)
+	assert.Equal(t, 2, client.flavorCalls)
+}
+
+func TestPoolLimits(t *testing.T) {
+	manager, err := newOVHManager(newFakeOVHClient(), nil)
+	assert.NoError(t, err)
+	for _, tc := range []struct {
+		annotations map[string]string
+		limits      poolLimits
+		managed     bool
+	}{
+		{map[string]string{maxSizeAnnotation: "3"}, poolLimits{minSize: 0, maxSize: 3}, true},
+		{map[string]string{minSizeAnnotation: "2", maxSizeAnnotation: "3"}, poolLimits{minSize: 2, maxSize: 3}, true},
+		{map[string]string{minSizeAnnotation: "2"}, poolLimits{}, false},
+		{map[string]string{minSizeAnnotation: "4", maxSizeAnnotation: "3"}, poolLimits{}, false},
+		{map[string]string{minSizeAnnotation: "x", maxSizeAnnotation: "3"}, poolLimits{}, false},
+	} {
+		pool := annotatedPool("id", "pool", "b2-7", 0, tc.annotations)
+		limits, managed := manager.poolLimits(&pool)
+		assert.Equal(t, tc.managed, managed, fmt.Sprint(tc.annotations))
+		assert.Equal(t, tc.limits, limits, fmt.Sprint(tc.annotations))
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	defaultEndpoint = "https://eu.api.ovh.com/1.0"
+
+	applicationKeyEnvVar    = "OVH_APPLICATION_KEY"
+	applicationSecretEnvVar = "OVH_APPLICATION_SECRET"
+	consumerKeyEnvVar       = "OVH_CONSUMER_KEY"
+)
+
+// CloudConfig is the OVHcloud cloud provider configuration, read as JSON from --cloud-config.
+// API credentials can also be passed with the OVH_APPLICATION_KEY, OVH_APPLICATION_SECRET and
+// OVH_CONSUMER_KEY environment variables.
+type CloudConfig struct {
+	// Endpoint is the OVHcloud API endpoint, defaults to the OVHcloud Europe API.
+	Endpoint          string `json:"endpoint"`
+	ApplicationKey    string `json:"applicationKey"`
+	ApplicationSecret string `json:"applicationSecret"`
+	ConsumerKey       string `json:"consumerKey"`
+	// ProjectID is the public cloud project of the cluster.
+	ProjectID string `json:"projectId"`
+	// ClusterID is the ID of the managed Kubernetes cluster.
+	ClusterID string `json:"clusterId"`
+}
+
+func readConfig(configReader io.Reader) (*CloudConfig, error) {
+	cfg := &CloudConfig{}
+	if configReader != nil {
+		if err := json.NewDecoder(configReader).Decode(cfg); err != nil {
+			return nil, fmt.Errorf("ovhcloud: failed to decode cloud config: %v", err)
+		}
+	}
+	if cfg.ApplicationKey == "" {
+		cfg.ApplicationKey = os.Getenv(applicationKeyEnvVar)
+	}
+	if cfg.ApplicationSecret == "" {
+		cfg.ApplicationSecret = os.Getenv(applicationSecretEnvVar)
+	}
+	if cfg.ConsumerKey == "" {
+		cfg.ConsumerKey = os.Getenv(consumerKeyEnvVar)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaultEndpoint
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cc *CloudConfig) validate() error {
+	if cc.ApplicationKey == "" || cc.ApplicationSecret == "" || cc.ConsumerKey == "" {
+		return fmt.Errorf("ovhcloud: application key, application secret and consumer key must be set")
+	}
+	if cc.ProjectID == "" || cc.ClusterID == "" {
+		return fmt.Errorf("ovhcloud: projectId and clusterId must be set")
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+	"k8s.io/klog"
+)
+
+const (
+	// minSizeAnnotation and maxSizeAnnotation set the size limits of a node pool that isn't
+	// passed with --nodes, in the annotations of the node pool template. Pools without
+	// maxSizeAnnotation aren't managed.
+	minSizeAnnotation = "ovhcloud.cluster-autoscaler.kubernetes.io/min-size"
+	maxSizeAnnotation = "ovhcloud.cluster-autoscaler.kubernetes.io/max-size"
+
+	// providerIDPrefix is the prefix of the provider ids set by the OpenStack cloud controller
+	// manager of OVHcloud managed clusters.
+	providerIDPrefix = "openstack:///"
+	// pendingProviderIDPrefix identifies nodes whose instance hasn't been created yet.
+	pendingProviderIDPrefix = "ovhcloud://"
+)
+
+type poolLimits struct {
+	minSize int
+	maxSize int
+}
+
+// nodeRef is a node of a managed pool, found by provider id.
+type nodeRef struct {
+	pool   *ovhNodePool
+	nodeID string
+}
+
+// ovhManager tracks the managed node pools of the cluster and caches their nodes.
+type ovhManager struct {
+	client ovhClient
+	// staticPools are the limits of the pools passed with --nodes, by pool name.
+	staticPools map[string]poolLimits
+
+	cacheMutex sync.Mutex
+	// managed are the managed node pools, by name.
+	managed map[string]*ovhNodePool
+	nodes   map[string]nodeRef
+	flavors map[string]flavor
+}
+
+func newOVHManager(client ovhClient, specs []string) (*ovhManager, error) {
+	m := &ovhManager{
+		client:      client,
+		staticPools: make(map[string]poolLimits),
+		managed:     make(map[string]*ovhNodePool),
+		nodes:       make(map[string]nodeRef),
+	}
+	for _, value := range specs {
+		spec, err := dynamic.SpecFromString(value, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node pool spec: %v", err)
+		}
+		m.staticPools[spec.Name] = poolLimits{minSize: spec.MinSize, maxSize: spec.MaxSize}
+	}
+	return m, nil
+}
+
+// refresh reloads the node pools of the cluster and the nodes of the managed ones.
+func (m *ovhManager) refresh() error {
+	pools, err := m.client.listNodePools()
+	if err != nil {
+		return fmt.Errorf("failed to list node pools: %v", err)
+	}
+
+	managed := make(map[string]*ovhNodePool)
+	nodes := make(map[string]nodeRef)
+	for i := range pools {
+		pool := pools[i]
+		limits, found := m.poolLimits(&pool)
+		if !found {
+			continue
+		}
+		poolNodes, err := m.client.listNodes(pool.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list nodes of node pool %s: %v", pool.Name, err)
+		}
+
+		m.cacheMutex.Lock()
+		ng, found := m.managed[pool.Name]
+		if !found {
+			ng = &ovhNodePool{manager: m, name: pool.Name}
+			klog.V(2).Infof("Managing OVHcloud node pool %s (%d:%d)", pool.Name, limits.minSize, limits.maxSize)
+		}
+		ng.minSize, ng.maxSize = limits.minSize, limits.maxSize
+		ng.pool, ng.nodes = &pool, poolNodes
+		m.cacheMutex.Unlock()
+		managed[pool.Name] = ng
+		for _, n := range poolNodes {
+			nodes[nodeProviderID(n)] = nodeRef{pool: ng, nodeID: n.ID}
+		}
+	}
+	for name := range m.staticPools {
+		if _, found := managed[name]; !found {
+			klog.Warningf("Node pool %s passed with --nodes not found", name)
+		}
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	m.managed = managed
+	m.nodes = nodes
+	return nil
+}
+
+// poolLimits returns the size limits of the pool if it's managed.
+func (m *ovhManager) poolLimits(pool *nodePool) (poolLimits, bool) {
+	if limits, found := m.staticPools[pool.Name]; found {
+		return limits, true
+	}
+	annotations := pool.Template.Metadata.Annotations
+	maxValue, found := annotations[maxSizeAnnotation]
+	if !found {
+		return poolLimits{}, false
+	}
+	limits := poolLimits{}
+	var err error
+	if limits.maxSize, err = strconv.Atoi(maxValue); err != nil {
+		klog.Warningf("Ignoring node pool %s: invalid %s annotation %q", pool.Name, maxSizeAnnotation, maxValue)
+		return poolLimits{}, false
+	}
+	if minValue, found := annotations[minSizeAnnotation]; found {
+		if limits.minSize, err = strconv.Atoi(minValue); err != nil {
+			klog.Warningf("Ignoring node pool %s: invalid %s annotation %q", pool.Name, minSizeAnnotation, minValue)
+			return poolLimits{}, false
+		}
+	}
+	if limits.minSize < 0 || limits.maxSize < limits.minSize {
+		klog.Warningf("Ignoring node pool %s: invalid size limits %d:%d", pool.Name, limits.minSize, limits.maxSize)
+		return poolLimits{}, false
+	}
+	return limits, true
+}
+
+// managedNodePools returns the managed node pools sorted by name.
+func (m *ovhManager) managedNodePools() []*ovhNodePool {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	result := make([]*ovhNodePool, 0, len(m.managed))
+	for _, ng := range m.managed {
+		result = append(result, ng)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// nodeForProviderID returns the managed node with the given provider id.
+func (m *ovhManager) nodeForProviderID(providerID string) (nodeRef, bool) {
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	ref, found := m.nodes[providerID]
+	return ref, found
+}
+
+// flavor returns the flavor with the given name. Flavors are listed the first time they're
+// needed, and again when a flavor isn't known yet.
+func (m *ovhManager) flavor(name string) (flavor, error) {
+	m.cacheMutex.Lock()
+	f, found := m.flavors[name]
+	m.cacheMutex.Unlock()
+	if found {
+		return f, nil
+	}
+	flavors, err := m.client.listFlavors()
+	if err != nil {
+		return flavor{}, fmt.Errorf("failed to list flavors: %v", err)
+	}
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	m.flavors = make(map[string]flavor, len(flavors))
+	for _, f := range flavors {
+		m.flavors[f.Name] = f
+	}
+	if f, found = m.flavors[name]; !found {
+		return flavor{}, fmt.Errorf("flavor %s not found", name)
+	}
+	return f, nil
+}
+
+// setDesiredNodes updates the desired size of the pool, removing the given nodes.
+func (m *ovhManager) setDesiredNodes(ng *ovhNodePool, desiredNodes int, nodesToRemove []string) error {
+	m.cacheMutex.Lock()
+	poolID := ng.pool.ID
+	m.cacheMutex.Unlock()
+
+	if err := m.client.updateNodePool(poolID, desiredNodes, nodesToRemove); err != nil {
+		return fmt.Errorf("failed to update node pool %s: %v", ng.name, err)
+	}
+
+	m.cacheMutex.Lock()
+	defer m.cacheMutex.Unlock()
+	ng.pool.DesiredNodes = desiredNodes
+	if len(nodesToRemove) == 0 {
+		return nil
+	}
+	removed := make(map[string]bool, len(nodesToRemove))
+	for _, id := range nodesToRemove {
+		removed[id] = true
+	}
+	nodes := make([]node, 0, len(ng.nodes))
+	for _, n := range ng.nodes {
+		if removed[n.ID] {
+			delete(m.nodes, nodeProviderID(n))
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	ng.nodes = nodes
+	return nil
+}
+
+// nodeProviderID returns the provider id of the node, or a placeholder id while its instance
+// doesn't exist.
+func nodeProviderID(n node) string {
+	if n.InstanceID == "" {
+		return pendingProviderIDPrefix + n.ID
+	}
+	return providerIDPrefix + n.InstanceID
+}
+
+// nodeStatus maps the status of a node to an instance status.
+func nodeStatus(n node) *cloudprovider.InstanceStatus {
+	switch strings.ToUpper(n.Status) {
+	case "INSTALLING", "REDEPLOYING", "REOPENING":
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}
+	case "DELETING":
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting}
+	case "ERROR":
+		return &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OtherErrorClass,
+				ErrorCode:    n.Status,
+				ErrorMessage: fmt.Sprintf("node %s is in error", n.Name),
+			},
+		}
+	default:
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning}
+	}
+}
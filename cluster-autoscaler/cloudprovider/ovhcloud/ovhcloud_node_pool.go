@@ -0,0 +1,216 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	defaultPodsPerNode = 110
+	gb                 = 1024 * 1024 * 1024
+	// nodePoolLabel is set by OVHcloud on the nodes of a node pool.
+	nodePoolLabel = "nodepool"
+)
+
+// ovhNodePool implements NodeGroup for a node pool of an OVHcloud managed Kubernetes cluster.
+type ovhNodePool struct {
+	manager *ovhManager
+	name    string
+
+	// Guarded by the manager cache mutex.
+	minSize int
+	maxSize int
+	pool    *nodePool
+	nodes   []node
+}
+
+var _ cloudprovider.NodeGroup = (*ovhNodePool)(nil)
+
+// MaxSize returns maximum size of the node group.
+func (ng *ovhNodePool) MaxSize() int {
+	ng.manager.cacheMutex.Lock()
+	defer ng.manager.cacheMutex.Unlock()
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *ovhNodePool) MinSize() int {
+	ng.manager.cacheMutex.Lock()
+	defer ng.manager.cacheMutex.Unlock()
+	return ng.minSize
+}
+
+// TargetSize returns the desired number of nodes of the pool.
+func (ng *ovhNodePool) TargetSize() (int, error) {
+	pool, _ := ng.state()
+	return pool.DesiredNodes, nil
+}
+
+// IncreaseSize increases the desired number of nodes of the pool.
+func (ng *ovhNodePool) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	pool, _ := ng.state()
+	size := pool.DesiredNodes
+	if size+delta > ng.MaxSize() {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.MaxSize())
+	}
+	return ng.manager.setDesiredNodes(ng, size+delta, nil)
+}
+
+// DeleteNodes removes the given nodes from the pool, decreasing its desired number of nodes.
+func (ng *ovhNodePool) DeleteNodes(nodes []*apiv1.Node) error {
+	pool, _ := ng.state()
+	size := pool.DesiredNodes
+	if size-len(nodes) < ng.MinSize() {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", size-len(nodes), ng.MinSize())
+	}
+	nodeIDs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ref, found := ng.manager.nodeForProviderID(n.Spec.ProviderID)
+		if !found || ref.pool != ng {
+			return fmt.Errorf("node %s doesn't belong to node pool %s", n.Name, ng.name)
+		}
+		nodeIDs = append(nodeIDs, ref.nodeID)
+	}
+	return ng.manager.setDesiredNodes(ng, size-len(nodes), nodeIDs)
+}
+
+// DecreaseTargetSize decreases the desired number of nodes of the pool without removing
+// existing nodes.
+func (ng *ovhNodePool) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	pool, nodes := ng.state()
+	size := pool.DesiredNodes
+	if size+delta < len(nodes) {
+		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
+			size, delta, len(nodes))
+	}
+	return ng.manager.setDesiredNodes(ng, size+delta, nil)
+}
+
+// Id returns the node pool name.
+func (ng *ovhNodePool) Id() string {
+	return ng.name
+}
+
+// Debug returns a debug string for the node pool.
+func (ng *ovhNodePool) Debug() string {
+	return fmt.Sprintf("%s (%d:%d)", ng.Id(), ng.MinSize(), ng.MaxSize())
+}
+
+// Nodes returns the nodes of the pool, including the ones whose instance doesn't exist yet.
+func (ng *ovhNodePool) Nodes() ([]cloudprovider.Instance, error) {
+	_, nodes := ng.state()
+	instances := make([]cloudprovider.Instance, 0, len(nodes))
+	for _, n := range nodes {
+		instances = append(instances, cloudprovider.Instance{
+			Id:     nodeProviderID(n),
+			Status: nodeStatus(n),
+		})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template built from the flavor of the pool and the metadata
+// and taints of the pool template.
+func (ng *ovhNodePool) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	pool, _ := ng.state()
+	f, err := ng.manager.flavor(pool.Flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-template", ng.name)
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      make(map[string]string),
+			Annotations: make(map[string]string),
+		},
+		Spec: apiv1.NodeSpec{
+			ProviderID: pendingProviderIDPrefix + name,
+			Taints:     append([]apiv1.Taint{}, pool.Template.Spec.Taints...),
+		},
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    *resource.NewQuantity(f.VCPUs, resource.DecimalSI),
+				apiv1.ResourceMemory: *resource.NewQuantity(f.RAM*gb, resource.BinarySI),
+				apiv1.ResourcePods:   *resource.NewQuantity(defaultPodsPerNode, resource.DecimalSI),
+			},
+			Conditions: cloudprovider.BuildReadyConditions(),
+		},
+	}
+	if f.GPUs > 0 {
+		node.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(f.GPUs, resource.DecimalSI)
+	}
+	node.Status.Allocatable = node.Status.Capacity
+
+	for key, value := range pool.Template.Metadata.Labels {
+		node.Labels[key] = value
+	}
+	for key, value := range pool.Template.Metadata.Annotations {
+		node.Annotations[key] = value
+	}
+	node.Labels[nodePoolLabel] = ng.name
+	node.Labels[apiv1.LabelHostname] = name
+	node.Labels[apiv1.LabelOSStable] = cloudprovider.DefaultOS
+	node.Labels[apiv1.LabelArchStable] = cloudprovider.DefaultArch
+	node.Labels[apiv1.LabelInstanceType] = f.Name
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.name))
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (ng *ovhNodePool) Exist() bool {
+	return true
+}
+
+// Create creates the node group on the cloud provider side.
+func (ng *ovhNodePool) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side.
+func (ng *ovhNodePool) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *ovhNodePool) Autoprovisioned() bool {
+	return false
+}
+
+// state returns a copy of the cached node pool and its nodes.
+func (ng *ovhNodePool) state() (nodePool, []node) {
+	ng.manager.cacheMutex.Lock()
+	defer ng.manager.cacheMutex.Unlock()
+	return *ng.pool, ng.nodes
+}
@@ -52,6 +52,8 @@ type TestCloudProvider struct {
 	machineTypes      []string
 	machineTemplates  map[string]*schedulernodeinfo.NodeInfo
 	resourceLimiter   *cloudprovider.ResourceLimiter
+	deletedInstances  map[string]bool
+	unremovableNodes  map[string]string
 }
 
 // NewTestCloudProvider builds new TestCloudProvider
@@ -203,6 +205,46 @@ func (tcp *TestCloudProvider) AddNode(nodeGroupId string, node *apiv1.Node) {
 	tcp.nodes[node.Name] = nodeGroupId
 }
 
+// DeleteInstance marks the instance of the given node as deleted on the cloud provider side, while
+// the node itself is kept.
+func (tcp *TestCloudProvider) DeleteInstance(nodeName string) {
+	tcp.Lock()
+	defer tcp.Unlock()
+
+	if tcp.deletedInstances == nil {
+		tcp.deletedInstances = make(map[string]bool)
+	}
+	tcp.deletedInstances[nodeName] = true
+}
+
+// HasInstance returns false if the instance of the given node was deleted with DeleteInstance.
+func (tcp *TestCloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	tcp.Lock()
+	defer tcp.Unlock()
+
+	return !tcp.deletedInstances[node.Name], nil
+}
+
+// SetNodeUnremovable marks the given node as unremovable for the given reason.
+func (tcp *TestCloudProvider) SetNodeUnremovable(nodeName, reason string) {
+	tcp.Lock()
+	defer tcp.Unlock()
+
+	if tcp.unremovableNodes == nil {
+		tcp.unremovableNodes = make(map[string]string)
+	}
+	tcp.unremovableNodes[nodeName] = reason
+}
+
+// IsNodeUnremovable returns true if the given node was marked with SetNodeUnremovable.
+func (tcp *TestCloudProvider) IsNodeUnremovable(node *apiv1.Node) (bool, string, error) {
+	tcp.Lock()
+	defer tcp.Unlock()
+
+	reason, found := tcp.unremovableNodes[node.Name]
+	return found, reason, nil
+}
+
 // GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
 func (tcp *TestCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
 	return tcp.resourceLimiter, nil
@@ -234,9 +276,13 @@ type TestNodeGroup struct {
 	targetSize      int
 	exist           bool
 	autoprovisioned bool
+	persistent      bool
 	machineType     string
 	labels          map[string]string
 	taints          []apiv1.Taint
+	problemReason   string
+	problemMessage  string
+	options         *cloudprovider.NodeGroupAutoscalingOptions
 }
 
 // MaxSize returns maximum size of the node group.
@@ -273,6 +319,63 @@ func (tng *TestNodeGroup) SetTargetSize(size int) {
 	tng.targetSize = size
 }
 
+// SetProblem makes the node group report a problem with the given reason and
+// message. An empty reason clears the problem.
+func (tng *TestNodeGroup) SetProblem(reason, message string) {
+	tng.Lock()
+	defer tng.Unlock()
+	tng.problemReason = reason
+	tng.problemMessage = message
+}
+
+// Problem returns the problem set with SetProblem.
+func (tng *TestNodeGroup) Problem() (string, string, bool) {
+	tng.Lock()
+	defer tng.Unlock()
+	return tng.problemReason, tng.problemMessage, tng.problemReason != ""
+}
+
+// SetOptions makes the node group override the autoscaling options with the given ones. Nil
+// options stop overriding them.
+func (tng *TestNodeGroup) SetOptions(options *cloudprovider.NodeGroupAutoscalingOptions) {
+	tng.Lock()
+	defer tng.Unlock()
+	tng.options = options
+}
+
+// GetOptions returns the options set with SetOptions.
+func (tng *TestNodeGroup) GetOptions(defaults cloudprovider.NodeGroupAutoscalingOptions) (*cloudprovider.NodeGroupAutoscalingOptions, error) {
+	tng.Lock()
+	defer tng.Unlock()
+	if tng.options == nil {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	return tng.options, nil
+}
+
+// SetPersistent makes a node group that doesn't exist yet be created as a regular node group.
+func (tng *TestNodeGroup) SetPersistent() error {
+	tng.Lock()
+	defer tng.Unlock()
+	if tng.exist {
+		return fmt.Errorf("group already exist")
+	}
+	tng.persistent = true
+	return nil
+}
+
+// SetSizeLimits sets the min and max sizes of a node group that doesn't exist yet.
+func (tng *TestNodeGroup) SetSizeLimits(minSize, maxSize int) error {
+	tng.Lock()
+	defer tng.Unlock()
+	if tng.exist {
+		return fmt.Errorf("group already exist")
+	}
+	tng.minSize = minSize
+	tng.maxSize = maxSize
+	return nil
+}
+
 // IncreaseSize increases the size of the node group. To delete a node you need
 // to explicitly name it and use DeleteNode. This function should wait until
 // node group size is updated.
@@ -297,7 +400,8 @@ func (tng *TestNodeGroup) Create() (cloudprovider.NodeGroup, error) {
 	if tng.Exist() {
 		return nil, fmt.Errorf("group already exist")
 	}
-	newNodeGroup := tng.cloudProvider.AddAutoprovisionedNodeGroup(tng.id, tng.minSize, tng.maxSize, 0, tng.machineType)
+	newNodeGroup := tng.cloudProvider.BuildNodeGroup(tng.id, tng.minSize, tng.maxSize, 0, !tng.persistent, tng.machineType)
+	tng.cloudProvider.InsertNodeGroup(newNodeGroup)
 	return newNodeGroup, tng.cloudProvider.onNodeGroupCreate(tng.id)
 }
 
@@ -23,6 +23,17 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 )
 
+func TestSetCSIAttachLimits(t *testing.T) {
+	node := &apiv1.Node{}
+	SetCSIAttachLimits(node, map[string]int64{"ebs.csi.aws.com": 25})
+
+	key := apiv1.ResourceName("attachable-volumes-csi-ebs.csi.aws.com")
+	capacity := node.Status.Capacity[key]
+	allocatable := node.Status.Allocatable[key]
+	assert.Equal(t, int64(25), capacity.Value())
+	assert.Equal(t, int64(25), allocatable.Value())
+}
+
 func TestBuildReadyConditions(t *testing.T) {
 	conditions := BuildReadyConditions()
 	foundReady := false
@@ -50,3 +61,32 @@ func TestJoinStringMaps(t *testing.T) {
 	result := JoinStringMaps(map1, map2, map3)
 	assert.Equal(t, map[string]string{"1": "a", "2": "d", "3": "c", "5": "e"}, result)
 }
+
+type limitedNodeGroup struct {
+	NodeGroup
+	maxSize         int
+	providerMaxSize int
+	limited         bool
+}
+
+func (ng *limitedNodeGroup) MaxSize() int {
+	return ng.maxSize
+}
+
+func (ng *limitedNodeGroup) ProviderMaxSize() (int, string, bool) {
+	return ng.providerMaxSize, "asg max size", ng.limited
+}
+
+func TestEffectiveMaxSize(t *testing.T) {
+	maxSize, reason := EffectiveMaxSize(&limitedNodeGroup{maxSize: 10, providerMaxSize: 5, limited: true})
+	assert.Equal(t, 5, maxSize)
+	assert.Equal(t, "asg max size", reason)
+
+	maxSize, reason = EffectiveMaxSize(&limitedNodeGroup{maxSize: 10, providerMaxSize: 15, limited: true})
+	assert.Equal(t, 10, maxSize)
+	assert.Equal(t, "", reason)
+
+	maxSize, reason = EffectiveMaxSize(&limitedNodeGroup{maxSize: 10, providerMaxSize: 5, limited: false})
+	assert.Equal(t, 10, maxSize)
+	assert.Equal(t, "", reason)
+}
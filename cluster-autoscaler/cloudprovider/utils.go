@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubetypes "k8s.io/kubernetes/pkg/kubelet/types"
+	volumeutil "k8s.io/kubernetes/pkg/volume/util"
 )
 
 const (
@@ -123,3 +124,40 @@ func JoinStringMaps(items ...map[string]string) map[string]string {
 	}
 	return result
 }
+
+// SetCSIAttachLimits advertises the given per-driver CSI volume attach limits
+// in the node capacity and allocatable, the same way kubelet does for real
+// nodes, so that the scheduler simulation respects them on template nodes.
+func SetCSIAttachLimits(node *apiv1.Node, limits map[string]int64) {
+	for driver, limit := range limits {
+		key := apiv1.ResourceName(volumeutil.GetCSIAttachLimitKey(driver))
+		quantity := *resource.NewQuantity(limit, resource.DecimalSI)
+		if node.Status.Capacity == nil {
+			node.Status.Capacity = apiv1.ResourceList{}
+		}
+		node.Status.Capacity[key] = quantity
+		if node.Status.Allocatable == nil {
+			node.Status.Allocatable = apiv1.ResourceList{}
+		}
+		node.Status.Allocatable[key] = quantity
+	}
+}
+
+// EffectiveMaxSize returns the max size of the node group clamped by any
+// limit reported by the cloud provider. If the provider limit is the one in
+// effect a non-empty reason describing it is returned as well.
+func EffectiveMaxSize(nodeGroup NodeGroup) (int, string) {
+	maxSize := nodeGroup.MaxSize()
+	limited, ok := nodeGroup.(ProviderLimitedNodeGroup)
+	if !ok {
+		return maxSize, ""
+	}
+	providerMax, reason, ok := limited.ProviderMaxSize()
+	if !ok || providerMax >= maxSize {
+		return maxSize, ""
+	}
+	if providerMax < 0 {
+		providerMax = 0
+	}
+	return providerMax, reason
+}
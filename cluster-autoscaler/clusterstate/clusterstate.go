@@ -26,6 +26,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	core_api "k8s.io/autoscaler/cluster-autoscaler/core/api"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
@@ -90,6 +91,9 @@ type ClusterStateRegistryConfig struct {
 	OkTotalUnreadyCount int
 	//  Maximum time CA waits for node to be provisioned
 	MaxNodeProvisionTime time.Duration
+	// Whether the instances of the nodes are looked up in the cloud provider to find the nodes whose
+	// instance doesn't exist anymore.
+	LookUpGhostNodes bool
 }
 
 // IncorrectNodeGroupSize contains information about how much the current size of the node group
@@ -113,6 +117,15 @@ type UnregisteredNode struct {
 	UnregisteredSince time.Time
 }
 
+// GhostNode contains information about nodes that are registered in Kubernetes but whose instance
+// doesn't exist on the cloud provider side anymore.
+type GhostNode struct {
+	// Node is the Kubernetes node.
+	Node *apiv1.Node
+	// GhostSince is the time when the missing instance was first spotted.
+	GhostSince time.Time
+}
+
 // ClusterStateRegistry is a structure to keep track the current state of the cluster.
 type ClusterStateRegistry struct {
 	sync.Mutex
@@ -127,6 +140,7 @@ type ClusterStateRegistry struct {
 	acceptableRanges                   map[string]AcceptableRange
 	incorrectNodeGroupSizes            map[string]IncorrectNodeGroupSize
 	unregisteredNodes                  map[string]UnregisteredNode
+	ghostNodes                         map[string]GhostNode
 	candidatesForScaleDown             map[string][]string
 	backoff                            backoff.Backoff
 	lastStatus                         *api.ClusterAutoscalerStatus
@@ -134,6 +148,13 @@ type ClusterStateRegistry struct {
 	logRecorder                        *utils.LogEventRecorder
 	cloudProviderNodeInstances         map[string][]cloudprovider.Instance
 	previousCloudProviderNodeInstances map[string][]cloudprovider.Instance
+	nodeGroupProblems                  map[string]NodeGroupProblem
+}
+
+// NodeGroupProblem is a problem of a node group reported by the cloud provider.
+type NodeGroupProblem struct {
+	Reason  string
+	Message string
 }
 
 // NewClusterStateRegistry creates new ClusterStateRegistry.
@@ -152,7 +173,9 @@ func NewClusterStateRegistry(cloudProvider cloudprovider.CloudProvider, config C
 		acceptableRanges:        make(map[string]AcceptableRange),
 		incorrectNodeGroupSizes: make(map[string]IncorrectNodeGroupSize),
 		unregisteredNodes:       make(map[string]UnregisteredNode),
+		ghostNodes:              make(map[string]GhostNode),
 		candidatesForScaleDown:  make(map[string][]string),
+		nodeGroupProblems:       make(map[string]NodeGroupProblem),
 		backoff:                 backoff,
 		lastStatus:              emptyStatus,
 		logRecorder:             logRecorder,
@@ -178,7 +201,7 @@ func (csr *ClusterStateRegistry) registerOrUpdateScaleUpNoLock(nodeGroup cloudpr
 			NodeGroup:       nodeGroup,
 			Increase:        delta,
 			Time:            currentTime,
-			ExpectedAddTime: currentTime.Add(csr.config.MaxNodeProvisionTime),
+			ExpectedAddTime: currentTime.Add(csr.maxNodeProvisionTime(nodeGroup)),
 		}
 		csr.scaleUpRequests[nodeGroup.Id()] = scaleUpRequest
 		return
@@ -200,7 +223,7 @@ func (csr *ClusterStateRegistry) registerOrUpdateScaleUpNoLock(nodeGroup cloudpr
 	if delta > 0 {
 		// if we are actually adding new nodes shift Time and ExpectedAddTime
 		scaleUpRequest.Time = currentTime
-		scaleUpRequest.ExpectedAddTime = currentTime.Add(csr.config.MaxNodeProvisionTime)
+		scaleUpRequest.ExpectedAddTime = currentTime.Add(csr.maxNodeProvisionTime(nodeGroup))
 	}
 }
 
@@ -248,6 +271,12 @@ func (csr *ClusterStateRegistry) updateScaleRequests(currentTime time.Time) {
 	csr.scaleDownRequests = newScaleDownRequests
 }
 
+// maxNodeProvisionTime returns the time the nodes of the node group have to be provisioned in.
+func (csr *ClusterStateRegistry) maxNodeProvisionTime(nodeGroup cloudprovider.NodeGroup) time.Duration {
+	defaults := cloudprovider.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: csr.config.MaxNodeProvisionTime}
+	return cloudprovider.GetNodeGroupOptions(nodeGroup, defaults).MaxNodeProvisionTime
+}
+
 // To be executed under a lock.
 func (csr *ClusterStateRegistry) backoffNodeGroup(nodeGroup cloudprovider.NodeGroup, errorClass cloudprovider.InstanceErrorClass, errorCode string, currentTime time.Time) {
 	nodeGroupInfo := csr.nodeInfosForGroups[nodeGroup.Id()]
@@ -255,6 +284,20 @@ func (csr *ClusterStateRegistry) backoffNodeGroup(nodeGroup cloudprovider.NodeGr
 	klog.Warningf("Disabling scale-up for node group %v until %v; errorClass=%v; errorCode=%v", nodeGroup.Id(), backoffUntil, errorClass, errorCode)
 }
 
+// ExportBackoffData returns the backoff data of the backed off node groups.
+func (csr *ClusterStateRegistry) ExportBackoffData() map[string]core_api.BackoffData {
+	csr.Lock()
+	defer csr.Unlock()
+	return csr.backoff.ExportBackoffData()
+}
+
+// ImportBackoffData replaces the backoff data of the node groups with the given one.
+func (csr *ClusterStateRegistry) ImportBackoffData(data map[string]core_api.BackoffData) {
+	csr.Lock()
+	defer csr.Unlock()
+	csr.backoff.ImportBackoffData(data)
+}
+
 // RegisterFailedScaleUp should be called after getting error from cloudprovider
 // when trying to scale-up node group. It will mark this group as not safe to autoscale
 // for some time.
@@ -282,16 +325,23 @@ func (csr *ClusterStateRegistry) UpdateNodes(nodes []*apiv1.Node, nodeInfosForGr
 		return err
 	}
 	notRegistered := getNotRegisteredNodes(nodes, cloudProviderNodeInstances, currentTime)
+	ghosts := make([]GhostNode, 0)
+	if csr.config.LookUpGhostNodes {
+		ghosts = getGhostNodes(nodes, csr.cloudProvider, currentTime)
+	}
+	problems := getNodeGroupProblems(csr.cloudProvider)
 
 	csr.Lock()
 	defer csr.Unlock()
 
 	csr.nodes = nodes
+	csr.nodeGroupProblems = problems
 	csr.nodeInfosForGroups = nodeInfosForGroups
 	csr.previousCloudProviderNodeInstances = csr.cloudProviderNodeInstances
 	csr.cloudProviderNodeInstances = cloudProviderNodeInstances
 
 	csr.updateUnregisteredNodes(notRegistered)
+	csr.updateGhostNodes(ghosts)
 	csr.updateReadinessStats(currentTime)
 
 	// update acceptable ranges based on requests from last loop and targetSizes
@@ -317,6 +367,21 @@ func (csr *ClusterStateRegistry) Recalculate() {
 	csr.updateAcceptableRanges(targetSizes)
 }
 
+// getNodeGroupProblems gets the problems of the node groups reporting them.
+func getNodeGroupProblems(cp cloudprovider.CloudProvider) map[string]NodeGroupProblem {
+	result := make(map[string]NodeGroupProblem)
+	for _, ng := range cp.NodeGroups() {
+		reporting, ok := ng.(cloudprovider.HealthReportingNodeGroup)
+		if !ok {
+			continue
+		}
+		if reason, message, ok := reporting.Problem(); ok {
+			result[ng.Id()] = NodeGroupProblem{Reason: reason, Message: message}
+		}
+	}
+	return result
+}
+
 // getTargetSizes gets target sizes of node groups.
 func getTargetSizes(cp cloudprovider.CloudProvider) (map[string]int, error) {
 	result := make(map[string]int)
@@ -559,7 +624,7 @@ func (csr *ClusterStateRegistry) updateReadinessStats(currentTime time.Time) {
 			continue
 		}
 		perNgCopy := perNodeGroup[nodeGroup.Id()]
-		if unregistered.UnregisteredSince.Add(csr.config.MaxNodeProvisionTime).Before(currentTime) {
+		if unregistered.UnregisteredSince.Add(csr.maxNodeProvisionTime(nodeGroup)).Before(currentTime) {
 			perNgCopy.LongUnregistered++
 			total.LongUnregistered++
 		} else {
@@ -638,6 +703,31 @@ func (csr *ClusterStateRegistry) GetUnregisteredNodes() []UnregisteredNode {
 	return result
 }
 
+func (csr *ClusterStateRegistry) updateGhostNodes(ghostNodes []GhostNode) {
+	result := make(map[string]GhostNode)
+	for _, ghost := range ghostNodes {
+		if prev, found := csr.ghostNodes[ghost.Node.Name]; found {
+			prev.Node = ghost.Node
+			result[ghost.Node.Name] = prev
+		} else {
+			result[ghost.Node.Name] = ghost
+		}
+	}
+	csr.ghostNodes = result
+}
+
+// GetGhostNodes returns a list of all nodes whose instance doesn't exist in the cloud provider.
+func (csr *ClusterStateRegistry) GetGhostNodes() []GhostNode {
+	csr.Lock()
+	defer csr.Unlock()
+
+	result := make([]GhostNode, 0, len(csr.ghostNodes))
+	for _, ghost := range csr.ghostNodes {
+		result = append(result, ghost)
+	}
+	return result
+}
+
 // UpdateScaleDownCandidates updates scale down candidates
 func (csr *ClusterStateRegistry) UpdateScaleDownCandidates(nodes []*apiv1.Node, now time.Time) {
 	result := make(map[string][]string)
@@ -671,8 +761,12 @@ func (csr *ClusterStateRegistry) GetStatus(now time.Time) *api.ClusterAutoscaler
 		acceptable := csr.acceptableRanges[nodeGroup.Id()]
 
 		// Health.
-		nodeGroupStatus.Conditions = append(nodeGroupStatus.Conditions, buildHealthStatusNodeGroup(
-			csr.IsNodeGroupHealthy(nodeGroup.Id()), readiness, acceptable, nodeGroup.MinSize(), nodeGroup.MaxSize()))
+		health := buildHealthStatusNodeGroup(
+			csr.IsNodeGroupHealthy(nodeGroup.Id()), readiness, acceptable, nodeGroup.MinSize(), nodeGroup.MaxSize())
+		if problem, found := csr.nodeGroupProblems[nodeGroup.Id()]; found {
+			health = withNodeGroupProblem(health, problem)
+		}
+		nodeGroupStatus.Conditions = append(nodeGroupStatus.Conditions, health)
 
 		// Scale up.
 		nodeGroupStatus.Conditions = append(nodeGroupStatus.Conditions, buildScaleUpStatusNodeGroup(
@@ -727,6 +821,15 @@ func buildHealthStatusNodeGroup(isReady bool, readiness Readiness, acceptable Ac
 	return condition
 }
 
+// withNodeGroupProblem returns the health condition of a node group made
+// unhealthy by the problem the cloud provider reports for it.
+func withNodeGroupProblem(condition api.ClusterAutoscalerCondition, problem NodeGroupProblem) api.ClusterAutoscalerCondition {
+	condition.Status = api.ClusterAutoscalerUnhealthy
+	condition.Reason = problem.Reason
+	condition.Message = fmt.Sprintf("%s problem=%s: %s", condition.Message, problem.Reason, problem.Message)
+	return condition
+}
+
 func buildScaleUpStatusNodeGroup(isScaleUpInProgress bool, isSafeToScaleUp bool, readiness Readiness, acceptable AcceptableRange) api.ClusterAutoscalerCondition {
 	condition := api.ClusterAutoscalerCondition{
 		Type: api.ClusterAutoscalerScaleUp,
@@ -951,6 +1054,33 @@ func getNotRegisteredNodes(allNodes []*apiv1.Node, cloudProviderNodeInstances ma
 	return notRegistered
 }
 
+// getGhostNodes returns the nodes whose instance the cloud provider reports as missing. Nothing is
+// returned if the cloud provider can't look up instances.
+func getGhostNodes(allNodes []*apiv1.Node, cloudProvider cloudprovider.CloudProvider, time time.Time) []GhostNode {
+	ghosts := make([]GhostNode, 0)
+	lookup, ok := cloudProvider.(cloudprovider.InstanceLookupCloudProvider)
+	if !ok {
+		return ghosts
+	}
+	for _, node := range allNodes {
+		exists, err := lookup.HasInstance(node)
+		if err == cloudprovider.ErrNotImplemented {
+			continue
+		}
+		if err != nil {
+			klog.Warningf("Failed to check if instance of node %s exists: %v", node.Name, err)
+			continue
+		}
+		if !exists {
+			ghosts = append(ghosts, GhostNode{
+				Node:       node,
+				GhostSince: time,
+			})
+		}
+	}
+	return ghosts
+}
+
 // GetClusterSize calculates and returns cluster's current size and target size. The current size is the
 // actual number of nodes provisioned in Kubernetes, the target size is the number of nodes the CA wants.
 func (csr *ClusterStateRegistry) GetClusterSize() (currentSize, targetSize int) {
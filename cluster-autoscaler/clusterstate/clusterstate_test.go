@@ -22,6 +22,7 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
@@ -159,6 +160,43 @@ func TestOKOneUnreadyNode(t *testing.T) {
 	assert.True(t, ng1Checked)
 }
 
+func TestNodeGroupProblem(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", ng1_1)
+	provider.GetNodeGroup("ng1").(*testprovider.TestNodeGroup).SetProblem("InvalidConfiguration", "unknown instance type")
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false)
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+	}, fakeLogRecorder, newBackoff())
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1}, nil, now)
+	assert.NoError(t, err)
+
+	status := clusterstate.GetStatus(now)
+	assert.Equal(t, 1, len(status.NodeGroupStatuses))
+	health := api.GetConditionByType(api.ClusterAutoscalerHealth, status.NodeGroupStatuses[0].Conditions)
+	assert.Equal(t, api.ClusterAutoscalerUnhealthy, health.Status)
+	assert.Equal(t, "InvalidConfiguration", health.Reason)
+	assert.Contains(t, health.Message, "problem=InvalidConfiguration: unknown instance type")
+
+	// The problem is gone once the node group stops reporting it.
+	provider.GetNodeGroup("ng1").(*testprovider.TestNodeGroup).SetProblem("", "")
+	err = clusterstate.UpdateNodes([]*apiv1.Node{ng1_1}, nil, now)
+	assert.NoError(t, err)
+	status = clusterstate.GetStatus(now)
+	health = api.GetConditionByType(api.ClusterAutoscalerHealth, status.NodeGroupStatuses[0].Conditions)
+	assert.Equal(t, api.ClusterAutoscalerHealthy, health.Status)
+	assert.Empty(t, health.Reason)
+}
+
 func TestNodeWithoutNodeGroupDontCrash(t *testing.T) {
 	now := time.Now()
 
@@ -335,6 +373,37 @@ func TestExpiredScaleUp(t *testing.T) {
 	assert.False(t, clusterstate.IsNodeGroupHealthy("ng1"))
 }
 
+func TestExpiredScaleUpWithNodeGroupMaxNodeProvisionTime(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 5)
+	provider.AddNode("ng1", ng1_1)
+	provider.GetNodeGroup("ng1").(*testprovider.TestNodeGroup).SetOptions(&cloudprovider.NodeGroupAutoscalingOptions{
+		MaxNodeProvisionTime: 10 * time.Minute,
+	})
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false)
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+		MaxNodeProvisionTime:      2 * time.Minute,
+	}, fakeLogRecorder, newBackoff())
+	clusterstate.RegisterOrUpdateScaleUp(provider.GetNodeGroup("ng1"), 4, now.Add(-3*time.Minute))
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1}, nil, now)
+	assert.NoError(t, err)
+	assert.True(t, clusterstate.IsNodeGroupHealthy("ng1"))
+	assert.True(t, clusterstate.IsNodeGroupScalingUp("ng1"))
+
+	err = clusterstate.UpdateNodes([]*apiv1.Node{ng1_1}, nil, now.Add(8*time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, clusterstate.IsNodeGroupScalingUp("ng1"))
+}
+
 func TestRegisterScaleDown(t *testing.T) {
 	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
 	provider := testprovider.NewTestCloudProvider(nil, nil)
@@ -481,6 +550,54 @@ func TestUnregisteredNodes(t *testing.T) {
 	assert.Equal(t, 0, len(clusterstate.GetUnregisteredNodes()))
 }
 
+func TestGhostNodes(t *testing.T) {
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	ng1_1.Spec.ProviderID = "ng1-1"
+	ng1_2 := BuildTestNode("ng1-2", 1000, 1000)
+	ng1_2.Spec.ProviderID = "ng1-2"
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng1", ng1_2)
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false)
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+		LookUpGhostNodes:          true,
+	}, fakeLogRecorder, newBackoff())
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2}, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(clusterstate.GetGhostNodes()))
+
+	provider.DeleteInstance("ng1-2")
+	since := time.Now().Add(-time.Minute)
+	err = clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2}, nil, since)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(clusterstate.GetGhostNodes()))
+	assert.Equal(t, "ng1-2", clusterstate.GetGhostNodes()[0].Node.Name)
+
+	// The time the instance went missing is kept between loops.
+	err = clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2}, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(clusterstate.GetGhostNodes()))
+	assert.Equal(t, since, clusterstate.GetGhostNodes()[0].GhostSince)
+
+	err = clusterstate.UpdateNodes([]*apiv1.Node{ng1_1}, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(clusterstate.GetGhostNodes()))
+
+	// The instances aren't looked up unless configured.
+	clusterstate = NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+	}, fakeLogRecorder, newBackoff())
+	err = clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2}, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(clusterstate.GetGhostNodes()))
+}
+
 func TestUpdateLastTransitionTimes(t *testing.T) {
 	now := metav1.Time{Time: time.Now()}
 	later := metav1.Time{Time: now.Time.Add(10 * time.Second)}
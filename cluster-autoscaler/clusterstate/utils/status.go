@@ -17,6 +17,7 @@ limitations under the License.
 package utils
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -25,6 +26,7 @@ import (
 	kube_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 
@@ -38,6 +40,11 @@ const (
 	ConfigMapLastUpdatedKey = "cluster-autoscaler.kubernetes.io/last-updated"
 	// ConfigMapLastUpdateFormat it the timestamp format used for last update annotation in status ConfigMap
 	ConfigMapLastUpdateFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
+	// StatusConfigMapStatusKey is the key of the human-readable status in status ConfigMap.
+	StatusConfigMapStatusKey = "status"
+	// StatusConfigMapStructuredStatusKey is the key of the JSON encoded api.ClusterAutoscalerStatus in
+	// status ConfigMap. It is only set while the cluster status is known.
+	StatusConfigMapStructuredStatusKey = "status.json"
 )
 
 // LogEventRecorder records events on some top-level object, to give user (without access to logs) a view of most important CA actions.
@@ -84,15 +91,36 @@ func NewStatusMapRecorder(kubeClient kube_client.Interface, namespace string, re
 // ConfigMap if it doesn't exist. If logRecorder is passed and configmap update is successful
 // logRecorder's internal reference will be updated.
 func WriteStatusConfigMap(kubeClient kube_client.Interface, namespace string, msg string, logRecorder *LogEventRecorder) (*apiv1.ConfigMap, error) {
+	return writeStatusConfigMap(kubeClient, namespace, msg, nil, logRecorder)
+}
+
+// WriteStructuredStatusConfigMap writes the status to status ConfigMap like WriteStatusConfigMap, both as
+// a human-readable message and JSON encoded, for clients to read it without parsing the message.
+func WriteStructuredStatusConfigMap(kubeClient kube_client.Interface, namespace string, status *api.ClusterAutoscalerStatus, logRecorder *LogEventRecorder) (*apiv1.ConfigMap, error) {
+	return writeStatusConfigMap(kubeClient, namespace, status.GetReadableString(), status, logRecorder)
+}
+
+func writeStatusConfigMap(kubeClient kube_client.Interface, namespace string, msg string, status *api.ClusterAutoscalerStatus, logRecorder *LogEventRecorder) (*apiv1.ConfigMap, error) {
 	statusUpdateTime := time.Now().Format(ConfigMapLastUpdateFormat)
 	statusMsg := fmt.Sprintf("Cluster-autoscaler status at %s:\n%v", statusUpdateTime, msg)
+	var structuredStatus []byte
+	if status != nil {
+		var err error
+		if structuredStatus, err = json.Marshal(status); err != nil {
+			klog.Errorf("Failed to encode status: %v", err)
+		}
+	}
 	var configMap *apiv1.ConfigMap
 	var getStatusError, writeStatusError error
 	var errMsg string
 	maps := kubeClient.CoreV1().ConfigMaps(namespace)
 	configMap, getStatusError = maps.Get(StatusConfigMapName, metav1.GetOptions{})
 	if getStatusError == nil {
-		configMap.Data["status"] = statusMsg
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[StatusConfigMapStatusKey] = statusMsg
+		setStructuredStatus(configMap, structuredStatus)
 		if configMap.ObjectMeta.Annotations == nil {
 			configMap.ObjectMeta.Annotations = make(map[string]string)
 		}
@@ -108,9 +136,10 @@ func WriteStatusConfigMap(kubeClient kube_client.Interface, namespace string, ms
 				},
 			},
 			Data: map[string]string{
-				"status": statusMsg,
+				StatusConfigMapStatusKey: statusMsg,
 			},
 		}
+		setStructuredStatus(configMap, structuredStatus)
 		configMap, writeStatusError = maps.Create(configMap)
 	} else {
 		errMsg = fmt.Sprintf("Failed to retrieve status configmap for update: %v", getStatusError)
@@ -131,6 +160,16 @@ func WriteStatusConfigMap(kubeClient kube_client.Interface, namespace string, ms
 	return configMap, nil
 }
 
+// setStructuredStatus sets the JSON encoded status of status ConfigMap, or removes it if there is none, so
+// that no stale status is left.
+func setStructuredStatus(configMap *apiv1.ConfigMap, structuredStatus []byte) {
+	if structuredStatus == nil {
+		delete(configMap.Data, StatusConfigMapStructuredStatusKey)
+		return
+	}
+	configMap.Data[StatusConfigMapStructuredStatusKey] = string(structuredStatus)
+}
+
 // DeleteStatusConfigMap deletes status configmap
 func DeleteStatusConfigMap(kubeClient kube_client.Interface, namespace string) error {
 	maps := kubeClient.CoreV1().ConfigMaps(namespace)
@@ -17,6 +17,7 @@ limitations under the License.
 package utils
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
@@ -24,6 +25,7 @@ import (
 	kube_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 
@@ -120,3 +122,25 @@ func TestWriteStatusConfigMapError(t *testing.T) {
 	assert.False(t, ti.updateCalled)
 	assert.False(t, ti.createCalled)
 }
+
+func TestWriteStructuredStatusConfigMap(t *testing.T) {
+	ti := setUpTest(t)
+	status := &api.ClusterAutoscalerStatus{
+		ClusterwideConditions: []api.ClusterAutoscalerCondition{{
+			Type:    api.ClusterAutoscalerHealth,
+			Status:  api.ClusterAutoscalerHealthy,
+			Message: "HEALTH_MESSAGE",
+		}},
+	}
+	result, err := WriteStructuredStatusConfigMap(ti.client, ti.namespace, status, nil)
+	assert.Nil(t, err)
+	assert.Contains(t, result.Data[StatusConfigMapStatusKey], "HEALTH_MESSAGE")
+	decoded := &api.ClusterAutoscalerStatus{}
+	assert.NoError(t, json.Unmarshal([]byte(result.Data[StatusConfigMapStructuredStatusKey]), decoded))
+	assert.Equal(t, status, decoded)
+
+	// Messages without a status don't leave the previous one behind.
+	result, err = WriteStatusConfigMap(ti.client, ti.namespace, "TEST_MSG", nil)
+	assert.Nil(t, err)
+	assert.NotContains(t, result.Data, StatusConfigMapStructuredStatusKey)
+}
@@ -42,6 +42,11 @@ type AutoscalingOptions struct {
 	ScaleDownUnneededTime time.Duration
 	// ScaleDownUnreadyTime represents how long an unready node should be unneeded before it is eligible for scale down
 	ScaleDownUnreadyTime time.Duration
+	// ScaleDownMinNodeLifetime represents how long a node has to exist before it is eligible for scale down.
+	// It keeps nodes added by a scale-up from being removed right away when the load oscillates.
+	ScaleDownMinNodeLifetime time.Duration
+	// NodeGroupMinNodeLifetimes overrides ScaleDownMinNodeLifetime for particular node groups, keyed by node group id.
+	NodeGroupMinNodeLifetimes map[string]time.Duration
 	// MaxNodesTotal sets the maximum number of nodes in the whole cluster
 	MaxNodesTotal int
 	// MaxCoresTotal sets the maximum number of cores in the whole cluster
@@ -62,6 +67,12 @@ type AutoscalingOptions struct {
 	ExpanderName string
 	// IgnoreDaemonSetsUtilization is whether CA will ignore DaemonSet pods when calculating resource utilization for scaling down
 	IgnoreDaemonSetsUtilization bool
+	// ReservedDaemonSetFraction is the fraction of DaemonSet pod requests CA doesn't count when calculating resource
+	// utilization for scaling down, between 0 (count them fully) and 1 (same as IgnoreDaemonSetsUtilization).
+	ReservedDaemonSetFraction float64
+	// NodeGroupReservedDaemonSetFractions overrides ReservedDaemonSetFraction and IgnoreDaemonSetsUtilization for
+	// particular node groups, keyed by node group id.
+	NodeGroupReservedDaemonSetFractions map[string]float64
 	// IgnoreMirrorPodsUtilization is whether CA will ignore Mirror pods when calculating resource utilization for scaling down
 	IgnoreMirrorPodsUtilization bool
 	// MaxGracefulTerminationSec is maximum number of seconds scale down waits for pods to terminate before
@@ -132,4 +143,115 @@ type AutoscalingOptions struct {
 	FilterOutSchedulablePodsUsesPacking bool
 	// Path to kube configuration if available
 	KubeConfigPath string
+	// ScaleDownEmptyInterval is how often empty unneeded nodes are removed outside of the main loop.
+	// Value of 0 disables it, empty nodes are then only removed by the main loop.
+	ScaleDownEmptyInterval time.Duration
+	// NotificationWebhookURL is the endpoint scale events and failures are pushed to. Empty disables notifications.
+	NotificationWebhookURL string
+	// NotificationWebhookFormat is the payload format used for NotificationWebhookURL, either "json" or "slack".
+	NotificationWebhookFormat string
+	// RemoveUninitializedTaint tells whether CA should remove the cluster-autoscaler.kubernetes.io/uninitialized
+	// startup taint from nodes once they become ready.
+	RemoveUninitializedTaint bool
+	// AnnotateNodesWithNodeGroup tells whether nodes should be annotated with the id of their node group
+	// and the config generation of the autoscaler when they join the cluster.
+	AnnotateNodesWithNodeGroup bool
+	// IPAMAwareScaleUp tells whether node groups that report no free IP addresses in their subnets
+	// should be skipped during scale-up.
+	IPAMAwareScaleUp bool
+	// GhostNodeDeletionGracePeriod is how long a node whose instance doesn't exist in the cloud provider
+	// anymore is kept before CA deletes the Node object. Value of 0 disables the deletion.
+	GhostNodeDeletionGracePeriod time.Duration
+	// ScaleDownPreserveZoneSpread tells whether scale-down should prefer removing the nodes that degrade the
+	// zone spread of the remaining replicas of Deployments and StatefulSets the least.
+	ScaleDownPreserveZoneSpread bool
+	// ScaleDownStatefulSetOrdering tells whether scale-down should prefer removing the nodes whose pods of
+	// OrderedReady StatefulSets can be recreated right away, and wait for these StatefulSets to have all their
+	// replicas ready before removing another node hosting their pods.
+	ScaleDownStatefulSetOrdering bool
+	// DrainOnlyNodeGroups are the ids of node groups whose instances are removed by an external system.
+	// CA only drains their nodes on scale down and annotates them as drained for removal.
+	DrainOnlyNodeGroups []string
+	// NodeBusynessPrometheusURL is the Prometheus server queried for the busyness of nodes in scale-down.
+	// Empty disables it and nodes are judged by their request-based utilization only.
+	NodeBusynessPrometheusURL string
+	// NodeBusynessPrometheusQuery is the PromQL query returning the busyness of every node, between 0 and 1.
+	NodeBusynessPrometheusQuery string
+	// NodeBusynessPrometheusNodeLabel is the label of the NodeBusynessPrometheusQuery results holding the node name.
+	NodeBusynessPrometheusNodeLabel string
+	// MaxPodScaleUpAttempts is the number of scale-ups a pod can trigger without becoming schedulable before
+	// it's excluded from scale-up. Value of 0 disables the limit.
+	MaxPodScaleUpAttempts int
+	// ApplyLimitRangeDefaults tells whether the default requests of namespace LimitRanges should be set on
+	// unschedulable pods lacking requests before simulating scale-up.
+	ApplyLimitRangeDefaults bool
+	// NodeReadinessConditions are the node condition types that have to be True on a ready node
+	// of a node group before it's counted as available capacity, unless the node group overrides them.
+	NodeReadinessConditions []string
+	// NodeReadinessLabels are the labels, either keys or key=value pairs, a ready node of a node group
+	// has to carry before it's counted as available capacity, unless the node group overrides them.
+	NodeReadinessLabels []string
+	// StatefulSetNodeGroupStickiness tells whether scale-up should prefer the node groups already hosting
+	// other replicas of the StatefulSets of pending pods.
+	StatefulSetNodeGroupStickiness bool
+	// NodeGroupWeights tells whether scale-up should prefer the node groups with the highest weight in the
+	// time window in effect, according to the node group weights ConfigMap.
+	NodeGroupWeights bool
+	// UnchangedStateSkipDuration is how long iterations are skipped while the cluster state doesn't change
+	// after an iteration that had nothing left to do. Value of 0 disables skipping.
+	UnchangedStateSkipDuration time.Duration
+	// StaleTaintCleanupAge is the age after which ToBeDeleted and DeletionCandidate taints CA doesn't rely on
+	// anymore are removed from nodes. Value of 0 disables the cleanup, the taints are then only removed on startup.
+	StaleTaintCleanupAge time.Duration
+	// WorkloadClassLabel is the pod label holding the workload class of pods, e.g. batch or serving.
+	WorkloadClassLabel string
+	// WorkloadClassExpanders are the names of the expanders used for scale-ups of pods of particular
+	// workload classes, keyed by workload class. ExpanderName is used for other pods.
+	WorkloadClassExpanders map[string]string
+	// ExpanderHintNamespaces are the namespaces whose pending pods may choose the expander or the node
+	// group order of their scale-up with annotations. Hints of pods in other namespaces are ignored.
+	ExpanderHintNamespaces []string
+	// CloudConfigReload tells whether the cloud provider should be rebuilt whenever the content of the
+	// CloudConfig file changes, e.g. when the mounted Secret holding it is rotated.
+	CloudConfigReload bool
+	// DaemonSetRolloutHeadroom tells whether scale-down should keep room on the nodes of DaemonSets being
+	// rolled out for their updated pods when moving the pods of removed nodes.
+	DaemonSetRolloutHeadroom bool
+	// MaxConcurrentNodeGroupOperations is the maximum number of node group size increases and node deletions
+	// running concurrently against the cloud provider. 0 means no limit.
+	MaxConcurrentNodeGroupOperations int
+	// MachineAPINamespaces are the namespaces whose machine API objects are watched by the
+	// openshiftmachineapi cloud provider. Objects of all namespaces are watched if empty.
+	MachineAPINamespaces []string
+	// MachineAPILabelSelector selects the MachineSets and MachineDeployments the openshiftmachineapi
+	// cloud provider uses as node groups. All of them are used if empty.
+	MachineAPILabelSelector string
+	// ScaleDownCompactionTarget is the packing efficiency, the average utilization of the nodes considered for
+	// scale down, below which CA evicts the pods of a node above ScaleDownUtilizationThreshold so that the node can
+	// be removed once they are rescheduled elsewhere. Value of 0 disables compaction.
+	ScaleDownCompactionTarget float64
+	// ScaleDownCompactionDelay is the minimum time between two compactions.
+	ScaleDownCompactionDelay time.Duration
+	// NodeGroupSpecsEnabled tells whether node groups should be created for the NodeGroupSpec custom
+	// resources. Only the gke and openshift-machine-api cloud providers support it.
+	NodeGroupSpecsEnabled bool
+	// SchedulerNames are the names of the schedulers whose unschedulable pods trigger scale-up. Pods of
+	// all schedulers trigger scale-up if empty.
+	SchedulerNames []string
+	// SchedulerPredicates restricts the predicates checked in simulations for the pods of some schedulers,
+	// keyed by scheduler name. Pods of other schedulers are checked against all predicates.
+	SchedulerPredicates map[string][]string
+	// DeviceResources maps the extended resources device plugins share between the pods of a device to
+	// the resources counting the devices of nodes. Their requests are packed on single devices when
+	// estimating scale-ups.
+	DeviceResources map[string]string
+	// DevicePodPendingTimeout is how long a scheduled pod requesting device resources may wait for its
+	// containers to be created before it triggers scale-up like an unschedulable pod. 0 disables it.
+	DevicePodPendingTimeout time.Duration
+	// BalancingIgnoredLabels are the labels the nodes of similar node groups can differ in, on top of the
+	// ones ignored by default and by the cloud provider, when balancing scale-ups between them.
+	BalancingIgnoredLabels []string
+	// SpotNodeGroupPreference is "prefer" to prefer scaling up the node groups backed by spot or preemptible
+	// instances, or "avoid" to prefer the other ones, on cloud providers labeling their nodes. Empty if none.
+	SpotNodeGroupPreference string
 }
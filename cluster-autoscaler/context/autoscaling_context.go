@@ -24,6 +24,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/workpool"
 	kube_client "k8s.io/client-go/kubernetes"
 	kube_record "k8s.io/client-go/tools/record"
 	"k8s.io/klog"
@@ -45,6 +46,9 @@ type AutoscalingContext struct {
 	ExpanderStrategy expander.Strategy
 	// EstimatorBuilder is the builder function for node count estimator to be used.
 	EstimatorBuilder estimator.EstimatorBuilder
+	// NodeGroupOperations limits the node group operations running concurrently against the cloud
+	// provider, by node group id. Nil means no limit.
+	NodeGroupOperations *workpool.Pool
 }
 
 // AutoscalingKubeClients contains all Kubernetes API clients,
@@ -89,6 +93,7 @@ func NewAutoscalingContext(options config.AutoscalingOptions, predicateChecker *
 		PredicateChecker:       predicateChecker,
 		ExpanderStrategy:       expanderStrategy,
 		EstimatorBuilder:       estimatorBuilder,
+		NodeGroupOperations:    workpool.NewPool(options.MaxConcurrentNodeGroupOperations),
 	}
 }
 
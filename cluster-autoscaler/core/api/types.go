@@ -0,0 +1,42 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api holds the types of the runtime state of the autoscaler shared with its clients.
+package api
+
+import (
+	"time"
+)
+
+// AutoscalerState is the runtime state of an autoscaler that a new instance replacing it, e.g. during
+// an upgrade or a migration of the control plane, imports to keep backing off the same node groups and
+// to keep the same scale-down delays instead of starting over.
+type AutoscalerState struct {
+	LastScaleUpTime         time.Time `json:"lastScaleUpTime"`
+	LastScaleDownDeleteTime time.Time `json:"lastScaleDownDeleteTime"`
+	LastScaleDownFailTime   time.Time `json:"lastScaleDownFailTime"`
+	// UnneededNodes holds since when nodes have been unneeded, by node name.
+	UnneededNodes map[string]time.Time `json:"unneededNodes,omitempty"`
+	// NodeGroupBackoffs holds the backoffs of the node groups, by node group.
+	NodeGroupBackoffs map[string]BackoffData `json:"nodeGroupBackoffs,omitempty"`
+}
+
+// BackoffData is the backoff of a node group, as exported by a Backoff.
+type BackoffData struct {
+	Duration            time.Duration `json:"duration"`
+	BackoffUntil        time.Time     `json:"backoffUntil"`
+	LastFailedExecution time.Time     `json:"lastFailedExecution"`
+}
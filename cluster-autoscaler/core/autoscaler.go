@@ -19,19 +19,29 @@ package core
 import (
 	"time"
 
+	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/core/api"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/factory"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/podhint"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/spot"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/statefulset"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/weights"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/workloadclass"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	kube_client "k8s.io/client-go/kubernetes"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog"
 )
 
 // AutoscalerOptions is the whole set of options for configuring an autoscaler
@@ -46,6 +56,7 @@ type AutoscalerOptions struct {
 	EstimatorBuilder       estimator.EstimatorBuilder
 	Processors             *ca_processors.AutoscalingProcessors
 	Backoff                backoff.Backoff
+	ConfigMapLister        v1lister.ConfigMapNamespaceLister
 }
 
 // Autoscaler is the main component of CA which scales up/down node groups according to its configuration
@@ -53,28 +64,40 @@ type AutoscalerOptions struct {
 type Autoscaler interface {
 	// RunOnce represents an iteration in the control-loop of CA
 	RunOnce(currentTime time.Time) errors.AutoscalerError
+	// RunEmptyNodeCleanup removes empty unneeded nodes between the iterations of the control-loop. It must not
+	// be called concurrently with RunOnce
+	RunEmptyNodeCleanup(currentTime time.Time) errors.AutoscalerError
 	// ExitCleanUp is a clean-up performed just before process termination.
 	ExitCleanUp()
+	// ExportState returns the runtime state of the autoscaler, to be imported by an instance replacing it.
+	ExportState() *api.AutoscalerState
+	// ImportState replaces the runtime state of the autoscaler with the one exported by another instance.
+	ImportState(state *api.AutoscalerState)
 }
 
 // NewAutoscaler creates an autoscaler of an appropriate type according to the parameters
 func NewAutoscaler(opts AutoscalerOptions) (Autoscaler, errors.AutoscalerError) {
-	err := initializeDefaultOptions(&opts)
+	stopChannel := make(chan struct{})
+	err := initializeDefaultOptions(&opts, stopChannel)
 	if err != nil {
 		return nil, errors.ToAutoscalerError(errors.InternalError, err)
 	}
-	return NewStaticAutoscaler(
+	autoscaler := NewStaticAutoscaler(
 		opts.AutoscalingOptions,
 		opts.PredicateChecker,
 		opts.AutoscalingKubeClients,
 		opts.Processors, opts.CloudProvider,
 		opts.ExpanderStrategy,
 		opts.EstimatorBuilder,
-		opts.Backoff), nil
+		opts.Backoff,
+		opts.ConfigMapLister)
+	autoscaler.stopChannel = stopChannel
+	return autoscaler, nil
 }
 
-// Initialize default options if not provided.
-func initializeDefaultOptions(opts *AutoscalerOptions) error {
+// Initialize default options if not provided. The listers built for the
+// autoscaler stop when stopChannel is closed.
+func initializeDefaultOptions(opts *AutoscalerOptions, stopChannel chan struct{}) error {
 	if opts.Processors == nil {
 		opts.Processors = ca_processors.DefaultProcessors()
 	}
@@ -88,20 +111,72 @@ func initializeDefaultOptions(opts *AutoscalerOptions) error {
 			return err
 		}
 		opts.PredicateChecker = predicateChecker
+		if len(opts.SchedulerPredicates) > 0 {
+			if err := predicateChecker.SetSchedulerPredicates(opts.SchedulerPredicates); err != nil {
+				return err
+			}
+		}
 	}
 	if opts.CloudProvider == nil {
 		opts.CloudProvider = cloudBuilder.NewCloudProvider(opts.AutoscalingOptions)
 	}
+	if opts.ConfigMapLister == nil {
+		opts.ConfigMapLister = kube_util.NewConfigMapLister(opts.KubeClient, opts.ConfigNamespace, stopChannel)
+	}
 	if opts.ExpanderStrategy == nil {
 		expanderStrategy, err := factory.ExpanderStrategyFromString(opts.ExpanderName,
 			opts.CloudProvider, opts.AutoscalingKubeClients.AllNodeLister())
 		if err != nil {
 			return err
 		}
+		if len(opts.WorkloadClassExpanders) > 0 {
+			strategies := make(map[string]expander.Strategy, len(opts.WorkloadClassExpanders))
+			for class, expanderName := range opts.WorkloadClassExpanders {
+				strategy, err := factory.ExpanderStrategyFromString(expanderName,
+					opts.CloudProvider, opts.AutoscalingKubeClients.AllNodeLister())
+				if err != nil {
+					return err
+				}
+				strategies[class] = strategy
+			}
+			expanderStrategy = workloadclass.NewStrategy(opts.WorkloadClassLabel, strategies, expanderStrategy)
+		}
+		if len(opts.ExpanderHintNamespaces) > 0 {
+			strategies := make(map[string]expander.Strategy, len(expander.AvailableExpanders))
+			for _, expanderName := range expander.AvailableExpanders {
+				strategy, err := factory.ExpanderStrategyFromString(expanderName,
+					opts.CloudProvider, opts.AutoscalingKubeClients.AllNodeLister())
+				if err != nil {
+					klog.V(1).Infof("Expander %s can't be chosen by pods: %v", expanderName, err)
+					continue
+				}
+				strategies[expanderName] = strategy
+			}
+			expanderStrategy = podhint.NewStrategy(opts.ExpanderHintNamespaces, strategies, expanderStrategy)
+		}
+		if opts.SpotNodeGroupPreference != "" {
+			if spotCloudProvider, ok := opts.CloudProvider.(cloudprovider.SpotCloudProvider); ok && spotCloudProvider.SpotLabel() != "" {
+				expanderStrategy = spot.NewStrategy(expanderStrategy, spotCloudProvider.SpotLabel(),
+					opts.SpotNodeGroupPreference == spot.Prefer)
+			} else {
+				klog.Warningf("Cloud provider %s doesn't label spot nodes, ignoring the spot node group preference", opts.CloudProvider.Name())
+			}
+		}
+		if opts.NodeGroupWeights {
+			expanderStrategy = weights.NewStrategy(expanderStrategy, opts.ConfigMapLister)
+		}
+		if opts.StatefulSetNodeGroupStickiness {
+			expanderStrategy = statefulset.NewStrategy(expanderStrategy, opts.CloudProvider,
+				opts.AutoscalingKubeClients.ScheduledPodLister(), opts.AutoscalingKubeClients.AllNodeLister())
+		}
 		opts.ExpanderStrategy = expanderStrategy
 	}
 	if opts.EstimatorBuilder == nil {
-		estimatorBuilder, err := estimator.NewEstimatorBuilder(opts.EstimatorName)
+		deviceResources := make(estimator.DeviceResources, len(opts.DeviceResources))
+		for name, countName := range opts.DeviceResources {
+			deviceResources[apiv1.ResourceName(name)] = apiv1.ResourceName(countName)
+		}
+		estimatorBuilder, err := estimator.NewEstimatorBuilder(opts.EstimatorName, deviceResources)
 		if err != nil {
 			return err
 		}
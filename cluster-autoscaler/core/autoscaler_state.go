@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/core/api"
+	"k8s.io/klog"
+)
+
+// ExportState returns the runtime state of the autoscaler.
+func (a *StaticAutoscaler) ExportState() *api.AutoscalerState {
+	a.scaleDownMutex.Lock()
+	defer a.scaleDownMutex.Unlock()
+
+	unneededNodes := make(map[string]time.Time, len(a.scaleDown.unneededNodes)+len(a.scaleDown.importedUnneededNodes))
+	for name, since := range a.scaleDown.importedUnneededNodes {
+		unneededNodes[name] = since
+	}
+	for name, since := range a.scaleDown.unneededNodes {
+		unneededNodes[name] = since
+	}
+	return &api.AutoscalerState{
+		LastScaleUpTime:         a.lastScaleUpTime,
+		LastScaleDownDeleteTime: a.lastScaleDownDeleteTime,
+		LastScaleDownFailTime:   a.lastScaleDownFailTime,
+		UnneededNodes:           unneededNodes,
+		NodeGroupBackoffs:       a.clusterStateRegistry.ExportBackoffData(),
+	}
+}
+
+// ImportState replaces the runtime state of the autoscaler with the given one, exported by another
+// instance. Imported unneeded nodes are only considered for scale-down once the next scale-down
+// simulation finds them unneeded, with the time they have been unneeded since. Backoffs of node
+// groups that are gone are forgotten once they are stale.
+func (a *StaticAutoscaler) ImportState(state *api.AutoscalerState) {
+	a.scaleDownMutex.Lock()
+	defer a.scaleDownMutex.Unlock()
+
+	a.lastScaleUpTime = state.LastScaleUpTime
+	a.lastScaleDownDeleteTime = state.LastScaleDownDeleteTime
+	a.lastScaleDownFailTime = state.LastScaleDownFailTime
+	unneededNodes := make(map[string]time.Time, len(state.UnneededNodes))
+	for name, since := range state.UnneededNodes {
+		unneededNodes[name] = since
+	}
+	a.scaleDown.importedUnneededNodes = unneededNodes
+	a.clusterStateRegistry.ImportBackoffData(state.NodeGroupBackoffs)
+	klog.V(1).Infof("Imported autoscaler state: lastScaleUpTime=%s lastScaleDownDeleteTime=%s lastScaleDownFailTime=%s "+
+		"unneeded nodes=%d backed off node groups=%d", state.LastScaleUpTime, state.LastScaleDownDeleteTime,
+		state.LastScaleDownFailTime, len(state.UnneededNodes), len(state.NodeGroupBackoffs))
+}
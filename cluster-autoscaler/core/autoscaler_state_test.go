@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStateTestAutoscaler(provider cloudprovider.CloudProvider) *StaticAutoscaler {
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.35,
+	}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	return &StaticAutoscaler{
+		AutoscalingContext:   &context,
+		clusterStateRegistry: clusterStateRegistry,
+		scaleDown:            NewScaleDown(&context, clusterStateRegistry),
+	}
+}
+
+func TestExportImportState(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 10)
+	n2 := BuildTestNode("n2", 1000, 10)
+	SetNodeReadyState(n1, true, time.Time{})
+	SetNodeReadyState(n2, true, time.Time{})
+	p2 := BuildTestPod("p2", 800, 0)
+	p2.Spec.NodeName = "n2"
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	now := time.Now()
+	exporting := newStateTestAutoscaler(provider)
+	exporting.lastScaleUpTime = now.Add(-time.Hour)
+	exporting.lastScaleDownDeleteTime = now.Add(-2 * time.Hour)
+	exporting.lastScaleDownFailTime = now.Add(-3 * time.Hour)
+	exporting.scaleDown.unneededNodes = map[string]time.Time{"n1": now.Add(-8 * time.Minute), "n2": now.Add(-9 * time.Minute)}
+	exporting.clusterStateRegistry.RegisterFailedScaleUp(provider.GetNodeGroup("ng1"), metrics.APIError, now)
+
+	state := exporting.ExportState()
+	assert.Equal(t, now.Add(-time.Hour), state.LastScaleUpTime)
+	assert.Equal(t, exporting.scaleDown.unneededNodes, state.UnneededNodes)
+	assert.Contains(t, state.NodeGroupBackoffs, "ng1")
+
+	importing := newStateTestAutoscaler(provider)
+	importing.ImportState(state)
+	assert.Equal(t, now.Add(-time.Hour), importing.lastScaleUpTime)
+	assert.Equal(t, now.Add(-2*time.Hour), importing.lastScaleDownDeleteTime)
+	assert.Equal(t, now.Add(-3*time.Hour), importing.lastScaleDownFailTime)
+	assert.Equal(t, state.NodeGroupBackoffs, importing.clusterStateRegistry.ExportBackoffData())
+	// Imported unneeded nodes aren't considered for scale down before being simulated, but are exported.
+	assert.Empty(t, importing.scaleDown.unneededNodes)
+	assert.Equal(t, state.UnneededNodes, importing.ExportState().UnneededNodes)
+
+	// Nodes still unneeded keep the imported unneeded time, the others are forgotten.
+	nodes := []*apiv1.Node{n1, n2}
+	assert.NoError(t, importing.scaleDown.UpdateUnneededNodes(nodes, nodes, []*apiv1.Pod{p2}, now, nil))
+	assert.Equal(t, map[string]time.Time{"n1": now.Add(-8 * time.Minute)}, importing.scaleDown.unneededNodes)
+	assert.Nil(t, importing.scaleDown.importedUnneededNodes)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog"
+)
+
+// packingEfficiency returns the average utilization of the given nodes, or 1 if there are none.
+func packingEfficiency(utilization map[string]simulator.UtilizationInfo) float64 {
+	if len(utilization) == 0 {
+		return 1
+	}
+	total := 0.0
+	for _, info := range utilization {
+		total += info.Utilization
+	}
+	return total / float64(len(utilization))
+}
+
+// getCompactionCandidates returns the nodes worth compacting, least utilized first: the nodes considered
+// for scale down in the last UpdateUnneededNodes call that aren't unneeded, whose utilization is below
+// the compaction target and whose node group is above its min size.
+func (sd *ScaleDown) getCompactionCandidates(nodesWithoutMaster []*apiv1.Node) []*apiv1.Node {
+	nodeGroupSize := getNodeGroupSizeMap(sd.context.CloudProvider)
+	candidates := make([]*apiv1.Node, 0)
+	for _, node := range nodesWithoutMaster {
+		utilInfo, found := sd.nodeUtilizationMap[node.Name]
+		if !found || utilInfo.Utilization >= sd.context.ScaleDownCompactionTarget {
+			continue
+		}
+		if _, found := sd.unneededNodes[node.Name]; found {
+			continue
+		}
+		if deletetaint.HasToBeDeletedTaint(node) || hasNoScaleDownAnnotation(node) {
+			continue
+		}
+		// The cloud provider may have started repairing the node since UpdateUnneededNodes.
+		if unremovable, reason := isUnremovableByCloudProvider(sd.context.CloudProvider, node); unremovable {
+			klog.V(4).Infof("Skipping %s from compaction - %s", node.Name, reason)
+			continue
+		}
+		nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			klog.Errorf("Error while checking node group for %s: %v", node.Name, err)
+			continue
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		if nodeGroupSize[nodeGroup.Id()] <= nodeGroup.MinSize() {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return sd.nodeUtilizationMap[candidates[i].Name].Utilization < sd.nodeUtilizationMap[candidates[j].Name].Utilization
+	})
+	return candidates
+}
+
+// TryToCompact evicts the pods of at most one node that isn't unneeded yet, so that it can be removed
+// once its pods are rescheduled on other nodes, if the packing efficiency of the nodes considered for
+// scale down is below ScaleDownCompactionTarget. The least utilized node whose pods can all be moved is
+// soft tainted, so that its pods aren't scheduled back, and considered unneeded until its pods can't be
+// moved anymore. Evictions are tried once, so those refused by PodDisruptionBudgets are left for a later
+// compaction or the node deletion. Must be called after UpdateUnneededNodes. Returns whether pods were
+// evicted.
+func (sd *ScaleDown) TryToCompact(allNodes []*apiv1.Node, pods []*apiv1.Pod, currentTime time.Time) (bool, errors.AutoscalerError) {
+	if sd.context.ScaleDownCompactionTarget <= 0 || sd.nodeDeleteStatus.IsDeleteInProgress() ||
+		sd.lastCompactionTime.Add(sd.context.ScaleDownCompactionDelay).After(currentTime) {
+		return false, nil
+	}
+	efficiency := packingEfficiency(sd.nodeUtilizationMap)
+	if efficiency >= sd.context.ScaleDownCompactionTarget {
+		return false, nil
+	}
+
+	nodesWithoutMaster := filterOutMasters(allNodes, pods)
+	candidates := sd.getCompactionCandidates(nodesWithoutMaster)
+	if len(candidates) == 0 {
+		klog.V(1).Infof("Compaction: no candidates, packing efficiency %f", efficiency)
+		return false, nil
+	}
+	nonExpendablePods := filterOutExpendablePods(pods, sd.context.ExpendablePodsPriorityCutoff)
+	nodesToRemove, _, _, typedErr := simulator.FindNodesToRemove(candidates, nodesWithoutMaster, nonExpendablePods, sd.context.ListerRegistry,
+		sd.context.PredicateChecker, 1, false, sd.podLocationHints, sd.usageTracker, currentTime, sd.pdbCache)
+	if typedErr != nil {
+		return false, typedErr.AddPrefix("Find node to compact failed: ")
+	}
+	if len(nodesToRemove) == 0 {
+		klog.V(1).Infof("Compaction: no node can be drained, packing efficiency %f", efficiency)
+		return false, nil
+	}
+	toCompact := nodesToRemove[0]
+	node := toCompact.Node
+
+	if err := deletetaint.MarkDeletionCandidate(node, sd.context.ClientSet); err != nil {
+		return false, errors.ToAutoscalerError(errors.ApiCallError, err)
+	}
+	sd.lastCompactionTime = currentTime
+	sd.compactedNodes[node.Name] = currentTime
+	if _, found := sd.unneededNodes[node.Name]; !found {
+		sd.unneededNodes[node.Name] = currentTime
+		sd.unneededNodesList = append(sd.unneededNodesList, node)
+	}
+
+	klog.V(0).Infof("Compaction: evicting %d pods from node %s, utilization: %v, packing efficiency %f below target %f",
+		len(toCompact.PodsToReschedule), node.Name, sd.nodeUtilizationMap[node.Name], efficiency, sd.context.ScaleDownCompactionTarget)
+	sd.context.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaleDownCompaction", "Compaction: evicting %d pods from node %s",
+		len(toCompact.PodsToReschedule), node.Name)
+	sd.context.Recorder.Eventf(node, apiv1.EventTypeNormal, "ScaleDownCompaction", "evicting %d pods so that the node can be removed",
+		len(toCompact.PodsToReschedule))
+
+	evicted := 0
+	namespaces := getPodNamespaces(toCompact.PodsToReschedule, sd.context.ClientSet)
+	for _, pod := range toCompact.PodsToReschedule {
+		maxGracefulTerminationSec := getMaxGracefulTerminationSec(pod, namespaces[pod.Namespace], sd.context.MaxGracefulTerminationSec)
+		if err := evictPod(pod, sd.context.ClientSet, sd.context.Recorder, maxGracefulTerminationSec, currentTime, 0); err != nil {
+			klog.Warningf("Compaction: %v", err)
+			continue
+		}
+		evicted++
+	}
+	metrics.RegisterEvictions(evicted)
+	return true, nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackingEfficiency(t *testing.T) {
+	assert.Equal(t, 1.0, packingEfficiency(map[string]simulator.UtilizationInfo{}))
+	assert.InDelta(t, 0.5, packingEfficiency(map[string]simulator.UtilizationInfo{
+		"n1": {Utilization: 0.2},
+		"n2": {Utilization: 0.8},
+	}), 1e-9)
+}
+
+func TestTryToCompact(t *testing.T) {
+	evictedPods := make(chan string, 10)
+	updatedNodes := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "job",
+			Namespace: "default",
+			SelfLink:  "/apivs/batch/v1/namespaces/default/jobs/job",
+		},
+	}
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Time{})
+	nodes := []*apiv1.Node{n1, n2, n3}
+
+	p1 := BuildTestPod("p1", 700, 0)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 400, 0)
+	p2.Spec.NodeName = "n2"
+	p3 := BuildTestPod("p3", 300, 0)
+	p3.Spec.NodeName = "n3"
+	pods := []*apiv1.Pod{p1, p2, p3}
+	for _, pod := range pods {
+		pod.OwnerReferences = GenerateOwnerReferences(job.Name, "Job", "batch/v1", "")
+	}
+
+	fakeClient.Fake.AddReactor("get", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		getAction := action.(core.GetAction)
+		for _, node := range nodes {
+			if node.Name == getAction.GetName() {
+				return true, node, nil
+			}
+		}
+		return true, nil, fmt.Errorf("wrong node: %v", getAction.GetName())
+	})
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		obj := action.(core.UpdateAction).GetObject().(*apiv1.Node)
+		updatedNodes <- obj.Name
+		return true, obj, nil
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := createAction.GetObject().(*policyv1.Eviction)
+		evictedPods <- eviction.Name
+		return true, nil, nil
+	})
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 3)
+	for _, node := range nodes {
+		provider.AddNode("ng1", node)
+	}
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.2,
+		ScaleDownCompactionTarget:     0.6,
+		ScaleDownCompactionDelay:      10 * time.Minute,
+		MaxGracefulTerminationSec:     60,
+	}
+	jobLister, err := kube_util.NewTestJobLister([]*batchv1.Job{&job})
+	assert.NoError(t, err)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil, nil, nil)
+	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+
+	// All the nodes are above the utilization threshold, but their average utilization is below the target.
+	now := time.Now()
+	assert.NoError(t, scaleDown.UpdateUnneededNodes(nodes, nodes, pods, now, nil))
+	assert.Empty(t, scaleDown.unneededNodes)
+
+	compacted, err := scaleDown.TryToCompact(nodes, pods, now)
+	assert.NoError(t, err)
+	assert.True(t, compacted)
+	assert.Equal(t, n3.Name, getStringFromChan(updatedNodes))
+	assert.Equal(t, p3.Name, getStringFromChan(evictedPods))
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(evictedPods))
+	assert.Contains(t, scaleDown.unneededNodes, n3.Name)
+
+	// Compactions are at least ScaleDownCompactionDelay apart.
+	compacted, err = scaleDown.TryToCompact(nodes, pods, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, compacted)
+
+	// The compacted node stays unneeded regardless of its utilization...
+	assert.NoError(t, scaleDown.UpdateUnneededNodes(nodes, nodes, pods, now.Add(time.Minute), nil))
+	assert.Equal(t, now, scaleDown.unneededNodes[n3.Name])
+	assert.Contains(t, scaleDown.compactedNodes, n3.Name)
+
+	// ...until its pods can't be moved anymore.
+	assert.NoError(t, scaleDown.UpdateUnneededNodes([]*apiv1.Node{n3}, []*apiv1.Node{n3}, []*apiv1.Pod{p3}, now.Add(2*time.Minute), nil))
+	assert.NotContains(t, scaleDown.unneededNodes, n3.Name)
+	assert.NotContains(t, scaleDown.compactedNodes, n3.Name)
+
+	// Nothing is compacted once the average utilization reaches the target.
+	scaleDown = NewScaleDown(&context, clusterStateRegistry)
+	context.ScaleDownCompactionTarget = 0.4
+	assert.NoError(t, scaleDown.UpdateUnneededNodes(nodes, nodes, pods, now, nil))
+	compacted, err = scaleDown.TryToCompact(nodes, pods, now)
+	assert.NoError(t, err)
+	assert.False(t, compacted)
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(evictedPods))
+
+	// Nodes the cloud provider reports as unremovable aren't compacted.
+	scaleDown = NewScaleDown(&context, clusterStateRegistry)
+	context.ScaleDownCompactionTarget = 0.6
+	assert.NoError(t, scaleDown.UpdateUnneededNodes(nodes, nodes, pods, now, nil))
+	provider.SetNodeUnremovable(n3.Name, "repairing")
+	compacted, err = scaleDown.TryToCompact(nodes, pods, now)
+	assert.NoError(t, err)
+	assert.True(t, compacted)
+	assert.Equal(t, n2.Name, getStringFromChan(updatedNodes))
+	assert.Equal(t, p2.Name, getStringFromChan(evictedPods))
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+
+	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
+
+	apiv1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podEquivalenceGroup is a group of pending pods owned by the same controller, with identical
+// labels and spec. Pods in a group behave the same way in scheduling simulations, so predicates
+// only have to be checked for one of them.
+type podEquivalenceGroup struct {
+	pods []*apiv1.Pod
+}
+
+// exemplar returns the pod representing the whole group in simulations.
+func (g *podEquivalenceGroup) exemplar() *apiv1.Pod {
+	return g.pods[0]
+}
+
+func (g *podEquivalenceGroup) matches(pod *apiv1.Pod) bool {
+	exemplar := g.exemplar()
+	return reflect.DeepEqual(pod.Labels, exemplar.Labels) && apiequality.Semantic.DeepEqual(pod.Spec, exemplar.Spec)
+}
+
+// buildPodEquivalenceGroups splits pods into equivalence groups, preserving the order in which
+// the groups were first seen. Pods without a controller always end up in a group of their own.
+func buildPodEquivalenceGroups(pods []*apiv1.Pod) []*podEquivalenceGroup {
+	groups := make([]*podEquivalenceGroup, 0)
+	groupsByController := make(map[types.UID][]*podEquivalenceGroup)
+
+podsloop:
+	for _, pod := range pods {
+		ref := drain.ControllerRef(pod)
+		if ref == nil {
+			groups = append(groups, &podEquivalenceGroup{pods: []*apiv1.Pod{pod}})
+			continue
+		}
+		for _, group := range groupsByController[ref.UID] {
+			if group.matches(pod) {
+				group.pods = append(group.pods, pod)
+				continue podsloop
+			}
+		}
+		group := &podEquivalenceGroup{pods: []*apiv1.Pod{pod}}
+		groupsByController[ref.UID] = append(groupsByController[ref.UID], group)
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPodEquivalenceGroups(t *testing.T) {
+	rc1Pod1 := BuildTestPod("rc1-1", 100, 0)
+	rc1Pod1.OwnerReferences = GenerateOwnerReferences("rc1", "ReplicationController", "extensions/v1beta1", "rc1-uid")
+	rc1Pod2 := BuildTestPod("rc1-2", 100, 0)
+	rc1Pod2.OwnerReferences = GenerateOwnerReferences("rc1", "ReplicationController", "extensions/v1beta1", "rc1-uid")
+	rc1Different := BuildTestPod("rc1-3", 500, 0)
+	rc1Different.OwnerReferences = GenerateOwnerReferences("rc1", "ReplicationController", "extensions/v1beta1", "rc1-uid")
+	rc2Pod := BuildTestPod("rc2-1", 100, 0)
+	rc2Pod.OwnerReferences = GenerateOwnerReferences("rc2", "ReplicationController", "extensions/v1beta1", "rc2-uid")
+	orphan1 := BuildTestPod("orphan-1", 100, 0)
+	orphan2 := BuildTestPod("orphan-2", 100, 0)
+
+	groups := buildPodEquivalenceGroups([]*apiv1.Pod{rc1Pod1, orphan1, rc2Pod, rc1Pod2, rc1Different, orphan2})
+
+	assert.Equal(t, 5, len(groups))
+	assert.Equal(t, []*apiv1.Pod{rc1Pod1, rc1Pod2}, groups[0].pods)
+	assert.Equal(t, []*apiv1.Pod{orphan1}, groups[1].pods)
+	assert.Equal(t, []*apiv1.Pod{rc2Pod}, groups[2].pods)
+	assert.Equal(t, []*apiv1.Pod{rc1Different}, groups[3].pods)
+	assert.Equal(t, []*apiv1.Pod{orphan2}, groups[4].pods)
+	assert.Equal(t, rc1Pod1, groups[0].exemplar())
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	kube_record "k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	"k8s.io/kubernetes/pkg/scheduler/algorithm/predicates"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// oversizedPods tracks the unschedulable pods requesting more resources than the template node of
+// any node group has left after its DaemonSet pods, and excludes them from scale-up simulations.
+// Each pod is reported once, and again only after its requests changed. Pods are forgotten as soon
+// as they are not unschedulable anymore. A nil oversizedPods filters out no pods.
+type oversizedPods struct {
+	// reported holds the requests the pods were last reported with.
+	reported map[types.UID]string
+}
+
+func newOversizedPods() *oversizedPods {
+	return &oversizedPods{
+		reported: make(map[types.UID]string),
+	}
+}
+
+// update forgets the pods that are not among the given unschedulable pods anymore.
+func (o *oversizedPods) update(unschedulablePods []*apiv1.Pod) {
+	if o == nil {
+		return
+	}
+	pending := make(map[types.UID]bool, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		pending[pod.UID] = true
+	}
+	for uid := range o.reported {
+		if !pending[uid] {
+			delete(o.reported, uid)
+		}
+	}
+}
+
+// filterOutOversized returns the given pods without those that fit no template node of the node
+// groups, emitting a NoNodeGroupCanFit event on the ones not reported with their requests yet. No
+// pods are filtered out if a node group has no template node, or if node groups can be
+// autoprovisioned, since a node group able to fit them may be created.
+func (o *oversizedPods) filterOutOversized(pods []*apiv1.Pod, nodeGroups []cloudprovider.NodeGroup,
+	nodeInfos map[string]*schedulernodeinfo.NodeInfo, autoprovisioning bool, recorder kube_record.EventRecorder) []*apiv1.Pod {
+	if o == nil || autoprovisioning || len(nodeGroups) == 0 {
+		return pods
+	}
+	templates := make([]*schedulernodeinfo.NodeInfo, 0, len(nodeGroups))
+	for _, nodeGroup := range nodeGroups {
+		nodeInfo, found := nodeInfos[nodeGroup.Id()]
+		if !found {
+			return pods
+		}
+		templates = append(templates, nodeInfo)
+	}
+
+	result := make([]*apiv1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		request := predicates.GetResourceRequest(pod)
+		if fitsAnyTemplate(request, templates) {
+			result = append(result, pod)
+			continue
+		}
+		requestKey := fmt.Sprintf("%+v", *request)
+		if o.reported[pod.UID] != requestKey {
+			o.reported[pod.UID] = requestKey
+			klog.Warningf("Pod %s/%s requests more resources than any node group can offer, excluding it from scale-up", pod.Namespace, pod.Name)
+			recorder.Eventf(pod, apiv1.EventTypeWarning, "NoNodeGroupCanFit",
+				"pod requests more resources than a node of any node group can offer, it won't trigger scale-up")
+			metrics.RegisterOversizedPod()
+		}
+		klog.V(3).Infof("Pod %s/%s fits no node group", pod.Namespace, pod.Name)
+	}
+	return result
+}
+
+// fitsAnyTemplate returns true if the request fits in the resources one of the template nodes has
+// left. Ephemeral storage is only checked against the templates reporting it.
+func fitsAnyTemplate(request *schedulernodeinfo.Resource, templates []*schedulernodeinfo.NodeInfo) bool {
+	for _, template := range templates {
+		allocatable := template.AllocatableResource()
+		requested := template.RequestedResource()
+		if request.MilliCPU > allocatable.MilliCPU-requested.MilliCPU ||
+			request.Memory > allocatable.Memory-requested.Memory {
+			continue
+		}
+		if allocatable.EphemeralStorage > 0 && request.EphemeralStorage > allocatable.EphemeralStorage-requested.EphemeralStorage {
+			continue
+		}
+		fits := true
+		for name, quantity := range request.ScalarResources {
+			if quantity > allocatable.ScalarResources[name]-requested.ScalarResources[name] {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return true
+		}
+	}
+	return false
+}
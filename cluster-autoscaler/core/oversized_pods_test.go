@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	kube_record "k8s.io/client-go/tools/record"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOversizedPods(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("small", 0, 10, 1)
+	provider.AddNodeGroup("large", 0, 10, 1)
+
+	small := schedulernodeinfo.NewNodeInfo()
+	small.SetNode(BuildTestNode("small-template", 1000, 1000))
+	// The DaemonSet pod of the large template leaves 3000 millicores to other pods.
+	large := schedulernodeinfo.NewNodeInfo(BuildTestPod("ds", 1000, 0))
+	large.SetNode(BuildTestNode("large-template", 4000, 1000))
+	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{"small": small, "large": large}
+
+	p1 := BuildTestPod("p1", 3000, 500)
+	p1.UID = types.UID("p1")
+	p2 := BuildTestPod("p2", 3500, 500)
+	p2.UID = types.UID("p2")
+	p3 := BuildTestPod("p3", 500, 2000)
+	p3.UID = types.UID("p3")
+	pods := []*apiv1.Pod{p1, p2, p3}
+	recorder := kube_record.NewFakeRecorder(10)
+
+	o := newOversizedPods()
+	o.update(pods)
+	assert.Equal(t, []*apiv1.Pod{p1}, o.filterOutOversized(pods, provider.NodeGroups(), nodeInfos, false, recorder))
+	assert.Equal(t, 2, len(recorder.Events))
+
+	// Pods are reported once.
+	assert.Equal(t, []*apiv1.Pod{p1}, o.filterOutOversized(pods, provider.NodeGroups(), nodeInfos, false, recorder))
+	assert.Equal(t, 2, len(recorder.Events))
+
+	// Pods are reported again once their requests changed.
+	p2.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU] = resource.MustParse("3600m")
+	assert.Equal(t, []*apiv1.Pod{p1}, o.filterOutOversized(pods, provider.NodeGroups(), nodeInfos, false, recorder))
+	assert.Equal(t, 3, len(recorder.Events))
+
+	// Pods that got scheduled are forgotten.
+	o.update([]*apiv1.Pod{p1, p2})
+	assert.NotContains(t, o.reported, p3.UID)
+	assert.Contains(t, o.reported, p2.UID)
+
+	// No pods are filtered out with autoprovisioning, nor if a template is missing.
+	assert.Equal(t, pods, o.filterOutOversized(pods, provider.NodeGroups(), nodeInfos, true, recorder))
+	delete(nodeInfos, "small")
+	assert.Equal(t, pods, o.filterOutOversized(pods, provider.NodeGroups(), nodeInfos, false, recorder))
+	assert.Equal(t, 3, len(recorder.Events))
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"time"
+
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const (
+	// PauseConfigMapName is the name of the ConfigMap, in the CA namespace, that pauses autoscaling.
+	PauseConfigMapName = "cluster-autoscaler-pause"
+	// PausedKey is the key of the pause ConfigMap that pauses autoscaling when set to "true".
+	PausedKey = "paused"
+	// PausedUntilKey is the key of the pause ConfigMap holding the time, in RFC 3339 format, the
+	// pause expires at. The pause doesn't expire if it's not set.
+	PausedUntilKey = "pausedUntil"
+)
+
+// isAutoscalingPaused tells whether autoscaling is paused by the pause ConfigMap in the given namespace.
+// Autoscaling isn't paused if the ConfigMap can't be read or its expiry time can't be parsed.
+func isAutoscalingPaused(client kube_client.Interface, namespace string, now time.Time) bool {
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(PauseConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !kube_errors.IsNotFound(err) {
+			klog.Warningf("Failed to get %s/%s, autoscaling isn't paused: %v", namespace, PauseConfigMapName, err)
+		}
+		return false
+	}
+	if configMap == nil || configMap.Data[PausedKey] != "true" {
+		return false
+	}
+	if value, found := configMap.Data[PausedUntilKey]; found {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			klog.Warningf("Invalid %s %q in %s/%s, autoscaling isn't paused: %v", PausedUntilKey, value, namespace, PauseConfigMapName, err)
+			return false
+		}
+		if !now.Before(until) {
+			klog.V(4).Infof("Autoscaling pause expired at %s", value)
+			return false
+		}
+	}
+	return true
+}
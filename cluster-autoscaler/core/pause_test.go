@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAutoscalingPaused(t *testing.T) {
+	now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		data     map[string]string
+		expected bool
+	}{
+		{name: "no config map"},
+		{name: "not paused", data: map[string]string{PausedKey: "false"}},
+		{name: "paused without expiry", data: map[string]string{PausedKey: "true"}, expected: true},
+		{name: "paused until later", data: map[string]string{PausedKey: "true", PausedUntilKey: "2019-05-01T13:00:00Z"}, expected: true},
+		{name: "pause expired", data: map[string]string{PausedKey: "true", PausedUntilKey: "2019-05-01T12:00:00Z"}},
+		{name: "invalid expiry", data: map[string]string{PausedKey: "true", PausedUntilKey: "tomorrow"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tc.data != nil {
+				client = fake.NewSimpleClientset(&apiv1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: PauseConfigMapName, Namespace: "kube-system"},
+					Data:       tc.data,
+				})
+			}
+			assert.Equal(t, tc.expected, isAutoscalingPaused(client, "kube-system", now))
+			assert.False(t, isAutoscalingPaused(client, "other", now))
+		})
+	}
+}
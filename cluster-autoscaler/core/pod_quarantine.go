@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kube_record "k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// podQuarantine tracks pods that keep triggering scale-ups without becoming schedulable, e.g. because
+// of contradictory affinity rules, and excludes them from scale-up once they triggered maxAttempts
+// scale-ups. Pods are forgotten as soon as they are not unschedulable anymore. A nil podQuarantine,
+// or one with non-positive maxAttempts, doesn't quarantine any pods.
+type podQuarantine struct {
+	maxAttempts int
+	attempts    map[types.UID]int
+}
+
+func newPodQuarantine(maxAttempts int) *podQuarantine {
+	return &podQuarantine{
+		maxAttempts: maxAttempts,
+		attempts:    make(map[types.UID]int),
+	}
+}
+
+func (q *podQuarantine) enabled() bool {
+	return q != nil && q.maxAttempts > 0
+}
+
+// update forgets the pods that are not among the given unschedulable pods anymore.
+func (q *podQuarantine) update(unschedulablePods []*apiv1.Pod) {
+	if !q.enabled() {
+		return
+	}
+	pending := make(map[types.UID]bool, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		pending[pod.UID] = true
+	}
+	for uid := range q.attempts {
+		if !pending[uid] {
+			delete(q.attempts, uid)
+		}
+	}
+}
+
+// filterOutQuarantined returns the given pods without the quarantined ones.
+func (q *podQuarantine) filterOutQuarantined(pods []*apiv1.Pod) []*apiv1.Pod {
+	if !q.enabled() {
+		return pods
+	}
+	result := make([]*apiv1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if q.attempts[pod.UID] >= q.maxAttempts {
+			klog.V(3).Infof("Pod %s/%s is quarantined after triggering %d scale-ups", pod.Namespace, pod.Name, q.attempts[pod.UID])
+			continue
+		}
+		result = append(result, pod)
+	}
+	return result
+}
+
+// registerScaleUp records a scale-up triggered by the given pods and emits an event on the pods
+// that are quarantined as a result.
+func (q *podQuarantine) registerScaleUp(pods []*apiv1.Pod, recorder kube_record.EventRecorder) {
+	if !q.enabled() {
+		return
+	}
+	for _, pod := range pods {
+		q.attempts[pod.UID]++
+		if q.attempts[pod.UID] == q.maxAttempts {
+			klog.Warningf("Pod %s/%s triggered %d scale-ups without becoming schedulable, excluding it from scale-up", pod.Namespace, pod.Name, q.maxAttempts)
+			recorder.Eventf(pod, apiv1.EventTypeWarning, "ScaleUpQuarantined",
+				"pod triggered %d scale-ups without becoming schedulable, it won't trigger scale-up anymore", q.maxAttempts)
+		}
+	}
+}
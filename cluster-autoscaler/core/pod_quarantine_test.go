@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPodQuarantine(t *testing.T) {
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.UID = types.UID("p1")
+	p2 := BuildTestPod("p2", 100, 0)
+	p2.UID = types.UID("p2")
+	pods := []*apiv1.Pod{p1, p2}
+	recorder := kube_record.NewFakeRecorder(10)
+
+	q := newPodQuarantine(2)
+	q.update(pods)
+	q.registerScaleUp([]*apiv1.Pod{p1}, recorder)
+	assert.Equal(t, pods, q.filterOutQuarantined(pods))
+	assert.Equal(t, 0, len(recorder.Events))
+
+	q.registerScaleUp([]*apiv1.Pod{p1, p2}, recorder)
+	assert.Equal(t, []*apiv1.Pod{p2}, q.filterOutQuarantined(pods))
+	assert.Equal(t, 1, len(recorder.Events))
+
+	// Further scale-ups don't emit more events.
+	q.registerScaleUp([]*apiv1.Pod{p1}, recorder)
+	assert.Equal(t, 1, len(recorder.Events))
+
+	// Pods that got scheduled are forgotten.
+	q.update([]*apiv1.Pod{p2})
+	assert.Equal(t, pods, q.filterOutQuarantined(pods))
+}
+
+func TestPodQuarantineDisabled(t *testing.T) {
+	p1 := BuildTestPod("p1", 100, 0)
+	pods := []*apiv1.Pod{p1}
+	recorder := kube_record.NewFakeRecorder(10)
+
+	for _, q := range []*podQuarantine{nil, newPodQuarantine(0)} {
+		q.update(pods)
+		q.registerScaleUp(pods, recorder)
+		q.registerScaleUp(pods, recorder)
+		assert.Equal(t, pods, q.filterOutQuarantined(pods))
+	}
+	assert.Equal(t, 0, len(recorder.Events))
+}
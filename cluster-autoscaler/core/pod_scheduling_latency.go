@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/klog"
+)
+
+// podSchedulingLatency measures the time pending pods take to be scheduled on nodes added by CA.
+// Nodes are considered added by CA if they were created within maxNodeProvisionTime after a
+// scale-up of their node group. For every pod seen pending and later scheduled on such a node, the
+// time from the first loop seeing the pod pending to its scheduling is recorded for the node group
+// of the node. Pods scheduled on other nodes are forgotten without being recorded. A nil
+// podSchedulingLatency records nothing.
+type podSchedulingLatency struct {
+	maxNodeProvisionTime time.Duration
+	// pendingSince holds when the pending pods were first seen pending.
+	pendingSince map[types.UID]time.Time
+	// scaleUps holds the times of the scale-ups of the last maxNodeProvisionTime, by node group id.
+	scaleUps map[string][]time.Time
+	// addedNodes holds the node group ids of the existing nodes added by CA, by node name.
+	addedNodes map[string]string
+	// observe records the latency of a pod scheduled on a node of the given node group.
+	observe func(nodeGroup string, latency time.Duration)
+}
+
+func newPodSchedulingLatency(maxNodeProvisionTime time.Duration) *podSchedulingLatency {
+	return &podSchedulingLatency{
+		maxNodeProvisionTime: maxNodeProvisionTime,
+		pendingSince:         make(map[types.UID]time.Time),
+		scaleUps:             make(map[string][]time.Time),
+		addedNodes:           make(map[string]string),
+		observe:              metrics.RegisterPodSchedulingLatency,
+	}
+}
+
+// registerScaleUp records the scale-up of the given node groups.
+func (l *podSchedulingLatency) registerScaleUp(scaleUpInfos []nodegroupset.ScaleUpInfo, now time.Time) {
+	if l == nil {
+		return
+	}
+	for _, info := range scaleUpInfos {
+		id := info.Group.Id()
+		l.scaleUps[id] = append(l.scaleUps[id], now)
+	}
+}
+
+// update starts tracking the new pending pods and records the latency of the tracked pods that
+// got scheduled on nodes added by CA.
+func (l *podSchedulingLatency) update(unschedulablePods []*apiv1.Pod, scheduledPods []*apiv1.Pod, nodes []*apiv1.Node,
+	cloudProvider cloudprovider.CloudProvider, now time.Time) {
+	if l == nil {
+		return
+	}
+	l.updateAddedNodes(nodes, cloudProvider, now)
+
+	pending := make(map[types.UID]bool, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		pending[pod.UID] = true
+		if _, found := l.pendingSince[pod.UID]; !found {
+			l.pendingSince[pod.UID] = now
+		}
+	}
+	if len(l.pendingSince) == len(pending) {
+		return
+	}
+
+	scheduled := make(map[types.UID]*apiv1.Pod, len(scheduledPods))
+	for _, pod := range scheduledPods {
+		scheduled[pod.UID] = pod
+	}
+	for uid, since := range l.pendingSince {
+		if pending[uid] {
+			continue
+		}
+		delete(l.pendingSince, uid)
+		pod, found := scheduled[uid]
+		if !found {
+			continue
+		}
+		nodeGroupId, found := l.addedNodes[pod.Spec.NodeName]
+		if !found {
+			continue
+		}
+		latency := podScheduledTime(pod, now).Sub(since)
+		if latency < 0 {
+			latency = 0
+		}
+		klog.V(4).Infof("Pod %s/%s was scheduled on node %s added by CA to node group %s after %v", pod.Namespace, pod.Name,
+			pod.Spec.NodeName, nodeGroupId, latency)
+		l.observe(nodeGroupId, latency)
+	}
+}
+
+// updateAddedNodes records the nodes created within maxNodeProvisionTime after a scale-up of their
+// node group, forgets the deleted nodes and the scale-ups new nodes can't be attributed to anymore.
+func (l *podSchedulingLatency) updateAddedNodes(nodes []*apiv1.Node, cloudProvider cloudprovider.CloudProvider, now time.Time) {
+	existing := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		existing[node.Name] = true
+	}
+	for name := range l.addedNodes {
+		if !existing[name] {
+			delete(l.addedNodes, name)
+		}
+	}
+
+	if len(l.scaleUps) > 0 {
+		for _, node := range nodes {
+			if _, found := l.addedNodes[node.Name]; found {
+				continue
+			}
+			created := node.CreationTimestamp.Time
+			if now.Sub(created) > l.maxNodeProvisionTime {
+				continue
+			}
+			nodeGroup, err := cloudProvider.NodeGroupForNode(node)
+			if err != nil {
+				klog.Warningf("Failed to get node group of node %s: %v", node.Name, err)
+				continue
+			}
+			if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+				continue
+			}
+			for _, scaleUp := range l.scaleUps[nodeGroup.Id()] {
+				if !created.Before(scaleUp) && created.Sub(scaleUp) <= l.maxNodeProvisionTime {
+					l.addedNodes[node.Name] = nodeGroup.Id()
+					break
+				}
+			}
+		}
+	}
+
+	for id, scaleUps := range l.scaleUps {
+		recent := scaleUps[:0]
+		for _, scaleUp := range scaleUps {
+			if now.Sub(scaleUp) <= l.maxNodeProvisionTime {
+				recent = append(recent, scaleUp)
+			}
+		}
+		if len(recent) == 0 {
+			delete(l.scaleUps, id)
+		} else {
+			l.scaleUps[id] = recent
+		}
+	}
+}
+
+// podScheduledTime returns when the pod was scheduled according to its PodScheduled condition, or
+// now if the pod has no such condition.
+func podScheduledTime(pod *apiv1.Pod, now time.Time) time.Time {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodScheduled && condition.Status == apiv1.ConditionTrue && !condition.LastTransitionTime.IsZero() {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return now
+}
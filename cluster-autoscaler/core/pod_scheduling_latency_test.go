@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPodSchedulingLatency(t *testing.T) {
+	now := time.Now()
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	old := BuildTestNode("old", 1000, 1000)
+	old.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	provider.AddNode("ng1", old)
+
+	p1 := BuildTestPod("p1", 500, 0)
+	p1.UID = types.UID("p1")
+	p2 := BuildTestPod("p2", 500, 0)
+	p2.UID = types.UID("p2")
+	p3 := BuildTestPod("p3", 500, 0)
+	p3.UID = types.UID("p3")
+
+	observed := make(map[string][]time.Duration)
+	l := newPodSchedulingLatency(15 * time.Minute)
+	l.observe = func(nodeGroup string, latency time.Duration) {
+		observed[nodeGroup] = append(observed[nodeGroup], latency)
+	}
+
+	l.update([]*apiv1.Pod{p1, p2, p3}, nil, []*apiv1.Node{old}, provider, now)
+	l.registerScaleUp([]nodegroupset.ScaleUpInfo{{Group: provider.GetNodeGroup("ng1")}}, now)
+
+	// The node added by the scale-up registers and p1 is scheduled on it, p2 on the old node.
+	added := BuildTestNode("added", 1000, 1000)
+	added.CreationTimestamp = metav1.NewTime(now.Add(3 * time.Minute))
+	provider.AddNode("ng1", added)
+	p1.Spec.NodeName = "added"
+	p1.Status.Conditions = []apiv1.PodCondition{{
+		Type:               apiv1.PodScheduled,
+		Status:             apiv1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(now.Add(4 * time.Minute)),
+	}}
+	p2.Spec.NodeName = "old"
+	nodes := []*apiv1.Node{old, added}
+	l.update([]*apiv1.Pod{p3}, []*apiv1.Pod{p1, p2}, nodes, provider, now.Add(5*time.Minute))
+	assert.Equal(t, map[string][]time.Duration{"ng1": {4 * time.Minute}}, observed)
+	assert.Equal(t, map[string]string{"added": "ng1"}, l.addedNodes)
+
+	// Pods without a PodScheduled condition are measured up to the loop seeing them scheduled.
+	p3.Spec.NodeName = "added"
+	l.update(nil, []*apiv1.Pod{p1, p2, p3}, nodes, provider, now.Add(6*time.Minute))
+	assert.Equal(t, map[string][]time.Duration{"ng1": {4 * time.Minute, 6 * time.Minute}}, observed)
+	assert.Empty(t, l.pendingSince)
+
+	// Scale-ups are forgotten after maxNodeProvisionTime, and deleted nodes right away.
+	l.update(nil, nil, []*apiv1.Node{old}, provider, now.Add(20*time.Minute))
+	assert.Empty(t, l.scaleUps)
+	assert.Empty(t, l.addedNodes)
+}
+
+func TestPodSchedulingLatencyDisabled(t *testing.T) {
+	var l *podSchedulingLatency
+	p1 := BuildTestPod("p1", 500, 0)
+	l.update([]*apiv1.Pod{p1}, nil, nil, testprovider.NewTestCloudProvider(nil, nil), time.Now())
+	l.registerScaleUp(nil, time.Now())
+}
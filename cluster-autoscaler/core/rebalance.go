@@ -0,0 +1,368 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	kube_client "k8s.io/client-go/kubernetes"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// RebalanceConfigMapName is the name of the ConfigMap, in the CA namespace, that requests the nodes
+	// to be spread evenly again across similar node groups.
+	RebalanceConfigMapName = "cluster-autoscaler-rebalance"
+	// RebalanceKey is the key of the rebalance ConfigMap that requests rebalancing when set to "true".
+	// It's set back to "false" once the similar node groups are balanced.
+	RebalanceKey = "rebalance"
+	// RebalanceMaxUnavailableKey is the key of the rebalance ConfigMap holding how many nodes of rebalanced
+	// node groups may be starting or being drained at the same time. Defaults to 1.
+	RebalanceMaxUnavailableKey = "maxUnavailable"
+	// RebalancedAtKey is the key of the rebalance ConfigMap set to the time, in RFC 3339 format, the
+	// similar node groups were found balanced.
+	RebalancedAtKey = "rebalancedAt"
+)
+
+// rebalanceMove is a node being moved from a node group to a similar one: the latter was scaled up to
+// targetSize, and a node of the former is drained once the latter has as many ready nodes.
+type rebalanceMove struct {
+	from       cloudprovider.NodeGroup
+	to         cloudprovider.NodeGroup
+	targetSize int
+	startTime  time.Time
+}
+
+// getRebalanceRequest returns the rebalance ConfigMap listed by the given lister and its maximum number
+// of unavailable nodes if it requests rebalancing, or nil otherwise. Rebalancing isn't requested if the
+// ConfigMap can't be read or its maximum number of unavailable nodes is invalid.
+func getRebalanceRequest(lister v1lister.ConfigMapNamespaceLister, namespace string) (*apiv1.ConfigMap, int) {
+	configMap, err := lister.Get(RebalanceConfigMapName)
+	if err != nil {
+		if !kube_errors.IsNotFound(err) {
+			klog.Warningf("Failed to get %s/%s, not rebalancing: %v", namespace, RebalanceConfigMapName, err)
+		}
+		return nil, 0
+	}
+	if configMap == nil || configMap.Data[RebalanceKey] != "true" {
+		return nil, 0
+	}
+	maxUnavailable := 1
+	if value, found := configMap.Data[RebalanceMaxUnavailableKey]; found {
+		maxUnavailable, err = strconv.Atoi(value)
+		if err != nil || maxUnavailable < 1 {
+			klog.Warningf("Invalid %s %q in %s/%s, not rebalancing", RebalanceMaxUnavailableKey, value, namespace, RebalanceConfigMapName)
+			return nil, 0
+		}
+	}
+	return configMap, maxUnavailable
+}
+
+// markRebalanced records in the rebalance ConfigMap that the similar node groups are balanced, so that
+// rebalancing isn't requested anymore.
+func markRebalanced(client kube_client.Interface, configMap *apiv1.ConfigMap, now time.Time) error {
+	updated := configMap.DeepCopy()
+	updated.Data[RebalanceKey] = "false"
+	updated.Data[RebalancedAtKey] = now.UTC().Format(time.RFC3339)
+	_, err := client.CoreV1().ConfigMaps(updated.Namespace).Update(updated)
+	return err
+}
+
+// similarNodeGroupSets splits the node groups into sets of node groups similar to each other, according
+// to the given processor. Node groups that aren't similar to any other one are left out.
+func similarNodeGroupSets(context *context.AutoscalingContext, processor nodegroupset.NodeGroupSetProcessor,
+	nodeInfosForGroups map[string]*schedulernodeinfo.NodeInfo) ([][]cloudprovider.NodeGroup, errors.AutoscalerError) {
+	var sets [][]cloudprovider.NodeGroup
+	assigned := make(map[string]bool)
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		if assigned[nodeGroup.Id()] {
+			continue
+		}
+		similar, err := processor.FindSimilarNodeGroups(context, nodeGroup, nodeInfosForGroups)
+		if err != nil {
+			return nil, err.AddPrefix("failed to find node groups similar to %s: ", nodeGroup.Id())
+		}
+		set := []cloudprovider.NodeGroup{nodeGroup}
+		assigned[nodeGroup.Id()] = true
+		for _, other := range similar {
+			if !assigned[other.Id()] {
+				set = append(set, other)
+				assigned[other.Id()] = true
+			}
+		}
+		if len(set) > 1 {
+			sets = append(sets, set)
+		}
+	}
+	return sets, nil
+}
+
+// pickRebalanceMove returns the largest node group of the set that can shrink and the smallest one that
+// can grow, if moving a node from the former to the latter makes the set more balanced. Returns nils if
+// the set is balanced as far as the sizes limits of its node groups allow.
+func pickRebalanceMove(set []cloudprovider.NodeGroup) (from, to cloudprovider.NodeGroup, err error) {
+	sizes := make(map[string]int, len(set))
+	for _, nodeGroup := range set {
+		size, err := nodeGroup.TargetSize()
+		if err != nil {
+			return nil, nil, err
+		}
+		sizes[nodeGroup.Id()] = size
+	}
+	sorted := make([]cloudprovider.NodeGroup, len(set))
+	copy(sorted, set)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sizes[sorted[i].Id()] > sizes[sorted[j].Id()]
+	})
+	for _, nodeGroup := range sorted {
+		if sizes[nodeGroup.Id()] > nodeGroup.MinSize() {
+			from = nodeGroup
+			break
+		}
+	}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if sizes[sorted[i].Id()] < sorted[i].MaxSize() {
+			to = sorted[i]
+			break
+		}
+	}
+	if from == nil || to == nil || sizes[from.Id()]-sizes[to.Id()] <= 1 {
+		return nil, nil, nil
+	}
+	return from, to, nil
+}
+
+// TryToRebalance moves at most one node from an over-represented node group of one of the sets of
+// similar node groups to an under-represented one: the latter is scaled up, and a node of the former is
+// drained and deleted once the new node is ready. No move starts while maxUnavailable nodes of the sets
+// are still starting or being deleted. Returns whether a node group is being scaled up or a node is
+// being drained, and whether all the sets are balanced.
+func (sd *ScaleDown) TryToRebalance(sets [][]cloudprovider.NodeGroup, allNodes []*apiv1.Node, pods []*apiv1.Pod,
+	pdbs []*policyv1.PodDisruptionBudget, maxUnavailable int, currentTime time.Time) (bool, bool, errors.AutoscalerError) {
+	if sd.nodeDeleteStatus.IsDeleteInProgress() {
+		return false, false, nil
+	}
+	sd.pdbCache.Update(pdbs)
+
+	nodesWithoutMaster := filterOutMasters(allNodes, pods)
+	nodesPerGroup := make(map[string][]*apiv1.Node)
+	for _, node := range nodesWithoutMaster {
+		nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			return false, false, errors.NewAutoscalerError(errors.CloudProviderError, "failed to find node group for %s: %v", node.Name, err)
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		nodesPerGroup[nodeGroup.Id()] = append(nodesPerGroup[nodeGroup.Id()], node)
+	}
+
+	unavailable := 0
+	upcoming := sd.clusterStateRegistry.GetUpcomingNodes()
+	for _, set := range sets {
+		for _, nodeGroup := range set {
+			unavailable += upcoming[nodeGroup.Id()]
+			for _, node := range nodesPerGroup[nodeGroup.Id()] {
+				if deletetaint.HasToBeDeletedTaint(node) {
+					unavailable++
+				}
+			}
+		}
+	}
+
+	nonExpendablePods := filterOutExpendablePods(pods, sd.context.ExpendablePodsPriorityCutoff)
+	moving := make(map[string]bool)
+	for i := 0; i < len(sd.rebalanceMoves); i++ {
+		move := sd.rebalanceMoves[i]
+		ready := 0
+		for _, node := range nodesPerGroup[move.to.Id()] {
+			if kube_util.IsNodeReadyAndSchedulable(node) && !deletetaint.HasToBeDeletedTaint(node) {
+				ready++
+			}
+		}
+		if ready < move.targetSize {
+			size, err := move.to.TargetSize()
+			if err != nil {
+				return false, false, errors.NewAutoscalerError(errors.CloudProviderError, "failed to get node group size: %v", err)
+			}
+			if size >= move.targetSize && move.startTime.Add(sd.context.MaxNodeProvisionTime).After(currentTime) {
+				klog.V(2).Infof("Rebalance: waiting for the new node of %s before draining a node of %s", move.to.Id(), move.from.Id())
+				moving[move.from.Id()] = true
+				moving[move.to.Id()] = true
+				continue
+			}
+			klog.Warningf("Rebalance: %s didn't get a ready node, not draining a node of %s", move.to.Id(), move.from.Id())
+			sd.rebalanceMoves = append(sd.rebalanceMoves[:i], sd.rebalanceMoves[i+1:]...)
+			i--
+			continue
+		}
+
+		sd.rebalanceMoves = append(sd.rebalanceMoves[:i], sd.rebalanceMoves[i+1:]...)
+		toRemove, typedErr := sd.findRebalanceCandidate(move.from, nodesPerGroup, nodesWithoutMaster, nonExpendablePods, currentTime)
+		if typedErr != nil {
+			return false, false, typedErr
+		}
+		if toRemove == nil {
+			klog.V(1).Infof("Rebalance: no node of %s can be drained anymore, leaving the new node of %s to scale down", move.from.Id(), move.to.Id())
+			i--
+			continue
+		}
+		klog.V(0).Infof("Rebalance: draining node %s of %s, moved to %s", toRemove.Node.Name, move.from.Id(), move.to.Id())
+		simulator.RemoveNodeFromTracker(sd.usageTracker, toRemove.Node.Name, sd.unneededNodes)
+		sd.nodeDeleteStatus.SetDeleteInProgress(true)
+		go func() {
+			var err error
+			defer func() { sd.nodeDeleteStatus.AddNodeDeleteResult(toRemove.Node.Name, err) }()
+			defer sd.nodeDeleteStatus.SetDeleteInProgress(false)
+			err = sd.deleteNode(toRemove.Node, toRemove.PodsToReschedule)
+			if err != nil {
+				klog.Errorf("Failed to delete %s while rebalancing: %v", toRemove.Node.Name, err)
+			}
+		}()
+		return true, false, nil
+	}
+
+	balanced := len(sd.rebalanceMoves) == 0
+	for _, set := range sets {
+		waiting := false
+		for _, nodeGroup := range set {
+			waiting = waiting || moving[nodeGroup.Id()]
+		}
+		if waiting {
+			continue
+		}
+		from, to, err := pickRebalanceMove(set)
+		if err != nil {
+			return false, false, errors.NewAutoscalerError(errors.CloudProviderError, "failed to get node group sizes: %v", err)
+		}
+		if from == nil {
+			continue
+		}
+		balanced = false
+		if unavailable >= maxUnavailable {
+			klog.V(2).Infof("Rebalance: waiting for %d starting or deleted nodes before moving a node from %s to %s", unavailable, from.Id(), to.Id())
+			continue
+		}
+
+		// Only scale up if a node could be drained once the new node is ready.
+		toRemove, typedErr := sd.findRebalanceCandidate(from, nodesPerGroup, nodesWithoutMaster, nonExpendablePods, currentTime)
+		if typedErr != nil {
+			return false, false, typedErr
+		}
+		if toRemove == nil {
+			klog.V(1).Infof("Rebalance: no node of %s can be drained", from.Id())
+			continue
+		}
+
+		size, err := to.TargetSize()
+		if err != nil {
+			return false, false, errors.NewAutoscalerError(errors.CloudProviderError, "failed to get node group size: %v", err)
+		}
+		klog.V(0).Infof("Rebalance: moving a node of %s to %s", from.Id(), to.Id())
+		sd.context.LogRecorder.Eventf(apiv1.EventTypeNormal, "Rebalance", "Rebalance: moving a node of %s to %s",
+			from.Id(), to.Id())
+		info := nodegroupset.ScaleUpInfo{Group: to, CurrentSize: size, NewSize: size + 1, MaxSize: to.MaxSize()}
+		if typedErr := executeScaleUp(sd.context, sd.clusterStateRegistry, info, gpu.GetGpuTypeForMetrics(gpu.GetGpuLabel(sd.context.CloudProvider), gpu.GetAvailableGpuTypes(sd.context.CloudProvider), toRemove.Node, from), currentTime); typedErr != nil {
+			return false, false, typedErr
+		}
+		sd.rebalanceMoves = append(sd.rebalanceMoves, rebalanceMove{from: from, to: to, targetSize: size + 1, startTime: currentTime})
+		return true, false, nil
+	}
+	return false, balanced, nil
+}
+
+// findRebalanceCandidate returns a node of the node group that can be drained and deleted, or nil if
+// there is none.
+func (sd *ScaleDown) findRebalanceCandidate(nodeGroup cloudprovider.NodeGroup, nodesPerGroup map[string][]*apiv1.Node,
+	nodesWithoutMaster []*apiv1.Node, pods []*apiv1.Pod, currentTime time.Time) (*simulator.NodeToBeRemoved, errors.AutoscalerError) {
+	candidates := make([]*apiv1.Node, 0, len(nodesPerGroup[nodeGroup.Id()]))
+	for _, node := range nodesPerGroup[nodeGroup.Id()] {
+		if deletetaint.HasToBeDeletedTaint(node) || hasNoScaleDownAnnotation(node) {
+			continue
+		}
+		if unremovable, reason := isUnremovableByCloudProvider(sd.context.CloudProvider, node); unremovable {
+			klog.V(4).Infof("Rebalance: skipping %s - %s", node.Name, reason)
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	nodesToRemove, _, _, typedErr := simulator.FindNodesToRemove(candidates, nodesWithoutMaster, pods, sd.context.ListerRegistry,
+		sd.context.PredicateChecker, 1, false, sd.podLocationHints, sd.usageTracker, currentTime, sd.pdbCache)
+	if typedErr != nil {
+		return nil, typedErr.AddPrefix("Find node to rebalance failed: ")
+	}
+	if len(nodesToRemove) == 0 {
+		return nil, nil
+	}
+	return &nodesToRemove[0], nil
+}
+
+// rebalance moves nodes between similar node groups while the rebalance ConfigMap requests it, and
+// records that it's done once they are balanced. Returns whether a node is being moved.
+func (a *StaticAutoscaler) rebalance(allNodes []*apiv1.Node, pods []*apiv1.Pod,
+	nodeInfosForGroups map[string]*schedulernodeinfo.NodeInfo, currentTime time.Time) (bool, errors.AutoscalerError) {
+	configMap, maxUnavailable := getRebalanceRequest(a.configMapLister, a.ConfigNamespace)
+	if configMap == nil {
+		return false, nil
+	}
+	if !a.BalanceSimilarNodeGroups {
+		klog.Warningf("Ignoring %s/%s, similar node groups can't be rebalanced without --balance-similar-node-groups", a.ConfigNamespace, RebalanceConfigMapName)
+		return false, nil
+	}
+
+	sets, typedErr := similarNodeGroupSets(a.AutoscalingContext, a.processors.NodeGroupSetProcessor, nodeInfosForGroups)
+	if typedErr != nil {
+		return false, typedErr
+	}
+	pdbs, err := a.PodDisruptionBudgetLister().List()
+	if err != nil {
+		return false, errors.ToAutoscalerError(errors.ApiCallError, err)
+	}
+
+	a.scaleDownMutex.Lock()
+	defer a.scaleDownMutex.Unlock()
+	moved, balanced, typedErr := a.scaleDown.TryToRebalance(sets, allNodes, pods, pdbs, maxUnavailable, currentTime)
+	if typedErr != nil {
+		return false, typedErr
+	}
+	if moved {
+		a.lastScaleUpTime = currentTime
+	}
+	if balanced {
+		klog.V(0).Infof("Rebalance: %d sets of similar node groups are balanced", len(sets))
+		if err := markRebalanced(a.ClientSet, configMap, currentTime); err != nil {
+			klog.Warningf("Failed to update %s/%s: %v", a.ConfigNamespace, RebalanceConfigMapName, err)
+		}
+	}
+	return moved, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	core "k8s.io/client-go/testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConfigMapLister(t *testing.T, configMaps ...*apiv1.ConfigMap) v1lister.ConfigMapNamespaceLister {
+	lister, err := kube_util.NewTestConfigMapLister(configMaps)
+	assert.NoError(t, err)
+	return lister.ConfigMaps("kube-system")
+}
+
+func TestGetRebalanceRequest(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		data                   map[string]string
+		expected               bool
+		expectedMaxUnavailable int
+	}{
+		{name: "no config map"},
+		{name: "not requested", data: map[string]string{RebalanceKey: "false"}},
+		{name: "requested", data: map[string]string{RebalanceKey: "true"}, expected: true, expectedMaxUnavailable: 1},
+		{name: "requested with budget", data: map[string]string{RebalanceKey: "true", RebalanceMaxUnavailableKey: "3"}, expected: true, expectedMaxUnavailable: 3},
+		{name: "zero budget", data: map[string]string{RebalanceKey: "true", RebalanceMaxUnavailableKey: "0"}},
+		{name: "invalid budget", data: map[string]string{RebalanceKey: "true", RebalanceMaxUnavailableKey: "some"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var configMaps []*apiv1.ConfigMap
+			if tc.data != nil {
+				configMaps = append(configMaps, &apiv1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: RebalanceConfigMapName, Namespace: "kube-system"},
+					Data:       tc.data,
+				})
+			}
+			configMap, maxUnavailable := getRebalanceRequest(newTestConfigMapLister(t, configMaps...), "kube-system")
+			assert.Equal(t, tc.expected, configMap != nil)
+			assert.Equal(t, tc.expectedMaxUnavailable, maxUnavailable)
+		})
+	}
+}
+
+func TestMarkRebalanced(t *testing.T) {
+	now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	configMap := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: RebalanceConfigMapName, Namespace: "kube-system"},
+		Data:       map[string]string{RebalanceKey: "true", RebalanceMaxUnavailableKey: "2"},
+	}
+	client := fake.NewSimpleClientset(configMap)
+
+	assert.NoError(t, markRebalanced(client, configMap, now))
+	updated, err := client.CoreV1().ConfigMaps("kube-system").Get(RebalanceConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		RebalanceKey:               "false",
+		RebalanceMaxUnavailableKey: "2",
+		RebalancedAtKey:            "2019-05-01T12:00:00Z",
+	}, updated.Data)
+	configMap, _ = getRebalanceRequest(newTestConfigMapLister(t, updated), "kube-system")
+	assert.Nil(t, configMap)
+}
+
+func TestPickRebalanceMove(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 4)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+	provider.AddNodeGroup("ng3", 0, 10, 2)
+	provider.AddNodeGroup("ng4", 5, 10, 5)
+	provider.AddNodeGroup("ng5", 0, 1, 1)
+	provider.AddNodeGroup("ng6", 0, 10, 3)
+	groups := func(ids ...string) []cloudprovider.NodeGroup {
+		var result []cloudprovider.NodeGroup
+		for _, id := range ids {
+			result = append(result, provider.GetNodeGroup(id))
+		}
+		return result
+	}
+	testCases := []struct {
+		name         string
+		set          []cloudprovider.NodeGroup
+		expectedFrom string
+		expectedTo   string
+	}{
+		{name: "unbalanced", set: groups("ng3", "ng1", "ng2"), expectedFrom: "ng1", expectedTo: "ng2"},
+		{name: "balanced", set: groups("ng3", "ng6")},
+		{name: "largest at min size", set: groups("ng4", "ng1", "ng2"), expectedFrom: "ng1", expectedTo: "ng2"},
+		{name: "smallest at max size", set: groups("ng5", "ng1", "ng3"), expectedFrom: "ng1", expectedTo: "ng3"},
+		{name: "balanced within limits", set: groups("ng4", "ng3")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, err := pickRebalanceMove(tc.set)
+			assert.NoError(t, err)
+			if tc.expectedFrom == "" {
+				assert.Nil(t, from)
+				assert.Nil(t, to)
+				return
+			}
+			assert.Equal(t, tc.expectedFrom, from.Id())
+			assert.Equal(t, tc.expectedTo, to.Id())
+		})
+	}
+}
+
+func TestTryToRebalance(t *testing.T) {
+	scaledUpGroups := make(chan string, 10)
+	deletedNodes := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Time{})
+	n4 := BuildTestNode("n4", 1000, 1000)
+	SetNodeReadyState(n4, true, time.Time{})
+	nodes := []*apiv1.Node{n1, n2, n3, n4}
+
+	fakeClient.Fake.AddReactor("list", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, &apiv1.PodList{Items: []apiv1.Pod{}}, nil
+	})
+	fakeClient.Fake.AddReactor("get", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		getAction := action.(core.GetAction)
+		for _, node := range nodes {
+			if node.Name == getAction.GetName() {
+				return true, node, nil
+			}
+		}
+		return true, nil, fmt.Errorf("wrong node: %v", getAction.GetName())
+	})
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		update := action.(core.UpdateAction)
+		return true, update.GetObject(), nil
+	})
+
+	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
+		scaledUpGroups <- nodeGroup
+		return nil
+	}, func(nodeGroup string, node string) error {
+		deletedNodes <- node
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 0, 10, 4)
+	provider.AddNodeGroup("ng2", 0, 10, 0)
+	for _, node := range nodes {
+		provider.AddNode("ng1", node)
+	}
+	sets := [][]cloudprovider.NodeGroup{{provider.GetNodeGroup("ng1"), provider.GetNodeGroup("ng2")}}
+
+	options := config.AutoscalingOptions{
+		MaxGracefulTerminationSec: 60,
+		MaxNodeProvisionTime:      15 * time.Minute,
+	}
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	assert.NoError(t, clusterStateRegistry.UpdateNodes(nodes, nil, time.Now()))
+
+	// Only the node the cloud provider doesn't report as unremovable can be moved.
+	for _, node := range []*apiv1.Node{n1, n2, n3} {
+		provider.SetNodeUnremovable(node.Name, "repairing")
+	}
+	now := time.Now()
+	moved, balanced, err := scaleDown.TryToRebalance(sets, nodes, nil, nil, 1, now)
+	assert.NoError(t, err)
+	assert.True(t, moved)
+	assert.False(t, balanced)
+	assert.Equal(t, "ng2", getStringFromChan(scaledUpGroups))
+
+	// No node is drained, nor is another move started within the budget, until the new node is ready.
+	assert.NoError(t, clusterStateRegistry.UpdateNodes(nodes, nil, now))
+	moved, balanced, err = scaleDown.TryToRebalance(sets, nodes, nil, nil, 2, now)
+	assert.NoError(t, err)
+	assert.False(t, moved)
+	assert.False(t, balanced)
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(deletedNodes))
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(scaledUpGroups))
+
+	n5 := BuildTestNode("n5", 1000, 1000)
+	SetNodeReadyState(n5, false, time.Time{})
+	provider.AddNode("ng2", n5)
+	nodes = append(nodes, n5)
+	moved, _, err = scaleDown.TryToRebalance(sets, nodes, nil, nil, 1, now)
+	assert.NoError(t, err)
+	assert.False(t, moved)
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(deletedNodes))
+
+	// The node of ng1 is drained once the new node is ready.
+	SetNodeReadyState(n5, true, time.Time{})
+	moved, balanced, err = scaleDown.TryToRebalance(sets, nodes, nil, nil, 1, now)
+	waitForDeleteToFinish(t, scaleDown)
+	assert.NoError(t, err)
+	assert.True(t, moved)
+	assert.False(t, balanced)
+	assert.Equal(t, n4.Name, getStringFromChan(deletedNodes))
+	assert.Empty(t, scaleDown.rebalanceMoves)
+}
+
+func TestTryToRebalanceWithoutReadyNode(t *testing.T) {
+	scaledUpGroups := make(chan string, 10)
+	deletedNodes := make(chan string, 10)
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	nodes := []*apiv1.Node{n1, n2}
+
+	fakeClient := &fake.Clientset{}
+	fakeClient.Fake.AddReactor("list", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, &apiv1.PodList{Items: []apiv1.Pod{}}, nil
+	})
+	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
+		scaledUpGroups <- nodeGroup
+		return nil
+	}, func(nodeGroup string, node string) error {
+		deletedNodes <- node
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 0, 10, 2)
+	provider.AddNodeGroup("ng2", 0, 10, 0)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+	sets := [][]cloudprovider.NodeGroup{{provider.GetNodeGroup("ng1"), provider.GetNodeGroup("ng2")}}
+
+	options := config.AutoscalingOptions{
+		MaxGracefulTerminationSec: 60,
+		MaxNodeProvisionTime:      15 * time.Minute,
+	}
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	assert.NoError(t, clusterStateRegistry.UpdateNodes(nodes, nil, time.Now()))
+
+	now := time.Now()
+	moved, _, err := scaleDown.TryToRebalance(sets, nodes, nil, nil, 1, now)
+	assert.NoError(t, err)
+	assert.True(t, moved)
+	assert.Equal(t, "ng2", getStringFromChan(scaledUpGroups))
+
+	// The move is given up once the new node takes longer than MaxNodeProvisionTime to be ready.
+	moved, _, err = scaleDown.TryToRebalance(sets, nodes, nil, nil, 1, now.Add(20*time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, moved)
+	assert.Empty(t, scaleDown.rebalanceMoves)
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(deletedNodes))
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,11 +29,14 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodes"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	scheduler_util "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/workpool"
 
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
@@ -50,6 +54,12 @@ import (
 const (
 	// ScaleDownDisabledKey is the name of annotation marking node as not eligible for scale down.
 	ScaleDownDisabledKey = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
+	// DrainedForRemovalAnnotationKey is the name of annotation marking a drained node of a drain-only node group
+	// as ready to be removed by an external system. Its value is the time the node was drained, in RFC 3339 format.
+	DrainedForRemovalAnnotationKey = "cluster-autoscaler.kubernetes.io/drained-for-removal"
+	// MaxGracefulTerminationSecKey is the name of annotation overriding --max-graceful-termination-sec for
+	// a pod or, when set on a namespace, for all pods in the namespace without their own annotation.
+	MaxGracefulTerminationSecKey = "cluster-autoscaler.kubernetes.io/max-graceful-termination-sec"
 )
 
 const (
@@ -181,6 +191,7 @@ func calculateScaleDownGpusTotal(nodes []*apiv1.Node, cp cloudprovider.CloudProv
 
 	result := make(map[string]int64)
 	ngCache := make(map[string]gpuInfo)
+	gpuLabel := gpu.GetGpuLabel(cp)
 	for _, node := range nodes {
 		if isNodeBeingDeleted(node, timestamp) {
 			// Nodes being deleted do not count towards total cluster resources
@@ -210,7 +221,7 @@ func calculateScaleDownGpusTotal(nodes []*apiv1.Node, cp cloudprovider.CloudProv
 			}
 		}
 		if !cacheHit {
-			gpuType, gpuCount, err = gpu.GetNodeTargetGpus(node, nodeGroup)
+			gpuType, gpuCount, err = gpu.GetNodeTargetGpus(gpuLabel, node, nodeGroup)
 			if err != nil {
 				return nil, errors.ToAutoscalerError(errors.CloudProviderError, err).AddPrefix("can not get gpu count for node %v when calculating cluster gpu usage")
 			}
@@ -244,7 +255,7 @@ func copyScaleDownResourcesLimits(source scaleDownResourcesLimits) scaleDownReso
 	return copy
 }
 
-func computeScaleDownResourcesDelta(node *apiv1.Node, nodeGroup cloudprovider.NodeGroup, resourcesWithLimits []string) (scaleDownResourcesDelta, errors.AutoscalerError) {
+func computeScaleDownResourcesDelta(gpuLabel string, node *apiv1.Node, nodeGroup cloudprovider.NodeGroup, resourcesWithLimits []string) (scaleDownResourcesDelta, errors.AutoscalerError) {
 	resultScaleDownDelta := make(scaleDownResourcesDelta)
 
 	nodeCPU, nodeMemory := getNodeCoresAndMemory(node)
@@ -252,7 +263,7 @@ func computeScaleDownResourcesDelta(node *apiv1.Node, nodeGroup cloudprovider.No
 	resultScaleDownDelta[cloudprovider.ResourceNameMemory] = nodeMemory
 
 	if cloudprovider.ContainsGpuResources(resourcesWithLimits) {
-		gpuType, gpuCount, err := gpu.GetNodeTargetGpus(node, nodeGroup)
+		gpuType, gpuCount, err := gpu.GetNodeTargetGpus(gpuLabel, node, nodeGroup)
 		if err != nil {
 			return scaleDownResourcesDelta{}, errors.ToAutoscalerError(errors.CloudProviderError, err).AddPrefix("Failed to get node %v gpu: %v", node.Name)
 		}
@@ -312,6 +323,19 @@ type ScaleDown struct {
 	nodeUtilizationMap   map[string]simulator.UtilizationInfo
 	usageTracker         *simulator.UsageTracker
 	nodeDeleteStatus     *NodeDeleteStatus
+	// pdbCache keeps the PDBs matching pods between the scale down simulations.
+	pdbCache *pdb.Cache
+	// nodeBusynessProvider, if set, complements the request-based utilization of nodes.
+	nodeBusynessProvider nodes.NodeBusynessProvider
+	// compactedNodes holds the nodes whose pods were evicted by compaction, with the time of the eviction.
+	// They are considered for scale down regardless of their utilization while they are unneeded.
+	compactedNodes     map[string]time.Time
+	lastCompactionTime time.Time
+	// importedUnneededNodes holds since when nodes were unneeded according to the state imported from
+	// another instance, until the next scale down simulation keeps them unneeded or forgets them.
+	importedUnneededNodes map[string]time.Time
+	// rebalanceMoves holds the nodes being moved between similar node groups, see TryToRebalance.
+	rebalanceMoves []rebalanceMove
 }
 
 // NewScaleDown builds new ScaleDown object.
@@ -326,6 +350,8 @@ func NewScaleDown(context *context.AutoscalingContext, clusterStateRegistry *clu
 		usageTracker:         simulator.NewUsageTracker(),
 		unneededNodesList:    make([]*apiv1.Node, 0),
 		nodeDeleteStatus:     &NodeDeleteStatus{nodeDeleteResults: make(map[string]error)},
+		pdbCache:             pdb.NewCache(nil),
+		compactedNodes:       make(map[string]time.Time),
 	}
 }
 
@@ -356,6 +382,7 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 	timestamp time.Time,
 	pdbs []*policyv1.PodDisruptionBudget) errors.AutoscalerError {
 
+	sd.pdbCache.Update(pdbs)
 	currentlyUnneededNodes := make([]*apiv1.Node, 0)
 	// Only scheduled non expendable pods and pods waiting for lower priority pods preemption can prevent node delete.
 	nonExpendablePods := filterOutExpendablePods(pods, sd.context.ExpendablePodsPriorityCutoff)
@@ -379,6 +406,8 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		klog.V(1).Infof("Scale-down calculation: ignoring %v nodes unremovable in the last %v", skipped, sd.context.AutoscalingOptions.UnremovableNodeRecheckTimeout)
 	}
 
+	busynessMap := sd.getNodesBusyness(filteredNodesToCheck)
+
 	// Phase1 - look at the nodes utilization. Calculate the utilization
 	// only for the managed nodes.
 	for _, node := range filteredNodesToCheck {
@@ -397,20 +426,36 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 			continue
 		}
 
+		// Skip drained nodes waiting for an external system to remove them
+		if isDrainedForRemoval(node) {
+			klog.V(1).Infof("Skipping %s from delete consideration - the node is drained and waiting for removal", node.Name)
+			continue
+		}
+
+		// Skip nodes the cloud provider is repairing or replacing
+		if unremovable, reason := isUnremovableByCloudProvider(sd.context.CloudProvider, node); unremovable {
+			klog.V(1).Infof("Skipping %s from delete consideration - %s", node.Name, reason)
+			continue
+		}
+
 		nodeInfo, found := nodeNameToNodeInfo[node.Name]
 		if !found {
 			klog.Errorf("Node info for %s not found", node.Name)
 			continue
 		}
-		utilInfo, err := simulator.CalculateUtilization(node, nodeInfo, sd.context.IgnoreDaemonSetsUtilization, sd.context.IgnoreMirrorPodsUtilization)
+		utilInfo, err := simulator.CalculateUtilizationWithReservedDaemonSets(node, nodeInfo, sd.reservedDaemonSetFraction(node), sd.context.IgnoreMirrorPodsUtilization)
 
 		if err != nil {
 			klog.Warningf("Failed to calculate utilization for %s: %v", node.Name, err)
 		}
 		klog.V(4).Infof("Node %s - utilization %f", node.Name, utilInfo.Utilization)
+		if busyness, found := busynessMap[node.Name]; found && busyness > utilInfo.Utilization {
+			klog.V(4).Infof("Node %s - busyness %f exceeds utilization", node.Name, busyness)
+			utilInfo.Utilization = busyness
+		}
 		utilizationMap[node.Name] = utilInfo
 
-		if utilInfo.Utilization >= sd.context.ScaleDownUtilizationThreshold {
+		if _, compacted := sd.compactedNodes[node.Name]; !compacted && utilInfo.Utilization >= sd.context.ScaleDownUtilizationThreshold {
 			klog.V(4).Infof("Node %s is not suitable for removal - utilization too big (%f)", node.Name, utilInfo.Utilization)
 			continue
 		}
@@ -437,7 +482,7 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 	// Look for nodes to remove in the current candidates
 	nodesToRemove, unremovable, newHints, simulatorErr := simulator.FindNodesToRemove(
 		currentCandidates, nodes, nonExpendablePods, nil, sd.context.PredicateChecker,
-		len(currentCandidates), true, sd.podLocationHints, sd.usageTracker, timestamp, pdbs)
+		len(currentCandidates), true, sd.podLocationHints, sd.usageTracker, timestamp, sd.pdbCache)
 	if simulatorErr != nil {
 		return sd.markSimulationError(simulatorErr, timestamp)
 	}
@@ -460,7 +505,7 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		additionalNodesToRemove, additionalUnremovable, additionalNewHints, simulatorErr :=
 			simulator.FindNodesToRemove(currentNonCandidates[:additionalCandidatesPoolSize], nodes, nonExpendablePods, nil,
 				sd.context.PredicateChecker, additionalCandidatesCount, true,
-				sd.podLocationHints, sd.usageTracker, timestamp, pdbs)
+				sd.podLocationHints, sd.usageTracker, timestamp, sd.pdbCache)
 		if simulatorErr != nil {
 			return sd.markSimulationError(simulatorErr, timestamp)
 		}
@@ -480,12 +525,15 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 	for _, node := range nodesToRemove {
 		name := node.Node.Name
 		unneededNodesList = append(unneededNodesList, node.Node)
-		if val, found := sd.unneededNodes[name]; !found {
-			result[name] = timestamp
-		} else {
+		if val, found := sd.unneededNodes[name]; found {
+			result[name] = val
+		} else if val, found := sd.importedUnneededNodes[name]; found {
 			result[name] = val
+		} else {
+			result[name] = timestamp
 		}
 	}
+	sd.importedUnneededNodes = nil
 
 	// Add nodes to unremovable map
 	if len(unremovable) > 0 {
@@ -496,6 +544,13 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		klog.V(1).Infof("%v nodes found to be unremovable in simulation, will re-check them at %v", len(unremovable), unremovableTimeout)
 	}
 
+	// Compacted nodes are only considered regardless of their utilization while they stay unneeded.
+	for name := range sd.compactedNodes {
+		if _, found := result[name]; !found {
+			delete(sd.compactedNodes, name)
+		}
+	}
+
 	// Update state and metrics
 	sd.unneededNodesList = unneededNodesList
 	sd.unneededNodes = result
@@ -619,22 +674,18 @@ func (sd *ScaleDown) SoftTaintUnneededNodes(allNodes []*apiv1.Node) (errors []er
 	return
 }
 
-// TryToScaleDown tries to scale down the cluster. It returns a result inside a ScaleDownStatus indicating if any node was
-// removed and error if such occurred.
-func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, pdbs []*policyv1.PodDisruptionBudget, currentTime time.Time) (*status.ScaleDownStatus, errors.AutoscalerError) {
-	scaleDownStatus := &status.ScaleDownStatus{NodeDeleteResults: sd.nodeDeleteStatus.DrainNodeDeleteResults()}
-	nodeDeletionDuration := time.Duration(0)
-	findNodesToRemoveDuration := time.Duration(0)
-	defer updateScaleDownMetrics(time.Now(), &findNodesToRemoveDuration, &nodeDeletionDuration)
-	nodesWithoutMaster := filterOutMasters(allNodes, pods)
+// getScaleDownCandidates returns the nodes that have been unneeded for long enough and can be removed
+// without violating node group size and cluster resource limits, together with their readiness, node
+// groups and the resource limits left for scale-down.
+func (sd *ScaleDown) getScaleDownCandidates(nodesWithoutMaster []*apiv1.Node, currentTime time.Time) ([]*apiv1.Node,
+	map[string]bool, map[string]cloudprovider.NodeGroup, scaleDownResourcesLimits, errors.AutoscalerError) {
 	candidates := make([]*apiv1.Node, 0)
 	readinessMap := make(map[string]bool)
 	candidateNodeGroups := make(map[string]cloudprovider.NodeGroup)
 
 	resourceLimiter, errCP := sd.context.CloudProvider.GetResourceLimiter()
 	if errCP != nil {
-		scaleDownStatus.Result = status.ScaleDownError
-		return scaleDownStatus, errors.ToAutoscalerError(errors.CloudProviderError, errCP)
+		return nil, nil, nil, nil, errors.ToAutoscalerError(errors.CloudProviderError, errCP)
 	}
 
 	scaleDownResourcesLeft := computeScaleDownResourcesLeftLimits(nodesWithoutMaster, resourceLimiter, sd.context.CloudProvider, currentTime)
@@ -652,6 +703,12 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 				continue
 			}
 
+			// The cloud provider may have started repairing the node since it became unneeded.
+			if unremovable, reason := isUnremovableByCloudProvider(sd.context.CloudProvider, node); unremovable {
+				klog.V(4).Infof("Skipping %s - %s", node.Name, reason)
+				continue
+			}
+
 			ready, _, _ := kube_util.GetReadinessState(node)
 			readinessMap[node.Name] = ready
 
@@ -675,6 +732,14 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 				continue
 			}
 
+			// A node that becomes unneeded right after it was added usually means the load is oscillating
+			// (e.g. HPA scaling the workload back and forth), keep it around for a while.
+			if minLifetime := sd.minNodeLifetime(nodeGroup); node.CreationTimestamp.Add(minLifetime).After(currentTime) {
+				klog.V(2).Infof("Skipping %s - node was created %s ago, less than min node lifetime %s of %s",
+					node.Name, currentTime.Sub(node.CreationTimestamp.Time).String(), minLifetime.String(), nodeGroup.Id())
+				continue
+			}
+
 			size, found := nodeGroupSize[nodeGroup.Id()]
 			if !found {
 				klog.Errorf("Error while checking node group size %s: group size not found in cache", nodeGroup.Id())
@@ -686,7 +751,7 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 				continue
 			}
 
-			scaleDownResourcesDelta, err := computeScaleDownResourcesDelta(node, nodeGroup, resourcesWithLimits)
+			scaleDownResourcesDelta, err := computeScaleDownResourcesDelta(gpu.GetGpuLabel(sd.context.CloudProvider), node, nodeGroup, resourcesWithLimits)
 			if err != nil {
 				klog.Errorf("Error getting node resources: %v", err)
 				continue
@@ -702,6 +767,114 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 			candidateNodeGroups[node.Name] = nodeGroup
 		}
 	}
+	return candidates, readinessMap, candidateNodeGroups, scaleDownResourcesLeft, nil
+}
+
+// minNodeLifetime returns how long nodes of the given node group have to exist before they can be scaled down.
+func (sd *ScaleDown) minNodeLifetime(nodeGroup cloudprovider.NodeGroup) time.Duration {
+	if lifetime, found := sd.context.NodeGroupMinNodeLifetimes[nodeGroup.Id()]; found {
+		return lifetime
+	}
+	return sd.context.ScaleDownMinNodeLifetime
+}
+
+// getNodesBusyness returns the busyness reported for the given nodes by the node busyness provider.
+// Failures are logged and the nodes are then judged by their request-based utilization only.
+func (sd *ScaleDown) getNodesBusyness(nodes []*apiv1.Node) map[string]float64 {
+	if sd.nodeBusynessProvider == nil {
+		return map[string]float64{}
+	}
+	busyness, err := sd.nodeBusynessProvider.GetNodesBusyness(nodes)
+	if err != nil {
+		klog.Warningf("Failed to get node busyness: %v", err)
+		return map[string]float64{}
+	}
+	return busyness
+}
+
+// reservedDaemonSetFraction returns the fraction of DaemonSet pod requests that isn't counted in the utilization
+// of the given node.
+func (sd *ScaleDown) reservedDaemonSetFraction(node *apiv1.Node) float64 {
+	if len(sd.context.NodeGroupReservedDaemonSetFractions) > 0 {
+		nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
+		if err == nil && nodeGroup != nil && !reflect.ValueOf(nodeGroup).IsNil() {
+			if fraction, found := sd.context.NodeGroupReservedDaemonSetFractions[nodeGroup.Id()]; found {
+				return fraction
+			}
+		}
+	}
+	if sd.context.IgnoreDaemonSetsUtilization {
+		return 1
+	}
+	return sd.context.ReservedDaemonSetFraction
+}
+
+// TryToScaleDownEmpty tries to remove empty nodes that have been unneeded for long enough. Unlike TryToScaleDown
+// it doesn't run any drain simulation, so it is cheap enough to run more often than the main loop. Nodes that
+// became empty since the last UpdateUnneededNodes call are marked as unneeded.
+func (sd *ScaleDown) TryToScaleDownEmpty(allNodes []*apiv1.Node, pods []*apiv1.Pod, currentTime time.Time) (*status.ScaleDownStatus, errors.AutoscalerError) {
+	scaleDownStatus := &status.ScaleDownStatus{}
+	nodesWithoutMaster := filterOutMasters(allNodes, pods)
+	sd.markEmptyNodesUnneeded(getPotentiallyUnneededNodes(sd.context, nodesWithoutMaster), pods, currentTime)
+
+	candidates, readinessMap, candidateNodeGroups, scaleDownResourcesLeft, typedErr := sd.getScaleDownCandidates(nodesWithoutMaster, currentTime)
+	if typedErr != nil {
+		scaleDownStatus.Result = status.ScaleDownError
+		return scaleDownStatus, typedErr
+	}
+
+	emptyNodes := getEmptyNodes(candidates, pods, sd.context.MaxEmptyBulkDelete, scaleDownResourcesLeft, sd.context.CloudProvider)
+	if len(emptyNodes) == 0 {
+		scaleDownStatus.Result = status.ScaleDownNoNodeDeleted
+		return scaleDownStatus, nil
+	}
+
+	confirmation := make(chan errors.AutoscalerError, len(emptyNodes))
+	sd.scheduleDeleteEmptyNodes(emptyNodes, sd.context.ClientSet, sd.context.Recorder, readinessMap, candidateNodeGroups, confirmation)
+	if err := sd.waitForEmptyNodesDeleted(emptyNodes, confirmation); err != nil {
+		scaleDownStatus.Result = status.ScaleDownError
+		return scaleDownStatus, err.AddPrefix("failed to delete at least one empty node: ")
+	}
+	scaleDownStatus.ScaledDownNodes = sd.mapNodesToStatusScaleDownNodes(emptyNodes, candidateNodeGroups, make(map[string][]*apiv1.Pod))
+	scaleDownStatus.Result = status.ScaleDownNodeDeleted
+	return scaleDownStatus, nil
+}
+
+// markEmptyNodesUnneeded starts the unneeded timer for empty nodes that are not tracked as unneeded yet.
+func (sd *ScaleDown) markEmptyNodesUnneeded(nodes []*apiv1.Node, pods []*apiv1.Pod, timestamp time.Time) {
+	toCheck := make([]*apiv1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if _, found := sd.unneededNodes[node.Name]; found {
+			continue
+		}
+		if isNodeBeingDeleted(node, timestamp) || hasNoScaleDownAnnotation(node) || isDrainedForRemoval(node) {
+			continue
+		}
+		if unremovable, _ := isUnremovableByCloudProvider(sd.context.CloudProvider, node); unremovable {
+			continue
+		}
+		toCheck = append(toCheck, node)
+	}
+	for _, node := range simulator.FindEmptyNodesToRemove(toCheck, pods) {
+		klog.V(2).Infof("Node %s became empty, marking it as unneeded", node.Name)
+		sd.unneededNodes[node.Name] = timestamp
+	}
+}
+
+// TryToScaleDown tries to scale down the cluster. It returns a result inside a ScaleDownStatus indicating if any node was
+// removed and error if such occurred.
+func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, pdbs []*policyv1.PodDisruptionBudget, currentTime time.Time) (*status.ScaleDownStatus, errors.AutoscalerError) {
+	scaleDownStatus := &status.ScaleDownStatus{NodeDeleteResults: sd.nodeDeleteStatus.DrainNodeDeleteResults()}
+	sd.pdbCache.Update(pdbs)
+	nodeDeletionDuration := time.Duration(0)
+	findNodesToRemoveDuration := time.Duration(0)
+	defer updateScaleDownMetrics(time.Now(), &findNodesToRemoveDuration, &nodeDeletionDuration)
+	nodesWithoutMaster := filterOutMasters(allNodes, pods)
+	candidates, readinessMap, candidateNodeGroups, scaleDownResourcesLeft, typedErr := sd.getScaleDownCandidates(nodesWithoutMaster, currentTime)
+	if typedErr != nil {
+		scaleDownStatus.Result = status.ScaleDownError
+		return scaleDownStatus, typedErr
+	}
 	if len(candidates) == 0 {
 		klog.V(1).Infof("No candidates for scale down")
 		scaleDownStatus.Result = status.ScaleDownNoUnneeded
@@ -730,10 +903,16 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 	findNodesToRemoveStart := time.Now()
 	// Only scheduled non expendable pods are taken into account and have to be moved.
 	nonExpendablePods := filterOutExpendablePods(pods, sd.context.ExpendablePodsPriorityCutoff)
+	if sd.context.ScaleDownPreserveZoneSpread {
+		candidates = sortCandidatesByZoneSpread(candidates, nodesWithoutMaster, nonExpendablePods)
+	}
+	if sd.context.ScaleDownStatefulSetOrdering {
+		candidates = orderCandidatesByStatefulSetOrdinals(candidates, nonExpendablePods, sd.context.ListerRegistry.StatefulSetLister())
+	}
 	// We look for only 1 node so new hints may be incomplete.
 	nodesToRemove, _, _, err := simulator.FindNodesToRemove(candidates, nodesWithoutMaster, nonExpendablePods, sd.context.ListerRegistry,
 		sd.context.PredicateChecker, 1, false,
-		sd.podLocationHints, sd.usageTracker, time.Now(), pdbs)
+		sd.podLocationHints, sd.usageTracker, time.Now(), sd.pdbCache)
 	findNodesToRemoveDuration = time.Now().Sub(findNodesToRemoveStart)
 
 	if err != nil {
@@ -776,9 +955,9 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 		}
 		nodeGroup := candidateNodeGroups[toRemove.Node.Name]
 		if readinessMap[toRemove.Node.Name] {
-			metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(toRemove.Node, nodeGroup), metrics.Underutilized)
+			metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(gpu.GetGpuLabel(sd.context.CloudProvider), gpu.GetAvailableGpuTypes(sd.context.CloudProvider), toRemove.Node, nodeGroup), metrics.Underutilized)
 		} else {
-			metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(toRemove.Node, nodeGroup), metrics.Unready)
+			metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(gpu.GetGpuLabel(sd.context.CloudProvider), gpu.GetAvailableGpuTypes(sd.context.CloudProvider), toRemove.Node, nodeGroup), metrics.Unready)
 		}
 	}()
 
@@ -838,7 +1017,7 @@ func getEmptyNodes(candidates []*apiv1.Node, pods []*apiv1.Pod, maxEmptyBulkDele
 			availabilityMap[nodeGroup.Id()] = available
 		}
 		if available > 0 {
-			resourcesDelta, err := computeScaleDownResourcesDelta(node, nodeGroup, resourcesNames)
+			resourcesDelta, err := computeScaleDownResourcesDelta(gpu.GetGpuLabel(cloudProvider), node, nodeGroup, resourcesNames)
 			if err != nil {
 				klog.Errorf("Error: %v", err)
 				continue
@@ -885,14 +1064,13 @@ func (sd *ScaleDown) scheduleDeleteEmptyNodes(emptyNodes []*apiv1.Node, client k
 				}
 			}()
 
-			deleteErr = deleteNodeFromCloudProvider(nodeToDelete, sd.context.CloudProvider,
-				sd.context.Recorder, sd.clusterStateRegistry)
+			deleteErr = sd.removeNode(nodeToDelete)
 			if deleteErr == nil {
 				nodeGroup := candidateNodeGroups[nodeToDelete.Name]
 				if readinessMap[nodeToDelete.Name] {
-					metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(nodeToDelete, nodeGroup), metrics.Empty)
+					metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(gpu.GetGpuLabel(sd.context.CloudProvider), gpu.GetAvailableGpuTypes(sd.context.CloudProvider), nodeToDelete, nodeGroup), metrics.Empty)
 				} else {
-					metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(nodeToDelete, nodeGroup), metrics.Unready)
+					metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(gpu.GetGpuLabel(sd.context.CloudProvider), gpu.GetAvailableGpuTypes(sd.context.CloudProvider), nodeToDelete, nodeGroup), metrics.Unready)
 				}
 			}
 			confirmation <- deleteErr
@@ -953,7 +1131,7 @@ func (sd *ScaleDown) deleteNode(node *apiv1.Node, pods []*apiv1.Pod) errors.Auto
 	drainSuccessful = true
 
 	// attempt delete from cloud provider
-	err := deleteNodeFromCloudProvider(node, sd.context.CloudProvider, sd.context.Recorder, sd.clusterStateRegistry)
+	err := sd.removeNode(node)
 	if err != nil {
 		return err
 	}
@@ -1005,10 +1183,16 @@ func drainNode(node *apiv1.Node, pods []*apiv1.Pod, client kube_client.Interface
 	toEvict := len(pods)
 	retryUntil := time.Now().Add(maxPodEvictionTime)
 	confirmations := make(chan error, toEvict)
+	namespaces := getPodNamespaces(pods, client)
+	maxWaitSec := maxGracefulTerminationSec
 	for _, pod := range pods {
-		go func(podToEvict *apiv1.Pod) {
-			confirmations <- evictPod(podToEvict, client, recorder, maxGracefulTerminationSec, retryUntil, waitBetweenRetries)
-		}(pod)
+		podMaxGracefulTerminationSec := getMaxGracefulTerminationSec(pod, namespaces[pod.Namespace], maxGracefulTerminationSec)
+		if podMaxGracefulTerminationSec > maxWaitSec {
+			maxWaitSec = podMaxGracefulTerminationSec
+		}
+		go func(podToEvict *apiv1.Pod, podMaxGracefulTerminationSec int) {
+			confirmations <- evictPod(podToEvict, client, recorder, podMaxGracefulTerminationSec, retryUntil, waitBetweenRetries)
+		}(pod, podMaxGracefulTerminationSec)
 	}
 
 	evictionErrs := make([]error, 0)
@@ -1031,9 +1215,9 @@ func drainNode(node *apiv1.Node, pods []*apiv1.Pod, client kube_client.Interface
 			errors.ApiCallError, "Failed to drain node %s/%s, due to following errors: %v", node.Namespace, node.Name, evictionErrs)
 	}
 
-	// Evictions created successfully, wait maxGracefulTerminationSec + PodEvictionHeadroom to see if pods really disappeared.
+	// Evictions created successfully, wait the longest graceful termination allowance + PodEvictionHeadroom to see if pods really disappeared.
 	allGone := true
-	for start := time.Now(); time.Now().Sub(start) < time.Duration(maxGracefulTerminationSec)*time.Second+PodEvictionHeadroom; time.Sleep(5 * time.Second) {
+	for start := time.Now(); time.Now().Sub(start) < time.Duration(maxWaitSec)*time.Second+PodEvictionHeadroom; time.Sleep(5 * time.Second) {
 		allGone = true
 		for _, pod := range pods {
 			podreturned, err := client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
@@ -1058,9 +1242,54 @@ func drainNode(node *apiv1.Node, pods []*apiv1.Pod, client kube_client.Interface
 		errors.TransientError, "Failed to drain node %s/%s: pods remaining after timeout", node.Namespace, node.Name)
 }
 
+// getPodNamespaces returns the namespaces of the given pods, keyed by name. Namespaces that can't be
+// fetched are left out, so their pods get the default graceful termination allowance.
+func getPodNamespaces(pods []*apiv1.Pod, client kube_client.Interface) map[string]*apiv1.Namespace {
+	namespaces := make(map[string]*apiv1.Namespace)
+	for _, pod := range pods {
+		if _, found := namespaces[pod.Namespace]; found {
+			continue
+		}
+		namespace, err := client.CoreV1().Namespaces().Get(pod.Namespace, metav1.GetOptions{})
+		if err != nil {
+			klog.Warningf("Failed to get namespace %s: %v", pod.Namespace, err)
+			namespace = nil
+		}
+		namespaces[pod.Namespace] = namespace
+	}
+	return namespaces
+}
+
+// getMaxGracefulTerminationSec returns the graceful termination allowance of the pod: the value of its
+// MaxGracefulTerminationSecKey annotation, else the one of its namespace, else the given default.
+func getMaxGracefulTerminationSec(pod *apiv1.Pod, namespace *apiv1.Namespace, defaultSec int) int {
+	if sec, found := parseMaxGracefulTerminationSec(pod.Annotations, "pod "+pod.Namespace+"/"+pod.Name); found {
+		return sec
+	}
+	if namespace != nil {
+		if sec, found := parseMaxGracefulTerminationSec(namespace.Annotations, "namespace "+namespace.Name); found {
+			return sec
+		}
+	}
+	return defaultSec
+}
+
+func parseMaxGracefulTerminationSec(annotations map[string]string, owner string) (int, bool) {
+	value, found := annotations[MaxGracefulTerminationSecKey]
+	if !found {
+		return 0, false
+	}
+	sec, err := strconv.Atoi(value)
+	if err != nil || sec < 0 {
+		klog.Warningf("Ignoring invalid %s annotation %q of %s", MaxGracefulTerminationSecKey, value, owner)
+		return 0, false
+	}
+	return sec, true
+}
+
 // Removes the given node from cloud provider. No extra pre-deletion actions are executed on
 // the Kubernetes side.
-func deleteNodeFromCloudProvider(node *apiv1.Node, cloudProvider cloudprovider.CloudProvider,
+func deleteNodeFromCloudProvider(node *apiv1.Node, cloudProvider cloudprovider.CloudProvider, operations *workpool.Pool,
 	recorder kube_record.EventRecorder, registry *clusterstate.ClusterStateRegistry) errors.AutoscalerError {
 	nodeGroup, err := cloudProvider.NodeGroupForNode(node)
 	if err != nil {
@@ -1070,7 +1299,7 @@ func deleteNodeFromCloudProvider(node *apiv1.Node, cloudProvider cloudprovider.C
 	if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
 		return errors.NewAutoscalerError(errors.InternalError, "picked node that doesn't belong to a node group: %s", node.Name)
 	}
-	if err = nodeGroup.DeleteNodes([]*apiv1.Node{node}); err != nil {
+	if err = operations.Do(nodeGroup.Id(), func() error { return nodeGroup.DeleteNodes([]*apiv1.Node{node}) }); err != nil {
 		return errors.NewAutoscalerError(errors.CloudProviderError, "failed to delete %s: %v", node.Name, err)
 	}
 	recorder.Eventf(node, apiv1.EventTypeNormal, "ScaleDown", "node removed by cluster autoscaler")
@@ -1083,10 +1312,83 @@ func deleteNodeFromCloudProvider(node *apiv1.Node, cloudProvider cloudprovider.C
 	return nil
 }
 
+// removeNode removes a drained node from the cloud provider or, if its node group is drain-only, annotates
+// it as drained for removal and leaves deleting the instance to an external system.
+func (sd *ScaleDown) removeNode(node *apiv1.Node) errors.AutoscalerError {
+	nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
+	if err == nil && nodeGroup != nil && !reflect.ValueOf(nodeGroup).IsNil() && sd.isDrainOnly(nodeGroup.Id()) {
+		return markDrainedForRemoval(node, sd.context.ClientSet, sd.context.Recorder, time.Now())
+	}
+	return deleteNodeFromCloudProvider(node, sd.context.CloudProvider, sd.context.NodeGroupOperations, sd.context.Recorder, sd.clusterStateRegistry)
+}
+
+// isDrainOnly returns true if the instances of the given node group are removed by an external system.
+func (sd *ScaleDown) isDrainOnly(nodeGroupId string) bool {
+	for _, id := range sd.context.DrainOnlyNodeGroups {
+		if id == nodeGroupId {
+			return true
+		}
+	}
+	return false
+}
+
+// Annotates the given drained node as ready to be removed by an external system. The node keeps
+// its ToBeDeleted taint so that no pods are scheduled on it until it's gone.
+func markDrainedForRemoval(node *apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder,
+	now time.Time) errors.AutoscalerError {
+	freshNode, err := client.CoreV1().Nodes().Get(node.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.NewAutoscalerError(errors.ApiCallError, "failed to get %s: %v", node.Name, err)
+	}
+	updatedNode := freshNode.DeepCopy()
+	if updatedNode.Annotations == nil {
+		updatedNode.Annotations = make(map[string]string)
+	}
+	updatedNode.Annotations[DrainedForRemovalAnnotationKey] = now.Format(time.RFC3339)
+	if _, err := client.CoreV1().Nodes().Update(updatedNode); err != nil {
+		return errors.NewAutoscalerError(errors.ApiCallError, "failed to annotate %s as drained for removal: %v", node.Name, err)
+	}
+	recorder.Eventf(node, apiv1.EventTypeNormal, "ScaleDown", "node drained by cluster autoscaler, waiting for removal")
+	return nil
+}
+
+func isDrainedForRemoval(node *apiv1.Node) bool {
+	_, found := node.Annotations[DrainedForRemovalAnnotationKey]
+	return found
+}
+
+func filterOutDrainedForRemoval(nodes []*apiv1.Node) []*apiv1.Node {
+	result := make([]*apiv1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !isDrainedForRemoval(node) {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
 func hasNoScaleDownAnnotation(node *apiv1.Node) bool {
 	return node.Annotations[ScaleDownDisabledKey] == "true"
 }
 
+// isUnremovableByCloudProvider returns true, with the reason, if the cloud provider reports
+// the node as unremovable. Nodes are removable if the cloud provider can't tell.
+func isUnremovableByCloudProvider(cloudProvider cloudprovider.CloudProvider, node *apiv1.Node) (bool, string) {
+	provider, ok := cloudProvider.(cloudprovider.UnremovableNodeCloudProvider)
+	if !ok {
+		return false, ""
+	}
+	unremovable, reason, err := provider.IsNodeUnremovable(node)
+	if err == cloudprovider.ErrNotImplemented {
+		return false, ""
+	}
+	if err != nil {
+		klog.Warningf("Failed to check if node %s is unremovable: %v", node.Name, err)
+		return false, ""
+	}
+	return unremovable, reason
+}
+
 const (
 	apiServerLabelKey   = "component"
 	apiServerLabelValue = "kube-apiserver"
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// zoneCounts is the number of replicas of a controller in every zone of the cluster.
+type zoneCounts map[string]int
+
+// skew returns the difference between the zones with the most and the fewest replicas.
+func (counts zoneCounts) skew() int {
+	first := true
+	min, max := 0, 0
+	for _, count := range counts {
+		if first || count < min {
+			min = count
+		}
+		if first || count > max {
+			max = count
+		}
+		first = false
+	}
+	return max - min
+}
+
+// sortCandidatesByZoneSpread orders scale-down candidates so that the nodes whose removal degrades
+// the zone spread of the remaining replicas of Deployments and StatefulSets the least come first.
+// The degradation of a node is the sum of the increases in zone skew of the controllers with pods
+// on it if these pods were gone from its zone. The order of nodes with the same degradation is kept.
+func sortCandidatesByZoneSpread(candidates []*apiv1.Node, allNodes []*apiv1.Node, pods []*apiv1.Pod) []*apiv1.Node {
+	nodeZones := make(map[string]string, len(allNodes))
+	zones := make(map[string]bool)
+	for _, node := range allNodes {
+		if zone, found := node.Labels[apiv1.LabelZoneFailureDomain]; found {
+			nodeZones[node.Name] = zone
+			zones[zone] = true
+		}
+	}
+	if len(zones) < 2 {
+		return candidates
+	}
+
+	counts := make(map[string]zoneCounts)
+	podsOnNodes := make(map[string]map[string]int)
+	for _, pod := range pods {
+		zone, found := nodeZones[pod.Spec.NodeName]
+		if !found {
+			continue
+		}
+		controller := spreadControllerKey(pod)
+		if controller == "" {
+			continue
+		}
+		if _, found := counts[controller]; !found {
+			counts[controller] = make(zoneCounts, len(zones))
+			for z := range zones {
+				counts[controller][z] = 0
+			}
+		}
+		counts[controller][zone]++
+		if _, found := podsOnNodes[pod.Spec.NodeName]; !found {
+			podsOnNodes[pod.Spec.NodeName] = make(map[string]int)
+		}
+		podsOnNodes[pod.Spec.NodeName][controller]++
+	}
+
+	degradation := make(map[string]int, len(candidates))
+	for _, node := range candidates {
+		zone := nodeZones[node.Name]
+		for controller, count := range podsOnNodes[node.Name] {
+			before := counts[controller].skew()
+			counts[controller][zone] -= count
+			degradation[node.Name] += counts[controller].skew() - before
+			counts[controller][zone] += count
+		}
+	}
+
+	result := make([]*apiv1.Node, len(candidates))
+	copy(result, candidates)
+	sort.SliceStable(result, func(i, j int) bool {
+		return degradation[result[i].Name] < degradation[result[j].Name]
+	})
+	return result
+}
+
+// spreadControllerKey returns the key of the Deployment's ReplicaSet or StatefulSet controlling
+// the pod, or an empty string for other pods.
+func spreadControllerKey(pod *apiv1.Pod) string {
+	controllerRef := metav1.GetControllerOf(pod)
+	if controllerRef == nil {
+		return ""
+	}
+	if controllerRef.Kind != "ReplicaSet" && controllerRef.Kind != "StatefulSet" {
+		return ""
+	}
+	return pod.Namespace + "/" + controllerRef.Kind + "/" + controllerRef.Name
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildZonalTestNode(name, zone string) *apiv1.Node {
+	node := BuildTestNode(name, 1000, 1000)
+	node.Labels = map[string]string{apiv1.LabelZoneFailureDomain: zone}
+	return node
+}
+
+func buildControlledTestPod(name, nodeName, kind, controller string) *apiv1.Pod {
+	pod := BuildTestPod(name, 100, 0)
+	pod.Spec.NodeName = nodeName
+	if kind != "" {
+		pod.OwnerReferences = GenerateOwnerReferences(controller, kind, "apps/v1", "")
+	}
+	return pod
+}
+
+func nodeNames(nodes []*apiv1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+func TestSortCandidatesByZoneSpread(t *testing.T) {
+	n1 := buildZonalTestNode("n1", "a")
+	n2 := buildZonalTestNode("n2", "a")
+	n3 := buildZonalTestNode("n3", "b")
+	n4 := buildZonalTestNode("n4", "b")
+	allNodes := []*apiv1.Node{n1, n2, n3, n4}
+
+	pods := []*apiv1.Pod{
+		// web has 2 replicas in zone a and 1 in zone b.
+		buildControlledTestPod("web-1", "n1", "ReplicaSet", "web"),
+		buildControlledTestPod("web-2", "n2", "ReplicaSet", "web"),
+		buildControlledTestPod("web-3", "n3", "ReplicaSet", "web"),
+		// db has one replica per zone.
+		buildControlledTestPod("db-0", "n2", "StatefulSet", "db"),
+		buildControlledTestPod("db-1", "n4", "StatefulSet", "db"),
+		// Other pods are ignored.
+		buildControlledTestPod("job", "n1", "Job", "job"),
+		buildControlledTestPod("standalone", "n1", "", ""),
+	}
+
+	// Removing n3 leaves web in a single zone, removing n2 unbalances db, removing n1 evens web out.
+	sorted := sortCandidatesByZoneSpread([]*apiv1.Node{n3, n2, n1}, allNodes, pods)
+	assert.Equal(t, []string{"n1", "n2", "n3"}, nodeNames(sorted))
+
+	// Nodes with the same degradation keep their order.
+	sorted = sortCandidatesByZoneSpread([]*apiv1.Node{n4, n3}, allNodes, pods)
+	assert.Equal(t, []string{"n4", "n3"}, nodeNames(sorted))
+
+	// Nothing to do in single zone clusters.
+	single := []*apiv1.Node{buildZonalTestNode("s1", "a"), buildZonalTestNode("s2", "a")}
+	sorted = sortCandidatesByZoneSpread(single, single, pods)
+	assert.Equal(t, []string{"s1", "s2"}, nodeNames(sorted))
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1appslister "k8s.io/client-go/listers/apps/v1"
+	podv1 "k8s.io/kubernetes/pkg/api/v1/pod"
+
+	"k8s.io/klog"
+)
+
+// orderedStatefulSet holds the readiness of the pods of a StatefulSet with the OrderedReady pod
+// management policy, by ordinal.
+type orderedStatefulSet struct {
+	settled bool
+	ready   map[int]bool
+}
+
+// orderCandidatesByStatefulSetOrdinals orders scale-down candidates so that the nodes whose pods of
+// StatefulSets with the OrderedReady pod management policy can be recreated right away come first.
+// A pod can be recreated right away if all the pods with a lower ordinal are ready and are not on
+// the same node. Nodes hosting pods of such StatefulSets that don't have all their replicas ready
+// yet, e.g. because a previous scale-down evicted some of them, are dropped so that their drains
+// are serialized. The order of the other nodes is kept.
+func orderCandidatesByStatefulSetOrdinals(candidates []*apiv1.Node, pods []*apiv1.Pod, statefulSetLister v1appslister.StatefulSetLister) []*apiv1.Node {
+	statefulSets := make(map[string]*orderedStatefulSet)
+	podsOnNodes := make(map[string][]*apiv1.Pod)
+	for _, pod := range pods {
+		key, ordinal, found := statefulSetOrdinal(pod)
+		if !found {
+			continue
+		}
+		sts, found := statefulSets[key]
+		if !found {
+			sts = getOrderedStatefulSet(pod, statefulSetLister)
+			statefulSets[key] = sts
+		}
+		if sts == nil {
+			continue
+		}
+		sts.ready[ordinal] = podv1.IsPodReady(pod)
+		podsOnNodes[pod.Spec.NodeName] = append(podsOnNodes[pod.Spec.NodeName], pod)
+	}
+	if len(podsOnNodes) == 0 {
+		return candidates
+	}
+
+	result := make([]*apiv1.Node, 0, len(candidates))
+	blocked := make(map[string]int, len(candidates))
+	for _, node := range candidates {
+		removable := true
+		onNode := make(map[string]map[int]bool)
+		for _, pod := range podsOnNodes[node.Name] {
+			key, ordinal, _ := statefulSetOrdinal(pod)
+			if !statefulSets[key].settled {
+				klog.V(2).Infof("Skipping %s from scale-down: StatefulSet %s doesn't have all its replicas ready", node.Name, key)
+				removable = false
+				break
+			}
+			if _, found := onNode[key]; !found {
+				onNode[key] = make(map[int]bool)
+			}
+			onNode[key][ordinal] = true
+		}
+		if !removable {
+			continue
+		}
+		for key, ordinals := range onNode {
+			for ordinal := range ordinals {
+				for lower := 0; lower < ordinal; lower++ {
+					if ordinals[lower] || !statefulSets[key].ready[lower] {
+						blocked[node.Name]++
+						break
+					}
+				}
+			}
+		}
+		result = append(result, node)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return blocked[result[i].Name] < blocked[result[j].Name]
+	})
+	return result
+}
+
+// getOrderedStatefulSet returns the StatefulSet controlling the pod if its pod management policy is
+// OrderedReady, or nil otherwise.
+func getOrderedStatefulSet(pod *apiv1.Pod, statefulSetLister v1appslister.StatefulSetLister) *orderedStatefulSet {
+	if statefulSetLister == nil {
+		return nil
+	}
+	controllerRef := metav1.GetControllerOf(pod)
+	sts, err := statefulSetLister.StatefulSets(pod.Namespace).Get(controllerRef.Name)
+	if err != nil {
+		klog.V(4).Infof("Failed to get StatefulSet %s/%s: %v", pod.Namespace, controllerRef.Name, err)
+		return nil
+	}
+	if sts.Spec.PodManagementPolicy != "" && sts.Spec.PodManagementPolicy != appsv1.OrderedReadyPodManagement {
+		return nil
+	}
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return &orderedStatefulSet{
+		settled: sts.Status.ObservedGeneration >= sts.Generation && sts.Status.ReadyReplicas >= replicas,
+		ready:   make(map[int]bool),
+	}
+}
+
+// statefulSetOrdinal returns the key of the StatefulSet controlling the pod and the ordinal of the
+// pod, or false if the pod isn't controlled by a StatefulSet.
+func statefulSetOrdinal(pod *apiv1.Pod) (string, int, bool) {
+	controllerRef := metav1.GetControllerOf(pod)
+	if controllerRef == nil || controllerRef.Kind != "StatefulSet" {
+		return "", 0, false
+	}
+	prefix := controllerRef.Name + "-"
+	if !strings.HasPrefix(pod.Name, prefix) {
+		return "", 0, false
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, prefix))
+	if err != nil || ordinal < 0 {
+		return "", 0, false
+	}
+	return pod.Namespace + "/" + controllerRef.Name, ordinal, true
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildTestStatefulSet(name string, policy appsv1.PodManagementPolicyType, replicas, ready int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:            &replicas,
+			PodManagementPolicy: policy,
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: ready},
+	}
+}
+
+func buildReadyStatefulSetTestPod(name, nodeName, statefulSet string) *apiv1.Pod {
+	pod := buildControlledTestPod(name, nodeName, "StatefulSet", statefulSet)
+	pod.Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+	return pod
+}
+
+func TestOrderCandidatesByStatefulSetOrdinals(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n3 := BuildTestNode("n3", 1000, 1000)
+	n4 := BuildTestNode("n4", 1000, 1000)
+	n5 := BuildTestNode("n5", 1000, 1000)
+	candidates := []*apiv1.Node{n1, n2, n3, n4, n5}
+
+	statefulSetLister, err := kube_util.NewTestStatefulSetLister([]*appsv1.StatefulSet{
+		buildTestStatefulSet("db", appsv1.OrderedReadyPodManagement, 4, 4),
+		buildTestStatefulSet("cache", appsv1.ParallelPodManagement, 2, 1),
+		buildTestStatefulSet("queue", "", 2, 1),
+	})
+	assert.NoError(t, err)
+
+	pods := []*apiv1.Pod{
+		// db-1 can only be recreated once db-0, on the same node, is.
+		buildReadyStatefulSetTestPod("db-0", "n1", "db"),
+		buildReadyStatefulSetTestPod("db-1", "n1", "db"),
+		buildReadyStatefulSetTestPod("db-2", "n2", "db"),
+		buildReadyStatefulSetTestPod("db-3", "n3", "db"),
+		// cache pods are created in parallel.
+		buildControlledTestPod("cache-0", "n3", "StatefulSet", "cache"),
+		// queue has a replica not ready yet.
+		buildReadyStatefulSetTestPod("queue-0", "n4", "queue"),
+		buildControlledTestPod("web-0", "n5", "ReplicaSet", "web"),
+	}
+
+	result := orderCandidatesByStatefulSetOrdinals(candidates, pods, statefulSetLister)
+	assert.Equal(t, []string{"n2", "n3", "n5", "n1"}, nodeNames(result))
+}
+
+func TestOrderCandidatesByStatefulSetOrdinalsWithoutStatefulSets(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	candidates := []*apiv1.Node{n2, n1}
+	pods := []*apiv1.Pod{
+		buildReadyStatefulSetTestPod("db-0", "n1", "db"),
+	}
+
+	statefulSetLister, err := kube_util.NewTestStatefulSetLister([]*appsv1.StatefulSet{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n2", "n1"}, nodeNames(orderCandidatesByStatefulSetOrdinals(candidates, pods, statefulSetLister)))
+	assert.Equal(t, []string{"n2", "n1"}, nodeNames(orderCandidatesByStatefulSetOrdinals(candidates, pods, nil)))
+}
@@ -172,6 +172,37 @@ func TestFindUnneededNodes(t *testing.T) {
 	assert.Equal(t, 0, len(sd.unremovableNodes))
 }
 
+func TestFindUnneededNodesUnremovableByCloudProvider(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 10)
+	n2 := BuildTestNode("n2", 1000, 10)
+	SetNodeReadyState(n1, true, time.Time{})
+	SetNodeReadyState(n2, true, time.Time{})
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+	provider.SetNodeUnremovable("n2", "the node is being remediated")
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.35,
+		UnremovableNodeRecheckTimeout: 5 * time.Minute,
+	}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
+
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2}, []*apiv1.Node{n1, n2}, []*apiv1.Pod{}, time.Now(), nil)
+
+	assert.Equal(t, 1, len(sd.unneededNodes))
+	_, found := sd.unneededNodes["n1"]
+	assert.True(t, found)
+
+	sd.markEmptyNodesUnneeded([]*apiv1.Node{n1, n2}, []*apiv1.Pod{}, time.Now())
+	_, found = sd.unneededNodes["n2"]
+	assert.False(t, found)
+}
+
 func TestPodsWithPrioritiesFindUnneededNodes(t *testing.T) {
 	// shared owner reference
 	ownerRef := GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
@@ -561,6 +592,52 @@ func TestDeleteNode(t *testing.T) {
 	}
 }
 
+func TestDeleteNodeDrainOnly(t *testing.T) {
+	updatedNodes := make(chan *apiv1.Node, 10)
+	deletedNodes := make(chan string, 10)
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		deletedNodes <- node
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 100, 100)
+	provider.AddNode("ng1", n1)
+
+	fakeClient := &fake.Clientset{}
+	fakeNode := n1.DeepCopy()
+	fakeClient.Fake.AddReactor("get", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		return true, fakeNode.DeepCopy(), nil
+	})
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		obj := action.(core.UpdateAction).GetObject().(*apiv1.Node)
+		updatedNodes <- obj.DeepCopy()
+		fakeNode = obj.DeepCopy()
+		return true, obj, nil
+	})
+
+	options := config.AutoscalingOptions{DrainOnlyNodeGroups: []string{"ng1"}}
+	context := NewScaleTestAutoscalingContext(options, fakeClient, nil, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+
+	assert.NoError(t, sd.deleteNode(n1, []*apiv1.Pod{}))
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(deletedNodes))
+
+	tainted := <-updatedNodes
+	assert.True(t, deletetaint.HasToBeDeletedTaint(tainted))
+	annotated := <-updatedNodes
+	assert.True(t, deletetaint.HasToBeDeletedTaint(annotated))
+	assert.True(t, isDrainedForRemoval(annotated))
+	assert.Equal(t, 0, len(updatedNodes))
+
+	// Drained nodes are no longer considered for scale down.
+	assert.Empty(t, filterOutDrainedForRemoval([]*apiv1.Node{annotated}))
+	assert.Equal(t, []*apiv1.Node{n1}, filterOutDrainedForRemoval([]*apiv1.Node{n1, annotated}))
+}
+
 func TestDrainNode(t *testing.T) {
 	deletedPods := make(chan string, 10)
 	fakeClient := &fake.Clientset{}
@@ -595,6 +672,65 @@ func TestDrainNode(t *testing.T) {
 	assert.Equal(t, p2.Name, deleted[1])
 }
 
+func TestDrainNodeMaxGracefulTerminationSecOverrides(t *testing.T) {
+	gracePeriods := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	longTermination := int64(3600)
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Namespace = "db"
+	p1.Spec.TerminationGracePeriodSeconds = &longTermination
+	p2 := BuildTestPod("p2", 100, 0)
+	p2.Namespace = "db"
+	p2.Spec.TerminationGracePeriodSeconds = &longTermination
+	p2.Annotations = map[string]string{MaxGracefulTerminationSecKey: "5"}
+	p3 := BuildTestPod("p3", 100, 0)
+	p3.Spec.TerminationGracePeriodSeconds = &longTermination
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	fakeClient.Fake.AddReactor("get", "namespaces", func(action core.Action) (bool, runtime.Object, error) {
+		name := action.(core.GetAction).GetName()
+		namespace := &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if name == "db" {
+			namespace.Annotations = map[string]string{MaxGracefulTerminationSecKey: "30"}
+		}
+		return true, namespace, nil
+	})
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		eviction := action.(core.CreateAction).GetObject().(*policyv1.Eviction)
+		gracePeriods <- fmt.Sprintf("%s-%d", eviction.Name, *eviction.DeleteOptions.GracePeriodSeconds)
+		return true, nil, nil
+	})
+	err := drainNode(n1, []*apiv1.Pod{p1, p2, p3}, fakeClient, kube_util.CreateEventRecorder(fakeClient), 20, 5*time.Second, 0*time.Second)
+	assert.NoError(t, err)
+	evicted := []string{getStringFromChan(gracePeriods), getStringFromChan(gracePeriods), getStringFromChan(gracePeriods)}
+	sort.Strings(evicted)
+	assert.Equal(t, []string{"p1-30", "p2-5", "p3-20"}, evicted)
+}
+
+func TestGetMaxGracefulTerminationSec(t *testing.T) {
+	pod := BuildTestPod("p1", 100, 0)
+	namespace := &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	assert.Equal(t, 600, getMaxGracefulTerminationSec(pod, nil, 600))
+	assert.Equal(t, 600, getMaxGracefulTerminationSec(pod, namespace, 600))
+
+	namespace.Annotations = map[string]string{MaxGracefulTerminationSecKey: "3600"}
+	assert.Equal(t, 3600, getMaxGracefulTerminationSec(pod, namespace, 600))
+
+	pod.Annotations = map[string]string{MaxGracefulTerminationSecKey: "0"}
+	assert.Equal(t, 0, getMaxGracefulTerminationSec(pod, namespace, 600))
+
+	// Invalid values are ignored.
+	pod.Annotations = map[string]string{MaxGracefulTerminationSecKey: "-1"}
+	assert.Equal(t, 3600, getMaxGracefulTerminationSec(pod, namespace, 600))
+	namespace.Annotations = map[string]string{MaxGracefulTerminationSecKey: "10m"}
+	assert.Equal(t, 600, getMaxGracefulTerminationSec(pod, namespace, 600))
+}
+
 func TestDrainNodeWithRescheduled(t *testing.T) {
 	deletedPods := make(chan string, 10)
 	fakeClient := &fake.Clientset{}
@@ -764,7 +900,7 @@ func TestScaleDown(t *testing.T) {
 	}
 	jobLister, err := kube_util.NewTestJobLister([]*batchv1.Job{&job})
 	assert.NoError(t, err)
-	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil, nil, nil)
 
 	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
 
@@ -780,6 +916,62 @@ func TestScaleDown(t *testing.T) {
 	assert.Equal(t, n1.Name, getStringFromChan(updatedNodes))
 }
 
+func TestScaleDownUnremovableByCloudProvider(t *testing.T) {
+	fakeClient := &fake.Clientset{}
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
+	p2 := BuildTestPod("p2", 800, 0)
+	p1.Spec.NodeName = "n1"
+	p2.Spec.NodeName = "n2"
+
+	fakeClient.Fake.AddReactor("list", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, &apiv1.PodList{Items: []apiv1.Pod{*p1, *p2}}, nil
+	})
+	fakeClient.Fake.AddReactor("delete", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		t.Errorf("Unexpected eviction of pod %s", action.(core.DeleteAction).GetName())
+		return true, nil, nil
+	})
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		obj := action.(core.UpdateAction).GetObject().(*apiv1.Node)
+		t.Errorf("Unexpected update of node %s", obj.Name)
+		return true, obj, nil
+	})
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		t.Errorf("Unexpected deletion of node %s", node)
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.5,
+		ScaleDownUnneededTime:         time.Minute,
+		MaxGracefulTerminationSec:     60,
+	}
+	context := NewScaleTestAutoscalingContext(options, fakeClient, nil, provider)
+
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
+		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2}, time.Now().Add(-5*time.Minute), nil)
+	_, found := scaleDown.unneededNodes["n1"]
+	assert.True(t, found)
+
+	// The node group of n1 started rolling out since n1 became unneeded, so
+	// its nodes can't be deleted and mustn't be drained.
+	provider.SetNodeUnremovable("n1", "nodegroup ng1 is rolling out")
+	scaleDownStatus, err := scaleDown.TryToScaleDown([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2}, nil, time.Now())
+	waitForDeleteToFinish(t, scaleDown)
+	assert.NoError(t, err)
+	assert.Equal(t, status.ScaleDownNoUnneeded, scaleDownStatus.Result)
+}
+
 func waitForDeleteToFinish(t *testing.T, sd *ScaleDown) {
 	for start := time.Now(); time.Since(start) < 20*time.Second; time.Sleep(100 * time.Millisecond) {
 		if !sd.nodeDeleteStatus.IsDeleteInProgress() {
@@ -1011,6 +1203,177 @@ func simpleScaleDownEmpty(t *testing.T, config *scaleTestConfig) {
 	assertEqualSet(t, config.expectedScaleDowns, deleted)
 }
 
+func TestScaleDownEmptyOutsideMainLoop(t *testing.T) {
+	deletedNodes := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	p2 := BuildTestPod("p2", 800, 0)
+	p2.Spec.NodeName = "n2"
+	nodes := []*apiv1.Node{n1, n2}
+
+	fakeClient.Fake.AddReactor("get", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		getAction := action.(core.GetAction)
+		switch getAction.GetName() {
+		case n1.Name:
+			return true, n1, nil
+		case n2.Name:
+			return true, n2, nil
+		}
+		return true, nil, fmt.Errorf("wrong node: %v", getAction.GetName())
+	})
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		update := action.(core.UpdateAction)
+		return true, update.GetObject(), nil
+	})
+
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		deletedNodes <- node
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	context := NewScaleTestAutoscalingContext(defaultScaleDownOptions, fakeClient, nil, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+
+	// The first run only notices that n1 became empty.
+	scaleDownStatus, err := scaleDown.TryToScaleDownEmpty(nodes, []*apiv1.Pod{p2}, time.Now().Add(-5*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, status.ScaleDownNoNodeDeleted, scaleDownStatus.Result)
+	assert.Contains(t, scaleDown.unneededNodes, "n1")
+	assert.NotContains(t, scaleDown.unneededNodes, "n2")
+
+	// Once n1 has been unneeded for long enough it is removed.
+	scaleDownStatus, err = scaleDown.TryToScaleDownEmpty(nodes, []*apiv1.Pod{p2}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, status.ScaleDownNodeDeleted, scaleDownStatus.Result)
+	assert.Equal(t, "n1", getStringFromChan(deletedNodes))
+	assert.Equal(t, nothingReturned, getStringFromChanImmediately(deletedNodes))
+}
+
+func TestScaleDownMinNodeLifetime(t *testing.T) {
+	now := time.Now()
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n1.CreationTimestamp = metav1.NewTime(now.Add(-5 * time.Minute))
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	n2.CreationTimestamp = metav1.NewTime(now.Add(-5 * time.Minute))
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Time{})
+	n3.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 2)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n3)
+	provider.AddNode("ng2", n2)
+
+	options := defaultScaleDownOptions
+	options.ScaleDownMinNodeLifetime = 30 * time.Minute
+	options.NodeGroupMinNodeLifetimes = map[string]time.Duration{"ng2": time.Minute}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	for _, node := range []*apiv1.Node{n1, n2, n3} {
+		scaleDown.unneededNodes[node.Name] = now.Add(-10 * time.Minute)
+	}
+
+	candidates, _, _, _, err := scaleDown.getScaleDownCandidates([]*apiv1.Node{n1, n2, n3}, now)
+	assert.NoError(t, err)
+	// n1 is younger than the default min lifetime, n2 is old enough for the ng2 override.
+	assert.Equal(t, []*apiv1.Node{n2, n3}, candidates)
+}
+
+func TestScaleDownReservedDaemonSetFraction(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Time{})
+
+	pods := make([]*apiv1.Pod, 0)
+	for _, node := range []*apiv1.Node{n1, n2, n3} {
+		pod := BuildTestPod("ds-"+node.Name, 600, 0)
+		pod.Spec.NodeName = node.Name
+		pod.OwnerReferences = GenerateOwnerReferences("ds", "DaemonSet", "apps/v1", "")
+		pods = append(pods, pod)
+	}
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 2)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+	provider.AddNode("ng2", n3)
+
+	options := defaultScaleDownOptions
+	options.ReservedDaemonSetFraction = 0.5
+	options.NodeGroupReservedDaemonSetFractions = map[string]float64{"ng2": 0}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2, n3}, []*apiv1.Node{n1, n2, n3}, pods, time.Now(), nil)
+
+	assert.InEpsilon(t, 0.3, sd.nodeUtilizationMap["n1"].Utilization, 0.01)
+	assert.InEpsilon(t, 0.6, sd.nodeUtilizationMap["n3"].Utilization, 0.01)
+	assert.Contains(t, sd.unneededNodes, "n1")
+	assert.NotContains(t, sd.unneededNodes, "n3")
+}
+
+type fakeNodeBusynessProvider struct {
+	busyness map[string]float64
+	err      error
+}
+
+func (p *fakeNodeBusynessProvider) GetNodesBusyness(nodes []*apiv1.Node) (map[string]float64, error) {
+	return p.busyness, p.err
+}
+
+func (p *fakeNodeBusynessProvider) CleanUp() {
+}
+
+func TestScaleDownNodeBusyness(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Time{})
+	nodes := []*apiv1.Node{n1, n2, n3}
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 3)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+	provider.AddNode("ng1", n3)
+
+	context := NewScaleTestAutoscalingContext(defaultScaleDownOptions, &fake.Clientset{}, nil, provider)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+	sd.nodeBusynessProvider = &fakeNodeBusynessProvider{busyness: map[string]float64{"n1": 0.9, "n2": 0.1}}
+	sd.UpdateUnneededNodes(nodes, nodes, []*apiv1.Pod{}, time.Now(), nil)
+
+	assert.InEpsilon(t, 0.9, sd.nodeUtilizationMap["n1"].Utilization, 0.01)
+	assert.InEpsilon(t, 0.1, sd.nodeUtilizationMap["n2"].Utilization, 0.01)
+	assert.NotContains(t, sd.unneededNodes, "n1")
+	assert.Contains(t, sd.unneededNodes, "n2")
+	assert.Contains(t, sd.unneededNodes, "n3")
+
+	// Nodes are judged by their utilization only when busyness is unavailable.
+	sd.nodeBusynessProvider = &fakeNodeBusynessProvider{err: fmt.Errorf("prometheus unavailable")}
+	sd.UpdateUnneededNodes(nodes, nodes, []*apiv1.Pod{}, time.Now(), nil)
+	assert.Contains(t, sd.unneededNodes, "n1")
+}
+
 func TestNoScaleDownUnready(t *testing.T) {
 	fakeClient := &fake.Clientset{}
 	n1 := BuildTestNode("n1", 1000, 1000)
@@ -1153,7 +1516,7 @@ func TestScaleDownNoMove(t *testing.T) {
 	}
 	jobLister, err := kube_util.NewTestJobLister([]*batchv1.Job{&job})
 	assert.NoError(t, err)
-	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil, nil, nil)
 
 	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
 
@@ -1402,7 +1765,7 @@ func TestSoftTaint(t *testing.T) {
 	}
 	jobLister, err := kube_util.NewTestJobLister([]*batchv1.Job{&job})
 	assert.NoError(t, err)
-	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil, nil, nil)
 
 	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
 
@@ -1512,7 +1875,7 @@ func TestSoftTaintTimeLimit(t *testing.T) {
 	}
 	jobLister, err := kube_util.NewTestJobLister([]*batchv1.Job{&job})
 	assert.NoError(t, err)
-	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil, nil, nil)
 
 	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider)
 
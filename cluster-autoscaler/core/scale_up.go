@@ -47,7 +47,26 @@ type scaleUpResourcesDelta map[string]int64
 // used as a value in scaleUpResourcesLimits if actual limit could not be obtained due to errors talking to cloud provider
 const scaleUpLimitUnknown = math.MaxInt64
 
+// nodeGroupSubnetsExhausted returns true if the node group reports that no
+// IP addresses are left for new nodes. Node groups not reporting IP capacity
+// are never considered exhausted.
+func nodeGroupSubnetsExhausted(nodeGroup cloudprovider.NodeGroup) bool {
+	ipCapacity, ok := nodeGroup.(cloudprovider.IPCapacityNodeGroup)
+	if !ok {
+		return false
+	}
+	available, err := ipCapacity.AvailableIPs()
+	if err != nil {
+		if err != cloudprovider.ErrNotImplemented {
+			klog.Warningf("Failed to get available IPs for node group %s: %v", nodeGroup.Id(), err)
+		}
+		return false
+	}
+	return available <= 0
+}
+
 func computeScaleUpResourcesLeftLimits(
+	gpuLabel string,
 	nodeGroups []cloudprovider.NodeGroup,
 	nodeInfos map[string]*schedulernodeinfo.NodeInfo,
 	nodesFromNotAutoscaledGroups []*apiv1.Node,
@@ -57,7 +76,7 @@ func computeScaleUpResourcesLeftLimits(
 	var totalGpus map[string]int64
 	var totalGpusErr error
 	if cloudprovider.ContainsGpuResources(resourceLimiter.GetResources()) {
-		totalGpus, totalGpusErr = calculateScaleUpGpusTotal(nodeGroups, nodeInfos, nodesFromNotAutoscaledGroups)
+		totalGpus, totalGpusErr = calculateScaleUpGpusTotal(gpuLabel, nodeGroups, nodeInfos, nodesFromNotAutoscaledGroups)
 	}
 
 	resultScaleUpLimits := make(scaleUpResourcesLimits)
@@ -134,6 +153,7 @@ func calculateScaleUpCoresMemoryTotal(
 }
 
 func calculateScaleUpGpusTotal(
+	gpuLabel string,
 	nodeGroups []cloudprovider.NodeGroup,
 	nodeInfos map[string]*schedulernodeinfo.NodeInfo,
 	nodesFromNotAutoscaledGroups []*apiv1.Node) (map[string]int64, errors.AutoscalerError) {
@@ -149,7 +169,7 @@ func calculateScaleUpGpusTotal(
 			return nil, errors.NewAutoscalerError(errors.CloudProviderError, "No node info for: %s", nodeGroup.Id())
 		}
 		if currentSize > 0 {
-			gpuType, gpuCount, err := gpu.GetNodeTargetGpus(nodeInfo.Node(), nodeGroup)
+			gpuType, gpuCount, err := gpu.GetNodeTargetGpus(gpuLabel, nodeInfo.Node(), nodeGroup)
 			if err != nil {
 				return nil, errors.ToAutoscalerError(errors.CloudProviderError, err).AddPrefix("Failed to get target gpu for node group %v:", nodeGroup.Id())
 			}
@@ -161,7 +181,7 @@ func calculateScaleUpGpusTotal(
 	}
 
 	for _, node := range nodesFromNotAutoscaledGroups {
-		gpuType, gpuCount, err := gpu.GetNodeTargetGpus(node, nil)
+		gpuType, gpuCount, err := gpu.GetNodeTargetGpus(gpuLabel, node, nil)
 		if err != nil {
 			return nil, errors.ToAutoscalerError(errors.CloudProviderError, err).AddPrefix("Failed to get target gpu for node gpus count for node %v:", node.Name)
 		}
@@ -178,7 +198,7 @@ func computeBelowMax(total int64, max int64) int64 {
 	return 0
 }
 
-func computeScaleUpResourcesDelta(nodeInfo *schedulernodeinfo.NodeInfo, nodeGroup cloudprovider.NodeGroup, resourceLimiter *cloudprovider.ResourceLimiter) (scaleUpResourcesDelta, errors.AutoscalerError) {
+func computeScaleUpResourcesDelta(gpuLabel string, nodeInfo *schedulernodeinfo.NodeInfo, nodeGroup cloudprovider.NodeGroup, resourceLimiter *cloudprovider.ResourceLimiter) (scaleUpResourcesDelta, errors.AutoscalerError) {
 	resultScaleUpDelta := make(scaleUpResourcesDelta)
 
 	nodeCPU, nodeMemory := getNodeInfoCoresAndMemory(nodeInfo)
@@ -186,7 +206,7 @@ func computeScaleUpResourcesDelta(nodeInfo *schedulernodeinfo.NodeInfo, nodeGrou
 	resultScaleUpDelta[cloudprovider.ResourceNameMemory] = nodeMemory
 
 	if cloudprovider.ContainsGpuResources(resourceLimiter.GetResources()) {
-		gpuType, gpuCount, err := gpu.GetNodeTargetGpus(nodeInfo.Node(), nodeGroup)
+		gpuType, gpuCount, err := gpu.GetNodeTargetGpus(gpuLabel, nodeInfo.Node(), nodeGroup)
 		if err != nil {
 			return scaleUpResourcesDelta{}, errors.ToAutoscalerError(errors.CloudProviderError, err).AddPrefix("Failed to get target gpu for node group %v:", nodeGroup.Id())
 		}
@@ -238,6 +258,7 @@ var (
 	backoffReason         = &skippedReasons{[]string{"in backoff after failed scale-up"}}
 	maxLimitReachedReason = &skippedReasons{[]string{"max limit reached"}}
 	notReadyReason        = &skippedReasons{[]string{"not ready for scale-up"}}
+	subnetExhaustedReason = &skippedReasons{[]string{"no free IP addresses in subnets"}}
 )
 
 // ScaleUp tries to scale the cluster up. Return true if it found a way to increase the size,
@@ -270,6 +291,7 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 	}
 
 	nodeGroups := context.CloudProvider.NodeGroups()
+	gpuLabel := gpu.GetGpuLabel(context.CloudProvider)
 
 	resourceLimiter, errCP := context.CloudProvider.GetResourceLimiter()
 	if errCP != nil {
@@ -278,7 +300,7 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 			errCP)
 	}
 
-	scaleUpResourcesLeft, errLimits := computeScaleUpResourcesLeftLimits(nodeGroups, nodeInfos, nodesFromNotAutoscaledGroups, resourceLimiter)
+	scaleUpResourcesLeft, errLimits := computeScaleUpResourcesLeftLimits(gpuLabel, nodeGroups, nodeInfos, nodesFromNotAutoscaledGroups, resourceLimiter)
 	if errLimits != nil {
 		return &status.ScaleUpStatus{Result: status.ScaleUpError}, errLimits.AddPrefix("Could not compute total resources: ")
 	}
@@ -308,7 +330,13 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 		}
 	}
 
-	podsPredicatePassingCheckFunctions := getPodsPredicatePassingCheckFunctions(context, unschedulablePods, nodeInfos)
+	podEquivalenceGroups := buildPodEquivalenceGroups(unschedulablePods)
+	for _, group := range podEquivalenceGroups {
+		metrics.RegisterPodEquivalenceGroupSize(len(group.pods))
+	}
+	klog.V(4).Infof("%d unschedulable pods in %d equivalence groups", len(unschedulablePods), len(podEquivalenceGroups))
+
+	podsPredicatePassingCheckFunctions := getPodsPredicatePassingCheckFunctions(context, podEquivalenceGroups, nodeInfos)
 	getPodsPassingPredicates := podsPredicatePassingCheckFunctions.getPodsPassingPredicates
 	getPodsNotPassingPredicates := podsPredicatePassingCheckFunctions.getPodsNotPassingPredicates
 
@@ -333,9 +361,23 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 			skippedNodeGroups[nodeGroup.Id()] = notReadyReason
 			continue
 		}
-		if currentTargetSize >= nodeGroup.MaxSize() {
-			klog.V(4).Infof("Skipping node group %s - max size reached", nodeGroup.Id())
-			skippedNodeGroups[nodeGroup.Id()] = maxLimitReachedReason
+		maxSize, limitReason := cloudprovider.EffectiveMaxSize(nodeGroup)
+		if currentTargetSize >= maxSize {
+			if limitReason != "" {
+				klog.V(4).Infof("Skipping node group %s - provider limit reached: %s", nodeGroup.Id(), limitReason)
+				skippedNodeGroups[nodeGroup.Id()] = &skippedReasons{[]string{fmt.Sprintf("max limit reached: %s", limitReason)}}
+			} else {
+				klog.V(4).Infof("Skipping node group %s - max size reached", nodeGroup.Id())
+				skippedNodeGroups[nodeGroup.Id()] = maxLimitReachedReason
+			}
+			continue
+		}
+
+		if context.IPAMAwareScaleUp && nodeGroupSubnetsExhausted(nodeGroup) {
+			klog.V(4).Infof("Skipping node group %s - no free IP addresses in subnets", nodeGroup.Id())
+			context.LogRecorder.Eventf(apiv1.EventTypeWarning, "SubnetExhausted",
+				"Skipping node group %s for scale-up: no free IP addresses in subnets", nodeGroup.Id())
+			skippedNodeGroups[nodeGroup.Id()] = subnetExhaustedReason
 			continue
 		}
 
@@ -346,7 +388,7 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 			continue
 		}
 
-		scaleUpResourcesDelta, err := computeScaleUpResourcesDelta(nodeInfo, nodeGroup, resourceLimiter)
+		scaleUpResourcesDelta, err := computeScaleUpResourcesDelta(gpuLabel, nodeInfo, nodeGroup, resourceLimiter)
 		if err != nil {
 			klog.Errorf("Skipping node group %s; error getting node group resources: %v", nodeGroup.Id(), err)
 			skippedNodeGroups[nodeGroup.Id()] = notReadyReason
@@ -488,7 +530,7 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 		}
 
 		// apply upper limits for CPU and memory
-		newNodes, err = applyScaleUpResourcesLimits(newNodes, scaleUpResourcesLeft, nodeInfo, bestOption.NodeGroup, resourceLimiter)
+		newNodes, err = applyScaleUpResourcesLimits(gpuLabel, newNodes, scaleUpResourcesLeft, nodeInfo, bestOption.NodeGroup, resourceLimiter)
 		if err != nil {
 			return &status.ScaleUpStatus{Result: status.ScaleUpError}, err
 		}
@@ -527,12 +569,18 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 			return &status.ScaleUpStatus{Result: status.ScaleUpError}, typedErr
 		}
 		klog.V(1).Infof("Final scale-up plan: %v", scaleUpInfos)
+		scaleUpInfos, typedErr = executeBalancedScaleUp(context, processors, clusterStateRegistry, targetNodeGroups, scaleUpInfos, gpu.GetGpuTypeForMetrics(gpuLabel, gpu.GetAvailableGpuTypes(context.CloudProvider), nodeInfo.Node(), nil), now)
+		if typedErr != nil {
+			return &status.ScaleUpStatus{Result: status.ScaleUpError}, typedErr
+		}
+
+		requestedNodes := 0
 		for _, info := range scaleUpInfos {
-			typedErr := executeScaleUp(context, clusterStateRegistry, info, gpu.GetGpuTypeForMetrics(nodeInfo.Node(), nil), now)
-			if typedErr != nil {
-				return &status.ScaleUpStatus{Result: status.ScaleUpError}, typedErr
-			}
+			requestedNodes += info.NewSize - info.CurrentSize
 		}
+		estimation := status.NewScaleUpEstimation(bestOption.NodeGroup.Id(), bestOption.NodeCount, requestedNodes, bestOption.Pods)
+		klog.V(1).Infof("Scale-up estimation: %v", estimation)
+		metrics.RegisterScaleUpEstimation(estimation.EstimatedNodes, estimation.Pods())
 
 		clusterStateRegistry.Recalculate()
 		return &status.ScaleUpStatus{
@@ -540,7 +588,8 @@ func ScaleUp(context *context.AutoscalingContext, processors *ca_processors.Auto
 				ScaleUpInfos:            scaleUpInfos,
 				PodsRemainUnschedulable: getRemainingPods(podsRemainUnschedulable, skippedNodeGroups),
 				PodsTriggeredScaleUp:    bestOption.Pods,
-				PodsAwaitEvaluation:     getPodsAwaitingEvaluation(unschedulablePods, podsRemainUnschedulable, bestOption.Pods)},
+				PodsAwaitEvaluation:     getPodsAwaitingEvaluation(unschedulablePods, podsRemainUnschedulable, bestOption.Pods),
+				Estimation:              estimation},
 			nil
 	}
 
@@ -554,7 +603,7 @@ type podsPredicatePassingCheckFunctions struct {
 
 func getPodsPredicatePassingCheckFunctions(
 	context *context.AutoscalingContext,
-	unschedulablePods []*apiv1.Pod,
+	podEquivalenceGroups []*podEquivalenceGroup,
 	nodeInfos map[string]*schedulernodeinfo.NodeInfo) podsPredicatePassingCheckFunctions {
 
 	podsPassingPredicatesCache := make(map[string][]*apiv1.Pod)
@@ -570,7 +619,7 @@ func getPodsPredicatePassingCheckFunctions(
 
 		podsPassing := make([]*apiv1.Pod, 0)
 		podsNotPassing := make(map[*apiv1.Pod]status.Reasons)
-		schedulableOnNode := checkPodsSchedulableOnNode(context, unschedulablePods, nodeGroupId, nodeInfo)
+		schedulableOnNode := checkPodsSchedulableOnNode(context, podEquivalenceGroups, nodeGroupId, nodeInfo)
 		for pod, err := range schedulableOnNode {
 			if err == nil {
 				podsPassing = append(podsPassing, pod)
@@ -687,7 +736,7 @@ func executeScaleUp(context *context.AutoscalingContext, clusterStateRegistry *c
 	context.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaledUpGroup",
 		"Scale-up: setting group %s size to %d", info.Group.Id(), info.NewSize)
 	increase := info.NewSize - info.CurrentSize
-	if err := info.Group.IncreaseSize(increase); err != nil {
+	if err := context.NodeGroupOperations.Do(info.Group.Id(), func() error { return info.Group.IncreaseSize(increase) }); err != nil {
 		context.LogRecorder.Eventf(apiv1.EventTypeWarning, "FailedToScaleUpGroup", "Scale-up failed for group %s: %v", info.Group.Id(), err)
 		clusterStateRegistry.RegisterFailedScaleUp(info.Group, metrics.APIError, now)
 		return errors.NewAutoscalerError(errors.CloudProviderError,
@@ -703,14 +752,71 @@ func executeScaleUp(context *context.AutoscalingContext, clusterStateRegistry *c
 	return nil
 }
 
+// executeBalancedScaleUp executes a scale-up plan split between similar node groups. When a group
+// fails to scale up, the nodes planned for it are balanced again between the groups that haven't
+// failed, so that the requested capacity is still added, e.g. in other zones. Returns the executed
+// scale-ups, and the last scale-up error if some of the nodes couldn't be added to any group.
+func executeBalancedScaleUp(context *context.AutoscalingContext, processors *ca_processors.AutoscalingProcessors, clusterStateRegistry *clusterstate.ClusterStateRegistry,
+	groups []cloudprovider.NodeGroup, scaleUpInfos []nodegroupset.ScaleUpInfo, gpuType string, now time.Time) ([]nodegroupset.ScaleUpInfo, errors.AutoscalerError) {
+	executed := make([]nodegroupset.ScaleUpInfo, 0, len(scaleUpInfos))
+	failedGroups := make(map[string]bool)
+	missingNodes := 0
+	var lastErr errors.AutoscalerError
+
+	for len(scaleUpInfos) > 0 {
+		failedNodes := 0
+		for _, info := range scaleUpInfos {
+			if typedErr := executeScaleUp(context, clusterStateRegistry, info, gpuType, now); typedErr != nil {
+				failedGroups[info.Group.Id()] = true
+				failedNodes += info.NewSize - info.CurrentSize
+				lastErr = typedErr
+				continue
+			}
+			executed = append(executed, info)
+		}
+		if failedNodes == 0 {
+			break
+		}
+
+		remainingGroups := make([]cloudprovider.NodeGroup, 0, len(groups))
+		for _, ng := range groups {
+			if !failedGroups[ng.Id()] {
+				remainingGroups = append(remainingGroups, ng)
+			}
+		}
+		if len(remainingGroups) == 0 {
+			missingNodes += failedNodes
+			break
+		}
+
+		var typedErr errors.AutoscalerError
+		scaleUpInfos, typedErr = processors.NodeGroupSetProcessor.BalanceScaleUpBetweenGroups(context, remainingGroups, failedNodes)
+		if typedErr != nil {
+			return executed, typedErr
+		}
+		for _, info := range scaleUpInfos {
+			failedNodes -= info.NewSize - info.CurrentSize
+		}
+		missingNodes += failedNodes
+		klog.V(1).Infof("Scale-up failed for some node groups, retrying on similar node groups: %v", scaleUpInfos)
+	}
+
+	if missingNodes > 0 {
+		klog.Warningf("Failed to add %v nodes to any similar node group", missingNodes)
+		return executed, lastErr
+	}
+	return executed, nil
+}
+
 func applyScaleUpResourcesLimits(
+	gpuLabel string,
 	newNodes int,
 	scaleUpResourcesLeft scaleUpResourcesLimits,
 	nodeInfo *schedulernodeinfo.NodeInfo,
 	nodeGroup cloudprovider.NodeGroup,
 	resourceLimiter *cloudprovider.ResourceLimiter) (int, errors.AutoscalerError) {
 
-	delta, err := computeScaleUpResourcesDelta(nodeInfo, nodeGroup, resourceLimiter)
+	delta, err := computeScaleUpResourcesDelta(gpuLabel, nodeInfo, nodeGroup, resourceLimiter)
 	if err != nil {
 		return 0, err
 	}
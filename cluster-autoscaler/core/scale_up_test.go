@@ -29,6 +29,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/pods"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
@@ -36,7 +37,12 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 
 	"github.com/stretchr/testify/assert"
@@ -382,7 +388,7 @@ func simpleScaleUpTest(t *testing.T, config *scaleTestConfig) {
 	}
 
 	podLister := kube_util.NewTestPodLister(pods)
-	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
 		expandedGroups <- groupSizeChange{groupName: nodeGroup, sizeChange: increase}
@@ -481,7 +487,7 @@ func TestScaleUpNodeComingNoScale(t *testing.T) {
 	p2.Spec.NodeName = "n2"
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{p1, p2})
-	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
 		t.Fatalf("No expansion is expected, but increased %s by %d", nodeGroup, increase)
@@ -531,7 +537,7 @@ func TestScaleUpNodeComingHasScale(t *testing.T) {
 	p2.Spec.NodeName = "n2"
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{p1, p2})
-	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	expandedGroups := make(chan string, 10)
 	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
@@ -567,6 +573,11 @@ func TestScaleUpNodeComingHasScale(t *testing.T) {
 	// Two nodes needed but one node is already coming, so it should increase by one.
 	assert.True(t, scaleUpStatus.WasSuccessful())
 	assert.Equal(t, "ng2-1", getStringFromChan(expandedGroups))
+	assert.NotNil(t, scaleUpStatus.Estimation)
+	assert.Equal(t, "ng2", scaleUpStatus.Estimation.NodeGroup)
+	assert.Equal(t, 1, scaleUpStatus.Estimation.EstimatedNodes)
+	assert.Equal(t, 1, scaleUpStatus.Estimation.RequestedNodes)
+	assert.Equal(t, 2, scaleUpStatus.Estimation.Pods())
 }
 
 func TestScaleUpUnhealthy(t *testing.T) {
@@ -581,7 +592,7 @@ func TestScaleUpUnhealthy(t *testing.T) {
 	p2.Spec.NodeName = "n2"
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{p1, p2})
-	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
 		t.Fatalf("No expansion is expected, but increased %s by %d", nodeGroup, increase)
@@ -621,7 +632,7 @@ func TestScaleUpNoHelp(t *testing.T) {
 	p1.Spec.NodeName = "n1"
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{p1})
-	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
 		t.Fatalf("No expansion is expected")
@@ -693,7 +704,7 @@ func TestScaleUpBalanceGroups(t *testing.T) {
 	}
 
 	podLister := kube_util.NewTestPodLister(podList)
-	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	options := config.AutoscalingOptions{
 		EstimatorName:            estimator.BinpackingEstimatorName,
@@ -730,6 +741,70 @@ func TestScaleUpBalanceGroups(t *testing.T) {
 	assert.Equal(t, 2, ng3size)
 }
 
+func TestScaleUpBalanceGroupsRetriesFailedGroups(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
+		if nodeGroup == "ng1" {
+			return fmt.Errorf("out of capacity")
+		}
+		return nil
+	}, nil)
+
+	nodes := make([]*apiv1.Node, 0)
+	podList := make([]*apiv1.Pod, 0)
+	for _, gid := range []string{"ng1", "ng2", "ng3"} {
+		provider.AddNodeGroup(gid, 1, 5, 1)
+		nodeName := fmt.Sprintf("%v-node-0", gid)
+		node := BuildTestNode(nodeName, 100, 1000)
+		SetNodeReadyState(node, true, time.Now())
+		nodes = append(nodes, node)
+
+		pod := BuildTestPod(fmt.Sprintf("%v-pod-0", gid), 80, 0)
+		pod.Spec.NodeName = nodeName
+		podList = append(podList, pod)
+
+		provider.AddNode(gid, node)
+	}
+
+	podLister := kube_util.NewTestPodLister(podList)
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	options := config.AutoscalingOptions{
+		EstimatorName:            estimator.BinpackingEstimatorName,
+		BalanceSimilarNodeGroups: true,
+		MaxCoresTotal:            config.DefaultMaxClusterCores,
+		MaxMemoryTotal:           config.DefaultMaxClusterMemory,
+	}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, listers, provider)
+
+	nodeInfos, _ := getNodeInfosForGroups(nodes, nil, provider, listers, []*appsv1.DaemonSet{}, context.PredicateChecker)
+	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	clusterState.UpdateNodes(nodes, nodeInfos, time.Now())
+
+	pods := make([]*apiv1.Pod, 0)
+	for i := 0; i < 3; i++ {
+		pods = append(pods, BuildTestPod(fmt.Sprintf("test-pod-%v", i), 80, 0))
+	}
+
+	processors := ca_processors.TestProcessors()
+	scaleUpStatus, typedErr := ScaleUp(&context, processors, clusterState, pods, nodes, []*appsv1.DaemonSet{}, nodeInfos)
+
+	assert.NoError(t, typedErr)
+	assert.True(t, scaleUpStatus.WasSuccessful())
+	for _, info := range scaleUpStatus.ScaleUpInfos {
+		assert.NotEqual(t, "ng1", info.Group.Id())
+	}
+
+	groupMap := make(map[string]cloudprovider.NodeGroup, 3)
+	for _, group := range provider.NodeGroups() {
+		groupMap[group.Id()] = group
+	}
+	ng2size, err := groupMap["ng2"].TargetSize()
+	assert.NoError(t, err)
+	ng3size, err := groupMap["ng3"].TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, ng2size+ng3size)
+}
+
 func TestScaleUpAutoprovisionedNodeGroup(t *testing.T) {
 	createdGroups := make(chan string, 10)
 	expandedGroups := make(chan string, 10)
@@ -777,6 +852,98 @@ func TestScaleUpAutoprovisionedNodeGroup(t *testing.T) {
 	assert.Equal(t, "autoprovisioned-T1-1", getStringFromChan(expandedGroups))
 }
 
+func TestScaleUpForDevicePendingPod(t *testing.T) {
+	const gpuMemory = apiv1.ResourceName("example.com/gpu-mem")
+	now := time.Now()
+	buildNode := func(name string, created time.Time) *apiv1.Node {
+		node := BuildTestNode(name, 1000, 1000)
+		node.Status.Allocatable[gpuMemory] = *resource.NewQuantity(10, resource.DecimalSI)
+		node.CreationTimestamp = metav1.NewTime(created)
+		SetNodeReadyState(node, true, created)
+		return node
+	}
+	buildPod := func(name, nodeName string) *apiv1.Pod {
+		pod := BuildTestPod(name, 600, 0)
+		pod.Spec.Containers[0].Resources.Requests[gpuMemory] = *resource.NewQuantity(5, resource.DecimalSI)
+		pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
+		pod.Spec.NodeName = nodeName
+		return pod
+	}
+	n1 := buildNode("n1", now.Add(-time.Hour))
+	// The devices of n1 are too fragmented for the pod scheduled there.
+	stuck := buildPod("stuck", "n1")
+	stuck.Status.Phase = apiv1.PodPending
+	stuck.Status.Conditions = []apiv1.PodCondition{{
+		Type:               apiv1.PodScheduled,
+		Status:             apiv1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+	}}
+	stuck.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+		State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}}
+
+	expandedGroups := make(chan string, 10)
+	evictedPods := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		evictedPods <- createAction.GetObject().(*policyv1.Eviction).Name
+		return true, nil, nil
+	})
+	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
+		expandedGroups <- fmt.Sprintf("%s-%d", nodeGroup, increase)
+		return nil
+	}, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", n1)
+
+	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{stuck})
+	listers := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	options := config.AutoscalingOptions{
+		EstimatorName:                 estimator.BinpackingEstimatorName,
+		MaxCoresTotal:                 config.DefaultMaxClusterCores,
+		MaxMemoryTotal:                config.DefaultMaxClusterMemory,
+		ScaleDownUtilizationThreshold: 0.5,
+		MaxGracefulTerminationSec:     60,
+	}
+	context := NewScaleTestAutoscalingContext(options, fakeClient, listers, provider)
+	processor := pods.NewDevicePendingPodListProcessor(nil, []apiv1.ResourceName{gpuMemory}, 5*time.Minute)
+	processors := ca_processors.TestProcessors()
+	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+
+	// The stuck pod triggers a scale-up.
+	nodes := []*apiv1.Node{n1}
+	unschedulablePods, _, err := processor.Process(&context, nil, []*apiv1.Pod{stuck}, nodes)
+	assert.NoError(t, err)
+	nodeInfos, _ := getNodeInfosForGroups(nodes, nil, provider, listers, []*appsv1.DaemonSet{}, context.PredicateChecker)
+	clusterState.UpdateNodes(nodes, nodeInfos, now)
+	scaleUpStatus, scaleUpErr := ScaleUp(&context, processors, clusterState, unschedulablePods, nodes, []*appsv1.DaemonSet{}, nodeInfos)
+	assert.NoError(t, scaleUpErr)
+	assert.True(t, scaleUpStatus.WasSuccessful())
+	assert.Equal(t, "ng1-1", getStringFromChan(expandedGroups))
+
+	// Once the added node is ready the stuck pod is evicted, instead of triggering another scale-up.
+	n2 := buildNode("n2", now)
+	provider.AddNode("ng1", n2)
+	nodes = []*apiv1.Node{n1, n2}
+	unschedulablePods, _, err = processor.Process(&context, nil, []*apiv1.Pod{stuck}, nodes)
+	assert.NoError(t, err)
+	assert.Empty(t, unschedulablePods)
+	assert.Equal(t, stuck.Name, getStringFromChan(evictedPods))
+
+	// Its replacement keeps the added node from being scaled down as unneeded, n1 is empty instead.
+	replacement := buildPod("replacement", "n2")
+	scaleDown := NewScaleDown(&context, clusterState)
+	scaleDown.UpdateUnneededNodes(nodes, nodes, []*apiv1.Pod{replacement}, now, nil)
+	_, found := scaleDown.unneededNodes["n2"]
+	assert.False(t, found)
+	_, found = scaleDown.unneededNodes["n1"]
+	assert.True(t, found)
+}
+
 func TestCheckScaleUpDeltaWithinLimits(t *testing.T) {
 	type testcase struct {
 		limits            scaleUpResourcesLimits
@@ -825,3 +992,25 @@ func TestCheckScaleUpDeltaWithinLimits(t *testing.T) {
 		}
 	}
 }
+
+type ipCapacityNodeGroup struct {
+	cloudprovider.NodeGroup
+	available int
+	err       error
+}
+
+func (ng *ipCapacityNodeGroup) AvailableIPs() (int, error) {
+	return ng.available, ng.err
+}
+
+func TestNodeGroupSubnetsExhausted(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	ng1 := provider.GetNodeGroup("ng1")
+
+	assert.False(t, nodeGroupSubnetsExhausted(ng1))
+	assert.False(t, nodeGroupSubnetsExhausted(&ipCapacityNodeGroup{NodeGroup: ng1, available: 5}))
+	assert.True(t, nodeGroupSubnetsExhausted(&ipCapacityNodeGroup{NodeGroup: ng1, available: 0}))
+	assert.False(t, nodeGroupSubnetsExhausted(&ipCapacityNodeGroup{NodeGroup: ng1, err: cloudprovider.ErrNotImplemented}))
+	assert.False(t, nodeGroupSubnetsExhausted(&ipCapacityNodeGroup{NodeGroup: ng1, err: fmt.Errorf("api error")}))
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+)
+
+// clusterStateChecksum computes a checksum of the parts of the cluster state autoscaling decisions
+// depend on: the nodes with their readiness and taints, the pods with the nodes they are bound to
+// and the target sizes of the node groups. It doesn't depend on the order of the lists.
+func clusterStateChecksum(nodes []*apiv1.Node, unschedulablePods, scheduledPods []*apiv1.Pod,
+	nodeGroups []cloudprovider.NodeGroup) uint64 {
+	entries := make([]string, 0, len(nodes)+len(unschedulablePods)+len(scheduledPods)+len(nodeGroups))
+	for _, node := range nodes {
+		ready, _, _ := kube_util.GetReadinessState(node)
+		entry := fmt.Sprintf("node/%s/%v/%v", node.Name, ready, node.Spec.Unschedulable)
+		for _, taint := range node.Spec.Taints {
+			entry += fmt.Sprintf("/%s=%s:%s", taint.Key, taint.Value, taint.Effect)
+		}
+		entries = append(entries, entry)
+	}
+	for _, pod := range unschedulablePods {
+		entries = append(entries, fmt.Sprintf("pod/%s/", pod.UID))
+	}
+	for _, pod := range scheduledPods {
+		entries = append(entries, fmt.Sprintf("pod/%s/%s", pod.UID, pod.Spec.NodeName))
+	}
+	for _, nodeGroup := range nodeGroups {
+		// Node groups whose size can't be read are included with an invalid size.
+		size, err := nodeGroup.TargetSize()
+		if err != nil {
+			size = -1
+		}
+		entries = append(entries, fmt.Sprintf("group/%s/%d", nodeGroup.Id(), size))
+	}
+	sort.Strings(entries)
+
+	hash := fnv.New64a()
+	for _, entry := range entries {
+		hash.Write([]byte(entry))
+		hash.Write([]byte{0})
+	}
+	return hash.Sum64()
+}
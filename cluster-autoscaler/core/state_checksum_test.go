@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterStateChecksum(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 2)
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Now())
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Now())
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.UID = types.UID("p1")
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 100, 0)
+	p2.UID = types.UID("p2")
+
+	checksum := func(nodes []*apiv1.Node, unschedulable, scheduled []*apiv1.Pod) uint64 {
+		return clusterStateChecksum(nodes, unschedulable, scheduled, provider.NodeGroups())
+	}
+	base := checksum([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1})
+
+	// The order of the lists doesn't matter.
+	assert.Equal(t, base, checksum([]*apiv1.Node{n2, n1}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1}))
+
+	// Nodes, pods and their binding are all part of the state.
+	assert.NotEqual(t, base, checksum([]*apiv1.Node{n1}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1}))
+	assert.NotEqual(t, base, checksum([]*apiv1.Node{n1, n2}, []*apiv1.Pod{}, []*apiv1.Pod{p1}))
+	p1Moved := p1.DeepCopy()
+	p1Moved.Spec.NodeName = "n2"
+	assert.NotEqual(t, base, checksum([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1Moved}))
+
+	// So are node readiness and taints.
+	n2Unready := n2.DeepCopy()
+	SetNodeReadyState(n2Unready, false, time.Now())
+	assert.NotEqual(t, base, checksum([]*apiv1.Node{n1, n2Unready}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1}))
+	n2Tainted := n2.DeepCopy()
+	n2Tainted.Spec.Taints = []apiv1.Taint{{Key: "dedicated", Value: "db", Effect: apiv1.TaintEffectNoSchedule}}
+	assert.NotEqual(t, base, checksum([]*apiv1.Node{n1, n2Tainted}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1}))
+
+	// And node group target sizes.
+	provider.GetNodeGroup("ng1").(*testprovider.TestNodeGroup).SetTargetSize(3)
+	assert.NotEqual(t, base, checksum([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p2}, []*apiv1.Pod{p1}))
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -34,10 +35,13 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/daemonset"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/tpu"
+	v1lister "k8s.io/client-go/listers/core/v1"
 
 	"k8s.io/klog"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
@@ -69,6 +73,25 @@ type StaticAutoscaler struct {
 	initialized             bool
 	// Caches nodeInfo computed for previously seen nodes
 	nodeInfoCache map[string]*schedulernodeinfo.NodeInfo
+	// Identifies the options this autoscaler was started with, see ConfigGenerationAnnotationKey.
+	configGeneration string
+	// Guards scaleDown and the scale-up/scale-down timestamps, which are shared
+	// between the control-loop and the export and import of the runtime state.
+	scaleDownMutex sync.Mutex
+	// Excludes pods that repeatedly triggered scale-ups without becoming schedulable.
+	podQuarantine *podQuarantine
+	// Excludes pods requesting more resources than any node group can offer.
+	oversizedPods *oversizedPods
+	// Measures the time pending pods take to be scheduled on nodes added by CA.
+	podSchedulingLatency *podSchedulingLatency
+	// Checksum of the cluster state at the end of the last iteration that had nothing left to do,
+	// and the time of that iteration. Zero time if the last full iteration wasn't such an iteration.
+	lastQuietStateChecksum uint64
+	lastQuietStateTime     time.Time
+	// Lists the ConfigMaps of the CA namespace, e.g. the rebalance ConfigMap.
+	configMapLister v1lister.ConfigMapNamespaceLister
+	// Stops the informers of the listers built for the autoscaler, closed by ExitCleanUp.
+	stopChannel chan struct{}
 }
 
 // NewStaticAutoscaler creates an instance of Autoscaler filled with provided parameters
@@ -80,17 +103,20 @@ func NewStaticAutoscaler(
 	cloudProvider cloudprovider.CloudProvider,
 	expanderStrategy expander.Strategy,
 	estimatorBuilder estimator.EstimatorBuilder,
-	backoff backoff.Backoff) *StaticAutoscaler {
+	backoff backoff.Backoff,
+	configMapLister v1lister.ConfigMapNamespaceLister) *StaticAutoscaler {
 	autoscalingContext := context.NewAutoscalingContext(opts, predicateChecker, autoscalingKubeClients, cloudProvider, expanderStrategy, estimatorBuilder)
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
 		MaxTotalUnreadyPercentage: opts.MaxTotalUnreadyPercentage,
 		OkTotalUnreadyCount:       opts.OkTotalUnreadyCount,
 		MaxNodeProvisionTime:      opts.MaxNodeProvisionTime,
+		LookUpGhostNodes:          opts.GhostNodeDeletionGracePeriod > 0,
 	}
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(autoscalingContext.CloudProvider, clusterStateConfig, autoscalingContext.LogRecorder, backoff)
 
 	scaleDown := NewScaleDown(autoscalingContext, clusterStateRegistry)
+	scaleDown.nodeBusynessProvider = processors.NodeBusynessProvider
 
 	return &StaticAutoscaler{
 		AutoscalingContext:      autoscalingContext,
@@ -102,6 +128,11 @@ func NewStaticAutoscaler(
 		processors:              processors,
 		clusterStateRegistry:    clusterStateRegistry,
 		nodeInfoCache:           make(map[string]*schedulernodeinfo.NodeInfo),
+		podQuarantine:           newPodQuarantine(opts.MaxPodScaleUpAttempts),
+		oversizedPods:           newOversizedPods(),
+		podSchedulingLatency:    newPodSchedulingLatency(opts.MaxNodeProvisionTime),
+		configGeneration:        configGeneration(opts),
+		configMapLister:         configMapLister,
 	}
 }
 
@@ -116,7 +147,8 @@ func (a *StaticAutoscaler) cleanUpIfRequired() {
 	if readyNodes, err := a.ReadyNodeLister().List(); err != nil {
 		klog.Errorf("Failed to list ready nodes, not cleaning up taints: %v", err)
 	} else {
-		deletetaint.CleanAllToBeDeleted(readyNodes, a.AutoscalingContext.ClientSet, a.Recorder)
+		// Nodes drained for removal by an external system keep their taint.
+		deletetaint.CleanAllToBeDeleted(filterOutDrainedForRemoval(readyNodes), a.AutoscalingContext.ClientSet, a.Recorder)
 		if a.AutoscalingContext.AutoscalingOptions.MaxBulkSoftTaintCount == 0 {
 			// Clean old taints if soft taints handling is disabled
 			deletetaint.CleanAllDeletionCandidates(readyNodes, a.AutoscalingContext.ClientSet, a.Recorder)
@@ -125,6 +157,24 @@ func (a *StaticAutoscaler) cleanUpIfRequired() {
 	a.initialized = true
 }
 
+// cleanUpStaleTaints removes ToBeDeleted and DeletionCandidate taints older than StaleTaintCleanupAge, which
+// are left when CA crashes or loses leadership in the middle of a scale-down. Taints this instance still
+// relies on are kept: ToBeDeleted while a deletion is in progress or on nodes drained for removal, and
+// DeletionCandidate on nodes that are unneeded.
+func (a *StaticAutoscaler) cleanUpStaleTaints(allNodes []*apiv1.Node, currentTime time.Time) {
+	addedBefore := currentTime.Add(-a.StaleTaintCleanupAge)
+	if !a.scaleDown.nodeDeleteStatus.IsDeleteInProgress() {
+		deletetaint.CleanAllStaleToBeDeleted(filterOutDrainedForRemoval(allNodes), a.ClientSet, a.Recorder, addedBefore)
+	}
+	notUnneeded := make([]*apiv1.Node, 0, len(allNodes))
+	for _, node := range allNodes {
+		if _, found := a.scaleDown.unneededNodes[node.Name]; !found {
+			notUnneeded = append(notUnneeded, node)
+		}
+	}
+	deletetaint.CleanAllStaleDeletionCandidates(notUnneeded, a.ClientSet, a.Recorder, addedBefore)
+}
+
 // RunOnce iterates over node groups and scales them up/down if necessary
 func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError {
 	a.cleanUpIfRequired()
@@ -159,6 +209,16 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		return errors.ToAutoscalerError(errors.CloudProviderError, err)
 	}
 
+	if a.processors != nil && a.processors.NodeGroupSpecProcessor != nil {
+		if typedErr := a.processors.NodeGroupSpecProcessor.Process(autoscalingContext); typedErr != nil {
+			klog.Errorf("Failed to process node group specs: %v", typedErr)
+		}
+	}
+
+	if autoscalingContext.AnnotateNodesWithNodeGroup {
+		annotateNodesWithNodeGroup(autoscalingContext, allNodes, a.configGeneration)
+	}
+
 	nodeInfosForGroups, autoscalerError := getNodeInfosForGroups(
 		readyNodes, a.nodeInfoCache, autoscalingContext.CloudProvider, autoscalingContext.ListerRegistry, daemonsets, autoscalingContext.PredicateChecker)
 	if autoscalerError != nil {
@@ -180,8 +240,8 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		// Update status information when the loop is done (regardless of reason)
 		if autoscalingContext.WriteStatusConfigMap {
 			status := a.clusterStateRegistry.GetStatus(currentTime)
-			utils.WriteStatusConfigMap(autoscalingContext.ClientSet, autoscalingContext.ConfigNamespace,
-				status.GetReadableString(), a.AutoscalingContext.LogRecorder)
+			utils.WriteStructuredStatusConfigMap(autoscalingContext.ClientSet, autoscalingContext.ConfigNamespace,
+				status, a.AutoscalingContext.LogRecorder)
 		}
 
 		// This deferred processor execution allows the processors to handle a situation when a scale-(up|down)
@@ -199,6 +259,17 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		}
 	}()
 
+	// Cluster state and metrics are kept up to date while paused, but nothing is scaled.
+	paused := isAutoscalingPaused(autoscalingContext.ClientSet, autoscalingContext.ConfigNamespace, currentTime)
+	metrics.UpdateAutoscalingPaused(paused)
+	if paused {
+		klog.V(1).Infof("Autoscaling is paused by %s/%s", autoscalingContext.ConfigNamespace, PauseConfigMapName)
+		a.scaleDownMutex.Lock()
+		scaleDown.CleanUpUnneededNodes()
+		a.scaleDownMutex.Unlock()
+		return nil
+	}
+
 	// Check if there are any nodes that failed to register in Kubernetes
 	// master.
 	unregisteredNodes := a.clusterStateRegistry.GetUnregisteredNodes()
@@ -222,15 +293,47 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		}
 	}
 
+	// Check if there are any nodes whose instance was deleted in the cloud
+	// provider.
+	ghostNodes := a.clusterStateRegistry.GetGhostNodes()
+	if len(ghostNodes) > 0 {
+		klog.V(1).Infof("%d nodes without cloud provider instance present", len(ghostNodes))
+		if a.GhostNodeDeletionGracePeriod > 0 {
+			removedAny, err := removeOldGhostNodes(ghostNodes, autoscalingContext, currentTime, autoscalingContext.LogRecorder)
+			// There was a problem with removing ghost nodes. Retry in the next loop.
+			if err != nil {
+				if removedAny {
+					klog.Warningf("Some nodes without instance were removed, but got error: %v", err)
+				} else {
+					klog.Errorf("Failed to remove nodes without instance: %v", err)
+				}
+				return errors.ToAutoscalerError(errors.ApiCallError, err)
+			}
+			// Some nodes were removed. Let's skip this iteration, the next one should be better.
+			if removedAny {
+				klog.V(0).Infof("Some nodes without instance were removed, skipping iteration")
+				return nil
+			}
+		}
+	}
+
 	if !a.clusterStateRegistry.IsClusterHealthy() {
 		klog.Warning("Cluster is not ready for autoscaling")
+		a.scaleDownMutex.Lock()
 		scaleDown.CleanUpUnneededNodes()
+		a.scaleDownMutex.Unlock()
 		autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeWarning, "ClusterUnhealthy", "Cluster is unhealthy")
 		return nil
 	}
 
 	a.deleteCreatedNodesWithErrors()
 
+	if a.StaleTaintCleanupAge > 0 {
+		a.scaleDownMutex.Lock()
+		a.cleanUpStaleTaints(allNodes, currentTime)
+		a.scaleDownMutex.Unlock()
+	}
+
 	// Check if there has been a constant difference between the number of nodes in k8s and
 	// the number of nodes on the cloud provider side.
 	// TODO: andrewskim - add protection for ready AWS nodes.
@@ -252,12 +355,28 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		return errors.ToAutoscalerError(errors.ApiCallError, err)
 	}
 	metrics.UpdateUnschedulablePodsCount(len(allUnschedulablePods))
+	a.podQuarantine.update(allUnschedulablePods)
+	a.oversizedPods.update(allUnschedulablePods)
 
 	allScheduled, err := scheduledPodLister.List()
 	if err != nil {
 		klog.Errorf("Failed to list scheduled pods: %v", err)
 		return errors.ToAutoscalerError(errors.ApiCallError, err)
 	}
+	a.podSchedulingLatency.update(allUnschedulablePods, allScheduled, allNodes, autoscalingContext.CloudProvider, currentTime)
+
+	var stateChecksum uint64
+	if a.UnchangedStateSkipDuration > 0 {
+		stateChecksum = clusterStateChecksum(allNodes, allUnschedulablePods, allScheduled, autoscalingContext.CloudProvider.NodeGroups())
+		if !a.lastQuietStateTime.IsZero() && stateChecksum == a.lastQuietStateChecksum &&
+			currentTime.Before(a.lastQuietStateTime.Add(a.UnchangedStateSkipDuration)) {
+			klog.V(1).Info("Cluster state unchanged since the last iteration with nothing to do, skipping iteration")
+			scaleUpStatus.Result = status.ScaleUpNotNeeded
+			scaleDownStatus.Result = status.ScaleDownNoUnneeded
+			return nil
+		}
+		a.lastQuietStateTime = time.Time{}
+	}
 
 	allUnschedulablePods, allScheduled, err = a.processors.PodListProcessor.Process(a.AutoscalingContext, allUnschedulablePods, allScheduled, allNodes)
 	if err != nil {
@@ -312,6 +431,9 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 
 	// finally, filter out pods that are too "young" to safely be considered for a scale-up (delay is configurable)
 	unschedulablePodsToHelp = a.filterOutYoungPods(unschedulablePodsToHelp, currentTime)
+	unschedulablePodsToHelp = a.podQuarantine.filterOutQuarantined(unschedulablePodsToHelp)
+	unschedulablePodsToHelp = a.oversizedPods.filterOutOversized(unschedulablePodsToHelp, autoscalingContext.CloudProvider.NodeGroups(),
+		nodeInfosForGroups, a.NodeAutoprovisioningEnabled, a.Recorder)
 
 	if len(unschedulablePodsToHelp) == 0 {
 		scaleUpStatus.Result = status.ScaleUpNotNeeded
@@ -345,7 +467,25 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 			return typedErr
 		}
 		if scaleUpStatus.Result == status.ScaleUpSuccessful {
+			a.podQuarantine.registerScaleUp(scaleUpStatus.PodsTriggeredScaleUp, a.Recorder)
+			a.podSchedulingLatency.registerScaleUp(scaleUpStatus.ScaleUpInfos, currentTime)
+			a.scaleDownMutex.Lock()
 			a.lastScaleUpTime = currentTime
+			a.scaleDownMutex.Unlock()
+			// No scale down in this iteration.
+			scaleDownStatus.Result = status.ScaleDownInCooldown
+			return nil
+		}
+	}
+
+	// Rebalancing removes nodes, so it's only done when scale down is enabled.
+	if !scaleDownForbidden && a.ScaleDownEnabled {
+		moved, typedErr := a.rebalance(allNodes, allScheduled, nodeInfosForGroups, currentTime)
+		if typedErr != nil {
+			klog.Errorf("Failed to rebalance: %v", typedErr)
+			return typedErr
+		}
+		if moved {
 			// No scale down in this iteration.
 			scaleDownStatus.Result = status.ScaleDownInCooldown
 			return nil
@@ -353,6 +493,9 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 	}
 
 	if a.ScaleDownEnabled {
+		a.scaleDownMutex.Lock()
+		defer a.scaleDownMutex.Unlock()
+
 		pdbs, err := pdbLister.List()
 		if err != nil {
 			scaleDownStatus.Result = status.ScaleDownError
@@ -367,7 +510,16 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		scaleDown.CleanUp(currentTime)
 		potentiallyUnneeded := getPotentiallyUnneededNodes(autoscalingContext, allNodes)
 
-		typedErr := scaleDown.UpdateUnneededNodes(allNodes, potentiallyUnneeded, append(allScheduled, unschedulableWaitingForLowerPriorityPreemption...), currentTime, pdbs)
+		scaleDownPods := allScheduled
+		if a.DaemonSetRolloutHeadroom {
+			reservations := daemonset.GetRolloutReservationPods(daemonsets, allScheduled, allNodes, autoscalingContext.PredicateChecker)
+			if len(reservations) > 0 {
+				klog.V(2).Infof("Keeping room for %d pods of DaemonSets being rolled out", len(reservations))
+				scaleDownPods = append(append([]*apiv1.Pod{}, allScheduled...), reservations...)
+			}
+		}
+
+		typedErr := scaleDown.UpdateUnneededNodes(allNodes, potentiallyUnneeded, append(scaleDownPods, unschedulableWaitingForLowerPriorityPreemption...), currentTime, pdbs)
 		if typedErr != nil {
 			scaleDownStatus.Result = status.ScaleDownError
 			klog.Errorf("Failed to scale down: %v", typedErr)
@@ -407,7 +559,7 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 
 			scaleDownStart := time.Now()
 			metrics.UpdateLastTime(metrics.ScaleDown, scaleDownStart)
-			scaleDownStatus, typedErr := scaleDown.TryToScaleDown(allNodes, allScheduled, pdbs, currentTime)
+			scaleDownStatus, typedErr := scaleDown.TryToScaleDown(allNodes, scaleDownPods, pdbs, currentTime)
 			metrics.UpdateDurationFromStart(metrics.ScaleDown, scaleDownStart)
 
 			if scaleDownStatus.Result == status.ScaleDownNodeDeleted {
@@ -415,6 +567,13 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 				a.clusterStateRegistry.Recalculate()
 			}
 
+			if typedErr == nil && (scaleDownStatus.Result == status.ScaleDownNoNodeDeleted ||
+				scaleDownStatus.Result == status.ScaleDownNoUnneeded) {
+				if _, compactionErr := scaleDown.TryToCompact(allNodes, scaleDownPods, currentTime); compactionErr != nil {
+					klog.Errorf("Failed to compact: %v", compactionErr)
+				}
+			}
+
 			if (scaleDownStatus.Result == status.ScaleDownNoNodeDeleted ||
 				scaleDownStatus.Result == status.ScaleDownNoUnneeded) &&
 				a.AutoscalingContext.AutoscalingOptions.MaxBulkSoftTaintCount != 0 {
@@ -433,6 +592,75 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 			}
 		}
 	}
+
+	// Nothing is pending, so later iterations can be skipped as long as the state stays the same.
+	if a.UnchangedStateSkipDuration > 0 && scaleUpStatus.Result == status.ScaleUpNotNeeded &&
+		len(scaleDown.unneededNodes) == 0 && !scaleDown.nodeDeleteStatus.IsDeleteInProgress() {
+		a.lastQuietStateChecksum = stateChecksum
+		a.lastQuietStateTime = currentTime
+	}
+	return nil
+}
+
+// RunEmptyNodeCleanup removes empty nodes that have been unneeded for long enough without waiting
+// for the next RunOnce. It only acts when RunOnce would be allowed to scale down, and leaves
+// the cluster alone while there are pods waiting for scale-up. It must be run between the calls
+// to RunOnce, as RunOnce adds nodes without holding scaleDownMutex.
+func (a *StaticAutoscaler) RunEmptyNodeCleanup(currentTime time.Time) errors.AutoscalerError {
+	if !a.ScaleDownEnabled {
+		return nil
+	}
+
+	a.scaleDownMutex.Lock()
+	defer a.scaleDownMutex.Unlock()
+
+	if !a.clusterStateRegistry.IsClusterHealthy() {
+		return nil
+	}
+	if isAutoscalingPaused(a.ClientSet, a.ConfigNamespace, currentTime) {
+		return nil
+	}
+	if a.lastScaleUpTime.Add(a.ScaleDownDelayAfterAdd).After(currentTime) ||
+		a.lastScaleDownFailTime.Add(a.ScaleDownDelayAfterFailure).After(currentTime) ||
+		a.lastScaleDownDeleteTime.Add(a.ScaleDownDelayAfterDelete).After(currentTime) ||
+		a.scaleDown.nodeDeleteStatus.IsDeleteInProgress() {
+		return nil
+	}
+
+	unschedulablePods, err := a.UnschedulablePodLister().List()
+	if err != nil {
+		klog.Errorf("Failed to list unscheduled pods: %v", err)
+		return errors.ToAutoscalerError(errors.ApiCallError, err)
+	}
+	if len(filterOutExpendablePods(unschedulablePods, a.ExpendablePodsPriorityCutoff)) > 0 {
+		klog.V(4).Infof("Skipping empty node cleanup - unschedulable pods present")
+		return nil
+	}
+
+	allNodes, err := a.AllNodeLister().List()
+	if err != nil {
+		klog.Errorf("Failed to list all nodes: %v", err)
+		return errors.ToAutoscalerError(errors.ApiCallError, err)
+	}
+	allScheduled, err := a.ScheduledPodLister().List()
+	if err != nil {
+		klog.Errorf("Failed to list scheduled pods: %v", err)
+		return errors.ToAutoscalerError(errors.ApiCallError, err)
+	}
+
+	scaleDownStatus, typedErr := a.scaleDown.TryToScaleDownEmpty(allNodes, allScheduled, currentTime)
+	if scaleDownStatus.Result == status.ScaleDownNodeDeleted {
+		a.lastScaleDownDeleteTime = currentTime
+		a.clusterStateRegistry.Recalculate()
+	}
+	if a.processors != nil && a.processors.ScaleDownStatusProcessor != nil {
+		a.processors.ScaleDownStatusProcessor.Process(a.AutoscalingContext, scaleDownStatus)
+	}
+	if typedErr != nil {
+		klog.Errorf("Failed to remove empty nodes: %v", typedErr)
+		a.lastScaleDownFailTime = currentTime
+		return typedErr
+	}
 	return nil
 }
 
@@ -465,7 +693,7 @@ func (a *StaticAutoscaler) deleteCreatedNodesWithErrors() {
 		if nodeGroup == nil {
 			err = fmt.Errorf("Node group %s not found", nodeGroup)
 		} else {
-			err = nodeGroup.DeleteNodes(nodesToBeDeleted)
+			err = a.NodeGroupOperations.Do(nodeGroupId, func() error { return nodeGroup.DeleteNodes(nodesToBeDeleted) })
 		}
 
 		if err != nil {
@@ -501,6 +729,9 @@ func (a *StaticAutoscaler) filterOutYoungPods(allUnschedulablePods []*apiv1.Pod,
 // ExitCleanUp performs all necessary clean-ups when the autoscaler's exiting.
 func (a *StaticAutoscaler) ExitCleanUp() {
 	a.processors.CleanUp()
+	if a.stopChannel != nil {
+		close(a.stopChannel)
+	}
 
 	if !a.AutoscalingContext.WriteStatusConfigMap {
 		return
@@ -525,7 +756,23 @@ func (a *StaticAutoscaler) obtainNodeLists() ([]*apiv1.Node, []*apiv1.Node, erro
 	// Treat those nodes as unready until GPU actually becomes available and let
 	// our normal handling for booting up nodes deal with this.
 	// TODO: Remove this call when we handle dynamically provisioned resources.
-	allNodes, readyNodes = gpu.FilterOutNodesWithUnreadyGpus(allNodes, readyNodes)
+	allNodes, readyNodes = gpu.FilterOutNodesWithUnreadyGpus(gpu.GetGpuLabel(a.CloudProvider), allNodes, readyNodes)
+
+	// Nodes still carrying startup taints are not initialized yet, treat them as unready too.
+	if a.RemoveUninitializedTaint {
+		deletetaint.CleanAllUninitialized(readyNodes, a.ClientSet, a.Recorder)
+	}
+	allNodes, readyNodes = deletetaint.FilterOutNodesWithStartupTaints(allNodes, readyNodes,
+		func(node *apiv1.Node) []string {
+			return getNodeStartupTaints(a.AutoscalingContext, node)
+		})
+
+	// Nodes not passing the readiness gates of their node group (e.g. CNI or device plugin not
+	// ready) don't deliver capacity yet either.
+	allNodes, readyNodes = kube_util.FilterOutNodesFailingReadinessGates(allNodes, readyNodes,
+		func(node *apiv1.Node) ([]string, []string) {
+			return getNodeReadinessGates(a.AutoscalingContext, node)
+		})
 	return allNodes, readyNodes, nil
 }
 
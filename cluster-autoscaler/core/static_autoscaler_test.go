@@ -28,8 +28,10 @@ import (
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 
@@ -137,6 +139,17 @@ func (m *onNodeGroupDeleteMock) Delete(id string) error {
 	return args.Error(0)
 }
 
+type scaleDownStatusProcessorMock struct {
+	results []status.ScaleDownResult
+}
+
+func (p *scaleDownStatusProcessorMock) Process(context *context.AutoscalingContext, status *status.ScaleDownStatus) {
+	p.results = append(p.results, status.Result)
+}
+
+func (p *scaleDownStatusProcessorMock) CleanUp() {
+}
+
 func TestStaticAutoscalerRunOnce(t *testing.T) {
 	readyNodeListerMock := &nodeListerMock{}
 	allNodeListerMock := &nodeListerMock{}
@@ -190,7 +203,7 @@ func TestStaticAutoscalerRunOnce(t *testing.T) {
 	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
 	listerRegistry := kube_util.NewListerRegistry(allNodeListerMock, readyNodeListerMock, scheduledPodMock,
 		unschedulablePodMock, podDisruptionBudgetListerMock, daemonSetListerMock,
-		nil, nil, nil, nil)
+		nil, nil, nil, nil, nil, nil)
 	context.ListerRegistry = listerRegistry
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
@@ -202,6 +215,7 @@ func TestStaticAutoscalerRunOnce(t *testing.T) {
 	sd := NewScaleDown(&context, clusterState)
 
 	autoscaler := &StaticAutoscaler{
+		configMapLister:       newTestConfigMapLister(t),
 		AutoscalingContext:    &context,
 		clusterStateRegistry:  clusterState,
 		lastScaleUpTime:       time.Now(),
@@ -374,7 +388,7 @@ func TestStaticAutoscalerRunOnceWithAutoprovisionedEnabled(t *testing.T) {
 	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
 	listerRegistry := kube_util.NewListerRegistry(allNodeListerMock, readyNodeListerMock, scheduledPodMock,
 		unschedulablePodMock, podDisruptionBudgetListerMock, daemonSetListerMock,
-		nil, nil, nil, nil)
+		nil, nil, nil, nil, nil, nil)
 	context.ListerRegistry = listerRegistry
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
@@ -386,6 +400,7 @@ func TestStaticAutoscalerRunOnceWithAutoprovisionedEnabled(t *testing.T) {
 	sd := NewScaleDown(&context, clusterState)
 
 	autoscaler := &StaticAutoscaler{
+		configMapLister:       newTestConfigMapLister(t),
 		AutoscalingContext:    &context,
 		clusterStateRegistry:  clusterState,
 		lastScaleUpTime:       time.Now(),
@@ -503,7 +518,7 @@ func TestStaticAutoscalerRunOnceWithALongUnregisteredNode(t *testing.T) {
 	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
 	listerRegistry := kube_util.NewListerRegistry(allNodeListerMock, readyNodeListerMock, scheduledPodMock,
 		unschedulablePodMock, podDisruptionBudgetListerMock, daemonSetListerMock,
-		nil, nil, nil, nil)
+		nil, nil, nil, nil, nil, nil)
 	context.ListerRegistry = listerRegistry
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
@@ -523,6 +538,7 @@ func TestStaticAutoscalerRunOnceWithALongUnregisteredNode(t *testing.T) {
 	sd := NewScaleDown(&context, clusterState)
 
 	autoscaler := &StaticAutoscaler{
+		configMapLister:       newTestConfigMapLister(t),
 		AutoscalingContext:    &context,
 		clusterStateRegistry:  clusterState,
 		lastScaleUpTime:       time.Now(),
@@ -635,7 +651,7 @@ func TestStaticAutoscalerRunOncePodsWithFilterOutSchedulablePodsUsesPackingFalse
 	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
 	listerRegistry := kube_util.NewListerRegistry(allNodeListerMock, readyNodeListerMock, scheduledPodMock,
 		unschedulablePodMock, podDisruptionBudgetListerMock, daemonSetListerMock,
-		nil, nil, nil, nil)
+		nil, nil, nil, nil, nil, nil)
 	context.ListerRegistry = listerRegistry
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
@@ -647,6 +663,7 @@ func TestStaticAutoscalerRunOncePodsWithFilterOutSchedulablePodsUsesPackingFalse
 	sd := NewScaleDown(&context, clusterState)
 
 	autoscaler := &StaticAutoscaler{
+		configMapLister:       newTestConfigMapLister(t),
 		AutoscalingContext:    &context,
 		clusterStateRegistry:  clusterState,
 		lastScaleUpTime:       time.Now(),
@@ -750,7 +767,7 @@ func TestStaticAutoscalerRunOncePodsWithPriorities(t *testing.T) {
 	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider)
 	listerRegistry := kube_util.NewListerRegistry(allNodeListerMock, readyNodeListerMock, scheduledPodMock,
 		unschedulablePodMock, podDisruptionBudgetListerMock, daemonSetListerMock,
-		nil, nil, nil, nil)
+		nil, nil, nil, nil, nil, nil)
 	context.ListerRegistry = listerRegistry
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
@@ -762,6 +779,7 @@ func TestStaticAutoscalerRunOncePodsWithPriorities(t *testing.T) {
 	sd := NewScaleDown(&context, clusterState)
 
 	autoscaler := &StaticAutoscaler{
+		configMapLister:       newTestConfigMapLister(t),
 		AutoscalingContext:    &context,
 		clusterStateRegistry:  clusterState,
 		lastScaleUpTime:       time.Now(),
@@ -816,6 +834,77 @@ func TestStaticAutoscalerRunOncePodsWithPriorities(t *testing.T) {
 		podDisruptionBudgetListerMock, daemonSetListerMock, onScaleUpMock, onScaleDownMock)
 }
 
+func TestStaticAutoscalerRunEmptyNodeCleanup(t *testing.T) {
+	allNodeListerMock := &nodeListerMock{}
+	scheduledPodMock := &podListerMock{}
+	unschedulablePodMock := &podListerMock{}
+	onScaleDownMock := &onScaleDownMock{}
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Now())
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Now())
+
+	p1 := BuildTestPod("p1", 600, 100)
+	p1.Spec.NodeName = "n1"
+
+	provider := testprovider.NewTestCloudProvider(nil, func(id string, name string) error {
+		return onScaleDownMock.ScaleDown(id, name)
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	options := config.AutoscalingOptions{
+		ScaleDownEnabled:              true,
+		ScaleDownUtilizationThreshold: 0.5,
+		ScaleDownUnneededTime:         time.Minute,
+		MaxEmptyBulkDelete:            10,
+		MaxNodesTotal:                 10,
+		MaxCoresTotal:                 10,
+		MaxMemoryTotal:                100000,
+	}
+	context := NewScaleTestAutoscalingContext(options, fake.NewSimpleClientset(n1, n2), nil, provider)
+	context.ListerRegistry = kube_util.NewListerRegistry(allNodeListerMock, nil, scheduledPodMock,
+		unschedulablePodMock, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{
+		OkTotalUnreadyCount: 1,
+	}, context.LogRecorder, newBackoff())
+	now := time.Now()
+	assert.NoError(t, clusterState.UpdateNodes([]*apiv1.Node{n1, n2}, nil, now))
+
+	processors := ca_processors.TestProcessors()
+	scaleDownStatusProcessor := &scaleDownStatusProcessorMock{}
+	processors.ScaleDownStatusProcessor = scaleDownStatusProcessor
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext:      &context,
+		clusterStateRegistry:    clusterState,
+		lastScaleUpTime:         now,
+		lastScaleDownFailTime:   now,
+		lastScaleDownDeleteTime: now,
+		scaleDown:               NewScaleDown(&context, clusterState),
+		processors:              processors,
+		initialized:             true,
+	}
+
+	// The empty node is marked as unneeded.
+	allNodeListerMock.On("List").Return([]*apiv1.Node{n1, n2}, nil).Twice()
+	scheduledPodMock.On("List").Return([]*apiv1.Pod{p1}, nil).Twice()
+	unschedulablePodMock.On("List").Return([]*apiv1.Pod{}, nil).Twice()
+
+	err := autoscaler.RunEmptyNodeCleanup(now)
+	assert.NoError(t, err)
+
+	// Once it has been unneeded for long enough it is removed, and the removal is reported.
+	onScaleDownMock.On("ScaleDown", "ng1", "n2").Return(nil).Once()
+
+	err = autoscaler.RunEmptyNodeCleanup(now.Add(time.Hour))
+	assert.NoError(t, err)
+	mock.AssertExpectationsForObjects(t, allNodeListerMock, scheduledPodMock, unschedulablePodMock, onScaleDownMock)
+	assert.Equal(t, []status.ScaleDownResult{status.ScaleDownNoNodeDeleted, status.ScaleDownNodeDeleted}, scaleDownStatusProcessor.results)
+}
+
 func TestStaticAutoscalerOutOfResources(t *testing.T) {
 
 	// setup
@@ -843,6 +932,7 @@ func TestStaticAutoscalerOutOfResources(t *testing.T) {
 
 	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterStateConfig, context.LogRecorder, newBackoff())
 	autoscaler := &StaticAutoscaler{
+		configMapLister:       newTestConfigMapLister(t),
 		AutoscalingContext:    &context,
 		clusterStateRegistry:  clusterState,
 		lastScaleUpTime:       time.Now(),
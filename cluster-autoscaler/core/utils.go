@@ -18,6 +18,7 @@ package core
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"reflect"
 	"sort"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
@@ -43,6 +45,8 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 
 	"k8s.io/klog"
@@ -51,6 +55,11 @@ import (
 const (
 	// ReschedulerTaintKey is the name of the taint created by rescheduler.
 	ReschedulerTaintKey = "CriticalAddonsOnly"
+	// NodeGroupAnnotationKey is the annotation holding the id of the node group the node belongs to.
+	NodeGroupAnnotationKey = "cluster-autoscaler.kubernetes.io/node-group"
+	// ConfigGenerationAnnotationKey is the annotation holding the generation of the autoscaler config
+	// that was in use when the node joined the cluster.
+	ConfigGenerationAnnotationKey = "cluster-autoscaler.kubernetes.io/config-generation"
 )
 
 // Following data structure is used to avoid running predicates #pending_pods * #nodes
@@ -207,39 +216,29 @@ func filterOutExpendablePods(pods []*apiv1.Pod, expendablePodsPriorityCutoff int
 	return result
 }
 
-// checkPodsSchedulableOnNode checks if pods can be scheduled on the given node.
-func checkPodsSchedulableOnNode(context *context.AutoscalingContext, pods []*apiv1.Pod, nodeGroupId string, nodeInfo *schedulernodeinfo.NodeInfo) map[*apiv1.Pod]*simulator.PredicateError {
+// checkPodsSchedulableOnNode checks if pods can be scheduled on the given node. Predicates are only
+// checked once per pod equivalence group and the result is applied to all pods of the group.
+func checkPodsSchedulableOnNode(context *context.AutoscalingContext, podEquivalenceGroups []*podEquivalenceGroup, nodeGroupId string, nodeInfo *schedulernodeinfo.NodeInfo) map[*apiv1.Pod]*simulator.PredicateError {
 	schedulingErrors := map[*apiv1.Pod]*simulator.PredicateError{}
 	loggingQuota := glogx.PodsLoggingQuota()
-	podSchedulable := make(podSchedulableMap)
 
-	for _, pod := range pods {
-		// Check if pod isn't repeated before overwriting result for it.
-		if _, repeated := schedulingErrors[pod]; repeated {
-			// This shouldn't really happen.
-			klog.Warningf("Pod %v appears multiple time on pods list, will only count it once in scale-up simulation", pod)
-		}
-		// Try to get result from cache.
-		err, found := podSchedulable.get(pod)
-		if found {
-			schedulingErrors[pod] = err
-			if err != nil {
-				glogx.V(2).UpTo(loggingQuota).Infof("Pod %s can't be scheduled on %s. Used cached predicate check results", pod.Name, nodeGroupId)
+	for _, group := range podEquivalenceGroups {
+		err := context.PredicateChecker.CheckPredicates(group.exemplar(), nil, nodeInfo)
+		for _, pod := range group.pods {
+			// Check if pod isn't repeated before overwriting result for it.
+			if _, repeated := schedulingErrors[pod]; repeated {
+				// This shouldn't really happen.
+				klog.Warningf("Pod %v appears multiple time on pods list, will only count it once in scale-up simulation", pod)
 			}
-		}
-		// Not found in cache, have to run the predicates.
-		if !found {
-			err = context.PredicateChecker.CheckPredicates(pod, nil, nodeInfo)
-			podSchedulable.set(pod, err)
 			schedulingErrors[pod] = err
-			if err != nil {
-				// Always log for the first pod in a controller.
-				klog.V(2).Infof("Pod %s can't be scheduled on %s, predicate failed: %v", pod.Name, nodeGroupId, err.VerboseError())
-			}
+		}
+		if err != nil {
+			glogx.V(2).UpTo(loggingQuota).Infof("Pod %s can't be scheduled on %s, predicate failed: %v (%d equivalent pods)",
+				group.exemplar().Name, nodeGroupId, err.VerboseError(), len(group.pods))
 		}
 	}
 
-	glogx.V(2).Over(loggingQuota).Infof("%v other pods can't be scheduled on %s.", -loggingQuota.Left(), nodeGroupId)
+	glogx.V(2).Over(loggingQuota).Infof("%v other pod groups can't be scheduled on %s.", -loggingQuota.Left(), nodeGroupId)
 	return schedulingErrors
 }
 
@@ -268,7 +267,7 @@ func getNodeInfosForGroups(nodes []*apiv1.Node, nodeInfoCache map[string]*schedu
 			if err != nil {
 				return false, "", err
 			}
-			sanitizedNodeInfo, err := sanitizeNodeInfo(nodeInfo, id)
+			sanitizedNodeInfo, err := sanitizeNodeInfo(nodeInfo, id, getStartupTaints(nodeGroup))
 			if err != nil {
 				return false, "", err
 			}
@@ -365,7 +364,7 @@ func getNodeInfoFromTemplate(nodeGroup cloudprovider.NodeGroup, daemonsets []*ap
 	pods = append(pods, baseNodeInfo.Pods()...)
 	fullNodeInfo := schedulernodeinfo.NewNodeInfo(pods...)
 	fullNodeInfo.SetNode(baseNodeInfo.Node())
-	sanitizedNodeInfo, typedErr := sanitizeNodeInfo(fullNodeInfo, id)
+	sanitizedNodeInfo, typedErr := sanitizeNodeInfo(fullNodeInfo, id, getStartupTaints(nodeGroup))
 	if typedErr != nil {
 		return nil, typedErr
 	}
@@ -403,9 +402,9 @@ func deepCopyNodeInfo(nodeInfo *schedulernodeinfo.NodeInfo) (*schedulernodeinfo.
 	return newNodeInfo, nil
 }
 
-func sanitizeNodeInfo(nodeInfo *schedulernodeinfo.NodeInfo, nodeGroupName string) (*schedulernodeinfo.NodeInfo, errors.AutoscalerError) {
+func sanitizeNodeInfo(nodeInfo *schedulernodeinfo.NodeInfo, nodeGroupName string, startupTaints []string) (*schedulernodeinfo.NodeInfo, errors.AutoscalerError) {
 	// Sanitize node name.
-	sanitizedNode, err := sanitizeTemplateNode(nodeInfo.Node(), nodeGroupName)
+	sanitizedNode, err := sanitizeTemplateNode(nodeInfo.Node(), nodeGroupName, startupTaints)
 	if err != nil {
 		return nil, err
 	}
@@ -426,7 +425,7 @@ func sanitizeNodeInfo(nodeInfo *schedulernodeinfo.NodeInfo, nodeGroupName string
 	return sanitizedNodeInfo, nil
 }
 
-func sanitizeTemplateNode(node *apiv1.Node, nodeGroup string) (*apiv1.Node, errors.AutoscalerError) {
+func sanitizeTemplateNode(node *apiv1.Node, nodeGroup string, startupTaints []string) (*apiv1.Node, errors.AutoscalerError) {
 	newNode := node.DeepCopy()
 	nodeName := fmt.Sprintf("template-node-for-%s-%d", nodeGroup, rand.Int63())
 	newNode.Labels = make(map[string]string, len(node.Labels))
@@ -451,6 +450,11 @@ func sanitizeTemplateNode(node *apiv1.Node, nodeGroup string) (*apiv1.Node, erro
 		case deletetaint.DeletionCandidateTaint:
 			klog.V(4).Infof("Removing autoscaler soft taint when creating template from node %s", node.Name)
 		default:
+			// New nodes are expected to get rid of their startup taints once initialized.
+			if deletetaint.IsStartupTaint(taint.Key, startupTaints) {
+				klog.V(4).Infof("Removing startup taint %s when creating template from node %s", taint.Key, node.Name)
+				continue
+			}
 			newTaints = append(newTaints, taint)
 		}
 	}
@@ -458,14 +462,61 @@ func sanitizeTemplateNode(node *apiv1.Node, nodeGroup string) (*apiv1.Node, erro
 	return newNode, nil
 }
 
+// getMaxNodeProvisionTime returns the time the nodes of the node group have to be provisioned in.
+func getMaxNodeProvisionTime(context *context.AutoscalingContext, nodeGroup cloudprovider.NodeGroup) time.Duration {
+	defaults := cloudprovider.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: context.MaxNodeProvisionTime}
+	return cloudprovider.GetNodeGroupOptions(nodeGroup, defaults).MaxNodeProvisionTime
+}
+
+// getNodeReadinessGates returns the conditions and labels the node has to pass before it's counted as available
+// capacity, which are the ones of its node group. No readiness gates apply to nodes that don't belong to any.
+func getNodeReadinessGates(context *context.AutoscalingContext, node *apiv1.Node) ([]string, []string) {
+	defaults := cloudprovider.NodeGroupAutoscalingOptions{
+		MaxNodeProvisionTime: context.MaxNodeProvisionTime,
+		ReadinessConditions:  context.NodeReadinessConditions,
+		ReadinessLabels:      context.NodeReadinessLabels,
+	}
+	nodeGroup, err := context.CloudProvider.NodeGroupForNode(node)
+	if err != nil {
+		klog.Warningf("Failed to get node group for %s, applying the default readiness gates: %v", node.Name, err)
+		return defaults.ReadinessConditions, defaults.ReadinessLabels
+	}
+	if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+		return nil, nil
+	}
+	options := cloudprovider.GetNodeGroupOptions(nodeGroup, defaults)
+	return options.ReadinessConditions, options.ReadinessLabels
+}
+
+// getStartupTaints returns the keys of the taints the new nodes of the node group carry until they are initialized, on
+// top of the ones deletetaint.IsStartupTaint always recognizes.
+func getStartupTaints(nodeGroup cloudprovider.NodeGroup) []string {
+	return cloudprovider.GetNodeGroupOptions(nodeGroup, cloudprovider.NodeGroupAutoscalingOptions{}).StartupTaints
+}
+
+// getNodeStartupTaints returns the startup taints of the node group of the node. Only the ones always recognized apply
+// to nodes that don't belong to any node group.
+func getNodeStartupTaints(context *context.AutoscalingContext, node *apiv1.Node) []string {
+	nodeGroup, err := context.CloudProvider.NodeGroupForNode(node)
+	if err != nil {
+		klog.Warningf("Failed to get node group for %s, applying the default startup taints: %v", node.Name, err)
+		return nil
+	}
+	return getStartupTaints(nodeGroup)
+}
+
 // Removes unregistered nodes if needed. Returns true if anything was removed and error if such occurred.
 func removeOldUnregisteredNodes(unregisteredNodes []clusterstate.UnregisteredNode, context *context.AutoscalingContext,
 	currentTime time.Time, logRecorder *utils.LogEventRecorder) (bool, error) {
 	removedAny := false
 	for _, unregisteredNode := range unregisteredNodes {
-		if unregisteredNode.UnregisteredSince.Add(context.MaxNodeProvisionTime).Before(currentTime) {
+		nodeGroup, err := context.CloudProvider.NodeGroupForNode(unregisteredNode.Node)
+		maxNodeProvisionTime := context.MaxNodeProvisionTime
+		if err == nil {
+			maxNodeProvisionTime = getMaxNodeProvisionTime(context, nodeGroup)
+		}
+		if unregisteredNode.UnregisteredSince.Add(maxNodeProvisionTime).Before(currentTime) {
 			klog.V(0).Infof("Removing unregistered node %v", unregisteredNode.Node.Name)
-			nodeGroup, err := context.CloudProvider.NodeGroupForNode(unregisteredNode.Node)
 			if err != nil {
 				klog.Warningf("Failed to get node group for %s: %v", unregisteredNode.Node.Name, err)
 				return removedAny, err
@@ -483,7 +534,9 @@ func removeOldUnregisteredNodes(unregisteredNodes []clusterstate.UnregisteredNod
 				klog.Warningf("Failed to remove node %s: node group min size reached, skipping unregistered node removal", unregisteredNode.Node.Name)
 				continue
 			}
-			err = nodeGroup.DeleteNodes([]*apiv1.Node{unregisteredNode.Node})
+			err = context.NodeGroupOperations.Do(nodeGroup.Id(), func() error {
+				return nodeGroup.DeleteNodes([]*apiv1.Node{unregisteredNode.Node})
+			})
 			if err != nil {
 				klog.Warningf("Failed to remove node %s: %v", unregisteredNode.Node.Name, err)
 				logRecorder.Eventf(apiv1.EventTypeWarning, "DeleteUnregisteredFailed",
@@ -498,6 +551,32 @@ func removeOldUnregisteredNodes(unregisteredNodes []clusterstate.UnregisteredNod
 	return removedAny, nil
 }
 
+// Removes the Node objects of nodes whose instance has been missing on the cloud provider side for
+// longer than GhostNodeDeletionGracePeriod. Returns true if any node was removed.
+func removeOldGhostNodes(ghostNodes []clusterstate.GhostNode, context *context.AutoscalingContext,
+	currentTime time.Time, logRecorder *utils.LogEventRecorder) (bool, error) {
+	removedAny := false
+	for _, ghostNode := range ghostNodes {
+		if !ghostNode.GhostSince.Add(context.GhostNodeDeletionGracePeriod).Before(currentTime) {
+			continue
+		}
+		klog.V(0).Infof("Removing node %v, its instance doesn't exist in the cloud provider", ghostNode.Node.Name)
+		err := context.ClientSet.CoreV1().Nodes().Delete(ghostNode.Node.Name, &metav1.DeleteOptions{})
+		if err != nil && !kube_errors.IsNotFound(err) {
+			klog.Warningf("Failed to remove node %s: %v", ghostNode.Node.Name, err)
+			logRecorder.Eventf(apiv1.EventTypeWarning, "DeleteGhostNodeFailed",
+				"Failed to remove node %s without instance: %v", ghostNode.Node.Name, err)
+			return removedAny, err
+		}
+		context.Recorder.Eventf(ghostNode.Node, apiv1.EventTypeNormal, "DeletedGhostNode",
+			"deleted the node, its instance doesn't exist in the cloud provider")
+		logRecorder.Eventf(apiv1.EventTypeNormal, "DeleteGhostNode",
+			"Removed node %v without instance", ghostNode.Node.Name)
+		removedAny = true
+	}
+	return removedAny, nil
+}
+
 // Sets the target size of node groups to the current number of nodes in them
 // if the difference was constant for a prolonged time. Returns true if managed
 // to fix something.
@@ -508,14 +587,14 @@ func fixNodeGroupSize(context *context.AutoscalingContext, clusterStateRegistry
 		if incorrectSize == nil {
 			continue
 		}
-		if incorrectSize.FirstObserved.Add(context.MaxNodeProvisionTime).Before(currentTime) {
+		if incorrectSize.FirstObserved.Add(getMaxNodeProvisionTime(context, nodeGroup)).Before(currentTime) {
 			delta := incorrectSize.CurrentSize - incorrectSize.ExpectedSize
 			if delta < 0 {
 				klog.V(0).Infof("Decreasing size of %s, expected=%d current=%d delta=%d", nodeGroup.Id(),
 					incorrectSize.ExpectedSize,
 					incorrectSize.CurrentSize,
 					delta)
-				if err := nodeGroup.DecreaseTargetSize(delta); err != nil {
+				if err := context.NodeGroupOperations.Do(nodeGroup.Id(), func() error { return nodeGroup.DecreaseTargetSize(delta) }); err != nil {
 					return fixed, fmt.Errorf("failed to decrease %s: %v", nodeGroup.Id(), err)
 				}
 				fixed = true
@@ -637,6 +716,7 @@ func UpdateClusterStateMetrics(csr *clusterstate.ClusterStateRegistry) {
 	metrics.UpdateClusterSafeToAutoscale(csr.IsClusterHealthy())
 	readiness := csr.GetClusterReadiness()
 	metrics.UpdateNodesCount(readiness.Ready, readiness.Unready+readiness.LongNotStarted, readiness.NotStarted, readiness.LongUnregistered, readiness.Unregistered)
+	metrics.UpdateGhostNodesCount(len(csr.GetGhostNodes()))
 }
 
 func getOldestCreateTime(pods []*apiv1.Pod) time.Time {
@@ -669,3 +749,39 @@ func updateEmptyClusterStateMetrics() {
 	metrics.UpdateClusterSafeToAutoscale(false)
 	metrics.UpdateNodesCount(0, 0, 0, 0, 0)
 }
+
+// configGeneration returns a short hash identifying the given autoscaling options.
+func configGeneration(options config.AutoscalingOptions) string {
+	hash := fnv.New32a()
+	fmt.Fprintf(hash, "%+v", options)
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+// annotateNodesWithNodeGroup annotates nodes that weren't annotated yet with the id of their node group
+// and the given config generation. Failed updates are retried in the next loop.
+func annotateNodesWithNodeGroup(context *context.AutoscalingContext, nodes []*apiv1.Node, generation string) {
+	for _, node := range nodes {
+		if _, found := node.Annotations[NodeGroupAnnotationKey]; found {
+			continue
+		}
+		nodeGroup, err := context.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			klog.Warningf("Failed to get node group for %s: %v", node.Name, err)
+			continue
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		updatedNode := node.DeepCopy()
+		if updatedNode.Annotations == nil {
+			updatedNode.Annotations = make(map[string]string)
+		}
+		updatedNode.Annotations[NodeGroupAnnotationKey] = nodeGroup.Id()
+		updatedNode.Annotations[ConfigGenerationAnnotationKey] = generation
+		if _, err := context.ClientSet.CoreV1().Nodes().Update(updatedNode); err != nil {
+			klog.Warningf("Failed to annotate node %s with node group %s: %v", node.Name, nodeGroup.Id(), err)
+			continue
+		}
+		klog.V(4).Infof("Annotated node %s with node group %s", node.Name, nodeGroup.Id())
+	}
+}
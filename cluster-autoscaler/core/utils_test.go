@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
@@ -360,7 +361,7 @@ func TestFilterSchedulablePodsForNode(t *testing.T) {
 		PredicateChecker: simulator.NewTestPredicateChecker(),
 	}
 
-	res := checkPodsSchedulableOnNode(context, unschedulablePods, "T1-abc", tni)
+	res := checkPodsSchedulableOnNode(context, buildPodEquivalenceGroups(unschedulablePods), "T1-abc", tni)
 	wantedSchedulable := []*apiv1.Pod{p1, p3_1, p3_2}
 	wantedUnschedulable := []*apiv1.Pod{p2_1, p2_2}
 
@@ -409,7 +410,7 @@ func TestGetNodeInfosForGroups(t *testing.T) {
 	provider2.AddNodeGroup("ng5", 1, 10, 1) // Nodegroup without nodes.
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{})
-	registry := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	predicateChecker := simulator.NewTestPredicateChecker()
 
@@ -477,7 +478,7 @@ func TestGetNodeInfosForGroupsCache(t *testing.T) {
 	provider1.AddNode("ng4", ready6)
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{})
-	registry := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	predicateChecker := simulator.NewTestPredicateChecker()
 
@@ -610,6 +611,112 @@ func TestRemoveOldUnregisteredNodes(t *testing.T) {
 	assert.Equal(t, "ng1/ng1-2", deletedNode)
 }
 
+func TestRemoveOldGhostNodes(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	ng1_1.Spec.ProviderID = "ng1-1"
+	ng1_2 := BuildTestNode("ng1-2", 1000, 1000)
+	ng1_2.Spec.ProviderID = "ng1-2"
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng1", ng1_2)
+	provider.DeleteInstance("ng1-2")
+
+	fakeClient := fake.NewSimpleClientset(ng1_1, ng1_2)
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false)
+	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+		LookUpGhostNodes:          true,
+	}, fakeLogRecorder, newBackoff())
+	err := clusterState.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2}, nil, now.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	context := &context.AutoscalingContext{
+		AutoscalingOptions: config.AutoscalingOptions{
+			GhostNodeDeletionGracePeriod: 45 * time.Minute,
+		},
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			ClientSet: fakeClient,
+			Recorder:  kube_record.NewFakeRecorder(5),
+		},
+		CloudProvider: provider,
+	}
+	ghostNodes := clusterState.GetGhostNodes()
+	assert.Equal(t, 1, len(ghostNodes))
+
+	// Nothing should be removed. The instance hasn't been missing for long enough.
+	removed, err := removeOldGhostNodes(ghostNodes, context, now.Add(-50*time.Minute), fakeLogRecorder)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	// ng1_2 should be removed.
+	removed, err = removeOldGhostNodes(ghostNodes, context, now, fakeLogRecorder)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	nodes, err := fakeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nodes.Items))
+	assert.Equal(t, "ng1-1", nodes.Items[0].Name)
+}
+
+func TestGetNodeReadinessGates(t *testing.T) {
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	ng2_1 := BuildTestNode("ng2-1", 1000, 1000)
+	other := BuildTestNode("other", 1000, 1000)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNode("ng2", ng2_1)
+	provider.GetNodeGroup("ng2").(*testprovider.TestNodeGroup).SetOptions(&cloudprovider.NodeGroupAutoscalingOptions{
+		ReadinessConditions: []string{"GPUDriverReady"},
+	})
+
+	context := &context.AutoscalingContext{
+		AutoscalingOptions: config.AutoscalingOptions{
+			NodeReadinessConditions: []string{"CNIReady"},
+			NodeReadinessLabels:     []string{"cni-ready"},
+		},
+		CloudProvider: provider,
+	}
+
+	// The flags apply to node groups that don't override them.
+	conditions, labels := getNodeReadinessGates(context, ng1_1)
+	assert.Equal(t, []string{"CNIReady"}, conditions)
+	assert.Equal(t, []string{"cni-ready"}, labels)
+
+	conditions, labels = getNodeReadinessGates(context, ng2_1)
+	assert.Equal(t, []string{"GPUDriverReady"}, conditions)
+	assert.Empty(t, labels)
+
+	// Nodes outside of node groups have no readiness gates.
+	conditions, labels = getNodeReadinessGates(context, other)
+	assert.Empty(t, conditions)
+	assert.Empty(t, labels)
+}
+
+func TestGetNodeStartupTaints(t *testing.T) {
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	ng2_1 := BuildTestNode("ng2-1", 1000, 1000)
+	other := BuildTestNode("other", 1000, 1000)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNode("ng2", ng2_1)
+	provider.GetNodeGroup("ng2").(*testprovider.TestNodeGroup).SetOptions(&cloudprovider.NodeGroupAutoscalingOptions{
+		StartupTaints: []string{"node.cilium.io/agent-not-ready"},
+	})
+	context := &context.AutoscalingContext{CloudProvider: provider}
+
+	assert.Empty(t, getNodeStartupTaints(context, ng1_1))
+	assert.Equal(t, []string{"node.cilium.io/agent-not-ready"}, getNodeStartupTaints(context, ng2_1))
+	assert.Empty(t, getNodeStartupTaints(context, other))
+}
+
 func TestSanitizeNodeInfo(t *testing.T) {
 	pod := BuildTestPod("p1", 80, 0)
 	pod.Spec.NodeName = "n1"
@@ -619,7 +726,7 @@ func TestSanitizeNodeInfo(t *testing.T) {
 	nodeInfo := schedulernodeinfo.NewNodeInfo(pod)
 	nodeInfo.SetNode(node)
 
-	res, err := sanitizeNodeInfo(nodeInfo, "test-group")
+	res, err := sanitizeNodeInfo(nodeInfo, "test-group", nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(res.Pods()))
 }
@@ -630,7 +737,7 @@ func TestSanitizeLabels(t *testing.T) {
 		apiv1.LabelHostname: "abc",
 		"x":                 "y",
 	}
-	node, err := sanitizeTemplateNode(oldNode, "bzium")
+	node, err := sanitizeTemplateNode(oldNode, "bzium", nil)
 	assert.NoError(t, err)
 	assert.NotEqual(t, node.Labels[apiv1.LabelHostname], "abc")
 	assert.Equal(t, node.Labels["x"], "y")
@@ -657,12 +764,32 @@ func TestSanitizeTaints(t *testing.T) {
 		Effect: apiv1.TaintEffectNoSchedule,
 	})
 	oldNode.Spec.Taints = taints
-	node, err := sanitizeTemplateNode(oldNode, "bzium")
+	node, err := sanitizeTemplateNode(oldNode, "bzium", nil)
 	assert.NoError(t, err)
 	assert.Equal(t, len(node.Spec.Taints), 1)
 	assert.Equal(t, node.Spec.Taints[0].Key, "test-taint")
 }
 
+func TestSanitizeStartupTaints(t *testing.T) {
+	oldNode := BuildTestNode("ng1-1", 1000, 1000)
+	oldNode.Spec.Taints = []apiv1.Taint{
+		{Key: deletetaint.UninitializedTaint, Effect: apiv1.TaintEffectNoSchedule},
+		{Key: deletetaint.StartupTaintPrefix + "cni", Effect: apiv1.TaintEffectNoExecute},
+		{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule},
+		{Key: "node.cilium.io/agent-not-ready", Effect: apiv1.TaintEffectNoSchedule},
+	}
+	node, err := sanitizeTemplateNode(oldNode, "bzium", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(node.Spec.Taints))
+	assert.Equal(t, "dedicated", node.Spec.Taints[0].Key)
+
+	// The startup taints of the node group are removed too.
+	node, err = sanitizeTemplateNode(oldNode, "bzium", []string{"node.cilium.io/agent-not-ready"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(node.Spec.Taints))
+	assert.Equal(t, "dedicated", node.Spec.Taints[0].Key)
+}
+
 func TestRemoveFixNodeTargetSize(t *testing.T) {
 	sizeChanges := make(chan string, 10)
 	now := time.Now()
@@ -842,3 +969,42 @@ func TestGetOldestPod(t *testing.T) {
 	assert.Equal(t, p1.CreationTimestamp.Time, getOldestCreateTime([]*apiv1.Pod{p1, p2, p3}))
 	assert.Equal(t, p1.CreationTimestamp.Time, getOldestCreateTime([]*apiv1.Pod{p3, p2, p1}))
 }
+
+func TestAnnotateNodesWithNodeGroup(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n2.Annotations = map[string]string{NodeGroupAnnotationKey: "ng-old", ConfigGenerationAnnotationKey: "old"}
+	n3 := BuildTestNode("n3", 1000, 1000)
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	fakeClient := fake.NewSimpleClientset(n1, n2, n3)
+	context := NewScaleTestAutoscalingContext(config.AutoscalingOptions{}, fakeClient, nil, provider)
+
+	annotateNodesWithNodeGroup(&context, []*apiv1.Node{n1, n2, n3}, "gen1")
+
+	updated, err := fakeClient.CoreV1().Nodes().Get("n1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1", updated.Annotations[NodeGroupAnnotationKey])
+	assert.Equal(t, "gen1", updated.Annotations[ConfigGenerationAnnotationKey])
+
+	// Nodes are only annotated once, when they join.
+	updated, err = fakeClient.CoreV1().Nodes().Get("n2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ng-old", updated.Annotations[NodeGroupAnnotationKey])
+
+	// Nodes without a node group are left alone.
+	updated, err = fakeClient.CoreV1().Nodes().Get("n3", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, updated.Annotations, NodeGroupAnnotationKey)
+}
+
+func TestConfigGeneration(t *testing.T) {
+	options := config.AutoscalingOptions{MaxNodesTotal: 10}
+	assert.Equal(t, configGeneration(options), configGeneration(options))
+	assert.Len(t, configGeneration(options), 8)
+	assert.NotEqual(t, configGeneration(options), configGeneration(config.AutoscalingOptions{MaxNodesTotal: 20}))
+}
@@ -35,6 +35,8 @@ type podInfo struct {
 // BinpackingNodeEstimator estimates the number of needed nodes to handle the given amount of pods.
 type BinpackingNodeEstimator struct {
 	predicateChecker *simulator.PredicateChecker
+	// deviceResources are the resources whose requests are packed on the devices of the nodes.
+	deviceResources DeviceResources
 }
 
 // NewBinpackingNodeEstimator builds a new BinpackingNodeEstimator.
@@ -44,12 +46,22 @@ func NewBinpackingNodeEstimator(predicateChecker *simulator.PredicateChecker) *B
 	}
 }
 
+// NewDeviceBinpackingNodeEstimator builds a new BinpackingNodeEstimator packing the requests of the
+// given device resources on the devices of the nodes rather than on the nodes as a whole.
+func NewDeviceBinpackingNodeEstimator(predicateChecker *simulator.PredicateChecker, deviceResources DeviceResources) *BinpackingNodeEstimator {
+	return &BinpackingNodeEstimator{
+		predicateChecker: predicateChecker,
+		deviceResources:  deviceResources,
+	}
+}
+
 // Estimate implements First Fit Decreasing bin-packing approximation algorithm.
 // See https://en.wikipedia.org/wiki/Bin_packing_problem for more details.
 // While it is a multi-dimensional bin packing (cpu, mem, ports) in most cases the main dimension
 // will be cpu thus the estimated overprovisioning of 11/9 * optimal + 6/9 should be
 // still be maintained.
 // It is assumed that all pods from the given list can fit to nodeTemplate.
+// With device resources, pods also have to fit a single device of the node for each of them.
 // Returns the number of nodes needed to accommodate all pods from the list.
 func (estimator *BinpackingNodeEstimator) Estimate(pods []*apiv1.Pod, nodeTemplate *schedulernodeinfo.NodeInfo,
 	upcomingNodes []*schedulernodeinfo.NodeInfo) int {
@@ -59,18 +71,26 @@ func (estimator *BinpackingNodeEstimator) Estimate(pods []*apiv1.Pod, nodeTempla
 
 	newNodes := make([]*schedulernodeinfo.NodeInfo, 0)
 	newNodes = append(newNodes, upcomingNodes...)
+	devices := make([]nodeDevices, 0, len(newNodes))
+	for _, nodeInfo := range upcomingNodes {
+		devices = append(devices, estimator.deviceResources.newNodeDevices(nodeInfo.Node(), nodeInfo.Pods()))
+	}
 
 	for _, podInfo := range podInfos {
 		found := false
+		deviceRequests := estimator.deviceResources.PodRequests(podInfo.pod)
 		for i, nodeInfo := range newNodes {
-			if err := estimator.predicateChecker.CheckPredicates(podInfo.pod, nil, nodeInfo); err == nil {
+			if err := estimator.predicateChecker.CheckPredicates(podInfo.pod, nil, nodeInfo); err == nil && devices[i].allocate(deviceRequests) {
 				found = true
 				newNodes[i] = schedulerUtils.NodeWithPod(nodeInfo, podInfo.pod)
 				break
 			}
 		}
 		if !found {
+			newDevices := estimator.deviceResources.newNodeDevices(nodeTemplate.Node(), nodeTemplate.Pods())
+			newDevices.allocate(deviceRequests)
 			newNodes = append(newNodes, schedulerUtils.NodeWithPod(nodeTemplate, podInfo.pod))
+			devices = append(devices, newDevices)
 		}
 	}
 	return len(newNodes) - len(upcomingNodes)
@@ -59,6 +59,33 @@ func TestBinpackingEstimate(t *testing.T) {
 	assert.Equal(t, 5, estimate)
 }
 
+func TestBinpackingEstimatePodCapacity(t *testing.T) {
+	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker())
+
+	pod := makePod(10, 10*units.MiB)
+	pods := make([]*apiv1.Pod, 0)
+	for i := 0; i < 25; i++ {
+		pods = append(pods, pod)
+	}
+	node := &apiv1.Node{
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    *resource.NewMilliQuantity(4000, resource.DecimalSI),
+				apiv1.ResourceMemory: *resource.NewQuantity(16*units.GiB, resource.DecimalSI),
+				apiv1.ResourcePods:   *resource.NewQuantity(10, resource.DecimalSI),
+			},
+		},
+	}
+	node.Status.Allocatable = node.Status.Capacity
+	SetNodeReadyState(node, true, time.Time{})
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo()
+	nodeInfo.SetNode(node)
+	// The pods would fit on a single node but for its pod capacity.
+	estimate := estimator.Estimate(pods, nodeInfo, []*schedulernodeinfo.NodeInfo{})
+	assert.Equal(t, 3, estimate)
+}
+
 func TestBinpackingEstimateComingNodes(t *testing.T) {
 	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker())
 
@@ -121,3 +148,45 @@ func TestBinpackingEstimateWithPorts(t *testing.T) {
 	estimate := estimator.Estimate(pods, nodeInfo, []*schedulernodeinfo.NodeInfo{})
 	assert.Equal(t, 8, estimate)
 }
+
+func TestBinpackingEstimateWithDevices(t *testing.T) {
+	const (
+		gpuMemory = apiv1.ResourceName("example.com/gpu-mem")
+		gpuCount  = apiv1.ResourceName("example.com/gpu-count")
+	)
+	pod := makePod(100, 100*units.MiB)
+	pod.Spec.Containers[0].Resources.Requests[gpuMemory] = *resource.NewQuantity(5, resource.DecimalSI)
+	pods := make([]*apiv1.Pod, 0)
+	for i := 0; i < 6; i++ {
+		pods = append(pods, pod)
+	}
+	node := &apiv1.Node{
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    *resource.NewMilliQuantity(10000, resource.DecimalSI),
+				apiv1.ResourceMemory: *resource.NewQuantity(10000*units.MiB, resource.DecimalSI),
+				apiv1.ResourcePods:   *resource.NewQuantity(10, resource.DecimalSI),
+				gpuMemory:            *resource.NewQuantity(16, resource.DecimalSI),
+				gpuCount:             *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		},
+	}
+	node.Status.Allocatable = node.Status.Capacity
+	SetNodeReadyState(node, true, time.Time{})
+
+	nodeInfo := schedulernodeinfo.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	// 3 pods fit the 16 units of a node in total, but only one fits each of its devices of 8 units.
+	estimate := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker()).Estimate(pods, nodeInfo, []*schedulernodeinfo.NodeInfo{})
+	assert.Equal(t, 2, estimate)
+	estimator := NewDeviceBinpackingNodeEstimator(simulator.NewTestPredicateChecker(), DeviceResources{gpuMemory: gpuCount})
+	estimate = estimator.Estimate(pods, nodeInfo, []*schedulernodeinfo.NodeInfo{})
+	assert.Equal(t, 3, estimate)
+
+	// Pods of upcoming nodes take their devices.
+	upcoming := schedulernodeinfo.NewNodeInfo(pod)
+	upcoming.SetNode(node)
+	estimate = estimator.Estimate(pods, nodeInfo, []*schedulernodeinfo.NodeInfo{upcoming})
+	assert.Equal(t, 3, estimate)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimator
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// DeviceResources maps the extended resources that device plugins share between the pods of a
+// device, e.g. the memory of GPUs shared by several pods, to the resource counting the devices of a
+// node. Nodes advertise the sum of the shared resource over all their devices, but the request of a
+// pod has to be served by a single device, so a node with enough of the resource in total may still
+// not fit a pod because the resource is fragmented across its devices.
+type DeviceResources map[apiv1.ResourceName]apiv1.ResourceName
+
+// PodRequests returns the requests of the pod for the shared resources.
+func (r DeviceResources) PodRequests(pod *apiv1.Pod) map[apiv1.ResourceName]int64 {
+	var requests map[apiv1.ResourceName]int64
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			if _, found := r[name]; !found || quantity.IsZero() {
+				continue
+			}
+			if requests == nil {
+				requests = make(map[apiv1.ResourceName]int64)
+			}
+			requests[name] += quantity.Value()
+		}
+	}
+	return requests
+}
+
+// nodeDevices holds the free amount of the shared resources on each device of a node.
+type nodeDevices map[apiv1.ResourceName][]int64
+
+// newNodeDevices returns the devices of the node, with the allocatable amount of the shared
+// resources split evenly between them, and the requests of the pods of the node allocated.
+func (r DeviceResources) newNodeDevices(node *apiv1.Node, pods []*apiv1.Pod) nodeDevices {
+	devices := make(nodeDevices, len(r))
+	for name, countName := range r {
+		total, found := node.Status.Allocatable[name]
+		if !found || total.IsZero() {
+			continue
+		}
+		count := int64(1)
+		if quantity, found := node.Status.Allocatable[countName]; found && quantity.Value() > 0 {
+			count = quantity.Value()
+		}
+		free := make([]int64, count)
+		for i := range free {
+			free[i] = total.Value() / count
+		}
+		devices[name] = free
+	}
+	for _, pod := range pods {
+		devices.allocate(r.PodRequests(pod))
+	}
+	return devices
+}
+
+// allocate allocates every request to the first device with enough of its resource left, and
+// returns false, leaving the devices unchanged, if a request doesn't fit any device.
+func (d nodeDevices) allocate(requests map[apiv1.ResourceName]int64) bool {
+	if len(requests) == 0 {
+		return true
+	}
+	indexes := make(map[apiv1.ResourceName]int, len(requests))
+	for name, request := range requests {
+		found := false
+		for i, free := range d[name] {
+			if free >= request {
+				indexes[name] = i
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for name, i := range indexes {
+		d[name][i] -= requests[name]
+	}
+	return true
+}
@@ -47,12 +47,13 @@ type Estimator interface {
 // EstimatorBuilder creates a new estimator object.
 type EstimatorBuilder func(*simulator.PredicateChecker) Estimator
 
-// NewEstimatorBuilder creates a new estimator object from flag.
-func NewEstimatorBuilder(name string) (EstimatorBuilder, error) {
+// NewEstimatorBuilder creates a new estimator object from flag. The binpacking estimator packs the
+// requests of the given device resources on the devices of the nodes.
+func NewEstimatorBuilder(name string, deviceResources DeviceResources) (EstimatorBuilder, error) {
 	switch name {
 	case BinpackingEstimatorName:
 		return func(predicateChecker *simulator.PredicateChecker) Estimator {
-			return NewBinpackingNodeEstimator(predicateChecker)
+			return NewDeviceBinpackingNodeEstimator(predicateChecker, deviceResources)
 		}, nil
 	// Deprecated.
 	// TODO(aleksandra-malinowska): remove in 1.5.
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podhint
+
+import (
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// ExpanderAnnotationKey is the pod annotation holding the name of the expander to be used for the
+	// scale-up of the pod.
+	ExpanderAnnotationKey = "cluster-autoscaler.kubernetes.io/expander"
+	// NodeGroupOrderAnnotationKey is the pod annotation holding a comma separated list of node group ids,
+	// by order of preference, to be scaled up for the pod. It takes precedence over ExpanderAnnotationKey.
+	NodeGroupOrderAnnotationKey = "cluster-autoscaler.kubernetes.io/node-group-order"
+)
+
+type hint struct {
+	expander       string
+	nodeGroupOrder string
+}
+
+type hinted struct {
+	namespaces      map[string]bool
+	strategies      map[string]expander.Strategy
+	defaultStrategy expander.Strategy
+}
+
+// NewStrategy returns a scale up strategy (expander) that follows the hints of the pending pods in the
+// given namespaces, which choose either the node groups to be scaled up, by order of preference, or the
+// expander picking them among the given strategies, keyed by name. The hint of most hinted pods wins.
+// The default strategy is used if there's no such hint or it can't be followed.
+func NewStrategy(namespaces []string, strategies map[string]expander.Strategy, defaultStrategy expander.Strategy) expander.Strategy {
+	allowed := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		allowed[namespace] = true
+	}
+	return &hinted{
+		namespaces:      allowed,
+		strategies:      strategies,
+		defaultStrategy: defaultStrategy,
+	}
+}
+
+// BestOption selects the best option following the hint of the pending pods.
+func (h *hinted) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	hint := h.podsHint(expansionOptions)
+	if hint.nodeGroupOrder != "" {
+		for _, id := range strings.Split(hint.nodeGroupOrder, ",") {
+			id = strings.TrimSpace(id)
+			for i := range expansionOptions {
+				if expansionOptions[i].NodeGroup.Id() == id {
+					klog.V(4).Infof("Using node group %s following the node group order of pending pods", id)
+					return &expansionOptions[i]
+				}
+			}
+		}
+		klog.V(4).Infof("None of the node groups %s of pending pods can be scaled up", hint.nodeGroupOrder)
+	}
+	if hint.expander != "" {
+		if strategy, found := h.strategies[hint.expander]; found {
+			klog.V(4).Infof("Using the expander %s of pending pods", hint.expander)
+			return strategy.BestOption(expansionOptions, nodeInfo)
+		}
+		klog.Warningf("Ignoring unknown expander %s of pending pods", hint.expander)
+	}
+	return h.defaultStrategy.BestOption(expansionOptions, nodeInfo)
+}
+
+// podsHint returns the hint of most of the hinted pods helped by the options, or an empty hint if there's
+// none or a tie between hints.
+func (h *hinted) podsHint(expansionOptions []expander.Option) hint {
+	seen := make(map[*apiv1.Pod]bool)
+	podsPerHint := make(map[hint]int)
+	for _, option := range expansionOptions {
+		for _, pod := range option.Pods {
+			if seen[pod] {
+				continue
+			}
+			seen[pod] = true
+			if podHint, found := h.podHint(pod); found {
+				podsPerHint[podHint]++
+			}
+		}
+	}
+
+	bestHint, bestCount, tie := hint{}, 0, false
+	for podHint, count := range podsPerHint {
+		if count > bestCount {
+			bestHint, bestCount, tie = podHint, count, false
+		} else if count == bestCount {
+			tie = true
+		}
+	}
+	if tie {
+		return hint{}
+	}
+	return bestHint
+}
+
+func (h *hinted) podHint(pod *apiv1.Pod) (hint, bool) {
+	if !h.namespaces[pod.Namespace] {
+		return hint{}, false
+	}
+	podHint := hint{
+		expander:       pod.Annotations[ExpanderAnnotationKey],
+		nodeGroupOrder: pod.Annotations[NodeGroupOrderAnnotationKey],
+	}
+	return podHint, podHint != hint{}
+}
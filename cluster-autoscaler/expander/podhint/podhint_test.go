@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podhint
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// pickStrategy always picks the option at the given index.
+type pickStrategy struct {
+	index int
+}
+
+func (p *pickStrategy) BestOption(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	return &options[p.index]
+}
+
+func buildHintedPod(name, namespace string, annotations map[string]string) *apiv1.Pod {
+	pod := BuildTestPod(name, 100, 0)
+	pod.Namespace = namespace
+	pod.Annotations = annotations
+	return pod
+}
+
+func TestPodHints(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng0", 0, 10, 1)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+
+	e := NewStrategy([]string{"batch"}, map[string]expander.Strategy{
+		"price":       &pickStrategy{1},
+		"least-waste": &pickStrategy{2},
+	}, &pickStrategy{0})
+
+	options := func(pods ...*apiv1.Pod) []expander.Option {
+		return []expander.Option{
+			{NodeGroup: provider.GetNodeGroup("ng0"), Debug: "0", Pods: pods},
+			{NodeGroup: provider.GetNodeGroup("ng1"), Debug: "1", Pods: pods},
+			{NodeGroup: provider.GetNodeGroup("ng2"), Debug: "2", Pods: pods},
+		}
+	}
+
+	price := buildHintedPod("price", "batch", map[string]string{ExpanderAnnotationKey: "price"})
+	waste := buildHintedPod("waste", "batch", map[string]string{ExpanderAnnotationKey: "least-waste"})
+	unknown := buildHintedPod("unknown", "batch", map[string]string{ExpanderAnnotationKey: "unknown"})
+	ordered := buildHintedPod("ordered", "batch", map[string]string{NodeGroupOrderAnnotationKey: "missing, ng2,ng1"})
+	missing := buildHintedPod("missing", "batch", map[string]string{NodeGroupOrderAnnotationKey: "missing"})
+	both := buildHintedPod("both", "batch", map[string]string{
+		ExpanderAnnotationKey:       "price",
+		NodeGroupOrderAnnotationKey: "missing",
+	})
+	unprivileged := buildHintedPod("unprivileged", "default", map[string]string{ExpanderAnnotationKey: "price"})
+	plain := buildHintedPod("plain", "batch", nil)
+
+	assert.Equal(t, "1", e.BestOption(options(price), nil).Debug)
+	assert.Equal(t, "2", e.BestOption(options(waste, plain), nil).Debug)
+	assert.Equal(t, "2", e.BestOption(options(ordered), nil).Debug)
+	// Hints that can't be followed fall back to the expander, then to the default strategy.
+	assert.Equal(t, "1", e.BestOption(options(both), nil).Debug)
+	assert.Equal(t, "0", e.BestOption(options(missing), nil).Debug)
+	assert.Equal(t, "0", e.BestOption(options(unknown), nil).Debug)
+	// Pods outside of the allowed namespaces have no say.
+	assert.Equal(t, "0", e.BestOption(options(unprivileged), nil).Debug)
+	assert.Equal(t, "0", e.BestOption(options(plain), nil).Debug)
+	// Ties between hints are resolved by the default strategy.
+	assert.Equal(t, "0", e.BestOption(options(price, waste), nil).Debug)
+
+	// Pods helped by several options are counted once.
+	shared := []expander.Option{
+		{NodeGroup: provider.GetNodeGroup("ng0"), Debug: "0", Pods: []*apiv1.Pod{waste, price}},
+		{NodeGroup: provider.GetNodeGroup("ng1"), Debug: "1", Pods: []*apiv1.Pod{waste}},
+		{NodeGroup: provider.GetNodeGroup("ng2"), Debug: "2", Pods: []*apiv1.Pod{waste}},
+	}
+	assert.Equal(t, "0", e.BestOption(shared, nil).Debug)
+}
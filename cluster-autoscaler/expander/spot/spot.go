@@ -0,0 +1,72 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spot
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// Prefer prefers scaling up the node groups backed by spot or preemptible instances.
+	Prefer = "prefer"
+	// Avoid prefers scaling up the node groups backed by on-demand instances.
+	Avoid = "avoid"
+)
+
+type preference struct {
+	fallbackStrategy expander.Strategy
+	spotLabel        string
+	preferSpot       bool
+}
+
+// NewStrategy returns a scale up strategy (expander) that prefers the node groups whose template nodes
+// have the spot label if preferSpot is true, or the ones whose template nodes don't have it otherwise.
+// The choice among the preferred node groups is left to the fallback strategy.
+func NewStrategy(fallbackStrategy expander.Strategy, spotLabel string, preferSpot bool) expander.Strategy {
+	return &preference{
+		fallbackStrategy: fallbackStrategy,
+		spotLabel:        spotLabel,
+		preferSpot:       preferSpot,
+	}
+}
+
+// BestOption selects the best option among the preferred ones, or among all of them if there are no such
+// options.
+func (p *preference) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	var preferredOptions []expander.Option
+	for _, option := range expansionOptions {
+		if p.isSpot(nodeInfo[option.NodeGroup.Id()]) == p.preferSpot {
+			preferredOptions = append(preferredOptions, option)
+		}
+	}
+	if len(preferredOptions) == 0 || len(preferredOptions) == len(expansionOptions) {
+		return p.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+	}
+	klog.V(4).Infof("%d of %d expansion options scale up preferred node groups (spot: %v)", len(preferredOptions), len(expansionOptions), p.preferSpot)
+	return p.fallbackStrategy.BestOption(preferredOptions, nodeInfo)
+}
+
+func (p *preference) isSpot(nodeInfo *schedulernodeinfo.NodeInfo) bool {
+	if nodeInfo == nil || nodeInfo.Node() == nil {
+		return false
+	}
+	_, found := nodeInfo.Node().Labels[p.spotLabel]
+	return found
+}
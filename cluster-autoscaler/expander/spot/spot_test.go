@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spot
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/mostpods"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpotLabel = "example.com/spot"
+
+func TestSpotPreference(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("on-demand", 0, 10, 0)
+	provider.AddNodeGroup("spot", 0, 10, 0)
+	provider.AddNodeGroup("other-spot", 0, 10, 0)
+
+	nodeInfos := make(map[string]*schedulernodeinfo.NodeInfo)
+	for _, id := range []string{"on-demand", "spot", "other-spot"} {
+		node := BuildTestNode(id+"-template", 1000, 1000)
+		if id != "on-demand" {
+			node.Labels[testSpotLabel] = ""
+		}
+		nodeInfos[id] = schedulernodeinfo.NewNodeInfo()
+		nodeInfos[id].SetNode(node)
+	}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 100, 0)
+	onDemand := expander.Option{NodeGroup: provider.GetNodeGroup("on-demand"), NodeCount: 1, Pods: []*apiv1.Pod{p1, p2}}
+	spot := expander.Option{NodeGroup: provider.GetNodeGroup("spot"), NodeCount: 1, Pods: []*apiv1.Pod{p1}}
+	otherSpot := expander.Option{NodeGroup: provider.GetNodeGroup("other-spot"), NodeCount: 1, Pods: []*apiv1.Pod{p1, p2}}
+
+	prefer := NewStrategy(mostpods.NewStrategy(), testSpotLabel, true)
+	// The spot node groups are preferred even though the fallback would choose the on-demand one.
+	assert.Equal(t, spot, *prefer.BestOption([]expander.Option{onDemand, spot}, nodeInfos))
+	// The fallback chooses among the spot node groups.
+	assert.Equal(t, otherSpot, *prefer.BestOption([]expander.Option{onDemand, spot, otherSpot}, nodeInfos))
+	// No option scales up a spot node group.
+	assert.Equal(t, onDemand, *prefer.BestOption([]expander.Option{onDemand}, nodeInfos))
+
+	avoid := NewStrategy(mostpods.NewStrategy(), testSpotLabel, false)
+	assert.Equal(t, onDemand, *avoid.BestOption([]expander.Option{onDemand, spot, otherSpot}, nodeInfos))
+	// Only spot node groups can be scaled up.
+	assert.Equal(t, otherSpot, *avoid.BestOption([]expander.Option{spot, otherSpot}, nodeInfos))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"reflect"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+type stickiness struct {
+	fallbackStrategy   expander.Strategy
+	cloudProvider      cloudprovider.CloudProvider
+	scheduledPodLister kube_util.PodLister
+	nodeLister         kube_util.NodeLister
+}
+
+// NewStrategy returns a scale up strategy (expander) that prefers the node groups already hosting other
+// replicas of the StatefulSets of the pending pods, so that their zone and volume affinity remains
+// satisfiable. The choice among the preferred node groups is left to the fallback strategy.
+func NewStrategy(fallbackStrategy expander.Strategy, cloudProvider cloudprovider.CloudProvider,
+	scheduledPodLister kube_util.PodLister, nodeLister kube_util.NodeLister) expander.Strategy {
+	return &stickiness{
+		fallbackStrategy:   fallbackStrategy,
+		cloudProvider:      cloudProvider,
+		scheduledPodLister: scheduledPodLister,
+		nodeLister:         nodeLister,
+	}
+}
+
+// BestOption selects the best option among the ones scaling up node groups that host other replicas
+// of the StatefulSets of their pods, or among all of them if there are no such options.
+func (s *stickiness) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	statefulSets := make(map[types.UID]bool)
+	for _, option := range expansionOptions {
+		for _, pod := range option.Pods {
+			if uid, found := statefulSetUID(pod); found {
+				statefulSets[uid] = true
+			}
+		}
+	}
+	if len(statefulSets) == 0 {
+		return s.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+	}
+
+	hostingNodeGroups, err := s.getHostingNodeGroups(statefulSets)
+	if err != nil {
+		klog.Warningf("Failed to find node groups hosting StatefulSet replicas: %v", err)
+		return s.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+	}
+
+	var preferredOptions []expander.Option
+	for _, option := range expansionOptions {
+		for _, pod := range option.Pods {
+			if uid, found := statefulSetUID(pod); found && hostingNodeGroups[uid][option.NodeGroup.Id()] {
+				preferredOptions = append(preferredOptions, option)
+				break
+			}
+		}
+	}
+	if len(preferredOptions) == 0 {
+		return s.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+	}
+	klog.V(4).Infof("%d of %d expansion options scale up node groups hosting StatefulSet replicas", len(preferredOptions), len(expansionOptions))
+	return s.fallbackStrategy.BestOption(preferredOptions, nodeInfo)
+}
+
+// getHostingNodeGroups returns the ids of node groups hosting scheduled replicas of the given StatefulSets.
+func (s *stickiness) getHostingNodeGroups(statefulSets map[types.UID]bool) (map[types.UID]map[string]bool, error) {
+	pods, err := s.scheduledPodLister.List()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeLister.List()
+	if err != nil {
+		return nil, err
+	}
+	nodesByName := make(map[string]*apiv1.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByName[node.Name] = node
+	}
+
+	result := make(map[types.UID]map[string]bool)
+	for _, pod := range pods {
+		uid, found := statefulSetUID(pod)
+		if !found || !statefulSets[uid] {
+			continue
+		}
+		node, found := nodesByName[pod.Spec.NodeName]
+		if !found {
+			continue
+		}
+		nodeGroup, err := s.cloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			return nil, err
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		if result[uid] == nil {
+			result[uid] = make(map[string]bool)
+		}
+		result[uid][nodeGroup.Id()] = true
+	}
+	return result, nil
+}
+
+func statefulSetUID(pod *apiv1.Pod) (types.UID, bool) {
+	controllerRef := metav1.GetControllerOf(pod)
+	if controllerRef == nil || controllerRef.Kind != "StatefulSet" {
+		return "", false
+	}
+	return controllerRef.UID, true
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/mostpods"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testNodeLister struct {
+	list []*apiv1.Node
+}
+
+func (n *testNodeLister) List() ([]*apiv1.Node, error) {
+	return n.list, nil
+}
+
+func TestStatefulSetStickiness(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+	provider.AddNodeGroup("ng3", 0, 10, 0)
+	n1 := BuildTestNode("n1", 1000, 1000)
+	provider.AddNode("ng1", n1)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	provider.AddNode("ng2", n2)
+
+	replica := BuildTestPod("db-0", 100, 0)
+	replica.OwnerReferences = GenerateOwnerReferences("db", "StatefulSet", "apps/v1", "db-uid")
+	replica.Spec.NodeName = "n2"
+	pending := BuildTestPod("db-1", 100, 0)
+	pending.OwnerReferences = GenerateOwnerReferences("db", "StatefulSet", "apps/v1", "db-uid")
+	other := BuildTestPod("other", 100, 0)
+
+	e := NewStrategy(mostpods.NewStrategy(), provider,
+		kube_util.NewTestPodLister([]*apiv1.Pod{replica}), &testNodeLister{[]*apiv1.Node{n1, n2}})
+
+	ng1 := expander.Option{NodeGroup: provider.GetNodeGroup("ng1"), NodeCount: 1, Pods: []*apiv1.Pod{pending, other}}
+	ng2 := expander.Option{NodeGroup: provider.GetNodeGroup("ng2"), NodeCount: 1, Pods: []*apiv1.Pod{pending}}
+	ng3 := expander.Option{NodeGroup: provider.GetNodeGroup("ng3"), NodeCount: 1, Pods: []*apiv1.Pod{pending, other}}
+
+	// ng2 hosts another replica, so it's preferred even though the fallback would choose otherwise.
+	assert.Equal(t, ng2, *e.BestOption([]expander.Option{ng1, ng2, ng3}, nil))
+
+	// No option scales up a node group hosting replicas, the fallback chooses among all of them.
+	assert.Equal(t, ng1, *e.BestOption([]expander.Option{ng1}, nil))
+
+	// No pending pod belongs to a StatefulSet.
+	plain := expander.Option{NodeGroup: provider.GetNodeGroup("ng2"), NodeCount: 1, Pods: []*apiv1.Pod{other}}
+	assert.Equal(t, ng1, *e.BestOption([]expander.Option{ng1, plain}, nil))
+}
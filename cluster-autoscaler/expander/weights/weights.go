@@ -0,0 +1,241 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package weights
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	v1lister "k8s.io/client-go/listers/core/v1"
+
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the CA namespace, holding the weights of the node
+	// groups.
+	ConfigMapName = "cluster-autoscaler-node-group-weights"
+	// WeightsKey is the key of the weights ConfigMap holding the time windows and their weights, in YAML.
+	WeightsKey = "weights"
+
+	timeOfDayLayout = "15:04"
+)
+
+// config is the content of WeightsKey.
+type config struct {
+	// TimeZone is the name of the time zone of the time windows, UTC if empty.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Windows are the time windows, the first one including the current time applies.
+	Windows []window `json:"windows"`
+}
+
+// window gives the weights of the node groups during the times of day it includes.
+type window struct {
+	// Days are the days of the week included, e.g. Mon, all of them if empty.
+	Days []string `json:"days,omitempty"`
+	// From and To are the times of day the window starts and ends, in the 15:04 format. The whole day
+	// is included if both are empty, and the window ends after midnight if To is before From.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Weights are the weights of the node groups whose ids match the regular expressions.
+	Weights map[string]int `json:"weights"`
+}
+
+// parsedConfig is a config ready to be evaluated.
+type parsedConfig struct {
+	location *time.Location
+	windows  []parsedWindow
+}
+
+type parsedWindow struct {
+	days     map[time.Weekday]bool
+	from, to time.Duration
+	allDay   bool
+	weights  map[*regexp.Regexp]int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type weighted struct {
+	fallbackStrategy expander.Strategy
+	configMapLister  v1lister.ConfigMapNamespaceLister
+	now              func() time.Time
+	// resourceVersion is the version of the weights ConfigMap config was parsed from.
+	resourceVersion string
+	config          *parsedConfig
+}
+
+// NewStrategy returns a scale up strategy (expander) that prefers the node groups with the highest
+// weight in the time window in effect, according to the weights ConfigMap listed by configMapLister.
+// The choice among the preferred node groups is left to the fallback strategy, which chooses among all
+// the node groups if there is no weights ConfigMap or no time window in effect.
+func NewStrategy(fallbackStrategy expander.Strategy, configMapLister v1lister.ConfigMapNamespaceLister) expander.Strategy {
+	return &weighted{
+		fallbackStrategy: fallbackStrategy,
+		configMapLister:  configMapLister,
+		now:              time.Now,
+	}
+}
+
+// BestOption selects the best option among the ones scaling up the node groups with the highest weight.
+func (w *weighted) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	config := w.getConfig()
+	if config == nil {
+		return w.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+	}
+	now := w.now().In(config.location)
+	for _, window := range config.windows {
+		if !window.includes(now) {
+			continue
+		}
+		var preferredOptions []expander.Option
+		highestWeight := 0
+		for _, option := range expansionOptions {
+			weight := window.weight(option.NodeGroup.Id())
+			if len(preferredOptions) == 0 || weight > highestWeight {
+				preferredOptions = []expander.Option{option}
+				highestWeight = weight
+			} else if weight == highestWeight {
+				preferredOptions = append(preferredOptions, option)
+			}
+		}
+		klog.V(4).Infof("%d of %d expansion options scale up node groups of weight %d", len(preferredOptions), len(expansionOptions), highestWeight)
+		return w.fallbackStrategy.BestOption(preferredOptions, nodeInfo)
+	}
+	return w.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+}
+
+// getConfig returns the config of the weights ConfigMap, or nil if there is none or it is invalid.
+func (w *weighted) getConfig() *parsedConfig {
+	configMap, err := w.configMapLister.Get(ConfigMapName)
+	if err != nil {
+		if !kube_errors.IsNotFound(err) {
+			klog.Warningf("Failed to get %s, ignoring node group weights: %v", ConfigMapName, err)
+		}
+		w.resourceVersion, w.config = "", nil
+		return nil
+	}
+	if configMap.ResourceVersion != w.resourceVersion || configMap.ResourceVersion == "" {
+		w.resourceVersion = configMap.ResourceVersion
+		w.config, err = parseConfig(configMap.Data[WeightsKey])
+		if err != nil {
+			klog.Warningf("Invalid %s in %s, ignoring node group weights: %v", WeightsKey, ConfigMapName, err)
+		}
+	}
+	return w.config
+}
+
+func parseConfig(data string) (*parsedConfig, error) {
+	var c config
+	if err := yaml.Unmarshal([]byte(data), &c); err != nil {
+		return nil, err
+	}
+	location := time.UTC
+	if c.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(c.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	parsed := &parsedConfig{location: location}
+	for i, window := range c.Windows {
+		p, err := parseWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %v", i, err)
+		}
+		parsed.windows = append(parsed.windows, p)
+	}
+	return parsed, nil
+}
+
+func parseWindow(w window) (parsedWindow, error) {
+	p := parsedWindow{days: make(map[time.Weekday]bool), weights: make(map[*regexp.Regexp]int)}
+	for _, day := range w.Days {
+		weekday, found := weekdays[strings.ToLower(day)]
+		if !found {
+			return p, fmt.Errorf("invalid day %q", day)
+		}
+		p.days[weekday] = true
+	}
+	if w.From == "" && w.To == "" {
+		p.allDay = true
+	} else {
+		var err error
+		if p.from, err = parseTimeOfDay(w.From); err != nil {
+			return p, err
+		}
+		if p.to, err = parseTimeOfDay(w.To); err != nil {
+			return p, err
+		}
+	}
+	for expr, weight := range w.Weights {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return p, err
+		}
+		p.weights[re] = weight
+	}
+	return p, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse(timeOfDayLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected the %s format", value, timeOfDayLayout)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// includes returns true if the window includes the given time.
+func (w parsedWindow) includes(now time.Time) bool {
+	if len(w.days) > 0 && !w.days[now.Weekday()] {
+		return false
+	}
+	if w.allDay {
+		return true
+	}
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if w.from <= w.to {
+		return w.from <= timeOfDay && timeOfDay < w.to
+	}
+	return w.from <= timeOfDay || timeOfDay < w.to
+}
+
+// weight returns the highest weight of the expressions matching the node group id, or 0 if none does.
+func (w parsedWindow) weight(id string) int {
+	weight, found := 0, false
+	for re, value := range w.weights {
+		if re.MatchString(id) && (!found || value > weight) {
+			weight, found = value, true
+		}
+	}
+	return weight
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package weights
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/mostpods"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWeights = `
+timeZone: Europe/Paris
+windows:
+- days: [Mon, Tue, Wed, Thu, Fri]
+  from: "08:00"
+  to: "18:00"
+  weights:
+    ".*-on-demand": 10
+    ".*-spot": 1
+- from: "22:00"
+  to: "06:00"
+  weights:
+    ".*-spot": 10
+`
+
+func TestWeightedStrategy(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng-on-demand", 0, 10, 0)
+	provider.AddNodeGroup("ng-spot", 0, 10, 0)
+	provider.AddNodeGroup("other-spot", 0, 10, 0)
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 100, 0)
+	onDemand := expander.Option{NodeGroup: provider.GetNodeGroup("ng-on-demand"), NodeCount: 1, Pods: []*apiv1.Pod{p1}}
+	spot := expander.Option{NodeGroup: provider.GetNodeGroup("ng-spot"), NodeCount: 1, Pods: []*apiv1.Pod{p1}}
+	otherSpot := expander.Option{NodeGroup: provider.GetNodeGroup("other-spot"), NodeCount: 1, Pods: []*apiv1.Pod{p1, p2}}
+	options := []expander.Option{onDemand, spot, otherSpot}
+
+	lister, err := kube_util.NewTestConfigMapLister([]*apiv1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "kube-system", ResourceVersion: "1"},
+		Data:       map[string]string{WeightsKey: testWeights},
+	}})
+	assert.NoError(t, err)
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+	strategy := NewStrategy(mostpods.NewStrategy(), lister.ConfigMaps("kube-system")).(*weighted)
+
+	testCases := []struct {
+		name     string
+		now      time.Time
+		expected expander.Option
+	}{
+		// 2019-06-03 is a Monday.
+		{name: "business hours", now: time.Date(2019, 6, 3, 10, 0, 0, 0, paris), expected: onDemand},
+		{name: "night before midnight", now: time.Date(2019, 6, 3, 23, 0, 0, 0, paris), expected: otherSpot},
+		{name: "night after midnight", now: time.Date(2019, 6, 4, 5, 59, 0, 0, paris), expected: otherSpot},
+		{name: "week-end", now: time.Date(2019, 6, 8, 10, 0, 0, 0, paris), expected: otherSpot},
+		{name: "other time zone", now: time.Date(2019, 6, 3, 7, 0, 0, 0, time.UTC), expected: onDemand},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy.now = func() time.Time { return tc.now }
+			assert.Equal(t, tc.expected, *strategy.BestOption(options, nil))
+		})
+	}
+
+	// The fallback chooses among the node groups of the highest weight.
+	strategy.now = func() time.Time { return time.Date(2019, 6, 3, 23, 0, 0, 0, paris) }
+	assert.Equal(t, spot, *strategy.BestOption([]expander.Option{onDemand, spot}, nil))
+}
+
+func TestWeightedStrategyWithoutWeights(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 0)
+	provider.AddNodeGroup("ng2", 0, 10, 0)
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 100, 0)
+	ng1 := expander.Option{NodeGroup: provider.GetNodeGroup("ng1"), NodeCount: 1, Pods: []*apiv1.Pod{p1}}
+	ng2 := expander.Option{NodeGroup: provider.GetNodeGroup("ng2"), NodeCount: 1, Pods: []*apiv1.Pod{p1, p2}}
+
+	for name, configMaps := range map[string][]*apiv1.ConfigMap{
+		"no config map": nil,
+		"invalid weights": {{
+			ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "kube-system"},
+			Data:       map[string]string{WeightsKey: "windows: [{from: noon, weights: {ng1: 10}}]"},
+		}},
+		"no window in effect": {{
+			ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "kube-system"},
+			Data:       map[string]string{WeightsKey: "windows: [{days: [Sun], weights: {ng1: 10}}]"},
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			lister, err := kube_util.NewTestConfigMapLister(configMaps)
+			assert.NoError(t, err)
+			strategy := NewStrategy(mostpods.NewStrategy(), lister.ConfigMaps("kube-system")).(*weighted)
+			strategy.now = func() time.Time { return time.Date(2019, 6, 3, 10, 0, 0, 0, time.UTC) }
+			assert.Equal(t, ng2, *strategy.BestOption([]expander.Option{ng1, ng2}, nil))
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	for name, data := range map[string]string{
+		"invalid yaml":       "windows: {",
+		"invalid time zone":  "timeZone: Nowhere/Else\nwindows: []",
+		"invalid day":        "windows: [{days: [Someday], weights: {ng1: 1}}]",
+		"missing end":        `windows: [{from: "08:00", weights: {ng1: 1}}]`,
+		"invalid expression": `windows: [{weights: {"ng(": 1}}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseConfig(data)
+			assert.Error(t, err)
+		})
+	}
+}
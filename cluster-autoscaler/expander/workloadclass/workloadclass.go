@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadclass
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+type router struct {
+	label           string
+	strategies      map[string]expander.Strategy
+	defaultStrategy expander.Strategy
+}
+
+// NewStrategy returns a scale up strategy (expander) that routes the choice to the strategy of the workload
+// class of the pending pods, which is the value of the given label on them. The class of most pending pods
+// wins. The default strategy is used if there's no such class or it has no strategy of its own.
+func NewStrategy(label string, strategies map[string]expander.Strategy, defaultStrategy expander.Strategy) expander.Strategy {
+	return &router{
+		label:           label,
+		strategies:      strategies,
+		defaultStrategy: defaultStrategy,
+	}
+}
+
+// BestOption selects the best option with the strategy of the workload class of the pending pods.
+func (r *router) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	class := r.workloadClass(expansionOptions)
+	if strategy, found := r.strategies[class]; found {
+		klog.V(4).Infof("Using the expander of workload class %s", class)
+		return strategy.BestOption(expansionOptions, nodeInfo)
+	}
+	return r.defaultStrategy.BestOption(expansionOptions, nodeInfo)
+}
+
+// workloadClass returns the class of most of the pods helped by the options, or an empty string if
+// there's a tie between classes.
+func (r *router) workloadClass(expansionOptions []expander.Option) string {
+	seen := make(map[*apiv1.Pod]bool)
+	podsPerClass := make(map[string]int)
+	for _, option := range expansionOptions {
+		for _, pod := range option.Pods {
+			if seen[pod] {
+				continue
+			}
+			seen[pod] = true
+			podsPerClass[r.podClass(pod)]++
+		}
+	}
+
+	bestClass, bestCount, tie := "", 0, false
+	for class, count := range podsPerClass {
+		if count > bestCount {
+			bestClass, bestCount, tie = class, count, false
+		} else if count == bestCount {
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return bestClass
+}
+
+func (r *router) podClass(pod *apiv1.Pod) string {
+	return pod.Labels[r.label]
+}
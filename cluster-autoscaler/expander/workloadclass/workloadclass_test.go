@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadclass
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// pickStrategy always picks the option at the given index.
+type pickStrategy struct {
+	index int
+}
+
+func (p *pickStrategy) BestOption(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	return &options[p.index]
+}
+
+func buildClassPod(name, class string) *apiv1.Pod {
+	pod := BuildTestPod(name, 100, 0)
+	if class != "" {
+		pod.Labels = map[string]string{"workload-class": class}
+	}
+	return pod
+}
+
+func TestWorkloadClassRouting(t *testing.T) {
+	e := NewStrategy("workload-class", map[string]expander.Strategy{
+		"batch":   &pickStrategy{1},
+		"serving": &pickStrategy{2},
+	}, &pickStrategy{0})
+
+	batch1 := buildClassPod("batch1", "batch")
+	batch2 := buildClassPod("batch2", "batch")
+	serving := buildClassPod("serving", "serving")
+	plain := buildClassPod("plain", "")
+
+	options := func(pods ...*apiv1.Pod) []expander.Option {
+		return []expander.Option{{Debug: "0", Pods: pods}, {Debug: "1", Pods: pods}, {Debug: "2", Pods: pods}}
+	}
+
+	assert.Equal(t, "1", e.BestOption(options(batch1, batch2, serving), nil).Debug)
+	assert.Equal(t, "2", e.BestOption(options(serving), nil).Debug)
+	assert.Equal(t, "0", e.BestOption(options(plain), nil).Debug)
+	// Ties between classes are resolved by the default strategy.
+	assert.Equal(t, "0", e.BestOption(options(batch1, serving), nil).Debug)
+
+	// Pods helped by several options are counted once.
+	shared := []expander.Option{
+		{Debug: "0", Pods: []*apiv1.Pod{serving, batch1}},
+		{Debug: "1", Pods: []*apiv1.Pod{serving}},
+		{Debug: "2", Pods: []*apiv1.Pod{serving}},
+	}
+	assert.Equal(t, "0", e.BestOption(shared, nil).Debug)
+}
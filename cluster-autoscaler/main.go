@@ -29,18 +29,27 @@ import (
 	"syscall"
 	"time"
 
+	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/client"
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/core"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/spot"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/weights"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodes"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/pods"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+	"k8s.io/client-go/dynamic"
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -78,9 +87,12 @@ func multiStringFlag(name string, usage string) *MultiStringFlag {
 var (
 	clusterName            = flag.String("cluster-name", "", "Autoscaled cluster name, if available")
 	address                = flag.String("address", ":8085", "The address to expose prometheus metrics.")
+	stateImportEnabled     = flag.Bool("state-import-enabled", false, "Should CA accept the runtime state of another instance, imported with the "+importStateCommand+" command on the metrics address. Requires --state-token-file")
+	stateTokenFile         = flag.String("state-token-file", "", "File holding the bearer token required to export and import the runtime state on the metrics address")
 	kubernetes             = flag.String("kubernetes", "", "Kubernetes master location. Leave blank for default")
 	kubeConfigFile         = flag.String("kubeconfig", "", "Path to kubeconfig file with authorization and master location information.")
 	cloudConfig            = flag.String("cloud-config", "", "The path to the cloud provider configuration file.  Empty string for no configuration file.")
+	cloudConfigReload      = flag.Bool("cloud-config-reload", false, "Should the cloud provider be rebuilt when the content of the cloud-config file changes, e.g. on credentials rotation")
 	namespace              = flag.String("namespace", "kube-system", "Namespace in which cluster-autoscaler run.")
 	scaleDownEnabled       = flag.Bool("scale-down-enabled", true, "Should CA scale down the cluster")
 	scaleDownDelayAfterAdd = flag.Duration("scale-down-delay-after-add", 10*time.Minute,
@@ -93,8 +105,17 @@ var (
 		"How long a node should be unneeded before it is eligible for scale down")
 	scaleDownUnreadyTime = flag.Duration("scale-down-unready-time", 20*time.Minute,
 		"How long an unready node should be unneeded before it is eligible for scale down")
+	scaleDownMinNodeLifetime = flag.Duration("scale-down-min-node-lifetime", 0,
+		"How long a node has to exist before it is eligible for scale down. Protects nodes added by a scale-up from being removed when the load oscillates")
+	nodeGroupMinNodeLifetime = multiStringFlag("node-group-min-node-lifetime",
+		"Overrides --scale-down-min-node-lifetime for a node group, in the format <node group id>=<duration>. Can be passed multiple times.")
 	scaleDownUtilizationThreshold = flag.Float64("scale-down-utilization-threshold", 0.5,
 		"Node utilization level, defined as sum of requested resources divided by capacity, below which a node can be considered for scale down")
+	scaleDownCompactionTarget = flag.Float64("scale-down-compaction-target", 0,
+		"Average utilization of the nodes considered for scale down below which CA evicts the pods of a node above scale-down-utilization-threshold, "+
+			"so that the node can be removed once its pods are rescheduled on other nodes. 0 disables compaction")
+	scaleDownCompactionDelay = flag.Duration("scale-down-compaction-delay", 10*time.Minute,
+		"Minimum time between two compactions")
 	scaleDownNonEmptyCandidatesCount = flag.Int("scale-down-non-empty-candidates-count", 30,
 		"Maximum number of non empty nodes considered in one iteration as candidates for scale down with drain."+
 			"Lower value means better CA responsiveness but possible slower scale down latency."+
@@ -111,16 +132,41 @@ var (
 			"for scale down when some candidates from previous iteration are no longer valid."+
 			"When calculating the pool size for additional candidates we take"+
 			"max(#nodes * scale-down-candidates-pool-ratio, scale-down-candidates-pool-min-count).")
+	scaleDownPreserveZoneSpread = flag.Bool("scale-down-preserve-zone-spread", false,
+		"When choosing which node to scale down, prefer the nodes whose removal degrades the zone spread of the remaining replicas of Deployments and StatefulSets the least")
+	scaleDownStatefulSetOrdering = flag.Bool("scale-down-statefulset-ordering", false,
+		"When choosing which node to scale down, prefer the nodes whose pods of OrderedReady StatefulSets can be recreated right away, and wait for these StatefulSets to have all their replicas ready before removing another of their nodes")
+	scaleDownEmptyInterval = flag.Duration("scale-down-empty-interval", 0,
+		"How often empty unneeded nodes are removed between the iterations of the main loop. 0 disables it and empty nodes are only removed every scan-interval")
+	nodeBusynessPrometheusURL = flag.String("node-busyness-prometheus-url", "",
+		"Prometheus server queried for the busyness of nodes, which is used in scale down when it exceeds their utilization. Empty disables it")
+	nodeBusynessPrometheusQuery = flag.String("node-busyness-prometheus-query", "",
+		"PromQL query returning the busyness of every node, between 0 and 1, e.g. its CPU usage")
+	nodeBusynessPrometheusNodeLabel = flag.String("node-busyness-prometheus-node-label", nodes.DefaultPrometheusNodeLabel,
+		"Label of the node-busyness-prometheus-query results holding the node name")
+	drainOnlyNodeGroupsFlag = multiStringFlag("drain-only-node-group",
+		"Id of a node group whose nodes are only drained and annotated on scale down, its instances being removed by an external system. Can be passed multiple times.")
+	nodeReadinessConditionsFlag = multiStringFlag("node-readiness-condition",
+		"Node condition type that has to be True on a ready node of a node group before it's counted as available capacity, unless the node group overrides the readiness gates. Can be passed multiple times.")
+	nodeReadinessLabelsFlag = multiStringFlag("node-readiness-label",
+		"Label, in the format <key> or <key>=<value>, a ready node of a node group has to carry before it's counted as available capacity, unless the node group overrides the readiness gates. Can be passed multiple times.")
+	unchangedStateSkipDuration = flag.Duration("unchanged-state-skip-duration", 0,
+		"How long iterations are skipped while the cluster state doesn't change after an iteration that had nothing to do. 0 disables skipping")
+	staleTaintCleanupAge = flag.Duration("stale-taint-cleanup-age", 0,
+		"Age after which ToBeDeleted and DeletionCandidate taints left by crashed or replaced instances are removed from nodes. Only safe when no other autoscaler instance taints the nodes. 0 disables the cleanup")
 	scanInterval      = flag.Duration("scan-interval", 10*time.Second, "How often cluster is reevaluated for scale up or down")
 	maxNodesTotal     = flag.Int("max-nodes-total", 0, "Maximum number of nodes in all node groups. Cluster autoscaler will not grow the cluster beyond this number.")
 	coresTotal        = flag.String("cores-total", minMaxFlagString(0, config.DefaultMaxClusterCores), "Minimum and maximum number of cores in cluster, in the format <min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers.")
 	memoryTotal       = flag.String("memory-total", minMaxFlagString(0, config.DefaultMaxClusterMemory), "Minimum and maximum number of gigabytes of memory in cluster, in the format <min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers.")
 	gpuTotal          = multiStringFlag("gpu-total", "Minimum and maximum number of different GPUs in cluster, in the format <gpu_type>:<min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers. Can be passed multiple times. CURRENTLY THIS FLAG ONLY WORKS ON GKE.")
-	cloudProviderFlag = flag.String("cloud-provider", cloudBuilder.DefaultCloudProvider,
-		"Cloud provider type. Available values: ["+strings.Join(cloudBuilder.AvailableCloudProviders, ",")+"]")
+	cloudProviderFlag = flag.String("cloud-provider", cloudBuilder.DefaultCloudProvider(),
+		"Cloud provider type. Available values: ["+strings.Join(cloudBuilder.AvailableCloudProviders(), ",")+"]")
+	machineAPINamespaces       = multiStringFlag("machine-api-namespace", "Namespace whose machine API objects are watched by the openshiftmachineapi cloud provider. Can be passed multiple times. Objects of all namespaces are watched if not set.")
+	machineAPILabelSelector    = flag.String("machine-api-label-selector", "", "Label selector of the MachineSets and MachineDeployments the openshiftmachineapi cloud provider uses as node groups, e.g. to split them between several cluster autoscalers. All of them are used if not set.")
 	maxBulkSoftTaintCount      = flag.Int("max-bulk-soft-taint-count", 10, "Maximum number of nodes that can be tainted/untainted PreferNoSchedule at the same time. Set to 0 to turn off such tainting.")
 	maxBulkSoftTaintTime       = flag.Duration("max-bulk-soft-taint-time", 3*time.Second, "Maximum duration of tainting/untainting nodes as PreferNoSchedule at the same time.")
 	maxEmptyBulkDeleteFlag     = flag.Int("max-empty-bulk-delete", 10, "Maximum number of empty nodes that can be deleted at the same time.")
+	maxConcurrentNodeGroupOps  = flag.Int("max-concurrent-node-group-operations", 0, "Maximum number of node group size increases and node deletions running at the same time against the cloud provider, shared fairly between node groups. 0 means no limit.")
 	maxGracefulTerminationFlag = flag.Int("max-graceful-termination-sec", 10*60, "Maximum number of seconds CA waits for pod termination when trying to scale down a node.")
 	maxTotalUnreadyPercentage  = flag.Float64("max-total-unready-percentage", 45, "Maximum percentage of unready nodes in the cluster.  After this is exceeded, CA halts operations")
 	okTotalUnreadyCount        = flag.Int("ok-total-unready-count", 3, "Number of allowed unready nodes, irrespective of max-total-unready-percentage")
@@ -141,9 +187,35 @@ var (
 
 	expanderFlag = flag.String("expander", expander.RandomExpanderName,
 		"Type of node group expander to be used in scale up. Available values: ["+strings.Join(expander.AvailableExpanders, ",")+"]")
+	workloadClassLabel = flag.String("workload-class-label", "workload-class",
+		"Pod label holding the workload class of pods, used to pick the expander with workload-class-expander")
+	workloadClassExpanders = multiStringFlag("workload-class-expander",
+		"Expander to be used in scale up of pods of a workload class, in the format <workload class>=<expander>. Can be passed multiple times.")
+	expanderHintNamespaces = multiStringFlag("expander-hint-namespace",
+		"Namespace whose pending pods may choose the expander or the node group order of their scale-up with annotations. Can be passed multiple times.")
+	schedulerNames = multiStringFlag("scheduler-name",
+		"Scheduler whose unschedulable pods trigger scale up, in the format <scheduler name> or <scheduler name>=<predicate>,... to only check the given predicates for its pods. Can be passed multiple times. Pods of all schedulers trigger scale up if not passed.")
+	deviceResources = multiStringFlag("device-resource",
+		"Extended resource device plugins share between the pods of a device, in the format <resource>=<device count resource>, e.g. aliyun.com/gpu-mem=aliyun.com/gpu-count. Requests of the resource are packed on single devices when estimating scale up. Can be passed multiple times.")
+	devicePodPendingTimeout = flag.Duration("device-pod-pending-timeout", 0,
+		"How long a scheduled pod requesting a device resource may wait for its containers to be created, e.g. because the resource is fragmented across the devices of its node, before it triggers scale up like an unschedulable pod. 0 disables it.")
+	balancingIgnoredLabels = multiStringFlag("balancing-ignore-label",
+		"Label the nodes of similar node groups can differ in, on top of the ones ignored by default and by the cloud provider, when balancing them with balance-similar-node-groups. Can be passed multiple times.")
+	spotNodeGroupPreference = flag.String("spot-node-group-preference", "",
+		"Whether scale up should prefer (prefer) or avoid (avoid) the node groups backed by spot or preemptible instances, on the cloud providers labeling their nodes. Empty to treat them like the other node groups.")
+	statefulSetNodeGroupStickiness = flag.Bool("statefulset-node-group-stickiness", false,
+		"Prefer scaling up the node groups already hosting other replicas of the StatefulSets of pending pods")
+	nodeGroupWeights = flag.Bool("node-group-weights", false,
+		"Prefer scaling up the node groups with the highest weight in the time window in effect, according to the "+weights.ConfigMapName+" ConfigMap")
 
 	ignoreDaemonSetsUtilization = flag.Bool("ignore-daemonsets-utilization", false,
 		"Should CA ignore DaemonSet pods when calculating resource utilization for scaling down")
+	reservedDaemonSetFraction = flag.Float64("daemonset-requests-reserved-fraction", 0,
+		"Fraction of DaemonSet pod requests, between 0 and 1, that CA doesn't count when calculating resource utilization for scaling down")
+	nodeGroupReservedDaemonSetFraction = multiStringFlag("node-group-daemonset-requests-reserved-fraction",
+		"Overrides --daemonset-requests-reserved-fraction and --ignore-daemonsets-utilization for a node group, in the format <node group id>=<fraction>. Can be passed multiple times.")
+	daemonSetRolloutHeadroom = flag.Bool("daemonset-rollout-headroom", false,
+		"Should CA keep room for the updated pods of DaemonSets being rolled out when moving the pods of scaled-down nodes")
 	ignoreMirrorPodsUtilization = flag.Bool("ignore-mirror-pods-utilization", false,
 		"Should CA ignore Mirror pods when calculating resource utilization for scaling down")
 
@@ -157,11 +229,20 @@ var (
 	unremovableNodeRecheckTimeout       = flag.Duration("unremovable-node-recheck-timeout", 5*time.Minute, "The timeout before we check again a node that couldn't be removed before")
 	expendablePodsPriorityCutoff        = flag.Int("expendable-pods-priority-cutoff", -10, "Pods with priority below cutoff will be expendable. They can be killed without any consideration during scale down and they don't cause scale up. Pods with null priority (PodPriority disabled) are non expendable.")
 	regional                            = flag.Bool("regional", false, "Cluster is regional.")
+	applyLimitRangeDefaults             = flag.Bool("apply-limit-range-defaults", false, "Set the default requests of namespace LimitRanges on pending pods lacking requests before simulating scale-up")
+	maxPodScaleUpAttempts               = flag.Int("max-pod-scale-up-attempts", 0, "Number of scale-ups a pod can trigger without becoming schedulable before it's excluded from scale-up. 0 disables the limit")
 	newPodScaleUpDelay                  = flag.Duration("new-pod-scale-up-delay", 0*time.Second, "Pods less than this old will not be considered for scale-up.")
 	filterOutSchedulablePodsUsesPacking = flag.Bool("filter-out-schedulable-pods-uses-packing", true,
 		"Filtering out schedulable pods before CA scale up by trying to pack the schedulable pods on free capacity on existing nodes."+
 			"Setting it to false employs a more lenient filtering approach that does not try to pack the pods on the nodes."+
 			"Pods with nominatedNodeName set are always filtered out.")
+	notificationWebhookURL       = flag.String("notification-webhook-url", "", "URL scale events and failures are posted to, e.g. a Slack incoming webhook. Empty disables notifications")
+	notificationWebhookFormat    = flag.String("notification-webhook-format", status.WebhookFormatJSON, "Payload format of notification-webhook-url. Available values: ["+status.WebhookFormatJSON+","+status.WebhookFormatSlack+"]")
+	removeUninitializedTaint     = flag.Bool("remove-uninitialized-taint", false, "Remove the cluster-autoscaler.kubernetes.io/uninitialized taint from nodes once they become ready")
+	annotateNodesWithNodeGroup   = flag.Bool("annotate-nodes-with-node-group", false, "Annotate nodes with the id of their node group and the autoscaler config generation when they join the cluster")
+	ipamAwareScaleUp             = flag.Bool("ipam-aware-scale-up", false, "Skip node groups whose subnets have no free IP addresses left during scale-up, for cloud providers that report it")
+	ghostNodeDeletionGracePeriod = flag.Duration("ghost-node-deletion-grace-period", 0, "How long a node whose instance doesn't exist in the cloud provider anymore is kept before it's deleted, for cloud providers that report it. 0 disables the deletion")
+	nodeGroupSpecsEnabled        = flag.Bool("node-group-specs-enabled", false, "Create node groups for the NodeGroupSpec custom resources. Only supported by the gke and openshift-machine-api cloud providers")
 )
 
 func createAutoscalingOptions() config.AutoscalingOptions {
@@ -182,6 +263,38 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		klog.Fatalf("Failed to parse flags: %v", err)
 	}
 
+	parsedNodeGroupMinNodeLifetimes, err := parseNodeGroupMinNodeLifetimes(*nodeGroupMinNodeLifetime)
+	if err != nil {
+		klog.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	parsedWorkloadClassExpanders, err := parseWorkloadClassExpanders(*workloadClassExpanders)
+	if err != nil {
+		klog.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	parsedSchedulerNames, parsedSchedulerPredicates, err := parseSchedulerNames(*schedulerNames)
+	if err != nil {
+		klog.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	parsedDeviceResources, err := parseDeviceResources(*deviceResources)
+	if err != nil {
+		klog.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	if *reservedDaemonSetFraction < 0 || *reservedDaemonSetFraction > 1 {
+		klog.Fatalf("Failed to parse flags: daemonset-requests-reserved-fraction must be between 0 and 1, got %v", *reservedDaemonSetFraction)
+	}
+	parsedNodeGroupReservedDaemonSetFractions, err := parseNodeGroupReservedDaemonSetFractions(*nodeGroupReservedDaemonSetFraction)
+	if err != nil {
+		klog.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	if *spotNodeGroupPreference != "" && *spotNodeGroupPreference != spot.Prefer && *spotNodeGroupPreference != spot.Avoid {
+		klog.Fatalf("Failed to parse flags: spot-node-group-preference must be %q, %q or empty, got %q", spot.Prefer, spot.Avoid, *spotNodeGroupPreference)
+	}
+
 	return config.AutoscalingOptions{
 		CloudConfig:                         *cloudConfig,
 		CloudProviderName:                   *cloudProviderFlag,
@@ -191,6 +304,8 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		EstimatorName:                       *estimatorFlag,
 		ExpanderName:                        *expanderFlag,
 		IgnoreDaemonSetsUtilization:         *ignoreDaemonSetsUtilization,
+		ReservedDaemonSetFraction:           *reservedDaemonSetFraction,
+		NodeGroupReservedDaemonSetFractions: parsedNodeGroupReservedDaemonSetFractions,
 		IgnoreMirrorPodsUtilization:         *ignoreMirrorPodsUtilization,
 		MaxBulkSoftTaintCount:               *maxBulkSoftTaintCount,
 		MaxBulkSoftTaintTime:                *maxBulkSoftTaintTime,
@@ -210,6 +325,8 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		ScaleDownEnabled:                    *scaleDownEnabled,
 		ScaleDownUnneededTime:               *scaleDownUnneededTime,
 		ScaleDownUnreadyTime:                *scaleDownUnreadyTime,
+		ScaleDownMinNodeLifetime:            *scaleDownMinNodeLifetime,
+		NodeGroupMinNodeLifetimes:           parsedNodeGroupMinNodeLifetimes,
 		ScaleDownUtilizationThreshold:       *scaleDownUtilizationThreshold,
 		ScaleDownNonEmptyCandidatesCount:    *scaleDownNonEmptyCandidatesCount,
 		ScaleDownCandidatesPoolRatio:        *scaleDownCandidatesPoolRatio,
@@ -224,8 +341,46 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		ExpendablePodsPriorityCutoff:        *expendablePodsPriorityCutoff,
 		Regional:                            *regional,
 		NewPodScaleUpDelay:                  *newPodScaleUpDelay,
+		MaxPodScaleUpAttempts:               *maxPodScaleUpAttempts,
+		ApplyLimitRangeDefaults:             *applyLimitRangeDefaults,
 		FilterOutSchedulablePodsUsesPacking: *filterOutSchedulablePodsUsesPacking,
 		KubeConfigPath:                      *kubeConfigFile,
+		IPAMAwareScaleUp:                    *ipamAwareScaleUp,
+		GhostNodeDeletionGracePeriod:        *ghostNodeDeletionGracePeriod,
+		ScaleDownPreserveZoneSpread:         *scaleDownPreserveZoneSpread,
+		ScaleDownStatefulSetOrdering:        *scaleDownStatefulSetOrdering,
+		ScaleDownEmptyInterval:              *scaleDownEmptyInterval,
+		DrainOnlyNodeGroups:                 *drainOnlyNodeGroupsFlag,
+		NodeBusynessPrometheusURL:           *nodeBusynessPrometheusURL,
+		NodeBusynessPrometheusQuery:         *nodeBusynessPrometheusQuery,
+		NodeBusynessPrometheusNodeLabel:     *nodeBusynessPrometheusNodeLabel,
+		NotificationWebhookURL:              *notificationWebhookURL,
+		NotificationWebhookFormat:           *notificationWebhookFormat,
+		RemoveUninitializedTaint:            *removeUninitializedTaint,
+		AnnotateNodesWithNodeGroup:          *annotateNodesWithNodeGroup,
+		NodeReadinessConditions:             *nodeReadinessConditionsFlag,
+		NodeReadinessLabels:                 *nodeReadinessLabelsFlag,
+		StatefulSetNodeGroupStickiness:      *statefulSetNodeGroupStickiness,
+		NodeGroupWeights:                    *nodeGroupWeights,
+		UnchangedStateSkipDuration:          *unchangedStateSkipDuration,
+		StaleTaintCleanupAge:                *staleTaintCleanupAge,
+		WorkloadClassLabel:                  *workloadClassLabel,
+		WorkloadClassExpanders:              parsedWorkloadClassExpanders,
+		ExpanderHintNamespaces:              *expanderHintNamespaces,
+		CloudConfigReload:                   *cloudConfigReload,
+		DaemonSetRolloutHeadroom:            *daemonSetRolloutHeadroom,
+		MaxConcurrentNodeGroupOperations:    *maxConcurrentNodeGroupOps,
+		MachineAPINamespaces:                *machineAPINamespaces,
+		MachineAPILabelSelector:             *machineAPILabelSelector,
+		ScaleDownCompactionTarget:           *scaleDownCompactionTarget,
+		ScaleDownCompactionDelay:            *scaleDownCompactionDelay,
+		NodeGroupSpecsEnabled:               *nodeGroupSpecsEnabled,
+		SchedulerNames:                      parsedSchedulerNames,
+		SchedulerPredicates:                 parsedSchedulerPredicates,
+		DeviceResources:                     parsedDeviceResources,
+		DevicePodPendingTimeout:             *devicePodPendingTimeout,
+		BalancingIgnoredLabels:              *balancingIgnoredLabels,
+		SpotNodeGroupPreference:             *spotNodeGroupPreference,
 	}
 }
 
@@ -277,10 +432,56 @@ func buildAutoscaler() (core.Autoscaler, error) {
 	kubeClient := createKubeClient(getKubeConfig())
 	eventsKubeClient := createKubeClient(getKubeConfig())
 	processors := ca_processors.DefaultProcessors()
+	nodeGroupSetProcessor := &nodegroupset.BalancingNodeGroupSetProcessor{
+		IgnoredLabels: autoscalingOptions.BalancingIgnoredLabels}
 	if autoscalingOptions.CloudProviderName == "gke" {
-		processors.NodeGroupSetProcessor = &nodegroupset.BalancingNodeGroupSetProcessor{
-			Comparator: nodegroupset.IsGkeNodeInfoSimilar}
-
+		nodeGroupSetProcessor.Comparator = nodegroupset.IsGkeNodeInfoSimilar
+	}
+	if autoscalingOptions.CloudProviderName == "openshift-machine-api" {
+		nodeGroupSetProcessor.Comparator = nodegroupset.IsMachineAPINodeInfoSimilar
+	}
+	processors.NodeGroupSetProcessor = nodeGroupSetProcessor
+	if autoscalingOptions.NotificationWebhookURL != "" {
+		sink, err := status.NewWebhookNotificationSink(autoscalingOptions.NotificationWebhookURL, autoscalingOptions.NotificationWebhookFormat)
+		if err != nil {
+			return nil, err
+		}
+		processors.ScaleUpStatusProcessor = &status.NotifyingScaleUpStatusProcessor{Processor: processors.ScaleUpStatusProcessor, Sink: sink}
+		processors.ScaleDownStatusProcessor = &status.NotifyingScaleDownStatusProcessor{Processor: processors.ScaleDownStatusProcessor, Sink: sink}
+	}
+	if autoscalingOptions.ApplyLimitRangeDefaults {
+		limitRangeLister := kube_util.NewLimitRangeLister(kubeClient, make(chan struct{}))
+		processors.PodListProcessor = pods.NewLimitRangeDefaultsPodListProcessor(processors.PodListProcessor, limitRangeLister)
+	}
+	if len(autoscalingOptions.SchedulerNames) > 0 {
+		processors.PodListProcessor = pods.NewSchedulerNamePodListProcessor(processors.PodListProcessor, autoscalingOptions.SchedulerNames)
+	}
+	if len(autoscalingOptions.DeviceResources) > 0 && autoscalingOptions.DevicePodPendingTimeout > 0 {
+		resourceNames := make([]apiv1.ResourceName, 0, len(autoscalingOptions.DeviceResources))
+		for name := range autoscalingOptions.DeviceResources {
+			resourceNames = append(resourceNames, apiv1.ResourceName(name))
+		}
+		processors.PodListProcessor = pods.NewDevicePendingPodListProcessor(processors.PodListProcessor, resourceNames,
+			autoscalingOptions.DevicePodPendingTimeout)
+	}
+	if autoscalingOptions.NodeBusynessPrometheusURL != "" {
+		provider, err := nodes.NewPrometheusNodeBusynessProvider(autoscalingOptions.NodeBusynessPrometheusURL,
+			autoscalingOptions.NodeBusynessPrometheusQuery, autoscalingOptions.NodeBusynessPrometheusNodeLabel)
+		if err != nil {
+			return nil, err
+		}
+		processors.NodeBusynessProvider = provider
+	}
+	if autoscalingOptions.NodeGroupSpecsEnabled {
+		// Other cloud providers can't create node groups yet.
+		if autoscalingOptions.CloudProviderName != "gke" && autoscalingOptions.CloudProviderName != "openshift-machine-api" {
+			return nil, fmt.Errorf("--node-group-specs-enabled is only supported by the gke and openshift-machine-api cloud providers, not %s", autoscalingOptions.CloudProviderName)
+		}
+		dynamicClient, err := dynamic.NewForConfig(getKubeConfig())
+		if err != nil {
+			return nil, err
+		}
+		processors.NodeGroupSpecProcessor = nodegroups.NewCRDNodeGroupSpecProcessor(dynamicClient)
 	}
 	opts := core.AutoscalerOptions{
 		AutoscalingOptions: autoscalingOptions,
@@ -296,7 +497,7 @@ func buildAutoscaler() (core.Autoscaler, error) {
 	return core.NewAutoscaler(opts)
 }
 
-func run(healthCheck *metrics.HealthCheck) {
+func run(healthCheck *metrics.HealthCheck, state *stateHandler) {
 	metrics.RegisterAll()
 
 	autoscaler, err := buildAutoscaler()
@@ -304,16 +505,31 @@ func run(healthCheck *metrics.HealthCheck) {
 		klog.Fatalf("Failed to create autoscaler: %v", err)
 	}
 
+	// Serve and import the runtime state of the autoscaler.
+	state.setAutoscaler(autoscaler)
+
 	// Register signal handlers for graceful shutdown.
 	registerSignalHandlers(autoscaler)
 
 	// Start updating health check endpoint.
 	healthCheck.StartMonitoring()
 
+	// Empty nodes are removed between the iterations, so that their removal never races with
+	// the scale-ups of an iteration.
+	var emptyNodeCleanup <-chan time.Time
+	if *scaleDownEmptyInterval > 0 {
+		emptyNodeCleanup = time.Tick(*scaleDownEmptyInterval)
+	}
+
 	// Autoscale ad infinitum.
+	nextLoop := time.After(*scanInterval)
 	for {
 		select {
-		case <-time.After(*scanInterval):
+		case <-emptyNodeCleanup:
+			if err := autoscaler.RunEmptyNodeCleanup(time.Now()); err != nil && err.Type() != errors.TransientError {
+				metrics.RegisterError(err)
+			}
+		case <-nextLoop:
 			{
 				loopStart := time.Now()
 				metrics.UpdateLastTime(metrics.Main, loopStart)
@@ -327,6 +543,7 @@ func run(healthCheck *metrics.HealthCheck) {
 				}
 
 				metrics.UpdateDurationFromStart(metrics.Main, loopStart)
+				nextLoop = time.After(*scanInterval)
 			}
 		}
 	}
@@ -335,24 +552,52 @@ func run(healthCheck *metrics.HealthCheck) {
 func main() {
 	klog.InitFlags(nil)
 
+	if len(os.Args) > 1 && os.Args[1] == generateManifestsCommand {
+		if err := generateManifests(os.Stdout, os.Args[2:]); err != nil && err != pflag.ErrHelp {
+			klog.Fatalf("Failed to generate manifests: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == exportStateCommand {
+		if err := exportState(os.Stdout, os.Args[2:]); err != nil && err != pflag.ErrHelp {
+			klog.Fatalf("Failed to export state: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == importStateCommand {
+		if err := importState(os.Stdin, os.Args[2:]); err != nil && err != pflag.ErrHelp {
+			klog.Fatalf("Failed to import state: %v", err)
+		}
+		return
+	}
+
 	leaderElection := defaultLeaderElectionConfiguration()
 	leaderElection.LeaderElect = true
 
 	leaderelectionconfig.BindFlags(&leaderElection, pflag.CommandLine)
 	kube_flag.InitFlags()
 	healthCheck := metrics.NewHealthCheck(*maxInactivityTimeFlag, *maxFailingTimeFlag)
+	stateToken, err := readStateToken(*stateTokenFile)
+	if err != nil {
+		klog.Fatalf("Failed to read state token: %v", err)
+	}
+	if *stateImportEnabled && stateToken == "" {
+		klog.Fatalf("--state-import-enabled requires --state-token-file")
+	}
+	state := newStateHandler(*stateImportEnabled, stateToken)
 
 	klog.V(1).Infof("Cluster Autoscaler %s", ClusterAutoscalerVersion)
 
 	go func() {
 		http.Handle("/metrics", prometheus.Handler())
 		http.Handle("/health-check", healthCheck)
+		http.Handle(client.StatePath, state)
 		err := http.ListenAndServe(*address, nil)
 		klog.Fatalf("Failed to start metrics: %v", err)
 	}()
 
 	if !leaderElection.LeaderElect {
-		run(healthCheck)
+		run(healthCheck, state)
 	} else {
 		id, err := os.Hostname()
 		if err != nil {
@@ -391,7 +636,7 @@ func main() {
 				OnStartedLeading: func(_ ctx.Context) {
 					// Since we are committing a suicide after losing
 					// mastership, we can safely ignore the argument.
-					run(healthCheck)
+					run(healthCheck, state)
 				},
 				OnStoppedLeading: func() {
 					klog.Fatalf("lost master")
@@ -467,6 +712,102 @@ func parseMultipleGpuLimits(flags MultiStringFlag) ([]config.GpuLimits, error) {
 	return parsedFlags, nil
 }
 
+func parseNodeGroupMinNodeLifetimes(flags MultiStringFlag) (map[string]time.Duration, error) {
+	lifetimes := make(map[string]time.Duration, len(flags))
+	for _, flag := range flags {
+		separator := strings.LastIndex(flag, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("incorrect node group min node lifetime specification: %v", flag)
+		}
+		lifetime, err := time.ParseDuration(flag[separator+1:])
+		if err != nil {
+			return nil, fmt.Errorf("incorrect node group min node lifetime - not a duration: %v", flag)
+		}
+		if lifetime < 0 {
+			return nil, fmt.Errorf("incorrect node group min node lifetime - less than 0: %v", flag)
+		}
+		lifetimes[flag[:separator]] = lifetime
+	}
+	return lifetimes, nil
+}
+
+func parseNodeGroupReservedDaemonSetFractions(flags MultiStringFlag) (map[string]float64, error) {
+	fractions := make(map[string]float64, len(flags))
+	for _, flag := range flags {
+		separator := strings.LastIndex(flag, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("incorrect node group reserved daemonset fraction specification: %v", flag)
+		}
+		fraction, err := strconv.ParseFloat(flag[separator+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect node group reserved daemonset fraction - not a number: %v", flag)
+		}
+		if fraction < 0 || fraction > 1 {
+			return nil, fmt.Errorf("incorrect node group reserved daemonset fraction - not between 0 and 1: %v", flag)
+		}
+		fractions[flag[:separator]] = fraction
+	}
+	return fractions, nil
+}
+
+func parseWorkloadClassExpanders(flags MultiStringFlag) (map[string]string, error) {
+	expanders := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		separator := strings.LastIndex(flag, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("incorrect workload class expander specification: %v", flag)
+		}
+		expanderName := flag[separator+1:]
+		found := false
+		for _, availableExpander := range expander.AvailableExpanders {
+			if expanderName == availableExpander {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("incorrect workload class expander - unknown expander: %v", flag)
+		}
+		expanders[flag[:separator]] = expanderName
+	}
+	return expanders, nil
+}
+
+func parseSchedulerNames(flags MultiStringFlag) ([]string, map[string][]string, error) {
+	names := make([]string, 0, len(flags))
+	schedulerPredicates := make(map[string][]string)
+	for _, flag := range flags {
+		name := flag
+		if separator := strings.Index(flag, "="); separator >= 0 {
+			name = flag[:separator]
+			predicateNames := []string{}
+			for _, predicateName := range strings.Split(flag[separator+1:], ",") {
+				if predicateName = strings.TrimSpace(predicateName); predicateName != "" {
+					predicateNames = append(predicateNames, predicateName)
+				}
+			}
+			schedulerPredicates[name] = predicateNames
+		}
+		if name == "" {
+			return nil, nil, fmt.Errorf("incorrect scheduler specification: %v", flag)
+		}
+		names = append(names, name)
+	}
+	return names, schedulerPredicates, nil
+}
+
+func parseDeviceResources(flags MultiStringFlag) (map[string]string, error) {
+	resources := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		separator := strings.Index(flag, "=")
+		if separator <= 0 || separator == len(flag)-1 {
+			return nil, fmt.Errorf("incorrect device resource specification: %v", flag)
+		}
+		resources[flag[:separator]] = flag[separator+1:]
+	}
+	return resources, nil
+}
+
 func parseSingleGpuLimit(limits string) (config.GpuLimits, error) {
 	parts := strings.Split(limits, ":")
 	if len(parts) != 3 {
@@ -17,9 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/openshiftmachineapi"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/core"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -91,3 +97,128 @@ func TestParseSingleGpuLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestParseNodeGroupMinNodeLifetimes(t *testing.T) {
+	lifetimes, err := parseNodeGroupMinNodeLifetimes(MultiStringFlag{"ng1=10m", "https://mig/ng=2=1h"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"ng1": 10 * time.Minute, "https://mig/ng=2": time.Hour}, lifetimes)
+
+	for _, input := range []string{"ng1", "=10m", "ng1=ten", "ng1=-1m"} {
+		_, err := parseNodeGroupMinNodeLifetimes(MultiStringFlag{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseWorkloadClassExpanders(t *testing.T) {
+	expanders, err := parseWorkloadClassExpanders(MultiStringFlag{"batch=price", "serving=least-waste"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"batch": "price", "serving": "least-waste"}, expanders)
+
+	for _, input := range []string{"batch", "=price", "batch=cheapest"} {
+		_, err := parseWorkloadClassExpanders(MultiStringFlag{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseSchedulerNames(t *testing.T) {
+	names, schedulerPredicates, err := parseSchedulerNames(MultiStringFlag{"default-scheduler", "batch=PodFitsResources, ready", "overcommitting="})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default-scheduler", "batch", "overcommitting"}, names)
+	assert.Equal(t, map[string][]string{"batch": {"PodFitsResources", "ready"}, "overcommitting": {}}, schedulerPredicates)
+
+	for _, input := range []string{"", "=PodFitsResources"} {
+		_, _, err := parseSchedulerNames(MultiStringFlag{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseDeviceResources(t *testing.T) {
+	resources, err := parseDeviceResources(MultiStringFlag{"aliyun.com/gpu-mem=aliyun.com/gpu-count"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"aliyun.com/gpu-mem": "aliyun.com/gpu-count"}, resources)
+
+	for _, input := range []string{"aliyun.com/gpu-mem", "=aliyun.com/gpu-count", "aliyun.com/gpu-mem="} {
+		_, err := parseDeviceResources(MultiStringFlag{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseNodeGroupReservedDaemonSetFractions(t *testing.T) {
+	fractions, err := parseNodeGroupReservedDaemonSetFractions(MultiStringFlag{"ng1=0.5", "https://mig/ng=2=1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"ng1": 0.5, "https://mig/ng=2": 1}, fractions)
+
+	for _, input := range []string{"ng1", "=0.5", "ng1=half", "ng1=-0.1", "ng1=1.5"} {
+		_, err := parseNodeGroupReservedDaemonSetFractions(MultiStringFlag{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestGenerateManifests(t *testing.T) {
+	var out bytes.Buffer
+	err := generateManifests(&out, []string{"--cloud-provider=openshift-machine-api", "--nodes=1:10:ng1", "--nodes=0:3:ng2", "--image=cluster-autoscaler:test"})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "- --nodes=1:10:ng1\n")
+	assert.Contains(t, out.String(), "- --nodes=0:3:ng2\n")
+	assert.Contains(t, out.String(), "image: cluster-autoscaler:test\n")
+	assert.NotContains(t, out.String(), "--image")
+	assert.Contains(t, out.String(), "machine.openshift.io")
+
+	assert.Error(t, generateManifests(&out, []string{"--unknown-flag"}))
+}
+
+func hasRuleFor(rules []rbacv1.PolicyRule, apiGroup, resource string) bool {
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			for _, r := range rule.Resources {
+				if group == apiGroup && r == resource {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func allows(rules []rbacv1.PolicyRule, apiGroup, resource, resourceName, verb string) bool {
+	for _, rule := range rules {
+		if contains(rule.APIGroups, apiGroup) && contains(rule.Resources, resource) && contains(rule.Verbs, verb) &&
+			(len(rule.ResourceNames) == 0 || contains(rule.ResourceNames, resourceName)) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestManifestPolicyRules(t *testing.T) {
+	clusterRules := append(append([]rbacv1.PolicyRule{}, clusterPolicyRules...), providerPolicyRules[openshiftmachineapi.ProviderName]...)
+	assert.True(t, hasRuleFor(clusterRules, "", "nodes"))
+	assert.True(t, hasRuleFor(clusterRules, "machine.openshift.io", "machinesets"))
+	assert.True(t, hasRuleFor(clusterRules, "autoscaling.x-k8s.io", "nodegroupspecs"))
+	assert.True(t, allows(clusterRules, "", "nodes", "n1", "delete"))
+	assert.True(t, allows(clusterRules, "", "namespaces", "default", "get"))
+	assert.True(t, allows(clusterRules, "", "pods", "p1", "patch"))
+
+	// Providers not using the Kubernetes API have no extra rules.
+	assert.Empty(t, providerPolicyRules["aws"])
+
+	namespaceRules := namespacePolicyRules(resourcelock.LeasesResourceLock)
+	assert.True(t, hasRuleFor(namespaceRules, "coordination.k8s.io", "leases"))
+	assert.False(t, hasRuleFor(namespaceRules, "", "endpoints"))
+	for _, name := range []string{core.PauseConfigMapName, core.RebalanceConfigMapName} {
+		assert.True(t, allows(namespaceRules, "", "configmaps", name, "get"))
+		assert.True(t, allows(namespaceRules, "", "configmaps", name, "update"))
+	}
+	assert.False(t, allows(namespaceRules, "", "configmaps", "other", "update"))
+	assert.True(t, allows(namespaceRules, "", "configmaps", "", "list"))
+	assert.True(t, allows(namespaceRules, "", "configmaps", "", "watch"))
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/spf13/pflag"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/openshiftmachineapi"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/core"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/manifests"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/kubernetes/pkg/client/leaderelectionconfig"
+)
+
+const (
+	// generateManifestsCommand is the subcommand printing the manifests
+	// to deploy cluster-autoscaler with the flags following it.
+	generateManifestsCommand = "generate-manifests"
+
+	imageFlagName = "image"
+)
+
+// providerPolicyRules are the cluster-wide RBAC rules of the cloud
+// providers talking to the Kubernetes API.
+var providerPolicyRules = map[string][]rbacv1.PolicyRule{
+	openshiftmachineapi.ProviderName: openshiftmachineapi.PolicyRules,
+}
+
+// clusterPolicyRules are the cluster-wide RBAC rules needed by the
+// listers and informers of cluster-autoscaler, and by scale down.
+var clusterPolicyRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"events", "endpoints"},
+		Verbs:     []string{"create", "patch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods/eviction"},
+		Verbs:     []string{"create"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods/status"},
+		Verbs:     []string{"update"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"patch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"nodes"},
+		Verbs:     []string{"watch", "list", "get", "update", "delete"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+		Verbs:     []string{"get"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods", "services", "replicationcontrollers", "persistentvolumeclaims", "persistentvolumes", "limitranges"},
+		Verbs:     []string{"watch", "list", "get"},
+	},
+	{
+		APIGroups: []string{"apps"},
+		Resources: []string{"statefulsets", "replicasets", "daemonsets"},
+		Verbs:     []string{"watch", "list", "get"},
+	},
+	{
+		APIGroups: []string{"batch"},
+		Resources: []string{"jobs"},
+		Verbs:     []string{"watch", "list", "get"},
+	},
+	{
+		APIGroups: []string{"policy"},
+		Resources: []string{"poddisruptionbudgets"},
+		Verbs:     []string{"watch", "list"},
+	},
+	{
+		APIGroups: []string{"storage.k8s.io"},
+		Resources: []string{"storageclasses"},
+		Verbs:     []string{"watch", "list", "get"},
+	},
+	{
+		APIGroups: []string{nodegroups.NodeGroupSpecGroup},
+		Resources: []string{nodegroups.NodeGroupSpecResource},
+		Verbs:     []string{"list"},
+	},
+	{
+		APIGroups: []string{nodegroups.NodeGroupSpecGroup},
+		Resources: []string{nodegroups.NodeGroupSpecResource + "/status"},
+		Verbs:     []string{"update"},
+	},
+}
+
+// namespacePolicyRules are the RBAC rules needed by cluster-autoscaler
+// in its namespace, for the status, pause and rebalance ConfigMaps and
+// leader election.
+func namespacePolicyRules(resourceLock string) []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"create", "list", "watch"},
+		},
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{utils.StatusConfigMapName},
+			Verbs:         []string{"delete", "get", "update"},
+		},
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{core.PauseConfigMapName, core.RebalanceConfigMapName},
+			Verbs:         []string{"get", "update"},
+		},
+	}
+	switch resourceLock {
+	case resourcelock.EndpointsResourceLock:
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"endpoints"},
+			ResourceNames: []string{manifests.Name},
+			Verbs:         []string{"get", "update"},
+		})
+	case resourcelock.ConfigMapsResourceLock:
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{manifests.Name},
+			Verbs:         []string{"get", "update"},
+		})
+	case resourcelock.LeasesResourceLock:
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{"coordination.k8s.io"},
+			Resources: []string{"leases"},
+			Verbs:     []string{"create"},
+		}, rbacv1.PolicyRule{
+			APIGroups:     []string{"coordination.k8s.io"},
+			Resources:     []string{"leases"},
+			ResourceNames: []string{manifests.Name},
+			Verbs:         []string{"get", "update"},
+		})
+	}
+	return rules
+}
+
+// generateManifests writes to w the manifests to run cluster-autoscaler
+// with args. args are parsed as the flags of cluster-autoscaler, and
+// are passed to the generated Deployment except for --image.
+func generateManifests(w io.Writer, args []string) error {
+	flags := pflag.NewFlagSet(generateManifestsCommand, pflag.ContinueOnError)
+	image := flags.String(imageFlagName, "k8s.gcr.io/cluster-autoscaler:v"+ClusterAutoscalerVersion, "Image of the cluster-autoscaler container.")
+	leaderElection := defaultLeaderElectionConfiguration()
+	leaderElection.LeaderElect = true
+	leaderelectionconfig.BindFlags(&leaderElection, flags)
+	flags.AddGoFlagSet(flag.CommandLine)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var commandArgs []string
+	flags.Visit(func(f *pflag.Flag) {
+		if f.Name == imageFlagName {
+			return
+		}
+		if goFlag := flag.Lookup(f.Name); goFlag != nil {
+			if values, ok := goFlag.Value.(*MultiStringFlag); ok {
+				for _, value := range *values {
+					commandArgs = append(commandArgs, fmt.Sprintf("--%s=%s", f.Name, value))
+				}
+				return
+			}
+		}
+		commandArgs = append(commandArgs, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+	})
+
+	resourceLock := ""
+	if leaderElection.LeaderElect {
+		resourceLock = leaderElection.ResourceLock
+	}
+	return manifests.Render(w, manifests.Options{
+		Namespace:      *namespace,
+		Image:          *image,
+		ClusterRules:   append(append([]rbacv1.PolicyRule{}, clusterPolicyRules...), providerPolicyRules[*cloudProviderFlag]...),
+		NamespaceRules: namespacePolicyRules(resourceLock),
+		Args:           commandArgs,
+	})
+}
@@ -124,6 +124,15 @@ var (
 		},
 	)
 
+	podEquivalenceGroupSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: caNamespace,
+			Name:      "pod_equivalence_group_size",
+			Help:      "Number of unschedulable pods in equivalence groups simulated together in scale-up.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		},
+	)
+
 	/**** Metrics related to autoscaler execution ****/
 	lastActivity = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -159,6 +168,30 @@ var (
 		},
 	)
 
+	scaleUpEstimatedNodesCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "scale_up_estimated_nodes_total",
+			Help:      "Number of nodes the estimator found needed by the pods of CA scale-ups.",
+		},
+	)
+
+	scaleUpHelpedPodsCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "scale_up_helped_pods_total",
+			Help:      "Number of pods CA scale-ups were expected to help.",
+		},
+	)
+
+	oversizedPodsCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "oversized_pods_total",
+			Help:      "Number of pending pods CA found requesting more resources than any node group can offer.",
+		},
+	)
+
 	gpuScaleUpCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: caNamespace,
@@ -199,6 +232,15 @@ var (
 		},
 	)
 
+	podSchedulingLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: caNamespace,
+			Name:      "scaled_up_pods_time_to_schedulable_seconds",
+			Help:      "Time from CA first seeing pods pending to their scheduling on nodes added by CA.",
+			Buckets:   []float64{15, 30, 45, 60, 90, 120, 180, 240, 300, 420, 600, 900, 1200, 1800, 3600},
+		}, []string{"node_group"},
+	)
+
 	unneededNodesCount = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: caNamespace,
@@ -207,6 +249,22 @@ var (
 		},
 	)
 
+	ghostNodesCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "ghost_nodes_count",
+			Help:      "Number of nodes whose instance doesn't exist in the cloud provider anymore.",
+		},
+	)
+
+	autoscalingPaused = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "autoscaling_paused",
+			Help:      "Whether or not autoscaling is paused. 1 if it is, 0 otherwise.",
+		},
+	)
+
 	/**** Metrics related to NodeAutoprovisioning ****/
 	napEnabled = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -239,16 +297,23 @@ func RegisterAll() {
 	prometheus.MustRegister(nodesCount)
 	prometheus.MustRegister(nodeGroupsCount)
 	prometheus.MustRegister(unschedulablePodsCount)
+	prometheus.MustRegister(podEquivalenceGroupSize)
 	prometheus.MustRegister(lastActivity)
 	prometheus.MustRegister(functionDuration)
 	prometheus.MustRegister(errorsCount)
 	prometheus.MustRegister(scaleUpCount)
+	prometheus.MustRegister(scaleUpEstimatedNodesCount)
+	prometheus.MustRegister(scaleUpHelpedPodsCount)
+	prometheus.MustRegister(oversizedPodsCount)
 	prometheus.MustRegister(gpuScaleUpCount)
 	prometheus.MustRegister(failedScaleUpCount)
 	prometheus.MustRegister(scaleDownCount)
 	prometheus.MustRegister(gpuScaleDownCount)
 	prometheus.MustRegister(evictionsCount)
+	prometheus.MustRegister(podSchedulingLatency)
 	prometheus.MustRegister(unneededNodesCount)
+	prometheus.MustRegister(ghostNodesCount)
+	prometheus.MustRegister(autoscalingPaused)
 	prometheus.MustRegister(napEnabled)
 	prometheus.MustRegister(nodeGroupCreationCount)
 	prometheus.MustRegister(nodeGroupDeletionCount)
@@ -305,6 +370,11 @@ func UpdateUnschedulablePodsCount(podsCount int) {
 	unschedulablePodsCount.Set(float64(podsCount))
 }
 
+// RegisterPodEquivalenceGroupSize records the number of pods in an equivalence group evaluated in scale-up
+func RegisterPodEquivalenceGroupSize(podsCount int) {
+	podEquivalenceGroupSize.Observe(float64(podsCount))
+}
+
 // RegisterError records any errors preventing Cluster Autoscaler from working.
 // No more than one error should be recorded per loop.
 func RegisterError(err errors.AutoscalerError) {
@@ -319,6 +389,19 @@ func RegisterScaleUp(nodesCount int, gpuType string) {
 	}
 }
 
+// RegisterScaleUpEstimation records the number of nodes the estimator found
+// needed by a scale-up and the number of pods they are expected to help
+func RegisterScaleUpEstimation(estimatedNodes int, pods int) {
+	scaleUpEstimatedNodesCount.Add(float64(estimatedNodes))
+	scaleUpHelpedPodsCount.Add(float64(pods))
+}
+
+// RegisterOversizedPod records a pending pod requesting more resources than
+// any node group can offer
+func RegisterOversizedPod() {
+	oversizedPodsCount.Inc()
+}
+
 // RegisterFailedScaleUp records a failed scale-up operation
 func RegisterFailedScaleUp(reason FailedScaleUpReason) {
 	failedScaleUpCount.WithLabelValues(string(reason)).Inc()
@@ -337,11 +420,31 @@ func RegisterEvictions(podsCount int) {
 	evictionsCount.Add(float64(podsCount))
 }
 
+// RegisterPodSchedulingLatency records the time a pending pod took to be scheduled
+// on a node CA added to the given node group
+func RegisterPodSchedulingLatency(nodeGroup string, latency time.Duration) {
+	podSchedulingLatency.WithLabelValues(nodeGroup).Observe(latency.Seconds())
+}
+
 // UpdateUnneededNodesCount records number of currently unneeded nodes
 func UpdateUnneededNodesCount(nodesCount int) {
 	unneededNodesCount.Set(float64(nodesCount))
 }
 
+// UpdateGhostNodesCount records number of nodes without a cloud provider instance
+func UpdateGhostNodesCount(nodesCount int) {
+	ghostNodesCount.Set(float64(nodesCount))
+}
+
+// UpdateAutoscalingPaused records if autoscaling is paused
+func UpdateAutoscalingPaused(paused bool) {
+	if paused {
+		autoscalingPaused.Set(1)
+	} else {
+		autoscalingPaused.Set(0)
+	}
+}
+
 // UpdateNapEnabled records if NodeAutoprovisioning is enabled
 func UpdateNapEnabled(enabled bool) {
 	if enabled {
@@ -0,0 +1,255 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroups
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+)
+
+const (
+	// NodeGroupSpecGroup is the API group of the NodeGroupSpec custom resource.
+	NodeGroupSpecGroup = "autoscaling.x-k8s.io"
+	// NodeGroupSpecVersion is the API version of the NodeGroupSpec custom resource.
+	NodeGroupSpecVersion = "v1alpha1"
+	// NodeGroupSpecResource is the plural resource name of the NodeGroupSpec custom resource.
+	NodeGroupSpecResource = "nodegroupspecs"
+
+	// nodeGroupSpecRetryDelay is how long the creation of the node group of a NodeGroupSpec isn't
+	// retried after it failed.
+	nodeGroupSpecRetryDelay = 5 * time.Minute
+)
+
+// NodeGroupSpecGroupVersionResource identifies the NodeGroupSpec custom resource.
+var NodeGroupSpecGroupVersionResource = schema.GroupVersionResource{
+	Group:    NodeGroupSpecGroup,
+	Version:  NodeGroupSpecVersion,
+	Resource: NodeGroupSpecResource,
+}
+
+// NodeGroupSpec declares a node group independently of the cloud provider.
+type NodeGroupSpec struct {
+	// InstanceType is the machine type of the nodes, as named by the cloud provider.
+	InstanceType string `json:"instanceType"`
+	// Zone the nodes are created in, if the cloud provider needs one.
+	Zone string `json:"zone,omitempty"`
+	// Labels of the nodes.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints of the nodes.
+	Taints []apiv1.Taint `json:"taints,omitempty"`
+	// MinSize of the node group.
+	MinSize int `json:"minSize"`
+	// MaxSize of the node group.
+	MaxSize int `json:"maxSize"`
+}
+
+// NodeGroupSpecStatus is the status of a NodeGroupSpec.
+type NodeGroupSpecStatus struct {
+	// NodeGroup is the id of the node group created for the NodeGroupSpec.
+	NodeGroup string `json:"nodeGroup,omitempty"`
+	// Message tells why the node group couldn't be created.
+	Message string `json:"message,omitempty"`
+}
+
+// nodeGroupSpecObject is a NodeGroupSpec custom resource.
+type nodeGroupSpecObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodeGroupSpec       `json:"spec"`
+	Status NodeGroupSpecStatus `json:"status,omitempty"`
+}
+
+// nodeGroupSpecClient lists the NodeGroupSpec custom resources and updates their status.
+type nodeGroupSpecClient interface {
+	list() ([]unstructured.Unstructured, error)
+	updateStatus(obj *unstructured.Unstructured) error
+}
+
+type dynamicNodeGroupSpecClient struct {
+	client dynamic.Interface
+}
+
+func (c *dynamicNodeGroupSpecClient) list() ([]unstructured.Unstructured, error) {
+	list, err := c.client.Resource(NodeGroupSpecGroupVersionResource).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *dynamicNodeGroupSpecClient) updateStatus(obj *unstructured.Unstructured) error {
+	_, err := c.client.Resource(NodeGroupSpecGroupVersionResource).UpdateStatus(obj, metav1.UpdateOptions{})
+	return err
+}
+
+// CRDNodeGroupSpecProcessor creates a node group for every NodeGroupSpec custom resource, with
+// the NewNodeGroup method of the cloud provider, and records its id in the status of the
+// NodeGroupSpec. The NodeGroupSpecs of cloud providers that can't create node groups get a
+// status message telling so. Node groups aren't updated nor deleted with their NodeGroupSpec.
+type CRDNodeGroupSpecProcessor struct {
+	client nodeGroupSpecClient
+	// retryAfter holds when the creation of the node groups that failed can be tried again,
+	// keyed by NodeGroupSpec name.
+	retryAfter map[string]time.Time
+}
+
+// NewCRDNodeGroupSpecProcessor returns a CRDNodeGroupSpecProcessor reading the NodeGroupSpecs
+// with the given client.
+func NewCRDNodeGroupSpecProcessor(client dynamic.Interface) *CRDNodeGroupSpecProcessor {
+	return newCRDNodeGroupSpecProcessor(&dynamicNodeGroupSpecClient{client: client})
+}
+
+func newCRDNodeGroupSpecProcessor(client nodeGroupSpecClient) *CRDNodeGroupSpecProcessor {
+	return &CRDNodeGroupSpecProcessor{
+		client:     client,
+		retryAfter: make(map[string]time.Time),
+	}
+}
+
+// Process creates the node groups of the NodeGroupSpecs without one.
+func (p *CRDNodeGroupSpecProcessor) Process(context *context.AutoscalingContext) errors.AutoscalerError {
+	items, err := p.client.list()
+	if err != nil {
+		return errors.NewAutoscalerError(errors.ApiCallError, "failed to list node group specs: %v", err)
+	}
+
+	existing := make(map[string]bool)
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		existing[nodeGroup.Id()] = true
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(items))
+	for i := range items {
+		item := &items[i]
+		seen[item.GetName()] = true
+		obj := &nodeGroupSpecObject{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, obj); err != nil {
+			p.setStatus(item, NodeGroupSpecStatus{Message: fmt.Sprintf("invalid node group spec: %v", err)})
+			continue
+		}
+		if obj.Status.NodeGroup != "" && existing[obj.Status.NodeGroup] {
+			continue
+		}
+		if p.retryAfter[obj.Name].After(now) {
+			continue
+		}
+
+		nodeGroup, err := createNodeGroup(context.CloudProvider, &obj.Spec)
+		if err != nil {
+			klog.Warningf("Failed to create node group of node group spec %s: %v", obj.Name, err)
+			p.retryAfter[obj.Name] = now.Add(nodeGroupSpecRetryDelay)
+			p.setStatus(item, NodeGroupSpecStatus{Message: err.Error()})
+			continue
+		}
+		delete(p.retryAfter, obj.Name)
+		klog.V(0).Infof("Created node group %s of node group spec %s", nodeGroup.Id(), obj.Name)
+		context.LogRecorder.Eventf(apiv1.EventTypeNormal, "CreatedNodeGroup", "Created node group %s of node group spec %s",
+			nodeGroup.Id(), obj.Name)
+		p.setStatus(item, NodeGroupSpecStatus{NodeGroup: nodeGroup.Id()})
+	}
+
+	for name := range p.retryAfter {
+		if !seen[name] {
+			delete(p.retryAfter, name)
+		}
+	}
+	return nil
+}
+
+// CleanUp does nothing in CRDNodeGroupSpecProcessor.
+func (p *CRDNodeGroupSpecProcessor) CleanUp() {}
+
+// setStatus updates the status of the NodeGroupSpec if it changed. Failures are logged, the
+// status is updated again on the next iteration.
+func (p *CRDNodeGroupSpecProcessor) setStatus(item *unstructured.Unstructured, status NodeGroupSpecStatus) {
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		klog.Errorf("Failed to convert status of node group spec %s: %v", item.GetName(), err)
+		return
+	}
+	if current, _, _ := unstructured.NestedMap(item.Object, "status"); reflect.DeepEqual(current, fields) {
+		return
+	}
+	updated := item.DeepCopy()
+	updated.Object["status"] = fields
+	if err := p.client.updateStatus(updated); err != nil {
+		klog.Warningf("Failed to update status of node group spec %s: %v", item.GetName(), err)
+	}
+}
+
+// createNodeGroup creates a node group for the given spec on the cloud provider. The size
+// limits of the spec are only applied by the node groups implementing
+// cloudprovider.SizeLimitsNodeGroup, other node groups are created with the limits chosen by
+// their cloud provider. The node group is created as a regular node group, so that node
+// autoprovisioning never deletes it, and cloud providers that can only create autoprovisioned
+// node groups are refused.
+func createNodeGroup(cloudProvider cloudprovider.CloudProvider, spec *NodeGroupSpec) (cloudprovider.NodeGroup, error) {
+	if spec.InstanceType == "" {
+		return nil, fmt.Errorf("invalid node group spec: instanceType is required")
+	}
+	if spec.MinSize < 0 || spec.MaxSize < spec.MinSize {
+		return nil, fmt.Errorf("invalid node group spec: sizes must satisfy 0 <= minSize (%d) <= maxSize (%d)", spec.MinSize, spec.MaxSize)
+	}
+
+	labels := make(map[string]string, len(spec.Labels))
+	for key, value := range spec.Labels {
+		labels[key] = value
+	}
+	systemLabels := make(map[string]string)
+	if spec.Zone != "" {
+		systemLabels[apiv1.LabelZoneFailureDomain] = spec.Zone
+	}
+	taints := append([]apiv1.Taint{}, spec.Taints...)
+
+	nodeGroup, err := cloudProvider.NewNodeGroup(spec.InstanceType, labels, systemLabels, taints, map[string]resource.Quantity{})
+	if err == cloudprovider.ErrNotImplemented {
+		return nil, fmt.Errorf("cloud provider %s can't create node groups", cloudProvider.Name())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if persistent, ok := nodeGroup.(cloudprovider.PersistentNodeGroup); ok {
+		if err := persistent.SetPersistent(); err != nil {
+			return nil, err
+		}
+	} else if nodeGroup.Autoprovisioned() {
+		return nil, fmt.Errorf("cloud provider %s can only create node groups that node autoprovisioning deletes once unused", cloudProvider.Name())
+	}
+	if limits, ok := nodeGroup.(cloudprovider.SizeLimitsNodeGroup); ok {
+		if err := limits.SetSizeLimits(spec.MinSize, spec.MaxSize); err != nil {
+			return nil, err
+		}
+	} else {
+		klog.V(2).Infof("Node group %s doesn't support size limits, using min size %d and max size %d", nodeGroup.Id(), nodeGroup.MinSize(), nodeGroup.MaxSize())
+	}
+	return nodeGroup.Create()
+}
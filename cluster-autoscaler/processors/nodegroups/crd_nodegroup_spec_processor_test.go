@@ -0,0 +1,148 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroups
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/client-go/kubernetes/fake"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNodeGroupSpecClient struct {
+	items   map[string]*unstructured.Unstructured
+	updates int
+}
+
+func (c *fakeNodeGroupSpecClient) list() ([]unstructured.Unstructured, error) {
+	result := make([]unstructured.Unstructured, 0, len(c.items))
+	for _, item := range c.items {
+		result = append(result, *item.DeepCopy())
+	}
+	return result, nil
+}
+
+func (c *fakeNodeGroupSpecClient) updateStatus(obj *unstructured.Unstructured) error {
+	c.items[obj.GetName()] = obj
+	c.updates++
+	return nil
+}
+
+func buildNodeGroupSpec(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": NodeGroupSpecGroup + "/" + NodeGroupSpecVersion,
+		"kind":       "NodeGroupSpec",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       spec,
+	}}
+}
+
+func nodeGroupSpecStatus(t *testing.T, obj *unstructured.Unstructured) (string, string) {
+	nodeGroup, _, err := unstructured.NestedString(obj.Object, "status", "nodeGroup")
+	assert.NoError(t, err)
+	message, _, err := unstructured.NestedString(obj.Object, "status", "message")
+	assert.NoError(t, err)
+	return nodeGroup, message
+}
+
+func TestCRDNodeGroupSpecProcessor(t *testing.T) {
+	created := make(chan string, 10)
+	provider := testprovider.NewTestAutoprovisioningCloudProvider(nil, nil, func(id string) error {
+		created <- id
+		return nil
+	}, nil, []string{"large"}, nil)
+	logRecorder, err := utils.NewStatusMapRecorder(fake.NewSimpleClientset(), "kube-system", kube_record.NewFakeRecorder(5), false)
+	assert.NoError(t, err)
+	ctx := &context.AutoscalingContext{CloudProvider: provider, LogRecorder: logRecorder}
+
+	client := &fakeNodeGroupSpecClient{items: map[string]*unstructured.Unstructured{
+		"large": buildNodeGroupSpec("large", map[string]interface{}{
+			"instanceType": "large",
+			"zone":         "us-east-1a",
+			"labels":       map[string]interface{}{"pool": "large"},
+			"taints": []interface{}{
+				map[string]interface{}{"key": "dedicated", "value": "large", "effect": "NoSchedule"},
+			},
+			"minSize": int64(1),
+			"maxSize": int64(5),
+		}),
+		"invalid": buildNodeGroupSpec("invalid", map[string]interface{}{
+			"instanceType": "large",
+			"minSize":      int64(3),
+			"maxSize":      int64(2),
+		}),
+	}}
+	processor := newCRDNodeGroupSpecProcessor(client)
+
+	assert.Nil(t, processor.Process(ctx))
+	assert.Equal(t, "autoprovisioned-large", <-created)
+	nodeGroup, message := nodeGroupSpecStatus(t, client.items["large"])
+	assert.Equal(t, "autoprovisioned-large", nodeGroup)
+	assert.Empty(t, message)
+	group := provider.GetNodeGroup("autoprovisioned-large")
+	if assert.NotNil(t, group) {
+		assert.Equal(t, 1, group.MinSize())
+		assert.Equal(t, 5, group.MaxSize())
+		// Node autoprovisioning doesn't delete the node group once unused.
+		assert.False(t, group.Autoprovisioned())
+	}
+	_, message = nodeGroupSpecStatus(t, client.items["invalid"])
+	assert.Contains(t, message, "minSize (3) <= maxSize (2)")
+	assert.Equal(t, 2, client.updates)
+
+	// Existing node groups aren't created again, failed creations aren't retried right away and
+	// unchanged statuses aren't updated.
+	assert.Nil(t, processor.Process(ctx))
+	assert.Equal(t, 0, len(created))
+	assert.Equal(t, 2, client.updates)
+
+	// Failed creations are retried after a while.
+	processor.retryAfter["invalid"] = time.Now().Add(-time.Second)
+	assert.Nil(t, processor.Process(ctx))
+	assert.Equal(t, 2, client.updates)
+	assert.True(t, processor.retryAfter["invalid"].After(time.Now()))
+
+	// Deleted NodeGroupSpecs are forgotten.
+	delete(client.items, "invalid")
+	assert.Nil(t, processor.Process(ctx))
+	assert.NotContains(t, processor.retryAfter, "invalid")
+}
+
+func TestCreateNodeGroupNotImplemented(t *testing.T) {
+	provider := &notImplementedCloudProvider{testprovider.NewTestCloudProvider(nil, nil)}
+	_, err := createNodeGroup(provider, &NodeGroupSpec{InstanceType: "large", MaxSize: 1})
+	assert.EqualError(t, err, "cloud provider TestCloudProvider can't create node groups")
+}
+
+type notImplementedCloudProvider struct {
+	*testprovider.TestCloudProvider
+}
+
+func (*notImplementedCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroups
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+)
+
+// NodeGroupSpecProcessor creates the node groups declared outside of the cloud provider,
+// before the node groups are used by the autoscaling iteration.
+type NodeGroupSpecProcessor interface {
+	Process(context *context.AutoscalingContext) errors.AutoscalerError
+	CleanUp()
+}
+
+// NoOpNodeGroupSpecProcessor is a NodeGroupSpecProcessor that doesn't create any node group.
+type NoOpNodeGroupSpecProcessor struct {
+}
+
+// Process does nothing in NoOpNodeGroupSpecProcessor.
+func (*NoOpNodeGroupSpecProcessor) Process(context *context.AutoscalingContext) errors.AutoscalerError {
+	return nil
+}
+
+// CleanUp does nothing in NoOpNodeGroupSpecProcessor.
+func (*NoOpNodeGroupSpecProcessor) CleanUp() {}
+
+// NewDefaultNodeGroupSpecProcessor creates an instance of NodeGroupSpecProcessor.
+func NewDefaultNodeGroupSpecProcessor() NodeGroupSpecProcessor {
+	return &NoOpNodeGroupSpecProcessor{}
+}
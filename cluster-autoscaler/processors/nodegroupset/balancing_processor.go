@@ -30,6 +30,9 @@ import (
 // BalancingNodeGroupSetProcessor tries to keep similar node groups balanced on scale-up.
 type BalancingNodeGroupSetProcessor struct {
 	Comparator NodeInfoComparator
+	// IgnoredLabels are the labels the nodes of similar groups can differ
+	// in, on top of the ones ignored by the comparator and by the cloud provider.
+	IgnoredLabels []string
 }
 
 // FindSimilarNodeGroups returns a list of NodeGroups similar to the given one.
@@ -46,6 +49,8 @@ func (b *BalancingNodeGroupSetProcessor) FindSimilarNodeGroups(context *context.
 			"failed to find template node for node group %s",
 			nodeGroupId)
 	}
+	ignoredLabels := b.ignoredLabels(context.CloudProvider)
+	nodeInfo = withoutLabels(nodeInfo, ignoredLabels)
 	for _, ng := range context.CloudProvider.NodeGroups() {
 		ngId := ng.Id()
 		if ngId == nodeGroupId {
@@ -60,20 +65,62 @@ func (b *BalancingNodeGroupSetProcessor) FindSimilarNodeGroups(context *context.
 		if comparator == nil {
 			comparator = IsNodeInfoSimilar
 		}
-		if comparator(nodeInfo, ngNodeInfo) {
+		if comparator(nodeInfo, withoutLabels(ngNodeInfo, ignoredLabels)) {
 			result = append(result, ng)
 		}
 	}
 	return result, nil
 }
 
+// ignoredLabels returns the labels given by IgnoredLabels and by the cloud provider.
+func (b *BalancingNodeGroupSetProcessor) ignoredLabels(cloudProvider cloudprovider.CloudProvider) map[string]bool {
+	ignoredLabels := make(map[string]bool)
+	for _, label := range b.IgnoredLabels {
+		ignoredLabels[label] = true
+	}
+	if provider, ok := cloudProvider.(cloudprovider.BalancingIgnoredLabelsCloudProvider); ok {
+		for _, label := range provider.BalancingIgnoredLabels() {
+			ignoredLabels[label] = true
+		}
+	}
+	return ignoredLabels
+}
+
+// withoutLabels returns nodeInfo, or a copy of it without the labels if its node has any of them.
+func withoutLabels(nodeInfo *schedulernodeinfo.NodeInfo, labels map[string]bool) *schedulernodeinfo.NodeInfo {
+	node := nodeInfo.Node()
+	if node == nil {
+		return nodeInfo
+	}
+	found := false
+	for label := range node.Labels {
+		if labels[label] {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nodeInfo
+	}
+	node = node.DeepCopy()
+	for label := range labels {
+		delete(node.Labels, label)
+	}
+	nodeInfo = nodeInfo.Clone()
+	if err := nodeInfo.SetNode(node); err != nil {
+		klog.Warningf("Failed to remove ignored labels of node %s: %v", node.Name, err)
+	}
+	return nodeInfo
+}
+
 // BalanceScaleUpBetweenGroups distributes a given number of nodes between
 // given set of NodeGroups. The nodes are added to smallest group first, trying
 // to make the group sizes as evenly balanced as possible.
 //
 // Returns ScaleUpInfos for groups that need to be resized.
 //
-// MaxSize of each group will be respected. If newNodes > total free capacity
+// MaxSize of each group (clamped to any provider-reported limit) will be
+// respected. If newNodes > total free capacity
 // of all NodeGroups it will be capped to total capacity. In particular if all
 // group already have MaxSize, empty list will be returned.
 func (b *BalancingNodeGroupSetProcessor) BalanceScaleUpBetweenGroups(context *context.AutoscalingContext, groups []cloudprovider.NodeGroup, newNodes int) ([]ScaleUpInfo, errors.AutoscalerError) {
@@ -92,8 +139,8 @@ func (b *BalancingNodeGroupSetProcessor) BalanceScaleUpBetweenGroups(context *co
 				errors.CloudProviderError,
 				"failed to get node group size: %v", err)
 		}
-		maxSize := ng.MaxSize()
-		if currentSize == maxSize {
+		maxSize, _ := cloudprovider.EffectiveMaxSize(ng)
+		if currentSize >= maxSize {
 			// group already maxed, ignore it
 			continue
 		}
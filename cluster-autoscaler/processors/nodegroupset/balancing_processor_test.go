@@ -76,6 +76,62 @@ func TestFindSimilarNodeGroups(t *testing.T) {
 	basicSimilarNodeGroupsTest(t, processor)
 }
 
+type balancingIgnoredLabelsCloudProvider struct {
+	*testprovider.TestCloudProvider
+	ignoredLabels []string
+}
+
+func (p *balancingIgnoredLabelsCloudProvider) BalancingIgnoredLabels() []string {
+	return p.ignoredLabels
+}
+
+func TestFindSimilarNodeGroupsIgnoredLabels(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n1.Labels["provider-group"] = "ng1"
+	n1.Labels["user-group"] = "ng1"
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n2.Labels["provider-group"] = "ng2"
+	n2.Labels["user-group"] = "ng2"
+	testProvider := testprovider.NewTestCloudProvider(nil, nil)
+	testProvider.AddNodeGroup("ng1", 1, 10, 1)
+	testProvider.AddNodeGroup("ng2", 1, 10, 1)
+	testProvider.AddNode("ng1", n1)
+	testProvider.AddNode("ng2", n2)
+
+	ni1 := schedulernodeinfo.NewNodeInfo()
+	ni1.SetNode(n1)
+	ni2 := schedulernodeinfo.NewNodeInfo()
+	ni2.SetNode(n2)
+	nodeInfosForGroups := map[string]*schedulernodeinfo.NodeInfo{
+		"ng1": ni1, "ng2": ni2,
+	}
+	ng1, _ := testProvider.NodeGroupForNode(n1)
+	ng2, _ := testProvider.NodeGroupForNode(n2)
+
+	context := &context.AutoscalingContext{CloudProvider: testProvider}
+	processor := &BalancingNodeGroupSetProcessor{IgnoredLabels: []string{"user-group"}}
+	similar, err := processor.FindSimilarNodeGroups(context, ng1, nodeInfosForGroups)
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.NodeGroup{}, similar)
+
+	context.CloudProvider = &balancingIgnoredLabelsCloudProvider{
+		TestCloudProvider: testProvider,
+		ignoredLabels:     []string{"provider-group"},
+	}
+	similar, err = processor.FindSimilarNodeGroups(context, ng1, nodeInfosForGroups)
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.NodeGroup{ng2}, similar)
+
+	// The template nodes keep their labels.
+	assert.Equal(t, "ng1", n1.Labels["provider-group"])
+	assert.Equal(t, "ng2", ni2.Node().Labels["user-group"])
+
+	processor = &BalancingNodeGroupSetProcessor{}
+	similar, err = processor.FindSimilarNodeGroups(context, ng1, nodeInfosForGroups)
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.NodeGroup{}, similar)
+}
+
 func TestBalanceSingleGroup(t *testing.T) {
 	processor := &BalancingNodeGroupSetProcessor{}
 	context := &context.AutoscalingContext{}
@@ -61,6 +61,11 @@ func compareResourceMapsWithTolerance(resources map[apiv1.ResourceName][]resourc
 // are similar enough to likely be the same type of machine and if the set of labels
 // is the same (except for a pre-defined set of labels like hostname or zone).
 func IsNodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+	return isNodeInfoSimilar(n1, n2, nil)
+}
+
+// isNodeInfoSimilar is IsNodeInfoSimilar ignoring the given labels too.
+func isNodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo, extraIgnoredLabels map[string]bool) bool {
 	capacity := make(map[apiv1.ResourceName][]resource.Quantity)
 	allocatable := make(map[apiv1.ResourceName][]resource.Quantity)
 	free := make(map[apiv1.ResourceName][]resource.Quantity)
@@ -119,8 +124,7 @@ func IsNodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo) bool {
 	labels := make(map[string][]string)
 	for _, node := range nodes {
 		for label, value := range node.Node().ObjectMeta.Labels {
-			ignore, _ := ignoredLabels[label]
-			if !ignore {
+			if !ignoredLabels[label] && !extraIgnoredLabels[label] {
 				labels[label] = append(labels[label], value)
 			}
 		}
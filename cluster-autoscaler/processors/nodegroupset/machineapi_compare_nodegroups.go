@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// MachineAPIProviderSpecHashAnnotation is an annotation of the template nodes of the
+// openshift-machine-api cloud provider holding the hash of the providerSpec of the
+// MachineSet, without the fields that depend on the zone.
+const MachineAPIProviderSpecHashAnnotation = "machine.openshift.io/template-provider-spec-hash"
+
+// machineAPIIgnoredLabels are the zonal labels that are set on the nodes of MachineSets,
+// on top of those ignored by IsNodeInfoSimilar.
+var machineAPIIgnoredLabels = map[string]bool{
+	"topology.kubernetes.io/zone":   true,
+	"topology.kubernetes.io/region": true,
+}
+
+// IsMachineAPINodeInfoSimilar compares if two nodes should be considered part of the
+// same NodeGroupSet. Template nodes of MachineSets whose providerSpecs differ in more
+// than their zone are never similar. Otherwise the nodes are compared like in
+// IsNodeInfoSimilar, ignoring the zonal labels of MachineSet nodes too, so that the
+// MachineSets of one instance type that a cluster has in each zone are balanced.
+func IsMachineAPINodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+	n1Hash, n1Found := n1.Node().Annotations[MachineAPIProviderSpecHashAnnotation]
+	n2Hash, n2Found := n2.Node().Annotations[MachineAPIProviderSpecHashAnnotation]
+	if n1Found && n2Found && n1Hash != n2Hash {
+		return false
+	}
+	return isNodeInfoSimilar(n1, n2, machineAPIIgnoredLabels)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestIsMachineAPINodeInfoSimilar(t *testing.T) {
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n1.ObjectMeta.Labels[apiv1.LabelInstanceType] = "m5.xlarge"
+	n1.ObjectMeta.Labels[apiv1.LabelZoneFailureDomain] = "us-east-1a"
+	n1.ObjectMeta.Labels["topology.kubernetes.io/zone"] = "us-east-1a"
+	n2 := BuildTestNode("node2", 1000, 2000)
+	n2.ObjectMeta.Labels[apiv1.LabelInstanceType] = "m5.xlarge"
+	n2.ObjectMeta.Labels[apiv1.LabelZoneFailureDomain] = "us-east-1b"
+	n2.ObjectMeta.Labels["topology.kubernetes.io/zone"] = "us-east-1b"
+	// Nodes of MachineSets in different zones.
+	checkNodesSimilar(t, n1, n2, IsNodeInfoSimilar, false)
+	checkNodesSimilar(t, n1, n2, IsMachineAPINodeInfoSimilar, true)
+	// Only one template node.
+	n1.ObjectMeta.Annotations = map[string]string{MachineAPIProviderSpecHashAnnotation: "hash1"}
+	checkNodesSimilar(t, n1, n2, IsMachineAPINodeInfoSimilar, true)
+	// Template nodes with providerSpecs only differing in their zone.
+	n2.ObjectMeta.Annotations = map[string]string{MachineAPIProviderSpecHashAnnotation: "hash1"}
+	checkNodesSimilar(t, n1, n2, IsMachineAPINodeInfoSimilar, true)
+	// Template nodes with different providerSpecs.
+	n2.ObjectMeta.Annotations[MachineAPIProviderSpecHashAnnotation] = "hash2"
+	checkNodesSimilar(t, n1, n2, IsMachineAPINodeInfoSimilar, false)
+	// Different instance types.
+	n2.ObjectMeta.Annotations[MachineAPIProviderSpecHashAnnotation] = "hash1"
+	n2.ObjectMeta.Labels[apiv1.LabelInstanceType] = "m5a.xlarge"
+	checkNodesSimilar(t, n1, n2, IsMachineAPINodeInfoSimilar, false)
+}
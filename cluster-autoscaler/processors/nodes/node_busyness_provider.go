@@ -0,0 +1,48 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// NodeBusynessProvider returns a usage signal for nodes that complements their request-based
+// utilization in scale-down, e.g. the actual CPU usage of best-effort batch workloads.
+type NodeBusynessProvider interface {
+	// GetNodesBusyness returns the busyness of the given nodes, on the same 0 to 1 scale as utilization,
+	// keyed by node name. Nodes without a value are judged by their request-based utilization only.
+	GetNodesBusyness(nodes []*apiv1.Node) (map[string]float64, error)
+	CleanUp()
+}
+
+// NoOpNodeBusynessProvider doesn't report busyness for any node.
+type NoOpNodeBusynessProvider struct {
+}
+
+// NewDefaultNodeBusynessProvider creates an instance of NodeBusynessProvider.
+func NewDefaultNodeBusynessProvider() NodeBusynessProvider {
+	return &NoOpNodeBusynessProvider{}
+}
+
+// GetNodesBusyness returns no busyness values.
+func (p *NoOpNodeBusynessProvider) GetNodesBusyness(nodes []*apiv1.Node) (map[string]float64, error) {
+	return map[string]float64{}, nil
+}
+
+// CleanUp cleans up the provider's internal structures.
+func (p *NoOpNodeBusynessProvider) CleanUp() {
+}
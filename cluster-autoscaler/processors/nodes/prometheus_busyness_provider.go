@@ -0,0 +1,133 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// DefaultPrometheusNodeLabel is the label of the query results holding the node name by default.
+	DefaultPrometheusNodeLabel = "node"
+
+	prometheusTimeout = 10 * time.Second
+)
+
+// PrometheusNodeBusynessProvider reads the busyness of nodes from a PromQL query returning an
+// instant vector with one sample per node, e.g.
+// 1 - avg by (node) (rate(node_cpu_seconds_total{mode="idle"}[5m])).
+type PrometheusNodeBusynessProvider struct {
+	url       string
+	query     string
+	nodeLabel string
+	client    *http.Client
+}
+
+// NewPrometheusNodeBusynessProvider builds a NodeBusynessProvider running the given query against
+// the Prometheus server at the given url. Samples are matched to nodes by the value of nodeLabel.
+func NewPrometheusNodeBusynessProvider(url, query, nodeLabel string) (*PrometheusNodeBusynessProvider, error) {
+	if url == "" {
+		return nil, fmt.Errorf("prometheus url must not be empty")
+	}
+	if query == "" {
+		return nil, fmt.Errorf("prometheus query must not be empty")
+	}
+	if nodeLabel == "" {
+		nodeLabel = DefaultPrometheusNodeLabel
+	}
+	return &PrometheusNodeBusynessProvider{
+		url:       strings.TrimSuffix(url, "/"),
+		query:     query,
+		nodeLabel: nodeLabel,
+		client:    &http.Client{Timeout: prometheusTimeout},
+	}, nil
+}
+
+// prometheusResponse is the part of the Prometheus HTTP API instant query response used here.
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// GetNodesBusyness runs the query and returns the busyness of the given nodes found in the result.
+func (p *PrometheusNodeBusynessProvider) GetNodesBusyness(nodes []*apiv1.Node) (map[string]float64, error) {
+	resp, err := p.client.Get(p.url + "/api/v1/query?" + url.Values{"query": {p.query}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := prometheusResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode prometheus response with status %d: %v", resp.StatusCode, err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", response.Error)
+	}
+	if response.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("prometheus query returned %s, expected vector", response.Data.ResultType)
+	}
+
+	wanted := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		wanted[node.Name] = true
+	}
+	result := make(map[string]float64)
+	for _, sample := range response.Data.Result {
+		name := sample.Metric[p.nodeLabel]
+		if !wanted[name] {
+			continue
+		}
+		if len(sample.Value) != 2 {
+			klog.Warningf("Ignoring malformed busyness sample for %s: %v", name, sample.Value)
+			continue
+		}
+		value, ok := sample.Value[1].(string)
+		if !ok {
+			klog.Warningf("Ignoring malformed busyness sample for %s: %v", name, sample.Value)
+			continue
+		}
+		busyness, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(busyness) {
+			klog.Warningf("Ignoring invalid busyness %q for %s", value, name)
+			continue
+		}
+		result[name] = busyness
+	}
+	return result, nil
+}
+
+// CleanUp cleans up the provider's internal structures.
+func (p *PrometheusNodeBusynessProvider) CleanUp() {
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusNodeBusynessProvider(t *testing.T) {
+	query := `1 - avg by (node) (rate(node_cpu_seconds_total{mode="idle"}[5m]))`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, query, r.URL.Query().Get("query"))
+		fmt.Fprint(w, `{"status": "success", "data": {"resultType": "vector", "result": [
+			{"metric": {"node": "n1"}, "value": [1557381438.123, "0.75"]},
+			{"metric": {"node": "n2"}, "value": [1557381438.123, "NaN"]},
+			{"metric": {"node": "n3"}, "value": [1557381438.123, "0.1"]},
+			{"metric": {"node": "other"}, "value": [1557381438.123, "0.5"]}
+		]}}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewPrometheusNodeBusynessProvider(server.URL+"/", query, "")
+	assert.NoError(t, err)
+
+	nodes := []*apiv1.Node{BuildTestNode("n1", 1000, 1000), BuildTestNode("n2", 1000, 1000), BuildTestNode("n3", 1000, 1000)}
+	busyness, err := provider.GetNodesBusyness(nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"n1": 0.75, "n3": 0.1}, busyness)
+}
+
+func TestPrometheusNodeBusynessProviderErrors(t *testing.T) {
+	response := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+
+	provider, err := NewPrometheusNodeBusynessProvider(server.URL, "up", "instance")
+	assert.NoError(t, err)
+	nodes := []*apiv1.Node{BuildTestNode("n1", 1000, 1000)}
+
+	for _, response = range []string{
+		`{"status": "error", "error": "parse error"}`,
+		`{"status": "success", "data": {"resultType": "matrix", "result": []}}`,
+		`not json`,
+	} {
+		_, err = provider.GetNodesBusyness(nodes)
+		assert.Error(t, err, response)
+	}
+
+	_, err = NewPrometheusNodeBusynessProvider("", "up", "")
+	assert.Error(t, err)
+	_, err = NewPrometheusNodeBusynessProvider(server.URL, "", "")
+	assert.Error(t, err)
+}
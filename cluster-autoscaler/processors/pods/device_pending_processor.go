@@ -0,0 +1,233 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	scheduler_util "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const containerCreatingReason = "ContainerCreating"
+
+// DevicePendingPodListProcessor adds to the unschedulable pods the scheduled pods requesting device
+// resources whose containers have been waiting to be created for longer than a timeout. The scheduler
+// only sees the total of a device resource on a node, so it may bind a pod to a node whose devices
+// each have too little of the resource left for it, and the device plugin never allocates it. Such
+// pods are added as unschedulable pods kept off their node, and stay scheduled so that their requests
+// are still accounted for on it. Once a node added since such a pod was scheduled is ready and fits it,
+// the pod is evicted for its controller to replace it: it would stay stuck otherwise, and the added
+// node would be removed as unneeded.
+type DevicePendingPodListProcessor struct {
+	processor PodListProcessor
+	resources map[apiv1.ResourceName]bool
+	timeout   time.Duration
+}
+
+// NewDevicePendingPodListProcessor creates a DevicePendingPodListProcessor for the pods requesting the
+// given resources after running the given processor.
+func NewDevicePendingPodListProcessor(processor PodListProcessor, resources []apiv1.ResourceName, timeout time.Duration) *DevicePendingPodListProcessor {
+	names := make(map[apiv1.ResourceName]bool, len(resources))
+	for _, name := range resources {
+		names[name] = true
+	}
+	return &DevicePendingPodListProcessor{
+		processor: processor,
+		resources: names,
+		timeout:   timeout,
+	}
+}
+
+// Process processes lists of unschedulable and scheduled pods before scaling of the cluster.
+func (p *DevicePendingPodListProcessor) Process(context *context.AutoscalingContext, unschedulablePods []*apiv1.Pod, allScheduled []*apiv1.Pod, nodes []*apiv1.Node) ([]*apiv1.Pod, []*apiv1.Pod, error) {
+	if p.processor != nil {
+		var err error
+		unschedulablePods, allScheduled, err = p.processor.Process(context, unschedulablePods, allScheduled, nodes)
+		if err != nil {
+			return unschedulablePods, allScheduled, err
+		}
+	}
+
+	now := time.Now()
+	var readyNodeInfos map[string]*schedulernodeinfo.NodeInfo
+	for _, pod := range allScheduled {
+		if !p.requestsDevices(pod) || !containersCreating(pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		waiting := now.Sub(scheduledTime(pod))
+		if waiting <= p.timeout {
+			continue
+		}
+		unscheduled := unscheduledCopy(pod)
+		if readyNodeInfos == nil {
+			readyNodeInfos = scheduler_util.CreateNodeNameToInfoMap(allScheduled, readyNodes(nodes))
+		}
+		if nodeName, found := fitsNodeAddedSince(context, unscheduled, scheduledTime(pod), readyNodeInfos); found && metav1.GetControllerOf(pod) != nil {
+			klog.V(1).Infof("Pod %s/%s has been waiting for the creation of its containers on node %s for %v, evicting it as it fits on node %s added since",
+				pod.Namespace, pod.Name, pod.Spec.NodeName, waiting, nodeName)
+			if err := evictStuckPod(context, pod); err != nil {
+				klog.Warningf("Failed to evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+			continue
+		}
+		klog.V(2).Infof("Pod %s/%s has been waiting for the creation of its containers on node %s for %v, considering it unschedulable",
+			pod.Namespace, pod.Name, pod.Spec.NodeName, waiting)
+		unschedulablePods = append(unschedulablePods, unscheduled)
+	}
+	return unschedulablePods, allScheduled, nil
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *DevicePendingPodListProcessor) CleanUp() {
+	if p.processor != nil {
+		p.processor.CleanUp()
+	}
+}
+
+func (p *DevicePendingPodListProcessor) requestsDevices(pod *apiv1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			if p.resources[name] && !quantity.IsZero() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readyNodes returns the ready nodes among the given ones.
+func readyNodes(nodes []*apiv1.Node) []*apiv1.Node {
+	var result []*apiv1.Node
+	for _, node := range nodes {
+		if ready, _, _ := kube_util.GetReadinessState(node); ready {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// fitsNodeAddedSince returns the name of a node created after the given time the pod fits on, and
+// false if there is none.
+func fitsNodeAddedSince(context *context.AutoscalingContext, pod *apiv1.Pod, since time.Time, nodeInfos map[string]*schedulernodeinfo.NodeInfo) (string, bool) {
+	addedNodeInfos := make(map[string]*schedulernodeinfo.NodeInfo)
+	for name, nodeInfo := range nodeInfos {
+		if nodeInfo.Node().CreationTimestamp.Time.After(since) {
+			addedNodeInfos[name] = nodeInfo
+		}
+	}
+	if len(addedNodeInfos) == 0 {
+		return "", false
+	}
+	nodeName, err := context.PredicateChecker.FitsAny(pod, addedNodeInfos)
+	return nodeName, err == nil
+}
+
+// evictStuckPod evicts the pod for its controller to replace it.
+func evictStuckPod(context *context.AutoscalingContext, pod *apiv1.Pod) error {
+	gracePeriod := int64(apiv1.DefaultTerminationGracePeriodSeconds)
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		if *pod.Spec.TerminationGracePeriodSeconds < int64(context.MaxGracefulTerminationSec) {
+			gracePeriod = *pod.Spec.TerminationGracePeriodSeconds
+		} else {
+			gracePeriod = int64(context.MaxGracefulTerminationSec)
+		}
+	}
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: &gracePeriod,
+		},
+	}
+	if err := context.ClientSet.CoreV1().Pods(pod.Namespace).Evict(eviction); err != nil {
+		return err
+	}
+	context.Recorder.Eventf(pod, apiv1.EventTypeNormal, "DevicePendingEvicted", "evicted pod stuck waiting for devices, it fits on a node added since")
+	return nil
+}
+
+// containersCreating returns true if the pod is bound to a node and pending with none of its
+// containers created.
+func containersCreating(pod *apiv1.Pod) bool {
+	if pod.Spec.NodeName == "" || pod.Status.Phase != apiv1.PodPending {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting == nil || status.State.Waiting.Reason != containerCreatingReason {
+			return false
+		}
+	}
+	return true
+}
+
+// scheduledTime returns when the pod was scheduled according to its PodScheduled condition, or its
+// creation time if it has no such condition.
+func scheduledTime(pod *apiv1.Pod) time.Time {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodScheduled && condition.Status == apiv1.ConditionTrue && !condition.LastTransitionTime.IsZero() {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// unscheduledCopy returns a copy of the pod as an unschedulable pod that can't go back to its node.
+func unscheduledCopy(pod *apiv1.Pod) *apiv1.Pod {
+	result := pod.DeepCopy()
+	result.Spec.NodeName = ""
+	result.Status = apiv1.PodStatus{
+		Phase: apiv1.PodPending,
+		Conditions: []apiv1.PodCondition{{
+			Type:   apiv1.PodScheduled,
+			Status: apiv1.ConditionFalse,
+			Reason: apiv1.PodReasonUnschedulable,
+		}},
+	}
+
+	notOnNode := apiv1.NodeSelectorRequirement{
+		Key:      "metadata.name",
+		Operator: apiv1.NodeSelectorOpNotIn,
+		Values:   []string{pod.Spec.NodeName},
+	}
+	if result.Spec.Affinity == nil {
+		result.Spec.Affinity = &apiv1.Affinity{}
+	}
+	if result.Spec.Affinity.NodeAffinity == nil {
+		result.Spec.Affinity.NodeAffinity = &apiv1.NodeAffinity{}
+	}
+	nodeAffinity := result.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &apiv1.NodeSelector{
+			NodeSelectorTerms: []apiv1.NodeSelectorTerm{{}},
+		}
+	}
+	// Node selector terms are ORed, so every term has to keep the pod off its node.
+	terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for i := range terms {
+		terms[i].MatchFields = append(terms[i].MatchFields, notOnNode)
+	}
+	return result
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevicePendingPodListProcessor(t *testing.T) {
+	const gpuMemory = apiv1.ResourceName("example.com/gpu-mem")
+	now := time.Now()
+	buildPod := func(name string, gpu bool, scheduled time.Time, waitingReason string) *apiv1.Pod {
+		pod := BuildTestPod(name, 100, 0)
+		if gpu {
+			pod.Spec.Containers[0].Resources.Requests[gpuMemory] = *resource.NewQuantity(5, resource.DecimalSI)
+		}
+		pod.Spec.NodeName = "n1"
+		pod.Status.Phase = apiv1.PodPending
+		pod.Status.Conditions = []apiv1.PodCondition{{
+			Type:               apiv1.PodScheduled,
+			Status:             apiv1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(scheduled),
+		}}
+		if waitingReason != "" {
+			pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+				State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: waitingReason}},
+			}}
+		}
+		return pod
+	}
+	stuck := buildPod("stuck", true, now.Add(-10*time.Minute), containerCreatingReason)
+	noStatuses := buildPod("no-statuses", true, now.Add(-10*time.Minute), "")
+	recent := buildPod("recent", true, now.Add(-time.Minute), containerCreatingReason)
+	pulling := buildPod("pulling", true, now.Add(-10*time.Minute), "ErrImagePull")
+	noDevices := buildPod("no-devices", false, now.Add(-10*time.Minute), containerCreatingReason)
+	// Pods from the listers have trimmed statuses that still tell why their containers wait.
+	trimmedPulling := kube_util.TrimPod(buildPod("trimmed-pulling", true, now.Add(-10*time.Minute), "ErrImagePull")).(*apiv1.Pod)
+	unschedulable := BuildTestPod("unschedulable", 100, 0)
+	allScheduled := []*apiv1.Pod{stuck, noStatuses, recent, pulling, noDevices, trimmedPulling}
+
+	processor := NewDevicePendingPodListProcessor(NewDefaultPodListProcessor(), []apiv1.ResourceName{gpuMemory}, 5*time.Minute)
+	unschedulablePods, scheduledPods, err := processor.Process(&context.AutoscalingContext{}, []*apiv1.Pod{unschedulable}, allScheduled, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, allScheduled, scheduledPods)
+	if assert.Len(t, unschedulablePods, 3) {
+		assert.Equal(t, unschedulable, unschedulablePods[0])
+		for i, expected := range []*apiv1.Pod{stuck, noStatuses} {
+			pod := unschedulablePods[i+1]
+			assert.Equal(t, expected.Name, pod.Name)
+			assert.Empty(t, pod.Spec.NodeName)
+			assert.Equal(t, apiv1.PodReasonUnschedulable, pod.Status.Conditions[0].Reason)
+			assert.Equal(t, []apiv1.NodeSelectorTerm{{
+				MatchFields: []apiv1.NodeSelectorRequirement{{Key: "metadata.name", Operator: apiv1.NodeSelectorOpNotIn, Values: []string{"n1"}}},
+			}}, pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+		}
+	}
+	// The scheduled pods are left alone.
+	assert.Equal(t, "n1", stuck.Spec.NodeName)
+	assert.Nil(t, stuck.Spec.Affinity)
+}
+
+func TestDevicePendingPodListProcessorEvictsToAddedNodes(t *testing.T) {
+	const gpuMemory = apiv1.ResourceName("example.com/gpu-mem")
+	now := time.Now()
+	buildNode := func(name string, created time.Time, ready bool) *apiv1.Node {
+		node := BuildTestNode(name, 1000, 1000)
+		node.Status.Allocatable[gpuMemory] = *resource.NewQuantity(10, resource.DecimalSI)
+		node.CreationTimestamp = metav1.NewTime(created)
+		SetNodeReadyState(node, ready, created)
+		return node
+	}
+	buildPod := func(name string, controlled bool) *apiv1.Pod {
+		pod := BuildTestPod(name, 100, 0)
+		pod.Spec.Containers[0].Resources.Requests[gpuMemory] = *resource.NewQuantity(5, resource.DecimalSI)
+		if controlled {
+			pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
+		}
+		pod.Spec.NodeName = "n1"
+		pod.Status.Phase = apiv1.PodPending
+		pod.Status.Conditions = []apiv1.PodCondition{{
+			Type:               apiv1.PodScheduled,
+			Status:             apiv1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+		}}
+		pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+			State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: containerCreatingReason}},
+		}}
+		return pod
+	}
+	controlled := buildPod("controlled", true)
+	bare := buildPod("bare", false)
+	terminating := buildPod("terminating", true)
+	deletionTime := metav1.NewTime(now)
+	terminating.DeletionTimestamp = &deletionTime
+
+	n1 := buildNode("n1", now.Add(-time.Hour), true)
+	older := buildNode("older", now.Add(-time.Hour), true)
+	unready := buildNode("unready", now.Add(-time.Minute), false)
+	added := buildNode("added", now.Add(-time.Minute), true)
+
+	evictedPods := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		evictedPods <- createAction.GetObject().(*policyv1.Eviction).Name
+		return true, nil, nil
+	})
+	autoscalingContext := &context.AutoscalingContext{
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			ClientSet: fakeClient,
+			Recorder:  kube_record.NewFakeRecorder(10),
+		},
+		PredicateChecker: simulator.NewTestPredicateChecker(),
+	}
+	processor := NewDevicePendingPodListProcessor(nil, []apiv1.ResourceName{gpuMemory}, 5*time.Minute)
+	allScheduled := []*apiv1.Pod{controlled, bare, terminating}
+
+	// Without a ready node added since, the pods trigger scale-up.
+	unschedulablePods, _, err := processor.Process(autoscalingContext, nil, allScheduled, []*apiv1.Node{n1, older, unready})
+	assert.NoError(t, err)
+	if assert.Len(t, unschedulablePods, 2) {
+		assert.Equal(t, controlled.Name, unschedulablePods[0].Name)
+		assert.Equal(t, bare.Name, unschedulablePods[1].Name)
+	}
+	assert.Empty(t, evictedPods)
+
+	// Once one is ready, the pods with a controller are evicted to move there.
+	unschedulablePods, _, err = processor.Process(autoscalingContext, nil, allScheduled, []*apiv1.Node{n1, older, unready, added})
+	assert.NoError(t, err)
+	if assert.Len(t, unschedulablePods, 1) {
+		assert.Equal(t, bare.Name, unschedulablePods[0].Name)
+	}
+	if assert.Len(t, evictedPods, 1) {
+		assert.Equal(t, controlled.Name, <-evictedPods)
+	}
+}
+
+func TestUnscheduledCopyKeepsNodeAffinity(t *testing.T) {
+	pod := BuildTestPod("p", 100, 0)
+	pod.Spec.NodeName = "n1"
+	zone := apiv1.NodeSelectorRequirement{Key: "zone", Operator: apiv1.NodeSelectorOpIn, Values: []string{"a"}}
+	pod.Spec.Affinity = &apiv1.Affinity{NodeAffinity: &apiv1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+			NodeSelectorTerms: []apiv1.NodeSelectorTerm{{MatchExpressions: []apiv1.NodeSelectorRequirement{zone}}, {}},
+		},
+	}}
+
+	terms := unscheduledCopy(pod).Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	notOnNode := apiv1.NodeSelectorRequirement{Key: "metadata.name", Operator: apiv1.NodeSelectorOpNotIn, Values: []string{"n1"}}
+	assert.Equal(t, []apiv1.NodeSelectorTerm{
+		{MatchExpressions: []apiv1.NodeSelectorRequirement{zone}, MatchFields: []apiv1.NodeSelectorRequirement{notOnNode}},
+		{MatchFields: []apiv1.NodeSelectorRequirement{notOnNode}},
+	}, terms)
+	assert.Empty(t, pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchFields)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog"
+)
+
+// LimitRangeDefaultsPodListProcessor sets the default requests of the LimitRanges of their namespace
+// on the containers of unschedulable pods lacking requests, so that such pods are simulated at the
+// size they would be admitted with rather than zero.
+type LimitRangeDefaultsPodListProcessor struct {
+	processor        PodListProcessor
+	limitRangeLister v1lister.LimitRangeLister
+}
+
+// NewLimitRangeDefaultsPodListProcessor creates a LimitRangeDefaultsPodListProcessor applying
+// LimitRange defaults after running the given processor.
+func NewLimitRangeDefaultsPodListProcessor(processor PodListProcessor, limitRangeLister v1lister.LimitRangeLister) *LimitRangeDefaultsPodListProcessor {
+	return &LimitRangeDefaultsPodListProcessor{
+		processor:        processor,
+		limitRangeLister: limitRangeLister,
+	}
+}
+
+// Process processes lists of unschedulable and scheduled pods before scaling of the cluster.
+func (p *LimitRangeDefaultsPodListProcessor) Process(context *context.AutoscalingContext, unschedulablePods []*apiv1.Pod, allScheduled []*apiv1.Pod, nodes []*apiv1.Node) ([]*apiv1.Pod, []*apiv1.Pod, error) {
+	if p.processor != nil {
+		var err error
+		unschedulablePods, allScheduled, err = p.processor.Process(context, unschedulablePods, allScheduled, nodes)
+		if err != nil {
+			return unschedulablePods, allScheduled, err
+		}
+	}
+
+	defaults := make(map[string]apiv1.ResourceList)
+	result := make([]*apiv1.Pod, 0, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		namespaceDefaults, found := defaults[pod.Namespace]
+		if !found {
+			namespaceDefaults = p.getDefaultRequests(pod.Namespace)
+			defaults[pod.Namespace] = namespaceDefaults
+		}
+		result = append(result, applyDefaultRequests(pod, namespaceDefaults))
+	}
+	return result, allScheduled, nil
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *LimitRangeDefaultsPodListProcessor) CleanUp() {
+	if p.processor != nil {
+		p.processor.CleanUp()
+	}
+}
+
+// getDefaultRequests returns the default container requests of the LimitRanges in the namespace.
+// Like in LimitRange admission, the default limit is used for resources without a default request.
+func (p *LimitRangeDefaultsPodListProcessor) getDefaultRequests(namespace string) apiv1.ResourceList {
+	limitRanges, err := p.limitRangeLister.LimitRanges(namespace).List(labels.Everything())
+	if err != nil {
+		klog.Warningf("Failed to list LimitRanges in namespace %s: %v", namespace, err)
+		return nil
+	}
+	requests := apiv1.ResourceList{}
+	for _, limitRange := range limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != apiv1.LimitTypeContainer {
+				continue
+			}
+			for name, quantity := range item.Default {
+				if _, found := requests[name]; !found {
+					requests[name] = quantity
+				}
+			}
+			for name, quantity := range item.DefaultRequest {
+				requests[name] = quantity
+			}
+		}
+	}
+	return requests
+}
+
+// applyDefaultRequests returns the pod with the default requests set on the containers lacking them.
+// The pod is copied if it needs to be changed.
+func applyDefaultRequests(pod *apiv1.Pod, defaults apiv1.ResourceList) *apiv1.Pod {
+	if len(defaults) == 0 || !lacksRequests(pod, defaults) {
+		return pod
+	}
+	pod = pod.DeepCopy()
+	for i := range pod.Spec.InitContainers {
+		setDefaultRequests(&pod.Spec.InitContainers[i], defaults)
+	}
+	for i := range pod.Spec.Containers {
+		setDefaultRequests(&pod.Spec.Containers[i], defaults)
+	}
+	klog.V(4).Infof("Applied LimitRange default requests to pod %s/%s", pod.Namespace, pod.Name)
+	return pod
+}
+
+func lacksRequests(pod *apiv1.Pod, defaults apiv1.ResourceList) bool {
+	for _, containers := range [][]apiv1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			for name := range defaults {
+				if _, found := container.Resources.Requests[name]; !found {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func setDefaultRequests(container *apiv1.Container, defaults apiv1.ResourceList) {
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = apiv1.ResourceList{}
+	}
+	for name, quantity := range defaults {
+		if _, found := container.Resources.Requests[name]; found {
+			continue
+		}
+		// Requests default to limits, as in API defaulting.
+		if limit, found := container.Resources.Limits[name]; found {
+			container.Resources.Requests[name] = limit.DeepCopy()
+			continue
+		}
+		container.Resources.Requests[name] = quantity.DeepCopy()
+	}
+}
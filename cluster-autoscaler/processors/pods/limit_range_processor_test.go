@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitRangeDefaultsPodListProcessor(t *testing.T) {
+	limitRange := &apiv1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "batch"},
+		Spec: apiv1.LimitRangeSpec{
+			Limits: []apiv1.LimitRangeItem{
+				{
+					Type: apiv1.LimitTypeContainer,
+					Default: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("1"),
+						apiv1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					DefaultRequest: apiv1.ResourceList{
+						apiv1.ResourceCPU: resource.MustParse("500m"),
+					},
+				},
+				{
+					Type: apiv1.LimitTypePod,
+					Max: apiv1.ResourceList{
+						apiv1.ResourceCPU: resource.MustParse("4"),
+					},
+				},
+			},
+		},
+	}
+	lister, err := kube_util.NewTestLimitRangeLister([]*apiv1.LimitRange{limitRange})
+	assert.NoError(t, err)
+
+	// No requests at all.
+	p1 := BuildTestPod("p1", -1, -1)
+	p1.Namespace = "batch"
+	p1.Spec.Containers[0].Resources.Requests = nil
+	// Own cpu request, memory request defaults to the limit.
+	p2 := BuildTestPod("p2", 200, -1)
+	p2.Namespace = "batch"
+	p2.Spec.Containers[0].Resources.Limits = apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("2Gi")}
+	// Namespace without LimitRanges.
+	p3 := BuildTestPod("p3", -1, -1)
+	scheduled := BuildTestPod("scheduled", 0, 0)
+	scheduled.Namespace = "batch"
+
+	processor := NewLimitRangeDefaultsPodListProcessor(NewDefaultPodListProcessor(), lister)
+	unschedulable, allScheduled, err := processor.Process(&context.AutoscalingContext{}, []*apiv1.Pod{p1, p2, p3}, []*apiv1.Pod{scheduled}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apiv1.Pod{scheduled}, allScheduled)
+	assert.Equal(t, 3, len(unschedulable))
+
+	requests := unschedulable[0].Spec.Containers[0].Resources.Requests
+	assert.Equal(t, int64(500), requests.Cpu().MilliValue())
+	assert.Equal(t, int64(1<<30), requests.Memory().Value())
+	assert.Nil(t, p1.Spec.Containers[0].Resources.Requests)
+
+	requests = unschedulable[1].Spec.Containers[0].Resources.Requests
+	assert.Equal(t, int64(200), requests.Cpu().MilliValue())
+	assert.Equal(t, int64(2<<30), requests.Memory().Value())
+
+	assert.Equal(t, p3, unschedulable[2])
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/klog"
+)
+
+// SchedulerNamePodListProcessor drops the unschedulable pods of schedulers other than the given ones,
+// so that only the pending pods of these schedulers trigger scale-up. Pods without a scheduler name
+// belong to the default scheduler.
+type SchedulerNamePodListProcessor struct {
+	processor      PodListProcessor
+	schedulerNames map[string]bool
+}
+
+// NewSchedulerNamePodListProcessor creates a SchedulerNamePodListProcessor keeping the unschedulable
+// pods of the given schedulers after running the given processor.
+func NewSchedulerNamePodListProcessor(processor PodListProcessor, schedulerNames []string) *SchedulerNamePodListProcessor {
+	names := make(map[string]bool, len(schedulerNames))
+	for _, name := range schedulerNames {
+		names[name] = true
+	}
+	return &SchedulerNamePodListProcessor{
+		processor:      processor,
+		schedulerNames: names,
+	}
+}
+
+// Process processes lists of unschedulable and scheduled pods before scaling of the cluster.
+func (p *SchedulerNamePodListProcessor) Process(context *context.AutoscalingContext, unschedulablePods []*apiv1.Pod, allScheduled []*apiv1.Pod, nodes []*apiv1.Node) ([]*apiv1.Pod, []*apiv1.Pod, error) {
+	if p.processor != nil {
+		var err error
+		unschedulablePods, allScheduled, err = p.processor.Process(context, unschedulablePods, allScheduled, nodes)
+		if err != nil {
+			return unschedulablePods, allScheduled, err
+		}
+	}
+
+	result := make([]*apiv1.Pod, 0, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		if schedulerName := simulator.PodSchedulerName(pod); !p.schedulerNames[schedulerName] {
+			klog.V(4).Infof("Ignoring pod %s/%s of scheduler %s", pod.Namespace, pod.Name, schedulerName)
+			continue
+		}
+		result = append(result, pod)
+	}
+	return result, allScheduled, nil
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *SchedulerNamePodListProcessor) CleanUp() {
+	if p.processor != nil {
+		p.processor.CleanUp()
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerNamePodListProcessor(t *testing.T) {
+	defaulted := BuildTestPod("defaulted", 100, 0)
+	defaultScheduler := BuildTestPod("default", 100, 0)
+	defaultScheduler.Spec.SchedulerName = apiv1.DefaultSchedulerName
+	batch := BuildTestPod("batch", 100, 0)
+	batch.Spec.SchedulerName = "batch-scheduler"
+	other := BuildTestPod("other", 100, 0)
+	other.Spec.SchedulerName = "other-scheduler"
+	scheduled := BuildTestPod("scheduled", 100, 0)
+	scheduled.Spec.SchedulerName = "other-scheduler"
+
+	processor := NewSchedulerNamePodListProcessor(NewDefaultPodListProcessor(), []string{apiv1.DefaultSchedulerName, "batch-scheduler"})
+	unschedulable, allScheduled, err := processor.Process(&context.AutoscalingContext{},
+		[]*apiv1.Pod{defaulted, defaultScheduler, batch, other}, []*apiv1.Pod{scheduled}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apiv1.Pod{defaulted, defaultScheduler, batch}, unschedulable)
+	assert.Equal(t, []*apiv1.Pod{scheduled}, allScheduled)
+}
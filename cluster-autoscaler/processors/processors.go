@@ -19,6 +19,7 @@ package processors
 import (
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodes"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/pods"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
 )
@@ -40,6 +41,10 @@ type AutoscalingProcessors struct {
 	AutoscalingStatusProcessor status.AutoscalingStatusProcessor
 	// NodeGroupManager is responsible for creating/deleting node groups.
 	NodeGroupManager nodegroups.NodeGroupManager
+	// NodeBusynessProvider is used to complement request-based node utilization in scale-down.
+	NodeBusynessProvider nodes.NodeBusynessProvider
+	// NodeGroupSpecProcessor is used to create the node groups declared outside of the cloud provider.
+	NodeGroupSpecProcessor nodegroups.NodeGroupSpecProcessor
 }
 
 // DefaultProcessors returns default set of processors.
@@ -52,6 +57,8 @@ func DefaultProcessors() *AutoscalingProcessors {
 		ScaleDownStatusProcessor:   status.NewDefaultScaleDownStatusProcessor(),
 		AutoscalingStatusProcessor: status.NewDefaultAutoscalingStatusProcessor(),
 		NodeGroupManager:           nodegroups.NewDefaultNodeGroupManager(),
+		NodeBusynessProvider:       nodes.NewDefaultNodeBusynessProvider(),
+		NodeGroupSpecProcessor:     nodegroups.NewDefaultNodeGroupSpecProcessor(),
 	}
 }
 
@@ -66,6 +73,8 @@ func TestProcessors() *AutoscalingProcessors {
 		ScaleDownStatusProcessor:   &status.NoOpScaleDownStatusProcessor{},
 		AutoscalingStatusProcessor: &status.NoOpAutoscalingStatusProcessor{},
 		NodeGroupManager:           nodegroups.NewDefaultNodeGroupManager(),
+		NodeBusynessProvider:       nodes.NewDefaultNodeBusynessProvider(),
+		NodeGroupSpecProcessor:     nodegroups.NewDefaultNodeGroupSpecProcessor(),
 	}
 }
 
@@ -78,4 +87,6 @@ func (ap *AutoscalingProcessors) CleanUp() {
 	ap.ScaleDownStatusProcessor.CleanUp()
 	ap.AutoscalingStatusProcessor.CleanUp()
 	ap.NodeGroupManager.CleanUp()
+	ap.NodeBusynessProvider.CleanUp()
+	ap.NodeGroupSpecProcessor.CleanUp()
 }
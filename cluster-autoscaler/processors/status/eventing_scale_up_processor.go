@@ -37,9 +37,12 @@ func (p *EventingScaleUpStatusProcessor) Process(context *context.AutoscalingCon
 			fmt.Sprintf("pod didn't trigger scale-up (it wouldn't fit if a new node is added): %s", ReasonsMessage(noScaleUpInfo)))
 	}
 	if len(status.ScaleUpInfos) > 0 {
+		message := fmt.Sprintf("pod triggered scale-up: %v", status.ScaleUpInfos)
+		if status.Estimation != nil {
+			message = fmt.Sprintf("%s, %v", message, status.Estimation)
+		}
 		for _, pod := range status.PodsTriggeredScaleUp {
-			context.Recorder.Eventf(pod, apiv1.EventTypeNormal, "TriggeredScaleUp",
-				"pod triggered scale-up: %v", status.ScaleUpInfos)
+			context.Recorder.Event(pod, apiv1.EventTypeNormal, "TriggeredScaleUp", message)
 		}
 	}
 }
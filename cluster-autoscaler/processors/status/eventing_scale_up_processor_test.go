@@ -56,6 +56,7 @@ func TestEventingScaleUpStatusProcessor(t *testing.T) {
 		state               *ScaleUpStatus
 		expectedTriggered   int
 		expectedNoTriggered int
+		expectedMessage     string
 	}{
 		{
 			caseName: "No scale up",
@@ -81,6 +82,16 @@ func TestEventingScaleUpStatusProcessor(t *testing.T) {
 			expectedTriggered:   1,
 			expectedNoTriggered: 2,
 		},
+		{
+			caseName: "Scale up with estimation",
+			state: &ScaleUpStatus{
+				ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{}},
+				PodsTriggeredScaleUp: []*apiv1.Pod{p3},
+				Estimation:           NewScaleUpEstimation("ng1", 2, 1, []*apiv1.Pod{p3}),
+			},
+			expectedTriggered: 1,
+			expectedMessage:   "2 nodes estimated, 1 requested in ng1 for 1 pods: Pod default/p3 (1 pods)",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -98,6 +109,7 @@ func TestEventingScaleUpStatusProcessor(t *testing.T) {
 			case event := <-fakeRecorder.Events:
 				if strings.Contains(event, "TriggeredScaleUp") {
 					triggered += 1
+					assert.Contains(t, event, tc.expectedMessage, "Test case '%v' failed.", tc.caseName)
 				} else if strings.Contains(event, "NotTriggerScaleUp") {
 					noTriggered += 1
 				} else {
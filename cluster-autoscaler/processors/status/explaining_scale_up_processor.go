@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/klog"
+)
+
+const (
+	// ExplainAnnotationKey is the annotation requesting an explanation of the scale-up simulation
+	// of a pending pod when set to "true". It's removed once the explanation is attached.
+	ExplainAnnotationKey = "cluster-autoscaler.kubernetes.io/explain"
+	// ExplanationAnnotationKey is the annotation holding the explanation of the last scale-up
+	// simulation of a pod that requested one.
+	ExplanationAnnotationKey = "cluster-autoscaler.kubernetes.io/explanation"
+)
+
+// ExplainingScaleUpStatusProcessor attaches the per node group result of the scale-up simulation,
+// as an event and an annotation, to the pods annotated with ExplainAnnotationKey, after running the
+// wrapped processor. Every pod is explained once, then the annotation requesting it is removed.
+type ExplainingScaleUpStatusProcessor struct {
+	Processor ScaleUpStatusProcessor
+}
+
+// Process processes the status of the cluster after a scale-up.
+func (p *ExplainingScaleUpStatusProcessor) Process(context *context.AutoscalingContext, status *ScaleUpStatus) {
+	if p.Processor != nil {
+		p.Processor.Process(context, status)
+	}
+	for _, noScaleUpInfo := range status.PodsRemainUnschedulable {
+		if wantsExplanation(noScaleUpInfo.Pod) {
+			explain(context, noScaleUpInfo.Pod, noScaleUpExplanation(noScaleUpInfo))
+		}
+	}
+	if len(status.ScaleUpInfos) > 0 {
+		for _, pod := range status.PodsTriggeredScaleUp {
+			if wantsExplanation(pod) {
+				explain(context, pod, scaleUpExplanation(status))
+			}
+		}
+	}
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *ExplainingScaleUpStatusProcessor) CleanUp() {
+	if p.Processor != nil {
+		p.Processor.CleanUp()
+	}
+}
+
+func wantsExplanation(pod *apiv1.Pod) bool {
+	return pod.Annotations[ExplainAnnotationKey] == "true"
+}
+
+// explain records the explanation on the pod and clears its request, with a merge patch of the
+// annotations so that the rest of the pod, which may have been trimmed or modified by the
+// autoscaler, isn't written. If the pod can't be patched, the explanation is attempted again in
+// the next scale-up.
+func explain(context *context.AutoscalingContext, pod *apiv1.Pod, explanation string) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				ExplainAnnotationKey:     nil,
+				ExplanationAnnotationKey: explanation,
+			},
+		},
+	})
+	if err != nil {
+		klog.Warningf("Failed to build scale-up explanation patch of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	if _, err := context.ClientSet.CoreV1().Pods(pod.Namespace).Patch(pod.Name, types.MergePatchType, patch); err != nil {
+		klog.Warningf("Failed to attach scale-up explanation to pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	context.Recorder.Event(pod, apiv1.EventTypeNormal, "ScaleUpExplanation", explanation)
+}
+
+func noScaleUpExplanation(noScaleUpInfo NoScaleUpInfo) string {
+	groups := make([]string, 0, len(noScaleUpInfo.RejectedNodeGroups)+len(noScaleUpInfo.SkippedNodeGroups))
+	for id, reasons := range noScaleUpInfo.RejectedNodeGroups {
+		groups = append(groups, fmt.Sprintf("%s: wouldn't fit (%s)", id, strings.Join(reasons.Reasons(), ", ")))
+	}
+	for id, reasons := range noScaleUpInfo.SkippedNodeGroups {
+		groups = append(groups, fmt.Sprintf("%s: skipped (%s)", id, strings.Join(reasons.Reasons(), ", ")))
+	}
+	if len(groups) == 0 {
+		return "pod didn't trigger scale-up, no node groups were considered"
+	}
+	sort.Strings(groups)
+	return fmt.Sprintf("pod didn't trigger scale-up: %s", strings.Join(groups, "; "))
+}
+
+func scaleUpExplanation(status *ScaleUpStatus) string {
+	groups := make([]string, 0, len(status.ScaleUpInfos))
+	for _, info := range status.ScaleUpInfos {
+		groups = append(groups, fmt.Sprintf("%s %d->%d (max: %d)", info.Group.Id(), info.CurrentSize, info.NewSize, info.MaxSize))
+	}
+	return fmt.Sprintf("pod triggered scale-up: %s", strings.Join(groups, ", "))
+}
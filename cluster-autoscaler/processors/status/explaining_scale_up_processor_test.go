@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainingScaleUpStatusProcessor(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+
+	p1 := BuildTestPod("p1", 0, 0)
+	p1.Annotations = map[string]string{ExplainAnnotationKey: "true", "kubectl.kubernetes.io/last-applied-configuration": "{}"}
+	p1.Status.ContainerStatuses = []apiv1.ContainerStatus{{Name: "c", Image: "image"}}
+	p2 := BuildTestPod("p2", 0, 0)
+	p2.Annotations = map[string]string{ExplainAnnotationKey: "true"}
+	p3 := BuildTestPod("p3", 0, 0)
+
+	client := fake.NewSimpleClientset(p1, p2, p3)
+	// The processor gets the pods from the listers, which are trimmed.
+	p1 = p1.DeepCopy()
+	p1.Annotations = map[string]string{ExplainAnnotationKey: "true"}
+	p1.Status.ContainerStatuses = nil
+	recorder := kube_record.NewFakeRecorder(10)
+	context := &context.AutoscalingContext{
+		AutoscalingKubeClients: context.AutoscalingKubeClients{ClientSet: client, Recorder: recorder},
+	}
+	processor := &ExplainingScaleUpStatusProcessor{Processor: &NoOpScaleUpStatusProcessor{}}
+
+	processor.Process(context, &ScaleUpStatus{
+		Result:               ScaleUpSuccessful,
+		ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{Group: provider.GetNodeGroup("ng1"), CurrentSize: 1, NewSize: 2, MaxSize: 10}},
+		PodsTriggeredScaleUp: []*apiv1.Pod{p1},
+		PodsRemainUnschedulable: []NoScaleUpInfo{
+			{
+				Pod:                p2,
+				RejectedNodeGroups: map[string]Reasons{"ng2": &testReason{"Insufficient cpu"}},
+				SkippedNodeGroups:  map[string]Reasons{"ng1": &testReason{"max node group size reached"}},
+			},
+			{
+				Pod:                p3,
+				RejectedNodeGroups: map[string]Reasons{"ng2": &testReason{"Insufficient cpu"}},
+			},
+		},
+	})
+
+	// The fake client doesn't remove the annotations set to null by merge patches.
+	patches := make(map[string]string)
+	for _, action := range client.Actions() {
+		if patch, ok := action.(core.PatchAction); ok {
+			assert.Equal(t, types.MergePatchType, patch.GetPatchType())
+			patches[patch.GetName()] = string(patch.GetPatch())
+		}
+	}
+	assert.Len(t, patches, 2)
+	for _, name := range []string{"p1", "p2"} {
+		assert.Contains(t, patches[name], `"`+ExplainAnnotationKey+`":null`)
+	}
+
+	explained, err := client.CoreV1().Pods(p1.Namespace).Get("p1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pod triggered scale-up: ng1 1->2 (max: 10)", explained.Annotations[ExplanationAnnotationKey])
+	// Only the annotations are written.
+	assert.Equal(t, "{}", explained.Annotations["kubectl.kubernetes.io/last-applied-configuration"])
+	assert.Equal(t, []apiv1.ContainerStatus{{Name: "c", Image: "image"}}, explained.Status.ContainerStatuses)
+
+	explained, err = client.CoreV1().Pods(p2.Namespace).Get("p2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pod didn't trigger scale-up: ng1: skipped (max node group size reached); ng2: wouldn't fit (Insufficient cpu)",
+		explained.Annotations[ExplanationAnnotationKey])
+
+	notExplained, err := client.CoreV1().Pods(p3.Namespace).Get("p3", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, notExplained.Annotations, ExplanationAnnotationKey)
+
+	assert.Equal(t, 2, len(recorder.Events))
+}
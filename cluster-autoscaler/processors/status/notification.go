@@ -0,0 +1,241 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/klog"
+)
+
+const (
+	// WebhookFormatJSON posts the Notification serialized as JSON.
+	WebhookFormatJSON = "json"
+	// WebhookFormatSlack posts a Slack-compatible {"text": ...} payload.
+	WebhookFormatSlack = "slack"
+
+	webhookTimeout = 10 * time.Second
+)
+
+// NotificationType describes what happened in a Notification.
+type NotificationType string
+
+const (
+	// ScaledUpNotification is sent after node groups were scaled up.
+	ScaledUpNotification NotificationType = "ScaledUp"
+	// ScaleUpFailedNotification is sent when a scale-up attempt failed.
+	ScaleUpFailedNotification NotificationType = "ScaleUpFailed"
+	// ScaledDownNotification is sent after nodes were removed or their removal was started.
+	ScaledDownNotification NotificationType = "ScaledDown"
+	// ScaleDownFailedNotification is sent when a scale-down attempt or a node deletion failed.
+	ScaleDownFailedNotification NotificationType = "ScaleDownFailed"
+)
+
+// Notification is a capacity change pushed to a NotificationSink.
+type Notification struct {
+	Type      NotificationType `json:"type"`
+	Cluster   string           `json:"cluster,omitempty"`
+	Message   string           `json:"message"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// NotificationSink delivers notifications to an external system.
+type NotificationSink interface {
+	Send(notification Notification) error
+}
+
+// WebhookNotificationSink posts notifications to an HTTP endpoint.
+type WebhookNotificationSink struct {
+	url    string
+	format string
+	client *http.Client
+}
+
+// NewWebhookNotificationSink builds a NotificationSink posting to the given url
+// in the given format.
+func NewWebhookNotificationSink(url, format string) (*WebhookNotificationSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook url must not be empty")
+	}
+	if format != WebhookFormatJSON && format != WebhookFormatSlack {
+		return nil, fmt.Errorf("unknown webhook format %q, expected %q or %q", format, WebhookFormatJSON, WebhookFormatSlack)
+	}
+	return &WebhookNotificationSink{
+		url:    url,
+		format: format,
+		client: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// Send posts the notification to the webhook.
+func (s *WebhookNotificationSink) Send(notification Notification) error {
+	var payload interface{} = notification
+	if s.format == WebhookFormatSlack {
+		text := fmt.Sprintf("[%s] %s", notification.Type, notification.Message)
+		if notification.Cluster != "" {
+			text = fmt.Sprintf("[%s] %s: %s", notification.Type, notification.Cluster, notification.Message)
+		}
+		payload = map[string]string{"text": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sendNotifications(sink NotificationSink, notifications []Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+	// Delivery must never block the autoscaling loop.
+	go func() {
+		for _, notification := range notifications {
+			if err := sink.Send(notification); err != nil {
+				klog.Warningf("Failed to send %s notification: %v", notification.Type, err)
+			}
+		}
+	}()
+}
+
+// NotifyingScaleUpStatusProcessor pushes scale-up results to a NotificationSink
+// after running the wrapped processor.
+type NotifyingScaleUpStatusProcessor struct {
+	Processor ScaleUpStatusProcessor
+	Sink      NotificationSink
+}
+
+// Process processes the status of the cluster after a scale-up.
+func (p *NotifyingScaleUpStatusProcessor) Process(context *context.AutoscalingContext, status *ScaleUpStatus) {
+	if p.Processor != nil {
+		p.Processor.Process(context, status)
+	}
+	sendNotifications(p.Sink, scaleUpNotifications(context.ClusterName, status, time.Now()))
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *NotifyingScaleUpStatusProcessor) CleanUp() {
+	if p.Processor != nil {
+		p.Processor.CleanUp()
+	}
+}
+
+func scaleUpNotifications(cluster string, status *ScaleUpStatus, now time.Time) []Notification {
+	switch status.Result {
+	case ScaleUpSuccessful:
+		groups := make([]string, 0, len(status.ScaleUpInfos))
+		for _, info := range status.ScaleUpInfos {
+			groups = append(groups, fmt.Sprintf("%s %d->%d (max: %d)", info.Group.Id(), info.CurrentSize, info.NewSize, info.MaxSize))
+		}
+		return []Notification{{
+			Type:      ScaledUpNotification,
+			Cluster:   cluster,
+			Message:   fmt.Sprintf("scaled up %s for %d pods", strings.Join(groups, ", "), len(status.PodsTriggeredScaleUp)),
+			Timestamp: now,
+		}}
+	case ScaleUpError:
+		return []Notification{{
+			Type:      ScaleUpFailedNotification,
+			Cluster:   cluster,
+			Message:   fmt.Sprintf("scale-up failed, %d pods remain unschedulable", len(status.PodsRemainUnschedulable)),
+			Timestamp: now,
+		}}
+	}
+	return nil
+}
+
+// NotifyingScaleDownStatusProcessor pushes scale-down results to a NotificationSink
+// after running the wrapped processor.
+type NotifyingScaleDownStatusProcessor struct {
+	Processor ScaleDownStatusProcessor
+	Sink      NotificationSink
+}
+
+// Process processes the status of the cluster after a scale-down.
+func (p *NotifyingScaleDownStatusProcessor) Process(context *context.AutoscalingContext, status *ScaleDownStatus) {
+	if p.Processor != nil {
+		p.Processor.Process(context, status)
+	}
+	sendNotifications(p.Sink, scaleDownNotifications(context.ClusterName, status, time.Now()))
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *NotifyingScaleDownStatusProcessor) CleanUp() {
+	if p.Processor != nil {
+		p.Processor.CleanUp()
+	}
+}
+
+func scaleDownNotifications(cluster string, status *ScaleDownStatus, now time.Time) []Notification {
+	notifications := []Notification{}
+	switch status.Result {
+	case ScaleDownNodeDeleted, ScaleDownNodeDeleteStarted:
+		nodes := make([]string, 0, len(status.ScaledDownNodes))
+		for _, node := range status.ScaledDownNodes {
+			if node.NodeGroup != nil {
+				nodes = append(nodes, fmt.Sprintf("%s (%s)", node.Node.Name, node.NodeGroup.Id()))
+			} else {
+				nodes = append(nodes, node.Node.Name)
+			}
+		}
+		notifications = append(notifications, Notification{
+			Type:      ScaledDownNotification,
+			Cluster:   cluster,
+			Message:   fmt.Sprintf("removing nodes %s", strings.Join(nodes, ", ")),
+			Timestamp: now,
+		})
+	case ScaleDownError:
+		notifications = append(notifications, Notification{
+			Type:      ScaleDownFailedNotification,
+			Cluster:   cluster,
+			Message:   "scale-down failed",
+			Timestamp: now,
+		})
+	}
+
+	failed := make([]string, 0)
+	for node, err := range status.NodeDeleteResults {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", node, err))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		notifications = append(notifications, Notification{
+			Type:      ScaleDownFailedNotification,
+			Cluster:   cluster,
+			Message:   fmt.Sprintf("failed to delete nodes: %s", strings.Join(failed, ", ")),
+			Timestamp: now,
+		})
+	}
+	return notifications
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotificationSink(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	notification := Notification{Type: ScaledUpNotification, Cluster: "c1", Message: "scaled up ng1"}
+
+	sink, err := NewWebhookNotificationSink(server.URL, WebhookFormatJSON)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Send(notification))
+	var received Notification
+	assert.NoError(t, json.Unmarshal(<-bodies, &received))
+	assert.Equal(t, notification, received)
+
+	sink, err = NewWebhookNotificationSink(server.URL, WebhookFormatSlack)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Send(notification))
+	assert.JSONEq(t, `{"text": "[ScaledUp] c1: scaled up ng1"}`, string(<-bodies))
+
+	_, err = NewWebhookNotificationSink(server.URL, "xml")
+	assert.Error(t, err)
+	_, err = NewWebhookNotificationSink("", WebhookFormatJSON)
+	assert.Error(t, err)
+}
+
+func TestWebhookNotificationSinkErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookNotificationSink(server.URL, WebhookFormatJSON)
+	assert.NoError(t, err)
+	assert.Error(t, sink.Send(Notification{Type: ScaledUpNotification}))
+}
+
+func TestScaleUpNotifications(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	now := time.Now()
+
+	notifications := scaleUpNotifications("c1", &ScaleUpStatus{
+		Result:               ScaleUpSuccessful,
+		ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{Group: provider.GetNodeGroup("ng1"), CurrentSize: 1, NewSize: 3, MaxSize: 10}},
+		PodsTriggeredScaleUp: []*apiv1.Pod{BuildTestPod("p1", 0, 0)},
+	}, now)
+	assert.Equal(t, []Notification{{
+		Type:      ScaledUpNotification,
+		Cluster:   "c1",
+		Message:   "scaled up ng1 1->3 (max: 10) for 1 pods",
+		Timestamp: now,
+	}}, notifications)
+
+	notifications = scaleUpNotifications("c1", &ScaleUpStatus{Result: ScaleUpError}, now)
+	assert.Equal(t, 1, len(notifications))
+	assert.Equal(t, ScaleUpFailedNotification, notifications[0].Type)
+
+	assert.Empty(t, scaleUpNotifications("c1", &ScaleUpStatus{Result: ScaleUpNotNeeded}, now))
+}
+
+func TestScaleDownNotifications(t *testing.T) {
+	now := time.Now()
+
+	notifications := scaleDownNotifications("", &ScaleDownStatus{
+		Result:            ScaleDownNodeDeleted,
+		ScaledDownNodes:   []*ScaleDownNode{{Node: BuildTestNode("n1", 1000, 1000)}},
+		NodeDeleteResults: map[string]error{"n2": fmt.Errorf("timeout"), "n3": nil},
+	}, now)
+	assert.Equal(t, []Notification{{
+		Type:      ScaledDownNotification,
+		Message:   "removing nodes n1",
+		Timestamp: now,
+	}, {
+		Type:      ScaleDownFailedNotification,
+		Message:   "failed to delete nodes: n2: timeout",
+		Timestamp: now,
+	}}, notifications)
+
+	assert.Empty(t, scaleDownNotifications("", &ScaleDownStatus{Result: ScaleDownNoUnneeded}, now))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleUpEstimation is the breakdown of the estimation a scale-up is based on.
+type ScaleUpEstimation struct {
+	// NodeGroup is the id of the node group picked by the expander.
+	NodeGroup string
+	// EstimatedNodes is the number of nodes the estimator found the pods need.
+	EstimatedNodes int
+	// RequestedNodes is the number of nodes requested, once the estimation was
+	// capped by cluster-wide and resource limits.
+	RequestedNodes int
+	// PodGroups are the groups of pods the new nodes are expected to help.
+	PodGroups []PodGroupEstimation
+}
+
+// PodGroupEstimation is a group of pods, owned by the same controller, a
+// scale-up is expected to help.
+type PodGroupEstimation struct {
+	// Owner is the kind, namespace and name of the controller of the pods,
+	// or those of the pod if it has no controller.
+	Owner string
+	// Pods is the number of pods of the group.
+	Pods int
+}
+
+// NewScaleUpEstimation builds the estimation of a scale-up of the given node
+// group for the given pods, grouping the pods by controller.
+func NewScaleUpEstimation(nodeGroup string, estimatedNodes, requestedNodes int, pods []*apiv1.Pod) *ScaleUpEstimation {
+	estimation := &ScaleUpEstimation{
+		NodeGroup:      nodeGroup,
+		EstimatedNodes: estimatedNodes,
+		RequestedNodes: requestedNodes,
+	}
+	groups := make(map[string]int)
+	for _, pod := range pods {
+		owner := fmt.Sprintf("Pod %s/%s", pod.Namespace, pod.Name)
+		if ref := metav1.GetControllerOf(pod); ref != nil {
+			owner = fmt.Sprintf("%s %s/%s", ref.Kind, pod.Namespace, ref.Name)
+		}
+		i, found := groups[owner]
+		if !found {
+			i = len(estimation.PodGroups)
+			groups[owner] = i
+			estimation.PodGroups = append(estimation.PodGroups, PodGroupEstimation{Owner: owner})
+		}
+		estimation.PodGroups[i].Pods++
+	}
+	return estimation
+}
+
+// Pods returns the number of pods the scale-up is expected to help.
+func (e *ScaleUpEstimation) Pods() int {
+	pods := 0
+	for _, group := range e.PodGroups {
+		pods += group.Pods
+	}
+	return pods
+}
+
+// String returns a human readable description of the estimation.
+func (e *ScaleUpEstimation) String() string {
+	groups := make([]string, 0, len(e.PodGroups))
+	for _, group := range e.PodGroups {
+		groups = append(groups, fmt.Sprintf("%s (%d pods)", group.Owner, group.Pods))
+	}
+	return fmt.Sprintf("%d nodes estimated, %d requested in %s for %d pods: %s",
+		e.EstimatedNodes, e.RequestedNodes, e.NodeGroup, e.Pods(), strings.Join(groups, ", "))
+}
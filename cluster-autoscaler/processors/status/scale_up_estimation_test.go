@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScaleUpEstimation(t *testing.T) {
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.OwnerReferences = GenerateOwnerReferences("rs1", "ReplicaSet", "extensions/v1beta1", "")
+	p2 := BuildTestPod("p2", 100, 0)
+	p2.OwnerReferences = GenerateOwnerReferences("job1", "Job", "batch/v1", "")
+	p3 := BuildTestPod("p3", 100, 0)
+	p3.OwnerReferences = GenerateOwnerReferences("rs1", "ReplicaSet", "extensions/v1beta1", "")
+	p4 := BuildTestPod("p4", 100, 0)
+
+	estimation := NewScaleUpEstimation("ng1", 3, 2, []*apiv1.Pod{p1, p2, p3, p4})
+
+	assert.Equal(t, "ng1", estimation.NodeGroup)
+	assert.Equal(t, 3, estimation.EstimatedNodes)
+	assert.Equal(t, 2, estimation.RequestedNodes)
+	assert.Equal(t, []PodGroupEstimation{
+		{Owner: "ReplicaSet default/rs1", Pods: 2},
+		{Owner: "Job default/job1", Pods: 1},
+		{Owner: "Pod default/p4", Pods: 1},
+	}, estimation.PodGroups)
+	assert.Equal(t, 4, estimation.Pods())
+	assert.Equal(t, "3 nodes estimated, 2 requested in ng1 for 4 pods: ReplicaSet default/rs1 (2 pods), Job default/job1 (1 pods), Pod default/p4 (1 pods)", estimation.String())
+}
@@ -31,6 +31,8 @@ type ScaleUpStatus struct {
 	PodsTriggeredScaleUp    []*apiv1.Pod
 	PodsRemainUnschedulable []NoScaleUpInfo
 	PodsAwaitEvaluation     []*apiv1.Pod
+	// Estimation is the breakdown of the estimation a successful scale-up is based on.
+	Estimation *ScaleUpEstimation
 }
 
 // NoScaleUpInfo contains information about a pod that didn't trigger scale-up.
@@ -77,7 +79,7 @@ type ScaleUpStatusProcessor interface {
 
 // NewDefaultScaleUpStatusProcessor creates a default instance of ScaleUpStatusProcessor.
 func NewDefaultScaleUpStatusProcessor() ScaleUpStatusProcessor {
-	return &EventingScaleUpStatusProcessor{}
+	return &ExplainingScaleUpStatusProcessor{Processor: &EventingScaleUpStatusProcessor{}}
 }
 
 // NoOpScaleUpStatusProcessor is a ScaleUpStatusProcessor implementations useful for testing.
@@ -27,12 +27,12 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/glogx"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	scheduler_util "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/tpu"
 	"k8s.io/kubernetes/pkg/scheduler/algorithm/predicates"
 
 	apiv1 "k8s.io/api/core/v1"
-	policyv1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 
@@ -72,7 +72,7 @@ func FindNodesToRemove(candidates []*apiv1.Node, allNodes []*apiv1.Node, pods []
 	listers kube_util.ListerRegistry, predicateChecker *PredicateChecker, maxCount int,
 	fastCheck bool, oldHints map[string]string, usageTracker *UsageTracker,
 	timestamp time.Time,
-	podDisruptionBudgets []*policyv1.PodDisruptionBudget,
+	podDisruptionBudgets *pdb.Cache,
 ) (nodesToRemove []NodeToBeRemoved, unremovableNodes []*apiv1.Node, podReschedulingHints map[string]string, finalError errors.AutoscalerError) {
 
 	nodeNameToNodeInfo := scheduler_util.CreateNodeNameToInfoMap(pods, allNodes)
@@ -110,7 +110,7 @@ candidateloop:
 			unremovable = append(unremovable, node)
 			continue candidateloop
 		}
-		findProblems := findPlaceFor(node.Name, podsToRemove, allNodes, nodeNameToNodeInfo, predicateChecker, oldHints, newHints,
+		findProblems := findPlaceFor(node.Name, podsToRemove, allNodes, nodeNameToNodeInfo, predicateChecker, listers, oldHints, newHints,
 			usageTracker, timestamp)
 
 		if findProblems == nil {
@@ -153,18 +153,28 @@ func FindEmptyNodesToRemove(candidates []*apiv1.Node, pods []*apiv1.Pod) []*apiv
 // Per resource utilization is the sum of requests for it divided by allocatable. It also returns the individual
 // cpu and memory utilization.
 func CalculateUtilization(node *apiv1.Node, nodeInfo *schedulernodeinfo.NodeInfo, skipDaemonSetPods, skipMirrorPods bool) (utilInfo UtilizationInfo, err error) {
-	cpu, err := calculateUtilizationOfResource(node, nodeInfo, apiv1.ResourceCPU, skipDaemonSetPods, skipMirrorPods)
+	reservedDaemonSetFraction := 0.0
+	if skipDaemonSetPods {
+		reservedDaemonSetFraction = 1.0
+	}
+	return CalculateUtilizationWithReservedDaemonSets(node, nodeInfo, reservedDaemonSetFraction, skipMirrorPods)
+}
+
+// CalculateUtilizationWithReservedDaemonSets calculates utilization of a node like CalculateUtilization, but
+// treats the given fraction of the requests of DaemonSet pods as reserved: 0 counts them fully and 1 ignores them.
+func CalculateUtilizationWithReservedDaemonSets(node *apiv1.Node, nodeInfo *schedulernodeinfo.NodeInfo, reservedDaemonSetFraction float64, skipMirrorPods bool) (utilInfo UtilizationInfo, err error) {
+	cpu, err := calculateUtilizationOfResource(node, nodeInfo, apiv1.ResourceCPU, reservedDaemonSetFraction, skipMirrorPods)
 	if err != nil {
 		return UtilizationInfo{}, err
 	}
-	mem, err := calculateUtilizationOfResource(node, nodeInfo, apiv1.ResourceMemory, skipDaemonSetPods, skipMirrorPods)
+	mem, err := calculateUtilizationOfResource(node, nodeInfo, apiv1.ResourceMemory, reservedDaemonSetFraction, skipMirrorPods)
 	if err != nil {
 		return UtilizationInfo{}, err
 	}
 	return UtilizationInfo{CpuUtil: cpu, MemUtil: mem, Utilization: math.Max(cpu, mem)}, nil
 }
 
-func calculateUtilizationOfResource(node *apiv1.Node, nodeInfo *schedulernodeinfo.NodeInfo, resourceName apiv1.ResourceName, skipDaemonSetPods, skipMirrorPods bool) (float64, error) {
+func calculateUtilizationOfResource(node *apiv1.Node, nodeInfo *schedulernodeinfo.NodeInfo, resourceName apiv1.ResourceName, reservedDaemonSetFraction float64, skipMirrorPods bool) (float64, error) {
 	nodeAllocatable, found := node.Status.Allocatable[resourceName]
 	if !found {
 		return 0, fmt.Errorf("failed to get %v from %s", resourceName, node.Name)
@@ -173,28 +183,31 @@ func calculateUtilizationOfResource(node *apiv1.Node, nodeInfo *schedulernodeinf
 		return 0, fmt.Errorf("%v is 0 at %s", resourceName, node.Name)
 	}
 	podsRequest := resource.MustParse("0")
+	daemonSetPodsRequest := resource.MustParse("0")
 	for _, pod := range nodeInfo.Pods() {
-		// factor daemonset pods out of the utilization calculations
-		if skipDaemonSetPods && isDaemonSet(pod) {
-			continue
-		}
 		// factor mirror pods out of the utilization calculations
 		if skipMirrorPods && drain.IsMirrorPod(pod) {
 			continue
 		}
+		request := &podsRequest
+		// daemonset pods are partially factored out of the utilization calculations
+		if isDaemonSet(pod) {
+			request = &daemonSetPodsRequest
+		}
 		for _, container := range pod.Spec.Containers {
 			if resourceValue, found := container.Resources.Requests[resourceName]; found {
-				podsRequest.Add(resourceValue)
+				request.Add(resourceValue)
 			}
 		}
 	}
-	return float64(podsRequest.MilliValue()) / float64(nodeAllocatable.MilliValue()), nil
+	totalRequest := float64(podsRequest.MilliValue()) + (1-reservedDaemonSetFraction)*float64(daemonSetPodsRequest.MilliValue())
+	return totalRequest / float64(nodeAllocatable.MilliValue()), nil
 }
 
 // TODO: We don't need to pass list of nodes here as they are already available in nodeInfos.
 func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, nodeInfos map[string]*schedulernodeinfo.NodeInfo,
-	predicateChecker *PredicateChecker, oldHints map[string]string, newHints map[string]string, usageTracker *UsageTracker,
-	timestamp time.Time) error {
+	predicateChecker *PredicateChecker, listers kube_util.ListerRegistry, oldHints map[string]string, newHints map[string]string,
+	usageTracker *UsageTracker, timestamp time.Time) error {
 
 	newNodeInfos := make(map[string]*schedulernodeinfo.NodeInfo)
 	for k, v := range nodeInfos {
@@ -207,7 +220,7 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 
 	loggingQuota := glogx.PodsLoggingQuota()
 
-	tryNodeForPod := func(nodename string, pod *apiv1.Pod, predicateMeta predicates.PredicateMetadata) bool {
+	tryNodeForPod := func(nodename string, pod *apiv1.Pod, pvs []*apiv1.PersistentVolume, predicateMeta predicates.PredicateMetadata) bool {
 		nodeInfo, found := newNodeInfos[nodename]
 		if found {
 			if nodeInfo.Node() == nil {
@@ -217,6 +230,12 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 				klog.Warningf("No node in nodeInfo %s -> %v", nodename, nodeInfo)
 				return false
 			}
+			// Zonal volumes can't follow the pod to another zone, so don't rely on the scheduler
+			// predicates alone to keep the pod away from nodes it could never be started on.
+			if err := checkVolumesTopology(pvs, nodeInfo.Node()); err != nil {
+				glogx.V(4).UpTo(loggingQuota).Infof("Evaluation %s for %s/%s -> %v", nodename, pod.Namespace, pod.Name, err)
+				return false
+			}
 			err := predicateChecker.CheckPredicates(pod, predicateMeta, nodeInfo)
 			if err != nil {
 				glogx.V(4).UpTo(loggingQuota).Infof("Evaluation %s for %s/%s -> %v", nodename, pod.Namespace, pod.Name, err.VerboseError())
@@ -250,11 +269,16 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 		predicateMeta := predicateChecker.GetPredicateMetadata(pod, newNodeInfos)
 		loggingQuota.Reset()
 
+		pvs, err := getBoundPersistentVolumes(pod, listers)
+		if err != nil {
+			return fmt.Errorf("failed to check volumes of %s: %v", podKey(pod), err)
+		}
+
 		klog.V(5).Infof("Looking for place for %s/%s", pod.Namespace, pod.Name)
 
 		hintedNode, hasHint := oldHints[podKey(pod)]
 		if hasHint {
-			if hintedNode != removedNode && tryNodeForPod(hintedNode, pod, predicateMeta) {
+			if hintedNode != removedNode && tryNodeForPod(hintedNode, pod, pvs, predicateMeta) {
 				foundPlace = true
 				targetNode = hintedNode
 			}
@@ -264,7 +288,7 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 				if node.Name == removedNode {
 					continue
 				}
-				if tryNodeForPod(node.Name, pod, predicateMeta) {
+				if tryNodeForPod(node.Name, pod, pvs, predicateMeta) {
 					foundPlace = true
 					targetNode = node.Name
 					break
@@ -22,7 +22,6 @@ import (
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
-	policyv1 "k8s.io/api/policy/v1beta1"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/kubernetes/pkg/kubelet/types"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
@@ -60,6 +59,14 @@ func TestUtilization(t *testing.T) {
 	assert.NoError(t, err)
 	assert.InEpsilon(t, 2.0/10, utilInfo.Utilization, 0.01)
 
+	utilInfo, err = CalculateUtilizationWithReservedDaemonSets(node, nodeInfo, 0.5, false)
+	assert.NoError(t, err)
+	assert.InEpsilon(t, 1.5/10, utilInfo.Utilization, 0.01)
+
+	utilInfo, err = CalculateUtilizationWithReservedDaemonSets(node, nodeInfo, 1, false)
+	assert.NoError(t, err)
+	assert.InEpsilon(t, 1.0/10, utilInfo.Utilization, 0.01)
+
 	mirrorPod4 := BuildTestPod("p4", 100, 200000)
 	mirrorPod4.Annotations = map[string]string{
 		types.ConfigMirrorAnnotationKey: "",
@@ -100,7 +107,7 @@ func TestFindPlaceAllOk(t *testing.T) {
 		"x",
 		[]*apiv1.Pod{new1, new2},
 		[]*apiv1.Node{node1, node2},
-		nodeInfos, NewTestPredicateChecker(),
+		nodeInfos, NewTestPredicateChecker(), nil,
 		oldHints, newHints, tracker, time.Now())
 
 	assert.Len(t, newHints, 2)
@@ -139,7 +146,7 @@ func TestFindPlaceAllBas(t *testing.T) {
 		"nbad",
 		[]*apiv1.Pod{new1, new2, new3},
 		[]*apiv1.Node{nodebad, node1, node2},
-		nodeInfos, NewTestPredicateChecker(),
+		nodeInfos, NewTestPredicateChecker(), nil,
 		oldHints, newHints, tracker, time.Now())
 
 	assert.Error(t, err)
@@ -168,7 +175,7 @@ func TestFindNone(t *testing.T) {
 		"x",
 		[]*apiv1.Pod{},
 		[]*apiv1.Node{node1, node2},
-		nodeInfos, NewTestPredicateChecker(),
+		nodeInfos, NewTestPredicateChecker(), nil,
 		make(map[string]string),
 		make(map[string]string),
 		NewUsageTracker(),
@@ -313,7 +320,7 @@ func TestFindNodesToRemove(t *testing.T) {
 		toRemove, unremovable, _, err := FindNodesToRemove(
 			test.candidates, test.allNodes, pods, nil,
 			predicateChecker, len(test.allNodes), true, map[string]string{},
-			tracker, time.Now(), []*policyv1.PodDisruptionBudget{})
+			tracker, time.Now(), nil)
 		assert.NoError(t, err)
 		fmt.Printf("Test scenario: %s, found len(toRemove)=%v, expected len(test.toRemove)=%v\n", test.name, len(toRemove), len(test.toRemove))
 		assert.Equal(t, toRemove, test.toRemove)
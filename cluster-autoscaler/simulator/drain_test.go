@@ -23,6 +23,7 @@ import (
 	policyv1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/kubernetes/pkg/kubelet/types"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
@@ -167,7 +168,7 @@ func TestFastGetPodsToMove(t *testing.T) {
 		},
 	}
 
-	_, err = FastGetPodsToMove(schedulernodeinfo.NewNodeInfo(pod8), true, true, []*policyv1.PodDisruptionBudget{pdb8})
+	_, err = FastGetPodsToMove(schedulernodeinfo.NewNodeInfo(pod8), true, true, pdb.NewCache([]*policyv1.PodDisruptionBudget{pdb8}))
 	assert.Error(t, err)
 
 	// Pdb allowing
@@ -200,7 +201,7 @@ func TestFastGetPodsToMove(t *testing.T) {
 		},
 	}
 
-	r9, err := FastGetPodsToMove(schedulernodeinfo.NewNodeInfo(pod9), true, true, []*policyv1.PodDisruptionBudget{pdb9})
+	r9, err := FastGetPodsToMove(schedulernodeinfo.NewNodeInfo(pod9), true, true, pdb.NewCache([]*policyv1.PodDisruptionBudget{pdb9}))
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(r9))
 }
@@ -20,7 +20,6 @@ import (
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
-	policyv1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
@@ -44,8 +43,8 @@ func GetRequiredPodsForNode(nodename string, listers kube_util.ListerRegistry) (
 
 	podsToRemoveList, err := drain.GetPodsForDeletionOnNodeDrain(
 		allPods,
-		[]*policyv1.PodDisruptionBudget{}, // PDBs are irrelevant when considering new node.
-		true,                              // Force all removals.
+		nil,  // PDBs are irrelevant when considering new node.
+		true, // Force all removals.
 		false,
 		false,
 		false, // Setting this to true requires listers to be not-null.
@@ -55,7 +55,7 @@ func TestRequiredPodsForNode(t *testing.T) {
 	}
 
 	podLister := kube_util.NewTestPodLister([]*apiv1.Pod{&pod1, &pod2})
-	registry := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil)
+	registry := kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	pods, err := GetRequiredPodsForNode(nodeName, registry)
 	assert.NoError(t, err)
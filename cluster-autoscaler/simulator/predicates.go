@@ -42,6 +42,8 @@ const (
 	// We want to disable affinity predicate for performance reasons if no pod
 	// requires it.
 	affinityPredicateName = "MatchInterPodAffinity"
+	// The predicate checking that nodes are ready and schedulable, run for the pods of all schedulers.
+	readyPredicateName = "ready"
 )
 
 type predicateInfo struct {
@@ -54,6 +56,9 @@ type PredicateChecker struct {
 	predicates                []predicateInfo
 	predicateMetadataProducer predicates.PredicateMetadataProducer
 	enableAffinityPredicate   bool
+	// schedulerPredicates holds the names of the only predicates checked for the pods of some
+	// schedulers, by scheduler name. The pods of other schedulers are checked against all predicates.
+	schedulerPredicates map[string]map[string]bool
 }
 
 // There are no const arrays in Go, this is meant to be used as a const.
@@ -141,7 +146,7 @@ func NewPredicateChecker(kubeClient kube_client.Interface, stop <-chan struct{})
 	for predicateName, predicateFunc := range sched.Config().Algorithm.Predicates() {
 		predicateMap[predicateName] = predicateFunc
 	}
-	predicateMap["ready"] = isNodeReadyAndSchedulablePredicate
+	predicateMap[readyPredicateName] = isNodeReadyAndSchedulablePredicate
 	if err != nil {
 		return nil, err
 	}
@@ -196,7 +201,7 @@ func NewTestPredicateChecker() *PredicateChecker {
 	return &PredicateChecker{
 		predicates: []predicateInfo{
 			{name: "default", predicate: predicates.GeneralPredicates},
-			{name: "ready", predicate: isNodeReadyAndSchedulablePredicate},
+			{name: readyPredicateName, predicate: isNodeReadyAndSchedulablePredicate},
 		},
 		predicateMetadataProducer: func(_ *apiv1.Pod, _ map[string]*schedulernodeinfo.NodeInfo) predicates.PredicateMetadata {
 			return nil
@@ -217,6 +222,30 @@ func (p *PredicateChecker) IsAffinityPredicateEnabled() bool {
 	return p.enableAffinityPredicate
 }
 
+// SetSchedulerPredicates restricts the predicates checked for the pods of the given schedulers, keyed
+// by scheduler name, to the given predicates, e.g. for schedulers not enforcing some of the predicates
+// of the default scheduler. Nodes are checked to be ready for the pods of all schedulers. Returns an
+// error if a predicate is unknown.
+func (p *PredicateChecker) SetSchedulerPredicates(schedulerPredicates map[string][]string) error {
+	known := make(map[string]bool, len(p.predicates))
+	for _, predInfo := range p.predicates {
+		known[predInfo.name] = true
+	}
+	result := make(map[string]map[string]bool, len(schedulerPredicates))
+	for schedulerName, predicateNames := range schedulerPredicates {
+		names := map[string]bool{readyPredicateName: true}
+		for _, predicateName := range predicateNames {
+			if !known[predicateName] {
+				return fmt.Errorf("unknown predicate %s for scheduler %s", predicateName, schedulerName)
+			}
+			names[predicateName] = true
+		}
+		result[schedulerName] = names
+	}
+	p.schedulerPredicates = result
+	return nil
+}
+
 // GetPredicateMetadata precomputes some information useful for running predicates on a given pod in a given state
 // of the cluster (represented by nodeInfos map). Passing the result of this function to CheckPredicates can significantly
 // improve the performance of running predicates, especially MatchInterPodAffinity predicate. However, calculating
@@ -319,11 +348,16 @@ func (pe *PredicateError) PredicateName() string {
 // performance gains of CheckPredicates won't always offset the cost of GetPredicateMetadata.
 // Alternatively you can pass nil as predicateMetadata.
 func (p *PredicateChecker) CheckPredicates(pod *apiv1.Pod, predicateMetadata predicates.PredicateMetadata, nodeInfo *schedulernodeinfo.NodeInfo) *PredicateError {
+	enabledPredicates := p.schedulerPredicates[PodSchedulerName(pod)]
 	for _, predInfo := range p.predicates {
 		// Skip affinity predicate if it has been disabled.
 		if !p.enableAffinityPredicate && predInfo.name == affinityPredicateName {
 			continue
 		}
+		// Skip predicates not enforced by the scheduler of the pod.
+		if enabledPredicates != nil && !enabledPredicates[predInfo.name] {
+			continue
+		}
 
 		match, failureReasons, err := predInfo.predicate(pod, predicateMetadata, nodeInfo)
 
@@ -337,3 +371,12 @@ func (p *PredicateChecker) CheckPredicates(pod *apiv1.Pod, predicateMetadata pre
 	}
 	return nil
 }
+
+// PodSchedulerName returns the name of the scheduler of the pod, defaulting to the default scheduler
+// like API defaulting does.
+func PodSchedulerName(pod *apiv1.Pod) string {
+	if pod.Spec.SchedulerName == "" {
+		return apiv1.DefaultSchedulerName
+	}
+	return pod.Spec.SchedulerName
+}
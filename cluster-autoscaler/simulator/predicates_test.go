@@ -67,3 +67,39 @@ func TestPredicates(t *testing.T) {
 	assert.Nil(t, predicateChecker.CheckPredicates(p4, nil, ni2))
 	assert.NotNil(t, predicateChecker.CheckPredicates(p3, nil, ni2))
 }
+
+func TestSchedulerPredicates(t *testing.T) {
+	p1 := BuildTestPod("p1", 450, 500000)
+	p2 := BuildTestPod("p2", 600, 500000)
+	p2.Spec.SchedulerName = "overcommitting-scheduler"
+	p3 := BuildTestPod("p3", 600, 500000)
+	p3.Spec.SchedulerName = "overcommitting-scheduler"
+
+	ni1 := schedulernodeinfo.NewNodeInfo(p1)
+	node1 := BuildTestNode("n1", 1000, 2000000)
+	SetNodeReadyState(node1, true, time.Time{})
+	ni1.SetNode(node1)
+	ni2 := schedulernodeinfo.NewNodeInfo()
+	node2 := BuildTestNode("n2", 1000, 2000000)
+	SetNodeReadyState(node2, false, time.Time{})
+	ni2.SetNode(node2)
+
+	predicateChecker := NewTestPredicateChecker()
+	assert.NotNil(t, predicateChecker.CheckPredicates(p2, nil, ni1))
+
+	assert.NoError(t, predicateChecker.SetSchedulerPredicates(map[string][]string{"overcommitting-scheduler": {}}))
+	assert.Nil(t, predicateChecker.CheckPredicates(p2, nil, ni1))
+	assert.Nil(t, predicateChecker.CheckPredicates(p3, nil, ni1))
+	// Nodes still need to be ready.
+	predicateErr := predicateChecker.CheckPredicates(p2, nil, ni2)
+	if assert.NotNil(t, predicateErr) {
+		assert.Equal(t, "ready", predicateErr.PredicateName())
+	}
+	// Pods of other schedulers are checked against all predicates.
+	assert.NotNil(t, predicateChecker.CheckPredicates(BuildTestPod("p4", 600, 500000), nil, ni1))
+
+	assert.NoError(t, predicateChecker.SetSchedulerPredicates(map[string][]string{"overcommitting-scheduler": {"default"}}))
+	assert.NotNil(t, predicateChecker.CheckPredicates(p2, nil, ni1))
+
+	assert.Error(t, predicateChecker.SetSchedulerPredicates(map[string][]string{"overcommitting-scheduler": {"unknown"}}))
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	volumehelpers "k8s.io/cloud-provider/volume/helpers"
+	volumeutil "k8s.io/kubernetes/pkg/volume/util"
+)
+
+// getBoundPersistentVolumes returns the persistent volumes bound to the claims used by the pod.
+// Volumes that are not bound yet don't restrict where the pod can go and are skipped.
+func getBoundPersistentVolumes(pod *apiv1.Pod, listers kube_util.ListerRegistry) ([]*apiv1.PersistentVolume, error) {
+	if listers == nil || listers.PersistentVolumeClaimLister() == nil || listers.PersistentVolumeLister() == nil {
+		return nil, nil
+	}
+	result := make([]*apiv1.PersistentVolume, 0)
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := volume.PersistentVolumeClaim.ClaimName
+		claim, err := listers.PersistentVolumeClaimLister().PersistentVolumeClaims(pod.Namespace).Get(claimName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume claim %s/%s: %v", pod.Namespace, claimName, err)
+		}
+		if claim.Spec.VolumeName == "" {
+			continue
+		}
+		pv, err := listers.PersistentVolumeLister().Get(claim.Spec.VolumeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume %s: %v", claim.Spec.VolumeName, err)
+		}
+		result = append(result, pv)
+	}
+	return result, nil
+}
+
+// checkVolumesTopology verifies that all of the given persistent volumes can be attached to the node,
+// based on their node affinity and zone/region labels.
+func checkVolumesTopology(pvs []*apiv1.PersistentVolume, node *apiv1.Node) error {
+	for _, pv := range pvs {
+		if err := volumeutil.CheckNodeAffinity(pv, node.Labels); err != nil {
+			return fmt.Errorf("node affinity of volume %s doesn't match", pv.Name)
+		}
+		for _, key := range []string{apiv1.LabelZoneFailureDomain, apiv1.LabelZoneRegion} {
+			value, found := pv.Labels[key]
+			if !found {
+				continue
+			}
+			allowed, err := volumehelpers.LabelZonesToSet(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s label on volume %s: %v", key, pv.Name, err)
+			}
+			if !allowed.Has(node.Labels[key]) {
+				return fmt.Errorf("volume %s is in %s %q", pv.Name, key, value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildZonalVolume(name, zone string) *apiv1.PersistentVolume {
+	return &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{apiv1.LabelZoneFailureDomain: zone},
+		},
+	}
+}
+
+func buildClaim(name, volumeName string) *apiv1.PersistentVolumeClaim {
+	return &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       apiv1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+	}
+}
+
+func buildZonalNode(name, zone string) *apiv1.Node {
+	node := BuildTestNode(name, 1000, 2000000)
+	node.Labels[apiv1.LabelZoneFailureDomain] = zone
+	SetNodeReadyState(node, true, time.Time{})
+	return node
+}
+
+func addClaimToPod(pod *apiv1.Pod, claimName string) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+		Name: claimName,
+		VolumeSource: apiv1.VolumeSource{
+			PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	})
+}
+
+func buildVolumeListers(t *testing.T, pvs []*apiv1.PersistentVolume, pvcs []*apiv1.PersistentVolumeClaim) kube_util.ListerRegistry {
+	pvLister, err := kube_util.NewTestPersistentVolumeLister(pvs)
+	assert.NoError(t, err)
+	pvcLister, err := kube_util.NewTestPersistentVolumeClaimLister(pvcs)
+	assert.NoError(t, err)
+	return kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, pvLister, pvcLister)
+}
+
+func TestGetBoundPersistentVolumes(t *testing.T) {
+	pvA := buildZonalVolume("pv-a", "zone-a")
+	listers := buildVolumeListers(t, []*apiv1.PersistentVolume{pvA},
+		[]*apiv1.PersistentVolumeClaim{buildClaim("bound", "pv-a"), buildClaim("unbound", "")})
+
+	pod := BuildTestPod("p1", 100, 0)
+	addClaimToPod(pod, "bound")
+	addClaimToPod(pod, "unbound")
+	pvs, err := getBoundPersistentVolumes(pod, listers)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apiv1.PersistentVolume{pvA}, pvs)
+
+	addClaimToPod(pod, "missing")
+	_, err = getBoundPersistentVolumes(pod, listers)
+	assert.Error(t, err)
+
+	pvs, err = getBoundPersistentVolumes(pod, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, pvs)
+}
+
+func TestCheckVolumesTopology(t *testing.T) {
+	nodeA := buildZonalNode("n1", "zone-a")
+	nodeB := buildZonalNode("n2", "zone-b")
+
+	pvs := []*apiv1.PersistentVolume{buildZonalVolume("pv-a", "zone-a")}
+	assert.NoError(t, checkVolumesTopology(pvs, nodeA))
+	assert.Error(t, checkVolumesTopology(pvs, nodeB))
+	assert.NoError(t, checkVolumesTopology(nil, nodeB))
+
+	multiZone := []*apiv1.PersistentVolume{buildZonalVolume("pv-ab", "zone-a__zone-b")}
+	assert.NoError(t, checkVolumesTopology(multiZone, nodeB))
+
+	affinity := &apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-affinity"},
+		Spec: apiv1.PersistentVolumeSpec{
+			NodeAffinity: &apiv1.VolumeNodeAffinity{
+				Required: &apiv1.NodeSelector{
+					NodeSelectorTerms: []apiv1.NodeSelectorTerm{{
+						MatchExpressions: []apiv1.NodeSelectorRequirement{{
+							Key:      apiv1.LabelZoneFailureDomain,
+							Operator: apiv1.NodeSelectorOpIn,
+							Values:   []string{"zone-b"},
+						}},
+					}},
+				},
+			},
+		},
+	}
+	assert.Error(t, checkVolumesTopology([]*apiv1.PersistentVolume{affinity}, nodeA))
+	assert.NoError(t, checkVolumesTopology([]*apiv1.PersistentVolume{affinity}, nodeB))
+}
+
+func TestFindPlaceForZonalVolumes(t *testing.T) {
+	listers := buildVolumeListers(t, []*apiv1.PersistentVolume{buildZonalVolume("pv-a", "zone-a")},
+		[]*apiv1.PersistentVolumeClaim{buildClaim("data", "pv-a")})
+
+	pod := BuildTestPod("p1", 300, 500000)
+	addClaimToPod(pod, "data")
+
+	removed := buildZonalNode("removed", "zone-a")
+	otherZone := buildZonalNode("other-zone", "zone-b")
+	sameZone := buildZonalNode("same-zone", "zone-a")
+
+	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{}
+	for _, node := range []*apiv1.Node{removed, otherZone, sameZone} {
+		nodeInfos[node.Name] = schedulernodeinfo.NewNodeInfo()
+		nodeInfos[node.Name].SetNode(node)
+	}
+
+	err := findPlaceFor("removed", []*apiv1.Pod{pod}, []*apiv1.Node{removed, otherZone},
+		nodeInfos, NewTestPredicateChecker(), listers,
+		make(map[string]string), make(map[string]string), NewUsageTracker(), time.Now())
+	assert.Error(t, err)
+
+	newHints := make(map[string]string)
+	err = findPlaceFor("removed", []*apiv1.Pod{pod}, []*apiv1.Node{removed, otherZone, sameZone},
+		nodeInfos, NewTestPredicateChecker(), listers,
+		map[string]string{"default/p1": "other-zone"}, newHints, NewUsageTracker(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "same-zone", newHints["default/p1"])
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"k8s.io/autoscaler/cluster-autoscaler/client"
+	"k8s.io/autoscaler/cluster-autoscaler/core"
+	core_api "k8s.io/autoscaler/cluster-autoscaler/core/api"
+	"k8s.io/klog"
+)
+
+const (
+	// exportStateCommand is the subcommand printing the runtime state
+	// of a running cluster-autoscaler.
+	exportStateCommand = "export-state"
+	// importStateCommand is the subcommand sending a runtime state
+	// printed by export-state to a running cluster-autoscaler.
+	importStateCommand = "import-state"
+)
+
+// stateHandler serves the runtime state of the autoscaler on GET and
+// imports the state sent on POST. State imported before the autoscaler
+// is built, e.g. while waiting to become the leader, is imported when
+// it is. Requests must carry the bearer token if one is set, and state
+// is never imported without one, as the metrics address is otherwise
+// unauthenticated.
+type stateHandler struct {
+	sync.Mutex
+	importEnabled bool
+	token         string
+	autoscaler    core.Autoscaler
+	pending       *core_api.AutoscalerState
+}
+
+func newStateHandler(importEnabled bool, token string) *stateHandler {
+	return &stateHandler{importEnabled: importEnabled, token: token}
+}
+
+// readStateToken returns the token held by file, or no token if file
+// is empty.
+func readStateToken(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	token, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(string(token))) == 0 {
+		return "", fmt.Errorf("state token file %s is empty", file)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// authorized returns true if r carries the token of h, if any.
+func (h *stateHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// setAutoscaler sets the autoscaler whose state is served, importing
+// the state received so far if any.
+func (h *stateHandler) setAutoscaler(autoscaler core.Autoscaler) {
+	h.Lock()
+	defer h.Unlock()
+	h.autoscaler = autoscaler
+	if h.pending != nil {
+		autoscaler.ImportState(h.pending)
+		h.pending = nil
+	}
+}
+
+func (h *stateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	if !h.authorized(r) {
+		http.Error(w, "invalid state token", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if h.autoscaler == nil {
+			http.Error(w, "autoscaler is not running", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(h.autoscaler.ExportState()); err != nil {
+			klog.Errorf("Failed to export state: %v", err)
+		}
+	case http.MethodPost:
+		if !h.importEnabled || h.token == "" {
+			http.Error(w, "state import is disabled", http.StatusForbidden)
+			return
+		}
+		state := &core_api.AutoscalerState{}
+		if err := json.NewDecoder(r.Body).Decode(state); err != nil {
+			http.Error(w, fmt.Sprintf("invalid state: %v", err), http.StatusBadRequest)
+			return
+		}
+		if h.autoscaler == nil {
+			klog.V(1).Infof("Received autoscaler state, importing it once the autoscaler is running")
+			h.pending = state
+		} else {
+			h.autoscaler.ImportState(state)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// exportState writes to w the runtime state of the cluster-autoscaler
+// given by args.
+func exportState(w io.Writer, args []string) error {
+	flags := pflag.NewFlagSet(exportStateCommand, pflag.ContinueOnError)
+	stateURL := flags.String("url", "http://localhost:8085", "URL of the metrics address of the cluster-autoscaler to export the state of.")
+	tokenFile := flags.String("token-file", "", "File holding the state token of the cluster-autoscaler, if it has one.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	token, err := readStateToken(*tokenFile)
+	if err != nil {
+		return err
+	}
+	state, err := client.NewStateClient(*stateURL, token, nil).ExportState()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(state)
+}
+
+// importState sends the runtime state read from r, or from the file
+// given by args, to the cluster-autoscaler given by args.
+func importState(r io.Reader, args []string) error {
+	flags := pflag.NewFlagSet(importStateCommand, pflag.ContinueOnError)
+	stateURL := flags.String("url", "http://localhost:8085", "URL of the metrics address of the cluster-autoscaler to import the state into.")
+	file := flags.String("file", "-", "File holding the state printed by "+exportStateCommand+", - for the standard input.")
+	tokenFile := flags.String("token-file", "", "File holding the state token of the cluster-autoscaler.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	token, err := readStateToken(*tokenFile)
+	if err != nil {
+		return err
+	}
+
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	state := &core_api.AutoscalerState{}
+	if err := json.NewDecoder(r).Decode(state); err != nil {
+		return fmt.Errorf("invalid state: %v", err)
+	}
+	return client.NewStateClient(*stateURL, token, nil).ImportState(state)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	core_api "k8s.io/autoscaler/cluster-autoscaler/core/api"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStateAutoscaler struct {
+	state *core_api.AutoscalerState
+}
+
+func (a *fakeStateAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError {
+	return nil
+}
+
+func (a *fakeStateAutoscaler) RunEmptyNodeCleanup(currentTime time.Time) errors.AutoscalerError {
+	return nil
+}
+
+func (a *fakeStateAutoscaler) ExitCleanUp() {}
+
+func (a *fakeStateAutoscaler) ExportState() *core_api.AutoscalerState {
+	return a.state
+}
+
+func (a *fakeStateAutoscaler) ImportState(state *core_api.AutoscalerState) {
+	a.state = state
+}
+
+func TestExportImportState(t *testing.T) {
+	lastScaleUpTime := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	exporting := &fakeStateAutoscaler{state: &core_api.AutoscalerState{
+		LastScaleUpTime: lastScaleUpTime,
+		UnneededNodes:   map[string]time.Time{"n1": lastScaleUpTime.Add(time.Minute)},
+	}}
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("token\n"), 0600))
+	wrongTokenFile := filepath.Join(dir, "wrong-token")
+	assert.NoError(t, ioutil.WriteFile(wrongTokenFile, []byte("wrong-token"), 0600))
+
+	exportingHandler := newStateHandler(false, "")
+	exportingServer := httptest.NewServer(exportingHandler)
+	defer exportingServer.Close()
+
+	// Nothing is exported before the autoscaler runs.
+	var out bytes.Buffer
+	assert.Error(t, exportState(&out, []string{"--url", exportingServer.URL}))
+	exportingHandler.setAutoscaler(exporting)
+	assert.NoError(t, exportState(&out, []string{"--url", exportingServer.URL}))
+	exported := out.Bytes()
+
+	// Import is disabled by default, and without a token.
+	assert.Error(t, importState(bytes.NewReader(exported), []string{"--url", exportingServer.URL}))
+	tokenlessHandler := newStateHandler(true, "")
+	tokenlessHandler.setAutoscaler(&fakeStateAutoscaler{})
+	tokenlessServer := httptest.NewServer(tokenlessHandler)
+	defer tokenlessServer.Close()
+	assert.Error(t, importState(bytes.NewReader(exported), []string{"--url", tokenlessServer.URL}))
+
+	// Requests need the token.
+	importing := &fakeStateAutoscaler{}
+	importingHandler := newStateHandler(true, "token")
+	importingServer := httptest.NewServer(importingHandler)
+	defer importingServer.Close()
+	assert.Error(t, importState(bytes.NewReader(exported), []string{"--url", importingServer.URL}))
+	assert.Error(t, importState(bytes.NewReader(exported), []string{"--url", importingServer.URL, "--token-file", wrongTokenFile}))
+	assert.Error(t, exportState(&out, []string{"--url", importingServer.URL}))
+
+	// State imported before the autoscaler runs is imported once it does.
+	assert.NoError(t, importState(bytes.NewReader(exported), []string{"--url", importingServer.URL, "--token-file", tokenFile}))
+	assert.Nil(t, importing.state)
+	importingHandler.setAutoscaler(importing)
+	if assert.NotNil(t, importing.state) {
+		assert.True(t, lastScaleUpTime.Equal(importing.state.LastScaleUpTime))
+		assert.True(t, lastScaleUpTime.Add(time.Minute).Equal(importing.state.UnneededNodes["n1"]))
+	}
+
+	// State is imported right away into a running autoscaler.
+	assert.NoError(t, importState(bytes.NewReader([]byte(`{"lastScaleUpTime": "2019-06-02T12:00:00Z"}`)), []string{"--url", importingServer.URL, "--token-file", tokenFile}))
+	assert.Equal(t, 2, importing.state.LastScaleUpTime.Day())
+
+	assert.Error(t, importState(bytes.NewReader([]byte(`not json`)), []string{"--url", importingServer.URL, "--token-file", tokenFile}))
+}
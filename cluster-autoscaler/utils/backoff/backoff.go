@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/core/api"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
 
@@ -33,4 +34,8 @@ type Backoff interface {
 	RemoveBackoff(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo)
 	// RemoveStaleBackoffData removes stale backoff data.
 	RemoveStaleBackoffData(currentTime time.Time)
+	// ExportBackoffData returns the backoff data of all backed off node groups, keyed by node group.
+	ExportBackoffData() map[string]api.BackoffData
+	// ImportBackoffData replaces the backoff data with the given one, e.g. exported by another instance.
+	ImportBackoffData(data map[string]api.BackoffData)
 }
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/core/api"
 
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
@@ -108,3 +109,28 @@ func (b *exponentialBackoff) RemoveStaleBackoffData(currentTime time.Time) {
 		}
 	}
 }
+
+// ExportBackoffData returns the backoff data of all backed off node groups, keyed by node group.
+func (b *exponentialBackoff) ExportBackoffData() map[string]api.BackoffData {
+	data := make(map[string]api.BackoffData, len(b.backoffInfo))
+	for key, backoffInfo := range b.backoffInfo {
+		data[key] = api.BackoffData{
+			Duration:            backoffInfo.duration,
+			BackoffUntil:        backoffInfo.backoffUntil,
+			LastFailedExecution: backoffInfo.lastFailedExecution,
+		}
+	}
+	return data
+}
+
+// ImportBackoffData replaces the backoff data with the given one, e.g. exported by another instance.
+func (b *exponentialBackoff) ImportBackoffData(data map[string]api.BackoffData) {
+	b.backoffInfo = make(map[string]exponentialBackoffInfo, len(data))
+	for key, backoffData := range data {
+		b.backoffInfo[key] = exponentialBackoffInfo{
+			duration:            backoffData.Duration,
+			backoffUntil:        backoffData.BackoffUntil,
+			lastFailedExecution: backoffData.LastFailedExecution,
+		}
+	}
+}
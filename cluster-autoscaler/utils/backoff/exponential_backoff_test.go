@@ -22,6 +22,7 @@ import (
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/core/api"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -81,3 +82,25 @@ func TestResetStaleBackoffData(t *testing.T) {
 	backoff.RemoveStaleBackoffData(startTime.Add(5 * time.Hour))
 	assert.Equal(t, 0, len(backoff.(*exponentialBackoff).backoffInfo))
 }
+
+func TestExportImportBackoffData(t *testing.T) {
+	backoff := NewIdBasedExponentialBackoff(1*time.Minute, 3*time.Minute, 3*time.Hour)
+	startTime := time.Now()
+	backoff.Backoff(nodeGroup1, nil, cloudprovider.OtherErrorClass, "", startTime)
+	backoff.Backoff(nodeGroup1, nil, cloudprovider.OtherErrorClass, "", startTime.Add(2*time.Minute))
+	data := backoff.ExportBackoffData()
+	assert.Equal(t, map[string]api.BackoffData{"id1": {
+		Duration:            2 * time.Minute,
+		BackoffUntil:        startTime.Add(4 * time.Minute),
+		LastFailedExecution: startTime.Add(2 * time.Minute),
+	}}, data)
+
+	imported := NewIdBasedExponentialBackoff(1*time.Minute, 3*time.Minute, 3*time.Hour)
+	imported.Backoff(nodeGroup2, nil, cloudprovider.OtherErrorClass, "", startTime)
+	imported.ImportBackoffData(data)
+	assert.True(t, imported.IsBackedOff(nodeGroup1, nil, startTime.Add(3*time.Minute)))
+	assert.False(t, imported.IsBackedOff(nodeGroup2, nil, startTime))
+	// The backoff duration keeps increasing from the imported one.
+	imported.Backoff(nodeGroup1, nil, cloudprovider.OtherErrorClass, "", startTime.Add(5*time.Minute))
+	assert.True(t, imported.IsBackedOff(nodeGroup1, nil, startTime.Add(7*time.Minute)))
+}
@@ -21,9 +21,11 @@ import (
 	"math/rand"
 
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	scheduler_util "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
 
@@ -46,3 +48,102 @@ func newPod(ds *appsv1.DaemonSet, nodeName string) *apiv1.Pod {
 	newPod.Spec.NodeName = nodeName
 	return newPod
 }
+
+// GetRolloutReservationPods returns placeholder pods reserving, on the nodes of DaemonSets being rolled
+// out, the room their updated pods need on top of the pods they replace, so that the pods of scaled-down
+// nodes aren't rescheduled into it. A DaemonSet is being rolled out while it's been created or updated
+// but doesn't run its updated pods on all the nodes it should yet. Placeholder pods are owned by their
+// DaemonSet, so they are never evicted. Nodes the updated pod doesn't fit on get no placeholder.
+func GetRolloutReservationPods(daemonsets []*appsv1.DaemonSet, pods []*apiv1.Pod, nodes []*apiv1.Node, predicateChecker *simulator.PredicateChecker) []*apiv1.Pod {
+	var result []*apiv1.Pod
+	var nodeInfos map[string]*schedulernodeinfo.NodeInfo
+	for _, ds := range daemonsets {
+		if ds.Status.ObservedGeneration >= ds.Generation && ds.Status.UpdatedNumberScheduled >= ds.Status.DesiredNumberScheduled {
+			continue
+		}
+		if nodeInfos == nil {
+			nodeInfos = scheduler_util.CreateNodeNameToInfoMap(pods, nodes)
+		}
+		for _, node := range nodes {
+			nodeInfo, found := nodeInfos[node.Name]
+			if !found {
+				continue
+			}
+			nodeInfo = nodeInfo.Clone()
+			var current *apiv1.Pod
+			for _, pod := range nodeInfo.Pods() {
+				if ref := metav1.GetControllerOf(pod); ref != nil && ref.UID == ds.UID {
+					current = pod
+					break
+				}
+			}
+
+			updated := newPod(ds, node.Name)
+			missing := podRequests(updated)
+			if current != nil {
+				for name, quantity := range podRequests(current) {
+					if request, found := missing[name]; found {
+						request.Sub(quantity)
+						missing[name] = request
+					}
+				}
+				if err := nodeInfo.RemovePod(current); err != nil {
+					continue
+				}
+			}
+			for name, quantity := range missing {
+				if quantity.Sign() <= 0 {
+					delete(missing, name)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			if err := predicateChecker.CheckPredicates(updated, nil, nodeInfo); err != nil {
+				continue
+			}
+			result = append(result, newRolloutReservationPod(ds, node.Name, missing))
+		}
+	}
+	return result
+}
+
+func newRolloutReservationPod(ds *appsv1.DaemonSet, nodeName string, requests apiv1.ResourceList) *apiv1.Pod {
+	controller := true
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-rollout-reservation-%s", ds.Name, nodeName),
+			Namespace: ds.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "DaemonSet",
+				Name:       ds.Name,
+				UID:        ds.UID,
+				Controller: &controller,
+			}},
+		},
+		Spec: apiv1.PodSpec{
+			NodeName: nodeName,
+			Containers: []apiv1.Container{{
+				Name:      "reservation",
+				Resources: apiv1.ResourceRequirements{Requests: requests},
+			}},
+		},
+	}
+}
+
+func podRequests(pod *apiv1.Pod) apiv1.ResourceList {
+	requests := apiv1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			sum, found := requests[name]
+			if !found {
+				requests[name] = quantity.DeepCopy()
+				continue
+			}
+			sum.Add(quantity)
+			requests[name] = sum
+		}
+	}
+	return requests
+}
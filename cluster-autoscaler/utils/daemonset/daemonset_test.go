@@ -17,6 +17,7 @@ limitations under the License.
 package daemonset
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -26,7 +27,9 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 
 	"github.com/stretchr/testify/assert"
@@ -52,6 +55,62 @@ func TestGetDaemonSetPodsForNode(t *testing.T) {
 	assert.Equal(t, 0, len(GetDaemonSetPodsForNode(nodeInfo, []*appsv1.DaemonSet{}, predicateChecker)))
 }
 
+func TestGetRolloutReservationPods(t *testing.T) {
+	var nodes []*apiv1.Node
+	for _, name := range []string{"n1", "n2", "n3", "n4"} {
+		node := BuildTestNode(name, 1000, 1000)
+		SetNodeReadyState(node, true, time.Now())
+		nodes = append(nodes, node)
+	}
+
+	rollingOut := newDaemonSet("rolling-out")
+	rollingOut.UID = types.UID("rolling-out")
+	rollingOut.Generation = 2
+	rollingOut.Status = appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 4, UpdatedNumberScheduled: 1}
+	rollingOut.Spec.Template.Spec.Containers[0].Resources.Requests = apiv1.ResourceList{
+		apiv1.ResourceCPU: *resource.NewMilliQuantity(300, resource.DecimalSI),
+	}
+	rolledOut := newDaemonSet("rolled-out")
+	rolledOut.UID = types.UID("rolled-out")
+	rolledOut.Generation = 1
+	rolledOut.Status = appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 4, UpdatedNumberScheduled: 4}
+	rolledOut.Spec.Template.Spec.Containers[0].Resources.Requests = rollingOut.Spec.Template.Spec.Containers[0].Resources.Requests
+
+	daemonSetPod := func(ds *appsv1.DaemonSet, node string, cpu int64) *apiv1.Pod {
+		pod := BuildTestPod(fmt.Sprintf("%s-%s", ds.Name, node), cpu, 0)
+		pod.UID = types.UID(pod.Name)
+		pod.OwnerReferences = GenerateOwnerReferences(ds.Name, "DaemonSet", "apps/v1", ds.UID)
+		pod.Spec.NodeName = node
+		return pod
+	}
+	busy := BuildTestPod("busy", 800, 0)
+	busy.UID = "busy"
+	busy.Spec.NodeName = "n3"
+	pods := []*apiv1.Pod{
+		// The pod to be replaced needs 200m more.
+		daemonSetPod(rollingOut, "n1", 100),
+		// Already updated.
+		daemonSetPod(rollingOut, "n2", 300),
+		// The updated pod doesn't fit next to the busy pod.
+		busy,
+		daemonSetPod(rolledOut, "n4", 300),
+	}
+
+	reservations := GetRolloutReservationPods([]*appsv1.DaemonSet{rollingOut, rolledOut}, pods, nodes, simulator.NewTestPredicateChecker())
+	assert.Equal(t, 2, len(reservations))
+	expected := map[string]int64{"n1": 200, "n4": 300}
+	for _, pod := range reservations {
+		cpu := pod.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU]
+		assert.Equal(t, expected[pod.Spec.NodeName], cpu.MilliValue(), pod.Spec.NodeName)
+		ref := metav1.GetControllerOf(pod)
+		assert.NotNil(t, ref)
+		assert.Equal(t, "DaemonSet", ref.Kind)
+		assert.Equal(t, rollingOut.UID, ref.UID)
+	}
+
+	assert.Empty(t, GetRolloutReservationPods([]*appsv1.DaemonSet{rolledOut}, pods, nodes, simulator.NewTestPredicateChecker()))
+}
+
 func newDaemonSet(name string) *appsv1.DaemonSet {
 	return &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -19,11 +19,13 @@ package deletetaint
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	kube_client "k8s.io/client-go/kubernetes"
 	kube_record "k8s.io/client-go/tools/record"
 
@@ -35,6 +37,12 @@ const (
 	ToBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
 	// DeletionCandidateTaint is a taint used to mark unneeded node as preferably unschedulable.
 	DeletionCandidateTaint = "DeletionCandidateOfClusterAutoscaler"
+	// UninitializedTaint is a startup taint that CA can remove itself once the node becomes ready.
+	UninitializedTaint = "cluster-autoscaler.kubernetes.io/uninitialized"
+	// StartupTaintPrefix is the key prefix of taints that are expected on new nodes until they are
+	// initialized. They are ignored when simulating new nodes and nodes carrying them are treated as
+	// not ready yet.
+	StartupTaintPrefix = "startup-taint.cluster-autoscaler.kubernetes.io/"
 )
 
 // Mutable only in unit tests
@@ -50,6 +58,8 @@ func getKeyShortName(key string) string {
 		return "ToBeDeletedTaint"
 	case DeletionCandidateTaint:
 		return "DeletionCandidateTaint"
+	case UninitializedTaint:
+		return "UninitializedTaint"
 	default:
 		return key
 	}
@@ -231,6 +241,86 @@ func CleanAllDeletionCandidates(nodes []*apiv1.Node, client kube_client.Interfac
 	cleanAllTaints(nodes, client, recorder, DeletionCandidateTaint)
 }
 
+// CleanAllStaleToBeDeleted cleans ToBeDeleted taints added before the given time from given nodes.
+func CleanAllStaleToBeDeleted(nodes []*apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder, addedBefore time.Time) {
+	cleanAllTaints(getNodesWithTaintAddedBefore(nodes, ToBeDeletedTaint, addedBefore), client, recorder, ToBeDeletedTaint)
+}
+
+// CleanAllStaleDeletionCandidates cleans DeletionCandidate taints added before the given time from given nodes.
+func CleanAllStaleDeletionCandidates(nodes []*apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder, addedBefore time.Time) {
+	cleanAllTaints(getNodesWithTaintAddedBefore(nodes, DeletionCandidateTaint, addedBefore), client, recorder, DeletionCandidateTaint)
+}
+
+// CleanAllUninitialized cleans Uninitialized taints from given nodes.
+func CleanAllUninitialized(nodes []*apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder) {
+	cleanAllTaints(nodes, client, recorder, UninitializedTaint)
+}
+
+// IsStartupTaint returns true if the taint key denotes a taint that new nodes carry until they are initialized:
+// the uninitialized taint, the taints prefixed with StartupTaintPrefix and the given startup taints of the node group.
+func IsStartupTaint(taintKey string, startupTaints []string) bool {
+	if taintKey == UninitializedTaint || strings.HasPrefix(taintKey, StartupTaintPrefix) {
+		return true
+	}
+	for _, startupTaint := range startupTaints {
+		if taintKey == startupTaint {
+			return true
+		}
+	}
+	return false
+}
+
+// HasStartupTaint returns true if the node still carries any startup taint, the given ones of its node group included.
+func HasStartupTaint(node *apiv1.Node, startupTaints []string) bool {
+	for _, taint := range node.Spec.Taints {
+		if IsStartupTaint(taint.Key, startupTaints) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterOutNodesWithStartupTaints treats ready nodes that still carry startup taints as unready,
+// so that they are handled like any other node that is still booting up. startupTaints returns the
+// startup taints of the node group of a node.
+func FilterOutNodesWithStartupTaints(allNodes, readyNodes []*apiv1.Node, startupTaints func(*apiv1.Node) []string) ([]*apiv1.Node, []*apiv1.Node) {
+	newAllNodes := make([]*apiv1.Node, 0)
+	newReadyNodes := make([]*apiv1.Node, 0)
+	nodesWithStartupTaints := make(map[string]*apiv1.Node)
+	for _, node := range readyNodes {
+		if HasStartupTaint(node, startupTaints(node)) {
+			klog.V(3).Infof("Overriding status of node %v, which still has startup taints", node.Name)
+			nodesWithStartupTaints[node.Name] = kube_util.GetUnreadyNodeCopy(node)
+		} else {
+			newReadyNodes = append(newReadyNodes, node)
+		}
+	}
+	for _, node := range allNodes {
+		if newNode, found := nodesWithStartupTaints[node.Name]; found {
+			newAllNodes = append(newAllNodes, newNode)
+		} else {
+			newAllNodes = append(newAllNodes, node)
+		}
+	}
+	return newAllNodes, newReadyNodes
+}
+
+// getNodesWithTaintAddedBefore returns the nodes carrying the taint with a time before the given one.
+// Taints whose value isn't a valid time are considered stale as well.
+func getNodesWithTaintAddedBefore(nodes []*apiv1.Node, taintKey string, addedBefore time.Time) []*apiv1.Node {
+	var result []*apiv1.Node
+	for _, node := range nodes {
+		if !hasTaint(node, taintKey) {
+			continue
+		}
+		taintTime, err := getTaintTime(node, taintKey)
+		if err != nil || taintTime.Before(addedBefore) {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
 func cleanAllTaints(nodes []*apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder, taintKey string) {
 	for _, node := range nodes {
 		if !hasTaint(node, taintKey) {
@@ -183,6 +183,96 @@ func TestCleanAllDeletionCandidates(t *testing.T) {
 	assert.Equal(t, 0, len(getNode(t, fakeClient, "n2").Spec.Taints))
 }
 
+func TestCleanAllStaleTaints(t *testing.T) {
+	now := time.Now()
+	fresh := BuildTestNode("fresh", 1000, 10)
+	fresh.Spec.Taints = []apiv1.Taint{
+		{Key: ToBeDeletedTaint, Value: strconv.FormatInt(now.Unix()-60, 10)},
+		{Key: DeletionCandidateTaint, Value: strconv.FormatInt(now.Unix()-60, 10)},
+	}
+	stale := BuildTestNode("stale", 1000, 10)
+	stale.Spec.Taints = []apiv1.Taint{
+		{Key: ToBeDeletedTaint, Value: strconv.FormatInt(now.Unix()-3601, 10)},
+		{Key: DeletionCandidateTaint, Value: strconv.FormatInt(now.Unix()-3601, 10)},
+	}
+	invalid := BuildTestNode("invalid", 1000, 10)
+	invalid.Spec.Taints = []apiv1.Taint{{Key: ToBeDeletedTaint, Value: "invalid"}}
+
+	fakeClient := buildFakeClient(t, fresh, stale, invalid)
+	fakeRecorder := kube_util.CreateEventRecorder(fakeClient)
+
+	nodes := []*apiv1.Node{fresh, stale, invalid}
+	CleanAllStaleToBeDeleted(nodes, fakeClient, fakeRecorder, now.Add(-time.Hour))
+	assert.True(t, HasToBeDeletedTaint(getNode(t, fakeClient, "fresh")))
+	assert.False(t, HasToBeDeletedTaint(getNode(t, fakeClient, "stale")))
+	assert.False(t, HasToBeDeletedTaint(getNode(t, fakeClient, "invalid")))
+
+	CleanAllStaleDeletionCandidates(nodes, fakeClient, fakeRecorder, now.Add(-time.Hour))
+	assert.True(t, HasDeletionCandidateTaint(getNode(t, fakeClient, "fresh")))
+	assert.False(t, HasDeletionCandidateTaint(getNode(t, fakeClient, "stale")))
+}
+
+func TestCleanAllUninitialized(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 10)
+	n1.Spec.Taints = []apiv1.Taint{{Key: UninitializedTaint, Effect: apiv1.TaintEffectNoSchedule}}
+
+	fakeClient := buildFakeClient(t, n1)
+	fakeRecorder := kube_util.CreateEventRecorder(fakeClient)
+
+	CleanAllUninitialized([]*apiv1.Node{n1}, fakeClient, fakeRecorder)
+
+	assert.Equal(t, 0, len(getNode(t, fakeClient, "n1").Spec.Taints))
+}
+
+func TestIsStartupTaint(t *testing.T) {
+	assert.True(t, IsStartupTaint(UninitializedTaint, nil))
+	assert.True(t, IsStartupTaint(StartupTaintPrefix+"cni", nil))
+	assert.False(t, IsStartupTaint(ToBeDeletedTaint, nil))
+	assert.False(t, IsStartupTaint("dedicated", nil))
+	assert.True(t, IsStartupTaint("node.cilium.io/agent-not-ready", []string{"node.cilium.io/agent-not-ready"}))
+	assert.True(t, IsStartupTaint(StartupTaintPrefix+"cni", []string{"node.cilium.io/agent-not-ready"}))
+}
+
+func TestFilterOutNodesWithStartupTaints(t *testing.T) {
+	start := time.Now()
+	initialized := BuildTestNode("initialized", 1000, 1000)
+	SetNodeReadyState(initialized, true, start)
+	tainted := BuildTestNode("tainted", 1000, 1000)
+	SetNodeReadyState(tainted, true, start)
+	tainted.Spec.Taints = []apiv1.Taint{{Key: StartupTaintPrefix + "cni", Effect: apiv1.TaintEffectNoSchedule}}
+	unready := BuildTestNode("unready", 1000, 1000)
+	SetNodeReadyState(unready, false, start)
+	// Only a startup taint of the node group of this node.
+	cilium := BuildTestNode("cilium", 1000, 1000)
+	SetNodeReadyState(cilium, true, start)
+	cilium.Spec.Taints = []apiv1.Taint{{Key: "node.cilium.io/agent-not-ready", Effect: apiv1.TaintEffectNoSchedule}}
+	startupTaints := func(node *apiv1.Node) []string {
+		if node.Name == cilium.Name {
+			return []string{"node.cilium.io/agent-not-ready"}
+		}
+		return nil
+	}
+
+	allNodes, readyNodes := FilterOutNodesWithStartupTaints(
+		[]*apiv1.Node{initialized, tainted, unready, cilium}, []*apiv1.Node{initialized, tainted, cilium}, startupTaints)
+
+	assert.Equal(t, []*apiv1.Node{initialized}, readyNodes)
+	assert.Equal(t, 4, len(allNodes))
+	assert.Equal(t, initialized, allNodes[0])
+	assert.Equal(t, unready, allNodes[2])
+	for _, node := range []*apiv1.Node{allNodes[1], allNodes[3]} {
+		ready, _, err := kube_util.GetReadinessState(node)
+		assert.NoError(t, err)
+		assert.False(t, ready)
+	}
+
+	// The same taint isn't a startup taint of the other node groups.
+	allNodes, readyNodes = FilterOutNodesWithStartupTaints([]*apiv1.Node{cilium}, []*apiv1.Node{cilium},
+		func(*apiv1.Node) []string { return nil })
+	assert.Equal(t, []*apiv1.Node{cilium}, readyNodes)
+	assert.Equal(t, []*apiv1.Node{cilium}, allNodes)
+}
+
 func setConflictRetryInterval(interval time.Duration) time.Duration {
 	before := conflictRetryInterval
 	conflictRetryInterval = interval
@@ -21,10 +21,9 @@ import (
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
-	policyv1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/kubernetes/pkg/kubelet/types"
 )
 
@@ -43,7 +42,7 @@ const (
 // about possibly problematic pods (unreplicated and daemonsets).
 func GetPodsForDeletionOnNodeDrain(
 	podList []*apiv1.Pod,
-	pdbs []*policyv1.PodDisruptionBudget,
+	pdbs *pdb.Cache,
 	deleteAll bool,
 	skipNodesWithSystemPods bool,
 	skipNodesWithLocalStorage bool,
@@ -53,14 +52,6 @@ func GetPodsForDeletionOnNodeDrain(
 	currentTime time.Time) ([]*apiv1.Pod, error) {
 
 	pods := []*apiv1.Pod{}
-	// filter kube-system PDBs to avoid doing it for every kube-system pod
-	kubeSystemPDBs := make([]*policyv1.PodDisruptionBudget, 0)
-	for _, pdb := range pdbs {
-		if pdb.Namespace == "kube-system" {
-			kubeSystemPDBs = append(kubeSystemPDBs, pdb)
-		}
-	}
-
 	for _, pod := range podList {
 		if IsMirrorPod(pod) {
 			continue
@@ -185,7 +176,7 @@ func GetPodsForDeletionOnNodeDrain(
 				return []*apiv1.Pod{}, fmt.Errorf("%s/%s is not replicated", pod.Namespace, pod.Name)
 			}
 			if pod.Namespace == "kube-system" && skipNodesWithSystemPods {
-				hasPDB, err := checkKubeSystemPDBs(pod, kubeSystemPDBs)
+				hasPDB, err := checkKubeSystemPDBs(pod, pdbs)
 				if err != nil {
 					return []*apiv1.Pod{}, fmt.Errorf("error matching pods to pdbs: %v", err)
 				}
@@ -246,18 +237,12 @@ func isLocalVolume(volume *apiv1.Volume) bool {
 
 // This only checks if a matching PDB exist and therefore if it makes sense to attempt drain simulation,
 // as we check for allowed-disruptions later anyway (for all pods with PDB, not just in kube-system)
-func checkKubeSystemPDBs(pod *apiv1.Pod, pdbs []*policyv1.PodDisruptionBudget) (bool, error) {
-	for _, pdb := range pdbs {
-		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
-		if err != nil {
-			return false, err
-		}
-		if selector.Matches(labels.Set(pod.Labels)) {
-			return true, nil
-		}
+func checkKubeSystemPDBs(pod *apiv1.Pod, pdbs *pdb.Cache) (bool, error) {
+	matching, err := pdbs.Matching(pod)
+	if err != nil {
+		return false, err
 	}
-
-	return false, nil
+	return len(matching) > 0, nil
 }
 
 // This checks if pod has PodSafeToEvictKey annotation
@@ -26,6 +26,7 @@ import (
 	policyv1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	v1appslister "k8s.io/client-go/listers/apps/v1"
 	v1lister "k8s.io/client-go/listers/core/v1"
@@ -538,9 +539,9 @@ func TestDrain(t *testing.T) {
 		ssLister, err := kube_util.NewTestStatefulSetLister([]*appsv1.StatefulSet{&statefulset})
 		assert.NoError(t, err)
 
-		registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, dsLister, rcLister, jobLister, rsLister, ssLister)
+		registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, dsLister, rcLister, jobLister, rsLister, ssLister, nil, nil)
 
-		pods, err := GetPodsForDeletionOnNodeDrain(test.pods, test.pdbs,
+		pods, err := GetPodsForDeletionOnNodeDrain(test.pods, pdb.NewCache(test.pdbs),
 			false, true, true, true, registry, 0, time.Now())
 
 		if test.expectFatal {
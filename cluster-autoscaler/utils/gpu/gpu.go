@@ -20,6 +20,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 
 	"k8s.io/klog"
 )
@@ -50,8 +51,8 @@ const (
 )
 
 var (
-	// knownGpuTypes lists all known GPU types, to be used in metrics; map for convenient access
-	// TODO(kgolab) obtain this from Cloud Provider
+	// knownGpuTypes lists all known GKE GPU types, to be used in metrics of cloud providers which
+	// don't list theirs; map for convenient access
 	knownGpuTypes = map[string]struct{}{
 		"nvidia-tesla-k80":  {},
 		"nvidia-tesla-p100": {},
@@ -59,16 +60,35 @@ var (
 	}
 )
 
+// GetGpuLabel returns the label of the nodes of the given cloud provider with GPUs,
+// GPULabel unless the cloud provider implements cloudprovider.GpuCloudProvider.
+func GetGpuLabel(cloudProvider cloudprovider.CloudProvider) string {
+	if gpuCloudProvider, ok := cloudProvider.(cloudprovider.GpuCloudProvider); ok {
+		return gpuCloudProvider.GPULabel()
+	}
+	return GPULabel
+}
+
+// GetAvailableGpuTypes returns the GPU types the nodes of the given cloud provider
+// can have, the GKE ones unless the cloud provider implements
+// cloudprovider.GpuCloudProvider.
+func GetAvailableGpuTypes(cloudProvider cloudprovider.CloudProvider) map[string]struct{} {
+	if gpuCloudProvider, ok := cloudProvider.(cloudprovider.GpuCloudProvider); ok {
+		return gpuCloudProvider.GetAvailableGPUTypes()
+	}
+	return knownGpuTypes
+}
+
 // FilterOutNodesWithUnreadyGpus removes nodes that should have GPU, but don't have it in allocatable
 // from ready nodes list and updates their status to unready on all nodes list.
 // This is a hack/workaround for nodes with GPU coming up without installed drivers, resulting
 // in GPU missing from their allocatable and capacity.
-func FilterOutNodesWithUnreadyGpus(allNodes, readyNodes []*apiv1.Node) ([]*apiv1.Node, []*apiv1.Node) {
+func FilterOutNodesWithUnreadyGpus(gpuLabel string, allNodes, readyNodes []*apiv1.Node) ([]*apiv1.Node, []*apiv1.Node) {
 	newAllNodes := make([]*apiv1.Node, 0)
 	newReadyNodes := make([]*apiv1.Node, 0)
 	nodesWithUnreadyGpu := make(map[string]*apiv1.Node)
 	for _, node := range readyNodes {
-		_, hasGpuLabel := node.Labels[GPULabel]
+		_, hasGpuLabel := node.Labels[gpuLabel]
 		gpuAllocatable, hasGpuAllocatable := node.Status.Allocatable[ResourceNvidiaGPU]
 		// We expect node to have GPU based on label, but it doesn't show up
 		// on node object. Assume the node is still not fully started (installing
@@ -76,7 +96,7 @@ func FilterOutNodesWithUnreadyGpus(allNodes, readyNodes []*apiv1.Node) ([]*apiv1
 		if hasGpuLabel && (!hasGpuAllocatable || gpuAllocatable.IsZero()) {
 			klog.V(3).Infof("Overriding status of node %v, which seems to have unready GPU",
 				node.Name)
-			nodesWithUnreadyGpu[node.Name] = getUnreadyNodeCopy(node)
+			nodesWithUnreadyGpu[node.Name] = kube_util.GetUnreadyNodeCopy(node)
 		} else {
 			newReadyNodes = append(newReadyNodes, node)
 		}
@@ -94,10 +114,10 @@ func FilterOutNodesWithUnreadyGpus(allNodes, readyNodes []*apiv1.Node) ([]*apiv1
 
 // GetGpuTypeForMetrics returns name of the GPU used on the node or empty string if there's no GPU
 // if the GPU type is unknown, "generic" is returned
-// NOTE: current implementation is GKE/GCE-specific
-func GetGpuTypeForMetrics(node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) string {
-	// we use the GKE label if there is one
-	gpuType, labelFound := node.Labels[GPULabel]
+// The GPU type is given by the gpuLabel of the node and has to be one of availableGPUTypes.
+func GetGpuTypeForMetrics(gpuLabel string, availableGPUTypes map[string]struct{}, node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) string {
+	// we use the GPU label if there is one
+	gpuType, labelFound := node.Labels[gpuLabel]
 	capacity, capacityFound := node.Status.Capacity[ResourceNvidiaGPU]
 
 	if !labelFound {
@@ -110,12 +130,12 @@ func GetGpuTypeForMetrics(node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) s
 		return MetricsNoGPU
 	}
 
-	// GKE-specific label & capacity are present - consistent state
+	// GPU label & capacity are present - consistent state
 	if capacityFound {
-		return validateGpuType(gpuType)
+		return validateGpuType(availableGPUTypes, gpuType)
 	}
 
-	// GKE-specific label present but no capacity (yet?) - check the node template
+	// GPU label present but no capacity (yet?) - check the node template
 	if nodeGroup != nil {
 		template, err := nodeGroup.TemplateNodeInfo()
 		if err != nil {
@@ -135,30 +155,13 @@ func GetGpuTypeForMetrics(node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) s
 	return MetricsUnexpectedLabelGPU
 }
 
-func validateGpuType(gpu string) string {
-	if _, found := knownGpuTypes[gpu]; found {
+func validateGpuType(availableGPUTypes map[string]struct{}, gpu string) string {
+	if _, found := availableGPUTypes[gpu]; found {
 		return gpu
 	}
 	return MetricsUnknownGPU
 }
 
-func getUnreadyNodeCopy(node *apiv1.Node) *apiv1.Node {
-	newNode := node.DeepCopy()
-	newReadyCondition := apiv1.NodeCondition{
-		Type:               apiv1.NodeReady,
-		Status:             apiv1.ConditionFalse,
-		LastTransitionTime: node.CreationTimestamp,
-	}
-	newNodeConditions := []apiv1.NodeCondition{newReadyCondition}
-	for _, condition := range newNode.Status.Conditions {
-		if condition.Type != apiv1.NodeReady {
-			newNodeConditions = append(newNodeConditions, condition)
-		}
-	}
-	newNode.Status.Conditions = newNodeConditions
-	return newNode
-}
-
 // NodeHasGpu returns true if a given node has GPU hardware.
 // The result will be true if there is hardware capability. It doesn't matter
 // if the drivers are installed and GPU is ready to use.
@@ -183,15 +186,16 @@ func PodRequestsGpu(pod *apiv1.Pod) bool {
 
 // GetNodeTargetGpus returns the number of gpus on a given node. This includes gpus which are not yet
 // ready to use and visible in kubernetes.
-func GetNodeTargetGpus(node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) (gpuType string, gpuCount int64, error errors.AutoscalerError) {
-	gpuLabel, found := node.Labels[GPULabel]
+// The GPU type and the expectation of GPUs are given by the gpuLabel of the node.
+func GetNodeTargetGpus(gpuLabel string, node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) (gpuType string, gpuCount int64, error errors.AutoscalerError) {
+	gpuType, found := node.Labels[gpuLabel]
 	if !found {
 		return "", 0, nil
 	}
 
 	gpuAllocatable, found := node.Status.Allocatable[ResourceNvidiaGPU]
 	if found && gpuAllocatable.Value() > 0 {
-		return gpuLabel, gpuAllocatable.Value(), nil
+		return gpuType, gpuAllocatable.Value(), nil
 	}
 
 	// A node is supposed to have GPUs (based on label), but they're not available yet
@@ -217,7 +221,7 @@ func GetNodeTargetGpus(node *apiv1.Node, nodeGroup cloudprovider.NodeGroup) (gpu
 		return "", 0, errors.ToAutoscalerError(errors.CloudProviderError, err)
 	}
 	if gpuCapacity, found := template.Node().Status.Capacity[ResourceNvidiaGPU]; found {
-		return gpuLabel, gpuCapacity.Value(), nil
+		return gpuType, gpuCapacity.Value(), nil
 	}
 
 	// if template does not define gpus we assume node will not have any even if ith has gpu label
@@ -24,6 +24,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/test"
 
 	"github.com/stretchr/testify/assert"
@@ -129,7 +130,7 @@ func TestFilterOutNodesWithUnreadyGpus(t *testing.T) {
 		nodeNoGpuUnready,
 	}
 
-	newAllNodes, newReadyNodes := FilterOutNodesWithUnreadyGpus(initialAllNodes, initialReadyNodes)
+	newAllNodes, newReadyNodes := FilterOutNodesWithUnreadyGpus(GPULabel, initialAllNodes, initialReadyNodes)
 
 	foundInReady := make(map[string]bool)
 	for _, node := range newReadyNodes {
@@ -151,6 +152,56 @@ func TestFilterOutNodesWithUnreadyGpus(t *testing.T) {
 	}
 }
 
+type gpuCloudProvider struct {
+	cloudprovider.CloudProvider
+}
+
+func (gpuCloudProvider) GPULabel() string {
+	return "example.com/gpu"
+}
+
+func (gpuCloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	return map[string]struct{}{"nvidia-tesla-t4": {}}
+}
+
+func TestGetGpuLabel(t *testing.T) {
+	var provider cloudprovider.CloudProvider
+	assert.Equal(t, GPULabel, GetGpuLabel(provider))
+	assert.Equal(t, knownGpuTypes, GetAvailableGpuTypes(provider))
+
+	provider = gpuCloudProvider{}
+	assert.Equal(t, "example.com/gpu", GetGpuLabel(provider))
+	assert.Equal(t, map[string]struct{}{"nvidia-tesla-t4": {}}, GetAvailableGpuTypes(provider))
+}
+
+func TestGetNodeTargetGpusWithProviderLabel(t *testing.T) {
+	provider := gpuCloudProvider{}
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node",
+			Labels: map[string]string{provider.GPULabel(): "nvidia-tesla-t4"},
+		},
+		Status: apiv1.NodeStatus{
+			Capacity:    apiv1.ResourceList{ResourceNvidiaGPU: *resource.NewQuantity(2, resource.DecimalSI)},
+			Allocatable: apiv1.ResourceList{ResourceNvidiaGPU: *resource.NewQuantity(2, resource.DecimalSI)},
+		},
+	}
+
+	gpuType, gpuCount, err := GetNodeTargetGpus(provider.GPULabel(), node, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "nvidia-tesla-t4", gpuType)
+	assert.Equal(t, int64(2), gpuCount)
+
+	gpuType, gpuCount, err = GetNodeTargetGpus(GPULabel, node, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", gpuType)
+	assert.Equal(t, int64(0), gpuCount)
+
+	assert.Equal(t, "nvidia-tesla-t4", GetGpuTypeForMetrics(provider.GPULabel(), provider.GetAvailableGPUTypes(), node, nil))
+	assert.Equal(t, MetricsUnknownGPU, GetGpuTypeForMetrics(provider.GPULabel(), knownGpuTypes, node, nil))
+	assert.Equal(t, MetricsGenericGPU, GetGpuTypeForMetrics(GPULabel, knownGpuTypes, node, nil))
+}
+
 func TestNodeHasGpu(t *testing.T) {
 	gpuLabels := map[string]string{
 		GPULabel: "nvidia-tesla-k80",
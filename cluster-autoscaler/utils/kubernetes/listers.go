@@ -46,6 +46,8 @@ type ListerRegistry interface {
 	JobLister() v1batchlister.JobLister
 	ReplicaSetLister() v1appslister.ReplicaSetLister
 	StatefulSetLister() v1appslister.StatefulSetLister
+	PersistentVolumeLister() v1lister.PersistentVolumeLister
+	PersistentVolumeClaimLister() v1lister.PersistentVolumeClaimLister
 }
 
 type listerRegistryImpl struct {
@@ -59,6 +61,8 @@ type listerRegistryImpl struct {
 	jobLister                   v1batchlister.JobLister
 	replicaSetLister            v1appslister.ReplicaSetLister
 	statefulSetLister           v1appslister.StatefulSetLister
+	persistentVolumeLister      v1lister.PersistentVolumeLister
+	persistentVolumeClaimLister v1lister.PersistentVolumeClaimLister
 }
 
 // NewListerRegistry returns a registry providing various listers to list pods or nodes matching conditions
@@ -66,7 +70,8 @@ func NewListerRegistry(allNode NodeLister, readyNode NodeLister, scheduledPod Po
 	unschedulablePod PodLister, podDisruptionBudgetLister PodDisruptionBudgetLister,
 	daemonSetLister v1appslister.DaemonSetLister, replicationControllerLister v1lister.ReplicationControllerLister,
 	jobLister v1batchlister.JobLister, replicaSetLister v1appslister.ReplicaSetLister,
-	statefulSetLister v1appslister.StatefulSetLister) ListerRegistry {
+	statefulSetLister v1appslister.StatefulSetLister, persistentVolumeLister v1lister.PersistentVolumeLister,
+	persistentVolumeClaimLister v1lister.PersistentVolumeClaimLister) ListerRegistry {
 	return listerRegistryImpl{
 		allNodeLister:               allNode,
 		readyNodeLister:             readyNode,
@@ -78,6 +83,8 @@ func NewListerRegistry(allNode NodeLister, readyNode NodeLister, scheduledPod Po
 		jobLister:                   jobLister,
 		replicaSetLister:            replicaSetLister,
 		statefulSetLister:           statefulSetLister,
+		persistentVolumeLister:      persistentVolumeLister,
+		persistentVolumeClaimLister: persistentVolumeClaimLister,
 	}
 }
 
@@ -93,9 +100,12 @@ func NewListerRegistryWithDefaultListers(kubeClient client.Interface, stopChanne
 	jobLister := NewJobLister(kubeClient, stopChannel)
 	replicaSetLister := NewReplicaSetLister(kubeClient, stopChannel)
 	statefulSetLister := NewStatefulSetLister(kubeClient, stopChannel)
+	persistentVolumeLister := NewPersistentVolumeLister(kubeClient, stopChannel)
+	persistentVolumeClaimLister := NewPersistentVolumeClaimLister(kubeClient, stopChannel)
 	return NewListerRegistry(allNodeLister, readyNodeLister, scheduledPodLister,
 		unschedulablePodLister, podDisruptionBudgetLister, daemonSetLister,
-		replicationControllerLister, jobLister, replicaSetLister, statefulSetLister)
+		replicationControllerLister, jobLister, replicaSetLister, statefulSetLister,
+		persistentVolumeLister, persistentVolumeClaimLister)
 }
 
 // AllNodeLister returns the AllNodeLister registered to this registry
@@ -148,6 +158,16 @@ func (r listerRegistryImpl) StatefulSetLister() v1appslister.StatefulSetLister {
 	return r.statefulSetLister
 }
 
+// PersistentVolumeLister returns the persistentVolumeLister registered to this registry
+func (r listerRegistryImpl) PersistentVolumeLister() v1lister.PersistentVolumeLister {
+	return r.persistentVolumeLister
+}
+
+// PersistentVolumeClaimLister returns the persistentVolumeClaimLister registered to this registry
+func (r listerRegistryImpl) PersistentVolumeClaimLister() v1lister.PersistentVolumeClaimLister {
+	return r.persistentVolumeClaimLister
+}
+
 // PodLister lists pods.
 type PodLister interface {
 	List() ([]*apiv1.Pod, error)
@@ -185,7 +205,7 @@ func NewUnschedulablePodInNamespaceLister(kubeClient client.Interface, namespace
 	selector := fields.ParseSelectorOrDie("spec.nodeName==" + "" + ",status.phase!=" +
 		string(apiv1.PodSucceeded) + ",status.phase!=" + string(apiv1.PodFailed))
 	podListWatch := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "pods", namespace, selector)
-	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	store := NewTransformingIndexer(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}), TrimPod)
 	podLister := v1lister.NewPodLister(store)
 	podReflector := cache.NewReflector(podListWatch, &apiv1.Pod{}, store, time.Hour)
 	go podReflector.Run(stopchannel)
@@ -210,7 +230,7 @@ func NewScheduledPodLister(kubeClient client.Interface, stopchannel <-chan struc
 	selector := fields.ParseSelectorOrDie("spec.nodeName!=" + "" + ",status.phase!=" +
 		string(apiv1.PodSucceeded) + ",status.phase!=" + string(apiv1.PodFailed))
 	podListWatch := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "pods", apiv1.NamespaceAll, selector)
-	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	store := NewTransformingIndexer(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}), TrimPod)
 	podLister := v1lister.NewPodLister(store)
 	podReflector := cache.NewReflector(podListWatch, &apiv1.Pod{}, store, time.Hour)
 	go podReflector.Run(stopchannel)
@@ -360,3 +380,43 @@ func NewStatefulSetLister(kubeClient client.Interface, stopchannel <-chan struct
 	go reflector.Run(stopchannel)
 	return lister
 }
+
+// NewPersistentVolumeLister builds a persistentvolume lister.
+func NewPersistentVolumeLister(kubeClient client.Interface, stopchannel <-chan struct{}) v1lister.PersistentVolumeLister {
+	listWatcher := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "persistentvolumes", apiv1.NamespaceAll, fields.Everything())
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	lister := v1lister.NewPersistentVolumeLister(store)
+	reflector := cache.NewReflector(listWatcher, &apiv1.PersistentVolume{}, store, time.Hour)
+	go reflector.Run(stopchannel)
+	return lister
+}
+
+// NewPersistentVolumeClaimLister builds a persistentvolumeclaim lister.
+func NewPersistentVolumeClaimLister(kubeClient client.Interface, stopchannel <-chan struct{}) v1lister.PersistentVolumeClaimLister {
+	listWatcher := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "persistentvolumeclaims", apiv1.NamespaceAll, fields.Everything())
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := v1lister.NewPersistentVolumeClaimLister(store)
+	reflector := cache.NewReflector(listWatcher, &apiv1.PersistentVolumeClaim{}, store, time.Hour)
+	go reflector.Run(stopchannel)
+	return lister
+}
+
+// NewLimitRangeLister builds a limitrange lister.
+func NewLimitRangeLister(kubeClient client.Interface, stopchannel <-chan struct{}) v1lister.LimitRangeLister {
+	listWatcher := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "limitranges", apiv1.NamespaceAll, fields.Everything())
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := v1lister.NewLimitRangeLister(store)
+	reflector := cache.NewReflector(listWatcher, &apiv1.LimitRange{}, store, time.Hour)
+	go reflector.Run(stopchannel)
+	return lister
+}
+
+// NewConfigMapLister builds a lister of the configmaps of the given namespace.
+func NewConfigMapLister(kubeClient client.Interface, namespace string, stopchannel <-chan struct{}) v1lister.ConfigMapNamespaceLister {
+	listWatcher := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "configmaps", namespace, fields.Everything())
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := v1lister.NewConfigMapLister(store).ConfigMaps(namespace)
+	reflector := cache.NewReflector(listWatcher, &apiv1.ConfigMap{}, store, time.Hour)
+	go reflector.Run(stopchannel)
+	return lister
+}
@@ -18,9 +18,11 @@ package kubernetes
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
 )
 
 // IsNodeReadyAndSchedulable returns true if the node is ready and schedulable.
@@ -72,3 +74,73 @@ func GetReadinessState(node *apiv1.Node) (isNodeReady bool, lastTransitionTime t
 	}
 	return canNodeBeReady, lastTransitionTime, nil
 }
+
+// GetUnreadyNodeCopy returns a copy of the node with its Ready condition overridden to false,
+// so that the rest of the code treats the node as still booting.
+func GetUnreadyNodeCopy(node *apiv1.Node) *apiv1.Node {
+	newNode := node.DeepCopy()
+	newReadyCondition := apiv1.NodeCondition{
+		Type:               apiv1.NodeReady,
+		Status:             apiv1.ConditionFalse,
+		LastTransitionTime: node.CreationTimestamp,
+	}
+	newNodeConditions := []apiv1.NodeCondition{newReadyCondition}
+	for _, condition := range newNode.Status.Conditions {
+		if condition.Type != apiv1.NodeReady {
+			newNodeConditions = append(newNodeConditions, condition)
+		}
+	}
+	newNode.Status.Conditions = newNodeConditions
+	return newNode
+}
+
+// PassesReadinessGates returns true if all the given conditions are True on the node and it has all
+// the given labels. A label is either a key the node has to carry or a key=value pair.
+func PassesReadinessGates(node *apiv1.Node, conditions []string, labels []string) bool {
+	for _, conditionType := range conditions {
+		satisfied := false
+		for _, cond := range node.Status.Conditions {
+			if string(cond.Type) == conditionType {
+				satisfied = cond.Status == apiv1.ConditionTrue
+				break
+			}
+		}
+		if !satisfied {
+			return false
+		}
+	}
+	for _, label := range labels {
+		parts := strings.SplitN(label, "=", 2)
+		value, found := node.Labels[parts[0]]
+		if !found || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterOutNodesFailingReadinessGates treats ready nodes that don't pass their readiness gates
+// as unready, so that they are handled like any other node that is still booting up. The readiness
+// gates of a node, i.e. the conditions and labels it has to pass, are returned by readinessGates.
+func FilterOutNodesFailingReadinessGates(allNodes, readyNodes []*apiv1.Node, readinessGates func(*apiv1.Node) ([]string, []string)) ([]*apiv1.Node, []*apiv1.Node) {
+	newAllNodes := make([]*apiv1.Node, 0)
+	newReadyNodes := make([]*apiv1.Node, 0)
+	nodesFailingGates := make(map[string]*apiv1.Node)
+	for _, node := range readyNodes {
+		conditions, labels := readinessGates(node)
+		if !PassesReadinessGates(node, conditions, labels) {
+			klog.V(3).Infof("Overriding status of node %v, which doesn't pass readiness gates yet", node.Name)
+			nodesFailingGates[node.Name] = GetUnreadyNodeCopy(node)
+		} else {
+			newReadyNodes = append(newReadyNodes, node)
+		}
+	}
+	for _, node := range allNodes {
+		if newNode, found := nodesFailingGates[node.Name]; found {
+			newAllNodes = append(newAllNodes, newNode)
+		} else {
+			newAllNodes = append(newAllNodes, node)
+		}
+	}
+	return newAllNodes, newReadyNodes
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestPassesReadinessGates(t *testing.T) {
+	node := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(node, true, time.Now())
+	node.Status.Conditions = append(node.Status.Conditions,
+		apiv1.NodeCondition{Type: "CNIReady", Status: apiv1.ConditionTrue},
+		apiv1.NodeCondition{Type: "GPUDriverReady", Status: apiv1.ConditionFalse})
+	node.Labels["csi-ready"] = "true"
+
+	assert.True(t, PassesReadinessGates(node, nil, nil))
+	assert.True(t, PassesReadinessGates(node, []string{"CNIReady"}, []string{"csi-ready", "csi-ready=true"}))
+	assert.False(t, PassesReadinessGates(node, []string{"GPUDriverReady"}, nil))
+	assert.False(t, PassesReadinessGates(node, []string{"Missing"}, nil))
+	assert.False(t, PassesReadinessGates(node, nil, []string{"csi-ready=false"}))
+	assert.False(t, PassesReadinessGates(node, nil, []string{"missing"}))
+}
+
+func TestFilterOutNodesFailingReadinessGates(t *testing.T) {
+	start := time.Now()
+	passing := BuildTestNode("passing", 1000, 1000)
+	SetNodeReadyState(passing, true, start)
+	passing.Labels["cni-ready"] = "true"
+	failing := BuildTestNode("failing", 1000, 1000)
+	SetNodeReadyState(failing, true, start)
+	unready := BuildTestNode("unready", 1000, 1000)
+	SetNodeReadyState(unready, false, start)
+
+	allNodes, readyNodes := FilterOutNodesFailingReadinessGates(
+		[]*apiv1.Node{passing, failing, unready}, []*apiv1.Node{passing, failing},
+		func(*apiv1.Node) ([]string, []string) { return nil, []string{"cni-ready"} })
+
+	assert.Equal(t, []*apiv1.Node{passing}, readyNodes)
+	assert.Equal(t, 3, len(allNodes))
+	assert.Equal(t, passing, allNodes[0])
+	assert.Equal(t, unready, allNodes[2])
+	ready, _, err := GetReadinessState(allNodes[1])
+	assert.NoError(t, err)
+	assert.False(t, ready)
+}
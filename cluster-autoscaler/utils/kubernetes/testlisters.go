@@ -102,3 +102,51 @@ func NewTestStatefulSetLister(sss []*appsv1.StatefulSet) (v1appslister.StatefulS
 	}
 	return v1appslister.NewStatefulSetLister(store), nil
 }
+
+// NewTestPersistentVolumeLister returns a lister that returns provided PersistentVolumes
+func NewTestPersistentVolumeLister(pvs []*apiv1.PersistentVolume) (v1lister.PersistentVolumeLister, error) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, pv := range pvs {
+		err := store.Add(pv)
+		if err != nil {
+			return nil, fmt.Errorf("Error adding object to cache: %v", err)
+		}
+	}
+	return v1lister.NewPersistentVolumeLister(store), nil
+}
+
+// NewTestPersistentVolumeClaimLister returns a lister that returns provided PersistentVolumeClaims
+func NewTestPersistentVolumeClaimLister(pvcs []*apiv1.PersistentVolumeClaim) (v1lister.PersistentVolumeClaimLister, error) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pvc := range pvcs {
+		err := store.Add(pvc)
+		if err != nil {
+			return nil, fmt.Errorf("Error adding object to cache: %v", err)
+		}
+	}
+	return v1lister.NewPersistentVolumeClaimLister(store), nil
+}
+
+// NewTestLimitRangeLister returns a lister that returns provided LimitRanges
+func NewTestLimitRangeLister(limitRanges []*apiv1.LimitRange) (v1lister.LimitRangeLister, error) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, limitRange := range limitRanges {
+		err := store.Add(limitRange)
+		if err != nil {
+			return nil, fmt.Errorf("Error adding object to cache: %v", err)
+		}
+	}
+	return v1lister.NewLimitRangeLister(store), nil
+}
+
+// NewTestConfigMapLister returns a lister that returns provided ConfigMaps
+func NewTestConfigMapLister(configMaps []*apiv1.ConfigMap) (v1lister.ConfigMapLister, error) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, configMap := range configMaps {
+		err := store.Add(configMap)
+		if err != nil {
+			return nil, fmt.Errorf("Error adding object to cache: %v", err)
+		}
+	}
+	return v1lister.NewConfigMapLister(store), nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// lastAppliedConfigAnnotation is the annotation kubectl apply stores the whole applied object in.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// TransformFunc modifies an object before it is stored in a cache.
+type TransformFunc func(obj interface{}) interface{}
+
+// transformingIndexer is an indexer applying a TransformFunc to the objects added to it, so that
+// the parts of the objects that are never read aren't kept in memory.
+type transformingIndexer struct {
+	cache.Indexer
+	transform TransformFunc
+}
+
+// NewTransformingIndexer returns an indexer storing the objects transformed by transform in indexer.
+func NewTransformingIndexer(indexer cache.Indexer, transform TransformFunc) cache.Indexer {
+	return &transformingIndexer{Indexer: indexer, transform: transform}
+}
+
+// Add transforms and adds obj.
+func (i *transformingIndexer) Add(obj interface{}) error {
+	return i.Indexer.Add(i.transform(obj))
+}
+
+// Update transforms and updates obj.
+func (i *transformingIndexer) Update(obj interface{}) error {
+	return i.Indexer.Update(i.transform(obj))
+}
+
+// Replace transforms list and replaces the content of the indexer with it.
+func (i *transformingIndexer) Replace(list []interface{}, resourceVersion string) error {
+	transformed := make([]interface{}, 0, len(list))
+	for _, obj := range list {
+		transformed = append(transformed, i.transform(obj))
+	}
+	return i.Indexer.Replace(transformed, resourceVersion)
+}
+
+// TrimPod drops the fields of pods that Cluster Autoscaler doesn't read: managed fields, the last
+// applied configuration and the details of container statuses but their state and its reason. The
+// pod is modified in place, it must be owned by the caller, e.g. freshly decoded by a reflector.
+func TrimPod(obj interface{}) interface{} {
+	pod, ok := obj.(*apiv1.Pod)
+	if !ok {
+		return obj
+	}
+	pod.ManagedFields = nil
+	delete(pod.Annotations, lastAppliedConfigAnnotation)
+	trimContainerStatuses(pod.Status.ContainerStatuses)
+	trimContainerStatuses(pod.Status.InitContainerStatuses)
+	return pod
+}
+
+// trimContainerStatuses keeps the name, readiness, restart count and state of the statuses, with
+// the reason of the state but no message.
+func trimContainerStatuses(statuses []apiv1.ContainerStatus) {
+	for i, status := range statuses {
+		trimmed := apiv1.ContainerStatus{
+			Name:         status.Name,
+			Ready:        status.Ready,
+			RestartCount: status.RestartCount,
+		}
+		if waiting := status.State.Waiting; waiting != nil {
+			trimmed.State.Waiting = &apiv1.ContainerStateWaiting{Reason: waiting.Reason}
+		}
+		if running := status.State.Running; running != nil {
+			trimmed.State.Running = &apiv1.ContainerStateRunning{StartedAt: running.StartedAt}
+		}
+		if terminated := status.State.Terminated; terminated != nil {
+			trimmed.State.Terminated = &apiv1.ContainerStateTerminated{ExitCode: terminated.ExitCode, Reason: terminated.Reason}
+		}
+		statuses[i] = trimmed
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildTrimmableTestPod(name string) *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Annotations: map[string]string{
+				lastAppliedConfigAnnotation: "{}",
+				"foo":                       "bar",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Spec: apiv1.PodSpec{NodeName: "n1"},
+		Status: apiv1.PodStatus{
+			Phase: apiv1.PodRunning,
+			ContainerStatuses: []apiv1.ContainerStatus{{
+				Name:    "c",
+				Image:   "image",
+				ImageID: "docker-pullable://image@sha256:0123",
+				State: apiv1.ContainerState{
+					Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating", Message: "pulling image"},
+				},
+			}},
+		},
+	}
+}
+
+func TestTransformingIndexer(t *testing.T) {
+	store := NewTransformingIndexer(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}), TrimPod)
+
+	assert.NoError(t, store.Add(buildTrimmableTestPod("p1")))
+	assert.NoError(t, store.Replace([]interface{}{buildTrimmableTestPod("p2")}, "1"))
+	assert.NoError(t, store.Update(buildTrimmableTestPod("p3")))
+
+	objs := store.List()
+	assert.Len(t, objs, 2)
+	for _, obj := range objs {
+		pod := obj.(*apiv1.Pod)
+		assert.Nil(t, pod.ManagedFields)
+		assert.Equal(t, []apiv1.ContainerStatus{{
+			Name:  "c",
+			State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+		}}, pod.Status.ContainerStatuses)
+		assert.Equal(t, map[string]string{"foo": "bar"}, pod.Annotations)
+		assert.Equal(t, "n1", pod.Spec.NodeName)
+		assert.Equal(t, apiv1.PodRunning, pod.Status.Phase)
+	}
+}
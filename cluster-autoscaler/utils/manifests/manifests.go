@@ -0,0 +1,174 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// Name is the name of all the generated objects.
+	Name = "cluster-autoscaler"
+)
+
+// Options describe the generated manifests.
+type Options struct {
+	// Namespace cluster-autoscaler runs in.
+	Namespace string
+	// Image of the cluster-autoscaler container.
+	Image string
+	// ClusterRules are the cluster-wide RBAC rules granted to
+	// cluster-autoscaler.
+	ClusterRules []rbacv1.PolicyRule
+	// NamespaceRules are the RBAC rules granted to cluster-autoscaler
+	// in its namespace.
+	NamespaceRules []rbacv1.PolicyRule
+	// Args of the cluster-autoscaler command.
+	Args []string
+}
+
+// Objects returns the ServiceAccount, RBAC, Deployment and
+// PodDisruptionBudget objects needed to run cluster-autoscaler with
+// the given options.
+func Objects(opts Options) []runtime.Object {
+	labels := map[string]string{"app": Name}
+	meta := metav1.ObjectMeta{Name: Name, Namespace: opts.Namespace, Labels: labels}
+	clusterMeta := metav1.ObjectMeta{Name: Name, Labels: labels}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: Name, Namespace: opts.Namespace}}
+	replicas := int32(1)
+	maxUnavailable := intstr.FromInt(1)
+
+	return []runtime.Object{
+		&apiv1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta,
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: clusterMeta,
+			Rules:      opts.ClusterRules,
+		},
+		&rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+			ObjectMeta: meta,
+			Rules:      opts.NamespaceRules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: clusterMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: Name},
+			Subjects:   subjects,
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+			ObjectMeta: meta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: Name},
+			Subjects:   subjects,
+		},
+		&appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: apiv1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: apiv1.PodSpec{
+						ServiceAccountName: Name,
+						Containers: []apiv1.Container{{
+							Name:    Name,
+							Image:   opts.Image,
+							Command: append([]string{"./cluster-autoscaler"}, opts.Args...),
+							Resources: apiv1.ResourceRequirements{
+								Limits: apiv1.ResourceList{
+									apiv1.ResourceCPU:    resource.MustParse("100m"),
+									apiv1.ResourceMemory: resource.MustParse("300Mi"),
+								},
+								Requests: apiv1.ResourceList{
+									apiv1.ResourceCPU:    resource.MustParse("100m"),
+									apiv1.ResourceMemory: resource.MustParse("300Mi"),
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+		&policyv1.PodDisruptionBudget{
+			TypeMeta:   metav1.TypeMeta{APIVersion: policyv1.SchemeGroupVersion.String(), Kind: "PodDisruptionBudget"},
+			ObjectMeta: meta,
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &maxUnavailable,
+				Selector:       &metav1.LabelSelector{MatchLabels: labels},
+			},
+		},
+	}
+}
+
+// Render writes the objects for the given options to w as a multi
+// document YAML stream.
+func Render(w io.Writer, opts Options) error {
+	for _, obj := range Objects(opts) {
+		data, err := marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshal returns obj as YAML, without its status and the empty
+// creation timestamps set by the API types.
+func marshal(obj runtime.Object) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "status")
+	removeCreationTimestamps(fields)
+	return yaml.Marshal(fields)
+}
+
+func removeCreationTimestamps(fields map[string]interface{}) {
+	for key, value := range fields {
+		if key == "creationTimestamp" && value == nil {
+			delete(fields, key)
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			removeCreationTimestamps(nested)
+		}
+	}
+}
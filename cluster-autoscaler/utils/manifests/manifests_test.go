@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestObjects(t *testing.T) {
+	clusterRules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list"}}}
+	namespaceRules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}}
+	opts := Options{
+		Namespace:      "openshift-machine-api",
+		Image:          "cluster-autoscaler:test",
+		ClusterRules:   clusterRules,
+		NamespaceRules: namespaceRules,
+		Args:           []string{"--cloud-provider=openshift-machine-api"},
+	}
+	objects := Objects(opts)
+	assert.Len(t, objects, 7)
+
+	clusterRole := objects[1].(*rbacv1.ClusterRole)
+	assert.Equal(t, clusterRules, clusterRole.Rules)
+
+	role := objects[2].(*rbacv1.Role)
+	assert.Equal(t, "openshift-machine-api", role.Namespace)
+	assert.Equal(t, namespaceRules, role.Rules)
+
+	clusterRoleBinding := objects[3].(*rbacv1.ClusterRoleBinding)
+	assert.Equal(t, Name, clusterRoleBinding.RoleRef.Name)
+	assert.Equal(t, "openshift-machine-api", clusterRoleBinding.Subjects[0].Namespace)
+
+	deployment := objects[5].(*appsv1.Deployment)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "cluster-autoscaler:test", container.Image)
+	assert.Equal(t, []string{"./cluster-autoscaler", "--cloud-provider=openshift-machine-api"}, container.Command)
+}
+
+func TestRender(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, Render(&out, Options{Namespace: "kube-system", Image: "cluster-autoscaler:test"}))
+
+	documents := strings.Split(out.String(), "---\n")[1:]
+	assert.Len(t, documents, 7)
+	assert.Contains(t, documents[0], "kind: ServiceAccount")
+	assert.Contains(t, documents[6], "kind: PodDisruptionBudget")
+	assert.NotContains(t, out.String(), "creationTimestamp")
+	assert.NotContains(t, out.String(), "status:")
+}
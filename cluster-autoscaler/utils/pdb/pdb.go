@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdb
+
+import (
+	"reflect"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/golang/groupcache/lru"
+)
+
+// Cache matches pods against PodDisruptionBudgets. The selector of each PDB is parsed once, and
+// the PDBs matching pods are cached by the namespace and labels of the pods, which usually are
+// the same for all the pods of a controller. The cached matches are kept until the PDBs change,
+// the least recently used ones being dropped beyond maxCachedMatches. A nil Cache holds no PDBs.
+type Cache struct {
+	sync.Mutex
+	budgets     []*budget
+	byName      map[string]*budget
+	byNamespace map[string][]*budget
+	matches     *lru.Cache
+}
+
+// maxCachedMatches is the maximum number of namespace and labels pairs whose matching PDBs are
+// cached, so that the cache doesn't grow with the pods of all the controllers ever seen.
+const maxCachedMatches = 10000
+
+// budget is a PDB of a Cache with its parsed selector.
+type budget struct {
+	pdb      *policyv1.PodDisruptionBudget
+	selector labels.Selector
+	err      error
+}
+
+// NewCache builds a Cache of the given PDBs.
+func NewCache(pdbs []*policyv1.PodDisruptionBudget) *Cache {
+	c := &Cache{}
+	c.Update(pdbs)
+	return c
+}
+
+// Update replaces the PDBs of the cache. The cached matches are dropped if a PDB was added,
+// removed, or had its selector changed; changes of the status of the PDBs are picked up anyway.
+func (c *Cache) Update(pdbs []*policyv1.PodDisruptionBudget) {
+	c.Lock()
+	defer c.Unlock()
+
+	changed := len(pdbs) != len(c.budgets)
+	budgets := make([]*budget, 0, len(pdbs))
+	byName := make(map[string]*budget, len(pdbs))
+	byNamespace := make(map[string][]*budget)
+	for _, pdb := range pdbs {
+		name := pdb.Namespace + "/" + pdb.Name
+		b, found := c.byName[name]
+		if found && b.pdb.UID == pdb.UID && reflect.DeepEqual(b.pdb.Spec.Selector, pdb.Spec.Selector) {
+			b.pdb = pdb
+		} else {
+			changed = true
+			b = &budget{pdb: pdb}
+			b.selector, b.err = metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		}
+		budgets = append(budgets, b)
+		byName[name] = b
+		byNamespace[pdb.Namespace] = append(byNamespace[pdb.Namespace], b)
+	}
+	c.budgets = budgets
+	c.byName = byName
+	c.byNamespace = byNamespace
+	if changed || c.matches == nil {
+		c.matches = lru.New(maxCachedMatches)
+	}
+}
+
+// List returns the PDBs of the cache.
+func (c *Cache) List() []*policyv1.PodDisruptionBudget {
+	if c == nil {
+		return nil
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	result := make([]*policyv1.PodDisruptionBudget, 0, len(c.budgets))
+	for _, b := range c.budgets {
+		result = append(result, b.pdb)
+	}
+	return result
+}
+
+// Matching returns the PDBs in the namespace of the pod whose selector matches its labels. An
+// error is returned if the selector of one of the PDBs of the namespace is invalid.
+func (c *Cache) Matching(pod *apiv1.Pod) ([]*policyv1.PodDisruptionBudget, error) {
+	if c == nil {
+		return nil, nil
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	key := pod.Namespace + "/" + labels.Set(pod.Labels).String()
+	var matches []*budget
+	if cached, found := c.matches.Get(key); found {
+		matches = cached.([]*budget)
+	} else {
+		for _, b := range c.byNamespace[pod.Namespace] {
+			if b.err != nil {
+				return nil, b.err
+			}
+			if b.selector.Matches(labels.Set(pod.Labels)) {
+				matches = append(matches, b)
+			}
+		}
+		c.matches.Add(key, matches)
+	}
+
+	result := make([]*policyv1.PodDisruptionBudget, 0, len(matches))
+	for _, b := range matches {
+		result = append(result, b.pdb)
+	}
+	return result, nil
+}
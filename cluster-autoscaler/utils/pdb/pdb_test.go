@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdb
+
+import (
+	"fmt"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildPdb(namespace, name string, matchLabels map[string]string, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: matchLabels},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			PodDisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+}
+
+func buildPod(namespace, name string, podLabels map[string]string) *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    podLabels,
+		},
+	}
+}
+
+func TestCacheMatching(t *testing.T) {
+	app := buildPdb("default", "app", map[string]string{"app": "web"}, 1)
+	other := buildPdb("other", "app", map[string]string{"app": "web"}, 1)
+	cache := NewCache([]*policyv1.PodDisruptionBudget{app, other})
+
+	matching, err := cache.Matching(buildPod("default", "p1", map[string]string{"app": "web", "tier": "front"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{app}, matching)
+
+	matching, err = cache.Matching(buildPod("default", "p2", map[string]string{"app": "db"}))
+	assert.NoError(t, err)
+	assert.Empty(t, matching)
+
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{app, other}, cache.List())
+}
+
+func TestCacheUpdate(t *testing.T) {
+	pod := buildPod("default", "p1", map[string]string{"app": "web"})
+	app := buildPdb("default", "app", map[string]string{"app": "web"}, 1)
+	cache := NewCache([]*policyv1.PodDisruptionBudget{app})
+
+	matching, err := cache.Matching(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{app}, matching)
+
+	// Matches are kept when only the status changes, and return the new status.
+	updated := buildPdb("default", "app", map[string]string{"app": "web"}, 0)
+	cache.Update([]*policyv1.PodDisruptionBudget{updated})
+	assert.Equal(t, 1, cache.matches.Len())
+	matching, err = cache.Matching(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{updated}, matching)
+
+	// Matches are dropped when the selector changes.
+	updated = buildPdb("default", "app", map[string]string{"app": "db"}, 0)
+	cache.Update([]*policyv1.PodDisruptionBudget{updated})
+	assert.Equal(t, 0, cache.matches.Len())
+	matching, err = cache.Matching(pod)
+	assert.NoError(t, err)
+	assert.Empty(t, matching)
+
+	// Matches are dropped when a PDB is added.
+	cache.Update([]*policyv1.PodDisruptionBudget{updated, app})
+	matching, err = cache.Matching(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{app}, matching)
+}
+
+func TestCacheMaxMatches(t *testing.T) {
+	app := buildPdb("default", "app", map[string]string{"app": "web"}, 1)
+	cache := NewCache([]*policyv1.PodDisruptionBudget{app})
+
+	for i := 0; i <= maxCachedMatches; i++ {
+		pod := buildPod("default", fmt.Sprintf("p%d", i), map[string]string{"app": "web", "hash": fmt.Sprint(i)})
+		matching, err := cache.Matching(pod)
+		assert.NoError(t, err)
+		assert.Equal(t, []*policyv1.PodDisruptionBudget{app}, matching)
+	}
+	assert.Equal(t, maxCachedMatches, cache.matches.Len())
+}
+
+func TestCacheInvalidSelector(t *testing.T) {
+	invalid := buildPdb("default", "invalid", nil, 1)
+	invalid.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}}
+	cache := NewCache([]*policyv1.PodDisruptionBudget{invalid})
+
+	_, err := cache.Matching(buildPod("default", "p1", nil))
+	assert.Error(t, err)
+
+	matching, err := cache.Matching(buildPod("other", "p1", nil))
+	assert.NoError(t, err)
+	assert.Empty(t, matching)
+}
+
+func TestNilCache(t *testing.T) {
+	var cache *Cache
+	matching, err := cache.Matching(buildPod("default", "p1", nil))
+	assert.NoError(t, err)
+	assert.Empty(t, matching)
+	assert.Empty(t, cache.List())
+}
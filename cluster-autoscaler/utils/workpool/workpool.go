@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workpool
+
+import (
+	"sync"
+)
+
+// Pool limits the number of operations running concurrently. Operations are
+// keyed, e.g. by node group, and the slots freed by finished operations are
+// granted to the keys with waiting operations in turn, so that many operations
+// of one key can't starve those of the others. A nil Pool, or one of size 0,
+// doesn't limit anything.
+type Pool struct {
+	mutex   sync.Mutex
+	size    int
+	running int
+	// waiting are the operations waiting for a slot, by key.
+	waiting map[string][]chan struct{}
+	// keys are the keys with waiting operations, in the order they get slots.
+	keys []string
+}
+
+// NewPool creates a pool running at most size operations concurrently.
+func NewPool(size int) *Pool {
+	return &Pool{
+		size:    size,
+		waiting: make(map[string][]chan struct{}),
+	}
+}
+
+// Do runs the given operation of the given key once a slot is free, and returns
+// its result.
+func (p *Pool) Do(key string, operation func() error) error {
+	if p == nil || p.size <= 0 {
+		return operation()
+	}
+	p.acquire(key)
+	defer p.release()
+	return operation()
+}
+
+func (p *Pool) acquire(key string) {
+	p.mutex.Lock()
+	if p.running < p.size && len(p.keys) == 0 {
+		p.running++
+		p.mutex.Unlock()
+		return
+	}
+	granted := make(chan struct{})
+	if len(p.waiting[key]) == 0 {
+		p.keys = append(p.keys, key)
+	}
+	p.waiting[key] = append(p.waiting[key], granted)
+	p.mutex.Unlock()
+	<-granted
+}
+
+func (p *Pool) release() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.keys) == 0 {
+		p.running--
+		return
+	}
+	// The slot goes straight to the next key, which goes to the end of the
+	// line if it has more waiting operations.
+	key := p.keys[0]
+	p.keys = p.keys[1:]
+	waiting := p.waiting[key]
+	close(waiting[0])
+	if len(waiting) == 1 {
+		delete(p.waiting, key)
+		return
+	}
+	p.waiting[key] = waiting[1:]
+	p.keys = append(p.keys, key)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workpool
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitFor(t *testing.T, p *Pool, running, waiting int) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		p.mutex.Lock()
+		w := 0
+		for _, operations := range p.waiting {
+			w += len(operations)
+		}
+		r := p.running
+		p.mutex.Unlock()
+		if r == running && w == waiting {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %d running and %d waiting operations", running, waiting)
+}
+
+func TestPoolFairness(t *testing.T) {
+	p := NewPool(1)
+
+	block := make(chan struct{})
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var order []string
+
+	run := func(key string, id int) {
+		defer wg.Done()
+		p.Do(key, func() error {
+			mutex.Lock()
+			order = append(order, fmt.Sprintf("%s%d", key, id))
+			mutex.Unlock()
+			return nil
+		})
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.Do("a", func() error {
+			<-block
+			return nil
+		})
+	}()
+	waitFor(t, p, 1, 0)
+
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go run("a", i)
+		waitFor(t, p, 1, i)
+	}
+	wg.Add(1)
+	go run("b", 1)
+	waitFor(t, p, 1, 4)
+
+	close(block)
+	wg.Wait()
+
+	assert.Equal(t, []string{"a1", "b1", "a2", "a3"}, order)
+	assert.Equal(t, 0, p.running)
+	assert.Empty(t, p.keys)
+	assert.Empty(t, p.waiting)
+}
+
+func TestPoolLimit(t *testing.T) {
+	p := NewPool(2)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.Do(fmt.Sprintf("ng%d", i%3), func() error {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestPoolUnlimited(t *testing.T) {
+	var p *Pool
+	err := fmt.Errorf("failed")
+	assert.Equal(t, err, p.Do("ng1", func() error { return err }))
+	assert.Equal(t, err, NewPool(0).Do("ng1", func() error { return err }))
+	assert.NoError(t, NewPool(1).Do("ng1", func() error { return nil }))
+}
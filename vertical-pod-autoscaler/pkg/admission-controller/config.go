@@ -18,10 +18,12 @@ package main
 
 import (
 	"crypto/tls"
+	"fmt"
 	"time"
 
 	"k8s.io/api/admissionregistration/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
@@ -29,6 +31,12 @@ import (
 
 const (
 	webhookConfigName = "vpa-webhook-config"
+
+	// neverReinvocationPolicy and ifNeededReinvocationPolicy are the values of
+	// the reinvocationPolicy field of mutating webhooks, which isn't part of the
+	// admissionregistration API version vendored here.
+	neverReinvocationPolicy    = "Never"
+	ifNeededReinvocationPolicy = "IfNeeded"
 )
 
 // get a clientset with in-cluster config.
@@ -56,7 +64,9 @@ func configTLS(clientset *kubernetes.Clientset, serverCert, serverKey []byte) *t
 
 // register this webhook admission controller with the kube-apiserver
 // by creating MutatingWebhookConfiguration.
-func selfRegistration(clientset *kubernetes.Clientset, caCert []byte, namespace *string, url string, registerByURL bool) {
+// With the IfNeeded reinvocation policy the webhook is called again if other
+// webhooks modify the pod after it, e.g. by injecting sidecar containers.
+func selfRegistration(clientset *kubernetes.Clientset, caCert []byte, namespace *string, url string, registerByURL bool, reinvocationPolicy string) {
 	time.Sleep(10 * time.Second)
 	client := clientset.AdmissionregistrationV1beta1().MutatingWebhookConfigurations()
 	_, err := client.Get(webhookConfigName, metav1.GetOptions{})
@@ -108,4 +118,24 @@ func selfRegistration(clientset *kubernetes.Clientset, caCert []byte, namespace
 	} else {
 		klog.V(3).Info("Self registration as MutatingWebhook succeeded.")
 	}
+	if reinvocationPolicy != neverReinvocationPolicy {
+		// The field is set with a patch as the vendored types don't have it.
+		// API servers not supporting reinvocation ignore it.
+		patch := fmt.Sprintf(`[{"op": "add", "path": "/webhooks/0/reinvocationPolicy", "value": %q}]`, reinvocationPolicy)
+		if _, err := client.Patch(webhookConfigName, types.JSONPatchType, []byte(patch)); err != nil {
+			klog.Errorf("Failed to set reinvocation policy %s: %v", reinvocationPolicy, err)
+		} else {
+			klog.V(3).Infof("Reinvocation policy set to %s.", reinvocationPolicy)
+		}
+	}
+}
+
+func validateReinvocationPolicy(reinvocationPolicy string) error {
+	switch reinvocationPolicy {
+	case neverReinvocationPolicy, ifNeededReinvocationPolicy:
+		return nil
+	default:
+		return fmt.Errorf("unsupported reinvocation policy %q, supported values: %s, %s",
+			reinvocationPolicy, neverReinvocationPolicy, ifNeededReinvocationPolicy)
+	}
 }
@@ -0,0 +1,186 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+const (
+	// RecommendationExceedsQuotaReason is the reason of the events recorded on
+	// VPA objects whose recommendations don't fit the resource quota of the
+	// namespace of a pod.
+	RecommendationExceedsQuotaReason = "RecommendationExceedsQuota"
+)
+
+// ResourceQuotaLimiter checks the resources recommended for pods against the
+// resource quotas of their namespaces.
+type ResourceQuotaLimiter interface {
+	// Limit returns the resources to set on the pod, given the recommended ones.
+	Limit(pod *v1.Pod, resources []ContainerResources, vpaName string) []ContainerResources
+}
+
+// NoopResourceQuotaLimiter leaves the recommended resources unchanged.
+type NoopResourceQuotaLimiter struct{}
+
+// Limit leaves the recommended resources unchanged.
+func (l *NoopResourceQuotaLimiter) Limit(pod *v1.Pod, resources []ContainerResources, vpaName string) []ContainerResources {
+	return resources
+}
+
+type resourceQuotaLimiter struct {
+	quotaLister   v1lister.ResourceQuotaLister
+	eventRecorder record.EventRecorder
+	capToQuota    bool
+}
+
+// NewResourceQuotaLimiter creates a ResourceQuotaLimiter recording an event on
+// the VPA whenever the recommended requests of a pod exceed the headroom of a
+// resource quota of its namespace. If capToQuota is set, the increase of the
+// requests over the ones of the pod is also scaled down to fit the headroom,
+// so that the pod isn't rejected because of VPA.
+// Only quotas without scopes are taken into account.
+func NewResourceQuotaLimiter(quotaLister v1lister.ResourceQuotaLister, eventRecorder record.EventRecorder, capToQuota bool) ResourceQuotaLimiter {
+	return &resourceQuotaLimiter{
+		quotaLister:   quotaLister,
+		eventRecorder: eventRecorder,
+		capToQuota:    capToQuota,
+	}
+}
+
+func (l *resourceQuotaLimiter) Limit(pod *v1.Pod, resources []ContainerResources, vpaName string) []ContainerResources {
+	quotas, err := l.quotaLister.ResourceQuotas(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Cannot list resource quotas of namespace %s: %v", pod.Namespace, err)
+		return resources
+	}
+
+	for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		headroom, quotaName, found := quotaHeadroom(quotas, resourceName)
+		if !found {
+			continue
+		}
+		total, increase := requestsIncrease(pod, resources, resourceName)
+		if total <= headroom {
+			continue
+		}
+
+		message := fmt.Sprintf("Recommended %s requests of pod %s exceed the headroom of resource quota %s", resourceName, pod.Name, quotaName)
+		if l.capToQuota && increase > 0 {
+			fraction := 1 - float64(total-headroom)/float64(increase)
+			if fraction < 0 {
+				fraction = 0
+			}
+			resources = scaleRequestsIncrease(pod, resources, resourceName, fraction)
+			message += ", the increase was capped"
+		}
+		klog.V(2).Info(message)
+		l.eventRecorder.Event(&v1.ObjectReference{
+			Kind:       "VerticalPodAutoscaler",
+			APIVersion: "autoscaling.k8s.io/v1beta2",
+			Namespace:  pod.Namespace,
+			Name:       vpaName,
+		}, v1.EventTypeWarning, RecommendationExceedsQuotaReason, message)
+	}
+	return resources
+}
+
+// quotaHeadroom returns the smallest headroom of the requests of the resource
+// among the quotas, and the name of the quota it comes from.
+func quotaHeadroom(quotas []*v1.ResourceQuota, resourceName v1.ResourceName) (int64, string, bool) {
+	var headroom int64
+	var quotaName string
+	found := false
+	for _, quota := range quotas {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		for _, key := range []v1.ResourceName{resourceName, v1.ResourceName("requests." + string(resourceName))} {
+			hard, hasHard := quota.Spec.Hard[key]
+			if !hasHard {
+				continue
+			}
+			used := quota.Status.Used[key]
+			available := quantityValue(resourceName, hard) - quantityValue(resourceName, used)
+			if !found || available < headroom {
+				headroom, quotaName, found = available, quota.Name, true
+			}
+		}
+	}
+	return headroom, quotaName, found
+}
+
+// requestsIncrease returns the total requests of the resource of the pod with
+// the recommended resources set, and by how much the recommendation increases
+// the requests of the containers it raises.
+func requestsIncrease(pod *v1.Pod, resources []ContainerResources, resourceName v1.ResourceName) (int64, int64) {
+	var total, increase int64
+	for i, container := range pod.Spec.Containers {
+		original := quantityValue(resourceName, container.Resources.Requests[resourceName])
+		recommended, found := resources[i].Requests[resourceName]
+		if !found {
+			total += original
+			continue
+		}
+		value := quantityValue(resourceName, recommended)
+		total += value
+		if value > original {
+			increase += value - original
+		}
+	}
+	return total, increase
+}
+
+// scaleRequestsIncrease scales the increase of the requests of the resource
+// over the ones of the pod by fraction.
+func scaleRequestsIncrease(pod *v1.Pod, resources []ContainerResources, resourceName v1.ResourceName, fraction float64) []ContainerResources {
+	limited := make([]ContainerResources, len(resources))
+	for i, containerResources := range resources {
+		limited[i] = ContainerResources{Requests: containerResources.Requests.DeepCopy()}
+		recommended, found := containerResources.Requests[resourceName]
+		if !found {
+			continue
+		}
+		original := quantityValue(resourceName, pod.Spec.Containers[i].Resources.Requests[resourceName])
+		value := quantityValue(resourceName, recommended)
+		if value <= original {
+			continue
+		}
+		value = original + int64(float64(value-original)*fraction)
+		if resourceName == v1.ResourceCPU {
+			limited[i].Requests[resourceName] = *resource.NewMilliQuantity(value, recommended.Format)
+		} else {
+			limited[i].Requests[resourceName] = *resource.NewQuantity(value, recommended.Format)
+		}
+	}
+	return limited
+}
+
+// quantityValue returns CPU in millicores and other resources in units.
+func quantityValue(resourceName v1.ResourceName, quantity resource.Quantity) int64 {
+	if resourceName == v1.ResourceCPU {
+		return quantity.MilliValue()
+	}
+	return quantity.Value()
+}
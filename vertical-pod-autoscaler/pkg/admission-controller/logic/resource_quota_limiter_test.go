@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func buildResourceQuota(name string, hard, used apiv1.ResourceList) *apiv1.ResourceQuota {
+	return &apiv1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       apiv1.ResourceQuotaSpec{Hard: hard},
+		Status:     apiv1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func newTestResourceQuotaLimiter(capToQuota bool, quotas ...*apiv1.ResourceQuota) (ResourceQuotaLimiter, *record.FakeRecorder) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, quota := range quotas {
+		store.Add(quota)
+	}
+	recorder := record.NewFakeRecorder(10)
+	return NewResourceQuotaLimiter(v1lister.NewResourceQuotaLister(store), recorder, capToQuota), recorder
+}
+
+func TestResourceQuotaLimiter(t *testing.T) {
+	pod := test.Pod().WithName("pod1").
+		AddContainer(test.BuildTestContainer("container1", "1", "100Mi")).
+		AddContainer(test.BuildTestContainer("container2", "1", "100Mi")).Get()
+	pod.Namespace = "default"
+	resources := []ContainerResources{
+		{Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse("3"),
+			apiv1.ResourceMemory: resource.MustParse("100Mi"),
+		}},
+		{Requests: apiv1.ResourceList{}},
+	}
+
+	// The namespace has 4.5 CPU left, while the pod would request 4 CPU.
+	fits := buildResourceQuota("fits",
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("10")},
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("5500m")})
+	// The namespace has 3 CPU left.
+	exceeded := buildResourceQuota("exceeded",
+		apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("8")},
+		apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("5")})
+	// Quotas with scopes are ignored.
+	scoped := buildResourceQuota("scoped",
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("1")},
+		apiv1.ResourceList{})
+	scoped.Spec.Scopes = []apiv1.ResourceQuotaScope{apiv1.ResourceQuotaScopeBestEffort}
+
+	limiter, recorder := newTestResourceQuotaLimiter(true, fits, scoped)
+	assert.Equal(t, resources, limiter.Limit(pod, resources, "vpa1"))
+	assert.Empty(t, recorder.Events)
+
+	limiter, recorder = newTestResourceQuotaLimiter(false, fits, exceeded)
+	assert.Equal(t, resources, limiter.Limit(pod, resources, "vpa1"))
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, RecommendationExceedsQuotaReason)
+
+	limiter, recorder = newTestResourceQuotaLimiter(true, fits, exceeded)
+	limited := limiter.Limit(pod, resources, "vpa1")
+	assert.Len(t, recorder.Events, 1)
+	// Only the increase over the requests of the pod is scaled down: container1
+	// keeps its 1 CPU plus half of the 2 CPU increase to fit the 3 CPU left.
+	cpu := limited[0].Requests[apiv1.ResourceCPU]
+	assert.Equal(t, int64(2000), cpu.MilliValue())
+	assert.Equal(t, resource.MustParse("100Mi"), limited[0].Requests[apiv1.ResourceMemory])
+	assert.Empty(t, limited[1].Requests)
+	// The recommended resources aren't modified.
+	assert.Equal(t, resource.MustParse("3"), resources[0].Requests[apiv1.ResourceCPU])
+}
+
+func TestNoopResourceQuotaLimiter(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.BuildTestContainer("container1", "1", "100Mi")).Get()
+	resources := []ContainerResources{{Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("3")}}}
+	limiter := &NoopResourceQuotaLimiter{}
+	assert.Equal(t, resources, limiter.Limit(pod, resources, "vpa1"))
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"path"
 
 	"strings"
 
@@ -37,11 +38,12 @@ import (
 type AdmissionServer struct {
 	recommendationProvider RecommendationProvider
 	podPreProcessor        PodPreProcessor
+	resourceQuotaLimiter   ResourceQuotaLimiter
 }
 
 // NewAdmissionServer constructs new AdmissionServer
-func NewAdmissionServer(recommendationProvider RecommendationProvider, podPreProcessor PodPreProcessor) *AdmissionServer {
-	return &AdmissionServer{recommendationProvider, podPreProcessor}
+func NewAdmissionServer(recommendationProvider RecommendationProvider, podPreProcessor PodPreProcessor, resourceQuotaLimiter ResourceQuotaLimiter) *AdmissionServer {
+	return &AdmissionServer{recommendationProvider, podPreProcessor, resourceQuotaLimiter}
 }
 
 type patchRecord struct {
@@ -68,6 +70,7 @@ func (s *AdmissionServer) getPatchesForPodResourceRequest(raw []byte, namespace
 	if err != nil {
 		return nil, err
 	}
+	containersResources = s.resourceQuotaLimiter.Limit(&pod, containersResources, vpaName)
 	if annotationsPerContainer == nil {
 		annotationsPerContainer = vpa_api_util.ContainerToAnnotationsMap{}
 	}
@@ -156,6 +159,9 @@ func validateVPA(vpa *vpa_types.VerticalPodAutoscaler, isCreate bool) error {
 		if _, found := possibleUpdateModes[*mode]; !found {
 			return fmt.Errorf("unexpected UpdateMode value %s", *mode)
 		}
+		if minChange := vpa.Spec.UpdatePolicy.MinChangePercent; minChange != nil && *minChange < 0 {
+			return fmt.Errorf("UpdatePolicy.MinChangePercent must not be negative")
+		}
 	}
 
 	if vpa.Spec.ResourcePolicy != nil {
@@ -163,6 +169,9 @@ func validateVPA(vpa *vpa_types.VerticalPodAutoscaler, isCreate bool) error {
 			if policy.ContainerName == "" {
 				return fmt.Errorf("ContainerPolicies.ContainerName is required")
 			}
+			if _, err := path.Match(policy.ContainerName, ""); err != nil {
+				return fmt.Errorf("ContainerPolicies.ContainerName %s is not a valid pattern", policy.ContainerName)
+			}
 			mode := policy.Mode
 			if mode != nil {
 				if _, found := possibleScalingModes[*mode]; !found {
@@ -178,6 +187,17 @@ func validateVPA(vpa *vpa_types.VerticalPodAutoscaler, isCreate bool) error {
 		}
 	}
 
+	if margin := vpa.Spec.RecommendationMargin; margin != nil {
+		if margin.Fraction != nil && *margin.Fraction < 0 {
+			return fmt.Errorf("RecommendationMargin.Fraction must not be negative")
+		}
+		for resource, min := range margin.Minimum {
+			if min.Sign() < 0 {
+				return fmt.Errorf("RecommendationMargin.Minimum for %v must not be negative", resource)
+			}
+		}
+	}
+
 	if isCreate && vpa.Spec.TargetRef == nil {
 		return fmt.Errorf("TargetRef is required. If you're using v1beta1 version of the API, please migrate to v1beta2.")
 	}
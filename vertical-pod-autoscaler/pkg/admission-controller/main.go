@@ -23,22 +23,33 @@ import (
 	"os"
 	"time"
 
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	kube_flag "k8s.io/apiserver/pkg/util/flag"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/common"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/logic"
 	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
+	vpa_scheme "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/scheme"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
 	metrics_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/admission"
 	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 	"k8s.io/client-go/informers"
 	kube_client "k8s.io/client-go/kubernetes"
+	clientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 )
 
 const (
 	defaultResyncPeriod time.Duration = 10 * time.Minute
+
+	noneResourceQuotaMode = "none"
+	warnResourceQuotaMode = "warn"
+	capResourceQuotaMode  = "cap"
 )
 
 var (
@@ -54,12 +65,22 @@ var (
 	webhookAddress = flag.String("webhook-address", "", "Address under which webhook is registered. Used when registerByURL is set to true.")
 	webhookPort    = flag.String("webhook-port", "", "Server Port for Webhook")
 	registerByURL  = flag.Bool("register-by-url", false, "If set to true, admission webhook will be registered by URL (webhookAddress:webhookPort) instead of by service name")
+
+	reinvocationPolicy = flag.String("reinvocation-policy", neverReinvocationPolicy, "Reinvocation policy of the admission webhook. With IfNeeded the webhook is called again when other webhooks modify pods after it, e.g. to inject sidecars. Supported values: Never, IfNeeded")
+	resourceQuotaMode  = flag.String("resource-quota-mode", noneResourceQuotaMode, "How recommendations exceeding the headroom of the resource quotas of the namespace of a pod are handled. With warn an event is recorded on the VPA, with cap the increase of the requests is also limited to the headroom. Supported values: none, warn, cap")
 )
 
 func main() {
 	kube_flag.InitFlags()
 	klog.V(1).Infof("Vertical Pod Autoscaler %s Admission Controller", common.VerticalPodAutoscalerVersion)
 
+	if err := validateReinvocationPolicy(*reinvocationPolicy); err != nil {
+		klog.Fatal(err)
+	}
+	if err := validateResourceQuotaMode(*resourceQuotaMode); err != nil {
+		klog.Fatal(err)
+	}
+
 	healthCheck := metrics.NewHealthCheck(time.Minute, false)
 	metrics.Initialize(*address, healthCheck)
 	metrics_admission.Register()
@@ -79,7 +100,7 @@ func main() {
 		target.NewVpaTargetSelectorFetcher(config, kubeClient, factory),
 		target.NewBeta1TargetSelectorFetcher(config),
 	)
-	as := logic.NewAdmissionServer(logic.NewRecommendationProvider(vpaLister, vpa_api_util.NewCappingRecommendationProcessor(), targetSelectorFetcher), logic.NewDefaultPodPreProcessor())
+	as := logic.NewAdmissionServer(logic.NewRecommendationProvider(vpaLister, vpa_api_util.NewCappingRecommendationProcessor(), targetSelectorFetcher), logic.NewDefaultPodPreProcessor(), newResourceQuotaLimiter(kubeClient, *resourceQuotaMode))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		as.Serve(w, r)
 		healthCheck.UpdateLastActivity()
@@ -90,6 +111,35 @@ func main() {
 		TLSConfig: configTLS(clientset, certs.serverCert, certs.serverKey),
 	}
 	url := fmt.Sprintf("%v:%v", webhookAddress, webhookPort)
-	go selfRegistration(clientset, certs.caCert, &namespace, url, *registerByURL)
+	go selfRegistration(clientset, certs.caCert, &namespace, url, *registerByURL, *reinvocationPolicy)
 	server.ListenAndServeTLS("", "")
 }
+
+func validateResourceQuotaMode(mode string) error {
+	switch mode {
+	case noneResourceQuotaMode, warnResourceQuotaMode, capResourceQuotaMode:
+		return nil
+	default:
+		return fmt.Errorf("unsupported resource quota mode %q, supported values: %s, %s, %s",
+			mode, noneResourceQuotaMode, warnResourceQuotaMode, capResourceQuotaMode)
+	}
+}
+
+func newResourceQuotaLimiter(kubeClient kube_client.Interface, mode string) logic.ResourceQuotaLimiter {
+	if mode == noneResourceQuotaMode {
+		return &logic.NoopResourceQuotaLimiter{}
+	}
+	quotaListWatch := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "resourcequotas", apiv1.NamespaceAll, fields.Everything())
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	quotaLister := v1lister.NewResourceQuotaLister(store)
+	quotaReflector := cache.NewReflector(quotaListWatch, &apiv1.ResourceQuota{}, store, time.Hour)
+	stopCh := make(chan struct{})
+	go quotaReflector.Run(stopCh)
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.V(4).Infof)
+	eventBroadcaster.StartRecordingToSink(&clientv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	eventRecorder := eventBroadcaster.NewRecorder(vpa_scheme.Scheme, apiv1.EventSource{Component: "vpa-admission-controller"})
+
+	return logic.NewResourceQuotaLimiter(quotaLister, eventRecorder, mode == capResourceQuotaMode)
+}
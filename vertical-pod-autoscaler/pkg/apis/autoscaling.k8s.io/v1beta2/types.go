@@ -85,6 +85,28 @@ type VerticalPodAutoscalerSpec struct {
 	// resources for all containers in the pod, without additional constraints.
 	// +optional
 	ResourcePolicy *PodResourcePolicy `json:"resourcePolicy,omitempty" protobuf:"bytes,3,opt,name=resourcePolicy"`
+
+	// Controls the safety margin the autoscaler adds to the resources used
+	// by the containers. If not specified, the margin is set by the flags of
+	// the recommender.
+	// +optional
+	RecommendationMargin *RecommendationMargin `json:"recommendationMargin,omitempty" protobuf:"bytes,4,opt,name=recommendationMargin"`
+}
+
+// RecommendationMargin controls the safety margin added on top of the
+// resources used by the containers when computing the recommendation, which
+// is the headroom the containers get to absorb spikes of usage. The margin of
+// a resource is the larger of Fraction of its usage and Minimum.
+type RecommendationMargin struct {
+	// Fraction of the usage added as the safety margin. The default is set
+	// by the --recommendation-margin-fraction flag of the recommender.
+	// +optional
+	Fraction *float64 `json:"fraction,omitempty" protobuf:"fixed64,1,opt,name=fraction"`
+	// Minimal amount of each resource added as the safety margin to the
+	// usage of a container. Resources not listed default to the
+	// --recommendation-min-margin-* flags of the recommender.
+	// +optional
+	Minimum v1.ResourceList `json:"minimum,omitempty" protobuf:"bytes,2,rep,name=minimum,casttype=ResourceList,castkey=ResourceName"`
 }
 
 // PodUpdatePolicy describes the rules on how changes are applied to the pods.
@@ -93,6 +115,15 @@ type PodUpdatePolicy struct {
 	// The default is 'Auto'.
 	// +optional
 	UpdateMode *UpdateMode `json:"updateMode,omitempty" protobuf:"bytes,1,opt,name=updateMode"`
+
+	// Minimal change, in percent, between the resources requested by a pod
+	// and the recommendation for the pod to be evicted. The relative changes
+	// of all the resources are summed up. When set, pods whose requests are
+	// outside the recommended range are only evicted if the change is large
+	// enough too, except after a quick OOM. The default is 10% for pods
+	// within the recommended range and no minimum outside of it.
+	// +optional
+	MinChangePercent *int32 `json:"minChangePercent,omitempty" protobuf:"varint,2,opt,name=minChangePercent"`
 }
 
 // UpdateMode controls when autoscaler applies changes to the pod resoures.
@@ -134,7 +165,9 @@ type PodResourcePolicy struct {
 type ContainerResourcePolicy struct {
 	// Name of the container or DefaultContainerResourcePolicy, in which
 	// case the policy is used by the containers that don't have their own
-	// policy specified.
+	// policy specified. It can also be a pattern, e.g. "istio-*", in which
+	// case the policy is used by the matching containers that don't have
+	// a policy with their exact name.
 	ContainerName string `json:"containerName,omitempty" protobuf:"bytes,1,opt,name=containerName"`
 	// Whether autoscaler is enabled for the container. The default is "Auto".
 	// +optional
@@ -116,6 +116,11 @@ func (in *PodUpdatePolicy) DeepCopyInto(out *PodUpdatePolicy) {
 		*out = new(UpdateMode)
 		**out = **in
 	}
+	if in.MinChangePercent != nil {
+		in, out := &in.MinChangePercent, &out.MinChangePercent
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -129,6 +134,34 @@ func (in *PodUpdatePolicy) DeepCopy() *PodUpdatePolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommendationMargin) DeepCopyInto(out *RecommendationMargin) {
+	*out = *in
+	if in.Fraction != nil {
+		in, out := &in.Fraction, &out.Fraction
+		*out = new(float64)
+		**out = **in
+	}
+	if in.Minimum != nil {
+		in, out := &in.Minimum, &out.Minimum
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationMargin.
+func (in *RecommendationMargin) DeepCopy() *RecommendationMargin {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommendationMargin)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RecommendedContainerResources) DeepCopyInto(out *RecommendedContainerResources) {
 	*out = *in
@@ -390,6 +423,11 @@ func (in *VerticalPodAutoscalerSpec) DeepCopyInto(out *VerticalPodAutoscalerSpec
 		*out = new(PodResourcePolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RecommendationMargin != nil {
+		in, out := &in.RecommendationMargin, &out.RecommendationMargin
+		*out = new(RecommendationMargin)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
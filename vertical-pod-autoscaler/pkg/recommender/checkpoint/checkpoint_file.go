@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+	"k8s.io/klog"
+)
+
+// fileCheckpointWriter stores the checkpoints of all the VPAs in a single gzip compressed JSON file
+// instead of VerticalPodAutoscalerCheckpoint objects, for clusters whose checkpoints would put too
+// much load on the API server and etcd. The file can live on a persistent volume or on a volume
+// backed by object storage. It is rewritten as a whole, atomically, on every StoreCheckpoints, and
+// the checkpoints of VPAs that no longer exist are dropped from it.
+type fileCheckpointWriter struct {
+	path        string
+	cluster     *model.ClusterState
+	checkpoints map[string]*vpa_types.VerticalPodAutoscalerCheckpoint
+}
+
+// NewFileCheckpointWriter returns a CheckpointWriter storing checkpoints in the file at path. The
+// checkpoints already in the file are kept until they are replaced or their VPA is deleted.
+func NewFileCheckpointWriter(cluster *model.ClusterState, path string) CheckpointWriter {
+	writer := &fileCheckpointWriter{
+		path:        path,
+		cluster:     cluster,
+		checkpoints: make(map[string]*vpa_types.VerticalPodAutoscalerCheckpoint),
+	}
+	checkpoints, err := ReadCheckpointFile(path)
+	if err != nil {
+		klog.Errorf("Cannot read checkpoints from %s. Reason: %+v", path, err)
+	}
+	for i := range checkpoints {
+		writer.checkpoints[checkpointKey(&checkpoints[i])] = &checkpoints[i]
+	}
+	return writer
+}
+
+func checkpointKey(checkpoint *vpa_types.VerticalPodAutoscalerCheckpoint) string {
+	return checkpoint.Namespace + "/" + checkpoint.Name
+}
+
+func (writer *fileCheckpointWriter) StoreCheckpoints(ctx context.Context, now time.Time, minCheckpoints int) error {
+	err := storeCheckpoints(ctx, writer.cluster, now, minCheckpoints, func(vpaCheckpoint *vpa_types.VerticalPodAutoscalerCheckpoint) error {
+		writer.checkpoints[checkpointKey(vpaCheckpoint)] = vpaCheckpoint
+		return nil
+	})
+
+	checkpoints := make([]vpa_types.VerticalPodAutoscalerCheckpoint, 0, len(writer.checkpoints))
+	for key, checkpoint := range writer.checkpoints {
+		vpaID := model.VpaID{Namespace: checkpoint.Namespace, VpaName: checkpoint.Spec.VPAObjectName}
+		if _, exists := writer.cluster.Vpas[vpaID]; !exists {
+			klog.V(3).Infof("Orphaned VPA checkpoint cleanup - deleting %v.", key)
+			delete(writer.checkpoints, key)
+			continue
+		}
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpointKey(&checkpoints[i]) < checkpointKey(&checkpoints[j])
+	})
+
+	if writeErr := writeCheckpointFile(writer.path, checkpoints); writeErr != nil {
+		klog.Errorf("Cannot write checkpoints to %s. Reason: %+v", writer.path, writeErr)
+		return writeErr
+	}
+	klog.V(3).Infof("Wrote %d VPA checkpoints to %s", len(checkpoints), writer.path)
+	return err
+}
+
+// ReadCheckpointFile returns the checkpoints stored in the file at path by a CheckpointWriter made
+// by NewFileCheckpointWriter. A missing file holds no checkpoints.
+func ReadCheckpointFile(path string) ([]vpa_types.VerticalPodAutoscalerCheckpoint, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress checkpoints: %v", err)
+	}
+	defer reader.Close()
+
+	var checkpointList vpa_types.VerticalPodAutoscalerCheckpointList
+	if err := json.NewDecoder(reader).Decode(&checkpointList); err != nil {
+		return nil, fmt.Errorf("cannot decode checkpoints: %v", err)
+	}
+	return checkpointList.Items, nil
+}
+
+// writeCheckpointFile replaces the file at path with one holding checkpoints. The new file is
+// written next to it and renamed, so that readers never see a partially written file.
+func writeCheckpointFile(path string, checkpoints []vpa_types.VerticalPodAutoscalerCheckpoint) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := gzip.NewWriter(file)
+	err = json.NewEncoder(writer).Encode(vpa_types.VerticalPodAutoscalerCheckpointList{Items: checkpoints})
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+func TestFileCheckpointWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoints.json.gz")
+
+	checkpoints, err := ReadCheckpointFile(path)
+	assert.NoError(t, err)
+	assert.Empty(t, checkpoints, "A missing file should hold no checkpoints.")
+
+	cluster := model.NewClusterState()
+	cluster.AddOrUpdatePod(testPodID1, testLabels, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(testContainerID1, testRequest))
+	timeNow := time.Unix(1, 0)
+	assert.NoError(t, cluster.AddSample(&model.ContainerUsageSampleWithKey{
+		ContainerUsageSample: model.ContainerUsageSample{
+			MeasureStart: timeNow,
+			Usage:        model.CPUAmountFromCores(1),
+			Request:      testRequest[model.ResourceCPU],
+			Resource:     model.ResourceCPU,
+		},
+		Container: testContainerID1,
+	}))
+	vpa := addVpa(cluster, testVpaID1, testSelectorStr)
+
+	writer := NewFileCheckpointWriter(cluster, path)
+	assert.NoError(t, writer.StoreCheckpoints(context.Background(), timeNow, 10))
+	assert.Equal(t, timeNow, vpa.CheckpointWritten)
+
+	checkpoints, err = ReadCheckpointFile(path)
+	assert.NoError(t, err)
+	if assert.Len(t, checkpoints, 1) {
+		assert.Equal(t, "namespace-1", checkpoints[0].Namespace)
+		assert.Equal(t, "vpa-1-container-1", checkpoints[0].Name)
+		assert.Equal(t, "vpa-1", checkpoints[0].Spec.VPAObjectName)
+		assert.Equal(t, "container-1", checkpoints[0].Spec.ContainerName)
+		assert.Equal(t, 1, checkpoints[0].Status.TotalSamplesCount)
+	}
+
+	// A new writer keeps the checkpoints of the file until its VPA is deleted.
+	cluster = model.NewClusterState()
+	addVpa(cluster, testVpaID1, testSelectorStr)
+	writer = NewFileCheckpointWriter(cluster, path)
+	assert.NoError(t, writer.StoreCheckpoints(context.Background(), timeNow, 0))
+	checkpoints, err = ReadCheckpointFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, checkpoints, 1)
+
+	assert.NoError(t, cluster.DeleteVpa(testVpaID1))
+	assert.NoError(t, writer.StoreCheckpoints(context.Background(), timeNow, 0))
+	checkpoints, err = ReadCheckpointFile(path)
+	assert.NoError(t, err)
+	assert.Empty(t, checkpoints, "The checkpoints of deleted VPAs should be dropped.")
+}
@@ -76,7 +76,16 @@ func getVpasToCheckpoint(clusterVpas map[model.VpaID]*model.Vpa) []*model.Vpa {
 }
 
 func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.Time, minCheckpoints int) error {
-	vpas := getVpasToCheckpoint(writer.cluster.Vpas)
+	return storeCheckpoints(ctx, writer.cluster, now, minCheckpoints, func(vpaCheckpoint *vpa_types.VerticalPodAutoscalerCheckpoint) error {
+		return api_util.CreateOrUpdateVpaCheckpoint(writer.vpaCheckpointClient.VerticalPodAutoscalerCheckpoints(vpaCheckpoint.Namespace), vpaCheckpoint)
+	})
+}
+
+// storeCheckpoints builds the checkpoints of the VPAs of the cluster, least recently checkpointed
+// first, and saves them with save.
+func storeCheckpoints(ctx context.Context, cluster *model.ClusterState, now time.Time, minCheckpoints int,
+	save func(vpaCheckpoint *vpa_types.VerticalPodAutoscalerCheckpoint) error) error {
+	vpas := getVpasToCheckpoint(cluster.Vpas)
 	for _, vpa := range vpas {
 
 		// Draining ctx.Done() channel. ctx.Err() will be checked if timeout occurred, but minCheckpoints have
@@ -90,7 +99,7 @@ func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.T
 			return ctx.Err()
 		}
 
-		aggregateContainerStateMap := buildAggregateContainerStateMap(vpa, writer.cluster, now)
+		aggregateContainerStateMap := buildAggregateContainerStateMap(vpa, cluster, now)
 		for container, aggregatedContainerState := range aggregateContainerStateMap {
 			containerCheckpoint, err := aggregatedContainerState.SaveToCheckpoint()
 			if err != nil {
@@ -99,14 +108,14 @@ func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.T
 			}
 			checkpointName := fmt.Sprintf("%s-%s", vpa.ID.VpaName, container)
 			vpaCheckpoint := vpa_types.VerticalPodAutoscalerCheckpoint{
-				ObjectMeta: metav1.ObjectMeta{Name: checkpointName},
+				ObjectMeta: metav1.ObjectMeta{Name: checkpointName, Namespace: vpa.ID.Namespace},
 				Spec: vpa_types.VerticalPodAutoscalerCheckpointSpec{
 					ContainerName: container,
 					VPAObjectName: vpa.ID.VpaName,
 				},
 				Status: *containerCheckpoint,
 			}
-			err = api_util.CreateOrUpdateVpaCheckpoint(writer.vpaCheckpointClient.VerticalPodAutoscalerCheckpoints(vpa.ID.Namespace), &vpaCheckpoint)
+			err = save(&vpaCheckpoint)
 			if err != nil {
 				klog.Errorf("Cannot save VPA %s/%s checkpoint for %s. Reason: %+v",
 					vpa.ID.Namespace, vpaCheckpoint.Spec.VPAObjectName, vpaCheckpoint.Spec.ContainerName, err)
@@ -30,6 +30,7 @@ import (
 	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
 	vpa_api "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/typed/autoscaling.k8s.io/v1beta2"
 	vpa_lister "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1beta2"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/checkpoint"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/history"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/metrics"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/oom"
@@ -84,6 +85,9 @@ type ClusterStateFeederFactory struct {
 	OOMObserver           oom.Observer
 	LegacySelectorFetcher target.VpaTargetSelectorFetcher
 	SelectorFetcher       target.VpaTargetSelectorFetcher
+	// CheckpointFile is the file checkpoints are loaded from instead of
+	// VerticalPodAutoscalerCheckpoint objects, if set.
+	CheckpointFile string
 }
 
 // Make creates new ClusterStateFeeder with internal data providers, based on kube client.
@@ -98,12 +102,14 @@ func (m ClusterStateFeederFactory) Make() *clusterStateFeeder {
 		specClient:            spec.NewSpecClient(m.PodLister),
 		legacySelectorFetcher: m.LegacySelectorFetcher,
 		selectorFetcher:       m.SelectorFetcher,
+		checkpointFile:        m.CheckpointFile,
 	}
 }
 
 // NewClusterStateFeeder creates new ClusterStateFeeder with internal data providers, based on kube client config.
+// Checkpoints are loaded from checkpointFile if it is set.
 // Deprecated; Use ClusterStateFeederFactory instead.
-func NewClusterStateFeeder(config *rest.Config, clusterState *model.ClusterState) ClusterStateFeeder {
+func NewClusterStateFeeder(config *rest.Config, clusterState *model.ClusterState, checkpointFile string) ClusterStateFeeder {
 	kubeClient := kube_client.NewForConfigOrDie(config)
 	podLister, oomObserver := NewPodListerAndOOMObserver(kubeClient)
 	factory := informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod)
@@ -117,6 +123,7 @@ func NewClusterStateFeeder(config *rest.Config, clusterState *model.ClusterState
 		ClusterState:          clusterState,
 		LegacySelectorFetcher: target.NewBeta1TargetSelectorFetcher(config),
 		SelectorFetcher:       target.NewVpaTargetSelectorFetcher(config, kubeClient, factory),
+		CheckpointFile:        checkpointFile,
 	}.Make()
 }
 
@@ -195,6 +202,7 @@ type clusterStateFeeder struct {
 	clusterState          *model.ClusterState
 	legacySelectorFetcher target.VpaTargetSelectorFetcher
 	selectorFetcher       target.VpaTargetSelectorFetcher
+	checkpointFile        string
 }
 
 func (feeder *clusterStateFeeder) InitFromHistoryProvider(historyProvider history.HistoryProvider) {
@@ -241,6 +249,11 @@ func (feeder *clusterStateFeeder) InitFromCheckpoints() {
 	klog.V(3).Info("Initializing VPA from checkpoints")
 	feeder.LoadVPAs()
 
+	if feeder.checkpointFile != "" {
+		feeder.initFromCheckpointFile()
+		return
+	}
+
 	namespaces := make(map[string]bool)
 	for _, v := range feeder.clusterState.Vpas {
 		namespaces[v.ID.Namespace] = true
@@ -265,7 +278,28 @@ func (feeder *clusterStateFeeder) InitFromCheckpoints() {
 	}
 }
 
+func (feeder *clusterStateFeeder) initFromCheckpointFile() {
+	klog.V(3).Infof("Fetching checkpoints from %s", feeder.checkpointFile)
+	checkpoints, err := checkpoint.ReadCheckpointFile(feeder.checkpointFile)
+	if err != nil {
+		klog.Errorf("Cannot read VPA checkpoints from %s. Reason: %+v", feeder.checkpointFile, err)
+		return
+	}
+	for i := range checkpoints {
+		checkpoint := &checkpoints[i]
+		klog.V(3).Infof("Loading VPA %s/%s checkpoint for %s", checkpoint.ObjectMeta.Namespace, checkpoint.Spec.VPAObjectName, checkpoint.Spec.ContainerName)
+		err = feeder.setVpaCheckpoint(checkpoint)
+		if err != nil {
+			klog.Errorf("Error while loading checkpoint. Reason: %+v", err)
+		}
+	}
+}
+
 func (feeder *clusterStateFeeder) GarbageCollectCheckpoints() {
+	if feeder.checkpointFile != "" {
+		// The checkpoints of deleted VPAs are dropped from the file whenever it is written.
+		return
+	}
 	klog.V(3).Info("Starting garbage collection of checkpoints")
 	feeder.LoadVPAs()
 
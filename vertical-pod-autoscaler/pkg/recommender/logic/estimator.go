@@ -47,6 +47,7 @@ type percentileEstimator struct {
 
 type marginEstimator struct {
 	marginFraction float64
+	minMargin      model.Resources
 	baseEstimator  ResourceEstimator
 }
 
@@ -74,7 +75,14 @@ func NewPercentileEstimator(cpuPercentile float64, memoryPercentile float64) Res
 // WithMargin returns a given ResourceEstimator with margin applied.
 // The returned resources are equal to the original resources plus (originalResource * marginFraction)
 func WithMargin(marginFraction float64, baseEstimator ResourceEstimator) ResourceEstimator {
-	return &marginEstimator{marginFraction, baseEstimator}
+	return &marginEstimator{marginFraction: marginFraction, baseEstimator: baseEstimator}
+}
+
+// WithMinMargin returns a given ResourceEstimator with margin applied, where the margin
+// of each resource is at least minMargin.
+// The returned resources are equal to the original resources plus max(originalResource * marginFraction, minMargin)
+func WithMinMargin(marginFraction float64, minMargin model.Resources, baseEstimator ResourceEstimator) ResourceEstimator {
+	return &marginEstimator{marginFraction, minMargin, baseEstimator}
 }
 
 // WithMinResources returns a given ResourceEstimator with minResources applied.
@@ -140,6 +148,9 @@ func (e *marginEstimator) GetResourceEstimation(s *model.AggregateContainerState
 	newResources := make(model.Resources)
 	for resource, resourceAmount := range originalResources {
 		margin := model.ScaleResource(resourceAmount, e.marginFraction)
+		if margin < e.minMargin[resource] {
+			margin = e.minMargin[resource]
+		}
 		newResources[resource] = originalResources[resource] + margin
 	}
 	return newResources
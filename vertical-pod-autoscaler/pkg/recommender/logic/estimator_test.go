@@ -127,6 +127,24 @@ func TestMarginEstimator(t *testing.T) {
 	assert.Equal(t, 3.14e9*1.1, model.BytesFromMemoryAmount(resourceEstimation[model.ResourceMemory]))
 }
 
+// Verifies that the MarginEstimator adds at least the minimal margin to the
+// originally estimated resources.
+func TestMarginEstimatorWithMinMargin(t *testing.T) {
+	baseEstimator := NewConstEstimator(model.Resources{
+		model.ResourceCPU:    model.CPUAmountFromCores(3.14),
+		model.ResourceMemory: model.MemoryAmountFromBytes(3.14e9),
+	})
+	minMargin := model.Resources{
+		model.ResourceCPU:    model.CPUAmountFromCores(1),
+		model.ResourceMemory: model.MemoryAmountFromBytes(1e8),
+	}
+	testedEstimator := WithMinMargin(0.1, minMargin, baseEstimator)
+	s := model.NewAggregateContainerState()
+	resourceEstimation := testedEstimator.GetResourceEstimation(s)
+	assert.Equal(t, 4.14, model.CoresFromCPUAmount(resourceEstimation[model.ResourceCPU]))
+	assert.Equal(t, 3.14e9*1.1, model.BytesFromMemoryAmount(resourceEstimation[model.ResourceMemory]))
+}
+
 // Verifies that the MinResourcesEstimator returns at least MinResources.
 func TestMinResourcesEstimator(t *testing.T) {
 
@@ -19,13 +19,17 @@ package logic
 import (
 	"flag"
 
+	apiv1 "k8s.io/api/core/v1"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 )
 
 var (
-	safetyMarginFraction = flag.Float64("recommendation-margin-fraction", 0.15, `Fraction of usage added as the safety margin to the recommended request`)
-	podMinCPUMillicores  = flag.Float64("pod-recommendation-min-cpu-millicores", 25, `Minimum CPU recommendation for a pod`)
-	podMinMemoryMb       = flag.Float64("pod-recommendation-min-memory-mb", 250, `Minimum memory recommendation for a pod`)
+	safetyMarginFraction         = flag.Float64("recommendation-margin-fraction", 0.15, `Fraction of usage added as the safety margin to the recommended request`)
+	minSafetyMarginCPUMillicores = flag.Float64("recommendation-min-margin-cpu-millicores", 0, `Minimum CPU safety margin added to the recommended request of a container`)
+	minSafetyMarginMemoryMb      = flag.Float64("recommendation-min-margin-memory-mb", 0, `Minimum memory safety margin added to the recommended request of a container`)
+	podMinCPUMillicores          = flag.Float64("pod-recommendation-min-cpu-millicores", 25, `Minimum CPU recommendation for a pod`)
+	podMinMemoryMb               = flag.Float64("pod-recommendation-min-memory-mb", 250, `Minimum memory recommendation for a pod`)
 )
 
 // PodResourceRecommender computes resource recommendation for a Vpa object.
@@ -88,6 +92,13 @@ func (r *podResourceRecommender) estimateContainerResources(s *model.AggregateCo
 
 // CreatePodResourceRecommender returns the primary recommender.
 func CreatePodResourceRecommender() PodResourceRecommender {
+	return CreatePodResourceRecommenderWithMargin(nil)
+}
+
+// CreatePodResourceRecommenderWithMargin returns the primary recommender with the safety
+// margin of a VPA. The parts of the margin that aren't set, or all of it if margin is nil,
+// are taken from the flags.
+func CreatePodResourceRecommenderWithMargin(margin *vpa_types.RecommendationMargin) PodResourceRecommender {
 	targetCPUPercentile := 0.9
 	lowerBoundCPUPercentile := 0.5
 	upperBoundCPUPercentile := 0.95
@@ -100,9 +111,10 @@ func CreatePodResourceRecommender() PodResourceRecommender {
 	lowerBoundEstimator := NewPercentileEstimator(lowerBoundCPUPercentile, lowerBoundMemoryPeaksPercentile)
 	upperBoundEstimator := NewPercentileEstimator(upperBoundCPUPercentile, upperBoundMemoryPeaksPercentile)
 
-	targetEstimator = WithMargin(*safetyMarginFraction, targetEstimator)
-	lowerBoundEstimator = WithMargin(*safetyMarginFraction, lowerBoundEstimator)
-	upperBoundEstimator = WithMargin(*safetyMarginFraction, upperBoundEstimator)
+	marginFraction, minMargin := getSafetyMargin(margin)
+	targetEstimator = WithMinMargin(marginFraction, minMargin, targetEstimator)
+	lowerBoundEstimator = WithMinMargin(marginFraction, minMargin, lowerBoundEstimator)
+	upperBoundEstimator = WithMinMargin(marginFraction, minMargin, upperBoundEstimator)
 
 	// Apply confidence multiplier to the upper bound estimator. This means
 	// that the updater will be less eager to evict pods with short history
@@ -136,3 +148,26 @@ func CreatePodResourceRecommender() PodResourceRecommender {
 		lowerBoundEstimator,
 		upperBoundEstimator}
 }
+
+// getSafetyMargin returns the fraction of usage and the minimal resources added as the safety
+// margin to the recommendations, defaulting to the flags.
+func getSafetyMargin(margin *vpa_types.RecommendationMargin) (float64, model.Resources) {
+	marginFraction := *safetyMarginFraction
+	minMargin := model.Resources{
+		model.ResourceCPU:    model.CPUAmountFromCores(*minSafetyMarginCPUMillicores * 0.001),
+		model.ResourceMemory: model.MemoryAmountFromBytes(*minSafetyMarginMemoryMb * 1024 * 1024),
+	}
+	if margin == nil {
+		return marginFraction, minMargin
+	}
+	if margin.Fraction != nil {
+		marginFraction = *margin.Fraction
+	}
+	if cpu, found := margin.Minimum[apiv1.ResourceCPU]; found {
+		minMargin[model.ResourceCPU] = model.CPUAmountFromCores(float64(cpu.MilliValue()) * 0.001)
+	}
+	if memory, found := margin.Minimum[apiv1.ResourceMemory]; found {
+		minMargin[model.ResourceMemory] = model.MemoryAmountFromBytes(float64(memory.Value()))
+	}
+	return marginFraction, minMargin
+}
@@ -20,6 +20,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 )
 
@@ -63,3 +66,28 @@ func TestMinResourcesSplitAcrossContainers(t *testing.T) {
 	assert.Equal(t, model.MemoryAmountFromBytes((*podMinMemoryMb*1024*1024)/2), recommendedResources["container-2"].Target[model.ResourceMemory])
 	assert.Equal(t, model.MemoryAmountFromBytes((*podMinMemoryMb*1024*1024)/2), recommendedResources["container-2"].Target[model.ResourceMemory])
 }
+
+func TestGetSafetyMargin(t *testing.T) {
+	defaultMinMargin := model.Resources{
+		model.ResourceCPU:    model.CPUAmountFromCores(*minSafetyMarginCPUMillicores / 1000),
+		model.ResourceMemory: model.MemoryAmountFromBytes(*minSafetyMarginMemoryMb * 1024 * 1024),
+	}
+
+	fraction, minMargin := getSafetyMargin(nil)
+	assert.Equal(t, *safetyMarginFraction, fraction)
+	assert.Equal(t, defaultMinMargin, minMargin)
+
+	vpaFraction := 0.5
+	fraction, minMargin = getSafetyMargin(&vpa_types.RecommendationMargin{Fraction: &vpaFraction})
+	assert.Equal(t, vpaFraction, fraction)
+	assert.Equal(t, defaultMinMargin, minMargin)
+
+	fraction, minMargin = getSafetyMargin(&vpa_types.RecommendationMargin{
+		Minimum: apiv1.ResourceList{
+			apiv1.ResourceCPU: resource.MustParse("200m"),
+		},
+	})
+	assert.Equal(t, *safetyMarginFraction, fraction)
+	assert.Equal(t, model.CPUAmountFromCores(0.2), minMargin[model.ResourceCPU])
+	assert.Equal(t, defaultMinMargin[model.ResourceMemory], minMargin[model.ResourceMemory])
+}
@@ -23,6 +23,7 @@ import (
 	kube_flag "k8s.io/apiserver/pkg/util/flag"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/common"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/history"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/routines"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
 	metrics_recommender "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/recommender"
@@ -40,6 +41,8 @@ var (
 	kubeApiBurst           = flag.Float64("kube-api-burst", 10.0, `QPS burst limit when making requests to Kubernetes apiserver`)
 
 	storage = flag.String("storage", "", `Specifies storage mode. Supported values: prometheus, checkpoint (default)`)
+	// checkpoint storage configs
+	checkpointFile = flag.String("checkpoint-file", "", `If set, checkpoints are stored in this gzip compressed JSON file instead of VerticalPodAutoscalerCheckpoint objects`)
 	// prometheus history provider configs
 	historyLength       = flag.String("history-length", "8d", `How much time back prometheus have to be queried to get historical metrics`)
 	podLabelPrefix      = flag.String("pod-label-prefix", "pod_label_", `Which prefix to look for pod labels in metrics`)
@@ -49,6 +52,9 @@ var (
 	ctrNamespaceLabel   = flag.String("container-namespace-label", "namespace", `Label name to look for container names`)
 	ctrPodNameLabel     = flag.String("container-pod-name-label", "pod_name", `Label name to look for container names`)
 	ctrNameLabel        = flag.String("container-name-label", "name", `Label name to look for container names`)
+
+	aggregationTimeWindows = flag.String("aggregation-time-windows", model.NoTimeWindows, `Recurring time windows whose CPU usage is aggregated separately, so that recommendations follow the usage profile. Supported values: weekly (weekdays and weekends), diurnal (quarters of the day), none`)
+	capToNodeShapes        = flag.Bool("cap-to-node-shapes", false, `If true, recommendations are capped so that pods fit the largest node in the cluster or the node shape set in the vpaMaxNodeShape annotation of the VPA`)
 )
 
 func main() {
@@ -57,12 +63,18 @@ func main() {
 
 	config := createKubeConfig(float32(*kubeApiQps), int(*kubeApiBurst))
 
+	timeWindows, err := model.NewTimeWindows(*aggregationTimeWindows)
+	if err != nil {
+		klog.Fatalf("Failed to parse aggregation time windows: %v", err)
+	}
+	model.AggregationTimeWindows = timeWindows
+
 	healthCheck := metrics.NewHealthCheck(*metricsFetcherInterval*5, true)
 	metrics.Initialize(*address, healthCheck)
 	metrics_recommender.Register()
 
 	useCheckpoints := *storage != "prometheus"
-	recommender := routines.NewRecommender(config, *checkpointsGCInterval, useCheckpoints)
+	recommender := routines.NewRecommender(config, *checkpointsGCInterval, useCheckpoints, *capToNodeShapes, *checkpointFile)
 	if useCheckpoints {
 		recommender.GetClusterStateFeeder().InitFromCheckpoints()
 	} else {
@@ -76,6 +76,10 @@ type ContainerStateAggregator interface {
 type AggregateContainerState struct {
 	// AggregateCPUUsage is a distribution of all CPU samples.
 	AggregateCPUUsage util.Histogram
+	// WindowedCPUUsage holds distributions of CPU samples from each of the
+	// AggregationTimeWindows, keyed by the window name.
+	// Note: they aren't stored in checkpoints.
+	WindowedCPUUsage map[string]util.Histogram
 	// AggregateMemoryPeaks is a distribution of memory peaks from all containers:
 	// each container should add one peak per memory aggregation interval (e.g. once every 24h).
 	AggregateMemoryPeaks util.Histogram
@@ -89,6 +93,9 @@ type AggregateContainerState struct {
 func (a *AggregateContainerState) MergeContainerState(other *AggregateContainerState) {
 	a.AggregateCPUUsage.Merge(other.AggregateCPUUsage)
 	a.AggregateMemoryPeaks.Merge(other.AggregateMemoryPeaks)
+	for window, histogram := range other.WindowedCPUUsage {
+		a.windowedCPUUsage(window).Merge(histogram)
+	}
 
 	if !other.FirstSampleStart.IsZero() && other.FirstSampleStart.Before(a.FirstSampleStart) {
 		a.FirstSampleStart = other.FirstSampleStart
@@ -141,6 +148,10 @@ func (a *AggregateContainerState) addCPUSample(sample *ContainerUsageSample) {
 	// which helps react quickly to CPU starvation.
 	a.AggregateCPUUsage.AddSample(
 		cpuUsageCores, math.Max(cpuRequestCores, minSampleWeight), sample.MeasureStart)
+	if AggregationTimeWindows != nil {
+		a.windowedCPUUsage(AggregationTimeWindows.Window(sample.MeasureStart)).AddSample(
+			cpuUsageCores, math.Max(cpuRequestCores, minSampleWeight), sample.MeasureStart)
+	}
 	if sample.MeasureStart.After(a.LastSampleStart) {
 		a.LastSampleStart = sample.MeasureStart
 	}
@@ -150,6 +161,35 @@ func (a *AggregateContainerState) addCPUSample(sample *ContainerUsageSample) {
 	a.TotalSamplesCount++
 }
 
+func (a *AggregateContainerState) windowedCPUUsage(window string) util.Histogram {
+	if a.WindowedCPUUsage == nil {
+		a.WindowedCPUUsage = make(map[string]util.Histogram)
+	}
+	histogram, found := a.WindowedCPUUsage[window]
+	if !found {
+		histogram = util.NewDecayingHistogram(CPUHistogramOptions, CPUHistogramDecayHalfLife)
+		a.WindowedCPUUsage[window] = histogram
+	}
+	return histogram
+}
+
+// ForTimeWindow returns a view of the AggregateContainerState in which the CPU
+// usage distribution is the one of the time window the given time belongs to.
+// The whole distribution is kept if there are no samples from that window
+// yet, e.g. because windows aren't restored from checkpoints.
+func (a *AggregateContainerState) ForTimeWindow(now time.Time) *AggregateContainerState {
+	if AggregationTimeWindows == nil {
+		return a
+	}
+	histogram, found := a.WindowedCPUUsage[AggregationTimeWindows.Window(now)]
+	if !found || histogram.IsEmpty() {
+		return a
+	}
+	view := *a
+	view.AggregateCPUUsage = histogram
+	return &view
+}
+
 // SaveToCheckpoint serializes AggregateContainerState as VerticalPodAutoscalerCheckpointStatus.
 // The serialization may result in loss of precission of the histograms.
 func (a *AggregateContainerState) SaveToCheckpoint() (*vpa_types.VerticalPodAutoscalerCheckpointStatus, error) {
@@ -50,6 +50,10 @@ var (
 	// CPUHistogramDecayHalfLife is the amount of time it takes a historical
 	// CPU usage sample to lose half of its weight.
 	CPUHistogramDecayHalfLife = time.Hour * 24
+	// AggregationTimeWindows splits the CPU usage history into recurring time
+	// windows that are additionally aggregated separately. If nil, the whole
+	// history is aggregated together.
+	AggregationTimeWindows TimeWindows
 )
 
 const (
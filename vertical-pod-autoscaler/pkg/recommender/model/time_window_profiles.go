@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// NoTimeWindows is the profile with a single aggregation over the whole history.
+	NoTimeWindows = "none"
+	// WeeklyTimeWindows is the profile aggregating weekdays and weekends separately.
+	WeeklyTimeWindows = "weekly"
+	// DiurnalTimeWindows is the profile aggregating each quarter of the day separately.
+	DiurnalTimeWindows = "diurnal"
+
+	diurnalWindowLength = 6 * time.Hour
+)
+
+// TimeWindows splits time into recurring windows, so that usage samples from
+// the same window (e.g. from all weekends) can be aggregated together.
+type TimeWindows interface {
+	// Window returns the name of the window the given time belongs to.
+	Window(t time.Time) string
+}
+
+// NewTimeWindows returns the TimeWindows for the given profile, which is
+// one of NoTimeWindows, WeeklyTimeWindows and DiurnalTimeWindows.
+// Returns nil for NoTimeWindows.
+func NewTimeWindows(profile string) (TimeWindows, error) {
+	switch profile {
+	case NoTimeWindows:
+		return nil, nil
+	case WeeklyTimeWindows:
+		return weeklyTimeWindows{}, nil
+	case DiurnalTimeWindows:
+		return diurnalTimeWindows{}, nil
+	default:
+		return nil, fmt.Errorf("unknown time windows profile %q", profile)
+	}
+}
+
+// weeklyTimeWindows splits the week into weekdays and weekends (in UTC).
+type weeklyTimeWindows struct{}
+
+func (weeklyTimeWindows) Window(t time.Time) string {
+	switch t.UTC().Weekday() {
+	case time.Saturday, time.Sunday:
+		return "weekend"
+	default:
+		return "weekday"
+	}
+}
+
+// diurnalTimeWindows splits the day into four windows of six hours (in UTC).
+type diurnalTimeWindows struct{}
+
+func (diurnalTimeWindows) Window(t time.Time) string {
+	start := t.UTC().Hour() / int(diurnalWindowLength.Hours()) * int(diurnalWindowLength.Hours())
+	return fmt.Sprintf("%02d-%02d", start, start+int(diurnalWindowLength.Hours()))
+}
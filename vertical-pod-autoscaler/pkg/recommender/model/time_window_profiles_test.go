@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTimeWindows(t *testing.T) {
+	windows, err := NewTimeWindows(NoTimeWindows)
+	assert.NoError(t, err)
+	assert.Nil(t, windows)
+
+	_, err = NewTimeWindows("hourly")
+	assert.Error(t, err)
+
+	// 2019-06-01 was a Saturday.
+	saturday := time.Date(2019, 6, 1, 13, 30, 0, 0, time.UTC)
+	monday := saturday.Add(48 * time.Hour)
+
+	windows, err = NewTimeWindows(WeeklyTimeWindows)
+	assert.NoError(t, err)
+	assert.Equal(t, "weekend", windows.Window(saturday))
+	assert.Equal(t, "weekday", windows.Window(monday))
+
+	windows, err = NewTimeWindows(DiurnalTimeWindows)
+	assert.NoError(t, err)
+	assert.Equal(t, "12-18", windows.Window(saturday))
+	assert.Equal(t, "18-24", windows.Window(saturday.Add(5*time.Hour)))
+	assert.Equal(t, "00-06", windows.Window(saturday.Add(11*time.Hour)))
+}
+
+func TestAggregateContainerStateForTimeWindow(t *testing.T) {
+	defer func(windows TimeWindows) { AggregationTimeWindows = windows }(AggregationTimeWindows)
+	AggregationTimeWindows, _ = NewTimeWindows(WeeklyTimeWindows)
+
+	saturday := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	monday := saturday.Add(48 * time.Hour)
+	state := NewAggregateContainerState()
+	for i := 0; i < 10; i++ {
+		state.AddSample(&ContainerUsageSample{saturday.Add(time.Duration(i) * time.Minute),
+			CPUAmountFromCores(0.1), CPUAmountFromCores(1.0), ResourceCPU})
+		state.AddSample(&ContainerUsageSample{monday.Add(time.Duration(i) * time.Minute),
+			CPUAmountFromCores(2.0), CPUAmountFromCores(1.0), ResourceCPU})
+	}
+
+	weekend := state.ForTimeWindow(saturday.Add(7 * 24 * time.Hour))
+	weekday := state.ForTimeWindow(monday.Add(7 * 24 * time.Hour))
+	assert.True(t, weekend.AggregateCPUUsage.Percentile(0.9) < 0.2)
+	assert.True(t, weekday.AggregateCPUUsage.Percentile(0.9) > 1.8)
+	// The rest of the state isn't split into windows.
+	assert.Equal(t, 20, weekend.TotalSamplesCount)
+
+	// Windows are merged together with the rest of the state.
+	merged := NewAggregateContainerState()
+	merged.MergeContainerState(state)
+	assert.True(t, merged.WindowedCPUUsage["weekend"].Equals(state.WindowedCPUUsage["weekend"]))
+
+	// Without samples from the window the whole history is used.
+	empty := NewAggregateContainerState()
+	empty.AggregateCPUUsage.Merge(state.AggregateCPUUsage)
+	assert.Equal(t, empty, empty.ForTimeWindow(saturday))
+}
@@ -83,6 +83,8 @@ type Vpa struct {
 	aggregateContainerStates aggregateContainerStatesMap
 	// Pod Resource Policy provided in the VPA API object. Can be nil.
 	ResourcePolicy *vpa_types.PodResourcePolicy
+	// Safety margin provided in the VPA API object. Can be nil.
+	RecommendationMargin *vpa_types.RecommendationMargin
 	// Initial checkpoints of AggregateContainerStates for containers.
 	// The key is container name.
 	ContainersInitialAggregateState ContainerNameToAggregateStateMap
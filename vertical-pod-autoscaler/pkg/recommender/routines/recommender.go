@@ -21,8 +21,13 @@ import (
 	"flag"
 	"time"
 
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
 	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
+	vpa_scheme "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/scheme"
 	vpa_api "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/typed/autoscaling.k8s.io/v1beta2"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/checkpoint"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input"
@@ -30,13 +35,21 @@ import (
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 	metrics_recommender "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/recommender"
 	vpa_utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+	kube_client "k8s.io/client-go/kubernetes"
+	clientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 )
 
 const (
 	// AggregateContainerStateGCInterval defines how often expired AggregateContainerStates are garbage collected.
 	AggregateContainerStateGCInterval = 1 * time.Hour
+	// MaxNodeShapeAnnotation is the VPA annotation overriding the node shape recommendations are capped to,
+	// in the format cpu=<quantity>,memory=<quantity>.
+	MaxNodeShapeAnnotation = "vpaMaxNodeShape"
 )
 
 var (
@@ -72,6 +85,8 @@ type recommender struct {
 	podResourceRecommender        logic.PodResourceRecommender
 	useCheckpoints                bool
 	lastAggregateContainerStateGC time.Time
+	nodeLister                    v1lister.NodeLister
+	eventRecorder                 record.EventRecorder
 }
 
 func (r *recommender) GetClusterState() *model.ClusterState {
@@ -87,6 +102,16 @@ func (r *recommender) UpdateVPAs() {
 	cnt := metrics_recommender.NewObjectCounter()
 	defer cnt.Observe()
 
+	var maxNodeShape apiv1.ResourceList
+	if r.nodeLister != nil {
+		nodes, err := r.nodeLister.List(labels.Everything())
+		if err != nil {
+			klog.Errorf("Cannot list nodes to cap recommendations. Reason: %+v", err)
+		} else {
+			maxNodeShape = vpa_utils.GetMaxNodeShape(nodes)
+		}
+	}
+
 	for _, observedVpa := range r.clusterState.ObservedVpas {
 		key := model.VpaID{
 			Namespace: observedVpa.Namespace,
@@ -96,9 +121,16 @@ func (r *recommender) UpdateVPAs() {
 		if !found {
 			continue
 		}
-		resources := r.podResourceRecommender.GetRecommendedPodResources(GetContainerNameToAggregateStateMap(vpa))
+		podResourceRecommender := r.podResourceRecommender
+		if vpa.RecommendationMargin != nil {
+			podResourceRecommender = logic.CreatePodResourceRecommenderWithMargin(vpa.RecommendationMargin)
+		}
+		resources := podResourceRecommender.GetRecommendedPodResources(GetContainerNameToAggregateStateMap(vpa))
 		had := vpa.HasRecommendation()
 		vpa.Recommendation = getCappedRecommendation(vpa.ID, resources, observedVpa.Spec.ResourcePolicy)
+		if maxNodeShape != nil {
+			vpa.Recommendation = r.capToNodeShape(observedVpa, vpa.Recommendation, maxNodeShape)
+		}
 		// Set RecommendationProvided if recommendation not empty.
 		if len(vpa.Recommendation.ContainerRecommendations) > 0 {
 			vpa.Conditions.Set(vpa_types.RecommendationProvided, true, "", "")
@@ -144,6 +176,28 @@ func getCappedRecommendation(vpaID model.VpaID, resources logic.RecommendedPodRe
 	return cappedRecommendation
 }
 
+// capToNodeShape caps the recommendation so that the pod fits the node shape, which is the largest node
+// in the cluster unless overridden with an annotation on the VPA. An event is recorded if the
+// recommendation doesn't fit.
+func (r *recommender) capToNodeShape(vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources,
+	maxNodeShape apiv1.ResourceList) *vpa_types.RecommendedPodResources {
+	shape := maxNodeShape
+	if value, found := vpa.Annotations[MaxNodeShapeAnnotation]; found {
+		parsed, err := vpa_utils.ParseNodeShape(value)
+		if err != nil {
+			klog.Warningf("Ignoring node shape of VPA %v/%v: %v", vpa.Namespace, vpa.Name, err)
+		} else {
+			shape = parsed
+		}
+	}
+	capped, exceeded := vpa_utils.CapToNodeShape(recommendation, shape)
+	if len(exceeded) > 0 {
+		r.eventRecorder.Eventf(vpa, apiv1.EventTypeWarning, "RecommendationCappedToNodeShape",
+			"Recommended %v exceeds the node shape and was capped", exceeded)
+	}
+	return capped
+}
+
 func (r *recommender) MaintainCheckpoints(ctx context.Context, minCheckpointsPerRun int) {
 	now := time.Now()
 	if r.useCheckpoints {
@@ -205,6 +259,10 @@ type RecommenderFactory struct {
 
 	CheckpointsGCInterval time.Duration
 	UseCheckpoints        bool
+
+	// NodeLister is used to cap recommendations to node shapes. Capping is disabled if it's nil.
+	NodeLister    v1lister.NodeLister
+	EventRecorder record.EventRecorder
 }
 
 // Make creates a new recommender instance,
@@ -220,23 +278,53 @@ func (c RecommenderFactory) Make() Recommender {
 		podResourceRecommender:        c.PodResourceRecommender,
 		lastAggregateContainerStateGC: time.Now(),
 		lastCheckpointGC:              time.Now(),
+		nodeLister:                    c.NodeLister,
+		eventRecorder:                 c.EventRecorder,
 	}
 	klog.V(3).Infof("New Recommender created %+v", recommender)
 	return recommender
 }
 
 // NewRecommender creates a new recommender instance.
-// Dependencies are created automatically.
+// Dependencies are created automatically. Checkpoints are stored in checkpointFile instead of
+// VerticalPodAutoscalerCheckpoint objects if it is set.
 // Deprecated; use RecommenderFactory instead.
-func NewRecommender(config *rest.Config, checkpointsGCInterval time.Duration, useCheckpoints bool) Recommender {
+func NewRecommender(config *rest.Config, checkpointsGCInterval time.Duration, useCheckpoints bool, capToNodeShapes bool, checkpointFile string) Recommender {
 	clusterState := model.NewClusterState()
-	return RecommenderFactory{
+	factory := RecommenderFactory{
 		ClusterState:           clusterState,
-		ClusterStateFeeder:     input.NewClusterStateFeeder(config, clusterState),
+		ClusterStateFeeder:     input.NewClusterStateFeeder(config, clusterState, checkpointFile),
 		CheckpointWriter:       checkpoint.NewCheckpointWriter(clusterState, vpa_clientset.NewForConfigOrDie(config).AutoscalingV1beta2()),
 		VpaClient:              vpa_clientset.NewForConfigOrDie(config).AutoscalingV1beta2(),
 		PodResourceRecommender: logic.CreatePodResourceRecommender(),
 		CheckpointsGCInterval:  checkpointsGCInterval,
 		UseCheckpoints:         useCheckpoints,
-	}.Make()
+	}
+	if checkpointFile != "" {
+		factory.CheckpointWriter = checkpoint.NewFileCheckpointWriter(clusterState, checkpointFile)
+	}
+	if capToNodeShapes {
+		kubeClient := kube_client.NewForConfigOrDie(config)
+		factory.NodeLister = newNodeLister(kubeClient)
+		factory.EventRecorder = newEventRecorder(kubeClient)
+	}
+	return factory.Make()
+}
+
+func newNodeLister(kubeClient kube_client.Interface) v1lister.NodeLister {
+	nodeListWatch := cache.NewListWatchFromClient(kubeClient.CoreV1().RESTClient(), "nodes", apiv1.NamespaceAll, fields.Everything())
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	nodeLister := v1lister.NewNodeLister(store)
+	nodeReflector := cache.NewReflector(nodeListWatch, &apiv1.Node{}, store, time.Hour)
+	stopCh := make(chan struct{})
+	go nodeReflector.Run(stopCh)
+
+	return nodeLister
+}
+
+func newEventRecorder(kubeClient kube_client.Interface) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.V(4).Infof)
+	eventBroadcaster.StartRecordingToSink(&clientv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	return eventBroadcaster.NewRecorder(vpa_scheme.Scheme, apiv1.EventSource{Component: "vpa-recommender"})
 }
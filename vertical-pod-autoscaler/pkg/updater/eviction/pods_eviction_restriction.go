@@ -24,6 +24,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
@@ -73,6 +74,7 @@ type podsEvictionRestrictionFactoryImpl struct {
 	rcInformer                cache.SharedIndexInformer // informer for Replication Controllers
 	ssInformer                cache.SharedIndexInformer // informer for Stateful Sets
 	rsInformer                cache.SharedIndexInformer // informer for Replica Sets
+	dInformer                 cache.SharedIndexInformer // informer for Deployments, nil if rollout state isn't respected
 	minReplicas               int
 	evictionToleranceFraction float64
 }
@@ -84,6 +86,7 @@ const (
 	statefulSet           controllerKind = "StatefulSet"
 	replicaSet            controllerKind = "ReplicaSet"
 	job                   controllerKind = "Job"
+	deployment            controllerKind = "Deployment"
 )
 
 type podReplicaCreator struct {
@@ -155,9 +158,10 @@ func (e *podsEvictionRestrictionImpl) Evict(podToEvict *apiv1.Pod, eventRecorder
 	return nil
 }
 
-// NewPodsEvictionRestrictionFactory creates PodsEvictionRestrictionFactory
+// NewPodsEvictionRestrictionFactory creates PodsEvictionRestrictionFactory. If respectRolloutState is set,
+// pods of Deployments and StatefulSets that are mid-rollout or have too many unavailable replicas aren't evicted.
 func NewPodsEvictionRestrictionFactory(client kube_client.Interface, minReplicas int,
-	evictionToleranceFraction float64) (PodsEvictionRestrictionFactory, error) {
+	evictionToleranceFraction float64, respectRolloutState bool) (PodsEvictionRestrictionFactory, error) {
 	rcInformer, err := setUpInformer(client, replicationController)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create rcInformer: %v", err)
@@ -170,11 +174,19 @@ func NewPodsEvictionRestrictionFactory(client kube_client.Interface, minReplicas
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create rsInformer: %v", err)
 	}
+	var dInformer cache.SharedIndexInformer
+	if respectRolloutState {
+		dInformer, err = setUpInformer(client, deployment)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create dInformer: %v", err)
+		}
+	}
 	return &podsEvictionRestrictionFactoryImpl{
 		client:                    client,
 		rcInformer:                rcInformer, // informer for Replication Controllers
 		ssInformer:                ssInformer, // informer for Replica Sets
 		rsInformer:                rsInformer, // informer for Stateful Sets
+		dInformer:                 dInformer,  // informer for Deployments
 		minReplicas:               minReplicas,
 		evictionToleranceFraction: evictionToleranceFraction}, nil
 }
@@ -225,6 +237,20 @@ func (f *podsEvictionRestrictionFactoryImpl) NewPodsEvictionRestriction(pods []*
 			}
 		}
 
+		if f.dInformer != nil {
+			inProgress, err := f.isRolloutInProgress(creator)
+			if err != nil {
+				klog.Errorf("failed to obtain rollout state for %v %v/%v. %v",
+					creator.Kind, creator.Namespace, creator.Name, err)
+				continue
+			}
+			if inProgress {
+				klog.V(2).Infof("rollout in progress for %v %v/%v, deferring evictions",
+					creator.Kind, creator.Namespace, creator.Name)
+				continue
+			}
+		}
+
 		singleGroup := singleGroupStats{}
 		singleGroup.configured = configured
 		singleGroup.evictionTolerance = int(float64(configured) * f.evictionToleranceFraction)
@@ -320,6 +346,86 @@ func (f *podsEvictionRestrictionFactoryImpl) getReplicaCount(creator podReplicaC
 	return 0, nil
 }
 
+// isRolloutInProgress tells whether the Deployment owning a replica set, or a stateful set, is rolling out
+// a new version or has more unavailable replicas than its update strategy allows.
+func (f *podsEvictionRestrictionFactoryImpl) isRolloutInProgress(creator podReplicaCreator) (bool, error) {
+	switch creator.Kind {
+	case replicaSet:
+		rsObj, exists, err := f.rsInformer.GetStore().GetByKey(creator.Namespace + "/" + creator.Name)
+		if err != nil || !exists {
+			return false, err
+		}
+		rs, ok := rsObj.(*appsv1.ReplicaSet)
+		if !ok {
+			return false, fmt.Errorf("Failed to parse Replicaset")
+		}
+		owner := metav1.GetControllerOf(rs)
+		if owner == nil || controllerKind(owner.Kind) != deployment {
+			return false, nil
+		}
+		dObj, exists, err := f.dInformer.GetStore().GetByKey(creator.Namespace + "/" + owner.Name)
+		if err != nil || !exists {
+			return false, err
+		}
+		d, ok := dObj.(*appsv1.Deployment)
+		if !ok {
+			return false, fmt.Errorf("Failed to parse Deployment")
+		}
+		return isDeploymentRolloutInProgress(d), nil
+
+	case statefulSet:
+		ssObj, exists, err := f.ssInformer.GetStore().GetByKey(creator.Namespace + "/" + creator.Name)
+		if err != nil || !exists {
+			return false, err
+		}
+		ss, ok := ssObj.(*appsv1.StatefulSet)
+		if !ok {
+			return false, fmt.Errorf("Failed to parse StatefulSet")
+		}
+		return isStatefulSetRolloutInProgress(ss), nil
+	}
+
+	return false, nil
+}
+
+func isDeploymentRolloutInProgress(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.ObservedGeneration < d.Generation ||
+		d.Status.UpdatedReplicas < replicas ||
+		d.Status.Replicas > d.Status.UpdatedReplicas {
+		return true
+	}
+	maxUnavailable := 0
+	if d.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType &&
+		d.Spec.Strategy.RollingUpdate != nil && d.Spec.Strategy.RollingUpdate.MaxUnavailable != nil {
+		value, err := intstr.GetValueFromIntOrPercent(d.Spec.Strategy.RollingUpdate.MaxUnavailable, int(replicas), false)
+		if err == nil {
+			maxUnavailable = value
+		}
+	}
+	return int(d.Status.UnavailableReplicas) > maxUnavailable
+}
+
+func isStatefulSetRolloutInProgress(ss *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if ss.Spec.Replicas != nil {
+		replicas = *ss.Spec.Replicas
+	}
+	if ss.Status.ObservedGeneration < ss.Generation {
+		return true
+	}
+	// With OnDelete updates the new revision is only rolled out when pods are deleted, VPA evictions included.
+	if ss.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType &&
+		ss.Status.UpdateRevision != "" && ss.Status.CurrentRevision != ss.Status.UpdateRevision {
+		return true
+	}
+	// Stateful sets don't tolerate unavailable replicas during updates.
+	return ss.Status.ReadyReplicas < replicas
+}
+
 func managingControllerRef(pod *apiv1.Pod) *metav1.OwnerReference {
 	var managingController metav1.OwnerReference
 	for _, ownerReference := range pod.ObjectMeta.GetOwnerReferences() {
@@ -343,6 +449,9 @@ func setUpInformer(kubeClient kube_client.Interface, kind controllerKind) (cache
 	case statefulSet:
 		informer = appsinformer.NewStatefulSetInformer(kubeClient, apiv1.NamespaceAll,
 			resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	case deployment:
+		informer = appsinformer.NewDeploymentInformer(kubeClient, apiv1.NamespaceAll,
+			resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 	default:
 		return nil, fmt.Errorf("Unknown controller kind: %v", kind)
 	}
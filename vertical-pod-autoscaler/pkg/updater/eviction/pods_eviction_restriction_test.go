@@ -26,6 +26,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
@@ -478,6 +479,170 @@ func TestEvictAtLeastOne(t *testing.T) {
 	}
 }
 
+func TestEvictionDeferredDuringDeploymentRollout(t *testing.T) {
+	replicas := int32(4)
+	maxUnavailable := intstr.FromInt(1)
+
+	d := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "d",
+			Namespace:  "default",
+			Generation: 2,
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Deployment",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+			},
+		},
+	}
+	rs := appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rs",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicaSet",
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+		},
+	}
+	controller := true
+	rs.OwnerReferences = []metav1.OwnerReference{{Kind: "Deployment", Name: "d", Controller: &controller}}
+
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rs.ObjectMeta, &rs.TypeMeta).Get()
+	}
+
+	testCases := []struct {
+		name     string
+		status   appsv1.DeploymentStatus
+		canEvict bool
+	}{
+		{
+			name:     "rolled out",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 4},
+			canEvict: true,
+		},
+		{
+			name:     "unavailable replicas within strategy",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 4, UnavailableReplicas: 1},
+			canEvict: true,
+		},
+		{
+			name:     "unavailable replicas beyond strategy",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 4, UnavailableReplicas: 2},
+			canEvict: false,
+		},
+		{
+			name:     "new generation not observed",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 4},
+			canEvict: false,
+		},
+		{
+			name:     "old replicas still running",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 5, UpdatedReplicas: 2},
+			canEvict: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := d.DeepCopy()
+			deployment.Status = tc.status
+			factory, err := getEvictionRestrictionFactory(nil, &rs, nil, 2, 0.5)
+			assert.NoError(t, err)
+			addDeploymentInformer(t, factory, deployment)
+
+			eviction := factory.NewPodsEvictionRestriction(pods)
+			for _, pod := range pods {
+				assert.Equal(t, tc.canEvict, eviction.CanEvict(pod))
+			}
+		})
+	}
+}
+
+func TestEvictionDeferredDuringStatefulSetRollout(t *testing.T) {
+	replicas := int32(3)
+
+	ss := appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ss",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "StatefulSet",
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+		},
+	}
+
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&ss.ObjectMeta, &ss.TypeMeta).Get()
+	}
+
+	testCases := []struct {
+		name           string
+		updateStrategy appsv1.StatefulSetUpdateStrategyType
+		status         appsv1.StatefulSetStatus
+		canEvict       bool
+	}{
+		{
+			name:     "rolled out",
+			status:   appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentRevision: "r1", UpdateRevision: "r1"},
+			canEvict: true,
+		},
+		{
+			name:     "unready replicas",
+			status:   appsv1.StatefulSetStatus{ReadyReplicas: 2, CurrentRevision: "r1", UpdateRevision: "r1"},
+			canEvict: false,
+		},
+		{
+			name:     "rolling update in progress",
+			status:   appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentRevision: "r1", UpdateRevision: "r2"},
+			canEvict: false,
+		},
+		{
+			name:           "on delete update pending",
+			updateStrategy: appsv1.OnDeleteStatefulSetStrategyType,
+			status:         appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentRevision: "r1", UpdateRevision: "r2"},
+			canEvict:       true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statefulSet := ss.DeepCopy()
+			statefulSet.Spec.UpdateStrategy.Type = tc.updateStrategy
+			statefulSet.Status = tc.status
+			factory, err := getEvictionRestrictionFactory(nil, nil, statefulSet, 2, 0.5)
+			assert.NoError(t, err)
+			addDeploymentInformer(t, factory)
+
+			eviction := factory.NewPodsEvictionRestriction(pods)
+			for _, pod := range pods {
+				assert.Equal(t, tc.canEvict, eviction.CanEvict(pod))
+			}
+		})
+	}
+}
+
+// addDeploymentInformer makes the factory respect the rollout state, with the given deployments.
+func addDeploymentInformer(t *testing.T, factory PodsEvictionRestrictionFactory, deployments ...*appsv1.Deployment) {
+	dInformer := appsinformer.NewDeploymentInformer(&fake.Clientset{}, apiv1.NamespaceAll,
+		0*time.Second, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, d := range deployments {
+		assert.NoError(t, dInformer.GetIndexer().Add(d))
+	}
+	factory.(*podsEvictionRestrictionFactoryImpl).dInformer = dInformer
+}
+
 func getEvictionRestrictionFactory(rc *apiv1.ReplicationController, rs *appsv1.ReplicaSet,
 	ss *appsv1.StatefulSet, minReplicas int,
 	evictionToleranceFraction float64) (PodsEvictionRestrictionFactory, error) {
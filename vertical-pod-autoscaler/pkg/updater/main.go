@@ -26,6 +26,7 @@ import (
 	"k8s.io/autoscaler/vertical-pod-autoscaler/common"
 	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
 	updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/logic"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
 	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
 	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
@@ -45,6 +46,18 @@ var (
 	evictionToleranceFraction = flag.Float64("eviction-tolerance", 0.5,
 		`Fraction of replica count that can be evicted for update, if more than one pod can be evicted.`)
 
+	respectRolloutState = flag.Bool("respect-rollout-state", false,
+		`Whether pods of Deployments and StatefulSets that are mid-rollout or have more unavailable replicas than their update strategy allows should not be evicted.`)
+
+	provisionHeadroom = flag.Bool("provision-headroom", false,
+		`Whether pods should only be evicted to grow once a placeholder pod with the recommended requests is scheduled, so that cluster-autoscaler makes room for them before eviction.`)
+
+	headroomPriorityClass = flag.String("headroom-priority-class", "",
+		`Priority class of the placeholder pods. It should be above the expendable pods priority cutoff of cluster-autoscaler.`)
+
+	headroomTimeout = flag.Duration("headroom-timeout", 10*time.Minute,
+		`How long to wait for a placeholder pod to be scheduled before evicting the pod anyway.`)
+
 	address = flag.String("address", ":8943", "The address to expose Prometheus metrics.")
 )
 
@@ -71,8 +84,12 @@ func main() {
 		target.NewVpaTargetSelectorFetcher(config, kubeClient, factory),
 		target.NewBeta1TargetSelectorFetcher(config),
 	)
+	var evictionAdmission priority.PodEvictionAdmission
+	if *provisionHeadroom {
+		evictionAdmission = priority.NewHeadroomPodEvictionAdmission(kubeClient, *headroomPriorityClass, *headroomTimeout)
+	}
 	// TODO: use SharedInformerFactory in updater
-	updater, err := updater.NewUpdater(kubeClient, vpaClient, *minReplicas, *evictionToleranceFraction, vpa_api_util.NewCappingRecommendationProcessor(), nil, targetSelectorFetcher)
+	updater, err := updater.NewUpdater(kubeClient, vpaClient, *minReplicas, *evictionToleranceFraction, *respectRolloutState, vpa_api_util.NewCappingRecommendationProcessor(), evictionAdmission, targetSelectorFetcher)
 	if err != nil {
 		klog.Fatalf("Failed to create updater: %v", err)
 	}
@@ -0,0 +1,229 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const (
+	// HeadroomPodLabel is the label of the placeholder pods reserving room
+	// for the pods to be resized by the updater.
+	HeadroomPodLabel = "vpa-headroom"
+	// HeadroomPodNamePrefix is the prefix of the name of the placeholder pod
+	// of a pod to be resized.
+	HeadroomPodNamePrefix = "vpa-headroom-"
+
+	headroomPodImage = "k8s.gcr.io/pause:3.1"
+)
+
+// NewHeadroomPodEvictionAdmission constructs PodEvictionAdmission that only
+// admits pods whose recommended requests exceed their current ones once there
+// is room for them in the cluster. Room is reserved with a placeholder pod
+// requesting the recommended resources, which cluster-autoscaler scales the
+// cluster up for if it can't be scheduled. The placeholder is removed once it's
+// scheduled, right before the pod is evicted, or after timeout if the cluster
+// can't make room for it, in which case the pod is admitted anyway. The timeout
+// runs from the first time the pod wasn't admitted, even if its placeholder is
+// removed and recreated in between.
+func NewHeadroomPodEvictionAdmission(kubeClient kube_client.Interface, priorityClassName string, timeout time.Duration) PodEvictionAdmission {
+	return &headroomPodEvictionAdmission{
+		kubeClient:        kubeClient,
+		priorityClassName: priorityClassName,
+		timeout:           timeout,
+		deferredSince:     make(map[types.UID]time.Time),
+		now:               time.Now,
+	}
+}
+
+type headroomPodEvictionAdmission struct {
+	kubeClient        kube_client.Interface
+	priorityClassName string
+	timeout           time.Duration
+	// deferredSince is when each pod waiting for room was first not admitted.
+	deferredSince map[types.UID]time.Time
+	now           func() time.Time
+}
+
+// LoopInit removes the placeholder pods that timed out and forgets the pods
+// that are gone.
+func (a *headroomPodEvictionAdmission) LoopInit(allLivePods []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	livePods := make(map[types.UID]bool, len(allLivePods))
+	for _, pod := range allLivePods {
+		livePods[pod.UID] = true
+	}
+	for uid := range a.deferredSince {
+		if !livePods[uid] {
+			delete(a.deferredSince, uid)
+		}
+	}
+	a.removeExpiredHeadroomPods()
+}
+
+func (a *headroomPodEvictionAdmission) Admit(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) bool {
+	requests, needsRoom := recommendedPodRequests(pod, recommendation)
+	if !needsRoom {
+		return true
+	}
+
+	name := HeadroomPodNamePrefix + pod.Name
+	headroomPod, err := a.kubeClient.CoreV1().Pods(pod.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to get headroom pod of pod %v: %v", pod.Name, err)
+		a.admitted(pod)
+		return true
+	}
+
+	deferredSince, found := a.deferredSince[pod.UID]
+	if !found {
+		// A placeholder left by a previous updater started the wait.
+		deferredSince = a.now()
+		if err == nil && !headroomPod.CreationTimestamp.IsZero() {
+			deferredSince = headroomPod.CreationTimestamp.Time
+		}
+		a.deferredSince[pod.UID] = deferredSince
+	}
+	if a.now().Sub(deferredSince) > a.timeout {
+		klog.V(2).Infof("no room for pod %v after %v, evicting it anyway", pod.Name, a.timeout)
+		if err == nil {
+			a.deleteHeadroomPod(headroomPod)
+		}
+		a.admitted(pod)
+		return true
+	}
+
+	if errors.IsNotFound(err) {
+		if _, err := a.kubeClient.CoreV1().Pods(pod.Namespace).Create(a.buildHeadroomPod(name, pod, requests)); err != nil {
+			klog.Errorf("failed to create headroom pod for pod %v: %v", pod.Name, err)
+			a.admitted(pod)
+			return true
+		}
+		klog.V(2).Infof("created headroom pod for pod %v, waiting for room before evicting it", pod.Name)
+		return false
+	}
+
+	if headroomPod.Spec.NodeName == "" && !a.expired(headroomPod) {
+		klog.V(4).Infof("headroom pod of pod %v not scheduled yet", pod.Name)
+		return false
+	}
+	// The pod takes the place of its placeholder once evicted.
+	a.deleteHeadroomPod(headroomPod)
+	a.admitted(pod)
+	return true
+}
+
+// admitted forgets the wait of the admitted pod.
+func (a *headroomPodEvictionAdmission) admitted(pod *apiv1.Pod) {
+	delete(a.deferredSince, pod.UID)
+}
+
+// CleanUp removes all placeholder pods.
+func (a *headroomPodEvictionAdmission) CleanUp() {
+	for _, pod := range a.listHeadroomPods() {
+		a.deleteHeadroomPod(pod)
+	}
+}
+
+func (a *headroomPodEvictionAdmission) removeExpiredHeadroomPods() {
+	for _, pod := range a.listHeadroomPods() {
+		if a.expired(pod) {
+			a.deleteHeadroomPod(pod)
+		}
+	}
+}
+
+func (a *headroomPodEvictionAdmission) listHeadroomPods() []*apiv1.Pod {
+	podList, err := a.kubeClient.CoreV1().Pods(apiv1.NamespaceAll).List(metav1.ListOptions{LabelSelector: HeadroomPodLabel})
+	if err != nil {
+		klog.Errorf("failed to list headroom pods: %v", err)
+		return nil
+	}
+	pods := make([]*apiv1.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		pods = append(pods, &podList.Items[i])
+	}
+	return pods
+}
+
+func (a *headroomPodEvictionAdmission) expired(pod *apiv1.Pod) bool {
+	return a.now().Sub(pod.CreationTimestamp.Time) > a.timeout
+}
+
+func (a *headroomPodEvictionAdmission) deleteHeadroomPod(pod *apiv1.Pod) {
+	err := a.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to delete headroom pod %v: %v", pod.Name, err)
+	}
+}
+
+func (a *headroomPodEvictionAdmission) buildHeadroomPod(name string, pod *apiv1.Pod, requests apiv1.ResourceList) *apiv1.Pod {
+	gracePeriod := int64(0)
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pod.Namespace,
+			Labels:    map[string]string{HeadroomPodLabel: "true"},
+		},
+		Spec: apiv1.PodSpec{
+			Containers: []apiv1.Container{{
+				Name:      "headroom",
+				Image:     headroomPodImage,
+				Resources: apiv1.ResourceRequirements{Requests: requests},
+			}},
+			NodeSelector:                  pod.Spec.NodeSelector,
+			Affinity:                      pod.Spec.Affinity,
+			Tolerations:                   pod.Spec.Tolerations,
+			PriorityClassName:             a.priorityClassName,
+			TerminationGracePeriodSeconds: &gracePeriod,
+		},
+	}
+}
+
+// recommendedPodRequests returns the total requests of the pod with the
+// recommendation applied, and whether they exceed its current requests.
+func recommendedPodRequests(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) (apiv1.ResourceList, bool) {
+	requests := apiv1.ResourceList{}
+	needsRoom := false
+	for _, resourceName := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+		current, recommended := resource.Quantity{}, resource.Quantity{}
+		for _, container := range pod.Spec.Containers {
+			request := container.Resources.Requests[resourceName]
+			current.Add(request)
+			if containerRecommendation := vpa_api_util.GetRecommendationForContainer(container.Name, recommendation); containerRecommendation != nil {
+				if target, found := containerRecommendation.Target[resourceName]; found {
+					request = target
+				}
+			}
+			recommended.Add(request)
+		}
+		requests[resourceName] = recommended
+		if recommended.Cmp(current) > 0 {
+			needsRoom = true
+		}
+	}
+	return requests, needsRoom
+}
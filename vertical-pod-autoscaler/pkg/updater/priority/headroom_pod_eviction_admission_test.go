@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHeadroomPodEvictionAdmission(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	admission := NewHeadroomPodEvictionAdmission(kubeClient, "headroom", time.Hour)
+
+	pod := test.Pod().WithName("POD1").AddContainer(test.BuildTestContainer(containerName, "1", "10M")).Get()
+	pod.Namespace = "default"
+	pod.Spec.NodeSelector = map[string]string{"pool": "large"}
+	pods := kubeClient.CoreV1().Pods("default")
+
+	// Pods that don't grow don't need room.
+	shrinking := test.Recommendation().WithContainer(containerName).WithTarget("1", "5M").Get()
+	assert.True(t, admission.Admit(pod, shrinking))
+	podList, _ := pods.List(metav1.ListOptions{})
+	assert.Empty(t, podList.Items)
+
+	// A placeholder is created for growing pods, which aren't admitted until
+	// it's scheduled.
+	growing := test.Recommendation().WithContainer(containerName).WithTarget("2", "10M").Get()
+	assert.False(t, admission.Admit(pod, growing))
+	headroomPod, err := pods.Get(HeadroomPodNamePrefix+"POD1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	cpu := headroomPod.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU]
+	assert.Equal(t, int64(2000), cpu.MilliValue())
+	assert.Equal(t, "headroom", headroomPod.Spec.PriorityClassName)
+	assert.Equal(t, pod.Spec.NodeSelector, headroomPod.Spec.NodeSelector)
+
+	headroomPod.CreationTimestamp = metav1.Now()
+	pods.Update(headroomPod)
+	assert.False(t, admission.Admit(pod, growing))
+
+	// The placeholder makes room for the pod once it's scheduled.
+	headroomPod.Spec.NodeName = "node1"
+	pods.Update(headroomPod)
+	assert.True(t, admission.Admit(pod, growing))
+	_, err = pods.Get(HeadroomPodNamePrefix+"POD1", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestHeadroomPodEvictionAdmissionTimeout(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	admission := NewHeadroomPodEvictionAdmission(kubeClient, "", time.Hour)
+
+	pod := test.Pod().WithName("POD1").AddContainer(test.BuildTestContainer(containerName, "1", "10M")).Get()
+	pod.Namespace = "default"
+	growing := test.Recommendation().WithContainer(containerName).WithTarget("2", "20M").Get()
+	assert.False(t, admission.Admit(pod, growing))
+
+	// Pods are admitted once their placeholder times out.
+	pods := kubeClient.CoreV1().Pods("default")
+	headroomPod, _ := pods.Get(HeadroomPodNamePrefix+"POD1", metav1.GetOptions{})
+	headroomPod.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	pods.Update(headroomPod)
+	assert.True(t, admission.Admit(pod, growing))
+
+	// Expired placeholders are removed in the next loop.
+	assert.False(t, admission.Admit(pod, growing))
+	headroomPod, _ = pods.Get(HeadroomPodNamePrefix+"POD1", metav1.GetOptions{})
+	headroomPod.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	pods.Update(headroomPod)
+	admission.LoopInit(nil, nil)
+	podList, _ := pods.List(metav1.ListOptions{})
+	assert.Empty(t, podList.Items)
+}
+
+func TestHeadroomPodEvictionAdmissionTimeoutAcrossLoops(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	admission := NewHeadroomPodEvictionAdmission(kubeClient, "", time.Hour).(*headroomPodEvictionAdmission)
+	now := time.Now()
+	admission.now = func() time.Time { return now }
+
+	pod := test.Pod().WithName("POD1").AddContainer(test.BuildTestContainer(containerName, "1", "10M")).Get()
+	pod.Namespace = "default"
+	pod.UID = "pod1-uid"
+	growing := test.Recommendation().WithContainer(containerName).WithTarget("2", "20M").Get()
+	pods := kubeClient.CoreV1().Pods("default")
+
+	// The placeholder never gets scheduled, and LoopInit removes it as expired
+	// every few loops, but the pod is admitted once it waited for the timeout.
+	for i := 0; i < 4; i++ {
+		admission.LoopInit([]*apiv1.Pod{pod}, nil)
+		assert.False(t, admission.Admit(pod, growing), "loop %d", i)
+		headroomPod, err := pods.Get(HeadroomPodNamePrefix+"POD1", metav1.GetOptions{})
+		assert.NoError(t, err)
+		headroomPod.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+		pods.Update(headroomPod)
+		now = now.Add(15 * time.Minute)
+	}
+	now = now.Add(15 * time.Minute)
+	admission.LoopInit([]*apiv1.Pod{pod}, nil)
+	assert.True(t, admission.Admit(pod, growing))
+	podList, _ := pods.List(metav1.ListOptions{})
+	assert.Empty(t, podList.Items)
+
+	// The wait of a pod that's gone is forgotten.
+	assert.False(t, admission.Admit(pod, growing))
+	admission.LoopInit(nil, nil)
+	assert.Empty(t, admission.deferredSince)
+}
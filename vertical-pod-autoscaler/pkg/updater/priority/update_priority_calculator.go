@@ -60,6 +60,21 @@ type UpdateConfig struct {
 	// MinChangePriority is the minimum change priority that will trigger a update.
 	// TODO: should have separate for Mem and CPU?
 	MinChangePriority float64
+	// MinChangeOutsideRecommendedRange makes MinChangePriority apply to pods whose
+	// requests are outside the recommended range too.
+	MinChangeOutsideRecommendedRange bool
+}
+
+// NewUpdateConfig returns the UpdateConfig of the update policy of a VPA, or nil
+// if the policy doesn't set a minimal change.
+func NewUpdateConfig(policy *vpa_types.PodUpdatePolicy) *UpdateConfig {
+	if policy == nil || policy.MinChangePercent == nil {
+		return nil
+	}
+	return &UpdateConfig{
+		MinChangePriority:                float64(*policy.MinChangePercent) / 100,
+		MinChangeOutsideRecommendedRange: true,
+	}
 }
 
 // NewUpdatePriorityCalculator creates new UpdatePriorityCalculator for the given resources policy and configuration.
@@ -97,9 +112,15 @@ func (calc *UpdatePriorityCalculator) AddPod(pod *apiv1.Pod, recommendation *vpa
 	}
 
 	// The update is allowed in following cases:
-	// - the request is outside the recommended range for some container.
+	// - the request is outside the recommended range for some container
+	//   (and the resource diff is >= MinChangePriority if MinChangeOutsideRecommendedRange).
 	// - the pod lives for at least 24h and the resource diff is >= MinChangePriority.
 	// - there is only one container in a pod and it OOMed in less than evictAfterOOMThreshold
+	if updatePriority.outsideRecommendedRange && !quickOOM && calc.config.MinChangeOutsideRecommendedRange &&
+		updatePriority.resourceDiff < calc.config.MinChangePriority {
+		klog.V(2).Infof("not updating pod %v, resource diff too low: %v", pod.Name, updatePriority)
+		return
+	}
 	if !updatePriority.outsideRecommendedRange && !quickOOM {
 		if pod.Status.StartTime == nil {
 			// TODO: Set proper condition on the VPA.
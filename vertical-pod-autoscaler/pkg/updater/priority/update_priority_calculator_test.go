@@ -239,6 +239,37 @@ func TestUpdateShortlivedPods(t *testing.T) {
 	assert.Exactly(t, []*apiv1.Pod{pods[2]}, result, "Only POD3 should be updated")
 }
 
+// Verify that the minimal change of a VPA applies to pods outside the
+// [MinRecommended...MaxRecommended] range too.
+func TestUpdatePodsWithMinChangePercent(t *testing.T) {
+	assert.Nil(t, NewUpdateConfig(nil))
+	assert.Nil(t, NewUpdateConfig(&vpa_types.PodUpdatePolicy{}))
+
+	minChangePercent := int32(50)
+	calculator := NewUpdatePriorityCalculator(
+		nil, nil, NewUpdateConfig(&vpa_types.PodUpdatePolicy{MinChangePercent: &minChangePercent}),
+		&test.FakeRecommendationProcessor{})
+
+	pods := []*apiv1.Pod{
+		test.Pod().WithName("POD1").AddContainer(test.BuildTestContainer(containerName, "7", "")).Get(),
+		test.Pod().WithName("POD2").AddContainer(test.BuildTestContainer(containerName, "12", "")).Get(),
+	}
+
+	// Both pods are above the recommended range.
+	recommendation := test.Recommendation().WithContainer(containerName).
+		WithTarget("5", "").
+		WithLowerBound("1", "").
+		WithUpperBound("6", "").Get()
+
+	// Pretend that the test pods started 11 hours ago.
+	timestampNow := pods[0].Status.StartTime.Time.Add(time.Hour * 11)
+	for _, pod := range pods {
+		calculator.AddPod(pod, recommendation, timestampNow)
+	}
+	result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+	assert.Exactly(t, []*apiv1.Pod{pods[1]}, result, "Only POD2 should be updated")
+}
+
 func TestUpdatePodWithQuickOOM(t *testing.T) {
 	calculator := NewUpdatePriorityCalculator(
 		nil, nil, &UpdateConfig{MinChangePriority: 0.5}, &test.FakeRecommendationProcessor{})
@@ -19,6 +19,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 	"strings"
 	"time"
 
@@ -151,8 +152,11 @@ func GetUpdateMode(vpa *vpa_types.VerticalPodAutoscaler) vpa_types.UpdateMode {
 
 // GetContainerResourcePolicy returns the ContainerResourcePolicy for a given policy
 // and container name. It returns nil if there is no policy specified for the container.
+// A policy with the exact container name is preferred over the first policy whose
+// container name is a pattern (see path.Match) matching it, which in turn is preferred
+// over the default policy.
 func GetContainerResourcePolicy(containerName string, policy *vpa_types.PodResourcePolicy) *vpa_types.ContainerResourcePolicy {
-	var defaultPolicy *vpa_types.ContainerResourcePolicy
+	var patternPolicy, defaultPolicy *vpa_types.ContainerResourcePolicy
 	if policy != nil {
 		for i, containerPolicy := range policy.ContainerPolicies {
 			if containerPolicy.ContainerName == containerName {
@@ -160,9 +164,16 @@ func GetContainerResourcePolicy(containerName string, policy *vpa_types.PodResou
 			}
 			if containerPolicy.ContainerName == vpa_types.DefaultContainerResourcePolicy {
 				defaultPolicy = &policy.ContainerPolicies[i]
+			} else if patternPolicy == nil {
+				if matched, _ := path.Match(containerPolicy.ContainerName, containerName); matched {
+					patternPolicy = &policy.ContainerPolicies[i]
+				}
 			}
 		}
 	}
+	if patternPolicy != nil {
+		return patternPolicy
+	}
 	return defaultPolicy
 }
 
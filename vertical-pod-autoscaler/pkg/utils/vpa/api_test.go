@@ -197,4 +197,23 @@ func TestGetContainerResourcePolicy(t *testing.T) {
 	assert.Equal(t, &containerPolicy1, GetContainerResourcePolicy("container1", &policy))
 	assert.Equal(t, &containerPolicy2, GetContainerResourcePolicy("container2", &policy))
 	assert.Equal(t, &defaultPolicy, GetContainerResourcePolicy("container3", &policy))
+
+	// Add a pattern policy, used for matching containers without their own policy.
+	sidecarPolicy := vpa_types.ContainerResourcePolicy{
+		ContainerName: "istio-*",
+		MaxAllowed: core.ResourceList{
+			core.ResourceCPU: *resource.NewScaledQuantity(50, 1),
+		},
+	}
+	istioPolicy := vpa_types.ContainerResourcePolicy{
+		ContainerName: "istio-init",
+	}
+	policy = vpa_types.PodResourcePolicy{
+		ContainerPolicies: []vpa_types.ContainerResourcePolicy{
+			defaultPolicy, sidecarPolicy, istioPolicy,
+		},
+	}
+	assert.Equal(t, &sidecarPolicy, GetContainerResourcePolicy("istio-proxy", &policy))
+	assert.Equal(t, &istioPolicy, GetContainerResourcePolicy("istio-init", &policy))
+	assert.Equal(t, &defaultPolicy, GetContainerResourcePolicy("container1", &policy))
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
+)
+
+// GetMaxNodeShape returns the largest allocatable CPU and memory of the given nodes.
+func GetMaxNodeShape(nodes []*apiv1.Node) apiv1.ResourceList {
+	shape := apiv1.ResourceList{}
+	for _, node := range nodes {
+		for _, resourceName := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+			allocatable, found := node.Status.Allocatable[resourceName]
+			if !found {
+				continue
+			}
+			if current, found := shape[resourceName]; !found || allocatable.Cmp(current) > 0 {
+				shape[resourceName] = allocatable.DeepCopy()
+			}
+		}
+	}
+	return shape
+}
+
+// ParseNodeShape parses a node shape in the format cpu=<quantity>,memory=<quantity>.
+// Either resource can be omitted.
+func ParseNodeShape(value string) (apiv1.ResourceList, error) {
+	shape := apiv1.ResourceList{}
+	for _, part := range strings.Split(value, ",") {
+		keyValue := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("incorrect node shape: %v", value)
+		}
+		resourceName := apiv1.ResourceName(keyValue[0])
+		if resourceName != apiv1.ResourceCPU && resourceName != apiv1.ResourceMemory {
+			return nil, fmt.Errorf("incorrect node shape - unsupported resource %v: %v", resourceName, value)
+		}
+		quantity, err := resource.ParseQuantity(keyValue[1])
+		if err != nil {
+			return nil, fmt.Errorf("incorrect node shape - not a quantity: %v", value)
+		}
+		shape[resourceName] = quantity
+	}
+	return shape, nil
+}
+
+// CapToNodeShape scales the recommendation down so that the targets, lower bounds and upper bounds of
+// all containers summed up fit the node shape, keeping the proportions between containers.
+// Uncapped targets aren't changed. It returns the capped recommendation and the resources whose
+// targets exceeded the node shape.
+func CapToNodeShape(podRecommendation *vpa_types.RecommendedPodResources,
+	shape apiv1.ResourceList) (*vpa_types.RecommendedPodResources, []apiv1.ResourceName) {
+	if podRecommendation == nil {
+		return nil, nil
+	}
+	updatedRecommendations := make([]vpa_types.RecommendedContainerResources, 0, len(podRecommendation.ContainerRecommendations))
+	for _, containerRecommendation := range podRecommendation.ContainerRecommendations {
+		updated := containerRecommendation.DeepCopy()
+		updatedRecommendations = append(updatedRecommendations, *updated)
+	}
+
+	var exceeded []apiv1.ResourceName
+	for _, resourceName := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+		available, found := shape[resourceName]
+		if !found {
+			continue
+		}
+		if scaleToFit(updatedRecommendations, resourceName, available, getTarget) {
+			exceeded = append(exceeded, resourceName)
+		}
+		scaleToFit(updatedRecommendations, resourceName, available, getLowerBound)
+		scaleToFit(updatedRecommendations, resourceName, available, getUpperBound)
+	}
+	return &vpa_types.RecommendedPodResources{ContainerRecommendations: updatedRecommendations}, exceeded
+}
+
+func getTarget(r *vpa_types.RecommendedContainerResources) apiv1.ResourceList {
+	return r.Target
+}
+
+func getLowerBound(r *vpa_types.RecommendedContainerResources) apiv1.ResourceList {
+	return r.LowerBound
+}
+
+func getUpperBound(r *vpa_types.RecommendedContainerResources) apiv1.ResourceList {
+	return r.UpperBound
+}
+
+// scaleToFit scales the resource in the resource lists of all containers down proportionally if their
+// sum exceeds the available amount. It returns true if they were scaled.
+func scaleToFit(recommendations []vpa_types.RecommendedContainerResources, resourceName apiv1.ResourceName,
+	available resource.Quantity, resources func(*vpa_types.RecommendedContainerResources) apiv1.ResourceList) bool {
+	var sum int64
+	for i := range recommendations {
+		if quantity, found := resources(&recommendations[i])[resourceName]; found {
+			sum += quantityValue(resourceName, quantity)
+		}
+	}
+	if sum <= quantityValue(resourceName, available) {
+		return false
+	}
+	factor := float64(quantityValue(resourceName, available)) / float64(sum)
+	for i := range recommendations {
+		list := resources(&recommendations[i])
+		if quantity, found := list[resourceName]; found {
+			scaled := int64(float64(quantityValue(resourceName, quantity)) * factor)
+			if resourceName == apiv1.ResourceCPU {
+				list[resourceName] = *resource.NewMilliQuantity(scaled, quantity.Format)
+			} else {
+				list[resourceName] = *resource.NewQuantity(scaled, quantity.Format)
+			}
+		}
+	}
+	return true
+}
+
+// quantityValue returns CPU in millicores and other resources in units.
+func quantityValue(resourceName apiv1.ResourceName, quantity resource.Quantity) int64 {
+	if resourceName == apiv1.ResourceCPU {
+		return quantity.MilliValue()
+	}
+	return quantity.Value()
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
+)
+
+func buildNodeWithAllocatable(cpu, memory string) *apiv1.Node {
+	return &apiv1.Node{
+		Status: apiv1.NodeStatus{
+			Allocatable: apiv1.ResourceList{
+				apiv1.ResourceCPU:    resource.MustParse(cpu),
+				apiv1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func TestGetMaxNodeShape(t *testing.T) {
+	shape := GetMaxNodeShape([]*apiv1.Node{
+		buildNodeWithAllocatable("4", "2Gi"),
+		buildNodeWithAllocatable("2", "8Gi"),
+	})
+	assert.Equal(t, resource.MustParse("4"), shape[apiv1.ResourceCPU])
+	assert.Equal(t, resource.MustParse("8Gi"), shape[apiv1.ResourceMemory])
+
+	assert.Empty(t, GetMaxNodeShape(nil))
+}
+
+func TestParseNodeShape(t *testing.T) {
+	shape, err := ParseNodeShape("cpu=2,memory=4Gi")
+	assert.NoError(t, err)
+	assert.Equal(t, resource.MustParse("2"), shape[apiv1.ResourceCPU])
+	assert.Equal(t, resource.MustParse("4Gi"), shape[apiv1.ResourceMemory])
+
+	shape, err = ParseNodeShape("memory=1Gi")
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("1Gi")}, shape)
+
+	for _, value := range []string{"", "cpu", "gpu=1", "cpu=lots"} {
+		_, err = ParseNodeShape(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCapToNodeShape(t *testing.T) {
+	podRecommendation := &vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			{
+				ContainerName: "container1",
+				Target: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("3"),
+					apiv1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				UpperBound: apiv1.ResourceList{
+					apiv1.ResourceCPU: resource.MustParse("6"),
+				},
+				UncappedTarget: apiv1.ResourceList{
+					apiv1.ResourceCPU: resource.MustParse("3"),
+				},
+			},
+			{
+				ContainerName: "container2",
+				Target: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("1"),
+					apiv1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			},
+		},
+	}
+	shape := apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("2"),
+		apiv1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+
+	capped, exceeded := CapToNodeShape(podRecommendation, shape)
+	assert.Equal(t, []apiv1.ResourceName{apiv1.ResourceCPU}, exceeded)
+
+	// CPU is scaled down proportionally, memory fits.
+	cpu := capped.ContainerRecommendations[0].Target[apiv1.ResourceCPU]
+	assert.Equal(t, int64(1500), cpu.MilliValue())
+	cpu = capped.ContainerRecommendations[1].Target[apiv1.ResourceCPU]
+	assert.Equal(t, int64(500), cpu.MilliValue())
+	assert.Equal(t, resource.MustParse("1Gi"), capped.ContainerRecommendations[0].Target[apiv1.ResourceMemory])
+	cpu = capped.ContainerRecommendations[0].UpperBound[apiv1.ResourceCPU]
+	assert.Equal(t, int64(2000), cpu.MilliValue())
+	assert.Equal(t, resource.MustParse("3"), capped.ContainerRecommendations[0].UncappedTarget[apiv1.ResourceCPU])
+
+	// The original recommendation isn't modified.
+	assert.Equal(t, resource.MustParse("3"), podRecommendation.ContainerRecommendations[0].Target[apiv1.ResourceCPU])
+
+	_, exceeded = CapToNodeShape(podRecommendation, apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("8")})
+	assert.Empty(t, exceeded)
+}